@@ -0,0 +1,338 @@
+// Package genai exposes an omnillm ChatClient through the content generation
+// methods of google.golang.org/genai, so applications written against
+// genai.Models can route through omnillm without rewriting call sites.
+package genai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ContentGenerator is the subset of genai.Models used for content generation.
+// Both *genai.Models and *Models satisfy it, so call sites can depend on this
+// interface and switch implementations.
+type ContentGenerator interface {
+	GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error)
+	GenerateContentStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error]
+}
+
+var (
+	_ ContentGenerator = (*Models)(nil)
+	_ ContentGenerator = (*genai.Models)(nil)
+)
+
+// Models adapts an omnillm ChatClient to the genai.Models content generation methods
+type Models struct {
+	client *omnillm.ChatClient
+}
+
+// New creates a genai-style content generator backed by client
+func New(client *omnillm.ChatClient) *Models {
+	return &Models{client: client}
+}
+
+// GenerateContent generates content for the given model and contents
+func (m *Models) GenerateContent(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*genai.GenerateContentResponse, error) {
+	req, err := convertRequest(model, contents, config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return convertResponse(resp), nil
+}
+
+// GenerateContentStream generates content as a stream of partial responses.
+// Each yielded response carries the content delta for that chunk. Breaking
+// out of the loop closes the underlying stream.
+func (m *Models) GenerateContentStream(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig) iter.Seq2[*genai.GenerateContentResponse, error] {
+	return func(yield func(*genai.GenerateContentResponse, error) bool) {
+		req, err := convertRequest(model, contents, config)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		stream, err := m.client.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer stream.Close()
+
+		for {
+			chunk, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(convertChunk(chunk), nil) {
+				return
+			}
+		}
+	}
+}
+
+// convertRequest converts genai contents and config to an omnillm request
+func convertRequest(model string, contents []*genai.Content, config *genai.GenerateContentConfig) (*provider.ChatCompletionRequest, error) {
+	req := &provider.ChatCompletionRequest{Model: model}
+
+	if config != nil {
+		if config.SystemInstruction != nil {
+			req.Messages = append(req.Messages, provider.Message{
+				Role:    provider.RoleSystem,
+				Content: contentText(config.SystemInstruction),
+			})
+		}
+		req.Temperature = float64Ptr(config.Temperature)
+		req.TopP = float64Ptr(config.TopP)
+		req.PresencePenalty = float64Ptr(config.PresencePenalty)
+		req.FrequencyPenalty = float64Ptr(config.FrequencyPenalty)
+		if config.TopK != nil {
+			topK := int(*config.TopK)
+			req.TopK = &topK
+		}
+		if config.MaxOutputTokens > 0 {
+			maxTokens := int(config.MaxOutputTokens)
+			req.MaxTokens = &maxTokens
+		}
+		if config.CandidateCount > 0 {
+			n := int(config.CandidateCount)
+			req.N = &n
+		}
+		if config.Seed != nil {
+			seed := int(*config.Seed)
+			req.Seed = &seed
+		}
+		req.Stop = config.StopSequences
+		if config.ResponseMIMEType == "application/json" {
+			req.ResponseFormat = &provider.ResponseFormat{Type: "json_object"}
+		}
+		for _, tool := range config.Tools {
+			for _, fn := range tool.FunctionDeclarations {
+				params := fn.ParametersJsonSchema
+				if params == nil && fn.Parameters != nil {
+					params = fn.Parameters
+				}
+				req.Tools = append(req.Tools, provider.Tool{
+					Type: provider.ToolTypeFunction,
+					Function: provider.ToolSpec{
+						Name:        fn.Name,
+						Description: fn.Description,
+						Parameters:  params,
+					},
+				})
+			}
+		}
+	}
+
+	for _, content := range contents {
+		messages, err := convertContent(content)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, messages...)
+	}
+
+	return req, nil
+}
+
+// convertContent converts a genai content to omnillm messages. Function
+// responses become separate tool messages, one per response.
+func convertContent(content *genai.Content) ([]provider.Message, error) {
+	if content == nil {
+		return nil, nil
+	}
+
+	role := provider.RoleUser
+	if content.Role == genai.RoleModel {
+		role = provider.RoleAssistant
+	}
+
+	msg := provider.Message{Role: role}
+	var text []string
+	var toolResponses []provider.Message
+
+	for _, part := range content.Parts {
+		switch {
+		case part == nil || part.Thought:
+		case part.Text != "":
+			text = append(text, part.Text)
+		case part.InlineData != nil:
+			msg.Parts = append(msg.Parts, omnillm.NewDocumentPart(part.InlineData.Data, part.InlineData.MIMEType, part.InlineData.DisplayName))
+		case part.FileData != nil:
+			msg.Parts = append(msg.Parts, provider.ContentPart{
+				Type: provider.ContentPartTypeDocument,
+				Document: &provider.Document{
+					MIMEType: part.FileData.MIMEType,
+					Filename: part.FileData.DisplayName,
+					FileURI:  part.FileData.FileURI,
+				},
+			})
+		case part.FunctionCall != nil:
+			args, err := json.Marshal(part.FunctionCall.Args)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal function call arguments: %w", err)
+			}
+			msg.ToolCalls = append(msg.ToolCalls, provider.ToolCall{
+				ID:   part.FunctionCall.ID,
+				Type: provider.ToolTypeFunction,
+				Function: provider.ToolFunction{
+					Name:      part.FunctionCall.Name,
+					Arguments: string(args),
+				},
+			})
+		case part.FunctionResponse != nil:
+			output, err := json.Marshal(part.FunctionResponse.Response)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal function response: %w", err)
+			}
+			id := part.FunctionResponse.ID
+			if id == "" {
+				id = part.FunctionResponse.Name
+			}
+			name := part.FunctionResponse.Name
+			toolResponses = append(toolResponses, provider.Message{
+				Role:       provider.RoleTool,
+				Content:    string(output),
+				Name:       &name,
+				ToolCallID: &id,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported genai content part")
+		}
+	}
+	msg.Content = strings.Join(text, "")
+
+	if len(toolResponses) > 0 && msg.Content == "" && len(msg.Parts) == 0 && len(msg.ToolCalls) == 0 {
+		return toolResponses, nil
+	}
+	return append([]provider.Message{msg}, toolResponses...), nil
+}
+
+// convertResponse converts an omnillm response to a genai response
+func convertResponse(resp *provider.ChatCompletionResponse) *genai.GenerateContentResponse {
+	result := &genai.GenerateContentResponse{
+		ResponseID:    resp.ID,
+		ModelVersion:  resp.Model,
+		UsageMetadata: convertUsage(&resp.Usage),
+	}
+	for _, choice := range resp.Choices {
+		result.Candidates = append(result.Candidates, convertChoice(choice, &choice.Message))
+	}
+	return result
+}
+
+// convertUsage converts omnillm usage to genai usage metadata. Gemini counts
+// thinking tokens separately from candidate tokens.
+func convertUsage(usage *provider.Usage) *genai.GenerateContentResponseUsageMetadata {
+	result := &genai.GenerateContentResponseUsageMetadata{
+		PromptTokenCount:     int32(usage.PromptTokens),     //nolint:gosec // G115: token counts fit in int32
+		CandidatesTokenCount: int32(usage.CompletionTokens), //nolint:gosec // G115: token counts fit in int32
+		TotalTokenCount:      int32(usage.TotalTokens),      //nolint:gosec // G115: token counts fit in int32
+	}
+	if details := usage.PromptTokensDetails; details != nil {
+		result.CachedContentTokenCount = int32(details.CachedTokens) //nolint:gosec // G115: token counts fit in int32
+	}
+	if details := usage.CompletionTokensDetails; details != nil {
+		result.ThoughtsTokenCount = int32(details.ReasoningTokens)    //nolint:gosec // G115: token counts fit in int32
+		result.CandidatesTokenCount -= int32(details.ReasoningTokens) //nolint:gosec // G115: token counts fit in int32
+	}
+	return result
+}
+
+// convertChunk converts an omnillm stream chunk to a partial genai response
+func convertChunk(chunk *provider.ChatCompletionChunk) *genai.GenerateContentResponse {
+	result := &genai.GenerateContentResponse{
+		ResponseID:   chunk.ID,
+		ModelVersion: chunk.Model,
+	}
+	if chunk.Usage != nil {
+		result.UsageMetadata = convertUsage(chunk.Usage)
+	}
+	for _, choice := range chunk.Choices {
+		msg := choice.Delta
+		if msg == nil {
+			msg = &provider.Message{}
+		}
+		result.Candidates = append(result.Candidates, convertChoice(choice, msg))
+	}
+	return result
+}
+
+// convertChoice converts a choice and its message (or delta) to a genai candidate
+func convertChoice(choice provider.ChatCompletionChoice, msg *provider.Message) *genai.Candidate {
+	content := &genai.Content{Role: genai.RoleModel}
+	if msg.Content != "" {
+		content.Parts = append(content.Parts, genai.NewPartFromText(msg.Content))
+	}
+	for _, tc := range msg.ToolCalls {
+		var args map[string]any
+		if tc.Function.Arguments != "" {
+			_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+		}
+		content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+			ID:   tc.ID,
+			Name: tc.Function.Name,
+			Args: args,
+		}})
+	}
+
+	candidate := &genai.Candidate{
+		Index:   int32(choice.Index), //nolint:gosec // G115: choice indexes are small
+		Content: content,
+	}
+	if choice.FinishReason != nil {
+		candidate.FinishReason = convertFinishReason(*choice.FinishReason)
+	}
+	return candidate
+}
+
+// convertFinishReason maps an OpenAI-style finish reason to a genai finish reason
+func convertFinishReason(reason string) genai.FinishReason {
+	switch reason {
+	case "stop", "tool_calls", "end_turn":
+		return genai.FinishReasonStop
+	case "length", "max_tokens":
+		return genai.FinishReasonMaxTokens
+	case "content_filter":
+		return genai.FinishReasonSafety
+	default:
+		return genai.FinishReasonOther
+	}
+}
+
+// contentText concatenates the text parts of a content
+func contentText(content *genai.Content) string {
+	var text strings.Builder
+	for _, part := range content.Parts {
+		if part != nil {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+func float64Ptr(f *float32) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := float64(*f)
+	return &v
+}
@@ -0,0 +1,172 @@
+package genai
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// fakeProvider records the last request and returns canned responses
+type fakeProvider struct {
+	lastReq *provider.ChatCompletionRequest
+	resp    *provider.ChatCompletionResponse
+	chunks  []*provider.ChatCompletionChunk
+}
+
+func (f *fakeProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	f.lastReq = req
+	return f.resp, nil
+}
+
+func (f *fakeProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	f.lastReq = req
+	return &fakeStream{chunks: f.chunks}, nil
+}
+
+func (f *fakeProvider) Close() error { return nil }
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+type fakeStream struct {
+	chunks []*provider.ChatCompletionChunk
+	closed bool
+}
+
+func (s *fakeStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func newTestModels(t *testing.T, fake *fakeProvider) *Models {
+	t.Helper()
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		Providers: []omnillm.ProviderConfig{{CustomProvider: fake}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return New(client)
+}
+
+func TestModels_GenerateContent(t *testing.T) {
+	stop := "stop"
+	fake := &fakeProvider{resp: &provider.ChatCompletionResponse{
+		ID:    "resp-1",
+		Model: "gemini-2.5-flash",
+		Choices: []provider.ChatCompletionChoice{{
+			Message:      provider.Message{Role: provider.RoleAssistant, Content: "Bonjour"},
+			FinishReason: &stop,
+		}},
+		Usage: provider.Usage{PromptTokens: 4, CompletionTokens: 2, TotalTokens: 6},
+	}}
+	models := newTestModels(t, fake)
+
+	temperature := float32(0.5)
+	resp, err := models.GenerateContent(context.Background(), "gemini-2.5-flash",
+		[]*genai.Content{
+			genai.NewContentFromText("Say hello in French", genai.RoleUser),
+		},
+		&genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("Be brief", genai.RoleUser),
+			Temperature:       &temperature,
+			MaxOutputTokens:   64,
+		},
+	)
+	if err != nil {
+		t.Fatalf("GenerateContent() error = %v", err)
+	}
+
+	req := fake.lastReq
+	if len(req.Messages) != 2 || req.Messages[0].Role != provider.RoleSystem || req.Messages[0].Content != "Be brief" {
+		t.Errorf("unexpected messages: %+v", req.Messages)
+	}
+	if req.Temperature == nil || *req.Temperature != 0.5 || req.MaxTokens == nil || *req.MaxTokens != 64 {
+		t.Errorf("config not applied: temperature=%v max_tokens=%v", req.Temperature, req.MaxTokens)
+	}
+
+	if resp.Text() != "Bonjour" {
+		t.Errorf("Text() = %q, want Bonjour", resp.Text())
+	}
+	if resp.Candidates[0].FinishReason != genai.FinishReasonStop {
+		t.Errorf("FinishReason = %q", resp.Candidates[0].FinishReason)
+	}
+	if resp.UsageMetadata.TotalTokenCount != 6 {
+		t.Errorf("TotalTokenCount = %d, want 6", resp.UsageMetadata.TotalTokenCount)
+	}
+}
+
+func TestModels_FunctionCalling(t *testing.T) {
+	fake := &fakeProvider{resp: &provider.ChatCompletionResponse{
+		Choices: []provider.ChatCompletionChoice{{
+			Message: provider.Message{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{{
+				ID: "call_1", Type: "function",
+				Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+		}},
+	}}
+	models := newTestModels(t, fake)
+
+	resp, err := models.GenerateContent(context.Background(), "gemini-2.5-flash",
+		[]*genai.Content{
+			genai.NewContentFromText("Weather?", genai.RoleUser),
+			genai.NewContentFromFunctionResponse("lookup", map[string]any{"ok": true}, genai.RoleUser),
+		},
+		&genai.GenerateContentConfig{
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:                 "get_weather",
+				ParametersJsonSchema: map[string]any{"type": "object"},
+			}}}},
+		},
+	)
+	if err != nil {
+		t.Fatalf("GenerateContent() error = %v", err)
+	}
+
+	req := fake.lastReq
+	if len(req.Tools) != 1 || req.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("Tools = %+v", req.Tools)
+	}
+	if req.Messages[1].Role != provider.RoleTool || req.Messages[1].Content != `{"ok":true}` {
+		t.Errorf("function response message = %+v", req.Messages[1])
+	}
+
+	calls := resp.FunctionCalls()
+	if len(calls) != 1 || calls[0].Name != "get_weather" || calls[0].Args["city"] != "Paris" {
+		t.Errorf("FunctionCalls() = %+v", calls)
+	}
+}
+
+func TestModels_GenerateContentStream(t *testing.T) {
+	fake := &fakeProvider{chunks: []*provider.ChatCompletionChunk{
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "Hel"}}}},
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "lo"}}}},
+	}}
+	models := newTestModels(t, fake)
+
+	var text string
+	for resp, err := range models.GenerateContentStream(context.Background(), "gemini-2.5-flash",
+		genai.Text("Hi"), nil) {
+		if err != nil {
+			t.Fatalf("stream error = %v", err)
+		}
+		text += resp.Text()
+	}
+	if text != "Hello" {
+		t.Errorf("streamed text = %q, want Hello", text)
+	}
+}
@@ -0,0 +1,260 @@
+// Package langchaingo exposes an omnillm ChatClient as a langchaingo llms.Model,
+// so applications built on langchaingo gain omnillm's routing, fallback, and
+// caching without changing call sites.
+package langchaingo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// Model adapts an omnillm ChatClient to the langchaingo llms.Model interface
+type Model struct {
+	client *omnillm.ChatClient
+	model  string
+}
+
+var _ llms.Model = (*Model)(nil)
+
+// New creates a langchaingo model backed by client. The model ID is used when
+// a call does not set llms.WithModel.
+func New(client *omnillm.ChatClient, model string) *Model {
+	return &Model{client: client, model: model}
+}
+
+// Call generates a single string response from a single string prompt
+func (m *Model) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// GenerateContent generates content from a sequence of messages. When a
+// streaming function is set, the request is streamed and each content delta
+// is passed to it.
+func (m *Model) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	req, err := m.convertRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.StreamingFunc == nil {
+		resp, err := m.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return convertResponse(resp), nil
+	}
+
+	stream, err := m.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	acc := omnillm.NewStreamAccumulator()
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		acc.Add(chunk)
+		for _, choice := range chunk.Choices {
+			if choice.Index != 0 || choice.Delta == nil || choice.Delta.Content == "" {
+				continue
+			}
+			if err := opts.StreamingFunc(ctx, []byte(choice.Delta.Content)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return convertResponse(acc.Response()), nil
+}
+
+// convertRequest converts langchaingo messages and options to an omnillm request
+func (m *Model) convertRequest(messages []llms.MessageContent, opts llms.CallOptions) (*provider.ChatCompletionRequest, error) {
+	req := &provider.ChatCompletionRequest{
+		Model: m.model,
+		Stop:  opts.StopWords,
+	}
+	if opts.Model != "" {
+		req.Model = opts.Model
+	}
+	if opts.MaxTokens > 0 {
+		req.MaxTokens = &opts.MaxTokens
+	}
+	if opts.Temperature > 0 {
+		req.Temperature = &opts.Temperature
+	}
+	if opts.TopP > 0 {
+		req.TopP = &opts.TopP
+	}
+	if opts.TopK > 0 {
+		req.TopK = &opts.TopK
+	}
+	if opts.Seed != 0 {
+		req.Seed = &opts.Seed
+	}
+	if opts.N > 0 {
+		req.N = &opts.N
+	}
+	if opts.PresencePenalty != 0 {
+		req.PresencePenalty = &opts.PresencePenalty
+	}
+	if opts.FrequencyPenalty != 0 {
+		req.FrequencyPenalty = &opts.FrequencyPenalty
+	}
+	if opts.JSONMode {
+		req.ResponseFormat = &provider.ResponseFormat{Type: "json_object"}
+	}
+
+	for _, tool := range opts.Tools {
+		if tool.Function == nil {
+			return nil, fmt.Errorf("tool of type %q has no function definition", tool.Type)
+		}
+		req.Tools = append(req.Tools, convertFunction(*tool.Function))
+	}
+	for _, fn := range opts.Functions {
+		req.Tools = append(req.Tools, convertFunction(fn))
+	}
+	req.ToolChoice = opts.ToolChoice
+
+	for _, msg := range messages {
+		converted, err := convertMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		req.Messages = append(req.Messages, converted...)
+	}
+
+	return req, nil
+}
+
+// convertFunction converts a langchaingo function definition to an omnillm tool
+func convertFunction(fn llms.FunctionDefinition) provider.Tool {
+	return provider.Tool{
+		Type: provider.ToolTypeFunction,
+		Function: provider.ToolSpec{
+			Name:        fn.Name,
+			Description: fn.Description,
+			Parameters:  fn.Parameters,
+		},
+	}
+}
+
+// convertMessage converts a langchaingo message to omnillm messages. Tool call
+// responses become separate tool messages, one per response.
+func convertMessage(msg llms.MessageContent) ([]provider.Message, error) {
+	role, err := convertRole(msg.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	result := provider.Message{Role: role}
+	var text []string
+	var toolResponses []provider.Message
+
+	for _, part := range msg.Parts {
+		switch p := part.(type) {
+		case llms.TextContent:
+			text = append(text, p.Text)
+		case llms.BinaryContent:
+			result.Parts = append(result.Parts, omnillm.NewDocumentPart(p.Data, p.MIMEType, ""))
+		case llms.ToolCall:
+			tc := provider.ToolCall{ID: p.ID, Type: p.Type}
+			if tc.Type == "" {
+				tc.Type = provider.ToolTypeFunction
+			}
+			if p.FunctionCall != nil {
+				tc.Function = provider.ToolFunction{Name: p.FunctionCall.Name, Arguments: p.FunctionCall.Arguments}
+			}
+			result.ToolCalls = append(result.ToolCalls, tc)
+		case llms.ToolCallResponse:
+			toolCallID := p.ToolCallID
+			name := p.Name
+			toolResponses = append(toolResponses, provider.Message{
+				Role:       provider.RoleTool,
+				Content:    p.Content,
+				Name:       &name,
+				ToolCallID: &toolCallID,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported langchaingo content part %T", part)
+		}
+	}
+	result.Content = strings.Join(text, "\n")
+
+	if len(toolResponses) > 0 && result.Content == "" && len(result.Parts) == 0 && len(result.ToolCalls) == 0 {
+		return toolResponses, nil
+	}
+	return append([]provider.Message{result}, toolResponses...), nil
+}
+
+// convertRole converts a langchaingo message type to an omnillm role
+func convertRole(role llms.ChatMessageType) (provider.Role, error) {
+	switch role {
+	case llms.ChatMessageTypeSystem:
+		return provider.RoleSystem, nil
+	case llms.ChatMessageTypeHuman, llms.ChatMessageTypeGeneric:
+		return provider.RoleUser, nil
+	case llms.ChatMessageTypeAI:
+		return provider.RoleAssistant, nil
+	case llms.ChatMessageTypeTool, llms.ChatMessageTypeFunction:
+		return provider.RoleTool, nil
+	default:
+		return "", fmt.Errorf("unsupported langchaingo message role %q", role)
+	}
+}
+
+// convertResponse converts an omnillm response to a langchaingo content response
+func convertResponse(resp *provider.ChatCompletionResponse) *llms.ContentResponse {
+	result := &llms.ContentResponse{}
+	for _, choice := range resp.Choices {
+		contentChoice := &llms.ContentChoice{
+			Content: choice.Message.Content,
+			GenerationInfo: map[string]any{
+				"PromptTokens":     resp.Usage.PromptTokens,
+				"CompletionTokens": resp.Usage.CompletionTokens,
+				"TotalTokens":      resp.Usage.TotalTokens,
+			},
+		}
+		if details := resp.Usage.PromptTokensDetails; details != nil {
+			contentChoice.GenerationInfo["PromptCachedTokens"] = details.CachedTokens
+		}
+		if details := resp.Usage.CompletionTokensDetails; details != nil {
+			contentChoice.GenerationInfo["ReasoningTokens"] = details.ReasoningTokens
+		}
+		if choice.FinishReason != nil {
+			contentChoice.StopReason = *choice.FinishReason
+		}
+		for _, tc := range choice.Message.ToolCalls {
+			contentChoice.ToolCalls = append(contentChoice.ToolCalls, llms.ToolCall{
+				ID:   tc.ID,
+				Type: tc.Type,
+				FunctionCall: &llms.FunctionCall{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				},
+			})
+		}
+		if len(contentChoice.ToolCalls) > 0 {
+			contentChoice.FuncCall = contentChoice.ToolCalls[0].FunctionCall
+		}
+		result.Choices = append(result.Choices, contentChoice)
+	}
+	return result
+}
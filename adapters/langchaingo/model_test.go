@@ -0,0 +1,175 @@
+package langchaingo
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// fakeProvider records the last request and returns canned responses
+type fakeProvider struct {
+	lastReq *provider.ChatCompletionRequest
+	resp    *provider.ChatCompletionResponse
+	chunks  []*provider.ChatCompletionChunk
+}
+
+func (f *fakeProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	f.lastReq = req
+	return f.resp, nil
+}
+
+func (f *fakeProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	f.lastReq = req
+	return &fakeStream{chunks: f.chunks}, nil
+}
+
+func (f *fakeProvider) Close() error { return nil }
+
+func (f *fakeProvider) Name() string { return "fake" }
+
+type fakeStream struct {
+	chunks []*provider.ChatCompletionChunk
+}
+
+func (s *fakeStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if len(s.chunks) == 0 {
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error { return nil }
+
+func newTestModel(t *testing.T, fake *fakeProvider) *Model {
+	t.Helper()
+	client, err := omnillm.NewClient(omnillm.ClientConfig{
+		Providers: []omnillm.ProviderConfig{{CustomProvider: fake}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return New(client, "gpt-4o")
+}
+
+func TestModel_GenerateContent(t *testing.T) {
+	stop := "tool_calls"
+	fake := &fakeProvider{resp: &provider.ChatCompletionResponse{
+		Choices: []provider.ChatCompletionChoice{{
+			Message: provider.Message{
+				Role: provider.RoleAssistant,
+				ToolCalls: []provider.ToolCall{{
+					ID:       "call_1",
+					Type:     "function",
+					Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+				}},
+			},
+			FinishReason: &stop,
+		}},
+		Usage: provider.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}}
+	model := newTestModel(t, fake)
+
+	resp, err := model.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Be brief"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather in Paris?"),
+	},
+		llms.WithMaxTokens(100),
+		llms.WithTemperature(0.2),
+		llms.WithTools([]llms.Tool{{Type: "function", Function: &llms.FunctionDefinition{Name: "get_weather"}}}),
+	)
+	if err != nil {
+		t.Fatalf("GenerateContent() error = %v", err)
+	}
+
+	req := fake.lastReq
+	if req.Model != "gpt-4o" || len(req.Messages) != 2 || req.Messages[0].Role != provider.RoleSystem {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if req.MaxTokens == nil || *req.MaxTokens != 100 || req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Errorf("options not applied: max_tokens=%v temperature=%v", req.MaxTokens, req.Temperature)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("Tools = %+v", req.Tools)
+	}
+
+	choice := resp.Choices[0]
+	if choice.StopReason != "tool_calls" || choice.FuncCall == nil || choice.FuncCall.Name != "get_weather" {
+		t.Errorf("unexpected choice: %+v", choice)
+	}
+	if choice.GenerationInfo["TotalTokens"] != 15 {
+		t.Errorf("GenerationInfo = %v", choice.GenerationInfo)
+	}
+}
+
+func TestModel_ToolCallRoundTrip(t *testing.T) {
+	fake := &fakeProvider{resp: &provider.ChatCompletionResponse{
+		Choices: []provider.ChatCompletionChoice{{Message: provider.Message{Role: provider.RoleAssistant, Content: "Sunny"}}},
+	}}
+	model := newTestModel(t, fake)
+
+	_, err := model.GenerateContent(context.Background(), []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeHuman, "Weather?"),
+		{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{ID: "call_1", Type: "function", FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: "{}"}}}},
+		{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "call_1", Name: "get_weather", Content: "sunny"}}},
+	})
+	if err != nil {
+		t.Fatalf("GenerateContent() error = %v", err)
+	}
+
+	msgs := fake.lastReq.Messages
+	if len(msgs) != 3 {
+		t.Fatalf("Messages = %d, want 3", len(msgs))
+	}
+	if len(msgs[1].ToolCalls) != 1 || msgs[1].ToolCalls[0].ID != "call_1" {
+		t.Errorf("assistant tool calls = %+v", msgs[1].ToolCalls)
+	}
+	if msgs[2].Role != provider.RoleTool || msgs[2].ToolCallID == nil || *msgs[2].ToolCallID != "call_1" {
+		t.Errorf("tool message = %+v", msgs[2])
+	}
+}
+
+func TestModel_Streaming(t *testing.T) {
+	fake := &fakeProvider{chunks: []*provider.ChatCompletionChunk{
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "Hel"}}}},
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "lo"}}}},
+	}}
+	model := newTestModel(t, fake)
+
+	var streamed string
+	resp, err := model.GenerateContent(context.Background(),
+		[]llms.MessageContent{llms.TextParts(llms.ChatMessageTypeHuman, "Hi")},
+		llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed += string(chunk)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("GenerateContent() error = %v", err)
+	}
+	if streamed != "Hello" || resp.Choices[0].Content != "Hello" {
+		t.Errorf("streamed = %q, content = %q", streamed, resp.Choices[0].Content)
+	}
+}
+
+func TestModel_Call(t *testing.T) {
+	fake := &fakeProvider{resp: &provider.ChatCompletionResponse{
+		Choices: []provider.ChatCompletionChoice{{Message: provider.Message{Role: provider.RoleAssistant, Content: "pong"}}},
+	}}
+	model := newTestModel(t, fake)
+
+	got, err := model.Call(context.Background(), "ping", llms.WithModel("gpt-4o-mini"))
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if got != "pong" || fake.lastReq.Model != "gpt-4o-mini" {
+		t.Errorf("Call() = %q, model = %q", got, fake.lastReq.Model)
+	}
+}
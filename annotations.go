@@ -0,0 +1,131 @@
+package omnillm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// Common annotation types
+const (
+	AnnotationTypeSafety     = "safety"
+	AnnotationTypeModeration = "moderation"
+	AnnotationTypeGuardrail  = "guardrail"
+	AnnotationTypeJudge      = "judge"
+	AnnotationTypeConfidence = "confidence"
+	AnnotationTypeCitation   = "citation"
+)
+
+// AnnotateChoice appends annotations to the choice at the given index.
+// Returns false if the index is out of range.
+func AnnotateChoice(resp *provider.ChatCompletionResponse, index int, annotations ...provider.Annotation) bool {
+	if resp == nil || index < 0 || index >= len(resp.Choices) {
+		return false
+	}
+	resp.Choices[index].Annotations = append(resp.Choices[index].Annotations, annotations...)
+	return true
+}
+
+// FindAnnotations returns the annotations matching the given type
+func FindAnnotations(annotations []provider.Annotation, annotationType string) []provider.Annotation {
+	var found []provider.Annotation
+	for _, a := range annotations {
+		if a.Type == annotationType {
+			found = append(found, a)
+		}
+	}
+	return found
+}
+
+// ModerationAnnotation converts a moderation result into an annotation.
+// The score is the highest category score and the label is "flagged" or "clean".
+func ModerationAnnotation(result provider.ModerationResult, source string) provider.Annotation {
+	var maxScore float64
+	for _, score := range result.CategoryScores {
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+
+	label := "clean"
+	var flagged []string
+	for category, isFlagged := range result.Categories {
+		if isFlagged {
+			flagged = append(flagged, category)
+		}
+	}
+	if result.Flagged {
+		label = "flagged"
+	}
+	sort.Strings(flagged)
+
+	return provider.Annotation{
+		Type:   AnnotationTypeModeration,
+		Source: source,
+		Label:  label,
+		Score:  &maxScore,
+		Data: map[string]any{
+			"flagged_categories": flagged,
+			"category_scores":    result.CategoryScores,
+		},
+	}
+}
+
+// messageFromChoice returns the choice message with the choice annotations attached,
+// so derived signals are persisted alongside the message in memory.
+func messageFromChoice(choice provider.ChatCompletionChoice) provider.Message {
+	msg := choice.Message
+	if len(choice.Annotations) > 0 {
+		msg.Annotations = append(append([]provider.Annotation{}, msg.Annotations...), choice.Annotations...)
+	}
+	return msg
+}
+
+// FormatTranscript renders messages as a human-readable transcript including annotations
+func FormatTranscript(messages []provider.Message) string {
+	var sb strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "[%s] %s\n", msg.Role, msg.Content)
+		for _, tc := range msg.ToolCalls {
+			fmt.Fprintf(&sb, "  -> tool call %s(%s)\n", tc.Function.Name, tc.Function.Arguments)
+		}
+		for _, a := range msg.Annotations {
+			sb.WriteString("  # ")
+			sb.WriteString(a.Type)
+			if a.Source != "" {
+				fmt.Fprintf(&sb, " (%s)", a.Source)
+			}
+			if a.Label != "" {
+				fmt.Fprintf(&sb, ": %s", a.Label)
+			}
+			if a.Score != nil {
+				fmt.Fprintf(&sb, " [score=%.2f]", *a.Score)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// ExportTranscript renders a stored conversation as a human-readable transcript
+func (m *MemoryManager) ExportTranscript(ctx context.Context, sessionID string) (string, error) {
+	messages, err := m.GetMessages(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return FormatTranscript(messages), nil
+}
+
+// ExportTranscript renders a stored conversation as a human-readable transcript
+func (c *ChatClient) ExportTranscript(ctx context.Context, sessionID string) (string, error) {
+	if !c.HasMemory() {
+		return "", fmt.Errorf("memory not configured")
+	}
+	return c.memory.ExportTranscript(ctx, sessionID)
+}
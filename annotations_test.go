@@ -0,0 +1,84 @@
+package omnillm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestAnnotateChoice(t *testing.T) {
+	resp := &provider.ChatCompletionResponse{
+		Choices: []provider.ChatCompletionChoice{{Index: 0}},
+	}
+
+	score := 0.9
+	if !AnnotateChoice(resp, 0, Annotation{Type: AnnotationTypeConfidence, Score: &score}) {
+		t.Fatal("AnnotateChoice returned false for valid index")
+	}
+	if AnnotateChoice(resp, 1, Annotation{Type: AnnotationTypeConfidence}) {
+		t.Error("AnnotateChoice returned true for out-of-range index")
+	}
+
+	found := FindAnnotations(resp.Choices[0].Annotations, AnnotationTypeConfidence)
+	if len(found) != 1 || *found[0].Score != 0.9 {
+		t.Errorf("FindAnnotations = %v, want one confidence annotation", found)
+	}
+}
+
+func TestModerationAnnotation(t *testing.T) {
+	a := ModerationAnnotation(provider.ModerationResult{
+		Flagged:        true,
+		Categories:     map[string]bool{ModerationCategoryViolence: true, ModerationCategoryHate: false},
+		CategoryScores: map[string]float64{ModerationCategoryViolence: 0.8, ModerationCategoryHate: 0.1},
+	}, "openai")
+
+	if a.Type != AnnotationTypeModeration || a.Label != "flagged" || a.Source != "openai" {
+		t.Errorf("annotation = %+v", a)
+	}
+	if a.Score == nil || *a.Score != 0.8 {
+		t.Errorf("Score = %v, want 0.8", a.Score)
+	}
+}
+
+func TestChatClient_AnnotationsPersistedWithMemory(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.completionResp.Choices[0].Annotations = []provider.Annotation{
+		{Type: AnnotationTypeSafety, Source: "guardrail", Label: "pass"},
+	}
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mockProv}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	ctx := context.Background()
+	_, err = client.CreateChatCompletionWithMemory(ctx, "session1", &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionWithMemory failed: %v", err)
+	}
+
+	messages, err := client.GetConversationMessages(ctx, "session1")
+	if err != nil {
+		t.Fatalf("GetConversationMessages failed: %v", err)
+	}
+	if len(messages) != 2 || len(messages[1].Annotations) != 1 {
+		t.Fatalf("assistant message annotations not persisted: %+v", messages)
+	}
+
+	transcript, err := client.ExportTranscript(ctx, "session1")
+	if err != nil {
+		t.Fatalf("ExportTranscript failed: %v", err)
+	}
+	if !strings.Contains(transcript, "# safety (guardrail): pass") {
+		t.Errorf("transcript missing annotation:\n%s", transcript)
+	}
+}
@@ -0,0 +1,263 @@
+package omnillm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/grokify/mogo/log/slogutil"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// RedactedPlaceholder replaces redacted values in audit records
+const RedactedPlaceholder = "[REDACTED]"
+
+// AuditRecord is a single audited LLM call. Request and Response are redacted
+// copies; the originals are never modified.
+type AuditRecord struct {
+	CallID     string                           `json:"call_id"`
+	Provider   string                           `json:"provider"`
+	Model      string                           `json:"model"`
+	Stream     bool                             `json:"stream,omitempty"`
+	StartTime  time.Time                        `json:"start_time"`
+	DurationMS int64                            `json:"duration_ms"`
+	Request    *provider.ChatCompletionRequest  `json:"request"`
+	Response   *provider.ChatCompletionResponse `json:"response,omitempty"`
+	Error      string                           `json:"error,omitempty"`
+}
+
+// AuditSink receives audit records. Implementations must be safe for concurrent use.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink
+type AuditSinkFunc func(ctx context.Context, record *AuditRecord) error
+
+// WriteAudit calls f(ctx, record)
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, record *AuditRecord) error {
+	return f(ctx, record)
+}
+
+// jsonLinesAuditSink writes one JSON object per line
+type jsonLinesAuditSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLinesAuditSink returns a sink that writes each record as a line of JSON to w
+func NewJSONLinesAuditSink(w io.Writer) AuditSink {
+	return &jsonLinesAuditSink{enc: json.NewEncoder(w)}
+}
+
+func (s *jsonLinesAuditSink) WriteAudit(ctx context.Context, record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(record)
+}
+
+// AuditRedaction configures which parts of a record are redacted before it
+// reaches the sink
+type AuditRedaction struct {
+	// StripContent replaces message content, text parts, thinking, and audio
+	// transcripts in requests and responses with RedactedPlaceholder, and
+	// drops inline document and audio data
+	StripContent bool
+
+	// HashUserIDs replaces the request's User field with a salted SHA-256 hash,
+	// so records for the same user can still be correlated
+	HashUserIDs bool
+
+	// HashSalt is mixed into user ID hashes
+	HashSalt string
+
+	// DropToolArguments removes tool call arguments from messages and responses
+	DropToolArguments bool
+
+	// Patterns are replaced with RedactedPlaceholder wherever they match in
+	// message content, text parts, thinking, and audio transcripts (e.g.,
+	// email addresses, card numbers)
+	Patterns []*regexp.Regexp
+}
+
+// AuditConfig configures an AuditHook
+type AuditConfig struct {
+	// Sink receives the records (required)
+	Sink AuditSink
+
+	// Redaction rules applied to every record
+	Redaction AuditRedaction
+
+	// Redact is an optional final redaction step applied after the rules above
+	Redact func(record *AuditRecord)
+
+	// Logger reports sink errors (optional, defaults to null logger)
+	Logger *slog.Logger
+}
+
+// AuditHook is an ObservabilityHook that writes a redacted audit record for
+// every LLM call, including streaming calls once the stream ends. Sink errors
+// are logged and never fail the call. Responses served from the cache do not
+// reach the provider and are not audited.
+type AuditHook struct {
+	config AuditConfig
+	logger *slog.Logger
+}
+
+// NewAuditHook creates an audit hook
+func NewAuditHook(config AuditConfig) (*AuditHook, error) {
+	if config.Sink == nil {
+		return nil, fmt.Errorf("%w: audit sink is required", ErrInvalidConfiguration)
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slogutil.Null()
+	}
+	return &AuditHook{config: config, logger: logger}, nil
+}
+
+// BeforeRequest returns ctx unchanged
+func (h *AuditHook) BeforeRequest(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	return ctx
+}
+
+// AfterResponse audits a completed call or a failed stream creation
+func (h *AuditHook) AfterResponse(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	h.write(ctx, info, req, resp, err, false)
+}
+
+// WrapStream returns stream unchanged; streams are audited in AfterStream
+func (h *AuditHook) WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return stream
+}
+
+// AfterStream audits a streaming call with the response assembled from its chunks
+func (h *AuditHook) AfterStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stats StreamStats) {
+	h.write(ctx, info, req, stats.Response, stats.Err, true)
+}
+
+func (h *AuditHook) write(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error, stream bool) {
+	record := &AuditRecord{
+		CallID:     info.CallID,
+		Provider:   info.ProviderName,
+		Stream:     stream,
+		StartTime:  info.StartTime,
+		DurationMS: time.Since(info.StartTime).Milliseconds(),
+		Request:    cloneJSON(req),
+		Response:   cloneJSON(resp),
+	}
+	if req != nil {
+		record.Model = req.Model
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	h.config.Redaction.apply(record)
+	if h.config.Redact != nil {
+		h.config.Redact(record)
+	}
+
+	if writeErr := h.config.Sink.WriteAudit(ctx, record); writeErr != nil {
+		h.logger.Warn("failed to write audit record",
+			slog.String("call_id", info.CallID),
+			slog.String("error", writeErr.Error()))
+	}
+}
+
+// cloneJSON deep-copies a value through JSON; nil stays nil
+func cloneJSON[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var clone T
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil
+	}
+	return &clone
+}
+
+// apply redacts the record in place
+func (r AuditRedaction) apply(record *AuditRecord) {
+	if req := record.Request; req != nil {
+		for i := range req.Messages {
+			r.redactMessage(&req.Messages[i])
+		}
+		if r.HashUserIDs && req.User != nil {
+			hashed := r.hashUserID(*req.User)
+			req.User = &hashed
+		}
+	}
+	if resp := record.Response; resp != nil {
+		for i := range resp.Choices {
+			r.redactMessage(&resp.Choices[i].Message)
+			if resp.Choices[i].Delta != nil {
+				r.redactMessage(resp.Choices[i].Delta)
+			}
+		}
+	}
+}
+
+func (r AuditRedaction) redactMessage(msg *provider.Message) {
+	msg.Content = r.redactText(msg.Content)
+	for i := range msg.Parts {
+		part := &msg.Parts[i]
+		part.Text = r.redactText(part.Text)
+		if r.StripContent && part.Document != nil && len(part.Document.Data) > 0 {
+			part.Document.Data = nil
+		}
+		r.redactAudio(part.Audio)
+	}
+	r.redactAudio(msg.Audio)
+	for i := range msg.Thinking {
+		msg.Thinking[i].Text = r.redactText(msg.Thinking[i].Text)
+		msg.Thinking[i].Redacted = r.redactText(msg.Thinking[i].Redacted)
+	}
+	if r.DropToolArguments {
+		for i := range msg.ToolCalls {
+			msg.ToolCalls[i].Function.Arguments = ""
+		}
+	}
+}
+
+// redactAudio redacts the transcript of audio and, with StripContent, drops
+// its encoded bytes
+func (r AuditRedaction) redactAudio(audio *provider.Audio) {
+	if audio == nil {
+		return
+	}
+	audio.Transcript = r.redactText(audio.Transcript)
+	if r.StripContent {
+		audio.Data = nil
+	}
+}
+
+func (r AuditRedaction) redactText(text string) string {
+	if text == "" {
+		return text
+	}
+	if r.StripContent {
+		return RedactedPlaceholder
+	}
+	for _, re := range r.Patterns {
+		text = re.ReplaceAllString(text, RedactedPlaceholder)
+	}
+	return text
+}
+
+func (r AuditRedaction) hashUserID(id string) string {
+	sum := sha256.Sum256([]byte(r.HashSalt + id))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
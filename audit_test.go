@@ -0,0 +1,278 @@
+package omnillm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// memoryAuditSink collects records in memory
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []*AuditRecord
+}
+
+func (s *memoryAuditSink) WriteAudit(ctx context.Context, record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func auditRequest() *provider.ChatCompletionRequest {
+	user := "user-42"
+	return &provider.ChatCompletionRequest{
+		Model: "test-model",
+		User:  &user,
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "My email is jane@example.com"},
+			{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{
+				{ID: "call_1", Type: "function", Function: provider.ToolFunction{Name: "lookup", Arguments: `{"ssn":"123"}`}},
+			}},
+		},
+	}
+}
+
+func TestNewAuditHook_RequiresSink(t *testing.T) {
+	if _, err := NewAuditHook(AuditConfig{}); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("expected ErrInvalidConfiguration, got %v", err)
+	}
+}
+
+func TestAuditHook_Redaction(t *testing.T) {
+	sink := &memoryAuditSink{}
+	hook, err := NewAuditHook(AuditConfig{
+		Sink: sink,
+		Redaction: AuditRedaction{
+			HashUserIDs:       true,
+			HashSalt:          "salt",
+			DropToolArguments: true,
+			Patterns:          []*regexp.Regexp{regexp.MustCompile(`[\w.]+@[\w.]+`)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: NewMockProvider("test")}},
+		ObservabilityHook: hook,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := auditRequest()
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if record.Provider != "test" || record.Model != "test-model" || record.CallID == "" || record.Stream {
+		t.Errorf("unexpected record metadata: %+v", record)
+	}
+	if got := record.Request.Messages[0].Content; got != "My email is [REDACTED]" {
+		t.Errorf("pattern redaction: %q", got)
+	}
+	if got := record.Request.Messages[1].ToolCalls[0].Function.Arguments; got != "" {
+		t.Errorf("tool arguments not dropped: %q", got)
+	}
+	if got := *record.Request.User; !strings.HasPrefix(got, "sha256:") || strings.Contains(got, "user-42") {
+		t.Errorf("user ID not hashed: %q", got)
+	}
+	if record.Response == nil || record.Response.Choices[0].Message.Content != "Mock response" {
+		t.Errorf("unexpected response: %+v", record.Response)
+	}
+
+	// The caller's request must be untouched
+	if req.Messages[0].Content != "My email is jane@example.com" || *req.User != "user-42" ||
+		req.Messages[1].ToolCalls[0].Function.Arguments == "" {
+		t.Error("redaction modified the original request")
+	}
+}
+
+func TestAuditHook_StripContentAndErrors(t *testing.T) {
+	var buf bytes.Buffer
+	hook, err := NewAuditHook(AuditConfig{
+		Sink:      NewJSONLinesAuditSink(&buf),
+		Redaction: AuditRedaction{StripContent: true},
+		Redact: func(record *AuditRecord) {
+			record.Request.Model = "custom"
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockProv := NewMockProvider("test")
+	mockProv.completionError = errors.New("upstream failed")
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mockProv}},
+		ObservabilityHook: hook,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = client.CreateChatCompletion(context.Background(), auditRequest())
+
+	var record AuditRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", buf.String(), err)
+	}
+	if record.Error != "upstream failed" || record.Response != nil {
+		t.Errorf("unexpected error record: %+v", record)
+	}
+	if record.Request.Messages[0].Content != RedactedPlaceholder {
+		t.Errorf("content not stripped: %q", record.Request.Messages[0].Content)
+	}
+	if record.Request.Model != "custom" {
+		t.Error("custom Redact func not applied")
+	}
+}
+
+func TestAuditHook_Stream(t *testing.T) {
+	sink := &memoryAuditSink{}
+	hook, err := NewAuditHook(AuditConfig{Sink: sink, Redaction: AuditRedaction{StripContent: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := mocktest.NewScriptedProvider("scripted", mocktest.Step{Chunks: mocktest.TextChunks("secret ", "answer")})
+	client, err := NewClient(ClientConfig{
+		Providers:          []ProviderConfig{{CustomProvider: prov}},
+		ObservabilityHooks: []ObservabilityHook{hook},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), auditRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(sink.records))
+	}
+	record := sink.records[0]
+	if !record.Stream || record.Response == nil {
+		t.Fatalf("unexpected stream record: %+v", record)
+	}
+	if got := record.Response.Choices[0].Message.Content; got != RedactedPlaceholder {
+		t.Errorf("stream response not redacted: %q", got)
+	}
+}
+
+func TestAuditHook_RedactsAudio(t *testing.T) {
+	var buf bytes.Buffer
+	hook, err := NewAuditHook(AuditConfig{
+		Sink:      NewJSONLinesAuditSink(&buf),
+		Redaction: AuditRedaction{StripContent: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := mocktest.TextResponse("")
+	resp.Choices[0].Message.Audio = &provider.Audio{Format: "wav", Data: []byte("SECRETOUTPUT"), Transcript: "SECRETTRANSCRIPT"}
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mocktest.NewScriptedProvider("scripted", mocktest.Step{Response: resp})}},
+		ObservabilityHook: hook,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &provider.ChatCompletionRequest{
+		Model: "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Parts: []provider.ContentPart{
+			{Type: provider.ContentPartTypeAudio, Audio: &provider.Audio{Format: "wav", Data: []byte("SECRETINPUT")}},
+		}}},
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	line := buf.String()
+	for _, secret := range []string{"SECRETTRANSCRIPT", "SECRETINPUT", "SECRETOUTPUT"} {
+		encoded, _ := json.Marshal([]byte(secret))
+		if strings.Contains(line, secret) || strings.Contains(line, strings.Trim(string(encoded), `"`)) {
+			t.Errorf("audit record leaks %s: %s", secret, line)
+		}
+	}
+
+	var record AuditRecord
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", line, err)
+	}
+	if got := record.Response.Choices[0].Message.Audio.Transcript; got != RedactedPlaceholder {
+		t.Errorf("transcript = %q, want %q", got, RedactedPlaceholder)
+	}
+}
+
+func TestAuditHook_RedactsThinking(t *testing.T) {
+	sink := &memoryAuditSink{}
+	hook, err := NewAuditHook(AuditConfig{
+		Sink:      sink,
+		Redaction: AuditRedaction{Patterns: []*regexp.Regexp{regexp.MustCompile(`SECRET\w+`)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := mocktest.TextResponse("answer")
+	resp.Choices[0].Message.Thinking = []provider.ThinkingBlock{{Text: "The user said SECRETTHINK", Signature: "sig"}}
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mocktest.NewScriptedProvider("scripted", mocktest.Step{Response: resp})}},
+		ObservabilityHook: hook,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), auditRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	thinking := sink.records[0].Response.Choices[0].Message.Thinking[0]
+	if thinking.Text != "The user said "+RedactedPlaceholder || thinking.Signature != "sig" {
+		t.Errorf("thinking not redacted: %+v", thinking)
+	}
+
+	stripped := AuditRedaction{StripContent: true}
+	msg := provider.Message{Thinking: []provider.ThinkingBlock{{Text: "SECRETTHINK"}, {Redacted: "opaque"}}}
+	stripped.redactMessage(&msg)
+	if msg.Thinking[0].Text != RedactedPlaceholder || msg.Thinking[1].Redacted != RedactedPlaceholder {
+		t.Errorf("thinking not stripped: %+v", msg.Thinking)
+	}
+}
+
+func TestAuditHook_SinkErrorDoesNotFailCall(t *testing.T) {
+	hook, err := NewAuditHook(AuditConfig{
+		Sink: AuditSinkFunc(func(ctx context.Context, record *AuditRecord) error {
+			return errors.New("disk full")
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: NewMockProvider("test")}},
+		ObservabilityHook: hook,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), auditRequest()); err != nil {
+		t.Errorf("sink error leaked into call: %v", err)
+	}
+}
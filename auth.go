@@ -0,0 +1,133 @@
+package omnillm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// Authenticator authorizes the requests a provider sends, in place of its
+// API key. It runs for every request, including retries, so short-lived
+// tokens such as Azure AD or GCP access tokens can be refreshed without
+// rebuilding the client. Set it as ProviderConfig.Auth.
+type Authenticator interface {
+	// Authenticate adds credentials to req. The provider's own API key
+	// headers have been removed.
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc adapts a function to an Authenticator
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate calls f(req)
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// StaticKeyAuth sends key in header, e.g. StaticKeyAuth("api-key", key) for
+// Azure OpenAI
+func StaticKeyAuth(header, key string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set(header, key)
+		return nil
+	})
+}
+
+// BearerTokenAuth sends the token returned by token as a bearer token. token
+// is called for every request, so it should cache tokens until they expire.
+func BearerTokenAuth(token func(ctx context.Context) (string, error)) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		t, err := token(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+t)
+		return nil
+	})
+}
+
+// OAuth2Auth sends tokens from source, reusing each one until it expires
+func OAuth2Auth(source oauth2.TokenSource) Authenticator {
+	source = oauth2.ReuseTokenSource(nil, source)
+	return AuthenticatorFunc(func(req *http.Request) error {
+		t, err := source.Token()
+		if err != nil {
+			return fmt.Errorf("failed to get OAuth2 token: %w", err)
+		}
+		t.SetAuthHeader(req)
+		return nil
+	})
+}
+
+// SignerAuth calls sign with each request and its body, for signatures over
+// the payload such as AWS SigV4. The body has been read for sign; the request
+// still sends it.
+func SignerAuth(sign func(req *http.Request, body []byte) error) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return err
+		}
+		return sign(req, body)
+	})
+}
+
+// readRequestBody returns the body of req, leaving it readable
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// authPlaceholderKey stands in for the API key of a provider with an
+// Authenticator, since built-in providers require one. The headers carrying
+// it are removed before the request is sent.
+const authPlaceholderKey = "omnillm-authenticator" //nolint:gosec // G101: placeholder, not a credential
+
+// apiKeyHeaders are the headers built-in providers send their API key in
+var apiKeyHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key"}
+
+// authTransport replaces the provider's API key headers with the
+// credentials from an Authenticator
+type authTransport struct {
+	base http.RoundTripper
+	auth Authenticator
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	req = req.Clone(req.Context())
+	for _, name := range apiKeyHeaders {
+		req.Header.Del(name)
+	}
+	if err := t.auth.Authenticate(req); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return base.RoundTrip(req)
+}
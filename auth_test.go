@@ -0,0 +1,149 @@
+package omnillm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestAuth_BearerTokenRefresh(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+		_, _ = io.WriteString(w, gatewayCompletion)
+	}))
+	defer server.Close()
+
+	calls := 0
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider: ProviderNameOpenAI,
+			BaseURL:  server.URL,
+			Auth: BearerTokenAuth(func(ctx context.Context) (string, error) {
+				calls++
+				return fmt.Sprintf("token-%d", calls), nil
+			}),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	for range 2 {
+		if _, err := client.CreateChatCompletion(context.Background(), gatewayRequest()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 2 || got[0] != "Bearer token-1" || got[1] != "Bearer token-2" {
+		t.Errorf("Authorization = %q, want a fresh token per request", got)
+	}
+}
+
+func TestAuth_StaticKeyReplacesAPIKey(t *testing.T) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn"}`)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider: ProviderNameAnthropic,
+			APIKey:   "unused",
+			BaseURL:  server.URL,
+			Auth:     StaticKeyAuth("api-key", "gateway-key"),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateChatCompletion(context.Background(), gatewayRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("api-key") != "gateway-key" || header.Get("x-api-key") != "" {
+		t.Errorf("api-key = %q, x-api-key = %q", header.Get("api-key"), header.Get("x-api-key"))
+	}
+}
+
+func TestAuth_OAuth2(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, gatewayCompletion)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider: ProviderNameOpenAI,
+			BaseURL:  server.URL,
+			Auth:     OAuth2Auth(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "ya29.token", TokenType: "Bearer"})),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateChatCompletion(context.Background(), gatewayRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bearer ya29.token" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestAuth_SignerSeesBodyAndQuery(t *testing.T) {
+	var signature, body string
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Signature")
+		query = r.URL.Query()
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		_, _ = io.WriteString(w, gatewayCompletion)
+	}))
+	defer server.Close()
+
+	var signedQuery string
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider:    ProviderNameOpenAI,
+			BaseURL:     server.URL,
+			QueryParams: url.Values{"api-version": {"2024-10-21"}},
+			Auth: SignerAuth(func(req *http.Request, body []byte) error {
+				signedQuery = req.URL.RawQuery
+				sum := sha256.Sum256(body)
+				req.Header.Set("X-Signature", hex.EncodeToString(sum[:]))
+				return nil
+			}),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateChatCompletion(context.Background(), gatewayRequest()); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	if body == "" || signature != hex.EncodeToString(sum[:]) {
+		t.Errorf("signature %q does not match the body sent (%d bytes)", signature, len(body))
+	}
+	if signedQuery != "api-version=2024-10-21" || query.Get("api-version") != "2024-10-21" {
+		t.Errorf("signed query = %q, sent query = %v", signedQuery, query)
+	}
+}
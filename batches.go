@@ -0,0 +1,105 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrBatchesNotSupported is returned when no configured provider supports batch processing
+var ErrBatchesNotSupported = errors.New("batch processing not supported by configured providers")
+
+// Batch request outcomes reported in BatchResult.Err
+var (
+	ErrBatchRequestCanceled = provider.ErrBatchRequestCanceled
+	ErrBatchRequestExpired  = provider.ErrBatchRequestExpired
+	ErrBatchResultMissing   = provider.ErrBatchResultMissing
+)
+
+// defaultBatchPollInterval is how often WaitForBatch polls when no interval is given
+const defaultBatchPollInterval = 30 * time.Second
+
+// CreateBatch submits requests for asynchronous processing using the first
+// configured provider that supports batches. The client's default system
+// prompt and request validation apply to each request. Keep the requests to
+// match results back to them with GetBatchResults.
+func (c *ChatClient) CreateBatch(ctx context.Context, requests []provider.BatchRequest) (*provider.Batch, error) {
+	if c.batches == nil {
+		return nil, ErrBatchesNotSupported
+	}
+	if err := provider.ValidateBatchRequests(requests); err != nil {
+		return nil, err
+	}
+
+	prepared := make([]provider.BatchRequest, len(requests))
+	for i, r := range requests {
+		req, err := c.applySystemPrompt(r.Request)
+		if err != nil {
+			return nil, fmt.Errorf("batch request %q: %w", r.CustomID, err)
+		}
+		if err := c.validator.validate(req, false); err != nil {
+			return nil, fmt.Errorf("batch request %q: %w", r.CustomID, err)
+		}
+		prepared[i] = provider.BatchRequest{CustomID: r.CustomID, Request: req}
+	}
+	return c.batches.CreateBatch(ensureIdempotencyKey(ctx), prepared)
+}
+
+// GetBatch retrieves the current status of a batch
+func (c *ChatClient) GetBatch(ctx context.Context, batchID string) (*provider.Batch, error) {
+	if c.batches == nil {
+		return nil, ErrBatchesNotSupported
+	}
+	return c.batches.GetBatch(ctx, batchID)
+}
+
+// GetBatchResults fetches the results of a batch that has ended. If the
+// requests passed to CreateBatch are given, each result is matched to its
+// request by custom ID and results are returned in the same order.
+func (c *ChatClient) GetBatchResults(ctx context.Context, batchID string, requests []provider.BatchRequest) ([]provider.BatchResult, error) {
+	if c.batches == nil {
+		return nil, ErrBatchesNotSupported
+	}
+	return c.batches.GetBatchResults(ctx, batchID, requests)
+}
+
+// CancelBatch stops processing of a batch
+func (c *ChatClient) CancelBatch(ctx context.Context, batchID string) (*provider.Batch, error) {
+	if c.batches == nil {
+		return nil, ErrBatchesNotSupported
+	}
+	return c.batches.CancelBatch(ctx, batchID)
+}
+
+// WaitForBatch polls a batch every interval until it has ended or ctx is
+// done. An interval of zero or less polls every 30 seconds.
+func (c *ChatClient) WaitForBatch(ctx context.Context, batchID string, interval time.Duration) (*provider.Batch, error) {
+	if interval <= 0 {
+		interval = defaultBatchPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		batch, err := c.GetBatch(ctx, batchID)
+		if err != nil {
+			return nil, err
+		}
+		if batch.Done() {
+			return batch, nil
+		}
+		select {
+		case <-ctx.Done():
+			return batch, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// HasBatches returns true if a provider supporting batch processing is configured
+func (c *ChatClient) HasBatches() bool {
+	return c.batches != nil
+}
@@ -0,0 +1,126 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockBatchProvider is a provider that also processes batches. Each batch
+// ends after a fixed number of status polls.
+type mockBatchProvider struct {
+	*MockProvider
+	requests []provider.BatchRequest
+	polls    int
+}
+
+func (m *mockBatchProvider) CreateBatch(ctx context.Context, requests []provider.BatchRequest) (*provider.Batch, error) {
+	m.requests = requests
+	return &provider.Batch{ID: "batch-1", Provider: m.name, Status: provider.BatchStatusInProgress}, nil
+}
+
+func (m *mockBatchProvider) GetBatch(ctx context.Context, batchID string) (*provider.Batch, error) {
+	m.polls++
+	status := provider.BatchStatusInProgress
+	if m.polls >= 2 {
+		status = provider.BatchStatusEnded
+	}
+	return &provider.Batch{ID: batchID, Provider: m.name, Status: status}, nil
+}
+
+func (m *mockBatchProvider) GetBatchResults(ctx context.Context, batchID string, requests []provider.BatchRequest) ([]provider.BatchResult, error) {
+	// Results come back in reverse, with one missing
+	var results []provider.BatchResult
+	for i := len(m.requests) - 1; i > 0; i-- {
+		results = append(results, provider.BatchResult{
+			CustomID: m.requests[i].CustomID,
+			Response: &provider.ChatCompletionResponse{ID: m.requests[i].CustomID},
+		})
+	}
+	return provider.MatchBatchResults(requests, results), nil
+}
+
+func (m *mockBatchProvider) CancelBatch(ctx context.Context, batchID string) (*provider.Batch, error) {
+	return &provider.Batch{ID: batchID, Provider: m.name, Status: provider.BatchStatusCanceling}, nil
+}
+
+func TestChatClient_Batches(t *testing.T) {
+	batchProv := &mockBatchProvider{MockProvider: NewMockProvider("batches")}
+	client, err := NewClient(ClientConfig{
+		Providers:           []ProviderConfig{{CustomProvider: batchProv}},
+		DefaultSystemPrompt: "Be brief.",
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if !client.HasBatches() {
+		t.Fatal("HasBatches() = false")
+	}
+
+	requests := []BatchRequest{
+		{CustomID: "a", Request: &ChatCompletionRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "one"}}}},
+		{CustomID: "b", Request: &ChatCompletionRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "two"}}}},
+		{CustomID: "c", Request: &ChatCompletionRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "three"}}}},
+	}
+
+	ctx := context.Background()
+	batch, err := client.CreateBatch(ctx, requests)
+	if err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+	if sent := batchProv.requests[0].Request.Messages; len(sent) != 2 || sent[0].Content != "Be brief." {
+		t.Errorf("sent messages = %+v, want default system prompt first", sent)
+	}
+	if len(requests[0].Request.Messages) != 1 {
+		t.Error("CreateBatch modified the caller's request")
+	}
+
+	batch, err = client.WaitForBatch(ctx, batch.ID, time.Millisecond)
+	if err != nil || !batch.Done() {
+		t.Fatalf("WaitForBatch = %+v, %v", batch, err)
+	}
+
+	results, err := client.GetBatchResults(ctx, batch.ID, requests)
+	if err != nil {
+		t.Fatalf("GetBatchResults failed: %v", err)
+	}
+	if len(results) != 3 || results[1].Response.ID != "b" || results[2].Response.ID != "c" {
+		t.Errorf("results = %+v", results)
+	}
+	if !errors.Is(results[0].Err, ErrBatchResultMissing) || results[0].Request != requests[0].Request {
+		t.Errorf("missing result = %+v", results[0])
+	}
+}
+
+func TestChatClient_CreateBatchRejectsDuplicateCustomIDs(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: &mockBatchProvider{MockProvider: NewMockProvider("batches")}}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	req := &ChatCompletionRequest{Model: "m", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	_, err = client.CreateBatch(context.Background(), []BatchRequest{{CustomID: "a", Request: req}, {CustomID: "a", Request: req}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("CreateBatch error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestChatClient_BatchesNotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.HasBatches() {
+		t.Error("HasBatches() = true")
+	}
+	if _, err := client.GetBatch(context.Background(), "batch-1"); !errors.Is(err, ErrBatchesNotSupported) {
+		t.Errorf("GetBatch error = %v, want ErrBatchesNotSupported", err)
+	}
+}
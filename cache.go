@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/grokify/sogo/database/kvs"
@@ -19,6 +21,12 @@ type CacheConfig struct {
 	// Default: 1 hour
 	TTL time.Duration
 
+	// TTLByModel overrides TTL for specific models, so deterministic
+	// extraction models can be cached for days and creative ones for
+	// minutes. Models not listed, and non-positive durations, use TTL.
+	// WithCacheTTL overrides both for a single request.
+	TTLByModel map[string]time.Duration
+
 	// KeyPrefix is the prefix for cache keys in the KVS.
 	// Default: "omnillm:cache"
 	KeyPrefix string
@@ -31,8 +39,9 @@ type CacheConfig struct {
 	// If nil or empty, all models are cached.
 	CacheableModels []string
 
-	// ExcludeParameters lists parameters to exclude from cache key calculation.
-	// Common exclusions: "user" (user ID shouldn't affect cache)
+	// ExcludeParameters lists parameters to exclude from cache key
+	// calculation, by their JSON names (e.g., "max_tokens", "tools").
+	// The user ID never affects the key.
 	// Default: ["user"]
 	ExcludeParameters []string
 
@@ -44,6 +53,22 @@ type CacheConfig struct {
 	// IncludeSeed includes seed in cache key.
 	// Default: true
 	IncludeSeed bool
+
+	// L1 adds an in-process cache in front of the KVS, so hot prompts are
+	// served without a round trip (optional). Stores write through to both.
+	L1 *L1CacheConfig
+
+	// LegacyKeys hashes only the fields that earlier versions covered: the
+	// messages' role, content, name, tool call ID, and parts, plus max
+	// tokens, temperature, top-p, top-k, seed, stop, and n. It keeps entries
+	// written by those versions reachable, but requests that differ only in
+	// tools, tool calls, response format, or other parameters share a key.
+	// Default: false
+	LegacyKeys bool
+
+	// OnEvent is called after every cache lookup and store (optional).
+	// It runs synchronously on the request path, so it should return quickly.
+	OnEvent func(CacheEvent)
 }
 
 // DefaultCacheConfig returns a CacheConfig with sensible defaults
@@ -85,6 +110,15 @@ func (e *CacheEntry) IsExpired() bool {
 type CacheManager struct {
 	kvs    kvs.Client
 	config CacheConfig
+
+	l1 *l1Cache
+
+	hits    atomic.Int64
+	l1Hits  atomic.Int64
+	misses  atomic.Int64
+	expired atomic.Int64
+	errors  atomic.Int64
+	sets    atomic.Int64
 }
 
 // NewCacheManager creates a new cache manager with the given KVS client and configuration.
@@ -100,28 +134,54 @@ func NewCacheManager(kvsClient kvs.Client, config CacheConfig) *CacheManager {
 		config.ExcludeParameters = []string{"user"}
 	}
 
-	return &CacheManager{
+	m := &CacheManager{
 		kvs:    kvsClient,
 		config: config,
 	}
+	if config.L1 != nil {
+		m.l1 = newL1Cache(*config.L1)
+	}
+	return m
 }
 
 // Get retrieves a cached response for the given request.
 // Returns nil if no valid cache entry exists.
 func (m *CacheManager) Get(ctx context.Context, req *provider.ChatCompletionRequest) (*CacheEntry, error) {
 	key := m.BuildCacheKey(req)
+	event := CacheEvent{Key: key, Model: req.Model}
+
+	var data string
+	if m.l1 != nil {
+		data, event.L1 = m.l1.get(key)
+	}
+	if !event.L1 {
+		// The KVS interface does not distinguish a missing key from a
+		// failed read, so both count as misses
+		var err error
+		data, err = m.kvs.GetString(ctx, key)
+		if err != nil || data == "" {
+			m.record(event, CacheEventMiss)
+			return nil, nil
+		}
+	}
 
 	var entry CacheEntry
-	if err := m.kvs.GetAny(ctx, key, &entry); err != nil {
-		// Cache miss or error
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		event.Err = fmt.Errorf("decoding cache entry: %w", err)
+		m.record(event, CacheEventError)
 		return nil, nil
 	}
 
 	// Check expiration
 	if entry.IsExpired() {
+		m.record(event, CacheEventExpired)
 		return nil, nil
 	}
 
+	if m.l1 != nil && !event.L1 {
+		m.l1.set(key, data, entry.ExpiresAt)
+	}
+	m.record(event, CacheEventHit)
 	return &entry, nil
 }
 
@@ -133,17 +193,53 @@ func (m *CacheManager) Set(ctx context.Context, req *provider.ChatCompletionRequ
 	entry := CacheEntry{
 		Response:    resp,
 		CachedAt:    now,
-		ExpiresAt:   now.Add(m.config.TTL),
+		ExpiresAt:   now.Add(m.ttl(ctx, req)),
 		Model:       req.Model,
 		RequestHash: m.hashRequest(req),
 	}
 
-	return m.kvs.SetAny(ctx, key, entry)
+	event := CacheEvent{Key: key, Model: req.Model}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = m.kvs.SetString(ctx, key, string(data))
+	}
+	if err != nil {
+		event.Err = err
+		m.record(event, CacheEventError)
+		return err
+	}
+	if m.l1 != nil {
+		m.l1.set(key, string(data), entry.ExpiresAt)
+	}
+	m.record(event, CacheEventSet)
+	return nil
+}
+
+type cacheTTLContextKey struct{}
+
+// WithCacheTTL returns a context whose requests are cached for ttl, overriding
+// CacheConfig.TTL and TTLByModel. Use it to set TTLs per route or use case.
+func WithCacheTTL(ctx context.Context, ttl time.Duration) context.Context {
+	return context.WithValue(ctx, cacheTTLContextKey{}, ttl)
+}
+
+// ttl returns how long the response to req should be cached
+func (m *CacheManager) ttl(ctx context.Context, req *provider.ChatCompletionRequest) time.Duration {
+	if ttl, ok := ctx.Value(cacheTTLContextKey{}).(time.Duration); ok && ttl > 0 {
+		return ttl
+	}
+	if ttl := m.config.TTLByModel[req.Model]; ttl > 0 {
+		return ttl
+	}
+	return m.config.TTL
 }
 
 // Delete removes a cache entry for the given request.
 func (m *CacheManager) Delete(ctx context.Context, req *provider.ChatCompletionRequest) error {
 	key := m.BuildCacheKey(req)
+	if m.l1 != nil {
+		m.l1.delete(key)
+	}
 	return m.kvs.SetString(ctx, key, "") // KVS doesn't have Delete, use empty string
 }
 
@@ -175,23 +271,40 @@ func (m *CacheManager) BuildCacheKey(req *provider.ChatCompletionRequest) string
 	return fmt.Sprintf("%s:%s", m.config.KeyPrefix, hash)
 }
 
-// normalizedRequest is used for cache key generation
+// normalizedRequest is used for cache key generation. Every field is
+// omitted when unset, so requests that do not use the fields added after
+// the original key format hash as they did before.
 type normalizedRequest struct {
-	Model       string              `json:"model"`
-	Messages    []normalizedMessage `json:"messages"`
-	MaxTokens   *int                `json:"max_tokens,omitempty"`
-	Temperature *float64            `json:"temperature,omitempty"`
-	TopP        *float64            `json:"top_p,omitempty"`
-	TopK        *int                `json:"top_k,omitempty"`
-	Seed        *int                `json:"seed,omitempty"`
-	Stop        []string            `json:"stop,omitempty"`
+	Model            string                   `json:"model"`
+	Messages         []normalizedMessage      `json:"messages"`
+	MaxTokens        *int                     `json:"max_tokens,omitempty"`
+	Temperature      *float64                 `json:"temperature,omitempty"`
+	TopP             *float64                 `json:"top_p,omitempty"`
+	TopK             *int                     `json:"top_k,omitempty"`
+	Seed             *int                     `json:"seed,omitempty"`
+	Stop             []string                 `json:"stop,omitempty"`
+	N                *int                     `json:"n,omitempty"`
+	PresencePenalty  *float64                 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64                 `json:"frequency_penalty,omitempty"`
+	LogitBias        map[string]int           `json:"logit_bias,omitempty"`
+	Tools            []provider.Tool          `json:"tools,omitempty"`
+	ToolChoice       any                      `json:"tool_choice,omitempty"`
+	ResponseFormat   *provider.ResponseFormat `json:"response_format,omitempty"`
+	Logprobs         *bool                    `json:"logprobs,omitempty"`
+	TopLogprobs      *int                     `json:"top_logprobs,omitempty"`
+	Thinking         *provider.ThinkingConfig `json:"thinking,omitempty"`
+	ProviderOptions  map[string]any           `json:"provider_options,omitempty"`
 }
 
 type normalizedMessage struct {
-	Role       string  `json:"role"`
-	Content    string  `json:"content"`
-	Name       *string `json:"name,omitempty"`
-	ToolCallID *string `json:"tool_call_id,omitempty"`
+	Role       string                   `json:"role"`
+	Content    string                   `json:"content"`
+	Name       *string                  `json:"name,omitempty"`
+	ToolCallID *string                  `json:"tool_call_id,omitempty"`
+	Parts      []provider.ContentPart   `json:"parts,omitempty"`
+	ToolCalls  []provider.ToolCall      `json:"tool_calls,omitempty"`
+	Thinking   []provider.ThinkingBlock `json:"thinking,omitempty"`
+	AudioID    string                   `json:"audio_id,omitempty"`
 }
 
 // hashRequest creates a deterministic hash of the request for caching
@@ -199,48 +312,97 @@ func (m *CacheManager) hashRequest(req *provider.ChatCompletionRequest) string {
 	normalized := normalizedRequest{
 		Model: req.Model,
 	}
+	full := !m.config.LegacyKeys
 
 	// Normalize messages
 	for _, msg := range req.Messages {
-		normalized.Messages = append(normalized.Messages, normalizedMessage{
+		nm := normalizedMessage{
 			Role:       string(msg.Role),
 			Content:    msg.Content,
 			Name:       msg.Name,
 			ToolCallID: msg.ToolCallID,
-		})
+			Parts:      msg.Parts,
+		}
+		if full {
+			nm.ToolCalls = msg.ToolCalls
+			nm.Thinking = msg.Thinking
+			if msg.Audio != nil {
+				nm.AudioID = msg.Audio.ID
+			}
+		}
+		normalized.Messages = append(normalized.Messages, nm)
 	}
 
 	// Include parameters that affect output
-	if req.MaxTokens != nil {
+	if m.keyIncludes("max_tokens") {
 		normalized.MaxTokens = req.MaxTokens
 	}
-
-	if m.config.IncludeTemperature && req.Temperature != nil {
+	if m.config.IncludeTemperature && m.keyIncludes("temperature") {
 		normalized.Temperature = req.Temperature
 	}
-
-	if req.TopP != nil {
+	if m.keyIncludes("top_p") {
 		normalized.TopP = req.TopP
 	}
-
-	if req.TopK != nil {
+	if m.keyIncludes("top_k") {
 		normalized.TopK = req.TopK
 	}
-
-	if m.config.IncludeSeed && req.Seed != nil {
+	if m.config.IncludeSeed && m.keyIncludes("seed") {
 		normalized.Seed = req.Seed
 	}
-
-	if len(req.Stop) > 0 {
+	if len(req.Stop) > 0 && m.keyIncludes("stop") {
 		normalized.Stop = req.Stop
 	}
 
+	// N > 1 responses carry several choices; N = 1 is the default single choice
+	if req.N != nil && *req.N > 1 && m.keyIncludes("n") {
+		normalized.N = req.N
+	}
+
+	if full {
+		if m.keyIncludes("presence_penalty") {
+			normalized.PresencePenalty = req.PresencePenalty
+		}
+		if m.keyIncludes("frequency_penalty") {
+			normalized.FrequencyPenalty = req.FrequencyPenalty
+		}
+		if m.keyIncludes("logit_bias") {
+			normalized.LogitBias = req.LogitBias
+		}
+		if m.keyIncludes("tools") {
+			normalized.Tools = req.Tools
+		}
+		if m.keyIncludes("tool_choice") {
+			normalized.ToolChoice = req.ToolChoice
+		}
+		if m.keyIncludes("response_format") {
+			normalized.ResponseFormat = req.ResponseFormat
+		}
+		if m.keyIncludes("logprobs") {
+			normalized.Logprobs = req.Logprobs
+		}
+		if m.keyIncludes("top_logprobs") {
+			normalized.TopLogprobs = req.TopLogprobs
+		}
+		if m.keyIncludes("thinking") {
+			normalized.Thinking = req.Thinking
+		}
+		if m.keyIncludes("provider_options") {
+			normalized.ProviderOptions = req.ProviderOptions
+		}
+	}
+
 	// Hash the normalized request
 	data, _ := json.Marshal(normalized)
 	hash := sha256.Sum256(data)
 	return hex.EncodeToString(hash[:16]) // Use first 16 bytes for shorter keys
 }
 
+// keyIncludes reports whether a request parameter, by JSON name, is part of
+// the cache key
+func (m *CacheManager) keyIncludes(param string) bool {
+	return !slices.Contains(m.config.ExcludeParameters, param)
+}
+
 // Config returns the cache configuration
 func (m *CacheManager) Config() CacheConfig {
 	return m.config
@@ -248,8 +410,92 @@ func (m *CacheManager) Config() CacheConfig {
 
 // CacheStats contains statistics about cache usage
 type CacheStats struct {
-	Hits   int64
-	Misses int64
+	Hits    int64 `json:"hits"`
+	L1Hits  int64 `json:"l1_hits"` // Hits served by the in-process tier, included in Hits
+	Misses  int64 `json:"misses"`  // Lookups that found no entry
+	Expired int64 `json:"expired"` // Lookups that found an expired entry
+	Errors  int64 `json:"errors"`  // Undecodable entries and failed stores
+	Sets    int64 `json:"sets"`    // Responses stored
+}
+
+// Lookups returns the number of cache lookups
+func (s CacheStats) Lookups() int64 {
+	return s.Hits + s.Misses + s.Expired
+}
+
+// HitRate returns the fraction of lookups that were hits, or 0 if there were none
+func (s CacheStats) HitRate() float64 {
+	lookups := s.Lookups()
+	if lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(lookups)
+}
+
+// CacheEventType identifies what happened in a cache operation
+type CacheEventType string
+
+const (
+	CacheEventHit     CacheEventType = "hit"
+	CacheEventMiss    CacheEventType = "miss"
+	CacheEventExpired CacheEventType = "expired"
+	CacheEventError   CacheEventType = "error"
+	CacheEventSet     CacheEventType = "set"
+)
+
+// CacheEvent describes one cache lookup or store, for CacheConfig.OnEvent
+type CacheEvent struct {
+	Type  CacheEventType
+	Key   string
+	Model string
+	L1    bool  // The lookup was answered by the in-process tier
+	Err   error // Set for CacheEventError
+}
+
+// Stats returns the cache counters accumulated since the manager was created
+// or last reset
+func (m *CacheManager) Stats() CacheStats {
+	return CacheStats{
+		Hits:    m.hits.Load(),
+		L1Hits:  m.l1Hits.Load(),
+		Misses:  m.misses.Load(),
+		Expired: m.expired.Load(),
+		Errors:  m.errors.Load(),
+		Sets:    m.sets.Load(),
+	}
+}
+
+// ResetStats sets the cache counters to zero
+func (m *CacheManager) ResetStats() {
+	m.hits.Store(0)
+	m.l1Hits.Store(0)
+	m.misses.Store(0)
+	m.expired.Store(0)
+	m.errors.Store(0)
+	m.sets.Store(0)
+}
+
+// record counts a cache event of the given type and passes it to the OnEvent callback
+func (m *CacheManager) record(event CacheEvent, eventType CacheEventType) {
+	event.Type = eventType
+	switch eventType {
+	case CacheEventHit:
+		m.hits.Add(1)
+		if event.L1 {
+			m.l1Hits.Add(1)
+		}
+	case CacheEventMiss:
+		m.misses.Add(1)
+	case CacheEventExpired:
+		m.expired.Add(1)
+	case CacheEventError:
+		m.errors.Add(1)
+	case CacheEventSet:
+		m.sets.Add(1)
+	}
+	if m.config.OnEvent != nil {
+		m.config.OnEvent(event)
+	}
 }
 
 // CacheHitError is a marker type to indicate a cache hit (not an actual error)
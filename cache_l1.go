@@ -0,0 +1,115 @@
+package omnillm
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// L1CacheConfig configures the in-process tier of a two-tier cache
+type L1CacheConfig struct {
+	// MaxEntries bounds the number of responses held in process. The least
+	// recently used entry is evicted first. Default: 1000
+	MaxEntries int
+
+	// TTL bounds how long a response stays in process, so entries deleted
+	// or replaced in the KVS by other instances are not served for long.
+	// It never extends an entry past its own expiry. Default: 1 minute
+	TTL time.Duration
+}
+
+// l1Cache is an in-process LRU cache of encoded cache entries. Entries are
+// stored encoded so that each hit decodes its own copy of the response.
+type l1Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List // Front is most recently used
+	items      map[string]*list.Element
+}
+
+type l1Item struct {
+	key       string
+	data      string
+	expiresAt time.Time
+}
+
+func newL1Cache(config L1CacheConfig) *l1Cache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 1000
+	}
+	if config.TTL <= 0 {
+		config.TTL = time.Minute
+	}
+	return &l1Cache{
+		maxEntries: config.MaxEntries,
+		ttl:        config.TTL,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// get returns the encoded entry stored under key, if it has not expired
+func (c *l1Cache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	item := elem.Value.(*l1Item)
+	if time.Now().After(item.expiresAt) {
+		c.removeElement(elem)
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return item.data, true
+}
+
+// set stores an encoded entry that expires at entryExpiresAt, or sooner if
+// the L1 TTL is shorter
+func (c *l1Cache) set(key, data string, entryExpiresAt time.Time) {
+	expiresAt := time.Now().Add(c.ttl)
+	if entryExpiresAt.Before(expiresAt) {
+		expiresAt = entryExpiresAt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*l1Item)
+		item.data = data
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&l1Item{key: key, data: data, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// delete removes the entry stored under key
+func (c *l1Cache) delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// len returns the number of entries held
+func (c *l1Cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *l1Cache) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*l1Item).key)
+}
@@ -0,0 +1,104 @@
+package omnillm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	testutil "github.com/plexusone/omnillm/testing"
+)
+
+func TestL1Cache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newL1Cache(L1CacheConfig{MaxEntries: 2})
+	expires := time.Now().Add(time.Hour)
+
+	c.set("a", "1", expires)
+	c.set("b", "2", expires)
+	c.get("a") // b is now least recently used
+	c.set("c", "3", expires)
+
+	if _, ok := c.get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if data, ok := c.get("a"); !ok || data != "1" {
+		t.Errorf("expected a to be kept, got %q, %v", data, ok)
+	}
+	if c.len() != 2 {
+		t.Errorf("len = %d, want 2", c.len())
+	}
+}
+
+func TestL1Cache_TTL(t *testing.T) {
+	c := newL1Cache(L1CacheConfig{TTL: 20 * time.Millisecond})
+
+	c.set("long", "1", time.Now().Add(time.Hour))
+	c.set("short", "2", time.Now().Add(-time.Second)) // already past its entry expiry
+	if _, ok := c.get("short"); ok {
+		t.Error("expected the entry's own expiry to cap the L1 TTL")
+	}
+	if _, ok := c.get("long"); !ok {
+		t.Fatal("expected a hit within the L1 TTL")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.get("long"); ok {
+		t.Error("expected the entry to leave L1 after its TTL")
+	}
+}
+
+func TestCacheManager_TwoTier(t *testing.T) {
+	kvs := testutil.NewMockKVS()
+	config := DefaultCacheConfig()
+	config.L1 = &L1CacheConfig{MaxEntries: 10, TTL: time.Minute}
+	cache := NewCacheManager(kvs, config)
+	ctx := context.Background()
+
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: "user", Content: "Hello"}}}
+	resp := &provider.ChatCompletionResponse{ID: "resp-123"}
+
+	// Stores write through to both tiers
+	if err := cache.Set(ctx, req, resp); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if kvs.Size() != 1 {
+		t.Fatalf("expected the KVS to hold the entry, got %d keys", kvs.Size())
+	}
+	kvs.Clear()
+	entry, _ := cache.Get(ctx, req)
+	if entry == nil || entry.Response.ID != "resp-123" {
+		t.Fatalf("expected an L1 hit, got %+v", entry)
+	}
+
+	// Each hit decodes its own copy
+	entry.Response.ID = "mutated"
+	if again, _ := cache.Get(ctx, req); again == nil || again.Response.ID != "resp-123" {
+		t.Errorf("expected L1 entries to be unaffected by callers, got %+v", again)
+	}
+
+	// Entries written by another instance are promoted to L1 on first read
+	other := NewCacheManager(kvs, DefaultCacheConfig())
+	req2 := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: "user", Content: "Bye"}}}
+	if err := other.Set(ctx, req2, resp); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if entry, _ := cache.Get(ctx, req2); entry == nil {
+		t.Fatal("expected a KVS hit")
+	}
+	kvs.Clear()
+	if entry, _ := cache.Get(ctx, req2); entry == nil {
+		t.Fatal("expected the KVS hit to be kept in L1")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 4 || stats.L1Hits != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Delete clears both tiers
+	if err := cache.Delete(ctx, req); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if entry, _ := cache.Get(ctx, req); entry != nil {
+		t.Error("expected a miss after Delete")
+	}
+}
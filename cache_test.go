@@ -279,6 +279,79 @@ func TestCacheManager_KeyIncludesParameters(t *testing.T) {
 	}
 }
 
+func TestCacheManager_KeyIncludesN(t *testing.T) {
+	cache := NewCacheManager(testutil.NewMockKVS(), DefaultCacheConfig())
+
+	req := func(n *int) *provider.ChatCompletionRequest {
+		return &provider.ChatCompletionRequest{
+			Model:    "gpt-4o",
+			Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+			N:        n,
+		}
+	}
+	one, three := 1, 3
+
+	if cache.BuildCacheKey(req(nil)) != cache.BuildCacheKey(req(&one)) {
+		t.Error("N=1 should share the key of a request without N")
+	}
+	if cache.BuildCacheKey(req(nil)) == cache.BuildCacheKey(req(&three)) {
+		t.Error("N=3 should not share the key of a single-choice request")
+	}
+}
+
+func TestCacheManager_KeyIncludesOutputAffectingFields(t *testing.T) {
+	base := func() *provider.ChatCompletionRequest {
+		return &provider.ChatCompletionRequest{
+			Model:    "gpt-4o",
+			Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+		}
+	}
+	variants := map[string]func(*provider.ChatCompletionRequest){
+		"tools": func(r *provider.ChatCompletionRequest) {
+			r.Tools = []provider.Tool{{Type: provider.ToolTypeFunction, Function: provider.ToolSpec{Name: "lookup"}}}
+		},
+		"tool_choice": func(r *provider.ChatCompletionRequest) { r.ToolChoice = "required" },
+		"response_format": func(r *provider.ChatCompletionRequest) {
+			r.ResponseFormat = &provider.ResponseFormat{Type: provider.ResponseFormatJSONObject}
+		},
+		"thinking": func(r *provider.ChatCompletionRequest) { r.Thinking = &provider.ThinkingConfig{BudgetTokens: 2048} },
+		"provider_options": func(r *provider.ChatCompletionRequest) {
+			r.ProviderOptions = map[string]any{"openai": map[string]any{"reasoning_effort": "high"}}
+		},
+		"tool_calls": func(r *provider.ChatCompletionRequest) {
+			r.Messages[0].ToolCalls = []provider.ToolCall{{ID: "call_1", Type: "function"}}
+		},
+	}
+
+	cache := NewCacheManager(testutil.NewMockKVS(), DefaultCacheConfig())
+	legacyConfig := DefaultCacheConfig()
+	legacyConfig.LegacyKeys = true
+	legacy := NewCacheManager(testutil.NewMockKVS(), legacyConfig)
+
+	if cache.BuildCacheKey(base()) != legacy.BuildCacheKey(base()) {
+		t.Error("requests without the new fields should keep their legacy keys")
+	}
+	for name, apply := range variants {
+		req := base()
+		apply(req)
+		if cache.BuildCacheKey(req) == cache.BuildCacheKey(base()) {
+			t.Errorf("%s should change the cache key", name)
+		}
+		if legacy.BuildCacheKey(req) != legacy.BuildCacheKey(base()) {
+			t.Errorf("%s should not change the legacy cache key", name)
+		}
+	}
+
+	excludeConfig := DefaultCacheConfig()
+	excludeConfig.ExcludeParameters = []string{"user", "tools"}
+	exclude := NewCacheManager(testutil.NewMockKVS(), excludeConfig)
+	req := base()
+	variants["tools"](req)
+	if exclude.BuildCacheKey(req) != exclude.BuildCacheKey(base()) {
+		t.Error("excluded parameters should not change the cache key")
+	}
+}
+
 func TestCacheManager_KeyExcludesTemperatureWhenConfigured(t *testing.T) {
 	config := CacheConfig{
 		IncludeTemperature: false,
@@ -313,6 +386,97 @@ func TestCacheManager_KeyExcludesTemperatureWhenConfigured(t *testing.T) {
 	}
 }
 
+func TestCacheManager_Stats(t *testing.T) {
+	kvs := testutil.NewMockKVS()
+	var events []CacheEventType
+	config := DefaultCacheConfig()
+	config.TTL = 20 * time.Millisecond
+	config.OnEvent = func(e CacheEvent) { events = append(events, e.Type) }
+	cache := NewCacheManager(kvs, config)
+	ctx := context.Background()
+
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: "user", Content: "Hello"}}}
+	resp := &provider.ChatCompletionResponse{ID: "resp-123"}
+
+	if entry, _ := cache.Get(ctx, req); entry != nil {
+		t.Fatal("expected a miss")
+	}
+	if err := cache.Set(ctx, req, resp); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if entry, _ := cache.Get(ctx, req); entry == nil {
+		t.Fatal("expected a hit")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if entry, _ := cache.Get(ctx, req); entry != nil {
+		t.Fatal("expected the entry to expire")
+	}
+	if err := kvs.SetString(ctx, cache.BuildCacheKey(req), "{not json"); err != nil {
+		t.Fatalf("SetString failed: %v", err)
+	}
+	if entry, _ := cache.Get(ctx, req); entry != nil {
+		t.Fatal("expected a corrupt entry to be ignored")
+	}
+
+	stats := cache.Stats()
+	want := CacheStats{Hits: 1, Misses: 1, Expired: 1, Errors: 1, Sets: 1}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+	if stats.Lookups() != 3 || stats.HitRate() != 1.0/3 {
+		t.Errorf("unexpected lookups %d and hit rate %g", stats.Lookups(), stats.HitRate())
+	}
+
+	wantEvents := []CacheEventType{CacheEventMiss, CacheEventSet, CacheEventHit, CacheEventExpired, CacheEventError}
+	if len(events) != len(wantEvents) {
+		t.Fatalf("events = %v, want %v", events, wantEvents)
+	}
+	for i := range wantEvents {
+		if events[i] != wantEvents[i] {
+			t.Errorf("event %d = %s, want %s", i, events[i], wantEvents[i])
+		}
+	}
+
+	cache.ResetStats()
+	if stats := cache.Stats(); stats != (CacheStats{}) || stats.HitRate() != 0 {
+		t.Errorf("expected zero stats after reset, got %+v", stats)
+	}
+}
+
+func TestCacheManager_TTLByModel(t *testing.T) {
+	config := DefaultCacheConfig()
+	config.TTLByModel = map[string]time.Duration{"extractor": 72 * time.Hour, "zero": 0}
+	cache := NewCacheManager(testutil.NewMockKVS(), config)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		model string
+		want  time.Duration
+	}{
+		{"listed model", ctx, "extractor", 72 * time.Hour},
+		{"unlisted model", ctx, "writer", time.Hour},
+		{"zero override", ctx, "zero", time.Hour},
+		{"context override", WithCacheTTL(ctx, 5*time.Minute), "extractor", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &provider.ChatCompletionRequest{Model: tt.model, Messages: []provider.Message{{Role: "user", Content: tt.name}}}
+			if err := cache.Set(tt.ctx, req, &provider.ChatCompletionResponse{}); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			entry, _ := cache.Get(ctx, req)
+			if entry == nil {
+				t.Fatal("expected a cached entry")
+			}
+			if got := entry.ExpiresAt.Sub(entry.CachedAt); got != tt.want {
+				t.Errorf("TTL = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCacheEntry_IsExpired(t *testing.T) {
 	now := time.Now()
 
@@ -0,0 +1,39 @@
+package omnillm
+
+import "github.com/plexusone/omnillm/provider"
+
+// providerWrapper is implemented by the wrappers NewClient adds around each
+// configured provider, so capability checks can reach the provider beneath
+type providerWrapper interface {
+	unwrapProvider() provider.Provider
+}
+
+// unwrapProvider returns the provider beneath any NewClient wrappers
+func unwrapProvider(p provider.Provider) provider.Provider {
+	for {
+		w, ok := p.(providerWrapper)
+		if !ok {
+			return p
+		}
+		p = w.unwrapProvider()
+	}
+}
+
+// checkCapabilities checks req against the capabilities of the provider
+// beneath p's wrappers, returning nil if that provider does not report them
+func checkCapabilities(p provider.Provider, req *provider.ChatCompletionRequest, stream bool) error {
+	return provider.CheckCapabilities(unwrapProvider(p), req, stream)
+}
+
+// Capabilities returns the request features the primary provider supports,
+// and false if it does not report them
+func (c *ChatClient) Capabilities() (Capabilities, bool) {
+	if len(c.providers) == 0 {
+		return Capabilities{}, false
+	}
+	cp, ok := unwrapProvider(c.providers[0]).(provider.CapabilityProvider)
+	if !ok {
+		return Capabilities{}, false
+	}
+	return cp.Capabilities(), true
+}
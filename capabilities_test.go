@@ -0,0 +1,121 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// capableProvider is a mock provider that reports caps
+type capableProvider struct {
+	*mockProvider
+	caps Capabilities
+}
+
+func (p *capableProvider) Capabilities() Capabilities {
+	return p.caps
+}
+
+func logprobsRequest() *provider.ChatCompletionRequest {
+	logprobs := true
+	return &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hello"}},
+		Logprobs: &logprobs,
+	}
+}
+
+func TestClient_RejectsUnsupportedCapabilities(t *testing.T) {
+	primary := &capableProvider{mockProvider: newMockProvider("primary"), caps: Capabilities{Streaming: true}}
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: primary, ModelMap: map[string]string{"alias": "test-model"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), logprobsRequest())
+	var capErr *CapabilityError
+	if !errors.As(err, &capErr) || capErr.Provider != "primary" || capErr.Missing[0] != "logprobs" {
+		t.Fatalf("CreateChatCompletion() = %v, want logprobs CapabilityError", err)
+	}
+	if primary.callCount != 0 {
+		t.Error("expected the request not to reach the provider")
+	}
+
+	if caps, ok := client.Capabilities(); !ok || caps != primary.caps {
+		t.Errorf("Capabilities() = %+v, %v", caps, ok)
+	}
+
+	// SkipCapabilities leaves the request to the provider
+	client, err = NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: primary}},
+		RequestValidation: &RequestValidationConfig{SkipCapabilities: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), logprobsRequest()); err != nil {
+		t.Errorf("expected SkipCapabilities to send the request, got %v", err)
+	}
+}
+
+func TestFallbackProvider_SkipsUnsupportedCapabilities(t *testing.T) {
+	primary := &capableProvider{mockProvider: newMockProvider("primary")}
+	fallback := &capableProvider{mockProvider: newMockProvider("fallback"), caps: Capabilities{Logprobs: true}}
+	fp := NewFallbackProvider(primary, []provider.Provider{fallback}, nil)
+
+	resp, err := fp.CreateChatCompletion(context.Background(), logprobsRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if resp.ID != "mock-response-fallback" || primary.callCount != 0 {
+		t.Errorf("expected the request to skip primary, got %s after %d primary calls", resp.ID, primary.callCount)
+	}
+
+	// No provider supports streaming
+	_, err = fp.CreateChatCompletionStream(context.Background(), logprobsRequest())
+	var fbErr *FallbackError
+	if !errors.As(err, &fbErr) || !errors.Is(err, ErrUnsupportedCapability) || len(fbErr.Attempts) != 2 || !fbErr.Attempts[1].Skipped {
+		t.Errorf("CreateChatCompletionStream() = %v, want FallbackError of skipped attempts", err)
+	}
+}
+
+func TestRouterProvider_SkipsUnsupportedCapabilities(t *testing.T) {
+	incapable := &capableProvider{mockProvider: newMockProvider("incapable")}
+	capable := &capableProvider{mockProvider: newMockProvider("capable"), caps: Capabilities{Logprobs: true}}
+	router := NewRouterProvider(RouterConfig{
+		Targets: []RouteTarget{{Provider: incapable}, {Provider: capable}},
+	})
+
+	resp, err := router.CreateChatCompletion(context.Background(), logprobsRequest())
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if got := resp.ProviderMetadata["route_provider"]; got != "capable" {
+		t.Errorf("route_provider = %v, want capable", got)
+	}
+}
+
+func TestClient_RejectsFileSearchWithoutSupport(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Search my files"}},
+		Tools:    []provider.Tool{{Type: ToolTypeFileSearch, FileSearch: &provider.FileSearchTool{VectorStoreIDs: []string{"vs_1"}}}},
+	}
+
+	for _, name := range []ProviderName{ProviderNameAnthropic, ProviderNameXAI, ProviderNameOllama} {
+		client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{Provider: name, APIKey: "test-key"}}})
+		if err != nil {
+			t.Fatalf("%s: NewClient failed: %v", name, err)
+		}
+		_, err = client.CreateChatCompletion(context.Background(), req)
+		var capErr *CapabilityError
+		if !errors.As(err, &capErr) || len(capErr.Missing) != 1 || capErr.Missing[0] != "file_search" {
+			t.Errorf("%s: CreateChatCompletion() = %v, want file_search CapabilityError", name, err)
+		}
+		client.Close()
+	}
+}
@@ -0,0 +1,112 @@
+package omnillm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultChatCompletionsConcurrency is the default limit on requests in
+// flight in CreateChatCompletions
+const DefaultChatCompletionsConcurrency = 8
+
+// defaultChatCompletionsBackoff is the default wait before the first retry
+const defaultChatCompletionsBackoff = time.Second
+
+// ChatCompletionsOptions configures CreateChatCompletions
+type ChatCompletionsOptions struct {
+	// MaxConcurrency limits how many requests are in flight at once.
+	// Default: DefaultChatCompletionsConcurrency
+	MaxConcurrency int
+
+	// MaxRetries is how many times a request is retried after a retryable
+	// error. Default: 0 (no retries)
+	MaxRetries int
+
+	// RetryBackoff is the wait before a request's first retry, doubled for
+	// each retry after it. A Retry-After from the provider takes precedence.
+	// Default: 1s
+	RetryBackoff time.Duration
+}
+
+// ChatCompletionResult is the outcome of one request in CreateChatCompletions
+type ChatCompletionResult struct {
+	// Response is the response, or nil if the request failed
+	Response *provider.ChatCompletionResponse
+
+	// Err is the error from the last attempt, or nil on success
+	Err error
+
+	// Attempts is the number of times the request was sent
+	Attempts int
+}
+
+// CreateChatCompletions sends requests concurrently, each through
+// CreateChatCompletion, and returns their results in the order of reqs. A
+// failed request does not stop the others; its error is reported in its
+// result. This runs on the client, unlike provider batch APIs (see
+// CreateBatch), so results arrive in seconds but at full price.
+//
+// Each request gets its own idempotency key, shared by its retries, derived
+// from the key in ctx if there is one.
+func (c *ChatClient) CreateChatCompletions(ctx context.Context, reqs []*provider.ChatCompletionRequest, opts *ChatCompletionsOptions) []ChatCompletionResult {
+	if opts == nil {
+		opts = &ChatCompletionsOptions{}
+	}
+	limit := opts.MaxConcurrency
+	if limit <= 0 {
+		limit = DefaultChatCompletionsConcurrency
+	}
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultChatCompletionsBackoff
+	}
+
+	results := make([]ChatCompletionResult, len(reqs))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			itemCtx := ensureIdempotencyKey(deriveIdempotencyKey(ctx, fmt.Sprintf("item-%d", i)))
+			results[i] = c.completeWithRetries(itemCtx, req, opts.MaxRetries, backoff)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// completeWithRetries sends req, retrying retryable errors up to maxRetries
+// times with exponential backoff
+func (c *ChatClient) completeWithRetries(ctx context.Context, req *provider.ChatCompletionRequest, maxRetries int, backoff time.Duration) ChatCompletionResult {
+	var result ChatCompletionResult
+	for {
+		result.Attempts++
+		result.Response, result.Err = c.CreateChatCompletion(ctx, req)
+		if result.Err == nil || result.Attempts > maxRetries || !IsRetryableError(result.Err) || ctx.Err() != nil {
+			return result
+		}
+
+		wait := backoff
+		if retryAfter, ok := RetryAfter(result.Err); ok {
+			wait = retryAfter
+		}
+		backoff *= 2
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// echoProvider replies with each request's last message. Requests for
+// "bad" fail with a 400, and requests for "flaky" fail with a 500 on their
+// first attempt.
+type echoProvider struct {
+	*mockProvider
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	attempts    map[string]int
+	keys        map[string][]string
+}
+
+func newEchoProvider() *echoProvider {
+	return &echoProvider{
+		mockProvider: newMockProvider("echo"),
+		attempts:     make(map[string]int),
+		keys:         make(map[string][]string),
+	}
+}
+
+func (p *echoProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	content := req.Messages[len(req.Messages)-1].Content
+
+	p.mu.Lock()
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	p.attempts[content]++
+	attempt := p.attempts[content]
+	p.keys[content] = append(p.keys[content], IdempotencyKeyFromContext(ctx))
+	p.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	p.mu.Lock()
+	p.inFlight--
+	p.mu.Unlock()
+
+	switch {
+	case content == "bad":
+		return nil, NewAPIError("echo", 400, "bad request", "invalid_request_error", "")
+	case content == "flaky" && attempt == 1:
+		return nil, NewAPIError("echo", 500, "server error", "server_error", "")
+	}
+	return mocktest.TextResponse(content), nil
+}
+
+func TestChatClient_CreateChatCompletions(t *testing.T) {
+	echo := newEchoProvider()
+	c, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: echo}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	contents := []string{"a", "bad", "flaky", "b", "c", "d"}
+	reqs := make([]*provider.ChatCompletionRequest, len(contents))
+	for i, content := range contents {
+		reqs[i] = &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: content}}}
+	}
+
+	results := c.CreateChatCompletions(context.Background(), reqs, &ChatCompletionsOptions{
+		MaxConcurrency: 2,
+		MaxRetries:     2,
+		RetryBackoff:   time.Millisecond,
+	})
+
+	if len(results) != len(reqs) {
+		t.Fatalf("expected %d results, got %d", len(reqs), len(results))
+	}
+	for i, content := range contents {
+		r := results[i]
+		if content == "bad" {
+			if !errors.Is(r.Err, ErrInvalidRequest) || r.Attempts != 1 {
+				t.Errorf("result %d: expected one attempt failing with ErrInvalidRequest, got %+v", i, r)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("result %d: unexpected error %v", i, r.Err)
+			continue
+		}
+		if got := r.Response.Choices[0].Message.Content; got != content {
+			t.Errorf("result %d: expected %q in order, got %q", i, content, got)
+		}
+	}
+	if r := results[2]; r.Attempts != 2 {
+		t.Errorf("expected the flaky request to succeed on its second attempt, took %d", r.Attempts)
+	}
+	if echo.maxInFlight > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", echo.maxInFlight)
+	}
+
+	flakyKeys := echo.keys["flaky"]
+	if len(flakyKeys) != 2 || flakyKeys[0] == "" || flakyKeys[0] != flakyKeys[1] {
+		t.Errorf("expected retries to share an idempotency key, got %v", flakyKeys)
+	}
+	if echo.keys["a"][0] == echo.keys["b"][0] {
+		t.Error("expected a distinct idempotency key per request")
+	}
+}
+
+func TestChatClient_CreateChatCompletionsDerivesKeys(t *testing.T) {
+	echo := newEchoProvider()
+	c, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: echo}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	reqs := []*provider.ChatCompletionRequest{
+		{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "a"}}},
+		{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "b"}}},
+	}
+	c.CreateChatCompletions(WithIdempotencyKey(context.Background(), "job-7"), reqs, nil)
+
+	for i, content := range []string{"a", "b"} {
+		if want := fmt.Sprintf("job-7-item-%d", i); len(echo.keys[content]) != 1 || echo.keys[content][0] != want {
+			t.Errorf("request %d: expected key %q, got %v", i, want, echo.keys[content])
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package omnillm
 
 import (
+	"context"
 	"sync"
 	"time"
+
+	"github.com/grokify/sogo/database/kvs"
 )
 
 // CircuitState represents the state of a circuit breaker
@@ -54,6 +57,26 @@ type CircuitBreakerConfig struct {
 	// MinimumRequests is the minimum number of requests before failure rate is evaluated.
 	// Default: 10
 	MinimumRequests int
+
+	// Store shares circuit state between replicas through a KVS (optional).
+	// When a named circuit opens or closes, the change is written to the
+	// store, and other replicas follow it the next time they read the store,
+	// so failures seen by one replica open the circuit on all of them.
+	// FallbackProvider names its breakers after the providers. Store errors
+	// are ignored and the breaker keeps working locally.
+	Store kvs.Client
+
+	// StoreKeyPrefix prefixes store keys.
+	// Default: "omnillm:circuit"
+	StoreKeyPrefix string
+
+	// SyncInterval is the minimum time between store reads per breaker.
+	// Default: 5 seconds
+	SyncInterval time.Duration
+
+	// StoreTimeout bounds each store read or write.
+	// Default: 1 second
+	StoreTimeout time.Duration
 }
 
 // DefaultCircuitBreakerConfig returns a CircuitBreakerConfig with sensible defaults
@@ -85,11 +108,31 @@ type CircuitBreaker struct {
 	// Timing
 	lastFailure     time.Time
 	lastStateChange time.Time
+
+	// Shared state, used when config.Store is set and the breaker is named
+	name     string
+	lastSync time.Time
+}
+
+// sharedCircuitState is the circuit state stored in CircuitBreakerConfig.Store
+type sharedCircuitState struct {
+	State       CircuitState `json:"state"`
+	LastFailure time.Time    `json:"last_failure"`
+	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
 // NewCircuitBreaker creates a new circuit breaker with the given configuration.
 // If config has zero values, defaults are used for those fields.
+// The breaker does not use config.Store; see NewSharedCircuitBreaker.
 func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	return NewSharedCircuitBreaker("", config)
+}
+
+// NewSharedCircuitBreaker creates a circuit breaker whose state is shared
+// through config.Store under name. Breakers with the same name and store
+// follow each other's state. If name is empty or config.Store is nil, the
+// breaker is local.
+func NewSharedCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker {
 	// Apply defaults for zero values
 	if config.FailureThreshold == 0 {
 		config.FailureThreshold = 5
@@ -106,11 +149,24 @@ func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
 	if config.MinimumRequests == 0 {
 		config.MinimumRequests = 10
 	}
+	if config.StoreKeyPrefix == "" {
+		config.StoreKeyPrefix = "omnillm:circuit"
+	}
+	if config.SyncInterval == 0 {
+		config.SyncInterval = 5 * time.Second
+	}
+	if config.StoreTimeout == 0 {
+		config.StoreTimeout = time.Second
+	}
+	if config.Store == nil {
+		name = ""
+	}
 
 	return &CircuitBreaker{
 		config:          config,
 		state:           CircuitClosed,
 		lastStateChange: time.Now(),
+		name:            name,
 	}
 }
 
@@ -118,6 +174,8 @@ func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
 // In closed state, always allows. In open state, allows only after timeout.
 // In half-open state, allows a limited number of test requests.
 func (cb *CircuitBreaker) AllowRequest() bool {
+	cb.syncFromStore()
+
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
@@ -197,6 +255,16 @@ func (cb *CircuitBreaker) RecordFailure() {
 	}
 }
 
+// Trip opens the circuit immediately, as if the failure threshold had been
+// reached. The circuit moves to half-open after the configured timeout.
+func (cb *CircuitBreaker) Trip() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.lastFailure = time.Now()
+	cb.transitionTo(CircuitOpen)
+}
+
 // State returns the current state of the circuit breaker
 func (cb *CircuitBreaker) State() CircuitState {
 	cb.mu.RLock()
@@ -239,12 +307,21 @@ func (cb *CircuitBreaker) Stats() CircuitBreakerStats {
 	}
 }
 
-// transitionTo changes the circuit state (must be called with lock held)
+// transitionTo changes the circuit state and shares open and closed states
+// through the store (must be called with lock held)
 func (cb *CircuitBreaker) transitionTo(newState CircuitState) {
 	if cb.state == newState {
 		return
 	}
 
+	cb.setState(newState)
+	if newState != CircuitHalfOpen {
+		cb.publish()
+	}
+}
+
+// setState changes the circuit state without sharing it (must be called with lock held)
+func (cb *CircuitBreaker) setState(newState CircuitState) {
 	cb.state = newState
 	cb.lastStateChange = time.Now()
 
@@ -262,6 +339,62 @@ func (cb *CircuitBreaker) transitionTo(newState CircuitState) {
 	}
 }
 
+// storeKey returns the store key for the breaker
+func (cb *CircuitBreaker) storeKey() string {
+	return cb.config.StoreKeyPrefix + ":" + cb.name
+}
+
+// publish writes the current state to the store (must be called with lock held)
+func (cb *CircuitBreaker) publish() {
+	if cb.name == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cb.config.StoreTimeout)
+	defer cancel()
+	_ = cb.config.Store.SetAny(ctx, cb.storeKey(), sharedCircuitState{
+		State:       cb.state,
+		LastFailure: cb.lastFailure,
+		UpdatedAt:   cb.lastStateChange,
+	})
+}
+
+// syncFromStore follows a state change made by another replica, reading the
+// store at most once per SyncInterval
+func (cb *CircuitBreaker) syncFromStore() {
+	if cb.name == "" {
+		return
+	}
+
+	cb.mu.Lock()
+	if time.Since(cb.lastSync) < cb.config.SyncInterval {
+		cb.mu.Unlock()
+		return
+	}
+	cb.lastSync = time.Now()
+	cb.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cb.config.StoreTimeout)
+	defer cancel()
+	var shared sharedCircuitState
+	if err := cb.config.Store.GetAny(ctx, cb.storeKey(), &shared); err != nil {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch {
+	case shared.State == CircuitOpen && cb.state != CircuitOpen &&
+		shared.LastFailure.After(cb.lastFailure) && time.Since(shared.LastFailure) < cb.config.Timeout:
+		// Another replica opened the circuit
+		cb.lastFailure = shared.LastFailure
+		cb.setState(CircuitOpen)
+	case shared.State == CircuitClosed && cb.state != CircuitClosed &&
+		shared.UpdatedAt.After(cb.lastStateChange):
+		// Another replica saw the provider recover
+		cb.setState(CircuitClosed)
+	}
+}
+
 // CircuitBreakerStats contains statistics about the circuit breaker
 type CircuitBreakerStats struct {
 	State                CircuitState
@@ -3,6 +3,8 @@ package omnillm
 import (
 	"testing"
 	"time"
+
+	mocktest "github.com/plexusone/omnillm/testing"
 )
 
 func TestCircuitBreaker_InitialState(t *testing.T) {
@@ -263,3 +265,58 @@ func TestCircuitOpenError(t *testing.T) {
 		t.Errorf("expected error %q, got %q", expected, err.Error())
 	}
 }
+
+func TestCircuitBreaker_SharedState(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 2,
+		SuccessThreshold: 1,
+		Timeout:          50 * time.Millisecond,
+		Store:            mocktest.NewMockKVS(),
+		SyncInterval:     time.Nanosecond,
+	}
+	replicaA := NewSharedCircuitBreaker("openai", config)
+	replicaB := NewSharedCircuitBreaker("openai", config)
+	other := NewSharedCircuitBreaker("anthropic", config)
+
+	replicaA.RecordFailure()
+	replicaA.RecordFailure()
+	if replicaA.State() != CircuitOpen {
+		t.Fatalf("expected replica A open, got %s", replicaA.State())
+	}
+
+	// Replica B follows without seeing any failures itself
+	if replicaB.AllowRequest() {
+		t.Error("expected replica B to reject requests after replica A opened")
+	}
+	if replicaB.State() != CircuitOpen {
+		t.Errorf("expected replica B open, got %s", replicaB.State())
+	}
+	if !other.AllowRequest() {
+		t.Error("expected a breaker with another name to be unaffected")
+	}
+
+	// Replica A recovers and closes; replica B follows
+	time.Sleep(60 * time.Millisecond)
+	if !replicaA.AllowRequest() {
+		t.Fatal("expected replica A half-open after timeout")
+	}
+	replicaA.RecordSuccess()
+	if replicaA.State() != CircuitClosed {
+		t.Fatalf("expected replica A closed, got %s", replicaA.State())
+	}
+	if !replicaB.AllowRequest() || replicaB.State() != CircuitClosed {
+		t.Errorf("expected replica B closed, got %s", replicaB.State())
+	}
+}
+
+func TestCircuitBreaker_UnnamedIsLocal(t *testing.T) {
+	store := mocktest.NewMockKVS()
+	config := CircuitBreakerConfig{FailureThreshold: 1, Store: store, SyncInterval: time.Nanosecond}
+
+	a := NewCircuitBreaker(config)
+	b := NewCircuitBreaker(config)
+	a.RecordFailure()
+	if !b.AllowRequest() {
+		t.Error("expected breakers created without a name not to share state")
+	}
+}
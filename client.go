@@ -1,10 +1,13 @@
 package omnillm
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
-	"strings"
+	"net/http"
 	"time"
 
 	"github.com/grokify/mogo/log/slogutil"
@@ -16,12 +19,41 @@ import (
 // ChatClient is the main client interface that wraps a Provider
 type ChatClient struct {
 	provider       provider.Provider
+	providers      []provider.Provider
 	memory         *MemoryManager
 	cache          *CacheManager
 	tokenEstimator TokenEstimator
 	validateTokens bool
 	hook           ObservabilityHook
 	logger         *slog.Logger
+
+	moderation       provider.ModerationProvider
+	moderationConfig ModerationConfig
+	reranker         provider.RerankProvider
+	rerankConfig     RerankConfig
+	fim              provider.FIMProvider
+	fimConfig        FIMConfig
+	textCompletions  provider.TextCompletionProvider
+	files            provider.FileProvider
+	vectorStores     provider.VectorStoreProvider
+	batches          provider.BatchProvider
+	realtime         provider.RealtimeProvider
+	modelListers     []provider.ModelLister
+	outputGuardrails *OutputGuardrailConfig
+	healthConfig     HealthCheckConfig
+	healthProber     *healthProber
+	usage            *UsageTracker
+	scheduler        *Scheduler
+	tokenLimiter     *TokenRateLimiter
+	inflight         inflightTracker
+	timeouts         *TimeoutConfig
+	validator        *requestValidator
+	transport        *http.Transport // Shared by providers, or nil
+	maxRequestBytes  int64
+
+	systemPrompt       string
+	systemPromptPolicy SystemPromptPolicy
+	promptCompression  PromptCompressionConfig
 }
 
 // ClientConfig holds configuration for creating a client
@@ -48,13 +80,47 @@ type ClientConfig struct {
 	// When enabled, providers that fail repeatedly are temporarily skipped.
 	CircuitBreakerConfig *CircuitBreakerConfig
 
+	// ErrorStatusOverrides reclassifies provider API errors by HTTP status
+	// when deciding whether to fall back (optional). See
+	// FallbackProviderConfig.StatusOverrides and RegisterErrorClassifier.
+	ErrorStatusOverrides map[int]ErrorCategory
+
+	// FallbackOnEmptyStream makes a stream that fails or ends before its
+	// first chunk fall back to the next provider (optional). See
+	// FallbackProviderConfig.FallbackOnEmptyStream.
+	FallbackOnEmptyStream bool
+
+	// TransportConfig gives every provider that creates its own HTTP client
+	// one shared, tuned transport (optional). If nil, each provider uses
+	// net/http's default transport, which keeps only 2 idle connections
+	// per host.
+	TransportConfig *TransportConfig
+
+	// SizeLimits caps the size of requests and of provider responses,
+	// failing with a SizeLimitError when one is exceeded (optional).
+	// If nil, sizes are not limited.
+	SizeLimits *SizeLimitConfig
+
+	// StreamResume enables mid-stream fallback: a stream that fails partway
+	// is continued on the next fallback provider. If nil (default), the
+	// stream error is returned to the caller.
+	StreamResume *StreamResumeConfig
+
 	// Memory configuration (optional)
 	Memory       kvs.Client
 	MemoryConfig *MemoryConfig
 
+	// MemoryStore stores conversations in place of Memory, for backends
+	// other than a KVS (optional). It takes precedence over Memory.
+	MemoryStore ConversationStore
+
 	// ObservabilityHook is called before/after LLM calls (optional)
 	ObservabilityHook ObservabilityHook
 
+	// ObservabilityHooks are additional hooks composed after ObservabilityHook
+	// (optional). See ComposeHooks for the call ordering.
+	ObservabilityHooks []ObservabilityHook
+
 	// Logger for internal logging (optional, defaults to null logger)
 	Logger *slog.Logger
 
@@ -76,6 +142,73 @@ type ClientConfig struct {
 	// CacheConfig configures response caching behavior.
 	// If nil, DefaultCacheConfig() is used when Cache is provided.
 	CacheConfig *CacheConfig
+
+	// ModerationConfig configures content moderation (optional).
+	// If nil, moderation uses the first configured provider that supports it.
+	ModerationConfig *ModerationConfig
+
+	// RerankConfig configures document reranking (optional).
+	// If nil, reranking uses the first configured provider that supports it.
+	RerankConfig *RerankConfig
+
+	// FIMConfig configures fill-in-the-middle code completion (optional).
+	// If nil, FIM uses the first configured provider that supports it.
+	FIMConfig *FIMConfig
+
+	// OutputGuardrails validates non-streaming responses before they are
+	// returned or cached (optional)
+	OutputGuardrails *OutputGuardrailConfig
+
+	// HealthCheck configures provider health probes and optional background
+	// probing (optional). HealthCheck works without it, using list-models probes.
+	HealthCheck *HealthCheckConfig
+
+	// UsageTracker accumulates usage per provider, model, and tenant for
+	// UsageReport (optional). It is added as an observability hook.
+	UsageTracker *UsageTracker
+
+	// SchedulerConfig caps in-flight requests per provider and queues the
+	// excess by priority (optional). Set a request's priority with WithPriority.
+	SchedulerConfig *SchedulerConfig
+
+	// TokenRateLimit debits each request's estimated tokens from a
+	// per-model tokens-per-minute budget before it is sent, waiting while
+	// the budget is spent, and reconciles with the reported usage (optional)
+	TokenRateLimit *TokenRateLimitConfig
+
+	// Timeouts sets deadlines for the whole request, the first streamed
+	// token, and each fallback attempt (optional). WithTimeouts overrides it
+	// per request.
+	Timeouts *TimeoutConfig
+
+	// RequestValidation configures the checks run on each request before it
+	// is sent to a provider. If nil, requests are checked with
+	// provider.ValidateRequest and against the model's capabilities in
+	// DefaultModelCatalog(), after the primary provider's ModelMap and
+	// DefaultModel are applied.
+	RequestValidation *RequestValidationConfig
+
+	// DefaultSystemPrompt is added to every request, including those built
+	// from conversation memory (optional). SystemPromptPolicy decides how it
+	// combines with system messages the request already has.
+	DefaultSystemPrompt string
+
+	// SystemPromptPolicy merges DefaultSystemPrompt with a request's own
+	// system messages. Default: SystemPromptPrepend
+	SystemPromptPolicy SystemPromptPolicy
+
+	// PromptCompression compresses prompts before they are sent, to cut the
+	// tokens spent on verbose contexts (optional). WithPromptCompression
+	// overrides it per request.
+	PromptCompression *PromptCompressionConfig
+
+	// ValidateOnStartup makes NewClient probe every provider with a cheap
+	// authenticated call, as HealthCheck does, and fail with an error
+	// matching ErrProviderValidation if one is rejected, so a misconfigured
+	// key fails at startup instead of on the first request. Lazy providers
+	// are initialized by the check. Providers that cannot be probed pass.
+	// Default: false
+	ValidateOnStartup bool
 }
 
 // NewClient creates a new ChatClient based on the provider
@@ -91,49 +224,193 @@ func NewClient(config ClientConfig) (*ChatClient, error) {
 		return nil, ErrNoProviders
 	}
 
+	// Share one tuned transport across providers
+	var transport *http.Transport
+	if config.TransportConfig != nil {
+		t, err := NewTransport(*config.TransportConfig)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+		config.Providers = withTransport(config.Providers, transport)
+	}
+
+	// Apply per-provider query parameters, proxies, and authenticators
+	providers, err := withGateway(config.Providers)
+	if err != nil {
+		return nil, err
+	}
+	config.Providers = providers
+
+	// Cap request sizes here and response sizes in each provider's HTTP client
+	var maxRequestBytes int64
+	if limits := config.SizeLimits; limits != nil {
+		maxRequestBytes = limits.MaxRequestBytes
+		if limits.MaxResponseBytes > 0 {
+			config.Providers = withResponseLimit(config.Providers, limits.MaxResponseBytes)
+		}
+	}
+
+	// Build providers, deferring lazy ones to their first request
+	var healthConfig HealthCheckConfig
+	if config.HealthCheck != nil {
+		healthConfig = *config.HealthCheck
+	}
+	build := func(pc ProviderConfig) (provider.Provider, error) {
+		if pc.Lazy {
+			return newLazyProvider(pc, healthConfig), nil
+		}
+		return buildProviderFromConfig(pc)
+	}
+
 	// Build the primary provider from Providers[0]
 	primaryConfig := config.Providers[0]
-	prov, err := buildProviderFromConfig(primaryConfig)
+	prov, err := build(primaryConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create primary provider (%s): %w",
 			primaryConfig.Provider, err)
 	}
 
+	built := []provider.Provider{prov}
+
+	// Remap models and route requests through the token rate limiter and
+	// scheduler, keeping the unwrapped providers in built for capability
+	// discovery
+	var scheduler *Scheduler
+	if config.SchedulerConfig != nil {
+		scheduler = NewScheduler(*config.SchedulerConfig)
+	}
+	var tokenLimiter *TokenRateLimiter
+	if config.TokenRateLimit != nil {
+		tlConfig := *config.TokenRateLimit
+		if tlConfig.Estimator == nil {
+			tlConfig.Estimator = config.TokenEstimator
+		}
+		tokenLimiter = NewTokenRateLimiter(tlConfig)
+	}
+	wrap := func(p provider.Provider, pc ProviderConfig) provider.Provider {
+		if tokenLimiter != nil {
+			p = &tokenLimitedProvider{Provider: p, limiter: tokenLimiter}
+		}
+		p = NewModelMapProvider(p, pc.ModelMap, pc.DefaultModel)
+		if scheduler != nil {
+			p = &scheduledProvider{Provider: p, scheduler: scheduler}
+		}
+		return p
+	}
+	prov = wrap(prov, primaryConfig)
+
 	// Wrap with fallback provider if more than one provider is configured
 	if len(config.Providers) > 1 {
 		fallbacks := make([]provider.Provider, 0, len(config.Providers)-1)
 		for i, fbConfig := range config.Providers[1:] {
-			fb, err := buildProviderFromConfig(fbConfig)
+			fb, err := build(fbConfig)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create fallback provider %d (%s): %w",
 					i+1, fbConfig.Provider, err)
 			}
-			fallbacks = append(fallbacks, fb)
+			built = append(built, fb)
+			fallbacks = append(fallbacks, wrap(fb, fbConfig))
 		}
 
 		prov = NewFallbackProvider(prov, fallbacks, &FallbackProviderConfig{
-			CircuitBreakerConfig: config.CircuitBreakerConfig,
-			Logger:               logger,
+			CircuitBreakerConfig:  config.CircuitBreakerConfig,
+			StreamResume:          config.StreamResume,
+			StatusOverrides:       config.ErrorStatusOverrides,
+			FallbackOnEmptyStream: config.FallbackOnEmptyStream,
+			Logger:                logger,
 		})
 	}
 
 	client := &ChatClient{
-		provider:       prov,
-		tokenEstimator: config.TokenEstimator,
-		validateTokens: config.ValidateTokens,
-		hook:           config.ObservabilityHook,
-		logger:         logger,
+		provider:        prov,
+		providers:       built,
+		tokenEstimator:  config.TokenEstimator,
+		validateTokens:  config.ValidateTokens,
+		hook:            ComposeHooks(append([]ObservabilityHook{config.ObservabilityHook}, config.ObservabilityHooks...)...),
+		logger:          logger,
+		usage:           config.UsageTracker,
+		scheduler:       scheduler,
+		tokenLimiter:    tokenLimiter,
+		timeouts:        config.Timeouts,
+		transport:       transport,
+		maxRequestBytes: maxRequestBytes,
+
+		systemPrompt:       config.DefaultSystemPrompt,
+		systemPromptPolicy: config.SystemPromptPolicy,
+	}
+	if config.PromptCompression != nil {
+		client.promptCompression = *config.PromptCompression
+	}
+
+	// Initialize request validation against the primary provider's models
+	var validation RequestValidationConfig
+	if config.RequestValidation != nil {
+		validation = *config.RequestValidation
+	}
+	client.validator = newRequestValidator(validation, primaryConfig, prov)
+	if config.UsageTracker != nil {
+		client.hook = ComposeHooks(client.hook, config.UsageTracker)
 	}
 
 	// Initialize memory if provided
-	if config.Memory != nil {
+	if config.Memory != nil || config.MemoryStore != nil {
 		memoryConfig := DefaultMemoryConfig()
 		if config.MemoryConfig != nil {
 			memoryConfig = *config.MemoryConfig
 		}
-		client.memory = NewMemoryManager(config.Memory, memoryConfig)
+		if config.MemoryStore != nil {
+			client.memory = NewMemoryManagerWithStore(config.MemoryStore, memoryConfig)
+		} else {
+			client.memory = NewMemoryManager(config.Memory, memoryConfig)
+		}
 	}
 
+	// Initialize moderation
+	if config.ModerationConfig != nil {
+		client.moderationConfig = *config.ModerationConfig
+	}
+	client.moderation = client.moderationConfig.Provider
+	if client.moderation == nil {
+		client.moderation = findCapability[provider.ModerationProvider](built...)
+	}
+
+	// Initialize reranking
+	if config.RerankConfig != nil {
+		client.rerankConfig = *config.RerankConfig
+	}
+	client.reranker = client.rerankConfig.Provider
+	if client.reranker == nil {
+		client.reranker = findCapability[provider.RerankProvider](built...)
+	}
+
+	// Initialize fill-in-the-middle completion
+	if config.FIMConfig != nil {
+		client.fimConfig = *config.FIMConfig
+	}
+	client.fim = client.fimConfig.Provider
+	if client.fim == nil {
+		client.fim = findCapability[provider.FIMProvider](built...)
+	}
+
+	// Initialize output guardrails (after moderation, which they may use)
+	if config.OutputGuardrails != nil {
+		guardrails, err := config.OutputGuardrails.withClient(client)
+		if err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("failed to configure output guardrails: %w", err)
+		}
+		client.outputGuardrails = guardrails
+	}
+
+	// Initialize file storage
+	client.files = findCapability[provider.FileProvider](built...)
+	client.vectorStores = findCapability[provider.VectorStoreProvider](built...)
+	client.batches = findCapability[provider.BatchProvider](built...)
+	client.textCompletions = findCapability[provider.TextCompletionProvider](built...)
+	client.realtime = findCapability[provider.RealtimeProvider](built...)
+	client.modelListers = findCapabilities[provider.ModelLister](built...)
+
 	// Initialize cache if provided
 	if config.Cache != nil {
 		cacheConfig := DefaultCacheConfig()
@@ -143,19 +420,70 @@ func NewClient(config ClientConfig) (*ChatClient, error) {
 		client.cache = NewCacheManager(config.Cache, cacheConfig)
 	}
 
+	// Fail fast on providers that reject their credentials
+	client.healthConfig = healthConfig
+	if config.ValidateOnStartup {
+		if err := client.validateProviders(context.Background()); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+
+	// Initialize health checks, starting background probes if an interval is set
+	if config.HealthCheck != nil {
+		if client.healthConfig.Interval > 0 {
+			client.healthProber = startHealthProber(client, client.healthConfig)
+		}
+	}
+
 	return client, nil
 }
 
 // CreateChatCompletion creates a chat completion
 func (c *ChatClient) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	if err := c.inflight.acquire(); err != nil {
+		return nil, err
+	}
+	defer c.inflight.release()
+
+	req, err := c.applySystemPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validator.validate(req, false); err != nil {
+		return nil, err
+	}
+	ctx = ensureIdempotencyKey(ctx)
+
+	// Check cache first (if enabled)
+	if c.cache != nil && c.cache.ShouldCache(req) {
+		entry, err := c.cache.Get(ctx, req)
+		if err == nil && entry != nil {
+			// Cache hit - add metadata and return
+			if entry.Response.ProviderMetadata == nil {
+				entry.Response.ProviderMetadata = make(map[string]any)
+			}
+			entry.Response.ProviderMetadata[MetadataKeyCacheHit] = true
+			entry.Response.ProviderMetadata[MetadataKeyCachedAt] = entry.CachedAt
+			return entry.Response, nil
+		}
+	}
+
+	// Compress only on a cache miss, so the cache key is computed from the
+	// uncompressed request and cache hits do not pay for compression
+	sent := c.compressPrompt(ctx, req)
+	if err := checkRequestSize(sent, c.maxRequestBytes); err != nil {
+		return nil, err
+	}
+
 	// Token validation (if enabled)
 	if c.validateTokens && c.tokenEstimator != nil {
 		maxTokens := 4096 // Default max completion tokens
-		if req.MaxTokens != nil {
-			maxTokens = *req.MaxTokens
+		if sent.MaxTokens != nil {
+			maxTokens = *sent.MaxTokens
 		}
 
-		validation, err := ValidateTokens(c.tokenEstimator, req.Model, req.Messages, maxTokens)
+		validation, err := ValidateTokens(c.tokenEstimator, sent.Model, sent.Messages, maxTokens)
 		if err != nil {
 			return nil, fmt.Errorf("token validation failed: %w", err)
 		}
@@ -165,76 +493,130 @@ func (c *ChatClient) CreateChatCompletion(ctx context.Context, req *provider.Cha
 				EstimatedTokens: validation.EstimatedTokens,
 				ContextWindow:   validation.ContextWindow,
 				AvailableTokens: validation.AvailableTokens,
-				Model:           req.Model,
+				Model:           sent.Model,
 			}
 		}
 	}
 
-	// Check cache first (if enabled)
-	if c.cache != nil && c.cache.ShouldCache(req) {
-		entry, err := c.cache.Get(ctx, req)
-		if err == nil && entry != nil {
-			// Cache hit - add metadata and return
-			if entry.Response.ProviderMetadata == nil {
-				entry.Response.ProviderMetadata = make(map[string]any)
-			}
-			entry.Response.ProviderMetadata["cache_hit"] = true
-			entry.Response.ProviderMetadata["cached_at"] = entry.CachedAt
-			return entry.Response, nil
+	resp, err := c.callProvider(ctx, sent)
+
+	// Check the output against guardrails before it is returned or cached
+	var flagged bool
+	if err == nil && c.outputGuardrails != nil {
+		resp, flagged, err = c.applyOutputGuardrails(ctx, sent, resp)
+	}
+
+	// Cache the successful response
+	if err == nil && !flagged && c.cache != nil && c.cache.ShouldCache(req) {
+		if cacheErr := c.cache.Set(ctx, req, resp); cacheErr != nil {
+			c.logger.Warn("failed to cache response",
+				slog.String("error", cacheErr.Error()))
 		}
 	}
 
+	return resp, err
+}
+
+// callProvider sends a request to the provider, running the observability hooks
+func (c *ChatClient) callProvider(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
 	info := LLMCallInfo{
 		CallID:       newCallID(),
 		ProviderName: c.provider.Name(),
 		StartTime:    time.Now(),
 	}
 
+	ctx, timeouts, cancel := c.withRequestTimeouts(ctx)
+	defer cancel()
+
 	// Hook: before request
 	if c.hook != nil {
 		ctx = c.hook.BeforeRequest(ctx, info, req)
 	}
 
-	resp, err := c.provider.CreateChatCompletion(ctx, req)
+	var resp *provider.ChatCompletionResponse
+	var err error
+	if managesAttempts(c.provider) {
+		resp, err = c.provider.CreateChatCompletion(ctx, req)
+	} else {
+		resp, err = completeWithTimeouts(ctx, c.provider, req, timeouts)
+	}
+	if err == nil && resp != nil {
+		if resp.ProviderMetadata == nil {
+			resp.ProviderMetadata = make(map[string]any)
+		}
+		resp.ProviderMetadata[MetadataKeyLatency] = time.Since(info.StartTime).Milliseconds()
+	}
 
 	// Hook: after response
 	if c.hook != nil {
 		c.hook.AfterResponse(ctx, info, req, resp, err)
 	}
 
-	// Cache the successful response
-	if err == nil && c.cache != nil && c.cache.ShouldCache(req) {
-		if cacheErr := c.cache.Set(ctx, req, resp); cacheErr != nil {
-			c.logger.Warn("failed to cache response",
-				slog.String("error", cacheErr.Error()))
-		}
-	}
-
 	return resp, err
 }
 
 // CreateChatCompletionStream creates a streaming chat completion
 func (c *ChatClient) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	if err := c.inflight.acquire(); err != nil {
+		return nil, err
+	}
+	stream, err := c.createChatCompletionStream(ctx, req)
+	if err != nil {
+		c.inflight.release()
+		return nil, err
+	}
+	return c.trackStream(stream), nil
+}
+
+// createChatCompletionStream implements CreateChatCompletionStream
+func (c *ChatClient) createChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	req, err := c.applySystemPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validator.validate(req, true); err != nil {
+		return nil, err
+	}
+	req = c.compressPrompt(ctx, req)
+	if err := checkRequestSize(req, c.maxRequestBytes); err != nil {
+		return nil, err
+	}
+	ctx = ensureIdempotencyKey(ctx)
+
 	info := LLMCallInfo{
 		CallID:       newCallID(),
 		ProviderName: c.provider.Name(),
 		StartTime:    time.Now(),
 	}
 
+	ctx, timeouts, cancel := c.withRequestTimeouts(ctx)
+
 	// Hook: before request
 	if c.hook != nil {
 		ctx = c.hook.BeforeRequest(ctx, info, req)
 	}
 
-	stream, err := c.provider.CreateChatCompletionStream(ctx, req)
+	var stream provider.ChatCompletionStream
+	if managesAttempts(c.provider) {
+		stream, err = c.provider.CreateChatCompletionStream(ctx, req)
+	} else {
+		stream, err = openStreamWithTimeouts(ctx, c.provider, req, timeouts)
+	}
 	if err != nil {
+		cancel()
 		if c.hook != nil {
 			c.hook.AfterResponse(ctx, info, req, nil, err)
 		}
 		return nil, err
 	}
+	if timeouts.Total > 0 {
+		stream = &cancelOnCloseStream{ChatCompletionStream: stream, cancel: cancel}
+	}
 
-	// Hook: wrap stream for observability
+	// Hook: measure the stream, then wrap it for observability
+	if h, ok := c.hook.(StreamObservabilityHook); ok && observesStreams(h) {
+		stream = newMetricsStream(ctx, info, req, stream, h)
+	}
 	if c.hook != nil {
 		stream = c.hook.WrapStream(ctx, info, req, stream)
 	}
@@ -242,8 +624,42 @@ func (c *ChatClient) CreateChatCompletionStream(ctx context.Context, req *provid
 	return stream, nil
 }
 
-// Close closes the client
+// Ask sends a single user prompt and returns the content of the first choice
+func (c *ChatClient) Ask(ctx context.Context, model, prompt string) (string, error) {
+	return c.ChatText(ctx, model, "", prompt)
+}
+
+// ChatText sends an optional system prompt and a user message and returns the
+// content of the first choice. An empty system prompt is omitted.
+func (c *ChatClient) ChatText(ctx context.Context, model, system, user string) (string, error) {
+	var messages []provider.Message
+	if system != "" {
+		messages = append(messages, provider.Message{Role: provider.RoleSystem, Content: system})
+	}
+	messages = append(messages, provider.Message{Role: provider.RoleUser, Content: user})
+
+	resp, err := c.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrInvalidResponse
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// Close stops background health probes and closes the client. Requests
+// still in flight may fail; use Shutdown to let them finish first.
 func (c *ChatClient) Close() error {
+	if c.healthProber != nil {
+		c.healthProber.stop()
+	}
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+	}
 	return c.provider.Close()
 }
 
@@ -284,34 +700,65 @@ func (c *ChatClient) TokenEstimator() TokenEstimator {
 
 // CreateChatCompletionWithMemory creates a chat completion using conversation memory
 func (c *ChatClient) CreateChatCompletionWithMemory(ctx context.Context, sessionID string, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
-	if !c.HasMemory() {
-		return c.CreateChatCompletion(ctx, req)
+	return c.createChatCompletionWithMemory(ctx, sessionID, req, "")
+}
+
+// memoryRequest returns req with the session's stored messages before its
+// own, and a system message with system, if set, before those. Only req's
+// own messages are saved to memory.
+func (c *ChatClient) memoryRequest(ctx context.Context, sessionID string, req *provider.ChatCompletionRequest, system string) (*provider.ChatCompletionRequest, error) {
+	var allMessages []provider.Message
+	if system != "" {
+		allMessages = append(allMessages, provider.Message{Role: provider.RoleSystem, Content: system})
 	}
 
-	// Load existing conversation
-	conversation, err := c.memory.LoadConversation(ctx, sessionID)
-	if err != nil {
-		return nil, err
+	if c.HasMemory() {
+		// Load existing conversation
+		conversation, err := c.memory.LoadConversation(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		history, err := c.memory.packHistory(conversation.Messages, req.Model, c.tokenEstimator)
+		if err != nil {
+			return nil, err
+		}
+		allMessages = append(allMessages, history...)
 	}
 
-	// Merge stored messages with request messages
-	allMessages := append(conversation.Messages, req.Messages...)
+	if len(allMessages) == 0 {
+		return req, nil
+	}
 
-	// Create new request with combined messages
+	// Merge stored messages with request messages
 	memoryReq := *req
-	memoryReq.Messages = allMessages
+	memoryReq.Messages = append(allMessages, req.Messages...)
+	return &memoryReq, nil
+}
 
-	// Get response (use client method to ensure hook is called)
-	response, err := c.CreateChatCompletion(ctx, &memoryReq)
+// createChatCompletionWithMemory implements CreateChatCompletionWithMemory,
+// adding a system message with system, if set, that is not saved
+func (c *ChatClient) createChatCompletionWithMemory(ctx context.Context, sessionID string, req *provider.ChatCompletionRequest, system string) (*provider.ChatCompletionResponse, error) {
+	memoryReq, err := c.memoryRequest(ctx, sessionID, req, system)
 	if err != nil {
 		return nil, err
 	}
 
-	// Save the conversation with new messages and response
-	if len(response.Choices) > 0 {
+	// Get response (use client method to ensure hook is called)
+	response, err := c.CreateChatCompletion(ctx, memoryReq)
+	if err != nil || !c.HasMemory() {
+		return response, err
+	}
+
+	// Save the conversation with new messages and response. With N > 1 the
+	// conversation continues from the first choice.
+	if choice, ok := primaryChoice(response.Choices); ok {
 		// Save request messages and response
-		messagesToSave := append(req.Messages, response.Choices[0].Message)
-		err = c.memory.AppendMessages(ctx, sessionID, messagesToSave)
+		var usage *provider.Usage
+		if response.Usage.TotalTokens > 0 {
+			usage = &response.Usage
+		}
+		meta := responseMetadata(cmp.Or(response.Model, req.Model), usage)
+		err = c.memory.AppendMessagesWithMetadata(ctx, sessionID, memoryEntries(req.Messages, messageFromChoice(choice), meta))
 		if err != nil {
 			slogutil.LoggerFromContext(ctx, c.logger).Error("failed to save conversation to memory",
 				slog.String("session_id", sessionID),
@@ -322,39 +769,74 @@ func (c *ChatClient) CreateChatCompletionWithMemory(ctx context.Context, session
 	return response, nil
 }
 
-// CreateChatCompletionStreamWithMemory creates a streaming chat completion using conversation memory
-func (c *ChatClient) CreateChatCompletionStreamWithMemory(ctx context.Context, sessionID string, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
-	if !c.HasMemory() {
-		return c.CreateChatCompletionStream(ctx, req)
+// memoryEntries pairs the request messages and the response of one exchange
+// with their metadata for storage
+func memoryEntries(reqMessages []provider.Message, response provider.Message, meta MessageMetadata) []MessageWithMetadata {
+	entries := make([]MessageWithMetadata, 0, len(reqMessages)+1)
+	for _, msg := range reqMessages {
+		entries = append(entries, MessageWithMetadata{Message: msg, Metadata: MessageMetadata{Timestamp: meta.Timestamp}})
 	}
+	return append(entries, MessageWithMetadata{Message: response, Metadata: meta})
+}
 
-	// Load existing conversation
-	conversation, err := c.memory.LoadConversation(ctx, sessionID)
-	if err != nil {
-		return nil, err
+// responseMetadata describes a response from model, estimating its cost from
+// DefaultModelCatalog() when usage is known
+func responseMetadata(model string, usage *provider.Usage) MessageMetadata {
+	meta := MessageMetadata{Timestamp: time.Now(), Model: model}
+	if usage != nil {
+		u := *usage
+		meta.Usage = &u
+		if spec, ok := DefaultModelCatalog().Lookup(model); ok && spec.Pricing != nil {
+			meta.Cost = spec.Pricing.Cost(u)
+		}
 	}
+	return meta
+}
 
-	// Merge stored messages with request messages
-	allMessages := append(conversation.Messages, req.Messages...)
+// primaryChoice returns the choice with index 0, which continues the
+// conversation when a response has several choices
+func primaryChoice(choices []provider.ChatCompletionChoice) (provider.ChatCompletionChoice, bool) {
+	for _, choice := range choices {
+		if choice.Index == 0 {
+			return choice, true
+		}
+	}
+	if len(choices) > 0 {
+		return choices[0], true
+	}
+	return provider.ChatCompletionChoice{}, false
+}
 
-	// Create new request with combined messages
-	memoryReq := *req
-	memoryReq.Messages = allMessages
+// CreateChatCompletionStreamWithMemory creates a streaming chat completion using conversation memory
+func (c *ChatClient) CreateChatCompletionStreamWithMemory(ctx context.Context, sessionID string, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	return c.createChatCompletionStreamWithMemory(ctx, sessionID, req, "")
+}
 
-	// Get stream response (use client method to ensure hook is called)
-	stream, err := c.CreateChatCompletionStream(ctx, &memoryReq)
+// createChatCompletionStreamWithMemory implements
+// CreateChatCompletionStreamWithMemory, adding a system message with system,
+// if set, that is not saved
+func (c *ChatClient) createChatCompletionStreamWithMemory(ctx context.Context, sessionID string, req *provider.ChatCompletionRequest, system string) (provider.ChatCompletionStream, error) {
+	memoryReq, err := c.memoryRequest(ctx, sessionID, req, system)
 	if err != nil {
 		return nil, err
 	}
 
+	// Get stream response (use client method to ensure hook is called)
+	stream, err := c.CreateChatCompletionStream(ctx, memoryReq)
+	if err != nil || !c.HasMemory() {
+		return stream, err
+	}
+
 	// Wrap the stream to capture the response for memory storage
 	return &memoryAwareStream{
 		stream:      stream,
 		memory:      c.memory,
 		sessionID:   sessionID,
 		reqMessages: req.Messages,
+		model:       req.Model,
 		ctx:         ctx,
 		logger:      c.logger,
+		accumulator: NewStreamAccumulator(),
 	}, nil
 }
 
@@ -390,6 +872,15 @@ func (c *ChatClient) GetConversationMessages(ctx context.Context, sessionID stri
 	return c.memory.GetMessages(ctx, sessionID)
 }
 
+// GetConversationMessagesWithMetadata retrieves messages from a conversation
+// along with their timestamps, models, usage, and tags
+func (c *ChatClient) GetConversationMessagesWithMetadata(ctx context.Context, sessionID string) ([]MessageWithMetadata, error) {
+	if !c.HasMemory() {
+		return nil, fmt.Errorf("memory not configured")
+	}
+	return c.memory.GetMessagesWithMetadata(ctx, sessionID)
+}
+
 // CreateConversationWithSystemMessage creates a new conversation with a system message
 func (c *ChatClient) CreateConversationWithSystemMessage(ctx context.Context, sessionID, systemMessage string) error {
 	if !c.HasMemory() {
@@ -406,65 +897,100 @@ func (c *ChatClient) DeleteConversation(ctx context.Context, sessionID string) e
 	return c.memory.DeleteConversation(ctx, sessionID)
 }
 
+// ListConversations returns a summary of each stored conversation, most
+// recently updated first
+func (c *ChatClient) ListConversations(ctx context.Context) ([]ConversationSummary, error) {
+	if !c.HasMemory() {
+		return nil, fmt.Errorf("memory not configured")
+	}
+	return c.memory.ListConversations(ctx)
+}
+
+// GenerateConversationTitle generates and stores a short title for a
+// conversation using this client
+func (c *ChatClient) GenerateConversationTitle(ctx context.Context, sessionID string) (string, error) {
+	if !c.HasMemory() {
+		return "", fmt.Errorf("memory not configured")
+	}
+	return c.memory.GenerateTitle(ctx, sessionID, c)
+}
+
+// ForkConversation copies the first atMessageIndex messages of a conversation
+// into a new session, leaving the original unchanged
+func (c *ChatClient) ForkConversation(ctx context.Context, fromSessionID, newSessionID string, atMessageIndex int) error {
+	if !c.HasMemory() {
+		return fmt.Errorf("memory not configured")
+	}
+	return c.memory.ForkConversation(ctx, fromSessionID, newSessionID, atMessageIndex)
+}
+
 // memoryAwareStream wraps a ChatCompletionStream to capture responses for memory storage
 type memoryAwareStream struct {
 	stream      provider.ChatCompletionStream
 	memory      *MemoryManager
 	sessionID   string
 	reqMessages []provider.Message
+	model       string
 	ctx         context.Context
 	logger      *slog.Logger
 
-	// Buffer to collect the complete response
-	responseBuffer strings.Builder
-	streamClosed   bool
+	// Accumulates the complete response, including streamed tool calls
+	accumulator  *StreamAccumulator
+	streamClosed bool
 }
 
 // Recv receives the next chunk from the stream and buffers the response
 func (s *memoryAwareStream) Recv() (*provider.ChatCompletionChunk, error) {
 	chunk, err := s.stream.Recv()
 	if err != nil {
-		// If we hit EOF and haven't saved the response yet, save it now
-		if err.Error() == "EOF" && !s.streamClosed {
+		// Save the response at EOF; a response cut off by an error or by
+		// cancellation is not saved
+		if errors.Is(err, io.EOF) && !s.streamClosed {
 			s.saveBufferedResponse()
-			s.streamClosed = true
 		}
+		s.streamClosed = true
 		return chunk, err
 	}
 
-	// Buffer the response content
-	if len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
-		s.responseBuffer.WriteString(chunk.Choices[0].Delta.Content)
-	}
-
+	s.accumulator.Add(chunk)
 	return chunk, nil
 }
 
-// Close closes the stream and saves the complete response to memory
+// Close closes the stream and saves the response received so far to memory,
+// unless the stream failed or its context was canceled. It must not be called
+// concurrently with Recv.
 func (s *memoryAwareStream) Close() error {
-	if !s.streamClosed {
+	if !s.streamClosed && s.ctx.Err() == nil {
 		s.saveBufferedResponse()
-		s.streamClosed = true
 	}
+	s.streamClosed = true
 	return s.stream.Close()
 }
 
-// saveBufferedResponse saves the complete buffered response to memory
+// saveBufferedResponse saves the request messages and the first choice of the
+// buffered response to memory, if the response has content or tool calls
 func (s *memoryAwareStream) saveBufferedResponse() {
-	if s.responseBuffer.Len() > 0 {
-		// Create assistant message from buffered response
-		assistantMessage := provider.Message{
-			Role:    provider.RoleAssistant,
-			Content: s.responseBuffer.String(),
-		}
+	resp := s.accumulator.Response()
+	choice, ok := primaryChoice(resp.Choices)
+	if !ok {
+		return
+	}
+	assistantMessage := messageFromChoice(choice)
+	if assistantMessage.Content == "" && len(assistantMessage.ToolCalls) == 0 && assistantMessage.Audio == nil {
+		return
+	}
 
-		// Save request messages and response
-		messagesToSave := append(s.reqMessages, assistantMessage)
-		err := s.memory.AppendMessages(s.ctx, s.sessionID, messagesToSave)
-		if err != nil {
-			slogutil.LoggerFromContext(s.ctx, s.logger).Error("failed to save streaming response to memory",
-				slog.String("session_id", s.sessionID),
-				slog.String("error", err.Error()))
-		}
+	var usage *provider.Usage
+	if resp.Usage.TotalTokens > 0 {
+		usage = &resp.Usage
+	}
+	meta := responseMetadata(cmp.Or(resp.Model, s.model), usage)
+
+	// Save request messages and response
+	err := s.memory.AppendMessagesWithMetadata(s.ctx, s.sessionID, memoryEntries(s.reqMessages, assistantMessage, meta))
+	if err != nil {
+		slogutil.LoggerFromContext(s.ctx, s.logger).Error("failed to save streaming response to memory",
+			slog.String("session_id", s.sessionID),
+			slog.String("error", err.Error()))
 	}
 }
@@ -2,6 +2,7 @@ package omnillm
 
 import (
 	"context"
+	"errors"
 	"io"
 	"testing"
 	"time"
@@ -303,6 +304,102 @@ func TestChatClient_CreateChatCompletionWithMemory(t *testing.T) {
 	}
 }
 
+func TestChatClient_MemoryMessageMetadata(t *testing.T) {
+	resp := mocktest.TextResponse("Hi!")
+	resp.Model = ModelGPT4o
+	resp.Usage = provider.Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+	mock := mocktest.NewScriptedProvider("mock", mocktest.Step{Response: resp})
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mock}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	req := &provider.ChatCompletionRequest{Model: ModelGPT4o, Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hello"}}}
+	if _, err := client.CreateChatCompletionWithMemory(ctx, "session1", req); err != nil {
+		t.Fatalf("CreateChatCompletionWithMemory failed: %v", err)
+	}
+
+	messages, err := client.GetConversationMessagesWithMetadata(ctx, "session1")
+	if err != nil {
+		t.Fatalf("GetConversationMessagesWithMetadata failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if meta := messages[0].Metadata; meta.Timestamp.IsZero() || meta.Model != "" || meta.Usage != nil {
+		t.Errorf("unexpected request metadata: %+v", meta)
+	}
+	meta := messages[1].Metadata
+	if meta.Model != ModelGPT4o || meta.Usage == nil || meta.Usage.TotalTokens != 1500 || meta.Cost <= 0 {
+		t.Errorf("unexpected response metadata: %+v", meta)
+	}
+}
+
+func TestChatClient_StreamWithMemory_ToolCalls(t *testing.T) {
+	call := func(args string) *provider.ChatCompletionChunk {
+		tc := provider.ToolCall{Index: intPtr(0), Function: provider.ToolFunction{Arguments: args}}
+		if args == "" {
+			tc.ID, tc.Type, tc.Function.Name = "call_1", "function", "get_weather"
+		}
+		return &provider.ChatCompletionChunk{Choices: []provider.ChatCompletionChoice{
+			{Delta: &provider.Message{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{tc}}},
+		}}
+	}
+	mock := mocktest.NewScriptedProvider("mock",
+		mocktest.Step{Chunks: []*provider.ChatCompletionChunk{call(""), call(`{"city":`), call(`"Paris"}`)}},
+		mocktest.Step{Chunks: mocktest.TextChunks("Sunny in ", "Paris")},
+	)
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mock}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	turn := func(messages ...provider.Message) {
+		t.Helper()
+		stream, err := client.CreateChatCompletionStreamWithMemory(ctx, "agent", &provider.ChatCompletionRequest{Model: "m", Messages: messages})
+		if err != nil {
+			t.Fatalf("stream failed: %v", err)
+		}
+		if _, err := AccumulateStream(stream); err != nil {
+			t.Fatalf("accumulate failed: %v", err)
+		}
+	}
+	turn(provider.Message{Role: provider.RoleUser, Content: "Weather in Paris?"})
+	turn(provider.Message{Role: provider.RoleTool, Content: "Sunny", ToolCallID: stringPtr("call_1")})
+
+	// The second request replays the tool call from memory
+	replayed := mock.LastRequest().Messages
+	if len(replayed) != 3 || len(replayed[1].ToolCalls) != 1 {
+		t.Fatalf("expected the tool call to be replayed, got %+v", replayed)
+	}
+
+	messages, err := client.GetConversationMessages(ctx, "agent")
+	if err != nil {
+		t.Fatalf("GetConversationMessages failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("got %d messages, want 4: %+v", len(messages), messages)
+	}
+	calls := messages[1].ToolCalls
+	if len(calls) != 1 || calls[0].ID != "call_1" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("unexpected stored tool calls: %+v", calls)
+	}
+	if messages[2].Role != provider.RoleTool || messages[3].Content != "Sunny in Paris" {
+		t.Errorf("unexpected stored messages: %+v", messages)
+	}
+}
+
 func TestChatClient_CreateChatCompletionStreamWithMemory(t *testing.T) {
 	mockProv := NewMockProvider("test")
 	mockProv.streamChunks = []*provider.ChatCompletionChunk{
@@ -460,3 +557,113 @@ func TestChatClient_NoMemory(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestChatClient_AskAndChatText(t *testing.T) {
+	mockProv := &recordingProvider{MockProvider: NewMockProvider("test")}
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	answer, err := client.Ask(context.Background(), "test-model", "Hello")
+	if err != nil {
+		t.Fatalf("Ask failed: %v", err)
+	}
+	if answer != mockProv.completionResp.Choices[0].Message.Content {
+		t.Errorf("Ask() = %q", answer)
+	}
+	if len(mockProv.lastReq.Messages) != 1 || mockProv.lastReq.Messages[0].Role != RoleUser {
+		t.Errorf("Ask messages = %+v", mockProv.lastReq.Messages)
+	}
+
+	if _, err := client.ChatText(context.Background(), "test-model", "Be brief", "Hello"); err != nil {
+		t.Fatalf("ChatText failed: %v", err)
+	}
+	msgs := mockProv.lastReq.Messages
+	if len(msgs) != 2 || msgs[0].Role != RoleSystem || msgs[0].Content != "Be brief" || msgs[1].Content != "Hello" {
+		t.Errorf("ChatText messages = %+v", msgs)
+	}
+}
+
+func TestChatClient_Ask_NoChoices(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.completionResp = &provider.ChatCompletionResponse{}
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Ask(context.Background(), "test-model", "Hello"); !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("expected ErrInvalidResponse, got %v", err)
+	}
+}
+
+// recordingProvider records the last completion request
+type recordingProvider struct {
+	*MockProvider
+	lastReq *provider.ChatCompletionRequest
+}
+
+func (r *recordingProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	r.lastReq = req
+	return r.MockProvider.CreateChatCompletion(ctx, req)
+}
+
+func TestChatClient_MemorySavesFirstChoice(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.completionResp.Choices = []provider.ChatCompletionChoice{
+		{Index: 1, Message: provider.Message{Role: provider.RoleAssistant, Content: "second"}},
+		{Index: 0, Message: provider.Message{Role: provider.RoleAssistant, Content: "first"}},
+	}
+	mockProv.streamChunks = []*provider.ChatCompletionChunk{
+		{Choices: []provider.ChatCompletionChoice{
+			{Index: 1, Delta: &provider.Message{Content: "B"}},
+			{Index: 0, Delta: &provider.Message{Content: "A"}},
+		}},
+		{Choices: []provider.ChatCompletionChoice{{Index: 1, Delta: &provider.Message{Content: "b"}}}},
+		{Choices: []provider.ChatCompletionChoice{{Index: 0, Delta: &provider.Message{Content: "a"}}}},
+	}
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mockProv}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	req := &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hello"}},
+	}
+
+	if _, err := client.CreateChatCompletionWithMemory(ctx, "sync", req); err != nil {
+		t.Fatalf("CreateChatCompletionWithMemory failed: %v", err)
+	}
+	messages, err := client.GetConversationMessages(ctx, "sync")
+	if err != nil {
+		t.Fatalf("GetConversationMessages failed: %v", err)
+	}
+	if len(messages) != 2 || messages[1].Content != "first" {
+		t.Errorf("saved messages = %+v, want first choice", messages)
+	}
+
+	stream, err := client.CreateChatCompletionStreamWithMemory(ctx, "stream", req)
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStreamWithMemory failed: %v", err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatalf("AccumulateStream failed: %v", err)
+	}
+	messages, err = client.GetConversationMessages(ctx, "stream")
+	if err != nil {
+		t.Fatalf("GetConversationMessages failed: %v", err)
+	}
+	if len(messages) != 2 || messages[1].Content != "Aa" {
+		t.Errorf("saved stream messages = %+v, want first choice content Aa", messages)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/models"
+)
+
+// catalog lists the known models for each provider. The first model of each
+// provider is its default for chat and doctor.
+var catalog = map[omnillm.ProviderName][]string{
+	omnillm.ProviderNameOpenAI: {
+		models.GPT4oMini, models.GPT4o, models.GPT4_1, models.GPT4_1Mini, models.GPT4_1Nano,
+		models.GPT5, models.GPT5Mini, models.GPT5Nano,
+	},
+	omnillm.ProviderNameAnthropic: {
+		models.ClaudeHaiku4_5, models.ClaudeSonnet4_5, models.ClaudeOpus4_5,
+		models.ClaudeOpus4_1, models.ClaudeSonnet4, models.Claude3_7Sonnet, models.Claude3_5Haiku,
+	},
+	omnillm.ProviderNameGemini: {
+		models.Gemini2_5Flash, models.Gemini2_5Pro, models.Gemini1_5Pro, models.Gemini1_5Flash,
+	},
+	omnillm.ProviderNameXAI: {
+		models.Grok3Mini, models.Grok4_1FastReasoning, models.Grok4_1FastNonReasoning,
+		models.Grok4FastReasoning, models.Grok4FastNonReasoning, models.GrokCodeFast1, models.Grok3,
+	},
+	omnillm.ProviderNameHuggingFace: {
+		models.HuggingFaceLlama3_3_70B, models.HuggingFaceLlama3_1_8B, models.HuggingFaceQwen2_5_72B,
+		models.HuggingFaceDeepSeekV3, models.HuggingFaceMistral7B_0_3,
+	},
+	omnillm.ProviderNameOllama: {
+		models.OllamaLlama3_8B, models.OllamaLlama3_70B, models.OllamaMistral7B,
+		models.OllamaMixtral8x7B, models.OllamaQwen2_5, models.OllamaDeepSeek,
+	},
+	omnillm.ProviderNameBedrock: {
+		models.BedrockClaude3Sonnet, models.BedrockClaudeOpus4, models.BedrockClaude3Opus, models.BedrockTitan,
+	},
+}
+
+// defaultModel returns the default model for a provider
+func defaultModel(name omnillm.ProviderName) string {
+	if list := catalog[name]; len(list) > 0 {
+		return list[0]
+	}
+	return ""
+}
+
+// promptFlags holds the request flags shared by chat and stream
+type promptFlags struct {
+	model       string
+	system      string
+	temperature float64
+	maxTokens   int
+	showUsage   bool
+	session     string
+}
+
+func (p *promptFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.model, "model", "", "model to use (default: the primary provider's default model)")
+	fs.StringVar(&p.system, "system", "", "system prompt")
+	fs.Float64Var(&p.temperature, "temperature", -1, "sampling temperature (default: provider default)")
+	fs.IntVar(&p.maxTokens, "max-tokens", 0, "maximum tokens to generate (default: provider default)")
+	fs.BoolVar(&p.showUsage, "usage", false, "print token usage to stderr")
+	fs.StringVar(&p.session, "session", "", "continue a conversation stored in the configured memory")
+}
+
+// errNoMemory is returned for -session when the config has no memory
+var errNoMemory = errors.New("-session needs a memory section in the config file")
+
+// complete sends req, within the -session conversation if one was given
+func (p *promptFlags) complete(ctx context.Context, client *omnillm.ChatClient, req *omnillm.ChatCompletionRequest) (*omnillm.ChatCompletionResponse, error) {
+	if p.session == "" {
+		return client.CreateChatCompletion(ctx, req)
+	}
+	session, err := p.sessionClient(client)
+	if err != nil {
+		return nil, err
+	}
+	return session.CreateChatCompletion(ctx, req)
+}
+
+// openStream streams req, within the -session conversation if one was given
+func (p *promptFlags) openStream(ctx context.Context, client *omnillm.ChatClient, req *omnillm.ChatCompletionRequest) (omnillm.ChatCompletionStream, error) {
+	if p.session == "" {
+		return client.CreateChatCompletionStream(ctx, req)
+	}
+	session, err := p.sessionClient(client)
+	if err != nil {
+		return nil, err
+	}
+	return session.CreateChatCompletionStream(ctx, req)
+}
+
+// sessionClient returns the -session conversation. The -system prompt is
+// sent with each request rather than stored in it.
+func (p *promptFlags) sessionClient(client *omnillm.ChatClient) (*omnillm.SessionClient, error) {
+	if !client.HasMemory() {
+		return nil, errNoMemory
+	}
+	session := client.Session(p.session)
+	if p.system != "" {
+		session = session.WithSystemPrompt(p.system)
+	}
+	return session, nil
+}
+
+// request builds the chat request for the prompt
+func (p *promptFlags) request(config *omnillm.ClientConfig, prompt string) (*omnillm.ChatCompletionRequest, error) {
+	model := p.model
+	if model == "" {
+		model = defaultModel(config.Providers[0].Provider)
+	}
+	b := omnillm.NewRequest(model)
+	if p.system != "" && p.session == "" {
+		b.System(p.system)
+	}
+	b.User(prompt)
+	if p.temperature >= 0 {
+		b.Temperature(p.temperature)
+	}
+	if p.maxTokens > 0 {
+		b.MaxTokens(p.maxTokens)
+	}
+	return b.Build()
+}
+
+// readPrompt returns the arguments joined by spaces, or stdin if there are none
+func (c *cli) readPrompt(args []string) (string, error) {
+	if len(args) > 0 {
+		return strings.Join(args, " "), nil
+	}
+	data, err := io.ReadAll(c.stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+	prompt := strings.TrimSpace(string(data))
+	if prompt == "" {
+		return "", errors.New("no prompt given")
+	}
+	return prompt, nil
+}
+
+func (c *cli) printUsage(usage omnillm.Usage) {
+	fmt.Fprintf(c.stderr, "tokens: prompt=%d completion=%d total=%d\n",
+		usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+}
+
+// chat sends a prompt and prints the response
+func (c *cli) chat(args []string) error {
+	var pf promptFlags
+	fs := c.newFlagSet("chat", "[prompt]")
+	pf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	prompt, err := c.readPrompt(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	client, config, err := c.newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	req, err := pf.request(config, prompt)
+	if err != nil {
+		return err
+	}
+	resp, err := pf.complete(context.Background(), client, req)
+	if err != nil {
+		return err
+	}
+	if len(resp.Choices) == 0 {
+		return omnillm.ErrInvalidResponse
+	}
+	fmt.Fprintln(c.stdout, resp.Choices[0].Message.Content)
+	if pf.showUsage {
+		c.printUsage(resp.Usage)
+	}
+	return nil
+}
+
+// stream sends a prompt and writes the response as it arrives
+func (c *cli) stream(args []string) error {
+	var pf promptFlags
+	fs := c.newFlagSet("stream", "[prompt]")
+	pf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	prompt, err := c.readPrompt(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	client, config, err := c.newClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	req, err := pf.request(config, prompt)
+	if err != nil {
+		return err
+	}
+	stream, err := pf.openStream(context.Background(), client, req)
+	if err != nil {
+		return err
+	}
+
+	var usage *omnillm.Usage
+	for chunk, err := range omnillm.StreamChunks(context.Background(), stream) {
+		if err != nil {
+			fmt.Fprintln(c.stdout)
+			return err
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
+			fmt.Fprint(c.stdout, chunk.Choices[0].Delta.Content)
+		}
+	}
+	fmt.Fprintln(c.stdout)
+	if pf.showUsage && usage != nil {
+		c.printUsage(*usage)
+	}
+	return nil
+}
+
+// models lists the known models with their context windows
+func (c *cli) models(args []string) error {
+	fs := c.newFlagSet("models", "")
+	providerName := fs.String("provider", "", "only list models for this provider")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		if *providerName == "" || string(name) == *providerName {
+			names = append(names, string(name))
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("unknown provider %q", *providerName)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tMODEL\tCONTEXT")
+	for _, name := range names {
+		for _, model := range catalog[omnillm.ProviderName(name)] {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", name, model, omnillm.GetModelContextWindow(model))
+		}
+	}
+	return w.Flush()
+}
+
+// estimateTokens prints the estimated prompt tokens for text
+func (c *cli) estimateTokens(args []string) error {
+	fs := c.newFlagSet("estimate-tokens", "[text]")
+	model := fs.String("model", models.GPT4o, "model used for the estimate and context window")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	text, err := c.readPrompt(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	tokens, err := omnillm.EstimatePromptTokens(*model, []omnillm.Message{
+		{Role: omnillm.RoleUser, Content: text},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "tokens: %d\n", tokens)
+	if window := omnillm.GetModelContextWindow(*model); window > 0 {
+		fmt.Fprintf(c.stdout, "context window: %d (%.1f%% used)\n", window, float64(tokens)*100/float64(window))
+	}
+	return nil
+}
+
+// doctor checks every configured provider independently: it verifies that a
+// key is present where one is required, that the provider can be built, and
+// that a minimal completion succeeds.
+func (c *cli) doctor(args []string) error {
+	fs := c.newFlagSet("doctor", "")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for each connectivity check")
+	offline := fs.Bool("offline", false, "skip connectivity checks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for i, pc := range config.Providers {
+		role := "primary"
+		if i > 0 {
+			role = fmt.Sprintf("fallback %d", i)
+		}
+		fmt.Fprintf(c.stdout, "%s (%s)\n", pc.Provider, role)
+		if err := c.checkProvider(pc, *timeout, *offline); err != nil {
+			fmt.Fprintf(c.stdout, "  FAIL %v\n", err)
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d providers failed", failures, len(config.Providers))
+	}
+	fmt.Fprintln(c.stdout, "all providers OK")
+	return nil
+}
+
+// checkProvider runs the doctor checks for a single provider
+func (c *cli) checkProvider(pc omnillm.ProviderConfig, timeout time.Duration, offline bool) error {
+	switch pc.Provider {
+	case omnillm.ProviderNameOllama, omnillm.ProviderNameBedrock:
+	default:
+		if pc.APIKey == "" && pc.CustomProvider == nil {
+			return errors.New("no API key configured")
+		}
+		fmt.Fprintf(c.stdout, "  ok   API key present (%s)\n", maskKey(pc.APIKey))
+	}
+
+	client, err := omnillm.NewClient(omnillm.ClientConfig{Providers: []omnillm.ProviderConfig{pc}})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	fmt.Fprintln(c.stdout, "  ok   provider initialized")
+
+	if offline {
+		return nil
+	}
+
+	model := defaultModel(pc.Provider)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	req := omnillm.NewRequest(model).User("ping").MaxTokens(16).Request()
+	if _, err := client.CreateChatCompletion(ctx, req); err != nil {
+		return fmt.Errorf("completion with %s: %w", model, err)
+	}
+	fmt.Fprintf(c.stdout, "  ok   completion with %s (%s)\n", model, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// maskKey shows only the first and last few characters of an API key
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + "..." + key[len(key)-4:]
+}
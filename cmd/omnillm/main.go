@@ -0,0 +1,128 @@
+// Command omnillm is a small CLI for ad-hoc chats and for diagnosing provider
+// setups without writing Go code.
+//
+// Providers are read from the file given by -config (or OMNILLM_CONFIG) via
+// omnillm.LoadConfig, or from the environment via omnillm.ConfigFromEnv.
+//
+// Usage:
+//
+//	omnillm [-config file] <command> [flags] [args]
+//
+// Commands:
+//
+//	chat             send a prompt and print the response
+//	stream           send a prompt and stream the response
+//	models           list known models and their context windows
+//	estimate-tokens  estimate the prompt token count for text
+//	doctor           validate keys and connectivity for each provider
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/plexusone/omnillm"
+)
+
+const usage = `Usage: omnillm [-config file] <command> [flags] [args]
+
+Commands:
+  chat             send a prompt and print the response
+  stream           send a prompt and stream the response
+  models           list known models and their context windows
+  estimate-tokens  estimate the prompt token count for text
+  doctor           validate keys and connectivity for each provider
+
+Prompts are read from the remaining arguments, or from stdin when none are given.
+Run 'omnillm <command> -h' for command flags.
+`
+
+// cli holds the I/O streams and global options shared by all commands
+type cli struct {
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	configPath string
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// run parses global flags, dispatches to a command, and returns the exit code
+func (c *cli) run(args []string) int {
+	fs := flag.NewFlagSet("omnillm", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() { fmt.Fprint(c.stderr, usage) }
+	fs.StringVar(&c.configPath, "config", os.Getenv("OMNILLM_CONFIG"), "YAML or JSON config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	commands := map[string]func([]string) error{
+		"chat":            c.chat,
+		"stream":          c.stream,
+		"models":          c.models,
+		"estimate-tokens": c.estimateTokens,
+		"doctor":          c.doctor,
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(c.stderr, "omnillm: unknown command %q\n\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	if err := cmd(fs.Args()[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintf(c.stderr, "omnillm: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig loads the client configuration from the config file if one was
+// given, otherwise from the environment
+func (c *cli) loadConfig() (*omnillm.ClientConfig, error) {
+	if c.configPath != "" {
+		return omnillm.LoadConfig(c.configPath)
+	}
+	config, err := omnillm.ConfigFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("%w (set provider API keys or use -config)", err)
+	}
+	return config, nil
+}
+
+// newClient creates a client from the loaded configuration
+func (c *cli) newClient() (*omnillm.ChatClient, *omnillm.ClientConfig, error) {
+	config, err := c.loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := omnillm.NewClient(*config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, config, nil
+}
+
+// newFlagSet creates a flag set for a command that reports errors to stderr
+func (c *cli) newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: omnillm %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCLI runs the CLI with the given stdin and arguments
+func runCLI(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	c := &cli{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut}
+	code = c.run(args)
+	return code, out.String(), errOut.String()
+}
+
+// clearEnv unsets the environment variables the CLI reads config from
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, v := range []string{
+		"OMNILLM_CONFIG", "OMNILLM_PROVIDERS", "OPENAI_API_KEY", "ANTHROPIC_API_KEY",
+		"GEMINI_API_KEY", "GOOGLE_API_KEY", "XAI_API_KEY", "OLLAMA_BASE_URL",
+	} {
+		t.Setenv(v, "")
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, stderr := runCLI(t, "")
+	if code != 2 || !strings.Contains(stderr, "Usage: omnillm") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "", "bogus")
+	if code != 2 || !strings.Contains(stderr, `unknown command "bogus"`) {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_Models(t *testing.T) {
+	code, stdout, _ := runCLI(t, "", "models", "-provider", "anthropic")
+	if code != 0 {
+		t.Fatalf("code = %d", code)
+	}
+	if !strings.Contains(stdout, "anthropic") || strings.Contains(stdout, "openai") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+
+	code, _, stderr := runCLI(t, "", "models", "-provider", "nope")
+	if code != 1 || !strings.Contains(stderr, "unknown provider") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_EstimateTokens(t *testing.T) {
+	code, stdout, _ := runCLI(t, "hello from stdin", "estimate-tokens")
+	if code != 0 || !strings.Contains(stdout, "tokens: ") || !strings.Contains(stdout, "context window") {
+		t.Errorf("code = %d, stdout = %q", code, stdout)
+	}
+
+	code, _, stderr := runCLI(t, "", "estimate-tokens")
+	if code != 1 || !strings.Contains(stderr, "no prompt") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_NoProviders(t *testing.T) {
+	clearEnv(t)
+	code, _, stderr := runCLI(t, "", "chat", "hi")
+	if code != 1 || !strings.Contains(stderr, "use -config") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_DoctorOffline(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "omnillm.yaml")
+	config := "providers:\n  - provider: openai\n    api_key: sk-test-123456789\n  - provider: anthropic\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCLI(t, "", "-config", path, "doctor", "-offline")
+	if code != 1 {
+		t.Fatalf("code = %d, want 1 (anthropic has no key)", code)
+	}
+	if !strings.Contains(stdout, "sk-t...6789") || !strings.Contains(stdout, "no API key configured") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "1 of 2 providers failed") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+func TestMaskKey(t *testing.T) {
+	if got := maskKey("short"); got != "*****" {
+		t.Errorf("maskKey(short) = %q", got)
+	}
+	if got := maskKey("sk-abcdefghijkl"); got != "sk-a...ijkl" {
+		t.Errorf("maskKey = %q", got)
+	}
+}
+
+func TestRun_ChatSession(t *testing.T) {
+	clearEnv(t)
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"model":"llama3","message":{"role":"assistant","content":"Hello!"},"done":true}`)
+	}))
+	defer server.Close()
+
+	path := writeTestConfig(t, "providers:\n  - provider: ollama\n    base_url: "+server.URL+
+		"\nmemory:\n  dir: "+filepath.Join(t.TempDir(), "memory")+"\n")
+
+	for _, prompt := range []string{"first", "second"} {
+		code, stdout, stderr := runCLI(t, "", "-config", path, "chat", "-session", "s1", "-system", "Be brief.", prompt)
+		if code != 0 || strings.TrimSpace(stdout) != "Hello!" {
+			t.Fatalf("code = %d, stdout = %q, stderr = %q", code, stdout, stderr)
+		}
+	}
+	if len(requests) != 2 || !strings.Contains(requests[1], "first") || strings.Count(requests[1], "Be brief.") != 1 {
+		t.Errorf("second request does not continue the session once: %s", requests[len(requests)-1])
+	}
+
+	code, _, stderr := runCLI(t, "", "-config", writeTestConfig(t, "providers:\n  - provider: ollama\n    base_url: "+server.URL+"\n"), "chat", "-session", "s1", "hi")
+	if code != 1 || !strings.Contains(stderr, "memory section") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+// writeTestConfig writes a config file and returns its path
+func writeTestConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "omnillm.yaml")
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,32 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrTextCompletionNotSupported is returned when no configured provider
+// supports legacy text completion
+var ErrTextCompletionNotSupported = errors.New("text completion not supported by configured providers")
+
+// CreateCompletion continues a prompt using the legacy (non-chat) text
+// completion API of the first configured provider that supports it, such as
+// OpenAI, an OpenAI-compatible server like vLLM, or Ollama. Base models,
+// which have no chat template, are served this way. Set Echo and Logprobs to
+// score the prompt itself.
+func (c *ChatClient) CreateCompletion(ctx context.Context, req *provider.TextCompletionRequest) (*provider.TextCompletionResponse, error) {
+	if c.textCompletions == nil {
+		return nil, ErrTextCompletionNotSupported
+	}
+	if req.Model == "" {
+		return nil, ErrEmptyModel
+	}
+	return c.textCompletions.CreateTextCompletion(ctx, req)
+}
+
+// HasTextCompletion returns true if a provider supporting legacy text completion is configured
+func (c *ChatClient) HasTextCompletion() bool {
+	return c.textCompletions != nil
+}
@@ -0,0 +1,57 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockTextCompletionProvider is a provider that also serves legacy text completion
+type mockTextCompletionProvider struct {
+	*MockProvider
+}
+
+func (m *mockTextCompletionProvider) CreateTextCompletion(ctx context.Context, req *provider.TextCompletionRequest) (*provider.TextCompletionResponse, error) {
+	return &provider.TextCompletionResponse{
+		Model:   req.Model,
+		Choices: []provider.TextCompletionChoice{{Text: req.Prompt + " continued"}},
+	}, nil
+}
+
+func TestChatClient_CreateCompletion(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: &mockTextCompletionProvider{MockProvider: NewMockProvider("base")}}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if !client.HasTextCompletion() {
+		t.Fatal("HasTextCompletion() = false")
+	}
+
+	resp, err := client.CreateCompletion(context.Background(), &TextCompletionRequest{Model: "base-1", Prompt: "Once"})
+	if err != nil {
+		t.Fatalf("CreateCompletion failed: %v", err)
+	}
+	if resp.Choices[0].Text != "Once continued" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if _, err := client.CreateCompletion(context.Background(), &TextCompletionRequest{Prompt: "Once"}); !errors.Is(err, ErrEmptyModel) {
+		t.Errorf("error = %v, want ErrEmptyModel", err)
+	}
+}
+
+func TestChatClient_CreateCompletion_NotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.CreateCompletion(context.Background(), &TextCompletionRequest{Model: "m"}); !errors.Is(err, ErrTextCompletionNotSupported) {
+		t.Errorf("error = %v, want ErrTextCompletionNotSupported", err)
+	}
+}
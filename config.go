@@ -0,0 +1,479 @@
+package omnillm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the serializable form of ClientConfig used by LoadConfig.
+// Runtime-only fields (HTTP clients, KVS backends, hooks, loggers, custom
+// providers) cannot be expressed in a file and must be set on the returned
+// ClientConfig in code.
+//
+// Example YAML:
+//
+//	providers:
+//	  - provider: openai
+//	    api_key: ${OPENAI_API_KEY}
+//	    timeout: 60s
+//	  - provider: anthropic
+//	    api_key_env: ANTHROPIC_API_KEY
+//	circuit_breaker:
+//	  failure_threshold: 3
+//	  timeout: 30s
+//	cache:
+//	  ttl: 10m
+type FileConfig struct {
+	Providers      []ProviderFileConfig      `json:"providers" yaml:"providers"`
+	CircuitBreaker *CircuitBreakerFileConfig `json:"circuit_breaker,omitempty" yaml:"circuit_breaker,omitempty"`
+	Cache          *CacheFileConfig          `json:"cache,omitempty" yaml:"cache,omitempty"`
+	Transport      *TransportFileConfig      `json:"transport,omitempty" yaml:"transport,omitempty"`
+	Memory         *MemoryFileConfig         `json:"memory,omitempty" yaml:"memory,omitempty"`
+	ValidateTokens bool                      `json:"validate_tokens,omitempty" yaml:"validate_tokens,omitempty"`
+}
+
+// ProviderFileConfig is the serializable form of ProviderConfig
+type ProviderFileConfig struct {
+	Provider ProviderName `json:"provider" yaml:"provider"`
+
+	// APIKey is the API key. Environment references such as ${OPENAI_API_KEY} are expanded.
+	APIKey string `json:"api_key,omitempty" yaml:"api_key,omitempty"` //nolint:gosec // G117: config field for API key, not a hardcoded credential
+
+	// APIKeyEnv names an environment variable to read the API key from when APIKey is empty
+	APIKeyEnv string `json:"api_key_env,omitempty" yaml:"api_key_env,omitempty"`
+
+	// APIKeys is a pool of API keys rotated on rate limits. Environment references are expanded.
+	APIKeys []string `json:"api_keys,omitempty" yaml:"api_keys,omitempty"` //nolint:gosec // G117: config field for API keys, not hardcoded credentials
+
+	BaseURL     string            `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	QueryParams map[string]string `json:"query_params,omitempty" yaml:"query_params,omitempty"`
+	ProxyURL    string            `json:"proxy_url,omitempty" yaml:"proxy_url,omitempty"`
+	Region      string            `json:"region,omitempty" yaml:"region,omitempty"`
+	Timeout     Duration          `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty" yaml:"extra,omitempty"`
+}
+
+// CircuitBreakerFileConfig is the serializable form of CircuitBreakerConfig.
+// Unset fields keep the values from DefaultCircuitBreakerConfig.
+type CircuitBreakerFileConfig struct {
+	FailureThreshold     int      `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty"`
+	SuccessThreshold     int      `json:"success_threshold,omitempty" yaml:"success_threshold,omitempty"`
+	Timeout              Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	FailureRateThreshold float64  `json:"failure_rate_threshold,omitempty" yaml:"failure_rate_threshold,omitempty"`
+	MinimumRequests      int      `json:"minimum_requests,omitempty" yaml:"minimum_requests,omitempty"`
+}
+
+// CacheFileConfig is the serializable form of CacheConfig. Unset fields keep
+// the values from DefaultCacheConfig.
+type CacheFileConfig struct {
+	TTL                Duration            `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+	TTLByModel         map[string]Duration `json:"ttl_by_model,omitempty" yaml:"ttl_by_model,omitempty"`
+	KeyPrefix          string              `json:"key_prefix,omitempty" yaml:"key_prefix,omitempty"`
+	SkipStreaming      *bool               `json:"skip_streaming,omitempty" yaml:"skip_streaming,omitempty"`
+	CacheableModels    []string            `json:"cacheable_models,omitempty" yaml:"cacheable_models,omitempty"`
+	ExcludeParameters  []string            `json:"exclude_parameters,omitempty" yaml:"exclude_parameters,omitempty"`
+	IncludeTemperature *bool               `json:"include_temperature,omitempty" yaml:"include_temperature,omitempty"`
+	IncludeSeed        *bool               `json:"include_seed,omitempty" yaml:"include_seed,omitempty"`
+	LegacyKeys         bool                `json:"legacy_keys,omitempty" yaml:"legacy_keys,omitempty"`
+	L1                 *L1CacheFileConfig  `json:"l1,omitempty" yaml:"l1,omitempty"`
+
+	// Dir stores the cache as files in this directory, using a FileKVS
+	Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
+}
+
+// MemoryFileConfig is the serializable form of MemoryConfig. Unset fields
+// keep the values from DefaultMemoryConfig.
+type MemoryFileConfig struct {
+	MaxMessages int    `json:"max_messages,omitempty" yaml:"max_messages,omitempty"`
+	TitleModel  string `json:"title_model,omitempty" yaml:"title_model,omitempty"`
+
+	// Dir stores conversations as JSON files in this directory, using a
+	// FileConversationStore. Without it, set Memory or MemoryStore in code.
+	Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
+}
+
+// L1CacheFileConfig is the serializable form of L1CacheConfig
+type L1CacheFileConfig struct {
+	MaxEntries int      `json:"max_entries,omitempty" yaml:"max_entries,omitempty"`
+	TTL        Duration `json:"ttl,omitempty" yaml:"ttl,omitempty"`
+}
+
+// TransportFileConfig is the serializable form of TransportConfig. Unset
+// fields keep the values from DefaultTransportConfig.
+type TransportFileConfig struct {
+	MaxIdleConns        int      `json:"max_idle_conns,omitempty" yaml:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost int      `json:"max_idle_conns_per_host,omitempty" yaml:"max_idle_conns_per_host,omitempty"`
+	MaxConnsPerHost     int      `json:"max_conns_per_host,omitempty" yaml:"max_conns_per_host,omitempty"`
+	IdleConnTimeout     Duration `json:"idle_conn_timeout,omitempty" yaml:"idle_conn_timeout,omitempty"`
+	TLSHandshakeTimeout Duration `json:"tls_handshake_timeout,omitempty" yaml:"tls_handshake_timeout,omitempty"`
+	TLSSessionCacheSize int      `json:"tls_session_cache_size,omitempty" yaml:"tls_session_cache_size,omitempty"`
+	DisableHTTP2        bool     `json:"disable_http2,omitempty" yaml:"disable_http2,omitempty"`
+	ProxyURL            string   `json:"proxy_url,omitempty" yaml:"proxy_url,omitempty"`
+}
+
+// Duration is a time.Duration that is written in config files as a string
+// such as "30s" or "5m". Plain numbers are interpreted as seconds.
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string or number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+// UnmarshalYAML parses a duration string or number of seconds
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	return d.set(v)
+}
+
+func (d *Duration) set(v any) error {
+	switch value := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(value * float64(time.Second))
+	case int:
+		*d = Duration(time.Duration(value) * time.Second)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("invalid duration %v", v)
+	}
+	return nil
+}
+
+// LoadConfig reads a YAML (.yaml, .yml) or JSON (.json) config file and returns
+// the corresponding ClientConfig. Environment references such as ${VAR} in
+// string values are expanded after parsing, so secrets can stay out of the
+// file. Other $ characters are kept as written.
+func LoadConfig(path string) (*ClientConfig, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is supplied by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var fileConfig FileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fileConfig)
+	case ".json":
+		err = json.Unmarshal(data, &fileConfig)
+	default:
+		return nil, fmt.Errorf("%w: unsupported config file extension %q", ErrInvalidConfiguration, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	expandEnvRefs(reflect.ValueOf(&fileConfig).Elem())
+
+	return fileConfig.ClientConfig()
+}
+
+// envRefPattern matches a ${VAR} environment reference
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvRefs replaces ${VAR} references in the string values held by v,
+// including those nested in structs, pointers, slices, and maps
+func expandEnvRefs(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(envRefPattern.ReplaceAllStringFunc(v.String(), func(ref string) string {
+				return os.Getenv(ref[2 : len(ref)-1])
+			}))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			expandEnvRefs(v.Elem())
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			expandEnvRefs(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			expandEnvRefs(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			expandEnvRefs(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.Interface:
+		if !v.IsNil() && v.CanSet() {
+			value := reflect.New(v.Elem().Type()).Elem()
+			value.Set(v.Elem())
+			expandEnvRefs(value)
+			v.Set(value)
+		}
+	}
+}
+
+// ClientConfig converts the file configuration to a ClientConfig
+func (f *FileConfig) ClientConfig() (*ClientConfig, error) {
+	if len(f.Providers) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	config := &ClientConfig{
+		ValidateTokens: f.ValidateTokens,
+	}
+
+	for i, p := range f.Providers {
+		if p.Provider == "" {
+			return nil, fmt.Errorf("%w: providers[%d] is missing provider", ErrInvalidConfiguration, i)
+		}
+		apiKey := p.APIKey
+		if apiKey == "" && p.APIKeyEnv != "" {
+			apiKey = os.Getenv(p.APIKeyEnv)
+		}
+		var query url.Values
+		if len(p.QueryParams) > 0 {
+			query = make(url.Values, len(p.QueryParams))
+			for name, value := range p.QueryParams {
+				query.Set(name, value)
+			}
+		}
+		config.Providers = append(config.Providers, ProviderConfig{
+			Provider:    p.Provider,
+			APIKey:      apiKey,
+			APIKeys:     p.APIKeys,
+			BaseURL:     p.BaseURL,
+			QueryParams: query,
+			ProxyURL:    p.ProxyURL,
+			Region:      p.Region,
+			Timeout:     time.Duration(p.Timeout),
+			Extra:       p.Extra,
+		})
+	}
+
+	if f.CircuitBreaker != nil {
+		cb := DefaultCircuitBreakerConfig()
+		if f.CircuitBreaker.FailureThreshold > 0 {
+			cb.FailureThreshold = f.CircuitBreaker.FailureThreshold
+		}
+		if f.CircuitBreaker.SuccessThreshold > 0 {
+			cb.SuccessThreshold = f.CircuitBreaker.SuccessThreshold
+		}
+		if f.CircuitBreaker.Timeout > 0 {
+			cb.Timeout = time.Duration(f.CircuitBreaker.Timeout)
+		}
+		if f.CircuitBreaker.FailureRateThreshold > 0 {
+			cb.FailureRateThreshold = f.CircuitBreaker.FailureRateThreshold
+		}
+		if f.CircuitBreaker.MinimumRequests > 0 {
+			cb.MinimumRequests = f.CircuitBreaker.MinimumRequests
+		}
+		config.CircuitBreakerConfig = &cb
+	}
+
+	if t := f.Transport; t != nil {
+		config.TransportConfig = &TransportConfig{
+			MaxIdleConns:        t.MaxIdleConns,
+			MaxIdleConnsPerHost: t.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     t.MaxConnsPerHost,
+			IdleConnTimeout:     time.Duration(t.IdleConnTimeout),
+			TLSHandshakeTimeout: time.Duration(t.TLSHandshakeTimeout),
+			TLSSessionCacheSize: t.TLSSessionCacheSize,
+			DisableHTTP2:        t.DisableHTTP2,
+			ProxyURL:            t.ProxyURL,
+		}
+	}
+
+	if f.Cache != nil {
+		cacheConfig := DefaultCacheConfig()
+		if f.Cache.TTL > 0 {
+			cacheConfig.TTL = time.Duration(f.Cache.TTL)
+		}
+		if f.Cache.TTLByModel != nil {
+			cacheConfig.TTLByModel = make(map[string]time.Duration, len(f.Cache.TTLByModel))
+			for model, ttl := range f.Cache.TTLByModel {
+				cacheConfig.TTLByModel[model] = time.Duration(ttl)
+			}
+		}
+		if f.Cache.KeyPrefix != "" {
+			cacheConfig.KeyPrefix = f.Cache.KeyPrefix
+		}
+		if f.Cache.SkipStreaming != nil {
+			cacheConfig.SkipStreaming = *f.Cache.SkipStreaming
+		}
+		if f.Cache.CacheableModels != nil {
+			cacheConfig.CacheableModels = f.Cache.CacheableModels
+		}
+		if f.Cache.ExcludeParameters != nil {
+			cacheConfig.ExcludeParameters = f.Cache.ExcludeParameters
+		}
+		if f.Cache.IncludeTemperature != nil {
+			cacheConfig.IncludeTemperature = *f.Cache.IncludeTemperature
+		}
+		if f.Cache.IncludeSeed != nil {
+			cacheConfig.IncludeSeed = *f.Cache.IncludeSeed
+		}
+		cacheConfig.LegacyKeys = f.Cache.LegacyKeys
+		if l1 := f.Cache.L1; l1 != nil {
+			cacheConfig.L1 = &L1CacheConfig{MaxEntries: l1.MaxEntries, TTL: time.Duration(l1.TTL)}
+		}
+		config.CacheConfig = &cacheConfig
+		if f.Cache.Dir != "" {
+			store, err := NewFileKVS(f.Cache.Dir)
+			if err != nil {
+				return nil, fmt.Errorf("%w: cache: %w", ErrInvalidConfiguration, err)
+			}
+			config.Cache = store
+		}
+	}
+
+	if f.Memory != nil {
+		memoryConfig := DefaultMemoryConfig()
+		if f.Memory.MaxMessages > 0 {
+			memoryConfig.MaxMessages = f.Memory.MaxMessages
+		}
+		memoryConfig.TitleModel = f.Memory.TitleModel
+		config.MemoryConfig = &memoryConfig
+		if f.Memory.Dir != "" {
+			store, err := NewFileConversationStore(f.Memory.Dir)
+			if err != nil {
+				return nil, fmt.Errorf("%w: memory: %w", ErrInvalidConfiguration, err)
+			}
+			config.MemoryStore = store
+		}
+	}
+
+	return config, nil
+}
+
+// envProviders lists the providers detected by ConfigFromEnv, in default
+// priority order, with the environment variables used for each
+var envProviders = []struct {
+	name       ProviderName
+	keyVars    []string
+	baseURLVar string
+}{
+	{ProviderNameOpenAI, []string{"OPENAI_API_KEY"}, "OPENAI_BASE_URL"},
+	{ProviderNameAnthropic, []string{"ANTHROPIC_API_KEY"}, "ANTHROPIC_BASE_URL"},
+	{ProviderNameGemini, []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"}, "GEMINI_BASE_URL"},
+	{ProviderNameXAI, []string{"XAI_API_KEY"}, "XAI_BASE_URL"},
+	{ProviderNameHuggingFace, []string{"HF_TOKEN", "HUGGINGFACE_API_KEY"}, "HF_BASE_URL"},
+	{ProviderNameOllama, nil, "OLLAMA_BASE_URL"},
+}
+
+// ConfigFromEnv builds a ClientConfig from environment variables.
+//
+// Providers are detected from their standard API key variables (OPENAI_API_KEY,
+// ANTHROPIC_API_KEY, GEMINI_API_KEY or GOOGLE_API_KEY, XAI_API_KEY, HF_TOKEN or
+// HUGGINGFACE_API_KEY) and, for Ollama, OLLAMA_BASE_URL. OMNILLM_PROVIDERS sets
+// the order explicitly as a comma-separated list (e.g., "anthropic,openai"); the
+// first is primary and the rest are fallbacks. OMNILLM_TIMEOUT sets a per-provider timeout,
+// OMNILLM_CIRCUIT_BREAKER=true enables the circuit breaker with defaults, and
+// OMNILLM_CACHE_TTL sets the cache TTL (a KVS backend must still be set in code).
+func ConfigFromEnv() (*ClientConfig, error) {
+	order := make([]ProviderName, 0, len(envProviders))
+	if list := os.Getenv("OMNILLM_PROVIDERS"); list != "" {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				order = append(order, ProviderName(strings.ToLower(name)))
+			}
+		}
+	} else {
+		for _, p := range envProviders {
+			if envProviderConfigured(p.name) {
+				order = append(order, p.name)
+			}
+		}
+	}
+	if len(order) == 0 {
+		return nil, ErrNoProviders
+	}
+
+	var timeout time.Duration
+	if v := os.Getenv("OMNILLM_TIMEOUT"); v != "" {
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: OMNILLM_TIMEOUT: %w", ErrInvalidConfiguration, err)
+		}
+		timeout = parsed
+	}
+
+	config := &ClientConfig{}
+	for _, name := range order {
+		pc := ProviderConfig{Provider: name, Timeout: timeout}
+		for _, p := range envProviders {
+			if p.name != name {
+				continue
+			}
+			for _, v := range p.keyVars {
+				if key := os.Getenv(v); key != "" {
+					pc.APIKey = key
+					break
+				}
+			}
+			if p.baseURLVar != "" {
+				pc.BaseURL = os.Getenv(p.baseURLVar)
+			}
+		}
+		config.Providers = append(config.Providers, pc)
+	}
+
+	if v := os.Getenv("OMNILLM_CIRCUIT_BREAKER"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: OMNILLM_CIRCUIT_BREAKER: %w", ErrInvalidConfiguration, err)
+		}
+		if enabled {
+			cb := DefaultCircuitBreakerConfig()
+			config.CircuitBreakerConfig = &cb
+		}
+	}
+
+	if v := os.Getenv("OMNILLM_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: OMNILLM_CACHE_TTL: %w", ErrInvalidConfiguration, err)
+		}
+		cacheConfig := DefaultCacheConfig()
+		cacheConfig.TTL = ttl
+		config.CacheConfig = &cacheConfig
+	}
+
+	return config, nil
+}
+
+// envProviderConfigured reports whether the environment configures the provider
+func envProviderConfigured(name ProviderName) bool {
+	for _, p := range envProviders {
+		if p.name != name {
+			continue
+		}
+		for _, v := range p.keyVars {
+			if os.Getenv(v) != "" {
+				return true
+			}
+		}
+		return len(p.keyVars) == 0 && p.baseURLVar != "" && os.Getenv(p.baseURLVar) != ""
+	}
+	return false
+}
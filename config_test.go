@@ -0,0 +1,344 @@
+package omnillm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_YAML(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-openai")
+	t.Setenv("TEST_ANTHROPIC_KEY", "sk-anthropic")
+
+	path := writeConfigFile(t, "omnillm.yaml", `
+providers:
+  - provider: openai
+    api_key: ${TEST_OPENAI_KEY}
+    base_url: https://proxy.example.com/v1
+    query_params:
+      api-version: "2024-10-21"
+    proxy_url: http://egress.internal:3128
+    timeout: 45s
+  - provider: anthropic
+    api_key_env: TEST_ANTHROPIC_KEY
+    extra:
+      beta: true
+circuit_breaker:
+  failure_threshold: 3
+  timeout: 1m
+cache:
+  ttl: 10m
+  ttl_by_model:
+    gpt-4o-mini: 48h
+  key_prefix: "test:cache"
+  include_temperature: false
+  l1:
+    max_entries: 500
+    ttl: 30s
+transport:
+  max_idle_conns_per_host: 64
+  idle_conn_timeout: 2m
+  proxy_url: http://proxy.internal:3128
+validate_tokens: true
+`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if len(config.Providers) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(config.Providers))
+	}
+	primary := config.Providers[0]
+	if primary.Provider != ProviderNameOpenAI || primary.APIKey != "sk-openai" {
+		t.Errorf("unexpected primary provider: %+v", primary)
+	}
+	if primary.BaseURL != "https://proxy.example.com/v1" {
+		t.Errorf("BaseURL = %q", primary.BaseURL)
+	}
+	if primary.QueryParams.Get("api-version") != "2024-10-21" || primary.ProxyURL != "http://egress.internal:3128" {
+		t.Errorf("QueryParams = %v, ProxyURL = %q", primary.QueryParams, primary.ProxyURL)
+	}
+	if primary.Timeout != 45*time.Second {
+		t.Errorf("Timeout = %v, want 45s", primary.Timeout)
+	}
+	fallback := config.Providers[1]
+	if fallback.Provider != ProviderNameAnthropic || fallback.APIKey != "sk-anthropic" {
+		t.Errorf("unexpected fallback provider: %+v", fallback)
+	}
+	if fallback.Extra["beta"] != true {
+		t.Errorf("Extra = %v", fallback.Extra)
+	}
+
+	cb := config.CircuitBreakerConfig
+	if cb == nil {
+		t.Fatal("expected circuit breaker config")
+	}
+	if cb.FailureThreshold != 3 || cb.Timeout != time.Minute {
+		t.Errorf("unexpected circuit breaker config: %+v", cb)
+	}
+	if cb.SuccessThreshold != DefaultCircuitBreakerConfig().SuccessThreshold {
+		t.Errorf("SuccessThreshold = %d, want default", cb.SuccessThreshold)
+	}
+
+	cache := config.CacheConfig
+	if cache == nil {
+		t.Fatal("expected cache config")
+	}
+	if cache.TTL != 10*time.Minute || cache.KeyPrefix != "test:cache" || cache.TTLByModel["gpt-4o-mini"] != 48*time.Hour {
+		t.Errorf("unexpected cache config: %+v", cache)
+	}
+	if cache.IncludeTemperature {
+		t.Error("IncludeTemperature should be false")
+	}
+	if cache.L1 == nil || cache.L1.MaxEntries != 500 || cache.L1.TTL != 30*time.Second {
+		t.Errorf("unexpected L1 config: %+v", cache.L1)
+	}
+	if !cache.SkipStreaming {
+		t.Error("SkipStreaming should keep its default")
+	}
+
+	transport := config.TransportConfig
+	if transport == nil || transport.MaxIdleConnsPerHost != 64 || transport.IdleConnTimeout != 2*time.Minute || transport.ProxyURL != "http://proxy.internal:3128" {
+		t.Errorf("unexpected transport config: %+v", transport)
+	}
+
+	if !config.ValidateTokens {
+		t.Error("ValidateTokens should be true")
+	}
+}
+
+func TestLoadConfig_JSON(t *testing.T) {
+	path := writeConfigFile(t, "omnillm.json", `{
+  "providers": [
+    {"provider": "ollama", "base_url": "http://localhost:11434", "timeout": 120},
+    {"provider": "bedrock", "region": "us-west-2"}
+  ]
+}`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if len(config.Providers) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(config.Providers))
+	}
+	if config.Providers[0].Timeout != 2*time.Minute {
+		t.Errorf("Timeout = %v, want 2m", config.Providers[0].Timeout)
+	}
+	if config.Providers[1].Region != "us-west-2" {
+		t.Errorf("Region = %q", config.Providers[1].Region)
+	}
+	if config.CircuitBreakerConfig != nil || config.CacheConfig != nil {
+		t.Error("unset sections should stay nil")
+	}
+}
+
+func TestLoadConfig_EnvReferences(t *testing.T) {
+	t.Setenv("TEST_TRICKY_KEY", "sk-\"quoted\": value\ninjected: true")
+	t.Setenv("TEST_REGION", "eu-west-1")
+
+	path := writeConfigFile(t, "omnillm.yaml", `
+providers:
+  - provider: openai
+    api_key: ${TEST_TRICKY_KEY}
+    base_url: https://proxy.example.com/$v1
+    query_params:
+      sig: "abc$def"
+    extra:
+      region: ${TEST_REGION}
+      tags: ["${TEST_REGION}", "$HOME"]
+cache:
+  key_prefix: "cache$prefix:"
+`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	p := config.Providers[0]
+	if p.APIKey != "sk-\"quoted\": value\ninjected: true" {
+		t.Errorf("APIKey = %q", p.APIKey)
+	}
+	if p.BaseURL != "https://proxy.example.com/$v1" {
+		t.Errorf("BaseURL = %q, want the literal $ kept", p.BaseURL)
+	}
+	if p.QueryParams.Get("sig") != "abc$def" {
+		t.Errorf("sig = %q, want the literal $ kept", p.QueryParams.Get("sig"))
+	}
+	if p.Extra["region"] != "eu-west-1" {
+		t.Errorf("extra region = %v", p.Extra["region"])
+	}
+	if tags, _ := p.Extra["tags"].([]any); len(tags) != 2 || tags[0] != "eu-west-1" || tags[1] != "$HOME" {
+		t.Errorf("extra tags = %v", p.Extra["tags"])
+	}
+	if config.CacheConfig.KeyPrefix != "cache$prefix:" {
+		t.Errorf("KeyPrefix = %q, want the literal $ kept", config.CacheConfig.KeyPrefix)
+	}
+}
+
+func TestLoadConfig_FileBackends(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfigFile(t, "omnillm.yaml", `
+providers:
+  - provider: ollama
+cache:
+  dir: `+filepath.Join(dir, "cache")+`
+memory:
+  dir: `+filepath.Join(dir, "memory")+`
+  max_messages: 20
+`)
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if _, ok := config.Cache.(*FileKVS); !ok {
+		t.Errorf("Cache = %T, want *FileKVS", config.Cache)
+	}
+	if _, ok := config.MemoryStore.(*FileConversationStore); !ok {
+		t.Errorf("MemoryStore = %T, want *FileConversationStore", config.MemoryStore)
+	}
+	if config.MemoryConfig == nil || config.MemoryConfig.MaxMessages != 20 {
+		t.Errorf("MemoryConfig = %+v", config.MemoryConfig)
+	}
+	for _, sub := range []string{"cache", "memory"} {
+		if _, err := os.Stat(filepath.Join(dir, sub)); err != nil {
+			t.Errorf("%s directory not created: %v", sub, err)
+		}
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr error
+	}{
+		{"unsupported extension", "omnillm.toml", "", ErrInvalidConfiguration},
+		{"no providers", "omnillm.yaml", "providers: []\n", ErrNoProviders},
+		{"missing provider name", "omnillm.yaml", "providers:\n  - api_key: x\n", ErrInvalidConfiguration},
+		{"invalid duration", "omnillm.yaml", "providers:\n  - provider: openai\n    timeout: soon\n", nil},
+		{"invalid json", "omnillm.json", "{", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfigFile(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+// clearProviderEnv unsets every variable ConfigFromEnv reads
+func clearProviderEnv(t *testing.T) {
+	t.Helper()
+	vars := []string{"OMNILLM_PROVIDERS", "OMNILLM_TIMEOUT", "OMNILLM_CIRCUIT_BREAKER", "OMNILLM_CACHE_TTL"}
+	for _, p := range envProviders {
+		vars = append(vars, p.keyVars...)
+		if p.baseURLVar != "" {
+			vars = append(vars, p.baseURLVar)
+		}
+	}
+	for _, v := range vars {
+		t.Setenv(v, "")
+	}
+}
+
+func TestConfigFromEnv_Detect(t *testing.T) {
+	clearProviderEnv(t)
+	t.Setenv("ANTHROPIC_API_KEY", "sk-anthropic")
+	t.Setenv("GOOGLE_API_KEY", "google-key")
+	t.Setenv("OLLAMA_BASE_URL", "http://localhost:11434")
+	t.Setenv("OMNILLM_TIMEOUT", "20s")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv failed: %v", err)
+	}
+
+	want := []ProviderName{ProviderNameAnthropic, ProviderNameGemini, ProviderNameOllama}
+	if len(config.Providers) != len(want) {
+		t.Fatalf("expected %d providers, got %+v", len(want), config.Providers)
+	}
+	for i, name := range want {
+		if config.Providers[i].Provider != name {
+			t.Errorf("Providers[%d] = %s, want %s", i, config.Providers[i].Provider, name)
+		}
+		if config.Providers[i].Timeout != 20*time.Second {
+			t.Errorf("Providers[%d].Timeout = %v", i, config.Providers[i].Timeout)
+		}
+	}
+	if config.Providers[1].APIKey != "google-key" {
+		t.Errorf("Gemini APIKey = %q", config.Providers[1].APIKey)
+	}
+	if config.Providers[2].BaseURL != "http://localhost:11434" {
+		t.Errorf("Ollama BaseURL = %q", config.Providers[2].BaseURL)
+	}
+	if config.CircuitBreakerConfig != nil || config.CacheConfig != nil {
+		t.Error("circuit breaker and cache should be unset")
+	}
+}
+
+func TestConfigFromEnv_ExplicitOrder(t *testing.T) {
+	clearProviderEnv(t)
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+	t.Setenv("XAI_API_KEY", "xai-key")
+	t.Setenv("OMNILLM_PROVIDERS", "xai, OpenAI")
+	t.Setenv("OMNILLM_CIRCUIT_BREAKER", "true")
+	t.Setenv("OMNILLM_CACHE_TTL", "5m")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv failed: %v", err)
+	}
+	if len(config.Providers) != 2 ||
+		config.Providers[0].Provider != ProviderNameXAI ||
+		config.Providers[1].Provider != ProviderNameOpenAI {
+		t.Fatalf("unexpected providers: %+v", config.Providers)
+	}
+	if config.Providers[0].APIKey != "xai-key" || config.Providers[1].APIKey != "sk-openai" {
+		t.Errorf("API keys not resolved: %+v", config.Providers)
+	}
+	if config.CircuitBreakerConfig == nil {
+		t.Error("expected circuit breaker config")
+	}
+	if config.CacheConfig == nil || config.CacheConfig.TTL != 5*time.Minute {
+		t.Errorf("unexpected cache config: %+v", config.CacheConfig)
+	}
+}
+
+func TestConfigFromEnv_Errors(t *testing.T) {
+	clearProviderEnv(t)
+	if _, err := ConfigFromEnv(); !errors.Is(err, ErrNoProviders) {
+		t.Errorf("error = %v, want ErrNoProviders", err)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "sk-openai")
+	t.Setenv("OMNILLM_TIMEOUT", "soon")
+	if _, err := ConfigFromEnv(); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("error = %v, want ErrInvalidConfiguration", err)
+	}
+}
@@ -1,24 +1,29 @@
 package omnillm
 
-import "github.com/plexusone/omnillm/models"
+import (
+	"github.com/plexusone/omnillm/models"
+	"github.com/plexusone/omnillm/provider"
+)
 
 const (
-	EnvVarAnthropicAPIKey = "ANTHROPIC_API_KEY" // #nosec G101
-	EnvVarOpenAIAPIKey    = "OPENAI_API_KEY"    // #nosec G101
-	EnvVarGeminiAPIKey    = "GEMINI_API_KEY"    // #nosec G101
-	EnvVarXAIAPIKey       = "XAI_API_KEY"       // #nosec G101
+	EnvVarAnthropicAPIKey  = "ANTHROPIC_API_KEY" // #nosec G101
+	EnvVarOpenAIAPIKey     = "OPENAI_API_KEY"    // #nosec G101
+	EnvVarGeminiAPIKey     = "GEMINI_API_KEY"    // #nosec G101
+	EnvVarXAIAPIKey        = "XAI_API_KEY"       // #nosec G101
+	EnvVarHuggingFaceToken = "HF_TOKEN"          // #nosec G101
 )
 
 // ProviderName represents the different LLM provider names
-type ProviderName string
+type ProviderName = provider.ProviderName
 
 const (
-	ProviderNameOpenAI    ProviderName = "openai"
-	ProviderNameAnthropic ProviderName = "anthropic"
-	ProviderNameBedrock   ProviderName = "bedrock"
-	ProviderNameOllama    ProviderName = "ollama"
-	ProviderNameGemini    ProviderName = "gemini"
-	ProviderNameXAI       ProviderName = "xai"
+	ProviderNameOpenAI      ProviderName = "openai"
+	ProviderNameAnthropic   ProviderName = "anthropic"
+	ProviderNameBedrock     ProviderName = "bedrock"
+	ProviderNameOllama      ProviderName = "ollama"
+	ProviderNameGemini      ProviderName = "gemini"
+	ProviderNameXAI         ProviderName = "xai"
+	ProviderNameHuggingFace ProviderName = "huggingface"
 )
 
 // Common model constants for each provider.
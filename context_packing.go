@@ -0,0 +1,309 @@
+package omnillm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// PackStrategy selects the algorithm used to choose context items
+type PackStrategy string
+
+const (
+	// PackStrategyGreedy adds items in rank order, skipping any that no longer fit.
+	PackStrategyGreedy PackStrategy = "greedy"
+	// PackStrategyKnapsack maximizes the total score that fits within the budget.
+	// Falls back to greedy when the problem is too large to solve exactly.
+	PackStrategyKnapsack PackStrategy = "knapsack"
+)
+
+// PackOrder controls the order of selected items in the packed result
+type PackOrder string
+
+const (
+	// PackOrderRank orders selected items by priority, then score.
+	PackOrderRank PackOrder = "rank"
+	// PackOrderInput preserves the original input order (e.g., chronological memory).
+	PackOrderInput PackOrder = "input"
+)
+
+// Drop reasons reported for items that were not packed
+const (
+	DropReasonTooLarge       = "item alone exceeds budget"
+	DropReasonBudgetExceeded = "budget exhausted"
+)
+
+// maxKnapsackCells bounds the dynamic programming table size for knapsack packing
+const maxKnapsackCells = 1 << 24
+
+// ContextItem is a candidate piece of context, such as a retrieved document,
+// a memory message, or a tool result.
+type ContextItem struct {
+	// ID identifies the item in pack results
+	ID string
+
+	// Message is the content to inject
+	Message Message
+
+	// Priority is a hard tier; all higher-priority items are considered
+	// before any lower-priority item.
+	Priority int
+
+	// Score ranks items within a priority tier (e.g., retrieval similarity)
+	Score float64
+
+	// Tokens is the item's token cost. If zero, it is estimated.
+	Tokens int
+}
+
+// DroppedItem describes a context item that was not packed
+type DroppedItem struct {
+	Item   ContextItem
+	Reason string
+}
+
+// PackResult contains the outcome of packing context items
+type PackResult struct {
+	// Selected contains the packed items in the configured order
+	Selected []ContextItem
+
+	// Dropped contains the items that did not fit, in rank order
+	Dropped []DroppedItem
+
+	// TokensUsed is the total token cost of the selected items
+	TokensUsed int
+
+	// Budget is the token budget that was applied
+	Budget int
+}
+
+// Messages returns the selected items as messages
+func (r *PackResult) Messages() []Message {
+	messages := make([]Message, 0, len(r.Selected))
+	for _, item := range r.Selected {
+		messages = append(messages, item.Message)
+	}
+	return messages
+}
+
+// Text joins the content of the selected items with the given separator
+func (r *PackResult) Text(separator string) string {
+	contents := make([]string, 0, len(r.Selected))
+	for _, item := range r.Selected {
+		contents = append(contents, item.Message.Content)
+	}
+	return strings.Join(contents, separator)
+}
+
+// ContextPackerConfig configures a ContextPacker
+type ContextPackerConfig struct {
+	// Budget is the maximum number of tokens to pack. Required.
+	Budget int
+
+	// Strategy is the selection algorithm.
+	// Default: PackStrategyGreedy
+	Strategy PackStrategy
+
+	// Order is the order of selected items in the result.
+	// Default: PackOrderRank
+	Order PackOrder
+
+	// Estimator estimates item token costs when ContextItem.Tokens is zero.
+	// Default: NewTokenEstimator(DefaultTokenEstimatorConfig())
+	Estimator TokenEstimator
+
+	// Model is passed to the estimator
+	Model string
+}
+
+// ContextPacker selects the best-fitting subset of context items for a token budget
+type ContextPacker struct {
+	config ContextPackerConfig
+}
+
+// NewContextPacker creates a new context packer.
+// If config has zero values, defaults are used for those fields.
+func NewContextPacker(config ContextPackerConfig) *ContextPacker {
+	if config.Strategy == "" {
+		config.Strategy = PackStrategyGreedy
+	}
+	if config.Order == "" {
+		config.Order = PackOrderRank
+	}
+	if config.Estimator == nil {
+		config.Estimator = NewTokenEstimator(DefaultTokenEstimatorConfig())
+	}
+	return &ContextPacker{config: config}
+}
+
+// rankedItem tracks an item with its input position and resolved token cost
+type rankedItem struct {
+	item  ContextItem
+	index int
+}
+
+// Pack selects and orders the items that fit within the budget and reports
+// the ones that were dropped.
+func (p *ContextPacker) Pack(items []ContextItem) (*PackResult, error) {
+	if p.config.Budget <= 0 {
+		return nil, fmt.Errorf("context packer budget must be positive, got %d", p.config.Budget)
+	}
+
+	ranked := make([]rankedItem, 0, len(items))
+	for i, item := range items {
+		if item.Tokens <= 0 {
+			tokens, err := p.config.Estimator.EstimateTokens(p.config.Model, []provider.Message{item.Message})
+			if err != nil {
+				return nil, fmt.Errorf("failed to estimate tokens for context item %q: %w", item.ID, err)
+			}
+			item.Tokens = tokens
+		}
+		ranked = append(ranked, rankedItem{item: item, index: i})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].item.Priority != ranked[j].item.Priority {
+			return ranked[i].item.Priority > ranked[j].item.Priority
+		}
+		return ranked[i].item.Score > ranked[j].item.Score
+	})
+
+	var selected []bool
+	switch p.config.Strategy {
+	case PackStrategyGreedy:
+		selected = packGreedy(ranked, p.config.Budget)
+	case PackStrategyKnapsack:
+		selected = packKnapsack(ranked, p.config.Budget)
+	default:
+		return nil, fmt.Errorf("unknown pack strategy %q", p.config.Strategy)
+	}
+
+	result := &PackResult{Budget: p.config.Budget}
+	var chosen []rankedItem
+	for i, r := range ranked {
+		switch {
+		case selected[i]:
+			chosen = append(chosen, r)
+			result.TokensUsed += r.item.Tokens
+		case r.item.Tokens > p.config.Budget:
+			result.Dropped = append(result.Dropped, DroppedItem{Item: r.item, Reason: DropReasonTooLarge})
+		default:
+			result.Dropped = append(result.Dropped, DroppedItem{Item: r.item, Reason: DropReasonBudgetExceeded})
+		}
+	}
+
+	if p.config.Order == PackOrderInput {
+		sort.SliceStable(chosen, func(i, j int) bool { return chosen[i].index < chosen[j].index })
+	}
+	for _, r := range chosen {
+		result.Selected = append(result.Selected, r.item)
+	}
+
+	return result, nil
+}
+
+// packGreedy selects items in rank order while they fit
+func packGreedy(ranked []rankedItem, budget int) []bool {
+	selected := make([]bool, len(ranked))
+	remaining := budget
+	for i, r := range ranked {
+		if r.item.Tokens <= remaining {
+			selected[i] = true
+			remaining -= r.item.Tokens
+		}
+	}
+	return selected
+}
+
+// packKnapsack solves a 0/1 knapsack per priority tier, highest tier first,
+// so lower tiers only use the budget left over by higher ones.
+func packKnapsack(ranked []rankedItem, budget int) []bool {
+	selected := make([]bool, len(ranked))
+	remaining := budget
+
+	for start := 0; start < len(ranked); {
+		end := start
+		for end < len(ranked) && ranked[end].item.Priority == ranked[start].item.Priority {
+			end++
+		}
+		tier := ranked[start:end]
+
+		var picks []bool
+		if (len(tier)+1)*(remaining+1) > maxKnapsackCells {
+			picks = packGreedy(tier, remaining)
+		} else {
+			picks = knapsack(tier, remaining)
+		}
+		for i, ok := range picks {
+			if ok {
+				selected[start+i] = true
+				remaining -= tier[i].item.Tokens
+			}
+		}
+		start = end
+	}
+
+	return selected
+}
+
+// knapsack returns the subset of items maximizing total score within capacity.
+// Non-positive scores are treated as a small positive value so that items
+// without scores are still packed when space allows.
+func knapsack(items []rankedItem, capacity int) []bool {
+	n := len(items)
+	best := make([][]float64, n+1)
+	for i := range best {
+		best[i] = make([]float64, capacity+1)
+	}
+
+	for i := 1; i <= n; i++ {
+		weight := items[i-1].item.Tokens
+		value := knapsackValue(items[i-1].item.Score)
+		for c := 0; c <= capacity; c++ {
+			best[i][c] = best[i-1][c]
+			if weight <= c {
+				if v := best[i-1][c-weight] + value; v > best[i][c] {
+					best[i][c] = v
+				}
+			}
+		}
+	}
+
+	picks := make([]bool, n)
+	c := capacity
+	for i := n; i > 0; i-- {
+		if best[i][c] != best[i-1][c] {
+			picks[i-1] = true
+			c -= items[i-1].item.Tokens
+		}
+	}
+	return picks
+}
+
+func knapsackValue(score float64) float64 {
+	if score <= 0 {
+		return 1e-9
+	}
+	return score
+}
+
+// ContextItemsFromMessages converts conversation messages into context items
+// scored by recency, so packing keeps the most recent messages. System
+// messages are given a higher priority so they are always kept first.
+func ContextItemsFromMessages(messages []Message) []ContextItem {
+	items := make([]ContextItem, 0, len(messages))
+	for i, msg := range messages {
+		item := ContextItem{
+			ID:      fmt.Sprintf("message-%d", i),
+			Message: msg,
+			Score:   float64(i + 1),
+		}
+		if msg.Role == RoleSystem {
+			item.Priority = 1
+		}
+		items = append(items, item)
+	}
+	return items
+}
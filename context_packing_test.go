@@ -0,0 +1,144 @@
+package omnillm
+
+import "testing"
+
+func textItem(id string, tokens int, priority int, score float64) ContextItem {
+	return ContextItem{
+		ID:       id,
+		Message:  Message{Role: RoleUser, Content: id},
+		Priority: priority,
+		Score:    score,
+		Tokens:   tokens,
+	}
+}
+
+func selectedIDs(result *PackResult) []string {
+	ids := make([]string, 0, len(result.Selected))
+	for _, item := range result.Selected {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestContextPacker_Greedy(t *testing.T) {
+	packer := NewContextPacker(ContextPackerConfig{Budget: 100})
+	result, err := packer.Pack([]ContextItem{
+		textItem("low", 30, 0, 0.2),
+		textItem("high", 60, 0, 0.9),
+		textItem("mid", 50, 0, 0.5),
+		textItem("huge", 500, 0, 1.0),
+	})
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+
+	if got, want := selectedIDs(result), []string{"high", "low"}; !equalIDs(got, want) {
+		t.Errorf("Selected = %v, want %v", got, want)
+	}
+	if result.TokensUsed != 90 {
+		t.Errorf("TokensUsed = %d, want 90", result.TokensUsed)
+	}
+	if len(result.Dropped) != 2 {
+		t.Fatalf("Dropped = %d items, want 2", len(result.Dropped))
+	}
+	if result.Dropped[0].Item.ID != "huge" || result.Dropped[0].Reason != DropReasonTooLarge {
+		t.Errorf("Dropped[0] = %+v, want huge/too large", result.Dropped[0])
+	}
+	if result.Dropped[1].Item.ID != "mid" || result.Dropped[1].Reason != DropReasonBudgetExceeded {
+		t.Errorf("Dropped[1] = %+v, want mid/budget exhausted", result.Dropped[1])
+	}
+}
+
+func TestContextPacker_Knapsack(t *testing.T) {
+	items := []ContextItem{
+		textItem("a", 60, 0, 0.9),
+		textItem("b", 50, 0, 0.6),
+		textItem("c", 50, 0, 0.6),
+	}
+
+	greedy, err := NewContextPacker(ContextPackerConfig{Budget: 100}).Pack(items)
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	if got := selectedIDs(greedy); !equalIDs(got, []string{"a"}) {
+		t.Errorf("greedy Selected = %v, want [a]", got)
+	}
+
+	knap, err := NewContextPacker(ContextPackerConfig{Budget: 100, Strategy: PackStrategyKnapsack}).Pack(items)
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	if got := selectedIDs(knap); !equalIDs(got, []string{"b", "c"}) {
+		t.Errorf("knapsack Selected = %v, want [b c]", got)
+	}
+}
+
+func TestContextPacker_PriorityTiers(t *testing.T) {
+	packer := NewContextPacker(ContextPackerConfig{Budget: 100, Strategy: PackStrategyKnapsack})
+	result, err := packer.Pack([]ContextItem{
+		textItem("scored", 50, 0, 10),
+		textItem("pinned", 80, 1, 0),
+	})
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	if got := selectedIDs(result); !equalIDs(got, []string{"pinned"}) {
+		t.Errorf("Selected = %v, want [pinned]", got)
+	}
+}
+
+func TestContextPacker_InputOrder(t *testing.T) {
+	messages := []Message{
+		{Role: RoleSystem, Content: "system"},
+		{Role: RoleUser, Content: "first"},
+		{Role: RoleAssistant, Content: "second"},
+		{Role: RoleUser, Content: "third"},
+	}
+	items := ContextItemsFromMessages(messages)
+	for i := range items {
+		items[i].Tokens = 10
+	}
+
+	packer := NewContextPacker(ContextPackerConfig{Budget: 30, Order: PackOrderInput})
+	result, err := packer.Pack(items)
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+
+	got := result.Messages()
+	if len(got) != 3 || got[0].Content != "system" || got[1].Content != "second" || got[2].Content != "third" {
+		t.Errorf("Messages() = %+v, want system, second, third", got)
+	}
+}
+
+func TestContextPacker_EstimatesTokens(t *testing.T) {
+	packer := NewContextPacker(ContextPackerConfig{Budget: 1000})
+	result, err := packer.Pack([]ContextItem{{ID: "doc", Message: Message{Role: RoleUser, Content: "some retrieved text"}}})
+	if err != nil {
+		t.Fatalf("Pack() error = %v", err)
+	}
+	if len(result.Selected) != 1 || result.Selected[0].Tokens == 0 {
+		t.Errorf("expected estimated tokens on selected item, got %+v", result.Selected)
+	}
+	if result.Text("\n") != "some retrieved text" {
+		t.Errorf("Text() = %q", result.Text("\n"))
+	}
+}
+
+func TestContextPacker_InvalidBudget(t *testing.T) {
+	if _, err := NewContextPacker(ContextPackerConfig{}).Pack(nil); err == nil {
+		t.Error("Pack() expected error for zero budget")
+	}
+}
@@ -0,0 +1,145 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/grokify/sogo/database/kvs"
+)
+
+// ErrConversationNotFound is returned by ConversationStore.Load for a session
+// with no stored conversation
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationStore persists conversations for a MemoryManager. Built-in
+// implementations store them in a KVS, a SQL database, or JSON files; any
+// other backend can be used by implementing this interface.
+type ConversationStore interface {
+	// Load returns the conversation of a session, or an error matching
+	// ErrConversationNotFound if there is none
+	Load(ctx context.Context, sessionID string) (*ConversationMemory, error)
+
+	// Save stores a conversation, replacing any stored for its session
+	Save(ctx context.Context, conversation *ConversationMemory) error
+
+	// Delete removes the conversation of a session. Deleting a session with
+	// no conversation is not an error.
+	Delete(ctx context.Context, sessionID string) error
+
+	// List returns a summary of each stored conversation
+	List(ctx context.Context) ([]ConversationSummary, error)
+}
+
+// summary returns the ConversationSummary of c
+func (c *ConversationMemory) summary() ConversationSummary {
+	return ConversationSummary{
+		SessionID:    c.SessionID,
+		Title:        c.Title,
+		MessageCount: len(c.Messages),
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
+
+// KVSConversationStore stores conversations in a KVS as JSON under
+// "<prefix>:<session ID>". Since the KVS interface cannot list or delete
+// keys, it keeps an index of the conversations under "<prefix>.index" and
+// marks deleted conversations with an empty value.
+type KVSConversationStore struct {
+	kvs    kvs.Client
+	prefix string
+
+	indexMu sync.Mutex // Serializes updates to the index
+}
+
+// NewKVSConversationStore returns a store that keeps conversations in
+// kvsClient under keys starting with keyPrefix
+func NewKVSConversationStore(kvsClient kvs.Client, keyPrefix string) *KVSConversationStore {
+	return &KVSConversationStore{kvs: kvsClient, prefix: keyPrefix}
+}
+
+// Load implements ConversationStore
+func (s *KVSConversationStore) Load(ctx context.Context, sessionID string) (*ConversationMemory, error) {
+	var conversation ConversationMemory
+	// The KVS interface does not tell a missing key from a failed read
+	if err := s.kvs.GetAny(ctx, s.buildKey(sessionID), &conversation); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConversationNotFound, err)
+	}
+	return &conversation, nil
+}
+
+// Save implements ConversationStore
+func (s *KVSConversationStore) Save(ctx context.Context, conversation *ConversationMemory) error {
+	if err := s.kvs.SetAny(ctx, s.buildKey(conversation.SessionID), conversation); err != nil {
+		return err
+	}
+	return s.updateIndex(ctx, conversation.SessionID, func(summaries []ConversationSummary, i int) []ConversationSummary {
+		if i < 0 {
+			return append(summaries, conversation.summary())
+		}
+		summaries[i] = conversation.summary()
+		return summaries
+	})
+}
+
+// Delete implements ConversationStore
+func (s *KVSConversationStore) Delete(ctx context.Context, sessionID string) error {
+	// Since the KVS interface doesn't have a Delete method, we'll set an empty value
+	// This is a limitation of the current KVS interface
+	if err := s.kvs.SetString(ctx, s.buildKey(sessionID), ""); err != nil {
+		return err
+	}
+	return s.updateIndex(ctx, sessionID, func(summaries []ConversationSummary, i int) []ConversationSummary {
+		if i < 0 {
+			return summaries
+		}
+		return slices.Delete(summaries, i, i+1)
+	})
+}
+
+// List implements ConversationStore. Conversations saved before the index
+// existed are not listed until they are saved again.
+func (s *KVSConversationStore) List(ctx context.Context) ([]ConversationSummary, error) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	return s.loadIndex(ctx), nil
+}
+
+// updateIndex applies update to the index, passing the position of sessionID
+// or -1 if it is not indexed
+func (s *KVSConversationStore) updateIndex(ctx context.Context, sessionID string, update func(summaries []ConversationSummary, i int) []ConversationSummary) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	summaries := s.loadIndex(ctx)
+	i := slices.IndexFunc(summaries, func(summary ConversationSummary) bool {
+		return summary.SessionID == sessionID
+	})
+	if err := s.kvs.SetAny(ctx, s.indexKey(), update(summaries, i)); err != nil {
+		return fmt.Errorf("failed to update conversation index: %w", err)
+	}
+	return nil
+}
+
+// loadIndex returns the index, or nil if there is none
+func (s *KVSConversationStore) loadIndex(ctx context.Context) []ConversationSummary {
+	var summaries []ConversationSummary
+	if err := s.kvs.GetAny(ctx, s.indexKey(), &summaries); err != nil {
+		return nil
+	}
+	return summaries
+}
+
+// buildKey constructs the storage key for a session
+func (s *KVSConversationStore) buildKey(sessionID string) string {
+	return fmt.Sprintf("%s:%s", s.prefix, sessionID)
+}
+
+// indexKey is the storage key of the index. It uses a different separator
+// from buildKey so that no session ID maps to it.
+func (s *KVSConversationStore) indexKey() string {
+	return s.prefix + ".index"
+}
@@ -0,0 +1,129 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileConversationStore stores each conversation as a JSON file in a
+// directory, for local development and command-line tools that should not
+// need a database. Files are replaced atomically, but the store is not meant
+// for several processes writing the same session.
+type FileConversationStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileConversationStore returns a store that keeps conversations in dir,
+// creating it if needed
+func NewFileConversationStore(dir string) (*FileConversationStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create conversation directory: %w", err)
+	}
+	return &FileConversationStore{dir: dir}, nil
+}
+
+// Load implements ConversationStore
+func (s *FileConversationStore) Load(_ context.Context, sessionID string) (*ConversationMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(s.path(sessionID))
+}
+
+// Save implements ConversationStore
+func (s *FileConversationStore) Save(_ context.Context, conversation *ConversationMemory) error {
+	data, err := json.MarshalIndent(conversation, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeFileAtomic(s.path(conversation.SessionID), data)
+}
+
+// Delete implements ConversationStore
+func (s *FileConversationStore) Delete(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(sessionID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// List implements ConversationStore. It reads every conversation file.
+func (s *FileConversationStore) List(_ context.Context) ([]ConversationSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	var summaries []ConversationSummary
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		conversation, err := s.load(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, conversation.summary())
+	}
+	return summaries, nil
+}
+
+// load reads the conversation in path
+func (s *FileConversationStore) load(path string) (*ConversationMemory, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is built from the store's directory
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	var conversation ConversationMemory
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %w", filepath.Base(path), err)
+	}
+	return &conversation, nil
+}
+
+// path returns the file of a session. Session IDs are escaped, so they
+// cannot name files outside the directory.
+func (s *FileConversationStore) path(sessionID string) string {
+	return filepath.Join(s.dir, url.PathEscape(sessionID)+".json")
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path,
+// so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package omnillm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultConversationTable is the table SQLConversationStore uses by default
+const DefaultConversationTable = "omnillm_conversations"
+
+// sqlIdentifier matches the table names SQLConversationStore accepts
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLConversationStoreConfig configures a SQLConversationStore
+type SQLConversationStoreConfig struct {
+	// Table is the name of the conversation table, optionally qualified by
+	// a schema. Default: DefaultConversationTable
+	Table string
+
+	// Placeholder returns the bind parameter for the nth argument of a
+	// statement, counting from 1. Default: "?" for every argument, as used
+	// by SQLite and MySQL. Use DollarPlaceholder for PostgreSQL.
+	Placeholder func(n int) string
+}
+
+// DollarPlaceholder returns PostgreSQL-style bind parameters: $1, $2, ...
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// SQLConversationStore stores conversations in a SQL database through
+// database/sql, one row per session with the conversation as JSON. It works
+// with any driver; call CreateTable to create its table.
+type SQLConversationStore struct {
+	db    *sql.DB
+	table string
+
+	loadQuery   string
+	updateQuery string
+	insertQuery string
+	deleteQuery string
+	listQuery   string
+}
+
+// NewSQLConversationStore returns a store that keeps conversations in db
+func NewSQLConversationStore(db *sql.DB, config SQLConversationStoreConfig) (*SQLConversationStore, error) {
+	table := config.Table
+	if table == "" {
+		table = DefaultConversationTable
+	}
+	if !sqlIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("%w: invalid table name %q", ErrInvalidConfiguration, table)
+	}
+	p := config.Placeholder
+	if p == nil {
+		p = func(int) string { return "?" }
+	}
+
+	return &SQLConversationStore{
+		db:        db,
+		table:     table,
+		loadQuery: fmt.Sprintf("SELECT data FROM %s WHERE session_id = %s", table, p(1)),
+		updateQuery: fmt.Sprintf("UPDATE %s SET title = %s, message_count = %s, created_at = %s, updated_at = %s, data = %s WHERE session_id = %s",
+			table, p(1), p(2), p(3), p(4), p(5), p(6)),
+		insertQuery: fmt.Sprintf("INSERT INTO %s (title, message_count, created_at, updated_at, data, session_id) VALUES (%s, %s, %s, %s, %s, %s)",
+			table, p(1), p(2), p(3), p(4), p(5), p(6)),
+		deleteQuery: fmt.Sprintf("DELETE FROM %s WHERE session_id = %s", table, p(1)),
+		listQuery:   fmt.Sprintf("SELECT session_id, title, message_count, created_at, updated_at FROM %s", table),
+	}, nil
+}
+
+// CreateTable creates the conversation table if it does not exist.
+// Timestamps are stored as Unix nanoseconds.
+func (s *SQLConversationStore) CreateTable(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	session_id VARCHAR(255) PRIMARY KEY,
+	title TEXT NOT NULL,
+	message_count INTEGER NOT NULL,
+	created_at BIGINT NOT NULL,
+	updated_at BIGINT NOT NULL,
+	data TEXT NOT NULL
+)`, s.table))
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %w", s.table, err)
+	}
+	return nil
+}
+
+// Load implements ConversationStore
+func (s *SQLConversationStore) Load(ctx context.Context, sessionID string) (*ConversationMemory, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, s.loadQuery, sessionID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	var conversation ConversationMemory
+	if err := json.Unmarshal([]byte(data), &conversation); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation: %w", err)
+	}
+	return &conversation, nil
+}
+
+// Save implements ConversationStore. It updates the session's row, or
+// inserts one if there is none, so it needs no dialect-specific upsert.
+func (s *SQLConversationStore) Save(ctx context.Context, conversation *ConversationMemory) error {
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	args := []any{
+		conversation.Title,
+		len(conversation.Messages),
+		conversation.CreatedAt.UnixNano(),
+		conversation.UpdatedAt.UnixNano(),
+		string(data),
+		conversation.SessionID,
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, s.updateQuery, args...)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if _, err := tx.ExecContext(ctx, s.insertQuery, args...); err != nil {
+			return fmt.Errorf("failed to save conversation: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+// Delete implements ConversationStore
+func (s *SQLConversationStore) Delete(ctx context.Context, sessionID string) error {
+	if _, err := s.db.ExecContext(ctx, s.deleteQuery, sessionID); err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	return nil
+}
+
+// List implements ConversationStore
+func (s *SQLConversationStore) List(ctx context.Context) ([]ConversationSummary, error) {
+	rows, err := s.db.QueryContext(ctx, s.listQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []ConversationSummary
+	for rows.Next() {
+		var summary ConversationSummary
+		var createdAt, updatedAt int64
+		if err := rows.Scan(&summary.SessionID, &summary.Title, &summary.MessageCount, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
+		summary.CreatedAt = time.Unix(0, createdAt)
+		summary.UpdatedAt = time.Unix(0, updatedAt)
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,212 @@
+package omnillm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// testConversationStore exercises a ConversationStore through a MemoryManager
+func testConversationStore(t *testing.T, store ConversationStore) {
+	t.Helper()
+	mm := NewMemoryManagerWithStore(store, DefaultMemoryConfig())
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "missing"); !errors.Is(err, ErrConversationNotFound) {
+		t.Errorf("Load of a missing session = %v, want ErrConversationNotFound", err)
+	}
+
+	if err := mm.AppendMessages(ctx, "user/1", []Message{
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi!"},
+	}); err != nil {
+		t.Fatalf("AppendMessages failed: %v", err)
+	}
+	if err := mm.AppendMessage(ctx, "user-2", Message{Role: RoleUser, Content: "Hey"}); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	if err := mm.SetTitle(ctx, "user/1", "Greetings"); err != nil {
+		t.Fatalf("SetTitle failed: %v", err)
+	}
+
+	messages, err := mm.GetMessages(ctx, "user/1")
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	if len(messages) != 2 || messages[1].Content != "Hi!" {
+		t.Errorf("unexpected messages: %+v", messages)
+	}
+
+	list, err := mm.ListConversations(ctx)
+	if err != nil {
+		t.Fatalf("ListConversations failed: %v", err)
+	}
+	if len(list) != 2 || list[0].SessionID != "user/1" || list[0].Title != "Greetings" || list[0].MessageCount != 2 {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if err := mm.DeleteConversation(ctx, "user/1"); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+	if err := mm.DeleteConversation(ctx, "never-stored"); err != nil {
+		t.Errorf("DeleteConversation of a missing session failed: %v", err)
+	}
+	conv, err := mm.LoadConversation(ctx, "user/1")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if len(conv.Messages) != 0 {
+		t.Errorf("deleted conversation has %d messages", len(conv.Messages))
+	}
+	if list, _ := mm.ListConversations(ctx); len(list) != 1 || list[0].SessionID != "user-2" {
+		t.Errorf("list after delete = %+v", list)
+	}
+}
+
+func TestKVSConversationStore(t *testing.T) {
+	testConversationStore(t, NewKVSConversationStore(mocktest.NewMockKVS(), "test"))
+}
+
+func TestFileConversationStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "conversations")
+	store, err := NewFileConversationStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileConversationStore failed: %v", err)
+	}
+	testConversationStore(t, store)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "user-2.json" {
+		t.Errorf("unexpected files: %v", entries)
+	}
+}
+
+func TestSQLConversationStore(t *testing.T) {
+	db := sql.OpenDB(&fakeSQLConnector{db: &fakeSQLDB{rows: make(map[string][]driver.Value)}})
+	defer db.Close()
+
+	store, err := NewSQLConversationStore(db, SQLConversationStoreConfig{Placeholder: DollarPlaceholder})
+	if err != nil {
+		t.Fatalf("NewSQLConversationStore failed: %v", err)
+	}
+	if err := store.CreateTable(context.Background()); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	if !strings.Contains(store.updateQuery, "WHERE session_id = $6") {
+		t.Errorf("placeholders not applied: %s", store.updateQuery)
+	}
+	testConversationStore(t, store)
+}
+
+func TestNewSQLConversationStore_InvalidTable(t *testing.T) {
+	_, err := NewSQLConversationStore(nil, SQLConversationStoreConfig{Table: "t; DROP TABLE users"})
+	if !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("got %v, want ErrInvalidConfiguration", err)
+	}
+}
+
+// fakeSQLDB is an in-memory database that understands the statements of
+// SQLConversationStore. Rows hold title, message_count, created_at,
+// updated_at, and data, keyed by session ID.
+type fakeSQLDB struct {
+	mu   sync.Mutex
+	rows map[string][]driver.Value
+}
+
+type fakeSQLConnector struct{ db *fakeSQLDB }
+
+func (c *fakeSQLConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeSQLConn{c.db}, nil
+}
+func (c *fakeSQLConnector) Driver() driver.Driver { return nil }
+
+type fakeSQLConn struct{ db *fakeSQLDB }
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{db: c.db, query: query}, nil
+}
+func (c *fakeSQLConn) Close() error              { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeSQLConn) Commit() error             { return nil }
+func (c *fakeSQLConn) Rollback() error           { return nil }
+
+type fakeSQLStmt struct {
+	db    *fakeSQLDB
+	query string
+}
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "CREATE TABLE"):
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		id := args[5].(string)
+		if _, ok := s.db.rows[id]; !ok {
+			return driver.RowsAffected(0), nil
+		}
+		s.db.rows[id] = args[:5]
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		s.db.rows[args[5].(string)] = args[:5]
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(s.db.rows, args[0].(string))
+		return driver.RowsAffected(1), nil
+	}
+	return nil, errors.New("unexpected statement: " + s.query)
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	rows := &fakeSQLRows{}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT data"):
+		rows.columns = []string{"data"}
+		if row, ok := s.db.rows[args[0].(string)]; ok {
+			rows.values = [][]driver.Value{{row[4]}}
+		}
+	case strings.HasPrefix(s.query, "SELECT session_id"):
+		rows.columns = []string{"session_id", "title", "message_count", "created_at", "updated_at"}
+		for id, row := range s.db.rows {
+			rows.values = append(rows.values, []driver.Value{id, row[0], row[1], row[2], row[3]})
+		}
+	default:
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+	return rows, nil
+}
+
+type fakeSQLRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeSQLRows) Columns() []string { return r.columns }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
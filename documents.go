@@ -0,0 +1,55 @@
+package omnillm
+
+import "github.com/plexusone/omnillm/provider"
+
+// DefaultMaxDocumentBytes is the largest inline document accepted by request
+// validation. Individual providers may enforce lower limits.
+const DefaultMaxDocumentBytes = provider.DefaultMaxDocumentBytes
+
+// Content part types
+const (
+	ContentPartTypeText     = provider.ContentPartTypeText
+	ContentPartTypeDocument = provider.ContentPartTypeDocument
+	ContentPartTypeAudio    = provider.ContentPartTypeAudio
+)
+
+// NewTextPart creates a text content part
+func NewTextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartTypeText, Text: text}
+}
+
+// NewDocumentPart creates a content part carrying inline document bytes,
+// such as a PDF, with the given MIME type and optional filename.
+func NewDocumentPart(data []byte, mimeType, filename string) ContentPart {
+	return ContentPart{
+		Type: ContentPartTypeDocument,
+		Document: &Document{
+			MIMEType: mimeType,
+			Filename: filename,
+			Data:     data,
+		},
+	}
+}
+
+// NewFileDocumentPart creates a content part referencing a file previously
+// uploaded with UploadFile, so large documents need not be sent inline.
+func NewFileDocumentPart(file *File) ContentPart {
+	return ContentPart{
+		Type: ContentPartTypeDocument,
+		Document: &Document{
+			MIMEType: file.MIMEType,
+			Filename: file.Filename,
+			FileID:   file.ID,
+			FileURI:  file.URI,
+		},
+	}
+}
+
+// NewAudioPart creates a content part carrying audio, such as recorded
+// speech, in the given format (e.g., "wav" or "mp3")
+func NewAudioPart(data []byte, format string) ContentPart {
+	return ContentPart{
+		Type:  ContentPartTypeAudio,
+		Audio: &Audio{Format: format, Data: data},
+	}
+}
@@ -0,0 +1,55 @@
+package omnillm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewDocumentPart(t *testing.T) {
+	part := NewDocumentPart([]byte("%PDF-1.4"), "application/pdf", "report.pdf")
+	if part.Type != ContentPartTypeDocument {
+		t.Errorf("Type = %q, want %q", part.Type, ContentPartTypeDocument)
+	}
+	if part.Document == nil || part.Document.Filename != "report.pdf" || part.Document.MIMEType != "application/pdf" {
+		t.Errorf("unexpected document: %+v", part.Document)
+	}
+}
+
+func TestNewFileDocumentPart(t *testing.T) {
+	part := NewFileDocumentPart(&File{ID: "file-1", URI: "https://example.com/f", MIMEType: "application/pdf"})
+	if part.Document.FileID != "file-1" || part.Document.FileURI != "https://example.com/f" {
+		t.Errorf("unexpected document: %+v", part.Document)
+	}
+}
+
+func TestValidateRequest_DocumentParts(t *testing.T) {
+	tests := []struct {
+		name    string
+		part    ContentPart
+		wantErr bool
+	}{
+		{"text part", NewTextPart("hello"), false},
+		{"inline document", NewDocumentPart([]byte("data"), "application/pdf", ""), false},
+		{"file document", NewFileDocumentPart(&File{ID: "file-1"}), false},
+		{"missing document", ContentPart{Type: ContentPartTypeDocument}, true},
+		{"empty document", ContentPart{Type: ContentPartTypeDocument, Document: &Document{MIMEType: "application/pdf"}}, true},
+		{"oversized document", NewDocumentPart(make([]byte, DefaultMaxDocumentBytes+1), "application/pdf", ""), true},
+		{"unknown type", ContentPart{Type: "image"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &ChatCompletionRequest{
+				Model:    "gpt-4o",
+				Messages: []Message{{Role: RoleUser, Parts: []ContentPart{tt.part}}},
+			}
+			errs := validateRequest(req)
+			if gotErr := len(errs) > 0; gotErr != tt.wantErr {
+				t.Errorf("validateRequest() = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(errs, ErrInvalidRequest) {
+				t.Errorf("errors should wrap ErrInvalidRequest")
+			}
+		})
+	}
+}
@@ -2,15 +2,18 @@ package omnillm
 
 import (
 	"errors"
-	"fmt"
 	"net"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
 )
 
 var (
 	// Common errors
 	ErrUnsupportedProvider  = errors.New("unsupported provider")
-	ErrBedrockExternal      = errors.New("bedrock provider moved to github.com/plexusone/omnillm-bedrock; use CustomProvider to inject it")
+	ErrBedrockExternal      = errors.New("bedrock provider moved to github.com/plexusone/omnillm-bedrock; import it to register the provider, or use CustomProvider to inject it")
 	ErrInvalidConfiguration = errors.New("invalid configuration")
 	ErrNoProviders          = errors.New("at least one provider must be configured")
 	ErrEmptyAPIKey          = errors.New("API key cannot be empty")
@@ -18,39 +21,72 @@ var (
 	ErrEmptyMessages        = errors.New("messages cannot be empty")
 	ErrStreamClosed         = errors.New("stream is closed")
 	ErrInvalidResponse      = errors.New("invalid response format")
-	ErrRateLimitExceeded    = errors.New("rate limit exceeded")
-	ErrQuotaExceeded        = errors.New("quota exceeded")
-	ErrInvalidRequest       = errors.New("invalid request")
-	ErrModelNotFound        = errors.New("model not found")
-	ErrServerError          = errors.New("server error")
 	ErrNetworkError         = errors.New("network error")
+
+	// Matched by APIErrors with the corresponding status or error code
+	ErrRateLimitExceeded = provider.ErrRateLimitExceeded
+	ErrQuotaExceeded     = provider.ErrQuotaExceeded
+	ErrModelNotFound     = provider.ErrModelNotFound
+	ErrServerError       = provider.ErrServerError
+	ErrAuthentication    = provider.ErrAuthentication
+
+	// ErrInvalidRequest is matched by every ValidationError
+	ErrInvalidRequest = provider.ErrInvalidRequest
+
+	// ErrContentBlocked is matched by every ContentBlockedError
+	ErrContentBlocked = provider.ErrContentBlocked
+
+	// ErrUnsupportedCapability is matched by every CapabilityError
+	ErrUnsupportedCapability = provider.ErrUnsupportedCapability
 )
 
-// APIError represents an error response from the API
-type APIError struct {
-	StatusCode int          `json:"status_code"`
-	Message    string       `json:"message"`
-	Type       string       `json:"type"`
-	Code       string       `json:"code"`
-	Provider   ProviderName `json:"provider"`
-}
+// APIError represents an error response from the API. Providers return it
+// for HTTP error responses, with RetryAfter, RateLimit, and RequestID read
+// from the response headers and the raw Body. It matches the sentinel error
+// for its kind of failure, such as ErrRateLimitExceeded, via errors.Is.
+type APIError = provider.APIError
 
-func (e *APIError) Error() string {
-	return fmt.Sprintf("[%s] %s (status: %d, type: %s, code: %s)",
-		e.Provider, e.Message, e.StatusCode, e.Type, e.Code)
-}
+// RateLimitInfo is the rate limit state reported in response headers
+type RateLimitInfo = provider.RateLimitInfo
+
+// ContentBlockedError reports a prompt or response blocked by a provider's
+// safety filters. Retrying the same request will not help.
+type ContentBlockedError = provider.ContentBlockedError
+
+// SafetyRating is a provider's harm assessment for one category
+type SafetyRating = provider.SafetyRating
+
+// CapabilityError reports request features a provider does not support.
+// It also matches ErrInvalidRequest.
+type CapabilityError = provider.CapabilityError
+
+// ValidationError describes a single problem found while validating a request
+type ValidationError = provider.ValidationError
+
+// ValidationErrors is a multi-error containing every validation problem found in a request
+type ValidationErrors = provider.ValidationErrors
 
 // NewAPIError creates a new API error
-func NewAPIError(provider ProviderName, statusCode int, message, errorType, code string) *APIError {
+func NewAPIError(providerName ProviderName, statusCode int, message, errorType, code string) *APIError {
 	return &APIError{
 		StatusCode: statusCode,
 		Message:    message,
 		Type:       errorType,
 		Code:       code,
-		Provider:   provider,
+		Provider:   providerName,
 	}
 }
 
+// RetryAfter returns how long the provider asked clients to wait before
+// retrying, if err is or wraps an APIError that says so
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
+
 // ErrorCategory classifies errors for retry/fallback logic
 type ErrorCategory int
 
@@ -77,7 +113,68 @@ func (c ErrorCategory) String() string {
 	}
 }
 
-// ClassifyError determines the category of an error for retry/fallback decisions
+// ErrorClassifier classifies errors from one provider. It returns
+// ErrorCategoryUnknown to defer to the built-in rules.
+type ErrorClassifier func(err error) ErrorCategory
+
+var (
+	errorClassifiersMu sync.RWMutex
+	errorClassifiers   = make(map[string]ErrorClassifier)
+)
+
+// RegisterErrorClassifier adds a classifier that ClassifyError consults,
+// before its built-in rules, for errors from the named provider. It teaches
+// retry and fallback decisions the conventions of a gateway or proxy, such
+// as a 403 that means an exhausted quota:
+//
+//	omnillm.RegisterErrorClassifier("openai", func(err error) omnillm.ErrorCategory {
+//		var apiErr *omnillm.APIError
+//		if errors.As(err, &apiErr) && apiErr.StatusCode == 403 && strings.Contains(apiErr.Message, "quota") {
+//			return omnillm.ErrorCategoryRetryable
+//		}
+//		return omnillm.ErrorCategoryUnknown
+//	})
+//
+// Registering a name again replaces its classifier, and a nil classifier
+// removes the registration.
+func RegisterErrorClassifier(providerName string, classifier ErrorClassifier) {
+	errorClassifiersMu.Lock()
+	defer errorClassifiersMu.Unlock()
+	if classifier == nil {
+		delete(errorClassifiers, providerName)
+		return
+	}
+	errorClassifiers[providerName] = classifier
+}
+
+// classifyWithRegistered returns the category the classifier registered
+// for providerName gives err, or ErrorCategoryUnknown if there is none
+func classifyWithRegistered(providerName string, err error) ErrorCategory {
+	errorClassifiersMu.RLock()
+	classifier, ok := errorClassifiers[providerName]
+	errorClassifiersMu.RUnlock()
+	if !ok {
+		return ErrorCategoryUnknown
+	}
+	return classifier(err)
+}
+
+// ClassifyProviderError determines the category of an error returned by the
+// named provider. Unlike ClassifyError, it consults the provider's registered
+// classifier even for errors that are not APIErrors.
+func ClassifyProviderError(providerName string, err error) ErrorCategory {
+	if err == nil {
+		return ErrorCategoryUnknown
+	}
+	if category := classifyWithRegistered(providerName, err); category != ErrorCategoryUnknown {
+		return category
+	}
+	return ClassifyError(err)
+}
+
+// ClassifyError determines the category of an error for retry/fallback
+// decisions. An APIError is classified by the classifier registered for its
+// provider, if any, and otherwise by its status code.
 func ClassifyError(err error) ErrorCategory {
 	if err == nil {
 		return ErrorCategoryUnknown
@@ -86,6 +183,9 @@ func ClassifyError(err error) ErrorCategory {
 	// Check for APIError with status code
 	var apiErr *APIError
 	if errors.As(err, &apiErr) {
+		if category := classifyWithRegistered(string(apiErr.Provider), err); category != ErrorCategoryUnknown {
+			return category
+		}
 		return classifyStatusCode(apiErr.StatusCode)
 	}
 
@@ -95,13 +195,17 @@ func ClassifyError(err error) ErrorCategory {
 	}
 
 	// Check for known error types
-	if errors.Is(err, ErrRateLimitExceeded) || errors.Is(err, ErrServerError) || errors.Is(err, ErrNetworkError) {
+	if errors.Is(err, ErrRateLimitExceeded) || errors.Is(err, ErrServerError) || errors.Is(err, ErrNetworkError) ||
+		errors.Is(err, ErrAttemptTimeout) || errors.Is(err, ErrFirstTokenTimeout) ||
+		errors.Is(err, ErrEmptyStream) {
 		return ErrorCategoryRetryable
 	}
 
 	if errors.Is(err, ErrInvalidRequest) || errors.Is(err, ErrModelNotFound) ||
 		errors.Is(err, ErrEmptyAPIKey) || errors.Is(err, ErrEmptyModel) ||
-		errors.Is(err, ErrEmptyMessages) || errors.Is(err, ErrInvalidConfiguration) {
+		errors.Is(err, ErrEmptyMessages) || errors.Is(err, ErrInvalidConfiguration) ||
+		errors.Is(err, ErrContentBlocked) || errors.Is(err, ErrSystemPromptConflict) ||
+		errors.Is(err, ErrRequestTooLarge) || errors.Is(err, ErrAuthentication) {
 		return ErrorCategoryNonRetryable
 	}
 
@@ -189,6 +293,25 @@ func IsRetryableError(err error) bool {
 	return category == ErrorCategoryRetryable || category == ErrorCategoryUnknown
 }
 
+// IsRateLimitError returns true if the error is a rate limit (429) or quota error
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == 429 {
+		return true
+	}
+	if errors.Is(err, ErrRateLimitExceeded) || errors.Is(err, ErrQuotaExceeded) {
+		return true
+	}
+
+	errMsg := strings.ToLower(err.Error())
+	return strings.Contains(errMsg, "rate limit") || strings.Contains(errMsg, "too many requests") ||
+		strings.Contains(errMsg, "quota") || strings.Contains(errMsg, "resource_exhausted")
+}
+
 // IsNonRetryableError returns true if the error is permanent and retrying won't help.
 func IsNonRetryableError(err error) bool {
 	return ClassifyError(err) == ErrorCategoryNonRetryable
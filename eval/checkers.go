@@ -0,0 +1,191 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultJudgePassScore is the judge score, from 0 to 1, needed to pass
+const DefaultJudgePassScore = 0.7
+
+// Checker scores a case's output
+type Checker interface {
+	// Name identifies the check in results
+	Name() string
+
+	// Check scores output. An error fails the check and is recorded in the result.
+	Check(ctx context.Context, c *Case, output string) (CheckResult, error)
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Checker string
+	Pass    bool
+	Score   float64 // From 0 to 1
+	Reason  string  // Why the check failed, or the judge's reasoning
+	Err     error
+}
+
+// checkerFunc adapts a function to the Checker interface
+type checkerFunc struct {
+	name string
+	fn   func(ctx context.Context, c *Case, output string) (CheckResult, error)
+}
+
+// NewChecker creates a Checker from a function
+func NewChecker(name string, fn func(ctx context.Context, c *Case, output string) (CheckResult, error)) Checker {
+	return &checkerFunc{name: name, fn: fn}
+}
+
+func (c *checkerFunc) Name() string { return c.name }
+
+func (c *checkerFunc) Check(ctx context.Context, cs *Case, output string) (CheckResult, error) {
+	return c.fn(ctx, cs, output)
+}
+
+// passFail returns a CheckResult scored 1 or 0
+func passFail(pass bool, reason string) CheckResult {
+	if pass {
+		return CheckResult{Pass: true, Score: 1}
+	}
+	return CheckResult{Reason: reason}
+}
+
+// ExactMatch passes if the output, with surrounding whitespace trimmed,
+// equals expected. If expected is "", the case's Expected is used.
+func ExactMatch(expected string) Checker {
+	return NewChecker("exact_match", func(ctx context.Context, c *Case, output string) (CheckResult, error) {
+		want := expected
+		if want == "" {
+			want = c.Expected
+		}
+		got := strings.TrimSpace(output)
+		return passFail(got == strings.TrimSpace(want), fmt.Sprintf("expected %q, got %q", want, got)), nil
+	})
+}
+
+// Contains passes if the output contains substr, ignoring case
+func Contains(substr string) Checker {
+	return NewChecker("contains", func(ctx context.Context, c *Case, output string) (CheckResult, error) {
+		pass := strings.Contains(strings.ToLower(output), strings.ToLower(substr))
+		return passFail(pass, fmt.Sprintf("output does not contain %q", substr)), nil
+	})
+}
+
+// Regexp passes if the output matches re
+func Regexp(re *regexp.Regexp) Checker {
+	return NewChecker("regexp", func(ctx context.Context, c *Case, output string) (CheckResult, error) {
+		return passFail(re.MatchString(output), fmt.Sprintf("output does not match %q", re.String())), nil
+	})
+}
+
+// JSONValid passes if the output is valid JSON, optionally inside a
+// Markdown code fence
+func JSONValid() Checker {
+	return NewChecker("json_valid", func(ctx context.Context, c *Case, output string) (CheckResult, error) {
+		var value any
+		if err := json.Unmarshal([]byte(stripCodeFence(output)), &value); err != nil {
+			return passFail(false, "output is not valid JSON: "+err.Error()), nil
+		}
+		return passFail(true, ""), nil
+	})
+}
+
+// stripCodeFence removes a Markdown code fence around s, if present
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(s[3:], "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:] // Drop the language tag line
+	}
+	return strings.TrimSpace(s)
+}
+
+// JudgeConfig configures an LLM-as-judge check
+type JudgeConfig struct {
+	// Client sends the judging requests, usually to a different provider
+	// than the one being evaluated (required)
+	Client *omnillm.ChatClient
+
+	// Model is the judge model (required)
+	Model string
+
+	// Criteria describes what a good answer looks like, e.g. "Accurate,
+	// cites the source, and under 100 words" (required)
+	Criteria string
+
+	// PassScore is the score, from 0 to 1, needed to pass. Default: DefaultJudgePassScore.
+	PassScore float64
+}
+
+// judgeVerdict is the JSON the judge is asked to return
+type judgeVerdict struct {
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"`
+}
+
+// Judge scores the output with another model, which rates it from 0 to 10
+// against the criteria. The case's prompt and Expected answer, if set, are
+// shown to the judge.
+func Judge(config JudgeConfig) Checker {
+	if config.PassScore <= 0 {
+		config.PassScore = DefaultJudgePassScore
+	}
+	return NewChecker("judge", func(ctx context.Context, c *Case, output string) (CheckResult, error) {
+		if config.Client == nil || config.Model == "" {
+			return CheckResult{}, fmt.Errorf("%w: judge needs a client and a model", omnillm.ErrInvalidConfiguration)
+		}
+
+		var prompt strings.Builder
+		fmt.Fprintf(&prompt, "Criteria:\n%s\n\n", config.Criteria)
+		if question := lastUserMessage(c.request("")); question != "" {
+			fmt.Fprintf(&prompt, "Question:\n%s\n\n", question)
+		}
+		if c.Expected != "" {
+			fmt.Fprintf(&prompt, "Reference answer:\n%s\n\n", c.Expected)
+		}
+		fmt.Fprintf(&prompt, "Answer to grade:\n%s", output)
+
+		resp, err := config.Client.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+			Model: config.Model,
+			Messages: []provider.Message{
+				{Role: provider.RoleSystem, Content: "You grade answers against criteria. " +
+					`Reply with only a JSON object: {"score": <integer 0-10>, "reason": "<one sentence>"}.`},
+				{Role: provider.RoleUser, Content: prompt.String()},
+			},
+			ResponseFormat: &provider.ResponseFormat{Type: provider.ResponseFormatJSONObject},
+		})
+		if err != nil {
+			return CheckResult{}, fmt.Errorf("judge request failed: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return CheckResult{}, omnillm.ErrInvalidResponse
+		}
+
+		var verdict judgeVerdict
+		if err := json.Unmarshal([]byte(stripCodeFence(resp.Choices[0].Message.Content)), &verdict); err != nil {
+			return CheckResult{}, fmt.Errorf("judge reply is not valid JSON: %w", err)
+		}
+		score := min(max(verdict.Score/10, 0), 1)
+		return CheckResult{Pass: score >= config.PassScore, Score: score, Reason: verdict.Reason}, nil
+	})
+}
+
+// lastUserMessage returns the content of the request's last user message
+func lastUserMessage(req *provider.ChatCompletionRequest) string {
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == provider.RoleUser {
+			return req.Messages[i].Content
+		}
+	}
+	return ""
+}
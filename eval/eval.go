@@ -0,0 +1,307 @@
+// Package eval runs datasets of prompts against one or more omnillm clients
+// and scores the responses, to compare providers, models, and prompts.
+//
+// A Dataset is a list of Cases, each a prompt with the Checkers that score
+// its output. Run sends every case to every Target and returns a Report
+// with per-case results and a summary per target:
+//
+//	dataset := eval.Dataset{
+//		Name: "capitals",
+//		Cases: []eval.Case{
+//			{Name: "france", Prompt: "Capital of France? One word.", Checks: []eval.Checker{eval.ExactMatch("Paris")}},
+//			{Name: "json", Prompt: `Reply with {"ok": true}`, Checks: []eval.Checker{eval.JSONValid()}},
+//		},
+//	}
+//	report, err := eval.Run(ctx, dataset, []eval.Target{
+//		{Name: "gpt-4o", Client: openaiClient, Model: "gpt-4o"},
+//		{Name: "claude", Client: anthropicClient, Model: "claude-sonnet-4-5"},
+//	}, nil)
+//	for _, s := range report.Summaries {
+//		fmt.Printf("%s: %.0f%% passed\n", s.Target, 100*s.PassRate)
+//	}
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultConcurrency is the number of cases run at once per Run
+const DefaultConcurrency = 4
+
+var (
+	// ErrNoTargets is returned by Run when no targets are given
+	ErrNoTargets = errors.New("eval: no targets")
+
+	// ErrNoCases is returned by Run when the dataset has no cases
+	ErrNoCases = errors.New("eval: dataset has no cases")
+)
+
+// Dataset is a named list of cases
+type Dataset struct {
+	Name  string
+	Cases []Case
+}
+
+// Case is one prompt and the checks its output must pass
+type Case struct {
+	// Name identifies the case in the report
+	Name string
+
+	// Request is the base request (optional). Its model is replaced by the
+	// target's model, if the target sets one.
+	Request *provider.ChatCompletionRequest
+
+	// System and Prompt, if set, are appended to the request's messages as a
+	// system and a user message
+	System string
+	Prompt string
+
+	// Expected is the reference answer, for checkers that compare against
+	// one (optional)
+	Expected string
+
+	// Checks score the output. A case passes if every check passes.
+	Checks []Checker
+}
+
+// request builds the case's request for a target
+func (c *Case) request(model string) *provider.ChatCompletionRequest {
+	var req provider.ChatCompletionRequest
+	if c.Request != nil {
+		req = *c.Request
+		req.Messages = append([]provider.Message(nil), c.Request.Messages...)
+	}
+	if c.System != "" {
+		req.Messages = append(req.Messages, provider.Message{Role: provider.RoleSystem, Content: c.System})
+	}
+	if c.Prompt != "" {
+		req.Messages = append(req.Messages, provider.Message{Role: provider.RoleUser, Content: c.Prompt})
+	}
+	if model != "" {
+		req.Model = model
+	}
+	return &req
+}
+
+// Target is a client and model to evaluate
+type Target struct {
+	// Name identifies the target in the report
+	Name string
+
+	// Client sends the requests
+	Client *omnillm.ChatClient
+
+	// Model replaces each case's request model, if set
+	Model string
+}
+
+// Options configures Run
+type Options struct {
+	// Concurrency is the number of cases run at once. Default: DefaultConcurrency.
+	Concurrency int
+
+	// Timeout bounds each request, if set
+	Timeout time.Duration
+}
+
+// Result is the outcome of one case on one target
+type Result struct {
+	Target   string
+	Case     string
+	Output   string
+	Response *provider.ChatCompletionResponse
+	Err      error // The request error, if the request failed
+	Latency  time.Duration
+	Checks   []CheckResult
+
+	// Passed is true if the request succeeded and every check passed
+	Passed bool
+
+	// Score is the mean of the check scores, or 0 if the request failed
+	Score float64
+}
+
+// Summary aggregates a target's results
+type Summary struct {
+	Target      string
+	Cases       int
+	Passed      int
+	Errors      int // Failed requests
+	PassRate    float64
+	MeanScore   float64
+	MeanLatency time.Duration
+	Usage       provider.Usage
+}
+
+// Report is the outcome of a Run
+type Report struct {
+	Dataset   string
+	StartedAt time.Time
+	Duration  time.Duration
+
+	// Results holds one result per target and case, grouped by target in
+	// the order the targets were given, then in case order
+	Results []Result
+
+	// Summaries holds one summary per target, in the order given
+	Summaries []Summary
+}
+
+// Summary returns the summary for the named target
+func (r *Report) Summary(target string) (Summary, bool) {
+	for _, s := range r.Summaries {
+		if s.Target == target {
+			return s, true
+		}
+	}
+	return Summary{}, false
+}
+
+// Failures returns the results that did not pass
+func (r *Report) Failures() []Result {
+	var failures []Result
+	for _, res := range r.Results {
+		if !res.Passed {
+			failures = append(failures, res)
+		}
+	}
+	return failures
+}
+
+// Run sends every case in dataset to every target, scores the outputs, and
+// returns the report. Failed requests and checks are recorded in the report;
+// Run itself fails only for invalid input or a done ctx.
+func Run(ctx context.Context, dataset Dataset, targets []Target, opts *Options) (*Report, error) {
+	if len(targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	if len(dataset.Cases) == 0 {
+		return nil, ErrNoCases
+	}
+	for i, t := range targets {
+		if t.Client == nil {
+			return nil, fmt.Errorf("eval: target %d (%s) has no client", i, t.Name)
+		}
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	report := &Report{
+		Dataset:   dataset.Name,
+		StartedAt: time.Now(),
+		Results:   make([]Result, len(targets)*len(dataset.Cases)),
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for ti := range targets {
+		for ci := range dataset.Cases {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(target *Target, c *Case, result *Result) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				*result = runCase(ctx, target, c, opts.Timeout)
+			}(&targets[ti], &dataset.Cases[ci], &report.Results[ti*len(dataset.Cases)+ci])
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for ti, t := range targets {
+		report.Summaries = append(report.Summaries, summarize(t.Name, report.Results[ti*len(dataset.Cases):(ti+1)*len(dataset.Cases)]))
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// runCase sends one case to one target and scores the output
+func runCase(ctx context.Context, target *Target, c *Case, timeout time.Duration) Result {
+	result := Result{Target: target.Name, Case: c.Name}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, err := target.Client.CreateChatCompletion(ctx, c.request(target.Model))
+	result.Latency = time.Since(start)
+	if err == nil && len(resp.Choices) == 0 {
+		err = omnillm.ErrInvalidResponse
+	}
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Response = resp
+	result.Output = resp.Choices[0].Message.Content
+
+	result.Passed = true
+	var total float64
+	for _, check := range c.Checks {
+		cr := runCheck(ctx, check, c, result.Output)
+		result.Checks = append(result.Checks, cr)
+		result.Passed = result.Passed && cr.Pass
+		total += cr.Score
+	}
+	result.Score = 1
+	if len(c.Checks) > 0 {
+		result.Score = total / float64(len(c.Checks))
+	}
+	return result
+}
+
+// runCheck runs a check, recording its error as a failure
+func runCheck(ctx context.Context, check Checker, c *Case, output string) CheckResult {
+	cr, err := check.Check(ctx, c, output)
+	cr.Checker = check.Name()
+	if err != nil {
+		cr.Pass, cr.Score, cr.Err = false, 0, err
+		if cr.Reason == "" {
+			cr.Reason = err.Error()
+		}
+	}
+	return cr
+}
+
+// summarize aggregates one target's results
+func summarize(target string, results []Result) Summary {
+	s := Summary{Target: target, Cases: len(results)}
+	var totalScore float64
+	var totalLatency time.Duration
+	for _, r := range results {
+		totalLatency += r.Latency
+		if r.Err != nil {
+			s.Errors++
+			continue
+		}
+		if r.Passed {
+			s.Passed++
+		}
+		totalScore += r.Score
+		s.Usage.PromptTokens += r.Response.Usage.PromptTokens
+		s.Usage.CompletionTokens += r.Response.Usage.CompletionTokens
+		s.Usage.TotalTokens += r.Response.Usage.TotalTokens
+	}
+	if s.Cases > 0 {
+		s.PassRate = float64(s.Passed) / float64(s.Cases)
+		s.MeanScore = totalScore / float64(s.Cases)
+		s.MeanLatency = totalLatency / time.Duration(s.Cases)
+	}
+	return s
+}
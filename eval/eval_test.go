@@ -0,0 +1,102 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func newClient(t *testing.T, p provider.Provider) *omnillm.ChatClient {
+	t.Helper()
+	client, err := omnillm.NewClient(omnillm.ClientConfig{Providers: []omnillm.ProviderConfig{{CustomProvider: p}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestRun(t *testing.T) {
+	good := mocktest.NewScriptedProvider("good",
+		mocktest.TextStep("Paris"),
+		mocktest.TextStep("```json\n{\"ok\": true}\n```"),
+	)
+	bad := mocktest.NewScriptedProvider("bad",
+		mocktest.TextStep("Lyon"),
+		mocktest.Step{Err: omnillm.ErrServerError},
+	)
+
+	dataset := Dataset{
+		Name: "smoke",
+		Cases: []Case{
+			{Name: "capital", Prompt: "Capital of France?", Expected: "Paris", Checks: []Checker{ExactMatch(""), Regexp(regexp.MustCompile(`^[A-Z]`))}},
+			{Name: "json", System: "Reply in JSON.", Prompt: "ok?", Checks: []Checker{JSONValid(), Contains("OK")}},
+		},
+	}
+	report, err := Run(context.Background(), dataset, []Target{
+		{Name: "good", Client: newClient(t, good), Model: "model-a"},
+		{Name: "bad", Client: newClient(t, bad), Model: "model-b"},
+	}, &Options{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(report.Results) != 4 || report.Results[0].Target != "good" || report.Results[3].Case != "json" {
+		t.Fatalf("unexpected results: %+v", report.Results)
+	}
+	if got := good.LastRequest(); got.Model != "model-a" || len(got.Messages) != 2 || got.Messages[0].Role != provider.RoleSystem {
+		t.Errorf("unexpected request: %+v", got)
+	}
+
+	s, ok := report.Summary("good")
+	if !ok || s.Passed != 2 || s.PassRate != 1 || s.MeanScore != 1 {
+		t.Errorf("unexpected summary for good: %+v", s)
+	}
+	s, _ = report.Summary("bad")
+	if s.Passed != 0 || s.Errors != 1 || s.MeanScore != 0.25 {
+		t.Errorf("unexpected summary for bad: %+v", s)
+	}
+
+	failures := report.Failures()
+	if len(failures) != 2 || failures[0].Checks[0].Reason == "" || !errors.Is(failures[1].Err, omnillm.ErrServerError) {
+		t.Errorf("unexpected failures: %+v", failures)
+	}
+
+	if _, err := Run(context.Background(), dataset, nil, nil); !errors.Is(err, ErrNoTargets) {
+		t.Errorf("expected ErrNoTargets, got %v", err)
+	}
+}
+
+func TestJudge(t *testing.T) {
+	judgeProvider := mocktest.NewScriptedProvider("judge",
+		mocktest.TextStep(`{"score": 8, "reason": "accurate"}`),
+		mocktest.TextStep(`{"score": 3, "reason": "wrong city"}`),
+		mocktest.TextStep(`not json`),
+	)
+	judge := Judge(JudgeConfig{Client: newClient(t, judgeProvider), Model: "judge-model", Criteria: "Names the capital correctly"})
+	c := &Case{Prompt: "Capital of France?", Expected: "Paris"}
+
+	cr, err := judge.Check(context.Background(), c, "It is Paris.")
+	if err != nil || !cr.Pass || cr.Score != 0.8 || cr.Reason != "accurate" {
+		t.Errorf("unexpected verdict: %+v, %v", cr, err)
+	}
+	req := judgeProvider.LastRequest()
+	if req.Model != "judge-model" || req.ResponseFormat == nil {
+		t.Errorf("unexpected judge request: %+v", req)
+	}
+	if prompt := req.Messages[1].Content; !regexp.MustCompile(`(?s)Capital of France\?.*Paris.*It is Paris\.`).MatchString(prompt) {
+		t.Errorf("expected question, reference, and answer in the judge prompt, got %q", prompt)
+	}
+
+	if cr, _ := judge.Check(context.Background(), c, "Lyon"); cr.Pass || cr.Score != 0.3 {
+		t.Errorf("expected a failing verdict, got %+v", cr)
+	}
+	if _, err := judge.Check(context.Background(), c, "Paris"); err == nil {
+		t.Error("expected an error for an invalid judge reply")
+	}
+}
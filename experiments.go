@@ -0,0 +1,270 @@
+package omnillm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ExperimentVariant is one arm of an A/B experiment
+type ExperimentVariant struct {
+	// Name identifies the variant in response metadata and hook events (required)
+	Name string
+
+	// Weight is the variant's share of traffic, relative to the other
+	// variants' weights (required, must be positive)
+	Weight float64
+
+	// Provider serves the variant's requests. If nil, ExperimentConfig.Default is used.
+	Provider provider.Provider
+
+	// Model replaces the request model, if set
+	Model string
+
+	// Temperature and MaxTokens replace the request's values, if set
+	Temperature *float64
+	MaxTokens   *int
+
+	// Modify, if set, makes any other change to the variant's copy of the request
+	Modify func(*provider.ChatCompletionRequest)
+}
+
+// ExperimentConfig configures an ExperimentProvider
+type ExperimentConfig struct {
+	// Name identifies the experiment (required). Assignments are hashed with
+	// it, so the same key can land in different variants of different
+	// experiments.
+	Name string
+
+	// Variants are the experiment's arms (at least one required)
+	Variants []ExperimentVariant
+
+	// Default serves variants that do not set a provider
+	Default provider.Provider
+
+	// Hook observes each request with the variant in its context, see
+	// ExperimentInfoFromContext (optional)
+	Hook ObservabilityHook
+}
+
+// ExperimentInfo identifies the experiment variant serving a request
+type ExperimentInfo struct {
+	Experiment string
+	Variant    string
+}
+
+type (
+	experimentKeyContextKey  struct{}
+	experimentInfoContextKey struct{}
+)
+
+// WithExperimentKey returns a context whose requests are assigned to
+// experiment variants by key, such as a user or session ID, so the same key
+// always gets the same variant. Requests without a key are assigned at random.
+func WithExperimentKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, experimentKeyContextKey{}, key)
+}
+
+// ExperimentInfoFromContext returns the variant assigned to the request, in
+// the context passed to ExperimentConfig.Hook
+func ExperimentInfoFromContext(ctx context.Context) (ExperimentInfo, bool) {
+	info, ok := ctx.Value(experimentInfoContextKey{}).(ExperimentInfo)
+	return info, ok
+}
+
+// ExperimentProvider splits traffic between experiment variants, each a
+// provider, model, and parameters. Responses are tagged with the experiment
+// and variant in ProviderMetadata ("experiment", "experiment_variant"), as
+// are stream chunks.
+type ExperimentProvider struct {
+	config ExperimentConfig
+	total  float64
+}
+
+// NewExperimentProvider creates an experiment provider
+func NewExperimentProvider(config ExperimentConfig) (*ExperimentProvider, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("%w: experiment name is required", ErrInvalidConfiguration)
+	}
+	if len(config.Variants) == 0 {
+		return nil, fmt.Errorf("%w: experiment %q has no variants", ErrInvalidConfiguration, config.Name)
+	}
+
+	ep := &ExperimentProvider{config: config}
+	seen := make(map[string]bool)
+	for _, v := range config.Variants {
+		switch {
+		case v.Name == "":
+			return nil, fmt.Errorf("%w: experiment %q has a variant without a name", ErrInvalidConfiguration, config.Name)
+		case seen[v.Name]:
+			return nil, fmt.Errorf("%w: experiment %q has duplicate variant %q", ErrInvalidConfiguration, config.Name, v.Name)
+		case !(v.Weight > 0):
+			return nil, fmt.Errorf("%w: variant %q must have a positive weight", ErrInvalidConfiguration, v.Name)
+		case v.Provider == nil && config.Default == nil:
+			return nil, fmt.Errorf("%w: variant %q has no provider and there is no default", ErrInvalidConfiguration, v.Name)
+		}
+		seen[v.Name] = true
+		ep.total += v.Weight
+	}
+	return ep, nil
+}
+
+// Assign returns the variant for key. The same key always gets the same
+// variant while the experiment's variants and weights are unchanged. An
+// empty key gets a random variant.
+func (ep *ExperimentProvider) Assign(key string) *ExperimentVariant {
+	var point float64
+	if key == "" {
+		point = rand.Float64()
+	} else {
+		sum := sha256.Sum256([]byte(ep.config.Name + "\x00" + key))
+		point = float64(binary.BigEndian.Uint64(sum[:8])) / (math.MaxUint64 + 1.0)
+	}
+
+	point *= ep.total
+	for i := range ep.config.Variants {
+		v := &ep.config.Variants[i]
+		if point < v.Weight {
+			return v
+		}
+		point -= v.Weight
+	}
+	return &ep.config.Variants[len(ep.config.Variants)-1]
+}
+
+// CreateChatCompletion sends the request to its variant
+func (ep *ExperimentProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	v, p, variantReq := ep.route(ctx, req)
+	ctx, info := ep.before(ctx, v, p, variantReq)
+
+	resp, err := p.CreateChatCompletion(ctx, variantReq)
+	if resp != nil {
+		resp.ProviderMetadata = ep.tag(resp.ProviderMetadata, v)
+	}
+	if ep.config.Hook != nil {
+		ep.config.Hook.AfterResponse(ctx, info, variantReq, resp, err)
+	}
+	return resp, err
+}
+
+// CreateChatCompletionStream opens a stream on the request's variant
+func (ep *ExperimentProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	v, p, variantReq := ep.route(ctx, req)
+	ctx, info := ep.before(ctx, v, p, variantReq)
+
+	stream, err := p.CreateChatCompletionStream(ctx, variantReq)
+	if err != nil {
+		if ep.config.Hook != nil {
+			ep.config.Hook.AfterResponse(ctx, info, variantReq, nil, err)
+		}
+		return nil, err
+	}
+	stream = &experimentStream{ChatCompletionStream: stream, ep: ep, variant: v}
+	if ep.config.Hook != nil {
+		stream = ep.config.Hook.WrapStream(ctx, info, variantReq, stream)
+	}
+	return stream, nil
+}
+
+// route assigns the request to a variant and builds the variant's request
+func (ep *ExperimentProvider) route(ctx context.Context, req *provider.ChatCompletionRequest) (*ExperimentVariant, provider.Provider, *provider.ChatCompletionRequest) {
+	key, _ := ctx.Value(experimentKeyContextKey{}).(string)
+	v := ep.Assign(key)
+
+	p := v.Provider
+	if p == nil {
+		p = ep.config.Default
+	}
+
+	variantReq := *req
+	if v.Model != "" {
+		variantReq.Model = v.Model
+	}
+	if v.Temperature != nil {
+		variantReq.Temperature = v.Temperature
+	}
+	if v.MaxTokens != nil {
+		variantReq.MaxTokens = v.MaxTokens
+	}
+	if v.Modify != nil {
+		v.Modify(&variantReq)
+	}
+	return v, p, &variantReq
+}
+
+// before records the variant in ctx and runs the hook's BeforeRequest
+func (ep *ExperimentProvider) before(ctx context.Context, v *ExperimentVariant, p provider.Provider, req *provider.ChatCompletionRequest) (context.Context, LLMCallInfo) {
+	info := LLMCallInfo{CallID: newCallID(), ProviderName: p.Name(), StartTime: time.Now()}
+	ctx = context.WithValue(ctx, experimentInfoContextKey{}, ExperimentInfo{Experiment: ep.config.Name, Variant: v.Name})
+	if ep.config.Hook != nil {
+		ctx = ep.config.Hook.BeforeRequest(ctx, info, req)
+	}
+	return ctx, info
+}
+
+// tag adds the experiment and variant to metadata
+func (ep *ExperimentProvider) tag(metadata map[string]any, v *ExperimentVariant) map[string]any {
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata["experiment"] = ep.config.Name
+	metadata["experiment_variant"] = v.Name
+	return metadata
+}
+
+// Close closes the default provider and the variants' providers
+func (ep *ExperimentProvider) Close() error {
+	var errs []error
+	closed := make(map[provider.Provider]bool)
+	for _, p := range ep.providers() {
+		if closed[p] {
+			continue
+		}
+		closed[p] = true
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// providers returns the default provider and the variants' providers
+func (ep *ExperimentProvider) providers() []provider.Provider {
+	var providers []provider.Provider
+	if ep.config.Default != nil {
+		providers = append(providers, ep.config.Default)
+	}
+	for _, v := range ep.config.Variants {
+		if v.Provider != nil {
+			providers = append(providers, v.Provider)
+		}
+	}
+	return providers
+}
+
+// Name returns "experiment"
+func (ep *ExperimentProvider) Name() string {
+	return "experiment"
+}
+
+// experimentStream tags each chunk with the experiment and variant
+type experimentStream struct {
+	provider.ChatCompletionStream
+	ep      *ExperimentProvider
+	variant *ExperimentVariant
+}
+
+func (s *experimentStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if chunk != nil {
+		chunk.ProviderMetadata = s.ep.tag(chunk.ProviderMetadata, s.variant)
+	}
+	return chunk, err
+}
@@ -0,0 +1,134 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestExperimentProvider_StableAssignment(t *testing.T) {
+	control := mocktest.NewScriptedProvider("openai").Default(mocktest.TextStep("control"))
+	treatment := mocktest.NewScriptedProvider("anthropic").Default(mocktest.TextStep("treatment"))
+	temp := 0.2
+
+	ep, err := NewExperimentProvider(ExperimentConfig{
+		Name:    "claude-migration",
+		Default: control,
+		Variants: []ExperimentVariant{
+			{Name: "control", Weight: 3},
+			{Name: "treatment", Weight: 1, Provider: treatment, Model: "claude-sonnet-4-5", Temperature: &temp},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewExperimentProvider failed: %v", err)
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		counts[ep.Assign(fmt.Sprintf("user-%d", i)).Name]++
+	}
+	if counts["treatment"] < 350 || counts["treatment"] > 650 {
+		t.Errorf("expected about a quarter of users in treatment, got %v", counts)
+	}
+
+	// Find a user in each variant and check they stay there
+	var treated string
+	for i := 0; treated == ""; i++ {
+		if key := fmt.Sprintf("user-%d", i); ep.Assign(key).Name == "treatment" {
+			treated = key
+		}
+	}
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	for i := 0; i < 5; i++ {
+		resp, err := ep.CreateChatCompletion(WithExperimentKey(context.Background(), treated), req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		if resp.ProviderMetadata["experiment"] != "claude-migration" || resp.ProviderMetadata["experiment_variant"] != "treatment" {
+			t.Fatalf("unexpected metadata: %v", resp.ProviderMetadata)
+		}
+	}
+	if treatment.Calls() != 5 || control.Calls() != 0 {
+		t.Errorf("expected every request on treatment, got %d treatment and %d control calls", treatment.Calls(), control.Calls())
+	}
+	last := treatment.LastRequest()
+	if last.Model != "claude-sonnet-4-5" || last.Temperature == nil || *last.Temperature != 0.2 {
+		t.Errorf("expected the variant's model and parameters, got %+v", last)
+	}
+	if req.Model != "gpt-4o" || req.Temperature != nil {
+		t.Errorf("expected the caller's request to be unchanged, got %+v", req)
+	}
+}
+
+func TestExperimentProvider_HookAndStream(t *testing.T) {
+	p := mocktest.NewScriptedProvider("openai").Default(mocktest.Step{Chunks: mocktest.TextChunks("a", "b")})
+	hook := &experimentRecorder{}
+	ep, err := NewExperimentProvider(ExperimentConfig{
+		Name:     "prompt-v2",
+		Default:  p,
+		Hook:     hook,
+		Variants: []ExperimentVariant{{Name: "only", Weight: 1}},
+	})
+	if err != nil {
+		t.Fatalf("NewExperimentProvider failed: %v", err)
+	}
+
+	stream, err := ep.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	defer stream.Close()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if chunk.ProviderMetadata["experiment_variant"] != "only" {
+			t.Errorf("expected tagged chunks, got %v", chunk.ProviderMetadata)
+		}
+	}
+	if hook.info != (ExperimentInfo{Experiment: "prompt-v2", Variant: "only"}) {
+		t.Errorf("expected the hook to see the variant, got %+v", hook.info)
+	}
+}
+
+func TestNewExperimentProvider_Validation(t *testing.T) {
+	p := mocktest.NewScriptedProvider("p")
+	configs := []ExperimentConfig{
+		{Variants: []ExperimentVariant{{Name: "a", Weight: 1}}, Default: p},
+		{Name: "x", Default: p},
+		{Name: "x", Default: p, Variants: []ExperimentVariant{{Name: "a", Weight: 0}}},
+		{Name: "x", Default: p, Variants: []ExperimentVariant{{Name: "a", Weight: 1}, {Name: "a", Weight: 1}}},
+		{Name: "x", Variants: []ExperimentVariant{{Name: "a", Weight: 1}}},
+	}
+	for i, config := range configs {
+		if _, err := NewExperimentProvider(config); !errors.Is(err, ErrInvalidConfiguration) {
+			t.Errorf("config %d: expected configuration error, got %v", i, err)
+		}
+	}
+}
+
+// experimentRecorder is a hook that records the ExperimentInfo it sees
+type experimentRecorder struct {
+	info ExperimentInfo
+}
+
+func (r *experimentRecorder) BeforeRequest(ctx context.Context, _ LLMCallInfo, _ *provider.ChatCompletionRequest) context.Context {
+	r.info, _ = ExperimentInfoFromContext(ctx)
+	return ctx
+}
+
+func (r *experimentRecorder) AfterResponse(context.Context, LLMCallInfo, *provider.ChatCompletionRequest, *provider.ChatCompletionResponse, error) {
+}
+
+func (r *experimentRecorder) WrapStream(_ context.Context, _ LLMCallInfo, _ *provider.ChatCompletionRequest, s provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return s
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
 )
 
 // mockProvider is a test provider with configurable behavior
@@ -222,6 +224,57 @@ func TestFallbackProvider_NoFallbackOnAuthError(t *testing.T) {
 	}
 }
 
+func TestFallbackProvider_StatusOverrides(t *testing.T) {
+	primary := newMockProvider("primary")
+	primary.completionErr = NewAPIError("primary", 403, "gateway quota exhausted", "", "")
+	fallback := newMockProvider("fallback")
+
+	fp := NewFallbackProvider(primary, []provider.Provider{fallback}, &FallbackProviderConfig{
+		StatusOverrides: map[int]ErrorCategory{403: ErrorCategoryRetryable},
+	})
+
+	req := &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: "user", Content: "Hello"}},
+	}
+	if _, err := fp.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if fallback.callCount != 1 {
+		t.Errorf("expected fallback to be called once, got %d", fallback.callCount)
+	}
+}
+
+func TestRegisterErrorClassifier(t *testing.T) {
+	quota := func(err error) ErrorCategory {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == 403 && strings.Contains(apiErr.Message, "quota") {
+			return ErrorCategoryRetryable
+		}
+		return ErrorCategoryUnknown
+	}
+	RegisterErrorClassifier("gateway", quota)
+	defer RegisterErrorClassifier("gateway", nil)
+
+	if got := ClassifyError(NewAPIError("gateway", 403, "quota exhausted", "", "")); got != ErrorCategoryRetryable {
+		t.Errorf("gateway quota 403 = %v, want retryable", got)
+	}
+	if got := ClassifyError(NewAPIError("gateway", 403, "forbidden", "", "")); got != ErrorCategoryNonRetryable {
+		t.Errorf("gateway plain 403 = %v, want the built-in non-retryable", got)
+	}
+	if got := ClassifyError(NewAPIError("openai", 403, "quota exhausted", "", "")); got != ErrorCategoryNonRetryable {
+		t.Errorf("other provider's 403 = %v, want non-retryable", got)
+	}
+
+	RegisterErrorClassifier("gateway", func(err error) ErrorCategory { return ErrorCategoryNonRetryable })
+	if got := ClassifyProviderError("gateway", errors.New("upstream reset")); got != ErrorCategoryNonRetryable {
+		t.Errorf("ClassifyProviderError() = %v, want the registered category", got)
+	}
+	if got := ClassifyError(errors.New("upstream reset")); got != ErrorCategoryUnknown {
+		t.Errorf("ClassifyError() = %v, want unknown without a provider", got)
+	}
+}
+
 func TestFallbackProvider_AllProvidersFail(t *testing.T) {
 	primary := newMockProvider("primary")
 	primary.completionErr = NewAPIError("primary", 500, "server error", "server_error", "500")
@@ -460,3 +513,199 @@ func TestFallbackProvider_ProviderMetadata(t *testing.T) {
 		t.Errorf("expected fallback_attempt_count=1, got %v", attemptCount)
 	}
 }
+
+func TestFallbackProvider_StreamResume(t *testing.T) {
+	primary := mocktest.NewScriptedProvider("primary", mocktest.Step{
+		Chunks:    mocktest.TextChunks("The answer ", "is "),
+		StreamErr: errors.New("connection reset by peer"),
+	})
+	fallback := mocktest.NewScriptedProvider("fallback", mocktest.Step{Chunks: mocktest.TextChunks("42.")})
+
+	fp := NewFallbackProvider(primary, []provider.Provider{fallback}, &FallbackProviderConfig{
+		StreamResume: &StreamResumeConfig{Prompt: "Continue."},
+	})
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "question"}}}
+	stream, err := fp.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("expected resumed stream to succeed, got %v", err)
+	}
+	if got := resp.Choices[0].Message.Content; got != "The answer is 42." {
+		t.Errorf("expected content continued across providers, got %q", got)
+	}
+
+	resumed := fallback.LastRequest().Messages
+	if len(resumed) != 3 || resumed[1].Role != provider.RoleAssistant || resumed[1].Content != "The answer is " || resumed[2].Content != "Continue." {
+		t.Errorf("unexpected resumed messages: %+v", resumed)
+	}
+	if len(req.Messages) != 1 {
+		t.Error("expected the caller's request to be left unchanged")
+	}
+}
+
+func TestFallbackProvider_StreamResumeDisabled(t *testing.T) {
+	streamErr := errors.New("connection reset by peer")
+	primary := mocktest.NewScriptedProvider("primary", mocktest.Step{Chunks: mocktest.TextChunks("partial"), StreamErr: streamErr})
+	fallback := mocktest.NewScriptedProvider("fallback", mocktest.TextStep("unused"))
+
+	fp := NewFallbackProvider(primary, []provider.Provider{fallback}, nil)
+	stream, err := fp.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+	if _, err := AccumulateStream(stream); !errors.Is(err, streamErr) {
+		t.Errorf("expected stream error without resume, got %v", err)
+	}
+	if fallback.Calls() != 0 {
+		t.Error("expected fallback not to be called")
+	}
+}
+
+func TestFallbackProvider_FallbackOnEmptyStream(t *testing.T) {
+	tests := []struct {
+		name string
+		step mocktest.Step
+	}{
+		{name: "no chunks", step: mocktest.Step{}},
+		{name: "immediate error", step: mocktest.Step{StreamErr: errors.New("connection reset by peer")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := mocktest.NewScriptedProvider("primary", tt.step)
+			fallback := mocktest.NewScriptedProvider("fallback", mocktest.Step{Chunks: mocktest.TextChunks("from ", "fallback")})
+
+			fp := NewFallbackProvider(primary, []provider.Provider{fallback}, &FallbackProviderConfig{FallbackOnEmptyStream: true})
+			stream, err := fp.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+			if err != nil {
+				t.Fatalf("CreateChatCompletionStream failed: %v", err)
+			}
+			resp, err := AccumulateStream(stream)
+			if err != nil {
+				t.Fatalf("expected fallback stream to succeed, got %v", err)
+			}
+			if got := resp.Choices[0].Message.Content; got != "from fallback" {
+				t.Errorf("expected the whole fallback stream, got %q", got)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		primary := mocktest.NewScriptedProvider("primary", mocktest.Step{})
+		fallback := mocktest.NewScriptedProvider("fallback", mocktest.TextStep("unused"))
+
+		fp := NewFallbackProvider(primary, []provider.Provider{fallback}, nil)
+		stream, err := fp.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+		if err != nil {
+			t.Fatalf("CreateChatCompletionStream failed: %v", err)
+		}
+		_, _ = AccumulateStream(stream)
+		if fallback.Calls() != 0 {
+			t.Error("expected fallback not to be called")
+		}
+	})
+
+	t.Run("all empty", func(t *testing.T) {
+		primary := mocktest.NewScriptedProvider("primary", mocktest.Step{})
+		fallback := mocktest.NewScriptedProvider("fallback", mocktest.Step{})
+
+		fp := NewFallbackProvider(primary, []provider.Provider{fallback}, &FallbackProviderConfig{FallbackOnEmptyStream: true})
+		_, err := fp.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+		if !errors.Is(err, ErrEmptyStream) {
+			t.Errorf("expected ErrEmptyStream, got %v", err)
+		}
+	})
+}
+
+func TestFallbackProvider_RespectsRetryAfter(t *testing.T) {
+	primary := newMockProvider("primary")
+	fallback := newMockProvider("fallback")
+	rateLimited := NewAPIError("primary", 429, "slow down", "rate_limit", "")
+	rateLimited.RetryAfter = time.Minute
+	primary.completionErr = rateLimited
+
+	fp := NewFallbackProvider(primary, []provider.Provider{fallback}, nil)
+	req := &provider.ChatCompletionRequest{Model: "m"}
+
+	if _, err := fp.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if _, err := fp.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if primary.callCount != 1 {
+		t.Errorf("expected primary to be skipped during Retry-After, got %d calls", primary.callCount)
+	}
+
+	fallback.completionErr = ErrServerError
+	_, err := fp.CreateChatCompletion(context.Background(), req)
+	var fbErr *FallbackError
+	if !errors.As(err, &fbErr) || !fbErr.Attempts[0].Skipped {
+		t.Fatalf("expected skipped primary in FallbackError, got %v", err)
+	}
+	if wait, ok := RetryAfter(fbErr.Attempts[0].Error); !ok || wait != time.Minute {
+		t.Errorf("expected skipped attempt to carry the Retry-After error, got %v", fbErr.Attempts[0].Error)
+	}
+}
+
+func TestClient_FallbackModelMap(t *testing.T) {
+	primary := mocktest.NewScriptedProvider("openai").Default(mocktest.Step{Err: ErrServerError})
+	mapped := mocktest.NewScriptedProvider("anthropic").Default(mocktest.TextStep("ok"))
+	defaulted := mocktest.NewScriptedProvider("gemini").Default(mocktest.TextStep("ok"))
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: primary},
+			{CustomProvider: mapped, ModelMap: map[string]string{"gpt-4o": "claude-sonnet-4-5"}},
+			{CustomProvider: defaulted, DefaultModel: "gemini-2.5-flash"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := primary.LastRequest().Model; got != "gpt-4o" {
+		t.Errorf("expected the primary to get the original model, got %q", got)
+	}
+	if got := mapped.LastRequest().Model; got != "claude-sonnet-4-5" {
+		t.Errorf("expected the mapped model, got %q", got)
+	}
+	if req.Model != "gpt-4o" {
+		t.Errorf("expected the caller's request to be unchanged, got %q", req.Model)
+	}
+
+	// Models missing from the map are sent unchanged without a DefaultModel,
+	// and replaced with it otherwise
+	mapped.Default(mocktest.Step{Err: ErrServerError})
+	req.Model = "o3"
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := mapped.LastRequest().Model; got != "o3" {
+		t.Errorf("expected an unmapped model to pass through, got %q", got)
+	}
+	if got := defaulted.LastRequest().Model; got != "gemini-2.5-flash" {
+		t.Errorf("expected the default model, got %q", got)
+	}
+}
+
+func TestAPIError_ProviderName(t *testing.T) {
+	err := error(&APIError{StatusCode: 429, Provider: ProviderNameAnthropic})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Provider != ProviderNameAnthropic {
+		t.Fatalf("Provider = %v, want %v", apiErr.Provider, ProviderNameAnthropic)
+	}
+	if got := NewAPIError(ProviderNameOpenAI, 500, "", "", "").Provider; got != ProviderNameOpenAI {
+		t.Errorf("NewAPIError Provider = %v, want %v", got, ProviderNameOpenAI)
+	}
+}
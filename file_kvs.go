@@ -0,0 +1,93 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileKVS is a kvs.Client that stores each key as a file in a directory, so
+// the response cache, KVS memory, and usage tracking work in local
+// development and command-line tools without a Redis or DynamoDB server.
+// Keys are escaped to file names ending in ".json". Setting a key to the
+// empty string, which omnillm uses to delete entries, removes its file.
+// Expired cache entries stay on disk until they are overwritten or removed.
+type FileKVS struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileKVS returns a FileKVS that stores keys in dir, creating it if needed
+func NewFileKVS(dir string) (*FileKVS, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create KVS directory: %w", err)
+	}
+	return &FileKVS{dir: dir}, nil
+}
+
+// SetString stores val under key
+func (s *FileKVS) SetString(_ context.Context, key, val string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := s.path(key)
+	if val == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+		return nil
+	}
+	return writeFileAtomic(path, []byte(val))
+}
+
+// GetString returns the value under key, or an error if there is none
+func (s *FileKVS) GetString(_ context.Context, key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, err := os.ReadFile(s.path(key)) //nolint:gosec // G304: path is built from the store's directory
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("key not found: %s", key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return string(data), nil
+}
+
+// GetOrDefaultString returns the value under key, or def if there is none
+func (s *FileKVS) GetOrDefaultString(ctx context.Context, key, def string) string {
+	val, err := s.GetString(ctx, key)
+	if err != nil {
+		return def
+	}
+	return val
+}
+
+// SetAny stores val under key as JSON
+func (s *FileKVS) SetAny(ctx context.Context, key string, val any) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return s.SetString(ctx, key, string(data))
+}
+
+// GetAny decodes the JSON value under key into val
+func (s *FileKVS) GetAny(ctx context.Context, key string, val any) error {
+	data, err := s.GetString(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), val)
+}
+
+// path returns the file of a key. Keys are escaped, so they cannot name
+// files outside the directory.
+func (s *FileKVS) path(key string) string {
+	return filepath.Join(s.dir, url.QueryEscape(key)+".json")
+}
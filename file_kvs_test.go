@@ -0,0 +1,76 @@
+package omnillm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestFileKVS(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "kvs")
+	store, err := NewFileKVS(dir)
+	if err != nil {
+		t.Fatalf("NewFileKVS failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := store.GetString(ctx, "missing"); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+	if got := store.GetOrDefaultString(ctx, "missing", "def"); got != "def" {
+		t.Errorf("GetOrDefaultString = %q, want def", got)
+	}
+
+	if err := store.SetAny(ctx, "a:b/../c", map[string]int{"n": 1}); err != nil {
+		t.Fatalf("SetAny failed: %v", err)
+	}
+	var got map[string]int
+	if err := store.GetAny(ctx, "a:b/../c", &got); err != nil || got["n"] != 1 {
+		t.Errorf("GetAny = %v, %v", got, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("got %d files, want 1 in the directory", len(entries))
+	}
+
+	if err := store.SetString(ctx, "a:b/../c", ""); err != nil {
+		t.Fatalf("SetString failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("empty value left %d files", len(entries))
+	}
+}
+
+func TestFileKVS_Cache(t *testing.T) {
+	store, err := NewFileKVS(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileKVS failed: %v", err)
+	}
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("cached"))
+	client, err := NewClient(ClientConfig{
+		Providers:   []ProviderConfig{{CustomProvider: mock}},
+		Cache:       store,
+		CacheConfig: &CacheConfig{},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+	for range 2 {
+		if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls := mock.Calls(); calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,83 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrFilesNotSupported is returned when no configured provider supports file storage
+var ErrFilesNotSupported = errors.New("file storage not supported by configured providers")
+
+// UploadFile uploads a file using the first configured provider that supports file storage.
+// The returned handle can be referenced from messages sent to the same provider.
+func (c *ChatClient) UploadFile(ctx context.Context, req *provider.FileUploadRequest) (*provider.File, error) {
+	if c.files == nil {
+		return nil, ErrFilesNotSupported
+	}
+	if req.Filename == "" {
+		return nil, ErrInvalidRequest
+	}
+	return c.files.UploadFile(ctx, req)
+}
+
+// GetFile retrieves the metadata for a previously uploaded file
+func (c *ChatClient) GetFile(ctx context.Context, fileID string) (*provider.File, error) {
+	if c.files == nil {
+		return nil, ErrFilesNotSupported
+	}
+	return c.files.GetFile(ctx, fileID)
+}
+
+// DeleteFile deletes a previously uploaded file
+func (c *ChatClient) DeleteFile(ctx context.Context, fileID string) error {
+	if c.files == nil {
+		return ErrFilesNotSupported
+	}
+	return c.files.DeleteFile(ctx, fileID)
+}
+
+// HasFiles returns true if a provider supporting file storage is configured
+func (c *ChatClient) HasFiles() bool {
+	return c.files != nil
+}
+
+// findCapability returns the first provider that implements the capability
+// interface T, looking beneath any wrappers such as a key pool
+func findCapability[T any](providers ...provider.Provider) T {
+	for _, p := range providers {
+		if c, ok := asCapability[T](p); ok {
+			return c
+		}
+	}
+	var zero T
+	return zero
+}
+
+// asCapability returns p, or the first provider beneath its wrappers, that
+// implements the capability interface T
+func asCapability[T any](p provider.Provider) (T, bool) {
+	for {
+		if c, ok := p.(T); ok {
+			return c, true
+		}
+		w, ok := p.(providerWrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		p = w.unwrapProvider()
+	}
+}
+
+// findCapabilities returns every provider that implements the capability interface T
+func findCapabilities[T any](providers ...provider.Provider) []T {
+	var found []T
+	for _, p := range providers {
+		if c, ok := asCapability[T](p); ok {
+			found = append(found, c)
+		}
+	}
+	return found
+}
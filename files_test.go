@@ -0,0 +1,103 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockFileProvider is a provider that also supports file storage
+type mockFileProvider struct {
+	*MockProvider
+	files map[string]*provider.File
+}
+
+func newMockFileProvider(name string) *mockFileProvider {
+	return &mockFileProvider{
+		MockProvider: NewMockProvider(name),
+		files:        make(map[string]*provider.File),
+	}
+}
+
+func (m *mockFileProvider) UploadFile(ctx context.Context, req *provider.FileUploadRequest) (*provider.File, error) {
+	file := &provider.File{
+		ID:       "file-" + req.Filename,
+		Provider: m.name,
+		Filename: req.Filename,
+		MIMEType: req.MIMEType,
+		Bytes:    int64(len(req.Data)),
+	}
+	m.files[file.ID] = file
+	return file, nil
+}
+
+func (m *mockFileProvider) GetFile(ctx context.Context, fileID string) (*provider.File, error) {
+	file, ok := m.files[fileID]
+	if !ok {
+		return nil, ErrModelNotFound
+	}
+	return file, nil
+}
+
+func (m *mockFileProvider) DeleteFile(ctx context.Context, fileID string) error {
+	delete(m.files, fileID)
+	return nil
+}
+
+func TestChatClient_Files(t *testing.T) {
+	fileProv := newMockFileProvider("files")
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: NewMockProvider("chat")},
+			{CustomProvider: fileProv},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	ctx := context.Background()
+	file, err := client.UploadFile(ctx, &provider.FileUploadRequest{
+		Filename: "report.pdf",
+		MIMEType: "application/pdf",
+		Data:     []byte("%PDF-1.4"),
+	})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if file.Provider != "files" || file.Bytes != 8 {
+		t.Errorf("file = %+v", file)
+	}
+
+	got, err := client.GetFile(ctx, file.ID)
+	if err != nil || got.Filename != "report.pdf" {
+		t.Errorf("GetFile = %+v, %v", got, err)
+	}
+
+	if err := client.DeleteFile(ctx, file.ID); err != nil {
+		t.Fatalf("DeleteFile failed: %v", err)
+	}
+	if _, err := client.GetFile(ctx, file.ID); err == nil {
+		t.Error("GetFile should fail after delete")
+	}
+}
+
+func TestChatClient_FilesNotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if client.HasFiles() {
+		t.Error("HasFiles() = true, want false")
+	}
+	_, err = client.UploadFile(context.Background(), &provider.FileUploadRequest{Filename: "a.txt"})
+	if !errors.Is(err, ErrFilesNotSupported) {
+		t.Errorf("error = %v, want ErrFilesNotSupported", err)
+	}
+}
@@ -0,0 +1,42 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrFIMNotSupported is returned when no fill-in-the-middle provider is configured
+var ErrFIMNotSupported = errors.New("fill-in-the-middle completion not supported by configured providers")
+
+// FIMConfig configures fill-in-the-middle code completion
+type FIMConfig struct {
+	// Provider is the FIM provider to use, such as a client from the
+	// providers/fim package. If nil, the first configured provider that
+	// implements provider.FIMProvider is used.
+	Provider provider.FIMProvider
+
+	// Model is the default FIM model used when the request does not set one.
+	Model string
+}
+
+// CreateFIMCompletion generates the code between req.Prompt and req.Suffix,
+// as editor integrations do for completion at the cursor. Like moderation,
+// this works regardless of which chat provider is active.
+func (c *ChatClient) CreateFIMCompletion(ctx context.Context, req *provider.FIMRequest) (*provider.FIMResponse, error) {
+	if c.fim == nil {
+		return nil, ErrFIMNotSupported
+	}
+
+	fimReq := *req
+	if fimReq.Model == "" {
+		fimReq.Model = c.fimConfig.Model
+	}
+	return c.fim.CreateFIMCompletion(ctx, &fimReq)
+}
+
+// HasFIM returns true if a fill-in-the-middle provider is available
+func (c *ChatClient) HasFIM() bool {
+	return c.fim != nil
+}
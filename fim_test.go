@@ -0,0 +1,57 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockFIMProvider is a provider that also supports fill-in-the-middle completion
+type mockFIMProvider struct {
+	*MockProvider
+	lastReq *provider.FIMRequest
+}
+
+func (m *mockFIMProvider) CreateFIMCompletion(ctx context.Context, req *provider.FIMRequest) (*provider.FIMResponse, error) {
+	m.lastReq = req
+	return &provider.FIMResponse{Model: req.Model, Provider: m.Name(), Text: "middle"}, nil
+}
+
+func TestChatClient_CreateFIMCompletion(t *testing.T) {
+	prov := &mockFIMProvider{MockProvider: NewMockProvider("coder")}
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: prov}},
+		FIMConfig: &FIMConfig{Model: "code-default"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if !client.HasFIM() {
+		t.Fatal("HasFIM() = false")
+	}
+
+	resp, err := client.CreateFIMCompletion(context.Background(), &FIMRequest{Prompt: "a = ", Suffix: "\n"})
+	if err != nil {
+		t.Fatalf("CreateFIMCompletion failed: %v", err)
+	}
+	if prov.lastReq.Model != "code-default" || resp.Text != "middle" {
+		t.Errorf("request = %+v, response = %+v", prov.lastReq, resp)
+	}
+}
+
+func TestChatClient_CreateFIMCompletion_NotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.HasFIM() {
+		t.Error("HasFIM() = true")
+	}
+	if _, err := client.CreateFIMCompletion(context.Background(), &FIMRequest{Prompt: "a = "}); !errors.Is(err, ErrFIMNotSupported) {
+		t.Errorf("error = %v, want ErrFIMNotSupported", err)
+	}
+}
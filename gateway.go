@@ -0,0 +1,119 @@
+package omnillm
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+)
+
+// withGateway returns providers with their gateway settings applied: query
+// parameters in BaseURL are moved to QueryParams, and QueryParams, ProxyURL,
+// and Auth are applied to the transport of each provider that builds its own
+// HTTP client
+func withGateway(providers []ProviderConfig) ([]ProviderConfig, error) {
+	result := make([]ProviderConfig, len(providers))
+	for i, pc := range providers {
+		if pc.CustomProvider == nil {
+			var err error
+			if pc, err = applyGateway(pc); err != nil {
+				return nil, fmt.Errorf("providers[%d]: %w", i, err)
+			}
+		}
+		result[i] = pc
+	}
+	return result, nil
+}
+
+// applyGateway applies the gateway settings of one provider config
+func applyGateway(pc ProviderConfig) (ProviderConfig, error) {
+	if pc.BaseURL != "" {
+		base, err := url.Parse(pc.BaseURL)
+		if err != nil {
+			return pc, fmt.Errorf("%w: invalid base URL %q", ErrInvalidConfiguration, pc.BaseURL)
+		}
+		if base.RawQuery != "" {
+			query := make(url.Values, len(pc.QueryParams))
+			maps.Copy(query, pc.QueryParams)
+			for name, values := range base.Query() {
+				if !query.Has(name) {
+					query[name] = values
+				}
+			}
+			pc.QueryParams = query
+			base.RawQuery = ""
+			pc.BaseURL = base.String()
+		}
+	}
+	if len(pc.QueryParams) == 0 && pc.ProxyURL == "" && pc.Auth == nil {
+		return pc, nil
+	}
+	if pc.Auth != nil && pc.APIKey == "" && len(pc.APIKeys) == 0 {
+		pc.APIKey = authPlaceholderKey
+	}
+
+	var base http.RoundTripper
+	if pc.HTTPClient != nil {
+		base = pc.HTTPClient.Transport
+	} else {
+		base = pc.Transport
+	}
+
+	if pc.ProxyURL != "" {
+		proxyURL, err := url.Parse(pc.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return pc, fmt.Errorf("%w: invalid proxy URL %q", ErrInvalidConfiguration, pc.ProxyURL)
+		}
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		transport, ok := base.(*http.Transport)
+		if !ok {
+			return pc, fmt.Errorf("%w: ProxyURL needs an *http.Transport, got %T", ErrInvalidConfiguration, base)
+		}
+		transport = transport.Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		base = transport
+	}
+
+	// Authenticate after the query parameters are added, so signatures cover them
+	if pc.Auth != nil {
+		base = &authTransport{base: base, auth: pc.Auth}
+	}
+	if len(pc.QueryParams) > 0 {
+		base = &queryTransport{base: base, query: pc.QueryParams}
+	}
+
+	if pc.HTTPClient != nil {
+		client := *pc.HTTPClient
+		client.Transport = base
+		pc.HTTPClient = &client
+	} else {
+		pc.Transport = base
+	}
+	return pc, nil
+}
+
+// queryTransport adds query parameters to every request URL. Parameters the
+// request already has are kept.
+type queryTransport struct {
+	base  http.RoundTripper
+	query url.Values
+}
+
+func (t *queryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	query := req.URL.Query()
+	for name, values := range t.query {
+		if !query.Has(name) {
+			query[name] = values
+		}
+	}
+	req = req.Clone(req.Context())
+	req.URL.RawQuery = query.Encode()
+	return base.RoundTrip(req)
+}
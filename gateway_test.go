@@ -0,0 +1,105 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+const gatewayCompletion = `{"id":"r1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`
+
+func gatewayRequest() *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+}
+
+func TestGateway_PathPrefixAndQueryParams(t *testing.T) {
+	var got *url.URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL
+		_, _ = io.WriteString(w, gatewayCompletion)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider:    ProviderNameOpenAI,
+			APIKey:      "test-key",
+			BaseURL:     server.URL + "/openai/v1?api-version=2024-10-21",
+			QueryParams: url.Values{"tenant": {"acme"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateChatCompletion(context.Background(), gatewayRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Path != "/openai/v1/chat/completions" {
+		t.Errorf("path = %q, want the gateway prefix", got.Path)
+	}
+	if q := got.Query(); q.Get("api-version") != "2024-10-21" || q.Get("tenant") != "acme" {
+		t.Errorf("query = %q", got.RawQuery)
+	}
+}
+
+func TestGateway_ProxyURL(t *testing.T) {
+	var target, auth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target = r.URL.String()
+		auth = r.Header.Get("Proxy-Authorization")
+		_, _ = io.WriteString(w, gatewayCompletion)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider: ProviderNameOpenAI,
+			APIKey:   "test-key",
+			BaseURL:  "http://llm.internal/v1",
+			ProxyURL: proxyURL.String(),
+		}},
+		TransportConfig: &TransportConfig{},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateChatCompletion(context.Background(), gatewayRequest()); err != nil {
+		t.Fatal(err)
+	}
+	if target != "http://llm.internal/v1/chat/completions" {
+		t.Errorf("proxied URL = %q", target)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret")); auth != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", auth, want)
+	}
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestGateway_InvalidProxy(t *testing.T) {
+	tests := []ProviderConfig{
+		{Provider: ProviderNameOpenAI, APIKey: "k", ProxyURL: "not a proxy"},
+		{Provider: ProviderNameOpenAI, APIKey: "k", ProxyURL: "http://proxy:8080", Transport: roundTripperFunc(nil)},
+	}
+	for _, pc := range tests {
+		if _, err := NewClient(ClientConfig{Providers: []ProviderConfig{pc}}); !errors.Is(err, ErrInvalidConfiguration) {
+			t.Errorf("%+v: expected ErrInvalidConfiguration, got %v", pc, err)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrOutputBlocked is returned (wrapped in a *GuardrailError) when a response
+// violates an output guardrail
+var ErrOutputBlocked = errors.New("output blocked by guardrail")
+
+// MetadataKeyGuardrailViolations is the ProviderMetadata key holding the
+// []GuardrailViolation found when the guardrail action is GuardrailAnnotate
+const MetadataKeyGuardrailViolations = "guardrail_violations"
+
+// GuardrailAction is what happens when a response violates an output guardrail
+type GuardrailAction string
+
+const (
+	// GuardrailBlock returns a *GuardrailError instead of the response
+	GuardrailBlock GuardrailAction = "block"
+
+	// GuardrailRetry asks the model again with a corrective message, up to
+	// MaxRetries times, then blocks
+	GuardrailRetry GuardrailAction = "retry"
+
+	// GuardrailAnnotate returns the response with the violations recorded in
+	// its ProviderMetadata; annotated responses are not cached
+	GuardrailAnnotate GuardrailAction = "annotate"
+)
+
+// GuardrailViolation describes one failed output check
+type GuardrailViolation struct {
+	Validator   string `json:"validator"`
+	ChoiceIndex int    `json:"choice_index"`
+	Reason      string `json:"reason"`
+}
+
+// GuardrailError is returned when a response is blocked by output guardrails
+type GuardrailError struct {
+	Violations []GuardrailViolation
+}
+
+func (e *GuardrailError) Error() string {
+	reasons := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		reasons = append(reasons, v.Validator+": "+v.Reason)
+	}
+	return fmt.Sprintf("%s: %s", ErrOutputBlocked, strings.Join(reasons, "; "))
+}
+
+// Is reports whether target is ErrOutputBlocked
+func (e *GuardrailError) Is(target error) bool {
+	return target == ErrOutputBlocked
+}
+
+// OutputValidator checks a generated message. It returns a non-empty reason
+// when the message violates the policy; an error means the check itself
+// could not run and fails the request.
+type OutputValidator interface {
+	Name() string
+	ValidateOutput(ctx context.Context, msg *provider.Message) (reason string, err error)
+}
+
+// funcValidator adapts a function to an OutputValidator
+type funcValidator struct {
+	name string
+	fn   func(ctx context.Context, msg *provider.Message) (string, error)
+}
+
+func (v *funcValidator) Name() string { return v.name }
+
+func (v *funcValidator) ValidateOutput(ctx context.Context, msg *provider.Message) (string, error) {
+	return v.fn(ctx, msg)
+}
+
+// NewOutputValidator creates a named validator from a function
+func NewOutputValidator(name string, fn func(ctx context.Context, msg *provider.Message) (reason string, err error)) OutputValidator {
+	return &funcValidator{name: name, fn: fn}
+}
+
+// DenylistValidator rejects output matching any of the patterns
+func DenylistValidator(patterns ...*regexp.Regexp) OutputValidator {
+	return NewOutputValidator("denylist", func(ctx context.Context, msg *provider.Message) (string, error) {
+		for _, re := range patterns {
+			if re.MatchString(msg.Content) {
+				return fmt.Sprintf("output matches denied pattern %q", re.String()), nil
+			}
+		}
+		return "", nil
+	})
+}
+
+// MaxLengthValidator rejects output longer than maxChars characters
+func MaxLengthValidator(maxChars int) OutputValidator {
+	return NewOutputValidator("max_length", func(ctx context.Context, msg *provider.Message) (string, error) {
+		if n := utf8.RuneCountInString(msg.Content); n > maxChars {
+			return fmt.Sprintf("output is %d characters, limit is %d", n, maxChars), nil
+		}
+		return "", nil
+	})
+}
+
+// JSONSchemaValidator rejects output that is not JSON matching schema. The
+// common subset of JSON Schema is supported: type, properties, required,
+// additionalProperties (boolean), items, enum, minLength/maxLength,
+// minimum/maximum, and minItems/maxItems.
+func JSONSchemaValidator(schema map[string]any) OutputValidator {
+	return NewOutputValidator("json_schema", func(ctx context.Context, msg *provider.Message) (string, error) {
+		var value any
+		if err := json.Unmarshal([]byte(stripCodeFence(msg.Content)), &value); err != nil {
+			return "output is not valid JSON: " + err.Error(), nil
+		}
+		if problems := validateJSONSchema(value, schema, "$"); len(problems) > 0 {
+			return strings.Join(problems, "; "), nil
+		}
+		return "", nil
+	})
+}
+
+// moderationValidator flags output using a moderation provider
+type moderationValidator struct {
+	provider provider.ModerationProvider
+	config   ModerationConfig
+}
+
+// NewModerationValidator rejects output flagged by a moderation model. If mod
+// is nil, the client's own moderation provider and ModerationConfig are used,
+// and NewClient fails with ErrModerationNotSupported if there is none.
+func NewModerationValidator(mod provider.ModerationProvider) OutputValidator {
+	return &moderationValidator{provider: mod}
+}
+
+func (v *moderationValidator) Name() string { return "moderation" }
+
+func (v *moderationValidator) ValidateOutput(ctx context.Context, msg *provider.Message) (string, error) {
+	if v.provider == nil {
+		return "", ErrModerationNotSupported
+	}
+	if msg.Content == "" {
+		return "", nil
+	}
+	resp, err := v.provider.CreateModeration(ctx, &provider.ModerationRequest{
+		Input: []string{msg.Content},
+		Model: v.config.Model,
+	})
+	if err != nil {
+		return "", fmt.Errorf("moderation check failed: %w", err)
+	}
+	applyModerationThresholds(resp, v.config)
+
+	var categories []string
+	for _, result := range resp.Results {
+		if !result.Flagged {
+			continue
+		}
+		for category, flagged := range result.Categories {
+			if flagged {
+				categories = append(categories, category)
+			}
+		}
+	}
+	if len(categories) == 0 {
+		return "", nil
+	}
+	return "flagged by moderation: " + strings.Join(categories, ", "), nil
+}
+
+// OutputGuardrailConfig configures checks run on every non-streaming response
+// before it is returned or cached
+type OutputGuardrailConfig struct {
+	// Validators run in order on the message of each choice
+	Validators []OutputValidator
+
+	// Action taken when any validator reports a violation. Default: GuardrailBlock
+	Action GuardrailAction
+
+	// MaxRetries is the number of corrective retries for GuardrailRetry. Default: 1
+	MaxRetries int
+
+	// CorrectiveMessage builds the user message sent on retry. The default
+	// lists the violations and asks for a corrected answer.
+	CorrectiveMessage func(violations []GuardrailViolation) string
+}
+
+// withClient resolves validators that depend on client state
+func (g OutputGuardrailConfig) withClient(c *ChatClient) (*OutputGuardrailConfig, error) {
+	validators := make([]OutputValidator, len(g.Validators))
+	for i, v := range g.Validators {
+		if mv, ok := v.(*moderationValidator); ok && mv.provider == nil {
+			if c.moderation == nil {
+				return nil, fmt.Errorf("moderation validator: %w", ErrModerationNotSupported)
+			}
+			v = &moderationValidator{provider: c.moderation, config: c.moderationConfig}
+		}
+		validators[i] = v
+	}
+	g.Validators = validators
+	if g.Action == "" {
+		g.Action = GuardrailBlock
+	}
+	if g.MaxRetries <= 0 {
+		g.MaxRetries = 1
+	}
+	if g.CorrectiveMessage == nil {
+		g.CorrectiveMessage = defaultCorrectiveMessage
+	}
+	return &g, nil
+}
+
+func defaultCorrectiveMessage(violations []GuardrailViolation) string {
+	var sb strings.Builder
+	sb.WriteString("Your previous answer was rejected for the following reasons:\n")
+	for _, v := range violations {
+		sb.WriteString("- ")
+		sb.WriteString(v.Reason)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("Please provide a corrected answer.")
+	return sb.String()
+}
+
+// checkOutput runs every validator on every choice
+func (g *OutputGuardrailConfig) checkOutput(ctx context.Context, resp *provider.ChatCompletionResponse) ([]GuardrailViolation, error) {
+	var violations []GuardrailViolation
+	for _, choice := range resp.Choices {
+		for _, v := range g.Validators {
+			reason, err := v.ValidateOutput(ctx, &choice.Message)
+			if err != nil {
+				return nil, fmt.Errorf("guardrail %s: %w", v.Name(), err)
+			}
+			if reason != "" {
+				violations = append(violations, GuardrailViolation{
+					Validator:   v.Name(),
+					ChoiceIndex: choice.Index,
+					Reason:      reason,
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// rejectedChoice returns the message of the first choice with a violation,
+// to send back on retry, and that choice's violations
+func rejectedChoice(resp *provider.ChatCompletionResponse, violations []GuardrailViolation) (provider.Message, []GuardrailViolation) {
+	index := violations[0].ChoiceIndex
+	var own []GuardrailViolation
+	for _, v := range violations {
+		if v.ChoiceIndex == index {
+			own = append(own, v)
+		}
+	}
+	for _, choice := range resp.Choices {
+		if choice.Index == index {
+			return choice.Message, own
+		}
+	}
+	return resp.Choices[0].Message, own
+}
+
+// applyOutputGuardrails checks resp and applies the configured action. It
+// returns the response to use and whether it carries annotated violations.
+func (c *ChatClient) applyOutputGuardrails(ctx context.Context, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse) (*provider.ChatCompletionResponse, bool, error) {
+	g := c.outputGuardrails
+	violations, err := g.checkOutput(ctx, resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	retryReq := req
+	for attempt := 0; len(violations) > 0 && g.Action == GuardrailRetry && attempt < g.MaxRetries; attempt++ {
+		rejected, rejectedViolations := rejectedChoice(resp, violations)
+		retry := *retryReq
+		retry.Messages = append(append([]provider.Message(nil), retryReq.Messages...),
+			rejected,
+			provider.Message{Role: provider.RoleUser, Content: g.CorrectiveMessage(rejectedViolations)},
+		)
+		retryReq = &retry
+
+		c.logger.Debug("retrying after output guardrail violation",
+			"attempt", attempt+1,
+			"violations", len(violations))
+
+		resp, err = c.callProvider(deriveIdempotencyKey(ctx, fmt.Sprintf("retry-%d", attempt+1)), retryReq)
+		if err != nil {
+			return nil, false, err
+		}
+		if violations, err = g.checkOutput(ctx, resp); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(violations) == 0 {
+		return resp, false, nil
+	}
+	if g.Action == GuardrailAnnotate {
+		if resp.ProviderMetadata == nil {
+			resp.ProviderMetadata = make(map[string]any)
+		}
+		resp.ProviderMetadata[MetadataKeyGuardrailViolations] = violations
+		return resp, true, nil
+	}
+	return nil, false, &GuardrailError{Violations: violations}
+}
+
+// stripCodeFence removes a surrounding Markdown code fence, which models often
+// add around JSON output
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	s = strings.TrimPrefix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
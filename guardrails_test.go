@@ -0,0 +1,218 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func newGuardrailClient(t *testing.T, prov provider.Provider, config OutputGuardrailConfig) *ChatClient {
+	t.Helper()
+	client, err := NewClient(ClientConfig{
+		Providers:        []ProviderConfig{{CustomProvider: prov}},
+		OutputGuardrails: &config,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func guardrailRequest() *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+}
+
+func TestOutputGuardrails_Block(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted", mocktest.TextStep("the password is hunter2"))
+	client := newGuardrailClient(t, prov, OutputGuardrailConfig{
+		Validators: []OutputValidator{DenylistValidator(regexp.MustCompile(`password`))},
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), guardrailRequest())
+	if !errors.Is(err, ErrOutputBlocked) {
+		t.Fatalf("expected ErrOutputBlocked, got %v", err)
+	}
+	var gErr *GuardrailError
+	if !errors.As(err, &gErr) || len(gErr.Violations) != 1 || gErr.Violations[0].Validator != "denylist" {
+		t.Errorf("unexpected guardrail error: %+v", gErr)
+	}
+}
+
+func TestOutputGuardrails_RetrySucceeds(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted",
+		mocktest.TextStep(strings.Repeat("x", 20)),
+		mocktest.TextStep("short"),
+	)
+	client := newGuardrailClient(t, prov, OutputGuardrailConfig{
+		Validators: []OutputValidator{MaxLengthValidator(10)},
+		Action:     GuardrailRetry,
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), guardrailRequest())
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if resp.Choices[0].Message.Content != "short" {
+		t.Errorf("content = %q", resp.Choices[0].Message.Content)
+	}
+
+	retry := prov.LastRequest()
+	if len(retry.Messages) != 3 {
+		t.Fatalf("retry should include the rejected answer and a correction, got %d messages", len(retry.Messages))
+	}
+	if retry.Messages[1].Role != provider.RoleAssistant || !strings.Contains(retry.Messages[2].Content, "limit is 10") {
+		t.Errorf("unexpected retry messages: %+v", retry.Messages[1:])
+	}
+}
+
+func TestOutputGuardrails_RetryExhausted(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted").Default(mocktest.TextStep("not json"))
+	client := newGuardrailClient(t, prov, OutputGuardrailConfig{
+		Validators: []OutputValidator{JSONSchemaValidator(map[string]any{"type": "object"})},
+		Action:     GuardrailRetry,
+		MaxRetries: 2,
+		CorrectiveMessage: func(violations []GuardrailViolation) string {
+			return "Reply with JSON only."
+		},
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), guardrailRequest())
+	if !errors.Is(err, ErrOutputBlocked) {
+		t.Fatalf("expected ErrOutputBlocked, got %v", err)
+	}
+	if prov.Calls() != 3 {
+		t.Errorf("Calls() = %d, want 3 (1 + 2 retries)", prov.Calls())
+	}
+	if got := prov.LastRequest().Messages; got[len(got)-1].Content != "Reply with JSON only." {
+		t.Errorf("custom corrective message not used: %q", got[len(got)-1].Content)
+	}
+}
+
+func TestOutputGuardrails_Annotate(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted", mocktest.TextStep("too long for the limit"))
+	client := newGuardrailClient(t, prov, OutputGuardrailConfig{
+		Validators: []OutputValidator{MaxLengthValidator(5)},
+		Action:     GuardrailAnnotate,
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), guardrailRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, ok := resp.ProviderMetadata[MetadataKeyGuardrailViolations].([]GuardrailViolation)
+	if !ok || len(violations) != 1 || violations[0].Validator != "max_length" {
+		t.Errorf("unexpected annotations: %v", resp.ProviderMetadata)
+	}
+}
+
+func TestOutputGuardrails_Moderation(t *testing.T) {
+	modProv := newMockModerationProvider("mod")
+	client, err := NewClient(ClientConfig{
+		Providers:        []ProviderConfig{{CustomProvider: modProv}},
+		ModerationConfig: &ModerationConfig{DefaultThreshold: 0.5},
+		OutputGuardrails: &OutputGuardrailConfig{
+			Validators: []OutputValidator{NewModerationValidator(nil)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.CreateChatCompletion(context.Background(), guardrailRequest())
+	if !errors.Is(err, ErrOutputBlocked) || !strings.Contains(err.Error(), ModerationCategoryViolence) {
+		t.Fatalf("expected violence flag, got %v", err)
+	}
+	if modProv.lastReq == nil || modProv.lastReq.Input[0] != "Mock response" {
+		t.Errorf("moderation did not check the output: %+v", modProv.lastReq)
+	}
+}
+
+func TestOutputGuardrails_ModerationRequiresProvider(t *testing.T) {
+	_, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("test")}},
+		OutputGuardrails: &OutputGuardrailConfig{
+			Validators: []OutputValidator{NewModerationValidator(nil)},
+		},
+	})
+	if !errors.Is(err, ErrModerationNotSupported) {
+		t.Errorf("expected ErrModerationNotSupported from NewClient, got %v", err)
+	}
+}
+
+func TestOutputGuardrails_RetrySendsViolatingChoice(t *testing.T) {
+	resp := mocktest.TextResponse("ok")
+	resp.Choices = append(resp.Choices, provider.ChatCompletionChoice{
+		Index:   1,
+		Message: provider.Message{Role: provider.RoleAssistant, Content: "the password is hunter2"},
+	})
+	prov := mocktest.NewScriptedProvider("scripted",
+		mocktest.Step{Response: resp},
+		mocktest.TextStep("fine"),
+	)
+	client := newGuardrailClient(t, prov, OutputGuardrailConfig{
+		Validators: []OutputValidator{DenylistValidator(regexp.MustCompile(`password`))},
+		Action:     GuardrailRetry,
+	})
+
+	if _, err := client.CreateChatCompletion(context.Background(), guardrailRequest()); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	retry := prov.LastRequest()
+	if got := retry.Messages[1].Content; got != "the password is hunter2" {
+		t.Errorf("retry sent back %q, want the violating choice", got)
+	}
+}
+
+func TestJSONSchemaValidator(t *testing.T) {
+	v := JSONSchemaValidator(map[string]any{
+		"type":                 "object",
+		"required":             []string{"name", "tags"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "minLength": 1},
+			"age":   map[string]any{"type": "integer", "minimum": 0},
+			"level": map[string]any{"enum": []string{"low", "high"}},
+			"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "maxItems": 2},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"valid", `{"name":"a","age":3,"level":"low","tags":["x"]}`, ""},
+		{"fenced", "```json\n{\"name\":\"a\",\"tags\":[]}\n```", ""},
+		{"invalid json", `{"name":`, "not valid JSON"},
+		{"missing required", `{"name":"a"}`, `missing required property "tags"`},
+		{"wrong type", `{"name":"a","tags":[],"age":1.5}`, "$.age: expected integer"},
+		{"enum", `{"name":"a","tags":[],"level":"mid"}`, "$.level: value mid is not one of"},
+		{"items", `{"name":"a","tags":[1]}`, "$.tags[0]: expected string"},
+		{"max items", `{"name":"a","tags":["x","y","z"]}`, "more than 2"},
+		{"additional", `{"name":"a","tags":[],"extra":true}`, `unexpected property "extra"`},
+		{"min length", `{"name":"","tags":[]}`, "$.name: length 0 is less than 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := v.ValidateOutput(context.Background(), &provider.Message{Content: tt.content})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" && reason != "" {
+				t.Errorf("unexpected violation: %s", reason)
+			}
+			if tt.want != "" && !strings.Contains(reason, tt.want) {
+				t.Errorf("reason = %q, want it to contain %q", reason, tt.want)
+			}
+		})
+	}
+}
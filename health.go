@@ -0,0 +1,226 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrHealthCheckNotSupported is reported for a provider that has no probe
+// model configured and cannot list models
+var ErrHealthCheckNotSupported = errors.New("no health probe available: set HealthCheckConfig.ProbeModels or use a provider that lists models")
+
+// Health probe methods
+const (
+	HealthProbeListModels = "list_models"
+	HealthProbeCompletion = "completion"
+)
+
+// HealthCheckConfig configures provider health probing
+type HealthCheckConfig struct {
+	// ProbeModels maps provider names to a model used for a one-token
+	// completion probe. Providers without an entry are probed by listing
+	// models, if they support it.
+	ProbeModels map[string]string
+
+	// Timeout bounds each provider probe.
+	// Default: 10 seconds
+	Timeout time.Duration
+
+	// Interval enables background probing at this interval. Background
+	// probes stop when the client is closed. If 0, providers are only
+	// probed by HealthCheck.
+	Interval time.Duration
+
+	// OpenCircuits trips a provider's circuit breaker when a background
+	// probe fails with a retryable error, so fallback skips it before user
+	// traffic fails. Requires ClientConfig.CircuitBreakerConfig.
+	OpenCircuits bool
+
+	// OnResult is called with the results of each background probe round (optional)
+	OnResult func(results []ProviderHealth)
+}
+
+// ProviderHealth is the result of probing one provider
+type ProviderHealth struct {
+	// Provider is the provider name
+	Provider string
+
+	// Healthy is true if the probe succeeded
+	Healthy bool
+
+	// Method is the probe used: HealthProbeListModels or HealthProbeCompletion
+	Method string
+
+	// Latency is how long the probe took
+	Latency time.Duration
+
+	// Error is the probe error, or nil if healthy
+	Error error
+
+	// CheckedAt is when the probe started
+	CheckedAt time.Time
+}
+
+// HealthCheck probes every configured provider concurrently and returns
+// their status in provider order. Probes call the providers directly,
+// bypassing hooks, caching, and fallback.
+func (c *ChatClient) HealthCheck(ctx context.Context) []ProviderHealth {
+	results := make([]ProviderHealth, len(c.providers))
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func(i int, p provider.Provider) {
+			defer wg.Done()
+			results[i] = c.healthConfig.probe(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+	return results
+}
+
+// Ready returns true if at least one provider passes a health probe
+func (c *ChatClient) Ready(ctx context.Context) bool {
+	for _, result := range c.HealthCheck(ctx) {
+		if result.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// LastHealthCheck returns the results of the most recent background probe
+// round, or nil if background probing is disabled or has not run yet
+func (c *ChatClient) LastHealthCheck() []ProviderHealth {
+	if c.healthProber == nil {
+		return nil
+	}
+	return c.healthProber.last()
+}
+
+// probe runs the health probe for a single provider
+func (config HealthCheckConfig) probe(ctx context.Context, p provider.Provider) ProviderHealth {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := ProviderHealth{Provider: p.Name(), CheckedAt: time.Now()}
+	if model := config.ProbeModels[p.Name()]; model != "" {
+		result.Method = HealthProbeCompletion
+		maxTokens := 1
+		_, result.Error = p.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+			Model:     model,
+			Messages:  []provider.Message{{Role: provider.RoleUser, Content: "ping"}},
+			MaxTokens: &maxTokens,
+		})
+	} else if lister, ok := unwrapProvider(p).(provider.ModelLister); ok {
+		result.Method = HealthProbeListModels
+		_, result.Error = lister.ListModels(ctx)
+	} else {
+		result.Error = ErrHealthCheckNotSupported
+	}
+	result.Latency = time.Since(result.CheckedAt)
+	result.Healthy = result.Error == nil
+	return result
+}
+
+// healthProber probes providers in the background
+type healthProber struct {
+	client *ChatClient
+	config HealthCheckConfig
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.RWMutex
+	results []ProviderHealth
+}
+
+// startHealthProber starts probing the client's providers every config.Interval
+func startHealthProber(client *ChatClient, config HealthCheckConfig) *healthProber {
+	ctx, cancel := context.WithCancel(context.Background())
+	hp := &healthProber{
+		client: client,
+		config: config,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go hp.run(ctx)
+	return hp
+}
+
+// run probes on every tick until ctx is canceled
+func (hp *healthProber) run(ctx context.Context) {
+	defer close(hp.done)
+
+	ticker := time.NewTicker(hp.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		hp.probeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeAll runs one probe round and applies the results
+func (hp *healthProber) probeAll(ctx context.Context) {
+	results := hp.client.HealthCheck(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	hp.mu.Lock()
+	hp.results = results
+	hp.mu.Unlock()
+
+	for _, result := range results {
+		if result.Healthy {
+			continue
+		}
+		hp.client.logger.Warn("provider health check failed",
+			slog.String("provider", result.Provider),
+			slog.String("method", result.Method),
+			slog.String("error", result.Error.Error()))
+		if hp.config.OpenCircuits && IsRetryableError(result.Error) && !errors.Is(result.Error, ErrHealthCheckNotSupported) {
+			if cb := hp.client.circuitBreaker(result.Provider); cb != nil {
+				cb.Trip()
+			}
+		}
+	}
+
+	if hp.config.OnResult != nil {
+		hp.config.OnResult(results)
+	}
+}
+
+// last returns the most recent probe results
+func (hp *healthProber) last() []ProviderHealth {
+	hp.mu.RLock()
+	defer hp.mu.RUnlock()
+	return hp.results
+}
+
+// stop stops background probing and waits for an in-flight round to finish
+func (hp *healthProber) stop() {
+	hp.cancel()
+	<-hp.done
+}
+
+// circuitBreaker returns the fallback circuit breaker for a provider, or nil
+// if circuit breakers are not configured
+func (c *ChatClient) circuitBreaker(providerName string) *CircuitBreaker {
+	if fp, ok := c.provider.(*FallbackProvider); ok {
+		return fp.CircuitBreaker(providerName)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestChatClient_HealthCheck(t *testing.T) {
+	completion := NewMockProvider("completion")
+	lister := &mockModelLister{MockProvider: NewMockProvider("lister"), models: []provider.Model{{ID: "m"}}}
+	failing := &mockModelLister{MockProvider: NewMockProvider("failing"), err: ErrServerError}
+	unsupported := NewMockProvider("unsupported")
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: completion},
+			{CustomProvider: lister},
+			{CustomProvider: failing},
+			{CustomProvider: unsupported},
+		},
+		HealthCheck: &HealthCheckConfig{ProbeModels: map[string]string{"completion": "test-model"}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	results := client.HealthCheck(context.Background())
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+
+	if r := results[0]; !r.Healthy || r.Method != HealthProbeCompletion || r.Provider != "completion" {
+		t.Errorf("unexpected completion probe result: %+v", r)
+	}
+	if !completion.createCompletionCalled {
+		t.Error("expected completion probe to call the provider")
+	}
+	if r := results[1]; !r.Healthy || r.Method != HealthProbeListModels {
+		t.Errorf("unexpected list probe result: %+v", r)
+	}
+	if r := results[2]; r.Healthy || !errors.Is(r.Error, ErrServerError) {
+		t.Errorf("expected failing provider to be unhealthy, got %+v", r)
+	}
+	if r := results[3]; r.Healthy || !errors.Is(r.Error, ErrHealthCheckNotSupported) {
+		t.Errorf("expected unsupported probe error, got %+v", r)
+	}
+
+	if !client.Ready(context.Background()) {
+		t.Error("expected client to be ready with healthy providers")
+	}
+}
+
+func TestChatClient_HealthCheckBackgroundOpensCircuit(t *testing.T) {
+	primary := &mockModelLister{MockProvider: NewMockProvider("primary"), err: ErrServerError}
+	fallback := &mockModelLister{MockProvider: NewMockProvider("fallback")}
+
+	results := make(chan []ProviderHealth, 1)
+	client, err := NewClient(ClientConfig{
+		Providers:            []ProviderConfig{{CustomProvider: primary}, {CustomProvider: fallback}},
+		CircuitBreakerConfig: &CircuitBreakerConfig{FailureThreshold: 5, Timeout: time.Minute},
+		HealthCheck: &HealthCheckConfig{
+			Interval:     time.Hour,
+			OpenCircuits: true,
+			OnResult: func(r []ProviderHealth) {
+				select {
+				case results <- r:
+				default:
+				}
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	select {
+	case <-results:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background probe did not run")
+	}
+
+	if last := client.LastHealthCheck(); len(last) != 2 || last[0].Healthy || !last[1].Healthy {
+		t.Errorf("unexpected last health check: %+v", last)
+	}
+
+	fp := client.Provider().(*FallbackProvider)
+	if state := fp.CircuitBreaker("primary").State(); state != CircuitOpen {
+		t.Errorf("expected primary circuit open after failed probe, got %s", state)
+	}
+	if state := fp.CircuitBreaker("fallback").State(); state != CircuitClosed {
+		t.Errorf("expected fallback circuit closed, got %s", state)
+	}
+}
+
+func TestCircuitBreaker_Trip(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{Timeout: time.Minute})
+	cb.Trip()
+
+	if cb.State() != CircuitOpen {
+		t.Errorf("expected open circuit, got %s", cb.State())
+	}
+	if cb.AllowRequest() {
+		t.Error("expected tripped circuit to reject requests")
+	}
+}
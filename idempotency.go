@@ -0,0 +1,49 @@
+package omnillm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// WithIdempotencyKey returns a context whose requests carry key, which
+// OpenAI receives as the Idempotency-Key header and other providers ignore.
+// Every attempt of a call made with the context, across fallbacks, key pool
+// rotation, and load-balanced instances, carries the same key, so a retried
+// request is not billed or submitted twice. Calls that send several
+// different requests, such as tool loops and guardrail retries, derive a
+// distinct key for each from it.
+//
+// ChatClient generates a key for each call made without one.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return provider.WithIdempotencyKey(ctx, key)
+}
+
+// IdempotencyKeyFromContext returns the key set with WithIdempotencyKey, or ""
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	return provider.IdempotencyKeyFromContext(ctx)
+}
+
+// ensureIdempotencyKey returns ctx with a new idempotency key if it has none
+func ensureIdempotencyKey(ctx context.Context) context.Context {
+	if provider.IdempotencyKeyFromContext(ctx) != "" {
+		return ctx
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ctx
+	}
+	return provider.WithIdempotencyKey(ctx, "omnillm-"+hex.EncodeToString(b))
+}
+
+// deriveIdempotencyKey returns ctx with a key for one of several different
+// requests made under the key in ctx, or ctx unchanged if it has none
+func deriveIdempotencyKey(ctx context.Context, step string) context.Context {
+	key := provider.IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		return ctx
+	}
+	return provider.WithIdempotencyKey(ctx, key+"-"+step)
+}
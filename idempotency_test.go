@@ -0,0 +1,78 @@
+package omnillm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// keyRecordingProvider records the idempotency key of each request
+type keyRecordingProvider struct {
+	*mockProvider
+	keys []string
+}
+
+func (p *keyRecordingProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	p.keys = append(p.keys, IdempotencyKeyFromContext(ctx))
+	return p.mockProvider.CreateChatCompletion(ctx, req)
+}
+
+func TestIdempotencyKey_SharedAcrossFallback(t *testing.T) {
+	primary := &keyRecordingProvider{mockProvider: newMockProvider("primary")}
+	primary.completionErr = ErrServerError
+	secondary := &keyRecordingProvider{mockProvider: newMockProvider("secondary")}
+
+	c, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: primary}, {CustomProvider: secondary}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	for _, ctx := range []context.Context{context.Background(), context.Background(), WithIdempotencyKey(context.Background(), "order-42")} {
+		if _, err := c.CreateChatCompletion(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(primary.keys) != 3 || len(secondary.keys) != 3 {
+		t.Fatalf("keys = %v, %v", primary.keys, secondary.keys)
+	}
+	for i := range primary.keys {
+		if primary.keys[i] == "" || primary.keys[i] != secondary.keys[i] {
+			t.Errorf("call %d: fallback attempts carried keys %q and %q, want one shared key", i, primary.keys[i], secondary.keys[i])
+		}
+	}
+	if primary.keys[0] == primary.keys[1] || !strings.HasPrefix(primary.keys[0], "omnillm-") {
+		t.Errorf("generated keys = %v, want a distinct key per call", primary.keys[:2])
+	}
+	if primary.keys[2] != "order-42" {
+		t.Errorf("key = %q, want the caller's key", primary.keys[2])
+	}
+}
+
+func TestIdempotencyKey_DerivedForGuardrailRetries(t *testing.T) {
+	mock := &keyRecordingProvider{mockProvider: newMockProvider("primary")}
+	c, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mock}},
+		OutputGuardrails: &OutputGuardrailConfig{
+			Validators: []OutputValidator{MaxLengthValidator(1)},
+			Action:     GuardrailRetry,
+			MaxRetries: 2,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := WithIdempotencyKey(context.Background(), "k")
+	_, _ = c.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}})
+
+	want := []string{"k", "k-retry-1", "k-retry-2"}
+	if strings.Join(mock.keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys = %v, want %v", mock.keys, want)
+	}
+}
@@ -0,0 +1,187 @@
+package omnillm
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"unicode/utf8"
+)
+
+// validateJSONSchema checks a decoded JSON value against the common subset of
+// JSON Schema and returns one message per problem found. Unsupported keywords
+// are ignored.
+func validateJSONSchema(value any, schema map[string]any, path string) []string {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok && !matchesSchemaType(value, t) {
+		addf("expected %v, got %s", t, jsonTypeName(value))
+		return problems
+	}
+
+	if enum, ok := schema["enum"].([]string); ok {
+		if s, isString := value.(string); !isString || !slices.Contains(enum, s) {
+			addf("value %v is not one of %v", value, enum)
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addf("value %v is not one of %v", value, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if minLen, ok := schemaNumber(schema, "minLength"); ok && n < minLen {
+			addf("length %v is less than %v", n, minLen)
+		}
+		if maxLen, ok := schemaNumber(schema, "maxLength"); ok && n > maxLen {
+			addf("length %v is greater than %v", n, maxLen)
+		}
+	case float64:
+		if minimum, ok := schemaNumber(schema, "minimum"); ok && v < minimum {
+			addf("%v is less than minimum %v", v, minimum)
+		}
+		if maximum, ok := schemaNumber(schema, "maximum"); ok && v > maximum {
+			addf("%v is greater than maximum %v", v, maximum)
+		}
+	case []any:
+		n := float64(len(v))
+		if minItems, ok := schemaNumber(schema, "minItems"); ok && n < minItems {
+			addf("has %v items, fewer than %v", n, minItems)
+		}
+		if maxItems, ok := schemaNumber(schema, "maxItems"); ok && n > maxItems {
+			addf("has %v items, more than %v", n, maxItems)
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				problems = append(problems, validateJSONSchema(item, items, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]any:
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				addf("missing required property %q", name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propSchema, ok := properties[key].(map[string]any)
+			if !ok {
+				if additional, isBool := schema["additionalProperties"].(bool); isBool && !additional {
+					addf("unexpected property %q", key)
+				}
+				continue
+			}
+			problems = append(problems, validateJSONSchema(v[key], propSchema, path+"."+key)...)
+		}
+	}
+
+	return problems
+}
+
+// matchesSchemaType reports whether value matches a "type" keyword, which may
+// be a single type name or a list of names
+func matchesSchemaType(value any, t any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(value, t)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(value, s) {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, name := range t {
+			if matchesTypeName(value, name) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(value any, name string) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonTypeName(value) == name
+	}
+}
+
+// jsonTypeName returns the JSON Schema type name of a decoded JSON value
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaNumber reads a numeric keyword, accepting any Go numeric type so
+// schemas can be written as Go literals
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	switch n := schema[key].(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// schemaStrings reads a string list keyword written as []any or []string
+func schemaStrings(v any) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []any:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
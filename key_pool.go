@@ -0,0 +1,263 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultKeyCooldown is how long a rate-limited key is rested before it is used again
+const DefaultKeyCooldown = time.Minute
+
+// KeyPoolConfig configures a KeyPoolProvider
+type KeyPoolConfig struct {
+	// Cooldown is how long a key that hit a rate limit or quota error is
+	// skipped, unless the provider's Retry-After says otherwise.
+	// Default: DefaultKeyCooldown
+	Cooldown time.Duration
+}
+
+// KeyUsage reports the usage of one key in a pool
+type KeyUsage struct {
+	// Key is the masked API key, e.g. "sk-...a1b2"
+	Key string
+
+	Requests         int64
+	Failures         int64
+	RateLimited      int64 // Failures that were rate limit or quota errors
+	PromptTokens     int64
+	CompletionTokens int64
+
+	// CoolingUntil is when the key is used again after a rate limit, or zero
+	CoolingUntil time.Time
+
+	LastUsed time.Time
+}
+
+// KeyPoolProvider spreads requests across several API keys for the same
+// provider. It keeps using one key until that key hits a rate limit or quota
+// error, then rests it for the cooldown and retries the request on the next
+// key. Other errors are returned without rotating.
+//
+// NewClient builds a KeyPoolProvider when ProviderConfig.APIKeys is set.
+type KeyPoolProvider struct {
+	keys     []*pooledKey
+	cooldown time.Duration
+
+	mu      sync.Mutex
+	current int
+}
+
+// pooledKey is one key's provider and usage. Usage is guarded by KeyPoolProvider.mu.
+type pooledKey struct {
+	provider provider.Provider
+	usage    KeyUsage
+}
+
+// NewKeyPoolProvider creates a pool from keys, calling build to create the
+// provider for each key
+func NewKeyPoolProvider(keys []string, build func(apiKey string) (provider.Provider, error), config *KeyPoolConfig) (*KeyPoolProvider, error) {
+	if len(keys) == 0 {
+		return nil, ErrEmptyAPIKey
+	}
+	if config == nil {
+		config = &KeyPoolConfig{}
+	}
+
+	kp := &KeyPoolProvider{cooldown: config.Cooldown}
+	if kp.cooldown <= 0 {
+		kp.cooldown = DefaultKeyCooldown
+	}
+
+	for i, key := range keys {
+		p, err := build(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		kp.keys = append(kp.keys, &pooledKey{provider: p, usage: KeyUsage{Key: maskKey(key)}})
+	}
+	return kp, nil
+}
+
+// CreateChatCompletion sends the request with the current key, rotating on rate limits
+func (kp *KeyPoolProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	var lastErr error
+	for _, k := range kp.candidates() {
+		resp, err := k.provider.CreateChatCompletion(ctx, req)
+		if err == nil {
+			kp.recordUsage(k, &resp.Usage)
+			return resp, nil
+		}
+		lastErr = err
+		if !kp.recordFailure(k, err) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// CreateChatCompletionStream opens a stream with the current key, rotating on rate limits
+func (kp *KeyPoolProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	var lastErr error
+	for _, k := range kp.candidates() {
+		stream, err := k.provider.CreateChatCompletionStream(ctx, req)
+		if err == nil {
+			kp.recordUsage(k, nil)
+			return &keyPoolStream{stream: stream, kp: kp, key: k}, nil
+		}
+		lastErr = err
+		if !kp.recordFailure(k, err) {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// candidates returns the keys to try, starting with the current key and
+// skipping keys that are cooling down. If every key is cooling down, the one
+// that recovers first is tried.
+func (kp *KeyPoolProvider) candidates() []*pooledKey {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	now := time.Now()
+	var keys []*pooledKey
+	var soonest *pooledKey
+	for i := range kp.keys {
+		k := kp.keys[(kp.current+i)%len(kp.keys)]
+		if k.usage.CoolingUntil.After(now) {
+			if soonest == nil || k.usage.CoolingUntil.Before(soonest.usage.CoolingUntil) {
+				soonest = k
+			}
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, soonest)
+	}
+	return keys
+}
+
+// recordUsage counts a successful request and its token usage, if known
+func (kp *KeyPoolProvider) recordUsage(k *pooledKey, usage *provider.Usage) {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	k.usage.Requests++
+	k.usage.LastUsed = time.Now()
+	k.usage.CoolingUntil = time.Time{}
+	if usage != nil {
+		k.usage.PromptTokens += int64(usage.PromptTokens)
+		k.usage.CompletionTokens += int64(usage.CompletionTokens)
+	}
+}
+
+// recordFailure counts a failed request. If the error is a rate limit or
+// quota error, the key is rested for the provider's Retry-After, or the
+// cooldown if it gave none, and the pool moves to the next key, and
+// recordFailure returns true so the request is retried.
+func (kp *KeyPoolProvider) recordFailure(k *pooledKey, err error) bool {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	k.usage.Requests++
+	k.usage.Failures++
+	k.usage.LastUsed = time.Now()
+	if !IsRateLimitError(err) {
+		return false
+	}
+
+	cooldown := kp.cooldown
+	if wait, ok := RetryAfter(err); ok {
+		cooldown = wait
+	}
+	k.usage.RateLimited++
+	k.usage.CoolingUntil = time.Now().Add(cooldown)
+	for i, pk := range kp.keys {
+		if pk == k {
+			kp.current = (i + 1) % len(kp.keys)
+			break
+		}
+	}
+	return true
+}
+
+// Usage returns the usage of each key in pool order
+func (kp *KeyPoolProvider) Usage() []KeyUsage {
+	kp.mu.Lock()
+	defer kp.mu.Unlock()
+
+	usage := make([]KeyUsage, len(kp.keys))
+	for i, k := range kp.keys {
+		usage[i] = k.usage
+	}
+	return usage
+}
+
+// Close closes the provider for every key
+func (kp *KeyPoolProvider) Close() error {
+	var errs []error
+	for _, k := range kp.keys {
+		if err := k.provider.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the name of the pooled provider
+func (kp *KeyPoolProvider) Name() string {
+	return kp.keys[0].provider.Name()
+}
+
+// unwrapProvider returns the first key's provider, so capability checks and
+// capability APIs such as files, batches, and moderation use the pooled
+// provider. Those APIs always use the first key.
+func (kp *KeyPoolProvider) unwrapProvider() provider.Provider {
+	return kp.keys[0].provider
+}
+
+// keyPoolStream adds the stream's reported token usage to its key
+type keyPoolStream struct {
+	stream provider.ChatCompletionStream
+	kp     *KeyPoolProvider
+	key    *pooledKey
+}
+
+func (s *keyPoolStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	if err == nil && chunk.Usage != nil {
+		s.kp.mu.Lock()
+		s.key.usage.PromptTokens += int64(chunk.Usage.PromptTokens)
+		s.key.usage.CompletionTokens += int64(chunk.Usage.CompletionTokens)
+		s.kp.mu.Unlock()
+	}
+	return chunk, err
+}
+
+func (s *keyPoolStream) Close() error {
+	return s.stream.Close()
+}
+
+// maskKey returns a key with all but its prefix and last four characters hidden
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "..."
+	}
+	return key[:3] + "..." + key[len(key)-4:]
+}
+
+// KeyUsage returns the per-key usage of every configured key pool, keyed by
+// provider name
+func (c *ChatClient) KeyUsage() map[string][]KeyUsage {
+	usage := make(map[string][]KeyUsage)
+	for _, kp := range findCapabilities[*KeyPoolProvider](c.providers...) {
+		usage[kp.Name()] = kp.Usage()
+	}
+	return usage
+}
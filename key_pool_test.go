@@ -0,0 +1,158 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func newTestKeyPool(t *testing.T, keys []string, errs map[string]error) (*KeyPoolProvider, map[string]*countingProvider) {
+	t.Helper()
+	built := make(map[string]*countingProvider)
+	kp, err := NewKeyPoolProvider(keys, func(apiKey string) (provider.Provider, error) {
+		p := &countingProvider{MockProvider: NewMockProvider("openai"), err: errs[apiKey]}
+		built[apiKey] = p
+		return p, nil
+	}, &KeyPoolConfig{Cooldown: time.Minute})
+	if err != nil {
+		t.Fatalf("NewKeyPoolProvider failed: %v", err)
+	}
+	return kp, built
+}
+
+func TestKeyPoolProvider_RotatesOnRateLimit(t *testing.T) {
+	kp, built := newTestKeyPool(t, []string{"sk-key-one-1111", "sk-key-two-2222"}, map[string]error{
+		"sk-key-one-1111": errors.New("OpenAI API error: Rate limit reached for gpt-4o"),
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := kp.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"}); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+
+	// The rate-limited key is rested, so later requests go straight to the second key
+	if built["sk-key-one-1111"].calls != 1 || built["sk-key-two-2222"].calls != 3 {
+		t.Errorf("unexpected calls: %d/%d", built["sk-key-one-1111"].calls, built["sk-key-two-2222"].calls)
+	}
+
+	usage := kp.Usage()
+	if usage[0].Key != "sk-...1111" || usage[0].RateLimited != 1 || usage[0].CoolingUntil.IsZero() {
+		t.Errorf("unexpected usage for first key: %+v", usage[0])
+	}
+	if usage[1].Requests != 3 || usage[1].PromptTokens != 30 || usage[1].CompletionTokens != 60 {
+		t.Errorf("unexpected usage for second key: %+v", usage[1])
+	}
+}
+
+func TestKeyPoolProvider_OtherErrorsDoNotRotate(t *testing.T) {
+	kp, built := newTestKeyPool(t, []string{"key-a-00000", "key-b-00000"}, map[string]error{
+		"key-a-00000": ErrInvalidRequest,
+	})
+
+	_, err := kp.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected invalid request error, got %v", err)
+	}
+	if built["key-b-00000"].calls != 0 {
+		t.Error("expected no rotation on a non-rate-limit error")
+	}
+}
+
+func TestKeyPoolProvider_AllKeysRateLimited(t *testing.T) {
+	kp, built := newTestKeyPool(t, []string{"key-a-00000", "key-b-00000"}, map[string]error{
+		"key-a-00000": ErrRateLimitExceeded,
+		"key-b-00000": ErrQuotaExceeded,
+	})
+
+	_, err := kp.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected last key's error, got %v", err)
+	}
+
+	// With every key cooling down, the one that recovers first is tried
+	_, _ = kp.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if built["key-a-00000"].calls != 2 || built["key-b-00000"].calls != 1 {
+		t.Errorf("unexpected calls: %d/%d", built["key-a-00000"].calls, built["key-b-00000"].calls)
+	}
+}
+
+func TestNewClient_APIKeys(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider: ProviderNameOpenAI,
+			APIKey:   "sk-primary-0000",
+			APIKeys:  []string{"sk-second-1111"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	kp, ok := client.Provider().(*KeyPoolProvider)
+	if !ok {
+		t.Fatalf("expected KeyPoolProvider, got %T", client.Provider())
+	}
+	if kp.Name() != "openai" {
+		t.Errorf("expected pooled provider name, got %q", kp.Name())
+	}
+	usage := client.KeyUsage()["openai"]
+	if len(usage) != 2 || usage[0].Key != "sk-...0000" || usage[1].Key != "sk-...1111" {
+		t.Errorf("unexpected key pool: %+v", usage)
+	}
+}
+
+func TestNewClient_APIKeysKeepCapabilities(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{
+			Provider: ProviderNameOpenAI,
+			APIKeys:  []string{"sk-first-0000", "sk-second-1111"},
+		}, {
+			Provider: ProviderNameAnthropic,
+			APIKeys:  []string{"sk-ant-first-0000", "sk-ant-second-1111"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, ok := client.Capabilities(); !ok {
+		t.Error("expected the pooled provider's capabilities")
+	}
+	if !client.HasModeration() || !client.HasFiles() || !client.HasBatches() || !client.HasListModels() {
+		t.Errorf("pooled provider lost capabilities: moderation=%v files=%v batches=%v listModels=%v",
+			client.HasModeration(), client.HasFiles(), client.HasBatches(), client.HasListModels())
+	}
+
+	n := 2
+	req := &provider.ChatCompletionRequest{Model: "claude-sonnet-4", N: &n, Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}}}
+	var capErr *provider.CapabilityError
+	if err := checkCapabilities(client.providers[1], req, false); !errors.As(err, &capErr) {
+		t.Errorf("expected a capability error through the key pool, got %v", err)
+	}
+}
+
+func TestIsRateLimitError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrRateLimitExceeded, true},
+		{ErrQuotaExceeded, true},
+		{NewAPIError(ProviderNameOpenAI, 429, "slow down", "", ""), true},
+		{errors.New("You exceeded your current quota"), true},
+		{errors.New("429 Too Many Requests"), true},
+		{ErrServerError, false},
+	}
+	for _, tt := range tests {
+		if got := IsRateLimitError(tt.err); got != tt.want {
+			t.Errorf("IsRateLimitError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrProviderValidation is returned, wrapping the probe error, when a
+// provider fails validation at startup or before its first request
+var ErrProviderValidation = errors.New("provider validation failed")
+
+// lazyProvider builds its provider on first use and validates it with a
+// health probe before the first request is sent. Concurrent first requests
+// wait for one build. A failed build or probe is not kept, so the next
+// request tries again.
+type lazyProvider struct {
+	name   string
+	config ProviderConfig
+	health HealthCheckConfig
+
+	mu   sync.Mutex
+	prov provider.Provider
+}
+
+// newLazyProvider returns a provider that builds config on first use
+func newLazyProvider(config ProviderConfig, health HealthCheckConfig) *lazyProvider {
+	name := string(config.Provider)
+	if config.CustomProvider != nil {
+		name = config.CustomProvider.Name()
+	}
+	return &lazyProvider{name: name, config: config, health: health}
+}
+
+// get returns the provider, building and validating it on first use
+func (p *lazyProvider) get(ctx context.Context) (provider.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.prov != nil {
+		return p.prov, nil
+	}
+
+	prov, err := buildProviderFromConfig(p.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider (%s): %w", p.name, err)
+	}
+	if err := validateProvider(ctx, p.health, prov); err != nil {
+		_ = prov.Close()
+		return nil, err
+	}
+	p.prov = prov
+	return prov, nil
+}
+
+func (p *lazyProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	prov, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return prov.CreateChatCompletion(ctx, req)
+}
+
+func (p *lazyProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	prov, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return prov.CreateChatCompletionStream(ctx, req)
+}
+
+// Close closes the provider if it was built
+func (p *lazyProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.prov == nil {
+		return nil
+	}
+	return p.prov.Close()
+}
+
+func (p *lazyProvider) Name() string {
+	return p.name
+}
+
+// validateProvider runs the health probe against p, returning an error that
+// matches ErrProviderValidation and the probe error if it fails. Providers
+// that cannot be probed pass.
+func validateProvider(ctx context.Context, config HealthCheckConfig, p provider.Provider) error {
+	result := config.probe(ctx, p)
+	if result.Error == nil || errors.Is(result.Error, ErrHealthCheckNotSupported) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s: %w", ErrProviderValidation, p.Name(), result.Error)
+}
+
+// validateProviders validates every configured provider concurrently,
+// building lazy ones, and joins the failures
+func (c *ChatClient) validateProviders(ctx context.Context) error {
+	errs := make([]error, len(c.providers))
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func(i int, p provider.Provider) {
+			defer wg.Done()
+			if lazy, ok := p.(*lazyProvider); ok {
+				_, errs[i] = lazy.get(ctx)
+				return
+			}
+			errs[i] = validateProvider(ctx, c.healthConfig, p)
+		}(i, p)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,112 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestLazyProvider_InitializesOnFirstUse(t *testing.T) {
+	mockProv := mocktest.NewScriptedProvider("scripted").Default(mocktest.TextStep("ok"))
+	client, err := NewClient(ClientConfig{
+		Providers:   []ProviderConfig{{CustomProvider: mockProv, Lazy: true}},
+		HealthCheck: &HealthCheckConfig{ProbeModels: map[string]string{"scripted": "probe-model"}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if mockProv.Calls() != 0 {
+		t.Fatalf("expected no calls before the first request, got %d", mockProv.Calls())
+	}
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+				t.Errorf("request failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// One probe, shared by the concurrent first requests
+	if mockProv.Calls() != 5 {
+		t.Errorf("expected 1 probe and 4 requests, got %d calls", mockProv.Calls())
+	}
+	if probe := mockProv.Requests()[0].Request; probe.Model != "probe-model" {
+		t.Errorf("expected the probe first, got model %q", probe.Model)
+	}
+}
+
+func TestLazyProvider_RetriesFailedValidation(t *testing.T) {
+	mockProv := mocktest.NewScriptedProvider("scripted", mocktest.Step{Err: &provider.APIError{StatusCode: 401}}).
+		Default(mocktest.TextStep("ok"))
+	client, err := NewClient(ClientConfig{
+		Providers:   []ProviderConfig{{CustomProvider: mockProv, Lazy: true}},
+		HealthCheck: &HealthCheckConfig{ProbeModels: map[string]string{"scripted": "probe-model"}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	if !errors.Is(err, ErrProviderValidation) || !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("expected a validation error wrapping the probe error, got %v", err)
+	}
+	if mockProv.Calls() != 1 {
+		t.Errorf("expected the request not to be sent after a failed probe, got %d calls", mockProv.Calls())
+	}
+
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Errorf("expected the next request to validate again and succeed, got %v", err)
+	}
+	if mockProv.Calls() != 3 {
+		t.Errorf("expected a second probe and the request, got %d calls", mockProv.Calls())
+	}
+}
+
+func TestNewClient_ValidateOnStartup(t *testing.T) {
+	primary := mocktest.NewScriptedProvider("primary").Default(mocktest.TextStep("ok"))
+	fallback := mocktest.NewScriptedProvider("fallback").Default(mocktest.Step{Err: &provider.APIError{StatusCode: 401}})
+	unprobed := mocktest.NewScriptedProvider("unprobed")
+
+	_, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: primary},
+			{CustomProvider: fallback, Lazy: true},
+			{CustomProvider: unprobed},
+		},
+		HealthCheck:       &HealthCheckConfig{ProbeModels: map[string]string{"primary": "m", "fallback": "m"}},
+		ValidateOnStartup: true,
+	})
+	if !errors.Is(err, ErrProviderValidation) || !errors.Is(err, ErrAuthentication) {
+		t.Fatalf("expected NewClient to fail validation, got %v", err)
+	}
+	if primary.Calls() != 1 || fallback.Calls() != 1 || unprobed.Calls() != 0 {
+		t.Errorf("expected one probe per probeable provider, got %d, %d, %d", primary.Calls(), fallback.Calls(), unprobed.Calls())
+	}
+	if !primary.Closed() {
+		t.Error("expected the client to be closed after failed validation")
+	}
+
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: primary}, {CustomProvider: unprobed}},
+		HealthCheck:       &HealthCheckConfig{ProbeModels: map[string]string{"primary": "m"}},
+		ValidateOnStartup: true,
+	})
+	if err != nil {
+		t.Fatalf("expected healthy and unprobeable providers to pass, got %v", err)
+	}
+	_ = client.Close()
+}
@@ -0,0 +1,68 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrListModelsNotSupported is returned when no configured provider can list models
+var ErrListModelsNotSupported = errors.New("listing models not supported by configured providers")
+
+// ListModels lists the models available from every configured provider that
+// implements provider.ModelLister, in provider order. Context windows and
+// output limits the provider does not report are filled in from
+// DefaultModelCatalog.
+//
+// If some providers fail, the models from the others are returned along with
+// an error naming each failed provider.
+func (c *ChatClient) ListModels(ctx context.Context) ([]provider.Model, error) {
+	if len(c.modelListers) == 0 {
+		return nil, ErrListModelsNotSupported
+	}
+
+	var models []provider.Model
+	var errs []error
+	for _, lister := range c.modelListers {
+		listed, err := lister.ListModels(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", providerName(lister), err))
+			continue
+		}
+		for _, model := range listed {
+			if model.ContextWindow == 0 {
+				model.ContextWindow = knownContextWindow(model.ID)
+			}
+			if model.MaxOutputTokens == 0 {
+				spec, _ := DefaultModelCatalog().Lookup(model.ID)
+				model.MaxOutputTokens = spec.MaxOutputTokens
+			}
+			models = append(models, model)
+		}
+	}
+	return models, errors.Join(errs...)
+}
+
+// HasListModels returns true if a provider that can list models is configured
+func (c *ChatClient) HasListModels() bool {
+	return len(c.modelListers) > 0
+}
+
+// knownContextWindow returns the built-in context window for a model, or 0
+// if the model is unknown
+func knownContextWindow(model string) int {
+	if window := DefaultModelCatalog().ContextWindow(model); window > 0 {
+		return window
+	}
+	return knownContextWindows[model]
+}
+
+// providerName returns the name of a capability's provider, if it has one
+func providerName(v any) string {
+	if p, ok := v.(provider.Provider); ok {
+		return p.Name()
+	}
+	return fmt.Sprintf("%T", v)
+}
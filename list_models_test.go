@@ -0,0 +1,98 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockModelLister is a provider that also lists models
+type mockModelLister struct {
+	*MockProvider
+	models []provider.Model
+	err    error
+}
+
+func (m *mockModelLister) ListModels(ctx context.Context) ([]provider.Model, error) {
+	return m.models, m.err
+}
+
+func TestChatClient_ListModels(t *testing.T) {
+	primary := &mockModelLister{
+		MockProvider: NewMockProvider("primary"),
+		models: []provider.Model{
+			{ID: "gpt-4o", Provider: "primary"},
+			{ID: "custom-model", Provider: "primary", ContextWindow: 32000},
+			{ID: "unknown-model", Provider: "primary"},
+		},
+	}
+	fallback := &mockModelLister{
+		MockProvider: NewMockProvider("fallback"),
+		models:       []provider.Model{{ID: "claude-3-haiku", Provider: "fallback"}},
+	}
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: primary}, {CustomProvider: fallback}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if !client.HasListModels() {
+		t.Fatal("HasListModels() = false")
+	}
+
+	models, err := client.ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	want := map[string]int{"gpt-4o": 128000, "custom-model": 32000, "unknown-model": 0, "claude-3-haiku": 200000}
+	if len(models) != len(want) {
+		t.Fatalf("models = %+v", models)
+	}
+	for _, m := range models {
+		if m.ContextWindow != want[m.ID] {
+			t.Errorf("%s ContextWindow = %d, want %d", m.ID, m.ContextWindow, want[m.ID])
+		}
+	}
+	if models[3].Provider != "fallback" {
+		t.Errorf("models not in provider order: %+v", models)
+	}
+}
+
+func TestChatClient_ListModelsPartialFailure(t *testing.T) {
+	listErr := errors.New("unauthorized")
+	failing := &mockModelLister{MockProvider: NewMockProvider("failing"), err: listErr}
+	working := &mockModelLister{
+		MockProvider: NewMockProvider("working"),
+		models:       []provider.Model{{ID: "m1", Provider: "working"}},
+	}
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: failing}, {CustomProvider: working}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	models, err := client.ListModels(context.Background())
+	if !errors.Is(err, listErr) {
+		t.Errorf("err = %v, want %v", err, listErr)
+	}
+	if len(models) != 1 || models[0].ID != "m1" {
+		t.Errorf("models = %+v, want the working provider's models", models)
+	}
+}
+
+func TestChatClient_ListModelsNotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("plain")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if _, err := client.ListModels(context.Background()); !errors.Is(err, ErrListModelsNotSupported) {
+		t.Errorf("err = %v, want ErrListModelsNotSupported", err)
+	}
+}
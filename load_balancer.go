@@ -0,0 +1,354 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grokify/mogo/log/slogutil"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// LoadBalancingStrategy selects how requests are distributed across instances
+type LoadBalancingStrategy int
+
+const (
+	// LoadBalanceRoundRobin sends requests to each instance in turn
+	LoadBalanceRoundRobin LoadBalancingStrategy = iota
+	// LoadBalanceWeighted sends requests in proportion to instance weights,
+	// interleaving them smoothly rather than in bursts
+	LoadBalanceWeighted
+	// LoadBalanceLeastLatency sends requests to the instance with the lowest
+	// moving-average latency. Instances without measurements are tried first.
+	LoadBalanceLeastLatency
+)
+
+// String returns the string representation of the strategy
+func (s LoadBalancingStrategy) String() string {
+	switch s {
+	case LoadBalanceRoundRobin:
+		return "round-robin"
+	case LoadBalanceWeighted:
+		return "weighted"
+	case LoadBalanceLeastLatency:
+		return "least-latency"
+	default:
+		return "unknown"
+	}
+}
+
+// LoadBalancedInstance is one provider instance behind a LoadBalancingProvider,
+// such as a provider built with a particular API key or region
+type LoadBalancedInstance struct {
+	// Provider is the provider instance
+	Provider provider.Provider
+
+	// Weight is the instance's share of traffic for LoadBalanceWeighted.
+	// Default: 1
+	Weight int
+}
+
+// LoadBalancingProviderConfig configures a LoadBalancingProvider
+type LoadBalancingProviderConfig struct {
+	// Strategy selects the instance for each request.
+	// Default: LoadBalanceRoundRobin
+	Strategy LoadBalancingStrategy
+
+	// CircuitBreakerConfig enables a circuit breaker per instance. Instances
+	// with an open circuit are skipped. If nil, circuit breakers are disabled.
+	CircuitBreakerConfig *CircuitBreakerConfig
+
+	// Name is returned by Name. Default: the first instance's name
+	Name string
+
+	// Logger for logging balancing events
+	Logger *slog.Logger
+}
+
+// LoadBalancingProvider distributes requests across interchangeable provider
+// instances. The selected instance is tried first; on a retryable error the
+// remaining available instances are tried in strategy order. It implements
+// provider.Provider, so it can be used as a CustomProvider, including as one
+// entry of a fallback chain.
+type LoadBalancingProvider struct {
+	instances []*lbInstance
+	strategy  LoadBalancingStrategy
+	name      string
+	logger    *slog.Logger
+
+	mu   sync.Mutex
+	next int // Round-robin position
+}
+
+// lbInstance tracks the balancing state of one instance
+type lbInstance struct {
+	index    int
+	provider provider.Provider
+	weight   int
+	breaker  *CircuitBreaker
+
+	// Guarded by LoadBalancingProvider.mu
+	currentWeight int
+	latency       time.Duration // Exponentially weighted moving average
+	retryAt       time.Time     // Skipped until then, from Retry-After
+}
+
+// latencyAlpha is the weight of the newest sample in the latency average
+const latencyAlpha = 0.3
+
+// NewLoadBalancingProvider creates a provider that balances requests across instances
+func NewLoadBalancingProvider(instances []LoadBalancedInstance, config *LoadBalancingProviderConfig) *LoadBalancingProvider {
+	if config == nil {
+		config = &LoadBalancingProviderConfig{}
+	}
+
+	lb := &LoadBalancingProvider{
+		strategy: config.Strategy,
+		name:     config.Name,
+		logger:   config.Logger,
+	}
+	if lb.logger == nil {
+		lb.logger = slogutil.Null()
+	}
+
+	for i, inst := range instances {
+		li := &lbInstance{index: i, provider: inst.Provider, weight: inst.Weight}
+		if li.weight <= 0 {
+			li.weight = 1
+		}
+		if config.CircuitBreakerConfig != nil {
+			li.breaker = NewCircuitBreaker(*config.CircuitBreakerConfig)
+		}
+		lb.instances = append(lb.instances, li)
+	}
+
+	if lb.name == "" && len(lb.instances) > 0 {
+		lb.name = lb.instances[0].provider.Name()
+	}
+
+	return lb
+}
+
+// CreateChatCompletion sends the request to the selected instance, trying
+// the others on retryable errors
+func (lb *LoadBalancingProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	var attempts []FallbackAttempt
+	var lastErr error
+	for _, inst := range lb.order() {
+		start := time.Now()
+		resp, err := inst.provider.CreateChatCompletion(ctx, req)
+		duration := time.Since(start)
+		attempts = append(attempts, FallbackAttempt{Provider: inst.provider.Name(), Error: err, Duration: duration})
+
+		if err == nil {
+			lb.recordSuccess(inst, duration)
+			if resp.ProviderMetadata == nil {
+				resp.ProviderMetadata = make(map[string]any)
+			}
+			resp.ProviderMetadata["lb_instance"] = inst.index
+			return resp, nil
+		}
+
+		lastErr = err
+		lb.recordFailure(inst, err)
+		lb.logger.Debug("load balanced instance failed",
+			slog.Int("instance", inst.index),
+			slog.String("provider", inst.provider.Name()),
+			slog.String("error", err.Error()))
+		if IsNonRetryableError(err) {
+			return nil, err
+		}
+	}
+	return nil, lb.exhausted(attempts, lastErr)
+}
+
+// CreateChatCompletionStream opens a stream on the selected instance, trying
+// the others on retryable errors
+func (lb *LoadBalancingProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	var attempts []FallbackAttempt
+	var lastErr error
+	for _, inst := range lb.order() {
+		start := time.Now()
+		stream, err := inst.provider.CreateChatCompletionStream(ctx, req)
+		duration := time.Since(start)
+		attempts = append(attempts, FallbackAttempt{Provider: inst.provider.Name(), Error: err, Duration: duration})
+
+		if err == nil {
+			lb.recordSuccess(inst, duration)
+			return &lbStream{stream: stream, lb: lb, inst: inst}, nil
+		}
+
+		lastErr = err
+		lb.recordFailure(inst, err)
+		if IsNonRetryableError(err) {
+			return nil, err
+		}
+	}
+	return nil, lb.exhausted(attempts, lastErr)
+}
+
+// exhausted returns the error for a request that no instance served
+func (lb *LoadBalancingProvider) exhausted(attempts []FallbackAttempt, lastErr error) error {
+	if len(attempts) == 0 {
+		return &CircuitOpenError{Provider: lb.name, State: CircuitOpen}
+	}
+	return &FallbackError{Attempts: attempts, LastError: lastErr}
+}
+
+// order returns the instances whose circuits allow a request and that are not
+// waiting out a Retry-After, the selected instance first
+func (lb *LoadBalancingProvider) order() []*lbInstance {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	now := time.Now()
+	available := make([]*lbInstance, 0, len(lb.instances))
+	for _, inst := range lb.instances {
+		if now.Before(inst.retryAt) {
+			continue
+		}
+		if inst.breaker == nil || inst.breaker.AllowRequest() {
+			available = append(available, inst)
+		}
+	}
+	if len(available) == 0 {
+		return nil
+	}
+
+	switch lb.strategy {
+	case LoadBalanceWeighted:
+		// Smooth weighted round-robin: every instance gains its weight, the
+		// highest is selected and pays back the total
+		total := 0
+		selected := available[0]
+		for _, inst := range available {
+			inst.currentWeight += inst.weight
+			total += inst.weight
+			if inst.currentWeight > selected.currentWeight {
+				selected = inst
+			}
+		}
+		selected.currentWeight -= total
+		return moveToFront(available, selected)
+
+	case LoadBalanceLeastLatency:
+		sort.SliceStable(available, func(i, j int) bool {
+			return available[i].latency < available[j].latency
+		})
+		return available
+
+	default:
+		selected := available[lb.next%len(available)]
+		lb.next++
+		return moveToFront(available, selected)
+	}
+}
+
+// moveToFront returns instances with selected moved to the front
+func moveToFront(instances []*lbInstance, selected *lbInstance) []*lbInstance {
+	ordered := make([]*lbInstance, 0, len(instances))
+	ordered = append(ordered, selected)
+	for _, inst := range instances {
+		if inst != selected {
+			ordered = append(ordered, inst)
+		}
+	}
+	return ordered
+}
+
+// recordSuccess updates an instance's circuit breaker and latency average
+func (lb *LoadBalancingProvider) recordSuccess(inst *lbInstance, latency time.Duration) {
+	if inst.breaker != nil {
+		inst.breaker.RecordSuccess()
+	}
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if inst.latency == 0 {
+		inst.latency = latency
+	} else {
+		inst.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(inst.latency))
+	}
+}
+
+// recordFailure records a retryable failure on an instance's circuit breaker
+// and skips the instance for the wait requested by the error, if any
+func (lb *LoadBalancingProvider) recordFailure(inst *lbInstance, err error) {
+	if inst.breaker != nil && IsRetryableError(err) {
+		inst.breaker.RecordFailure()
+	}
+	if wait, ok := RetryAfter(err); ok {
+		lb.mu.Lock()
+		inst.retryAt = time.Now().Add(wait)
+		lb.mu.Unlock()
+	}
+}
+
+// Close closes all instances
+func (lb *LoadBalancingProvider) Close() error {
+	var errs []error
+	for _, inst := range lb.instances {
+		if err := inst.provider.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the configured name, or the first instance's name
+func (lb *LoadBalancingProvider) Name() string {
+	return lb.name
+}
+
+// Instances returns the balanced provider instances in configuration order
+func (lb *LoadBalancingProvider) Instances() []provider.Provider {
+	providers := make([]provider.Provider, len(lb.instances))
+	for i, inst := range lb.instances {
+		providers[i] = inst.provider
+	}
+	return providers
+}
+
+// CircuitBreaker returns the circuit breaker for the instance at index, or
+// nil if circuit breakers are not configured
+func (lb *LoadBalancingProvider) CircuitBreaker(index int) *CircuitBreaker {
+	if index < 0 || index >= len(lb.instances) {
+		return nil
+	}
+	return lb.instances[index].breaker
+}
+
+// Latency returns the moving-average latency of the instance at index, or 0
+// if it has not been measured
+func (lb *LoadBalancingProvider) Latency(index int) time.Duration {
+	if index < 0 || index >= len(lb.instances) {
+		return 0
+	}
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.instances[index].latency
+}
+
+// lbStream records mid-stream failures on the instance's circuit breaker
+type lbStream struct {
+	stream provider.ChatCompletionStream
+	lb     *LoadBalancingProvider
+	inst   *lbInstance
+}
+
+func (s *lbStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.lb.recordFailure(s.inst, err)
+	}
+	return chunk, err
+}
+
+func (s *lbStream) Close() error {
+	return s.stream.Close()
+}
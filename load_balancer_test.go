@@ -0,0 +1,161 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// countingProvider counts completions and can be made to fail
+type countingProvider struct {
+	*MockProvider
+	calls int
+	delay time.Duration
+	err   error
+}
+
+func (p *countingProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	p.calls++
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return nil, p.err
+	}
+	resp := *p.completionResp
+	return &resp, nil
+}
+
+func newCountingProviders(n int) []*countingProvider {
+	providers := make([]*countingProvider, n)
+	for i := range providers {
+		providers[i] = &countingProvider{MockProvider: NewMockProvider("openai")}
+	}
+	return providers
+}
+
+func instancesOf(providers []*countingProvider, weights ...int) []LoadBalancedInstance {
+	instances := make([]LoadBalancedInstance, len(providers))
+	for i, p := range providers {
+		instances[i] = LoadBalancedInstance{Provider: p}
+		if i < len(weights) {
+			instances[i].Weight = weights[i]
+		}
+	}
+	return instances
+}
+
+func TestLoadBalancingProvider_RoundRobin(t *testing.T) {
+	providers := newCountingProviders(3)
+	lb := NewLoadBalancingProvider(instancesOf(providers), nil)
+
+	for i := 0; i < 6; i++ {
+		resp, err := lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if got := resp.ProviderMetadata["lb_instance"]; got != i%3 {
+			t.Errorf("request %d: expected instance %d, got %v", i, i%3, got)
+		}
+	}
+	for i, p := range providers {
+		if p.calls != 2 {
+			t.Errorf("instance %d: expected 2 calls, got %d", i, p.calls)
+		}
+	}
+	if lb.Name() != "openai" {
+		t.Errorf("expected name of first instance, got %q", lb.Name())
+	}
+}
+
+func TestLoadBalancingProvider_Weighted(t *testing.T) {
+	providers := newCountingProviders(2)
+	lb := NewLoadBalancingProvider(instancesOf(providers, 3, 1), &LoadBalancingProviderConfig{
+		Strategy: LoadBalanceWeighted,
+	})
+
+	for i := 0; i < 8; i++ {
+		if _, err := lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"}); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+	if providers[0].calls != 6 || providers[1].calls != 2 {
+		t.Errorf("expected 6/2 split, got %d/%d", providers[0].calls, providers[1].calls)
+	}
+}
+
+func TestLoadBalancingProvider_LeastLatency(t *testing.T) {
+	providers := newCountingProviders(2)
+	providers[0].delay = 20 * time.Millisecond
+	lb := NewLoadBalancingProvider(instancesOf(providers), &LoadBalancingProviderConfig{
+		Strategy: LoadBalanceLeastLatency,
+	})
+
+	// The first two requests measure both instances
+	for i := 0; i < 5; i++ {
+		if _, err := lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"}); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+	if providers[0].calls != 1 || providers[1].calls != 4 {
+		t.Errorf("expected the faster instance to serve the rest, got %d/%d", providers[0].calls, providers[1].calls)
+	}
+	if lb.Latency(0) < 20*time.Millisecond {
+		t.Errorf("expected measured latency for slow instance, got %s", lb.Latency(0))
+	}
+}
+
+func TestLoadBalancingProvider_FailoverAndCircuitBreaker(t *testing.T) {
+	providers := newCountingProviders(2)
+	providers[0].err = ErrServerError
+	lb := NewLoadBalancingProvider(instancesOf(providers), &LoadBalancingProviderConfig{
+		CircuitBreakerConfig: &CircuitBreakerConfig{FailureThreshold: 1, Timeout: time.Minute},
+	})
+
+	resp, err := lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if got := resp.ProviderMetadata["lb_instance"]; got != 1 {
+		t.Errorf("expected instance 1, got %v", got)
+	}
+	if state := lb.CircuitBreaker(0).State(); state != CircuitOpen {
+		t.Fatalf("expected instance 0 circuit open, got %s", state)
+	}
+
+	// The open instance is skipped
+	if _, err := lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"}); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if providers[0].calls != 1 {
+		t.Errorf("expected open instance to be skipped, got %d calls", providers[0].calls)
+	}
+
+	providers[1].err = ErrServerError
+	_, err = lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	var fbErr *FallbackError
+	if !errors.As(err, &fbErr) {
+		t.Fatalf("expected FallbackError, got %v", err)
+	}
+
+	_, err = lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Errorf("expected CircuitOpenError with all circuits open, got %v", err)
+	}
+}
+
+func TestLoadBalancingProvider_NonRetryableStops(t *testing.T) {
+	providers := newCountingProviders(2)
+	providers[0].err = ErrInvalidRequest
+	lb := NewLoadBalancingProvider(instancesOf(providers), nil)
+
+	_, err := lb.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected invalid request error, got %v", err)
+	}
+	if providers[1].calls != 0 {
+		t.Error("expected no failover on a non-retryable error")
+	}
+}
@@ -0,0 +1,215 @@
+// Package mcp connects to Model Context Protocol servers and exposes their
+// tools to omnillm's tool-execution loop.
+//
+// A Client wraps an MCP session. Tools lists the server's tools as
+// provider.Tool definitions, CallTool dispatches a call to the server, and
+// AddTo registers every server tool in an omnillm.ToolSet so ChatClient.RunTools
+// can execute them:
+//
+//	client, err := mcp.ConnectStdio(ctx, nil, "npx", "-y", "@modelcontextprotocol/server-everything")
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	tools, err := client.ToolSet(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	result, err := chat.RunTools(ctx, req, tools, nil)
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrToolError is returned by CallTool when the server reports that the tool
+// failed. The error message includes the server's error text.
+var ErrToolError = errors.New("mcp tool returned an error")
+
+// Default client identity sent to servers during initialization
+const (
+	DefaultClientName    = "omnillm"
+	DefaultClientVersion = "1.0.0"
+)
+
+// Options configures a Client
+type Options struct {
+	// ClientName identifies this client to the server. Default: DefaultClientName
+	ClientName string
+
+	// ClientVersion is sent with ClientName. Default: DefaultClientVersion
+	ClientVersion string
+
+	// ToolPrefix is prepended to tool names exposed to the model, which keeps
+	// names unique when tools from several servers share a ToolSet. Calls are
+	// dispatched to the server under the original name.
+	ToolPrefix string
+}
+
+// Client is a connection to a single MCP server
+type Client struct {
+	session *mcpsdk.ClientSession
+	prefix  string
+}
+
+// Connect initializes an MCP session over transport
+func Connect(ctx context.Context, transport mcpsdk.Transport, opts *Options) (*Client, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	impl := &mcpsdk.Implementation{Name: opts.ClientName, Version: opts.ClientVersion}
+	if impl.Name == "" {
+		impl.Name = DefaultClientName
+	}
+	if impl.Version == "" {
+		impl.Version = DefaultClientVersion
+	}
+
+	session, err := mcpsdk.NewClient(impl, nil).Connect(ctx, transport, nil)
+	if err != nil {
+		return nil, fmt.Errorf("mcp connect: %w", err)
+	}
+	return &Client{session: session, prefix: opts.ToolPrefix}, nil
+}
+
+// ConnectStdio starts command as a subprocess and talks to it over stdin and
+// stdout. Closing the client stops the process.
+func ConnectStdio(ctx context.Context, opts *Options, command string, args ...string) (*Client, error) {
+	cmd := exec.Command(command, args...) //nolint:gosec // G204: the command is chosen by the caller
+	return Connect(ctx, &mcpsdk.CommandTransport{Command: cmd}, opts)
+}
+
+// ConnectHTTP connects to a server using the streamable HTTP transport. If
+// httpClient is nil, http.DefaultClient is used.
+func ConnectHTTP(ctx context.Context, endpoint string, httpClient *http.Client, opts *Options) (*Client, error) {
+	return Connect(ctx, &mcpsdk.StreamableClientTransport{Endpoint: endpoint, HTTPClient: httpClient}, opts)
+}
+
+// ConnectSSE connects to a server using the older HTTP+SSE transport. If
+// httpClient is nil, http.DefaultClient is used.
+func ConnectSSE(ctx context.Context, endpoint string, httpClient *http.Client, opts *Options) (*Client, error) {
+	return Connect(ctx, &mcpsdk.SSEClientTransport{Endpoint: endpoint, HTTPClient: httpClient}, opts)
+}
+
+// Session returns the underlying MCP session for features not wrapped here
+func (c *Client) Session() *mcpsdk.ClientSession {
+	return c.session
+}
+
+// Close ends the session
+func (c *Client) Close() error {
+	return c.session.Close()
+}
+
+// Tools lists the server's tools as provider.Tool definitions
+func (c *Client) Tools(ctx context.Context) ([]provider.Tool, error) {
+	var tools []provider.Tool
+	for tool, err := range c.session.Tools(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("mcp list tools: %w", err)
+		}
+		tools = append(tools, c.convertTool(tool))
+	}
+	return tools, nil
+}
+
+// CallTool calls a tool by its server-side name with JSON arguments and
+// returns the result as text. Text content blocks are joined with newlines;
+// other content is rendered as JSON.
+func (c *Client) CallTool(ctx context.Context, name, arguments string) (string, error) {
+	var args any
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("mcp tool %s: invalid arguments: %w", name, err)
+		}
+	}
+
+	result, err := c.session.CallTool(ctx, &mcpsdk.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		return "", fmt.Errorf("mcp tool %s: %w", name, err)
+	}
+
+	text, err := resultText(result)
+	if err != nil {
+		return "", fmt.Errorf("mcp tool %s: %w", name, err)
+	}
+	if result.IsError {
+		return "", fmt.Errorf("%w: %s", ErrToolError, text)
+	}
+	return text, nil
+}
+
+// AddTo registers every server tool in set, dispatching calls to this client
+func (c *Client) AddTo(ctx context.Context, set *omnillm.ToolSet) error {
+	tools, err := c.Tools(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tool := range tools {
+		serverName := strings.TrimPrefix(tool.Function.Name, c.prefix)
+		set.Add(tool, func(ctx context.Context, arguments string) (string, error) {
+			return c.CallTool(ctx, serverName, arguments)
+		})
+	}
+	return nil
+}
+
+// ToolSet returns a new ToolSet containing the server's tools
+func (c *Client) ToolSet(ctx context.Context) (*omnillm.ToolSet, error) {
+	set := omnillm.NewToolSet()
+	if err := c.AddTo(ctx, set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func (c *Client) convertTool(tool *mcpsdk.Tool) provider.Tool {
+	params := tool.InputSchema
+	if params == nil {
+		params = map[string]any{"type": "object", "properties": map[string]any{}}
+	}
+	return provider.Tool{
+		Type: omnillm.ToolTypeFunction,
+		Function: provider.ToolSpec{
+			Name:        c.prefix + tool.Name,
+			Description: tool.Description,
+			Parameters:  params,
+		},
+	}
+}
+
+// resultText flattens a tool result into the text sent back to the model
+func resultText(result *mcpsdk.CallToolResult) (string, error) {
+	parts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if text, ok := content.(*mcpsdk.TextContent); ok {
+			parts = append(parts, text.Text)
+			continue
+		}
+		data, err := json.Marshal(content)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, string(data))
+	}
+	if len(parts) == 0 && result.StructuredContent != nil {
+		data, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, "\n"), nil
+}
@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+type addArgs struct {
+	A int `json:"a" jsonschema:"first addend"`
+	B int `json:"b" jsonschema:"second addend"`
+}
+
+type addResult struct {
+	Sum int `json:"sum"`
+}
+
+// connectTestServer starts an in-memory MCP server with an "add" tool and a
+// "fail" tool and connects a Client to it
+func connectTestServer(t *testing.T, opts *Options) *Client {
+	t.Helper()
+	ctx := context.Background()
+
+	server := mcpsdk.NewServer(&mcpsdk.Implementation{Name: "test-server", Version: "1.0.0"}, nil)
+	mcpsdk.AddTool(server, &mcpsdk.Tool{Name: "add", Description: "Add two numbers"},
+		func(ctx context.Context, req *mcpsdk.CallToolRequest, args addArgs) (*mcpsdk.CallToolResult, addResult, error) {
+			return nil, addResult{Sum: args.A + args.B}, nil
+		})
+	mcpsdk.AddTool(server, &mcpsdk.Tool{Name: "fail", Description: "Always fails"},
+		func(ctx context.Context, req *mcpsdk.CallToolRequest, args struct{}) (*mcpsdk.CallToolResult, any, error) {
+			return nil, nil, errors.New("backend unavailable")
+		})
+
+	serverTransport, clientTransport := mcpsdk.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = serverSession.Close() })
+
+	client, err := Connect(ctx, clientTransport, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestClient_Tools(t *testing.T) {
+	client := connectTestServer(t, &Options{ToolPrefix: "calc_"})
+
+	tools, err := client.Tools(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 {
+		t.Fatalf("expected 2 tools, got %d", len(tools))
+	}
+
+	var add *provider.Tool
+	for i := range tools {
+		if tools[i].Function.Name == "calc_add" {
+			add = &tools[i]
+		}
+	}
+	if add == nil {
+		t.Fatalf("prefixed add tool not found: %+v", tools)
+	}
+	if add.Type != omnillm.ToolTypeFunction || add.Function.Description != "Add two numbers" {
+		t.Errorf("unexpected tool: %+v", add)
+	}
+	if add.Function.Parameters == nil {
+		t.Error("input schema not converted to parameters")
+	}
+}
+
+func TestClient_CallTool(t *testing.T) {
+	client := connectTestServer(t, nil)
+	ctx := context.Background()
+
+	got, err := client.CallTool(ctx, "add", `{"a":2,"b":3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `"sum":5`) {
+		t.Errorf("CallTool() = %q", got)
+	}
+
+	_, err = client.CallTool(ctx, "fail", `{}`)
+	if !errors.Is(err, ErrToolError) || !strings.Contains(err.Error(), "backend unavailable") {
+		t.Errorf("expected ErrToolError with server message, got %v", err)
+	}
+
+	if _, err := client.CallTool(ctx, "add", `{not json`); err == nil {
+		t.Error("expected invalid arguments error")
+	}
+}
+
+func TestClient_RunTools(t *testing.T) {
+	mcpClient := connectTestServer(t, &Options{ToolPrefix: "calc_"})
+	ctx := context.Background()
+
+	tools, err := mcpClient.ToolSet(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	callResp := mocktest.TextResponse("")
+	callResp.Choices[0].Message.ToolCalls = []provider.ToolCall{{
+		ID:       "call_1",
+		Type:     omnillm.ToolTypeFunction,
+		Function: provider.ToolFunction{Name: "calc_add", Arguments: `{"a":20,"b":22}`},
+	}}
+	prov := mocktest.NewScriptedProvider("scripted",
+		mocktest.Step{Response: callResp},
+		mocktest.TextStep("The sum is 42"),
+	)
+	chat, err := omnillm.NewClient(omnillm.ClientConfig{
+		Providers: []omnillm.ProviderConfig{{CustomProvider: prov}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := chat.RunTools(ctx, &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "What is 20 + 22?"}},
+	}, tools, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolMsg := result.Messages[2]
+	if toolMsg.Role != provider.RoleTool || !strings.Contains(toolMsg.Content, `"sum":42`) {
+		t.Errorf("tool result not dispatched to server: %+v", toolMsg)
+	}
+	if len(prov.Requests()[0].Request.Tools) != 2 {
+		t.Errorf("server tools not sent to model: %+v", prov.Requests()[0].Request.Tools)
+	}
+}
@@ -2,7 +2,10 @@ package omnillm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/grokify/sogo/database/kvs"
@@ -16,6 +19,16 @@ type MemoryConfig struct {
 	TTL time.Duration
 	// KeyPrefix allows customizing the key prefix for stored conversations
 	KeyPrefix string
+	// TitleModel is the model GenerateTitle uses, usually a small, cheap one.
+	// Required for GenerateTitle
+	TitleModel string
+	// TitlePrompt instructs the model that generates titles. Default: DefaultTitlePrompt
+	TitlePrompt string
+	// HistoryTokens limits the stored history sent with each request to this
+	// many tokens: system messages first, then the most recent turns back to
+	// the first one that does not fit. The stored conversation is unchanged.
+	// Default: 0 (send the whole history)
+	HistoryTokens int
 }
 
 // DefaultMemoryConfig returns sensible defaults for memory configuration
@@ -30,38 +43,101 @@ func DefaultMemoryConfig() MemoryConfig {
 // ConversationMemory represents stored conversation data
 type ConversationMemory struct {
 	SessionID string         `json:"session_id"`
+	Title     string         `json:"title,omitempty"`
 	Messages  []Message      `json:"messages"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	Metadata  map[string]any `json:"metadata,omitempty"`
+
+	// MessageMetadata holds the metadata of each message, aligned with
+	// Messages. Messages stored before metadata was recorded have zero values.
+	MessageMetadata []MessageMetadata `json:"message_metadata,omitempty"`
+}
+
+// MessageMetadata describes a stored message: when it was added and, for
+// responses, the model that produced it and what it cost
+type MessageMetadata struct {
+	Timestamp time.Time `json:"timestamp"`
+	Model     string    `json:"model,omitempty"`
+	Usage     *Usage    `json:"usage,omitempty"`
+	Cost      float64   `json:"cost,omitempty"` // Estimated USD at catalog list prices
+	Tags      []string  `json:"tags,omitempty"`
+
+	// Annotations holds application-defined values, such as ratings or trace IDs
+	Annotations map[string]any `json:"annotations,omitempty"`
 }
 
-// MemoryManager handles conversation persistence using KVS
+// MessageWithMetadata pairs a stored message with its metadata
+type MessageWithMetadata struct {
+	Message  Message         `json:"message"`
+	Metadata MessageMetadata `json:"metadata"`
+}
+
+// alignMetadata pads or truncates MessageMetadata to the length of Messages
+func (c *ConversationMemory) alignMetadata() {
+	if n := len(c.Messages); len(c.MessageMetadata) > n {
+		c.MessageMetadata = c.MessageMetadata[:n]
+	} else if len(c.MessageMetadata) < n {
+		c.MessageMetadata = append(c.MessageMetadata, make([]MessageMetadata, n-len(c.MessageMetadata))...)
+	}
+}
+
+// appendMessages adds messages with their metadata, stamping any without a timestamp
+func (c *ConversationMemory) appendMessages(messages []MessageWithMetadata) {
+	c.alignMetadata()
+	now := time.Now()
+	for _, m := range messages {
+		if m.Metadata.Timestamp.IsZero() {
+			m.Metadata.Timestamp = now
+		}
+		c.Messages = append(c.Messages, m.Message)
+		c.MessageMetadata = append(c.MessageMetadata, m.Metadata)
+	}
+}
+
+// ConversationSummary describes a stored conversation in ListConversations
+type ConversationSummary struct {
+	SessionID    string    `json:"session_id"`
+	Title        string    `json:"title,omitempty"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// MemoryManager handles conversation persistence using a ConversationStore
 type MemoryManager struct {
-	kvs    kvs.Client
+	store  ConversationStore
 	config MemoryConfig
 }
 
-// NewMemoryManager creates a new memory manager with the given KVS client and config
+// NewMemoryManager creates a new memory manager with the given KVS client and
+// config, storing conversations under config.KeyPrefix
 func NewMemoryManager(kvsClient kvs.Client, config MemoryConfig) *MemoryManager {
+	var store ConversationStore
+	if kvsClient != nil {
+		store = NewKVSConversationStore(kvsClient, config.KeyPrefix)
+	}
+	return NewMemoryManagerWithStore(store, config)
+}
+
+// NewMemoryManagerWithStore creates a new memory manager with the given
+// conversation store and config. KeyPrefix is not used.
+func NewMemoryManagerWithStore(store ConversationStore, config MemoryConfig) *MemoryManager {
 	return &MemoryManager{
-		kvs:    kvsClient,
+		store:  store,
 		config: config,
 	}
 }
 
-// LoadConversation retrieves a conversation from memory
+// LoadConversation retrieves a conversation from memory. A session with no
+// stored conversation gets a new, empty one.
 func (m *MemoryManager) LoadConversation(ctx context.Context, sessionID string) (*ConversationMemory, error) {
-	if m.kvs == nil {
+	if m.store == nil {
 		return nil, fmt.Errorf("memory not configured")
 	}
 
-	key := m.buildKey(sessionID)
-
-	var conversation ConversationMemory
-	err := m.kvs.GetAny(ctx, key, &conversation)
-	if err != nil {
-		// Return empty conversation if not found
+	conversation, err := m.store.Load(ctx, sessionID)
+	if errors.Is(err, ErrConversationNotFound) {
 		return &ConversationMemory{
 			SessionID: sessionID,
 			Messages:  []Message{},
@@ -70,27 +146,32 @@ func (m *MemoryManager) LoadConversation(ctx context.Context, sessionID string)
 			Metadata:  make(map[string]any),
 		}, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &conversation, nil
+	return conversation, nil
 }
 
 // SaveConversation stores a conversation in memory
 func (m *MemoryManager) SaveConversation(ctx context.Context, conversation *ConversationMemory) error {
-	if m.kvs == nil {
+	if m.store == nil {
 		return fmt.Errorf("memory not configured")
 	}
 
 	// Apply message limit
 	if m.config.MaxMessages > 0 && len(conversation.Messages) > m.config.MaxMessages {
-		// Keep system messages and limit the rest
-		systemMessages := []Message{}
-		otherMessages := []Message{}
+		// Keep system messages and limit the rest, moving metadata with its message
+		conversation.alignMetadata()
+		systemMessages := []MessageWithMetadata{}
+		otherMessages := []MessageWithMetadata{}
 
-		for _, msg := range conversation.Messages {
+		for i, msg := range conversation.Messages {
+			entry := MessageWithMetadata{Message: msg, Metadata: conversation.MessageMetadata[i]}
 			if msg.Role == RoleSystem {
-				systemMessages = append(systemMessages, msg)
+				systemMessages = append(systemMessages, entry)
 			} else {
-				otherMessages = append(otherMessages, msg)
+				otherMessages = append(otherMessages, entry)
 			}
 		}
 
@@ -98,15 +179,86 @@ func (m *MemoryManager) SaveConversation(ctx context.Context, conversation *Conv
 		maxOthers := m.config.MaxMessages - len(systemMessages)
 		if maxOthers > 0 && len(otherMessages) > maxOthers {
 			otherMessages = otherMessages[len(otherMessages)-maxOthers:]
+
+			// Providers reject tool results whose tool call was trimmed
+			for len(otherMessages) > 0 && otherMessages[0].Message.Role == RoleTool {
+				otherMessages = otherMessages[1:]
+			}
 		}
 
-		conversation.Messages = append(systemMessages, otherMessages...)
+		kept := append(systemMessages, otherMessages...)
+		conversation.Messages = make([]Message, len(kept))
+		conversation.MessageMetadata = make([]MessageMetadata, len(kept))
+		for i, entry := range kept {
+			conversation.Messages[i] = entry.Message
+			conversation.MessageMetadata[i] = entry.Metadata
+		}
 	}
 
 	conversation.UpdatedAt = time.Now()
-	key := m.buildKey(conversation.SessionID)
 
-	return m.kvs.SetAny(ctx, key, conversation)
+	return m.store.Save(ctx, conversation)
+}
+
+// packHistory returns the messages of a stored conversation that fit in
+// HistoryTokens, in their original order: the system messages, then an
+// unbroken run of the newest turns. An assistant message with tool calls is
+// packed together with its tool results, so neither is sent without the other.
+func (m *MemoryManager) packHistory(messages []Message, model string, estimator TokenEstimator) ([]Message, error) {
+	if m.config.HistoryTokens <= 0 || len(messages) == 0 {
+		return messages, nil
+	}
+	if estimator == nil {
+		estimator = NewTokenEstimator(DefaultTokenEstimatorConfig())
+	}
+
+	// Group each assistant tool call turn with the tool results that follow it
+	var groups [][]Message
+	for _, msg := range messages {
+		if n := len(groups); n > 0 && msg.Role == RoleTool && len(groups[n-1][0].ToolCalls) > 0 {
+			groups[n-1] = append(groups[n-1], msg)
+			continue
+		}
+		groups = append(groups, []Message{msg})
+	}
+
+	costs := make([]int, len(groups))
+	for i, group := range groups {
+		tokens, err := estimator.EstimateTokens(model, group)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate history tokens: %w", err)
+		}
+		costs[i] = max(tokens, 1)
+	}
+
+	// Keep the system messages that fit, then walk back from the newest turn
+	// and stop at the first one that does not fit, so the history has no gaps
+	budget := m.config.HistoryTokens
+	keep := make([]bool, len(groups))
+	for i, group := range groups {
+		if group[0].Role == RoleSystem && costs[i] <= budget {
+			keep[i] = true
+			budget -= costs[i]
+		}
+	}
+	for i := len(groups) - 1; i >= 0; i-- {
+		if groups[i][0].Role == RoleSystem {
+			continue
+		}
+		if costs[i] > budget {
+			break
+		}
+		keep[i] = true
+		budget -= costs[i]
+	}
+
+	var history []Message
+	for i, group := range groups {
+		if keep[i] {
+			history = append(history, group...)
+		}
+	}
+	return history, nil
 }
 
 // AppendMessage adds a message to the conversation and saves it
@@ -116,7 +268,7 @@ func (m *MemoryManager) AppendMessage(ctx context.Context, sessionID string, mes
 		return fmt.Errorf("failed to load conversation: %w", err)
 	}
 
-	conversation.Messages = append(conversation.Messages, message)
+	conversation.appendMessages([]MessageWithMetadata{{Message: message}})
 
 	return m.SaveConversation(ctx, conversation)
 }
@@ -128,22 +280,53 @@ func (m *MemoryManager) AppendMessages(ctx context.Context, sessionID string, me
 		return fmt.Errorf("failed to load conversation: %w", err)
 	}
 
-	conversation.Messages = append(conversation.Messages, messages...)
+	entries := make([]MessageWithMetadata, len(messages))
+	for i, msg := range messages {
+		entries[i] = MessageWithMetadata{Message: msg}
+	}
+	conversation.appendMessages(entries)
+
+	return m.SaveConversation(ctx, conversation)
+}
+
+// AppendMessagesWithMetadata adds messages and their metadata to the
+// conversation and saves it. Messages without a timestamp are stamped with
+// the current time.
+func (m *MemoryManager) AppendMessagesWithMetadata(ctx context.Context, sessionID string, messages []MessageWithMetadata) error {
+	conversation, err := m.LoadConversation(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	conversation.appendMessages(messages)
 
 	return m.SaveConversation(ctx, conversation)
 }
 
 // DeleteConversation removes a conversation from memory
 func (m *MemoryManager) DeleteConversation(ctx context.Context, sessionID string) error {
-	if m.kvs == nil {
+	if m.store == nil {
 		return fmt.Errorf("memory not configured")
 	}
 
-	key := m.buildKey(sessionID)
+	return m.store.Delete(ctx, sessionID)
+}
+
+// ListConversations returns a summary of each stored conversation, most
+// recently updated first
+func (m *MemoryManager) ListConversations(ctx context.Context) ([]ConversationSummary, error) {
+	if m.store == nil {
+		return nil, fmt.Errorf("memory not configured")
+	}
 
-	// Since the KVS interface doesn't have a Delete method, we'll set an empty value
-	// This is a limitation of the current KVS interface
-	return m.kvs.SetString(ctx, key, "")
+	summaries, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(summaries, func(a, b ConversationSummary) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	return summaries, nil
 }
 
 // GetMessages returns just the messages from a conversation
@@ -156,6 +339,22 @@ func (m *MemoryManager) GetMessages(ctx context.Context, sessionID string) ([]Me
 	return conversation.Messages, nil
 }
 
+// GetMessagesWithMetadata returns the messages of a conversation along with
+// their metadata
+func (m *MemoryManager) GetMessagesWithMetadata(ctx context.Context, sessionID string) ([]MessageWithMetadata, error) {
+	conversation, err := m.LoadConversation(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation.alignMetadata()
+	messages := make([]MessageWithMetadata, len(conversation.Messages))
+	for i, msg := range conversation.Messages {
+		messages[i] = MessageWithMetadata{Message: msg, Metadata: conversation.MessageMetadata[i]}
+	}
+	return messages, nil
+}
+
 // SetMetadata sets metadata for a conversation
 func (m *MemoryManager) SetMetadata(ctx context.Context, sessionID string, metadata map[string]any) error {
 	conversation, err := m.LoadConversation(ctx, sessionID)
@@ -174,25 +373,59 @@ func (m *MemoryManager) SetMetadata(ctx context.Context, sessionID string, metad
 	return m.SaveConversation(ctx, conversation)
 }
 
-// buildKey constructs the storage key for a session
-func (m *MemoryManager) buildKey(sessionID string) string {
-	return fmt.Sprintf("%s:%s", m.config.KeyPrefix, sessionID)
+// ForkConversation copies the first atMessageIndex messages of a stored
+// conversation, along with its metadata, into a new session, leaving the
+// original untouched. Forking at len(messages) copies the whole conversation;
+// forking at the index of an assistant message drops it, so the fork can
+// regenerate the reply from that point. The fork's metadata records its
+// origin under "forked_from" and "forked_at".
+func (m *MemoryManager) ForkConversation(ctx context.Context, fromSessionID, newSessionID string, atMessageIndex int) error {
+	if fromSessionID == newSessionID {
+		return fmt.Errorf("cannot fork session %q into itself", fromSessionID)
+	}
+
+	source, err := m.LoadConversation(ctx, fromSessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+	if atMessageIndex < 0 || atMessageIndex > len(source.Messages) {
+		return fmt.Errorf("fork index %d out of range for session %q with %d messages",
+			atMessageIndex, fromSessionID, len(source.Messages))
+	}
+
+	source.alignMetadata()
+	now := time.Now()
+	fork := &ConversationMemory{
+		SessionID:       newSessionID,
+		Messages:        slices.Clone(source.Messages[:atMessageIndex]),
+		MessageMetadata: slices.Clone(source.MessageMetadata[:atMessageIndex]),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+		Metadata:        maps.Clone(source.Metadata),
+	}
+	if fork.Metadata == nil {
+		fork.Metadata = make(map[string]any)
+	}
+	fork.Metadata["forked_from"] = fromSessionID
+	fork.Metadata["forked_at"] = atMessageIndex
+
+	return m.SaveConversation(ctx, fork)
 }
 
 // CreateConversationWithSystemMessage creates a new conversation with a system message
 func (m *MemoryManager) CreateConversationWithSystemMessage(ctx context.Context, sessionID, systemMessage string) error {
 	conversation := &ConversationMemory{
 		SessionID: sessionID,
-		Messages: []Message{
-			{
-				Role:    RoleSystem,
-				Content: systemMessage,
-			},
-		},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		Metadata:  make(map[string]any),
 	}
+	conversation.appendMessages([]MessageWithMetadata{{
+		Message: Message{
+			Role:    RoleSystem,
+			Content: systemMessage,
+		},
+	}})
 
 	return m.SaveConversation(ctx, conversation)
 }
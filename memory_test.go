@@ -2,6 +2,8 @@ package omnillm
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -198,6 +200,30 @@ func TestMemoryManager_MaxMessages(t *testing.T) {
 	}
 }
 
+func TestMemoryManager_MaxMessagesDropsOrphanedToolResults(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), MemoryConfig{MaxMessages: 2, KeyPrefix: "test"})
+	ctx := context.Background()
+
+	callID := "call_1"
+	err := mm.AppendMessages(ctx, "session1", []Message{
+		{Role: RoleUser, Content: "Weather?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: callID, Type: "function", Function: ToolFunction{Name: "get_weather"}}}},
+		{Role: RoleTool, Content: "Sunny", ToolCallID: &callID},
+		{Role: RoleAssistant, Content: "It's sunny."},
+	})
+	if err != nil {
+		t.Fatalf("AppendMessages failed: %v", err)
+	}
+
+	messages, err := mm.GetMessages(ctx, "session1")
+	if err != nil {
+		t.Fatalf("GetMessages failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Content != "It's sunny." {
+		t.Errorf("expected the orphaned tool result to be dropped, got %+v", messages)
+	}
+}
+
 func TestMemoryManager_GetMessages(t *testing.T) {
 	mockKVS := mocktest.NewMockKVS()
 	config := DefaultMemoryConfig()
@@ -286,13 +312,208 @@ func TestMemoryManager_SetMetadata(t *testing.T) {
 	}
 }
 
-func TestMemoryManager_BuildKey(t *testing.T) {
-	config := MemoryConfig{
-		KeyPrefix: "myapp:chat",
+func TestMemoryManager_ForkConversation(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), DefaultMemoryConfig())
+	ctx := context.Background()
+
+	messages := []Message{
+		{Role: RoleUser, Content: "Hello"},
+		{Role: RoleAssistant, Content: "Hi there!"},
+		{Role: RoleUser, Content: "Tell me a joke"},
+		{Role: RoleAssistant, Content: "Why did the chicken cross the road?"},
+	}
+	if err := mm.AppendMessages(ctx, "original", messages); err != nil {
+		t.Fatalf("AppendMessages failed: %v", err)
+	}
+	if err := mm.SetMetadata(ctx, "original", map[string]any{"user_id": "u1"}); err != nil {
+		t.Fatalf("SetMetadata failed: %v", err)
+	}
+
+	// Fork before the last reply to regenerate it
+	if err := mm.ForkConversation(ctx, "original", "branch", 3); err != nil {
+		t.Fatalf("ForkConversation failed: %v", err)
+	}
+	if err := mm.AppendMessage(ctx, "branch", Message{Role: RoleAssistant, Content: "Knock knock."}); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+
+	branch, err := mm.LoadConversation(ctx, "branch")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if len(branch.Messages) != 4 || branch.Messages[2].Content != "Tell me a joke" || branch.Messages[3].Content != "Knock knock." {
+		t.Errorf("unexpected branch messages: %+v", branch.Messages)
+	}
+	if branch.Metadata["user_id"] != "u1" || branch.Metadata["forked_from"] != "original" || branch.Metadata["forked_at"] != float64(3) {
+		t.Errorf("unexpected branch metadata: %+v", branch.Metadata)
+	}
+
+	original, err := mm.LoadConversation(ctx, "original")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if len(original.Messages) != 4 || original.Messages[3].Content != "Why did the chicken cross the road?" {
+		t.Errorf("expected the original to be unchanged, got %+v", original.Messages)
+	}
+	if _, ok := original.Metadata["forked_from"]; ok {
+		t.Error("expected the original metadata to be unchanged")
+	}
+
+	for _, index := range []int{-1, 5} {
+		if err := mm.ForkConversation(ctx, "original", "bad", index); err == nil {
+			t.Errorf("expected an error forking at %d", index)
+		}
+	}
+	if err := mm.ForkConversation(ctx, "original", "original", 2); err == nil {
+		t.Error("expected an error forking a session into itself")
+	}
+}
+
+func TestMemoryManager_MessageMetadata(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), MemoryConfig{MaxMessages: 3, KeyPrefix: "test"})
+	ctx := context.Background()
+
+	// Messages saved without metadata read back with zero values
+	if err := mm.SaveConversation(ctx, &ConversationMemory{
+		SessionID: "session1",
+		Messages:  []Message{{Role: RoleSystem, Content: "Be brief."}},
+	}); err != nil {
+		t.Fatalf("SaveConversation failed: %v", err)
+	}
+
+	usage := &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	err := mm.AppendMessagesWithMetadata(ctx, "session1", []MessageWithMetadata{
+		{Message: Message{Role: RoleUser, Content: "Hello"}, Metadata: MessageMetadata{Tags: []string{"greeting"}}},
+		{Message: Message{Role: RoleAssistant, Content: "Hi!"}, Metadata: MessageMetadata{Model: "gpt-4o", Usage: usage, Cost: 0.001}},
+		{Message: Message{Role: RoleUser, Content: "Bye"}},
+	})
+	if err != nil {
+		t.Fatalf("AppendMessagesWithMetadata failed: %v", err)
+	}
+
+	// MaxMessages drops "Hello" and keeps the metadata aligned
+	messages, err := mm.GetMessagesWithMetadata(ctx, "session1")
+	if err != nil {
+		t.Fatalf("GetMessagesWithMetadata failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	if !messages[0].Metadata.Timestamp.IsZero() {
+		t.Errorf("expected no timestamp for the legacy message, got %v", messages[0].Metadata.Timestamp)
+	}
+	reply := messages[1]
+	if reply.Message.Content != "Hi!" || reply.Metadata.Model != "gpt-4o" || reply.Metadata.Usage == nil ||
+		reply.Metadata.Usage.TotalTokens != 15 || reply.Metadata.Cost != 0.001 || reply.Metadata.Timestamp.IsZero() {
+		t.Errorf("unexpected reply metadata: %+v", reply)
+	}
+	if messages[2].Message.Content != "Bye" || messages[2].Metadata.Timestamp.IsZero() {
+		t.Errorf("expected a timestamp on the last message, got %+v", messages[2])
+	}
+
+	// The plain message API is unchanged
+	plain, err := mm.GetMessages(ctx, "session1")
+	if err != nil || len(plain) != 3 || plain[2].Content != "Bye" {
+		t.Errorf("unexpected messages: %+v, %v", plain, err)
+	}
+}
+
+func TestMemoryManager_ListConversations(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), DefaultMemoryConfig())
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := mm.AppendMessage(ctx, id, Message{Role: RoleUser, Content: "hi " + id}); err != nil {
+			t.Fatalf("AppendMessage failed: %v", err)
+		}
+	}
+	if err := mm.AppendMessage(ctx, "a", Message{Role: RoleAssistant, Content: "hello"}); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	if err := mm.DeleteConversation(ctx, "b"); err != nil {
+		t.Fatalf("DeleteConversation failed: %v", err)
+	}
+	if err := mm.SetTitle(ctx, "c", "Greetings"); err != nil {
+		t.Fatalf("SetTitle failed: %v", err)
 	}
-	mm := NewMemoryManager(nil, config)
 
-	key := mm.buildKey("session123")
+	got, err := mm.ListConversations(ctx)
+	if err != nil {
+		t.Fatalf("ListConversations failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d conversations, want 2: %+v", len(got), got)
+	}
+	if got[0].SessionID != "c" || got[0].Title != "Greetings" || got[0].MessageCount != 1 {
+		t.Errorf("most recent = %+v, want c titled Greetings", got[0])
+	}
+	if got[1].SessionID != "a" || got[1].MessageCount != 2 {
+		t.Errorf("second = %+v, want a with 2 messages", got[1])
+	}
+}
+
+func TestMemoryManager_GenerateTitle(t *testing.T) {
+	config := DefaultMemoryConfig()
+	config.TitleModel = "small"
+	mm := NewMemoryManager(mocktest.NewMockKVS(), config)
+	ctx := context.Background()
+
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("\"Planning a Trip to Kyoto.\"\n"))
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mock}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := mm.GenerateTitle(ctx, "trip", client); err == nil {
+		t.Error("expected an error for an empty conversation")
+	}
+
+	untitled := NewMemoryManager(mocktest.NewMockKVS(), DefaultMemoryConfig())
+	if _, err := untitled.GenerateTitle(ctx, "trip", client); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("expected ErrInvalidConfiguration without a TitleModel, got %v", err)
+	}
+
+	if err := mm.AppendMessages(ctx, "trip", []Message{
+		{Role: RoleSystem, Content: "You are a travel agent."},
+		{Role: RoleUser, Content: "I want to visit Kyoto in April."},
+		{Role: RoleAssistant, Content: "Cherry blossom season, great choice."},
+	}); err != nil {
+		t.Fatalf("AppendMessages failed: %v", err)
+	}
+	title, err := mm.GenerateTitle(ctx, "trip", client)
+	if err != nil {
+		t.Fatalf("GenerateTitle failed: %v", err)
+	}
+	if title != "Planning a Trip to Kyoto" {
+		t.Errorf("title = %q", title)
+	}
+
+	sent := mock.Requests()
+	if len(sent) != 1 {
+		t.Fatalf("got %d requests, want 1", len(sent))
+	}
+	req := sent[0].Request
+	if req.Model != "small" || req.Messages[0].Content != DefaultTitlePrompt {
+		t.Errorf("unexpected title request: %+v", req)
+	}
+	if want := "user: I want to visit Kyoto in April.\nassistant: Cherry blossom season, great choice.\n"; req.Messages[1].Content != want {
+		t.Errorf("transcript = %q, want %q", req.Messages[1].Content, want)
+	}
+
+	conv, err := mm.LoadConversation(ctx, "trip")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if conv.Title != title {
+		t.Errorf("stored title = %q, want %q", conv.Title, title)
+	}
+}
+
+func TestKVSConversationStore_BuildKey(t *testing.T) {
+	store := NewKVSConversationStore(nil, "myapp:chat")
+
+	key := store.buildKey("session123")
 	expected := "myapp:chat:session123"
 	if key != expected {
 		t.Errorf("buildKey = %s, want %s", key, expected)
@@ -312,3 +533,53 @@ func TestDefaultMemoryConfig(t *testing.T) {
 		t.Errorf("KeyPrefix = %s, want omnillm:session", config.KeyPrefix)
 	}
 }
+
+func TestMemoryManager_PackHistory(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), MemoryConfig{HistoryTokens: 64})
+	callID := "c1"
+	messages := []Message{
+		{Role: RoleSystem, Content: "be brief"},
+		{Role: RoleUser, Content: "first question"},
+		{Role: RoleAssistant, Content: "first answer"},
+		{Role: RoleUser, Content: "weather?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: callID, Type: "function", Function: ToolFunction{Name: "get_weather"}}}},
+		{Role: RoleTool, Content: "sunny", ToolCallID: &callID},
+		{Role: RoleAssistant, Content: "It is sunny"},
+	}
+
+	// With 10 tokens per message plus one per character, the system message
+	// and the newest turns fill the budget exactly
+	history, err := mm.packHistory(messages, "m", messageOnlyEstimator{})
+	if err != nil {
+		t.Fatalf("packHistory failed: %v", err)
+	}
+	want := []Message{messages[0], messages[4], messages[5], messages[6]}
+	if len(history) != len(want) {
+		t.Fatalf("got %d messages, want %d: %+v", len(history), len(want), history)
+	}
+	for i := range want {
+		if history[i].Role != want[i].Role || history[i].Content != want[i].Content {
+			t.Errorf("message %d = %+v, want %+v", i, history[i], want[i])
+		}
+	}
+
+	// An oversized middle turn ends the history rather than leaving a gap
+	long := []Message{
+		{Role: RoleUser, Content: "old"},
+		{Role: RoleAssistant, Content: strings.Repeat("x", 100)},
+		{Role: RoleUser, Content: "new"},
+	}
+	history, err = mm.packHistory(long, "m", messageOnlyEstimator{})
+	if err != nil {
+		t.Fatalf("packHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Content != "new" {
+		t.Errorf("got %+v, want only the newest turn", history)
+	}
+
+	// Without a budget the history is unchanged
+	unlimited := NewMemoryManager(mocktest.NewMockKVS(), DefaultMemoryConfig())
+	if history, _ := unlimited.packHistory(messages, "m", nil); len(history) != len(messages) {
+		t.Errorf("got %d messages without a budget, want %d", len(history), len(messages))
+	}
+}
@@ -0,0 +1,107 @@
+package omnillm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultTitlePrompt instructs the model that generates conversation titles
+const DefaultTitlePrompt = "Write a short title of at most six words for the following conversation. Reply with the title only, without quotes or punctuation at the end."
+
+// Limits on what GenerateTitle sends and stores
+const (
+	titleMessages     = 6   // Messages from the start of the conversation
+	titleMessageChars = 500 // Bytes kept of each message
+	maxTitleRunes     = 80
+)
+
+// GenerateTitle asks client for a short title for a stored conversation,
+// based on its first messages, and stores it as the conversation's Title.
+// The model is MemoryConfig.TitleModel, which is required.
+func (m *MemoryManager) GenerateTitle(ctx context.Context, sessionID string, client *ChatClient) (string, error) {
+	if m.config.TitleModel == "" {
+		return "", fmt.Errorf("%w: MemoryConfig.TitleModel is required to generate titles", ErrInvalidConfiguration)
+	}
+
+	conversation, err := m.LoadConversation(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	var transcript strings.Builder
+	n := 0
+	for _, msg := range conversation.Messages {
+		if msg.Role != RoleUser && msg.Role != RoleAssistant || msg.Content == "" {
+			continue
+		}
+		content := msg.Content
+		if len(content) > titleMessageChars {
+			content = strings.ToValidUTF8(content[:titleMessageChars], "") + "..."
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, content)
+		if n++; n == titleMessages {
+			break
+		}
+	}
+	if n == 0 {
+		return "", fmt.Errorf("conversation %q has no messages to title", sessionID)
+	}
+
+	prompt := m.config.TitlePrompt
+	if prompt == "" {
+		prompt = DefaultTitlePrompt
+	}
+	resp, err := client.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+		Model: m.config.TitleModel,
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: prompt},
+			{Role: provider.RoleUser, Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate title: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("failed to generate title: no choices returned")
+	}
+
+	title := cleanTitle(resp.Choices[0].Message.Content)
+	if title == "" {
+		return "", fmt.Errorf("failed to generate title: empty reply")
+	}
+	conversation.Title = title
+	if err := m.SaveConversation(ctx, conversation); err != nil {
+		return "", err
+	}
+	return title, nil
+}
+
+// SetTitle stores a title for a conversation, such as one chosen by the user
+func (m *MemoryManager) SetTitle(ctx context.Context, sessionID, title string) error {
+	conversation, err := m.LoadConversation(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+	conversation.Title = title
+	return m.SaveConversation(ctx, conversation)
+}
+
+// cleanTitle keeps the first line of a generated title without surrounding
+// quotes, a "Title:" label, or a trailing period, and caps its length
+func cleanTitle(s string) string {
+	s = strings.TrimSpace(s)
+	if line, _, ok := strings.Cut(s, "\n"); ok {
+		s = line
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "Title:"), "title:")
+	s = strings.Trim(strings.TrimSpace(s), "\"'`*")
+	s = strings.TrimRight(s, ".")
+	if utf8.RuneCountInString(s) > maxTitleRunes {
+		s = string([]rune(s)[:maxTitleRunes])
+	}
+	return strings.TrimSpace(s)
+}
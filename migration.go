@@ -0,0 +1,271 @@
+package omnillm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// MigrationReport summarizes the rewrites applied when migrating a conversation
+type MigrationReport struct {
+	// Target is the provider the conversation was migrated to
+	Target ProviderName
+
+	// SystemMessagesMerged is the number of system messages folded into a single leading system message
+	SystemMessagesMerged int
+
+	// ToolCallsFlattened is the number of assistant tool calls rewritten as text
+	ToolCallsFlattened int
+
+	// ToolResultsConverted is the number of tool result messages rewritten as user messages
+	ToolResultsConverted int
+
+	// RolesConverted is the number of messages with unsupported roles rewritten as user messages
+	RolesConverted int
+
+	// MessagesMerged is the number of consecutive same-role messages merged together
+	MessagesMerged int
+
+	// PlaceholderInserted is true if a user message was inserted so the conversation starts with a user turn
+	PlaceholderInserted bool
+}
+
+// Changed returns true if any rewrite was applied
+func (r *MigrationReport) Changed() bool {
+	return r.SystemMessagesMerged > 0 || r.ToolCallsFlattened > 0 || r.ToolResultsConverted > 0 ||
+		r.RolesConverted > 0 || r.MessagesMerged > 0 || r.PlaceholderInserted
+}
+
+// migrationProfile describes the message constraints of a target provider
+type migrationProfile struct {
+	// nativeTools is true if the provider adapter sends structured tool calls and tool results
+	nativeTools bool
+
+	// singleLeadingSystem is true if the provider only honors one system prompt
+	singleLeadingSystem bool
+
+	// alternatingRoles is true if the provider requires strict user/assistant alternation starting with user
+	alternatingRoles bool
+}
+
+// migrationProfiles maps providers to their message constraints
+var migrationProfiles = map[ProviderName]migrationProfile{
+	ProviderNameOpenAI:      {nativeTools: true},
+	ProviderNameXAI:         {},
+	ProviderNameHuggingFace: {nativeTools: true},
+	ProviderNameOllama:      {},
+	ProviderNameAnthropic:   {nativeTools: true, singleLeadingSystem: true, alternatingRoles: true},
+	ProviderNameBedrock:     {singleLeadingSystem: true, alternatingRoles: true},
+	ProviderNameGemini:      {nativeTools: true, singleLeadingSystem: true, alternatingRoles: true},
+}
+
+// conversationContinuedPlaceholder is inserted when a target requires the first turn to be a user message
+const conversationContinuedPlaceholder = "[Conversation continued]"
+
+// MigrateMessages rewrites provider-specific artifacts in a conversation so it can be
+// continued on the target provider. Tool calls and results are flattened to text for
+// providers without native tool support, system prompts are consolidated, and roles
+// are merged to satisfy alternation rules. The input slice is not modified.
+func MigrateMessages(messages []provider.Message, target ProviderName) ([]provider.Message, *MigrationReport, error) {
+	entries := make([]MessageWithMetadata, len(messages))
+	for i, msg := range messages {
+		entries[i] = MessageWithMetadata{Message: msg}
+	}
+	migrated, report, err := migrateEntries(entries, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make([]provider.Message, len(migrated))
+	for i, entry := range migrated {
+		result[i] = entry.Message
+	}
+	return result, report, nil
+}
+
+// migrateEntries implements MigrateMessages, keeping each message's metadata
+// with it. A merged message keeps the metadata of the first message merged
+// into it, and an inserted placeholder has none.
+func migrateEntries(entries []MessageWithMetadata, target ProviderName) ([]MessageWithMetadata, *MigrationReport, error) {
+	profile, ok := migrationProfiles[target]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, target)
+	}
+
+	report := &MigrationReport{Target: target}
+	toolNames := make(map[string]string)
+	var systemParts []string
+	var systemAnnotations []provider.Annotation
+	var systemMetadata *MessageMetadata
+	var result []MessageWithMetadata
+
+	for _, entry := range entries {
+		msg := entry.Message
+		msg.ToolCalls = append([]provider.ToolCall(nil), msg.ToolCalls...)
+		msg.Annotations = append([]provider.Annotation(nil), msg.Annotations...)
+
+		switch msg.Role {
+		case provider.RoleSystem:
+			if profile.singleLeadingSystem {
+				systemParts = append(systemParts, msg.Content)
+				systemAnnotations = append(systemAnnotations, msg.Annotations...)
+				if systemMetadata == nil {
+					systemMetadata = &entry.Metadata
+				}
+				continue
+			}
+
+		case provider.RoleAssistant:
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+			}
+			if !profile.nativeTools && len(msg.ToolCalls) > 0 {
+				msg.Content = appendToolCallText(msg.Content, msg.ToolCalls)
+				report.ToolCallsFlattened += len(msg.ToolCalls)
+				msg.ToolCalls = nil
+			}
+
+		case provider.RoleTool:
+			var callID string
+			if msg.ToolCallID != nil {
+				callID = *msg.ToolCallID
+			}
+			name, known := toolNames[callID]
+			if !profile.nativeTools || !known {
+				msg = toolResultAsUserMessage(msg, name, callID)
+				report.ToolResultsConverted++
+			}
+
+		case provider.RoleUser:
+
+		default:
+			msg.Role = provider.RoleUser
+			report.RolesConverted++
+		}
+
+		result = append(result, MessageWithMetadata{Message: msg, Metadata: entry.Metadata})
+	}
+
+	if profile.alternatingRoles {
+		result = mergeConsecutiveRoles(result, report)
+		if len(result) > 0 && result[0].Message.Role != provider.RoleUser {
+			placeholder := MessageWithMetadata{Message: provider.Message{Role: provider.RoleUser, Content: conversationContinuedPlaceholder}}
+			result = append([]MessageWithMetadata{placeholder}, result...)
+			report.PlaceholderInserted = true
+		}
+	}
+
+	if len(systemParts) > 0 {
+		if len(systemParts) > 1 {
+			report.SystemMessagesMerged = len(systemParts)
+		}
+		system := MessageWithMetadata{
+			Message: provider.Message{
+				Role:        provider.RoleSystem,
+				Content:     strings.Join(systemParts, "\n\n"),
+				Annotations: systemAnnotations,
+			},
+			Metadata: *systemMetadata,
+		}
+		result = append([]MessageWithMetadata{system}, result...)
+	}
+
+	return result, report, nil
+}
+
+// appendToolCallText renders tool calls as text appended to the assistant content
+func appendToolCallText(content string, toolCalls []provider.ToolCall) string {
+	var sb strings.Builder
+	sb.WriteString(content)
+	for _, tc := range toolCalls {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "[Called tool %s with arguments: %s]", tc.Function.Name, tc.Function.Arguments)
+	}
+	return sb.String()
+}
+
+// toolResultAsUserMessage rewrites a tool result as a user message
+func toolResultAsUserMessage(msg provider.Message, name, callID string) provider.Message {
+	label := name
+	if label == "" {
+		label = callID
+	}
+	if label == "" {
+		label = "unknown"
+	}
+	return provider.Message{
+		Role:        provider.RoleUser,
+		Content:     fmt.Sprintf("[Tool result from %s]: %s", label, msg.Content),
+		Annotations: msg.Annotations,
+	}
+}
+
+// mergeConsecutiveRoles merges adjacent messages that share a role. Tool
+// results each answer their own call, so they are not merged.
+func mergeConsecutiveRoles(entries []MessageWithMetadata, report *MigrationReport) []MessageWithMetadata {
+	var merged []MessageWithMetadata
+	for _, entry := range entries {
+		msg := entry.Message
+		if n := len(merged); n > 0 && merged[n-1].Message.Role == msg.Role && msg.Role != provider.RoleSystem && msg.Role != provider.RoleTool {
+			prev := &merged[n-1].Message
+			switch {
+			case prev.Content == "":
+				prev.Content = msg.Content
+			case msg.Content != "":
+				prev.Content += "\n\n" + msg.Content
+			}
+			prev.ToolCalls = append(prev.ToolCalls, msg.ToolCalls...)
+			prev.Annotations = append(prev.Annotations, msg.Annotations...)
+			report.MessagesMerged++
+			continue
+		}
+		merged = append(merged, entry)
+	}
+	return merged
+}
+
+// MigrateConversation rewrites a stored conversation for the target provider and saves it
+func (m *MemoryManager) MigrateConversation(ctx context.Context, sessionID string, target ProviderName) (*MigrationReport, error) {
+	conversation, err := m.LoadConversation(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	conversation.alignMetadata()
+	entries := make([]MessageWithMetadata, len(conversation.Messages))
+	for i, msg := range conversation.Messages {
+		entries[i] = MessageWithMetadata{Message: msg, Metadata: conversation.MessageMetadata[i]}
+	}
+	migrated, report, err := migrateEntries(entries, target)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation.Messages = make([]Message, len(migrated))
+	conversation.MessageMetadata = make([]MessageMetadata, len(migrated))
+	for i, entry := range migrated {
+		conversation.Messages[i] = entry.Message
+		conversation.MessageMetadata[i] = entry.Metadata
+	}
+	if conversation.Metadata == nil {
+		conversation.Metadata = make(map[string]any)
+	}
+	conversation.Metadata["migrated_to"] = string(target)
+
+	if err := m.SaveConversation(ctx, conversation); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// MigrateConversation rewrites a stored conversation so it can be continued on the target provider
+func (c *ChatClient) MigrateConversation(ctx context.Context, sessionID string, target ProviderName) (*MigrationReport, error) {
+	if !c.HasMemory() {
+		return nil, fmt.Errorf("memory not configured")
+	}
+	return c.memory.MigrateConversation(ctx, sessionID, target)
+}
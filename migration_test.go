@@ -0,0 +1,231 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func openAIToolConversation() []provider.Message {
+	return []provider.Message{
+		{Role: provider.RoleSystem, Content: "You are helpful"},
+		{Role: provider.RoleUser, Content: "What's the weather in Tokyo?"},
+		{
+			Role: provider.RoleAssistant,
+			ToolCalls: []provider.ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"location":"Tokyo"}`},
+			}},
+		},
+		{Role: provider.RoleTool, Content: "Sunny, 25C", ToolCallID: stringPtr("call_1")},
+		{Role: provider.RoleAssistant, Content: "It's sunny and 25C in Tokyo."},
+		{Role: provider.RoleSystem, Content: "Answer briefly"},
+		{Role: provider.RoleUser, Content: "And tomorrow?"},
+	}
+}
+
+func TestMigrateMessages_ToBedrock(t *testing.T) {
+	original := openAIToolConversation()
+	migrated, report, err := MigrateMessages(original, ProviderNameBedrock)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+
+	if migrated[0].Role != provider.RoleSystem || migrated[0].Content != "You are helpful\n\nAnswer briefly" {
+		t.Errorf("system message = %+v", migrated[0])
+	}
+	for i, msg := range migrated[1:] {
+		if msg.Role == provider.RoleTool || len(msg.ToolCalls) > 0 {
+			t.Errorf("message %d still has tool artifacts: %+v", i+1, msg)
+		}
+		if i > 0 && msg.Role == migrated[i].Role {
+			t.Errorf("messages %d and %d share role %s", i, i+1, msg.Role)
+		}
+	}
+
+	if report.SystemMessagesMerged != 2 || report.ToolCallsFlattened != 1 || report.ToolResultsConverted != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if !report.Changed() {
+		t.Error("Changed() = false, want true")
+	}
+
+	// Input must not be modified
+	if len(original[2].ToolCalls) != 1 {
+		t.Error("original messages were modified")
+	}
+
+	var all strings.Builder
+	for _, msg := range migrated {
+		all.WriteString(msg.Content)
+	}
+	if !strings.Contains(all.String(), "[Called tool get_weather") || !strings.Contains(all.String(), "[Tool result from get_weather]: Sunny") {
+		t.Errorf("tool content not flattened: %s", all.String())
+	}
+}
+
+func TestMigrateMessages_ToAnthropicKeepsTools(t *testing.T) {
+	original := openAIToolConversation()
+	migrated, report, err := MigrateMessages(original, ProviderNameAnthropic)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+
+	if migrated[0].Role != provider.RoleSystem || migrated[0].Content != "You are helpful\n\nAnswer briefly" {
+		t.Errorf("system message = %+v", migrated[0])
+	}
+	if report.SystemMessagesMerged != 2 || report.ToolCallsFlattened != 0 || report.ToolResultsConverted != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(migrated) != len(original)-1 {
+		t.Fatalf("message count = %d, want %d", len(migrated), len(original)-1)
+	}
+	if len(migrated[2].ToolCalls) != 1 || migrated[3].Role != provider.RoleTool || *migrated[3].ToolCallID != "call_1" {
+		t.Errorf("tool call and result not kept: %+v", migrated[2:4])
+	}
+}
+
+func TestMigrateMessages_ToGeminiKeepsTools(t *testing.T) {
+	migrated, report, err := MigrateMessages(openAIToolConversation(), ProviderNameGemini)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+	if report.ToolCallsFlattened != 0 || report.ToolResultsConverted != 0 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(migrated[2].ToolCalls) != 1 || migrated[3].Role != provider.RoleTool {
+		t.Errorf("tool call and result not kept: %+v", migrated[2:4])
+	}
+}
+
+func TestMigrateMessages_ParallelToolResultsNotMerged(t *testing.T) {
+	messages := []provider.Message{
+		{Role: provider.RoleUser, Content: "Weather in Tokyo and Paris?"},
+		{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{
+			{ID: "call_1", Type: "function", Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"location":"Tokyo"}`}},
+			{ID: "call_2", Type: "function", Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"location":"Paris"}`}},
+		}},
+		{Role: provider.RoleTool, Content: "Sunny", ToolCallID: stringPtr("call_1")},
+		{Role: provider.RoleTool, Content: "Rain", ToolCallID: stringPtr("call_2")},
+	}
+	migrated, report, err := MigrateMessages(messages, ProviderNameAnthropic)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+	if len(migrated) != 4 || report.MessagesMerged != 0 {
+		t.Errorf("tool results were merged: %+v", migrated)
+	}
+}
+
+func TestMigrateMessages_ToOpenAIKeepsTools(t *testing.T) {
+	migrated, report, err := MigrateMessages(openAIToolConversation(), ProviderNameOpenAI)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+	if len(migrated) != len(openAIToolConversation()) {
+		t.Errorf("message count = %d, want unchanged", len(migrated))
+	}
+	if report.Changed() {
+		t.Errorf("report = %+v, want no changes", report)
+	}
+}
+
+func TestMigrateMessages_OrphanToolResult(t *testing.T) {
+	messages := []provider.Message{
+		{Role: provider.RoleUser, Content: "hi"},
+		{Role: provider.RoleTool, Content: "result", ToolCallID: stringPtr("missing")},
+	}
+	migrated, report, err := MigrateMessages(messages, ProviderNameOpenAI)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+	if migrated[1].Role != provider.RoleUser || report.ToolResultsConverted != 1 {
+		t.Errorf("orphan tool result not converted: %+v", migrated[1])
+	}
+}
+
+func TestMigrateMessages_PlaceholderForAssistantFirst(t *testing.T) {
+	migrated, report, err := MigrateMessages([]provider.Message{
+		{Role: provider.RoleAssistant, Content: "Welcome back"},
+	}, ProviderNameGemini)
+	if err != nil {
+		t.Fatalf("MigrateMessages failed: %v", err)
+	}
+	if !report.PlaceholderInserted || migrated[0].Role != provider.RoleUser {
+		t.Errorf("expected leading user placeholder, got %+v", migrated)
+	}
+}
+
+func TestMigrateMessages_UnsupportedProvider(t *testing.T) {
+	_, _, err := MigrateMessages(nil, "unknown")
+	if !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("error = %v, want ErrUnsupportedProvider", err)
+	}
+}
+
+func TestMemoryManager_MigrateConversation(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), DefaultMemoryConfig())
+	ctx := context.Background()
+
+	if err := mm.AppendMessages(ctx, "session1", openAIToolConversation()); err != nil {
+		t.Fatalf("AppendMessages failed: %v", err)
+	}
+
+	report, err := mm.MigrateConversation(ctx, "session1", ProviderNameBedrock)
+	if err != nil {
+		t.Fatalf("MigrateConversation failed: %v", err)
+	}
+	if !report.Changed() {
+		t.Error("expected conversation to be rewritten")
+	}
+
+	conv, err := mm.LoadConversation(ctx, "session1")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if conv.Metadata["migrated_to"] != "bedrock" {
+		t.Errorf("migrated_to = %v, want bedrock", conv.Metadata["migrated_to"])
+	}
+	for _, msg := range conv.Messages {
+		if msg.Role == provider.RoleTool {
+			t.Error("stored conversation still contains tool messages")
+		}
+	}
+}
+
+func TestMemoryManager_MigrateConversationKeepsMetadataAligned(t *testing.T) {
+	mm := NewMemoryManager(mocktest.NewMockKVS(), DefaultMemoryConfig())
+	ctx := context.Background()
+
+	err := mm.AppendMessagesWithMetadata(ctx, "session1", []MessageWithMetadata{
+		{Message: provider.Message{Role: provider.RoleUser, Content: "First"}, Metadata: MessageMetadata{Tags: []string{"first"}}},
+		{Message: provider.Message{Role: provider.RoleUser, Content: "Second"}, Metadata: MessageMetadata{Tags: []string{"second"}}},
+		{Message: provider.Message{Role: provider.RoleAssistant, Content: "Answer"}, Metadata: MessageMetadata{Model: "gpt-4o"}},
+	})
+	if err != nil {
+		t.Fatalf("AppendMessagesWithMetadata failed: %v", err)
+	}
+
+	if _, err := mm.MigrateConversation(ctx, "session1", ProviderNameAnthropic); err != nil {
+		t.Fatalf("MigrateConversation failed: %v", err)
+	}
+
+	conv, err := mm.LoadConversation(ctx, "session1")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	if len(conv.Messages) != 2 || len(conv.MessageMetadata) != 2 {
+		t.Fatalf("got %d messages and %d metadata entries, want 2 of each", len(conv.Messages), len(conv.MessageMetadata))
+	}
+	if got := conv.MessageMetadata[0].Tags; len(got) != 1 || got[0] != "first" {
+		t.Errorf("merged user message tags = %v, want [first]", got)
+	}
+	if conv.Messages[1].Role != provider.RoleAssistant || conv.MessageMetadata[1].Model != "gpt-4o" {
+		t.Errorf("assistant metadata = %+v, want model gpt-4o", conv.MessageMetadata[1])
+	}
+}
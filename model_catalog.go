@@ -0,0 +1,151 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ModelSpec describes a model's limits, capabilities, and list pricing
+type ModelSpec struct {
+	ID              string                     `json:"id"`
+	Provider        ProviderName               `json:"provider"`
+	Name            string                     `json:"name,omitempty"`
+	ContextWindow   int                        `json:"context_window,omitempty"`    // Max input tokens
+	MaxOutputTokens int                        `json:"max_output_tokens,omitempty"` // Max generated tokens
+	Capabilities    provider.ModelCapabilities `json:"capabilities"`
+	Pricing         *ModelPricing              `json:"pricing,omitempty"` // Nil for local or unpriced models
+	Deprecated      bool                       `json:"deprecated,omitempty"`
+}
+
+// ModelPricing is a model's list price in USD per million tokens
+type ModelPricing struct {
+	InputPerMTok       float64 `json:"input_per_mtok"`
+	OutputPerMTok      float64 `json:"output_per_mtok"`
+	CachedInputPerMTok float64 `json:"cached_input_per_mtok,omitempty"` // Prompt cache reads; InputPerMTok if zero
+}
+
+// Cost returns the list price in USD of the given usage. Cached prompt tokens
+// are billed at CachedInputPerMTok and all other prompt tokens at InputPerMTok.
+func (p ModelPricing) Cost(usage provider.Usage) float64 {
+	input := usage.PromptTokens
+	var cached int
+	if d := usage.PromptTokensDetails; d != nil {
+		cached = d.CachedTokens
+		input -= cached
+	}
+	cachedRate := p.CachedInputPerMTok
+	if cachedRate == 0 {
+		cachedRate = p.InputPerMTok
+	}
+	return (float64(input)*p.InputPerMTok +
+		float64(cached)*cachedRate +
+		float64(usage.CompletionTokens)*p.OutputPerMTok) / 1e6
+}
+
+// ModelCatalog maps model IDs to their specs. It starts from the built-in
+// specs for the models in the models package and can be extended with
+// Register or refreshed at runtime from a JSON source with Refresh.
+// A ModelCatalog is safe for concurrent use.
+type ModelCatalog struct {
+	mu    sync.RWMutex
+	specs map[string]ModelSpec
+}
+
+// NewModelCatalog creates a catalog holding the given specs. Use
+// DefaultModelCatalog for the shared catalog with the built-in specs.
+func NewModelCatalog(specs ...ModelSpec) *ModelCatalog {
+	c := &ModelCatalog{specs: make(map[string]ModelSpec, len(specs))}
+	c.Register(specs...)
+	return c
+}
+
+var defaultModelCatalog = NewModelCatalog(builtinModelSpecs()...)
+
+// DefaultModelCatalog returns the shared catalog used by GetModelInfo, the
+// default token estimator, and ListModels. Changes to it are visible to all
+// of them.
+func DefaultModelCatalog() *ModelCatalog {
+	return defaultModelCatalog
+}
+
+// Lookup returns the spec for a model ID
+func (c *ModelCatalog) Lookup(modelID string) (ModelSpec, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	spec, ok := c.specs[modelID]
+	return spec, ok
+}
+
+// Register adds specs to the catalog, replacing any with the same ID.
+// Specs without an ID are ignored.
+func (c *ModelCatalog) Register(specs ...ModelSpec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, spec := range specs {
+		if spec.ID == "" {
+			continue
+		}
+		c.specs[spec.ID] = spec
+	}
+}
+
+// Models returns all specs in the catalog, sorted by ID
+func (c *ModelCatalog) Models() []ModelSpec {
+	c.mu.RLock()
+	specs := make([]ModelSpec, 0, len(c.specs))
+	for _, spec := range c.specs {
+		specs = append(specs, spec)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(specs, func(i, j int) bool { return specs[i].ID < specs[j].ID })
+	return specs
+}
+
+// ContextWindow returns the context window of a model, or 0 if it is unknown
+func (c *ModelCatalog) ContextWindow(modelID string) int {
+	spec, _ := c.Lookup(modelID)
+	return spec.ContextWindow
+}
+
+// LoadJSON reads a JSON array of ModelSpec and registers each spec, replacing
+// existing specs with the same ID. Models not in the input are kept.
+func (c *ModelCatalog) LoadJSON(r io.Reader) error {
+	var specs []ModelSpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return fmt.Errorf("failed to decode model catalog: %w", err)
+	}
+	c.Register(specs...)
+	return nil
+}
+
+// Refresh fetches a JSON array of ModelSpec from url and loads it with
+// LoadJSON. If httpClient is nil, http.DefaultClient is used. On error the
+// catalog is left unchanged.
+func (c *ModelCatalog) Refresh(ctx context.Context, httpClient *http.Client, url string) error {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create model catalog request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch model catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch model catalog: status %d", resp.StatusCode)
+	}
+	return c.LoadJSON(resp.Body)
+}
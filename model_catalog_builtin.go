@@ -0,0 +1,102 @@
+package omnillm
+
+import (
+	"github.com/plexusone/omnillm/models"
+	"github.com/plexusone/omnillm/provider"
+)
+
+// chatCapabilities returns the capabilities of a streaming chat model
+func chatCapabilities(tools, vision, jsonMode bool) provider.ModelCapabilities {
+	return provider.ModelCapabilities{
+		Chat:      true,
+		Streaming: true,
+		Tools:     tools,
+		Vision:    vision,
+		JSONMode:  jsonMode,
+	}
+}
+
+// pricing returns list pricing in USD per million tokens
+func pricing(input, output, cachedInput float64) *ModelPricing {
+	return &ModelPricing{InputPerMTok: input, OutputPerMTok: output, CachedInputPerMTok: cachedInput}
+}
+
+// builtinModelSpecs returns the specs for the models in the models package.
+// Prices are list prices at the time of writing; use ModelCatalog.Refresh to
+// keep them current.
+func builtinModelSpecs() []ModelSpec {
+	openai := chatCapabilities(true, true, true)
+	claude := chatCapabilities(true, true, false)
+	gemini := chatCapabilities(true, true, true)
+	grok := chatCapabilities(true, false, true)
+	grokVision := chatCapabilities(true, true, true)
+	local := chatCapabilities(false, false, true)
+	localTools := chatCapabilities(true, false, true)
+
+	return []ModelSpec{
+		// OpenAI
+		{ID: models.GPT5, Provider: ProviderNameOpenAI, Name: "GPT-5", ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: openai, Pricing: pricing(1.25, 10, 0.125)},
+		{ID: models.GPT5Mini, Provider: ProviderNameOpenAI, Name: "GPT-5 Mini", ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: openai, Pricing: pricing(0.25, 2, 0.025)},
+		{ID: models.GPT5Nano, Provider: ProviderNameOpenAI, Name: "GPT-5 Nano", ContextWindow: 400000, MaxOutputTokens: 128000, Capabilities: openai, Pricing: pricing(0.05, 0.4, 0.005)},
+		{ID: models.GPT5ChatLatest, Provider: ProviderNameOpenAI, Name: "GPT-5 Chat", ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: openai, Pricing: pricing(1.25, 10, 0.125)},
+		{ID: models.GPT4_1, Provider: ProviderNameOpenAI, Name: "GPT-4.1", ContextWindow: 1047576, MaxOutputTokens: 32768, Capabilities: openai, Pricing: pricing(2, 8, 0.5)},
+		{ID: models.GPT4_1Mini, Provider: ProviderNameOpenAI, Name: "GPT-4.1 Mini", ContextWindow: 1047576, MaxOutputTokens: 32768, Capabilities: openai, Pricing: pricing(0.4, 1.6, 0.1)},
+		{ID: models.GPT4_1Nano, Provider: ProviderNameOpenAI, Name: "GPT-4.1 Nano", ContextWindow: 1047576, MaxOutputTokens: 32768, Capabilities: openai, Pricing: pricing(0.1, 0.4, 0.025)},
+		{ID: models.GPT4o, Provider: ProviderNameOpenAI, Name: "GPT-4o", ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: openai, Pricing: pricing(2.5, 10, 1.25)},
+		{ID: models.GPT4oMini, Provider: ProviderNameOpenAI, Name: "GPT-4o Mini", ContextWindow: 128000, MaxOutputTokens: 16384, Capabilities: openai, Pricing: pricing(0.15, 0.6, 0.075)},
+		{ID: models.GPT4Turbo, Provider: ProviderNameOpenAI, Name: "GPT-4 Turbo", ContextWindow: 128000, MaxOutputTokens: 4096, Capabilities: openai, Pricing: pricing(10, 30, 0)},
+		{ID: models.GPT35Turbo, Provider: ProviderNameOpenAI, Name: "GPT-3.5 Turbo", ContextWindow: 16385, MaxOutputTokens: 4096, Capabilities: chatCapabilities(true, false, true), Pricing: pricing(0.5, 1.5, 0)},
+
+		// Anthropic
+		{ID: models.ClaudeOpus4_5, Provider: ProviderNameAnthropic, Name: "Claude Opus 4.5", ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: claude, Pricing: pricing(5, 25, 0.5)},
+		{ID: models.ClaudeSonnet4_5, Provider: ProviderNameAnthropic, Name: "Claude Sonnet 4.5", ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: claude, Pricing: pricing(3, 15, 0.3)},
+		{ID: models.ClaudeHaiku4_5, Provider: ProviderNameAnthropic, Name: "Claude Haiku 4.5", ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: claude, Pricing: pricing(1, 5, 0.1)},
+		{ID: models.ClaudeOpus4_1, Provider: ProviderNameAnthropic, Name: "Claude Opus 4.1", ContextWindow: 200000, MaxOutputTokens: 32000, Capabilities: claude, Pricing: pricing(15, 75, 1.5)},
+		{ID: models.ClaudeOpus4, Provider: ProviderNameAnthropic, Name: "Claude Opus 4", ContextWindow: 200000, MaxOutputTokens: 32000, Capabilities: claude, Pricing: pricing(15, 75, 1.5)},
+		{ID: models.ClaudeSonnet4, Provider: ProviderNameAnthropic, Name: "Claude Sonnet 4", ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: claude, Pricing: pricing(3, 15, 0.3)},
+		{ID: models.Claude3_7Sonnet, Provider: ProviderNameAnthropic, Name: "Claude 3.7 Sonnet", ContextWindow: 200000, MaxOutputTokens: 64000, Capabilities: claude, Pricing: pricing(3, 15, 0.3)},
+		{ID: models.Claude3_5Haiku, Provider: ProviderNameAnthropic, Name: "Claude 3.5 Haiku", ContextWindow: 200000, MaxOutputTokens: 8192, Capabilities: chatCapabilities(true, false, false), Pricing: pricing(0.8, 4, 0.08)},
+		{ID: models.Claude3Opus, Provider: ProviderNameAnthropic, Name: "Claude 3 Opus", ContextWindow: 200000, MaxOutputTokens: 4096, Capabilities: claude, Pricing: pricing(15, 75, 1.5), Deprecated: true},
+		{ID: models.Claude3Sonnet, Provider: ProviderNameAnthropic, Name: "Claude 3 Sonnet", ContextWindow: 200000, MaxOutputTokens: 4096, Capabilities: claude, Pricing: pricing(3, 15, 0.3), Deprecated: true},
+		{ID: models.Claude3Haiku, Provider: ProviderNameAnthropic, Name: "Claude 3 Haiku", ContextWindow: 200000, MaxOutputTokens: 4096, Capabilities: claude, Pricing: pricing(0.25, 1.25, 0.03)},
+
+		// Google Gemini
+		{ID: models.Gemini2_5Pro, Provider: ProviderNameGemini, Name: "Gemini 2.5 Pro", ContextWindow: 1000000, MaxOutputTokens: 65536, Capabilities: gemini, Pricing: pricing(1.25, 10, 0)},
+		{ID: models.Gemini2_5Flash, Provider: ProviderNameGemini, Name: "Gemini 2.5 Flash", ContextWindow: 1000000, MaxOutputTokens: 65536, Capabilities: gemini, Pricing: pricing(0.3, 2.5, 0)},
+		{ID: models.Gemini1_5Pro, Provider: ProviderNameGemini, Name: "Gemini 1.5 Pro", ContextWindow: 2000000, MaxOutputTokens: 8192, Capabilities: gemini, Pricing: pricing(1.25, 5, 0), Deprecated: true},
+		{ID: models.Gemini1_5Flash, Provider: ProviderNameGemini, Name: "Gemini 1.5 Flash", ContextWindow: 1000000, MaxOutputTokens: 8192, Capabilities: gemini, Pricing: pricing(0.075, 0.3, 0), Deprecated: true},
+		{ID: models.GeminiPro, Provider: ProviderNameGemini, Name: "Gemini Pro", ContextWindow: 32768, MaxOutputTokens: 8192, Capabilities: chatCapabilities(true, false, false), Deprecated: true},
+
+		// X.AI Grok
+		{ID: models.Grok4_1FastReasoning, Provider: ProviderNameXAI, Name: "Grok 4.1 Fast Reasoning", ContextWindow: 2000000, MaxOutputTokens: 30000, Capabilities: grokVision, Pricing: pricing(0.2, 0.5, 0.05)},
+		{ID: models.Grok4_1FastNonReasoning, Provider: ProviderNameXAI, Name: "Grok 4.1 Fast", ContextWindow: 2000000, MaxOutputTokens: 30000, Capabilities: grokVision, Pricing: pricing(0.2, 0.5, 0.05)},
+		{ID: models.Grok4_0709, Provider: ProviderNameXAI, Name: "Grok 4", ContextWindow: 256000, Capabilities: grokVision, Pricing: pricing(3, 15, 0.75)},
+		{ID: models.Grok4FastReasoning, Provider: ProviderNameXAI, Name: "Grok 4 Fast Reasoning", ContextWindow: 2000000, MaxOutputTokens: 30000, Capabilities: grokVision, Pricing: pricing(0.2, 0.5, 0.05)},
+		{ID: models.Grok4FastNonReasoning, Provider: ProviderNameXAI, Name: "Grok 4 Fast", ContextWindow: 2000000, MaxOutputTokens: 30000, Capabilities: grokVision, Pricing: pricing(0.2, 0.5, 0.05)},
+		{ID: models.GrokCodeFast1, Provider: ProviderNameXAI, Name: "Grok Code Fast 1", ContextWindow: 256000, Capabilities: grok, Pricing: pricing(0.2, 1.5, 0.02)},
+		{ID: models.Grok3, Provider: ProviderNameXAI, Name: "Grok 3", ContextWindow: 131072, Capabilities: grok, Pricing: pricing(3, 15, 0.75)},
+		{ID: models.Grok3Mini, Provider: ProviderNameXAI, Name: "Grok 3 Mini", ContextWindow: 131072, Capabilities: grok, Pricing: pricing(0.3, 0.5, 0.075)},
+		{ID: models.Grok2_1212, Provider: ProviderNameXAI, Name: "Grok 2", ContextWindow: 131072, Capabilities: grok, Pricing: pricing(2, 10, 0)},
+		{ID: models.Grok2_Vision, Provider: ProviderNameXAI, Name: "Grok 2 Vision", ContextWindow: 32768, Capabilities: grokVision, Pricing: pricing(2, 10, 0)},
+		{ID: models.GrokBeta, Provider: ProviderNameXAI, Name: "Grok Beta", ContextWindow: 131072, Capabilities: grok, Deprecated: true},
+		{ID: models.GrokVision, Provider: ProviderNameXAI, Name: "Grok Vision Beta", ContextWindow: 8192, Capabilities: grokVision, Deprecated: true},
+
+		// Ollama (local, unpriced)
+		{ID: models.OllamaLlama3_8B, Provider: ProviderNameOllama, Name: "Llama 3 8B", ContextWindow: 8192, Capabilities: local},
+		{ID: models.OllamaLlama3_70B, Provider: ProviderNameOllama, Name: "Llama 3 70B", ContextWindow: 8192, Capabilities: local},
+		{ID: models.OllamaMistral7B, Provider: ProviderNameOllama, Name: "Mistral 7B", ContextWindow: 32768, Capabilities: localTools},
+		{ID: models.OllamaMixtral8x7B, Provider: ProviderNameOllama, Name: "Mixtral 8x7B", ContextWindow: 32768, Capabilities: localTools},
+		{ID: models.OllamaCodeLlama, Provider: ProviderNameOllama, Name: "CodeLlama 13B", ContextWindow: 16384, Capabilities: local},
+		{ID: models.OllamaDeepSeek, Provider: ProviderNameOllama, Name: "DeepSeek Coder 6.7B", ContextWindow: 16384, Capabilities: local},
+		{ID: models.OllamaGemma2B, Provider: ProviderNameOllama, Name: "Gemma 2B", ContextWindow: 8192, Capabilities: local},
+		{ID: models.OllamaGemma7B, Provider: ProviderNameOllama, Name: "Gemma 7B", ContextWindow: 8192, Capabilities: local},
+		{ID: models.OllamaQwen2_5, Provider: ProviderNameOllama, Name: "Qwen 2.5 7B", ContextWindow: 32768, Capabilities: localTools},
+
+		// AWS Bedrock and Vertex AI
+		{ID: models.BedrockClaudeOpus4, Provider: ProviderNameBedrock, Name: "Claude Opus 4 (Bedrock)", ContextWindow: 200000, MaxOutputTokens: 32000, Capabilities: claude, Pricing: pricing(15, 75, 1.5)},
+		{ID: models.BedrockClaude3Opus, Provider: ProviderNameBedrock, Name: "Claude 3 Opus (Bedrock)", ContextWindow: 200000, MaxOutputTokens: 4096, Capabilities: claude, Pricing: pricing(15, 75, 0)},
+		{ID: models.BedrockClaude3Sonnet, Provider: ProviderNameBedrock, Name: "Claude 3 Sonnet (Bedrock)", ContextWindow: 200000, MaxOutputTokens: 4096, Capabilities: claude, Pricing: pricing(3, 15, 0)},
+		{ID: models.BedrockTitan, Provider: ProviderNameBedrock, Name: "Titan Text Express (Bedrock)", ContextWindow: 8192, MaxOutputTokens: 8192, Capabilities: chatCapabilities(false, false, false), Pricing: pricing(0.2, 0.6, 0)},
+		{ID: models.VertexClaudeOpus4, Provider: ProviderNameAnthropic, Name: "Claude Opus 4 (Vertex AI)", ContextWindow: 200000, MaxOutputTokens: 32000, Capabilities: claude, Pricing: pricing(15, 75, 1.5)},
+	}
+}
@@ -0,0 +1,131 @@
+package omnillm
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestDefaultModelCatalog_Builtin(t *testing.T) {
+	spec, ok := DefaultModelCatalog().Lookup(ModelGPT4o)
+	if !ok {
+		t.Fatal("expected gpt-4o in the default catalog")
+	}
+	if spec.Provider != ProviderNameOpenAI || spec.ContextWindow != 128000 {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+	if !spec.Capabilities.Vision || !spec.Capabilities.Tools || !spec.Capabilities.JSONMode {
+		t.Errorf("expected vision, tools, and JSON mode, got %+v", spec.Capabilities)
+	}
+	if spec.Pricing == nil || spec.MaxOutputTokens == 0 {
+		t.Errorf("expected pricing and max output tokens, got %+v", spec)
+	}
+
+	if _, ok := DefaultModelCatalog().Lookup(ModelOllamaLlama3_8B); !ok {
+		t.Error("expected Ollama models in the default catalog")
+	}
+}
+
+func TestModelCatalog_RegisterAndModels(t *testing.T) {
+	catalog := NewModelCatalog(ModelSpec{ID: "b", ContextWindow: 1000})
+	catalog.Register(
+		ModelSpec{ID: "a", ContextWindow: 2000},
+		ModelSpec{ID: "b", ContextWindow: 3000},
+		ModelSpec{ContextWindow: 4000},
+	)
+
+	specs := catalog.Models()
+	if len(specs) != 2 || specs[0].ID != "a" || specs[1].ID != "b" {
+		t.Fatalf("expected [a b], got %+v", specs)
+	}
+	if window := catalog.ContextWindow("b"); window != 3000 {
+		t.Errorf("expected replaced context window 3000, got %d", window)
+	}
+	if window := catalog.ContextWindow("missing"); window != 0 {
+		t.Errorf("expected 0 for unknown model, got %d", window)
+	}
+}
+
+func TestModelCatalog_LoadJSON(t *testing.T) {
+	catalog := NewModelCatalog(ModelSpec{ID: "kept", ContextWindow: 1000})
+
+	err := catalog.LoadJSON(strings.NewReader(`[
+		{"id": "new-model", "provider": "openai", "context_window": 64000,
+		 "capabilities": {"chat": true, "tools": true},
+		 "pricing": {"input_per_mtok": 1, "output_per_mtok": 2}}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+
+	spec, ok := catalog.Lookup("new-model")
+	if !ok || spec.ContextWindow != 64000 || !spec.Capabilities.Tools || spec.Pricing.OutputPerMTok != 2 {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+	if _, ok := catalog.Lookup("kept"); !ok {
+		t.Error("expected existing spec to be kept")
+	}
+
+	if err := catalog.LoadJSON(strings.NewReader(`{`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestModelCatalog_Refresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id": "remote-model", "provider": "xai", "context_window": 256000}]`))
+	}))
+	defer server.Close()
+
+	catalog := NewModelCatalog()
+	if err := catalog.Refresh(context.Background(), nil, server.URL+"/models.json"); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if window := catalog.ContextWindow("remote-model"); window != 256000 {
+		t.Errorf("expected context window 256000, got %d", window)
+	}
+
+	if err := catalog.Refresh(context.Background(), server.Client(), server.URL+"/missing"); err == nil {
+		t.Error("expected error for non-200 status")
+	}
+}
+
+func TestModelPricing_Cost(t *testing.T) {
+	p := ModelPricing{InputPerMTok: 2, OutputPerMTok: 8, CachedInputPerMTok: 0.5}
+	usage := provider.Usage{
+		PromptTokens:        1_000_000,
+		CompletionTokens:    500_000,
+		PromptTokensDetails: &provider.PromptTokensDetails{CachedTokens: 200_000},
+	}
+
+	// 800k uncached at $2 + 200k cached at $0.50 + 500k output at $8
+	if cost := p.Cost(usage); math.Abs(cost-5.7) > 1e-9 {
+		t.Errorf("expected cost 5.7, got %f", cost)
+	}
+
+	p.CachedInputPerMTok = 0
+	if cost := p.Cost(usage); math.Abs(cost-6.0) > 1e-9 {
+		t.Errorf("expected cached tokens at the input rate (6.0), got %f", cost)
+	}
+}
+
+func TestTokenEstimator_Catalog(t *testing.T) {
+	catalog := NewModelCatalog(ModelSpec{ID: "catalog-model", ContextWindow: 12345})
+	estimator := NewTokenEstimator(TokenEstimatorConfig{Catalog: catalog})
+
+	if window := estimator.GetContextWindow("catalog-model"); window != 12345 {
+		t.Errorf("expected context window from catalog, got %d", window)
+	}
+	if window := estimator.GetContextWindow("llama3"); window != 8192 {
+		t.Errorf("expected built-in alias fallback 8192, got %d", window)
+	}
+}
@@ -0,0 +1,53 @@
+package omnillm
+
+import (
+	"context"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// modelMapProvider rewrites the request model before passing it on
+type modelMapProvider struct {
+	provider.Provider
+	modelMap     map[string]string
+	defaultModel string
+}
+
+// NewModelMapProvider wraps p so that request models are translated to p's
+// own models. A model found in modelMap is replaced by its mapping; any
+// other model is replaced by defaultModel, if set, or left as-is. NewClient
+// applies ProviderConfig.ModelMap and DefaultModel this way, so a request
+// for "gpt-4o" that falls over to Anthropic asks for an Anthropic model.
+// If modelMap is empty and defaultModel is "", p is returned unchanged.
+func NewModelMapProvider(p provider.Provider, modelMap map[string]string, defaultModel string) provider.Provider {
+	if len(modelMap) == 0 && defaultModel == "" {
+		return p
+	}
+	return &modelMapProvider{Provider: p, modelMap: modelMap, defaultModel: defaultModel}
+}
+
+func (p *modelMapProvider) unwrapProvider() provider.Provider {
+	return p.Provider
+}
+
+// mapModel returns req with its model translated, or req itself if unchanged
+func (p *modelMapProvider) mapModel(req *provider.ChatCompletionRequest) *provider.ChatCompletionRequest {
+	model, ok := p.modelMap[req.Model]
+	if !ok {
+		model = p.defaultModel
+	}
+	if model == "" || model == req.Model {
+		return req
+	}
+	mapped := *req
+	mapped.Model = model
+	return &mapped
+}
+
+func (p *modelMapProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	return p.Provider.CreateChatCompletion(ctx, p.mapModel(req))
+}
+
+func (p *modelMapProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	return p.Provider.CreateChatCompletionStream(ctx, p.mapModel(req))
+}
@@ -0,0 +1,22 @@
+package models
+
+// Hugging Face Model Documentation
+const (
+	// HuggingFaceModelsURL lists the models served by Inference Providers.
+	// Use this to check which models support chat completion.
+	HuggingFaceModelsURL = "https://huggingface.co/models?inference_provider=all&pipeline_tag=text-generation"
+
+	// HuggingFaceAPIURL is the Inference Providers API reference page.
+	HuggingFaceAPIURL = "https://huggingface.co/docs/inference-providers"
+)
+
+// Hugging Face Models. IDs are Hub repository names; append ":<provider>"
+// (e.g. ":together") to pin an inference provider, or ":fastest" or
+// ":cheapest" to choose by policy.
+const (
+	HuggingFaceLlama3_3_70B  = "meta-llama/Llama-3.3-70B-Instruct"  // Llama 3.3 70B Instruct
+	HuggingFaceLlama3_1_8B   = "meta-llama/Llama-3.1-8B-Instruct"   // Llama 3.1 8B Instruct
+	HuggingFaceQwen2_5_72B   = "Qwen/Qwen2.5-72B-Instruct"          // Qwen 2.5 72B Instruct
+	HuggingFaceDeepSeekV3    = "deepseek-ai/DeepSeek-V3"            // DeepSeek V3
+	HuggingFaceMistral7B_0_3 = "mistralai/Mistral-7B-Instruct-v0.3" // Mistral 7B Instruct v0.3
+)
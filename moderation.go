@@ -0,0 +1,108 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrModerationNotSupported is returned when no configured provider supports moderation
+var ErrModerationNotSupported = errors.New("moderation not supported by configured providers")
+
+// Common moderation category names (OpenAI taxonomy, used as the unified vocabulary)
+const (
+	ModerationCategoryHarassment            = "harassment"
+	ModerationCategoryHarassmentThreatening = "harassment/threatening"
+	ModerationCategoryHate                  = "hate"
+	ModerationCategoryHateThreatening       = "hate/threatening"
+	ModerationCategorySelfHarm              = "self-harm"
+	ModerationCategorySelfHarmIntent        = "self-harm/intent"
+	ModerationCategorySelfHarmInstructions  = "self-harm/instructions"
+	ModerationCategorySexual                = "sexual"
+	ModerationCategorySexualMinors          = "sexual/minors"
+	ModerationCategoryViolence              = "violence"
+	ModerationCategoryViolenceGraphic       = "violence/graphic"
+)
+
+// ModerationConfig configures content moderation behavior
+type ModerationConfig struct {
+	// Provider is the moderation provider to use.
+	// If nil, the first configured provider that implements
+	// provider.ModerationProvider is used.
+	Provider provider.ModerationProvider
+
+	// Model is the default moderation model used when the request does not set one.
+	Model string
+
+	// Thresholds maps category names to score thresholds.
+	// A category is flagged when its score is greater than or equal to its threshold.
+	Thresholds map[string]float64
+
+	// DefaultThreshold applies to categories without an explicit threshold.
+	// If 0, the provider's own flag is kept for those categories.
+	DefaultThreshold float64
+}
+
+// CreateModeration classifies input content using the configured moderation provider.
+// This works regardless of which chat provider is active, allowing pipelines to
+// pre-screen user input before sending it to any model.
+func (c *ChatClient) CreateModeration(ctx context.Context, req *provider.ModerationRequest) (*provider.ModerationResponse, error) {
+	if c.moderation == nil {
+		return nil, ErrModerationNotSupported
+	}
+	if len(req.Input) == 0 {
+		return nil, ErrEmptyMessages
+	}
+
+	modReq := *req
+	if modReq.Model == "" {
+		modReq.Model = c.moderationConfig.Model
+	}
+
+	resp, err := c.moderation.CreateModeration(ctx, &modReq)
+	if err != nil {
+		return nil, err
+	}
+
+	applyModerationThresholds(resp, c.moderationConfig)
+	return resp, nil
+}
+
+// HasModeration returns true if a moderation provider is available
+func (c *ChatClient) HasModeration() bool {
+	return c.moderation != nil
+}
+
+// applyModerationThresholds re-evaluates category flags using configured thresholds
+func applyModerationThresholds(resp *provider.ModerationResponse, config ModerationConfig) {
+	if len(config.Thresholds) == 0 && config.DefaultThreshold == 0 {
+		return
+	}
+
+	for i := range resp.Results {
+		result := &resp.Results[i]
+		if result.Categories == nil {
+			result.Categories = make(map[string]bool)
+		}
+
+		for category, score := range result.CategoryScores {
+			threshold, ok := config.Thresholds[category]
+			if !ok {
+				if config.DefaultThreshold == 0 {
+					continue
+				}
+				threshold = config.DefaultThreshold
+			}
+			result.Categories[category] = score >= threshold
+		}
+
+		result.Flagged = false
+		for _, flagged := range result.Categories {
+			if flagged {
+				result.Flagged = true
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockModerationProvider is a provider that also supports moderation
+type mockModerationProvider struct {
+	*MockProvider
+	lastReq *provider.ModerationRequest
+	resp    *provider.ModerationResponse
+}
+
+func (m *mockModerationProvider) CreateModeration(ctx context.Context, req *provider.ModerationRequest) (*provider.ModerationResponse, error) {
+	m.lastReq = req
+	return m.resp, nil
+}
+
+func newMockModerationProvider(name string) *mockModerationProvider {
+	return &mockModerationProvider{
+		MockProvider: NewMockProvider(name),
+		resp: &provider.ModerationResponse{
+			ID:    "modr-1",
+			Model: "mock-moderation",
+			Results: []provider.ModerationResult{
+				{
+					Flagged: false,
+					Categories: map[string]bool{
+						ModerationCategoryHate:     false,
+						ModerationCategoryViolence: false,
+					},
+					CategoryScores: map[string]float64{
+						ModerationCategoryHate:     0.2,
+						ModerationCategoryViolence: 0.6,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestChatClient_CreateModeration_NotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	if client.HasModeration() {
+		t.Error("HasModeration() = true, want false")
+	}
+
+	_, err = client.CreateModeration(context.Background(), &provider.ModerationRequest{Input: []string{"hi"}})
+	if !errors.Is(err, ErrModerationNotSupported) {
+		t.Errorf("error = %v, want ErrModerationNotSupported", err)
+	}
+}
+
+func TestChatClient_CreateModeration_FromFallbackProvider(t *testing.T) {
+	modProv := newMockModerationProvider("moderator")
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: NewMockProvider("chat")},
+			{CustomProvider: modProv},
+		},
+		ModerationConfig: &ModerationConfig{Model: "omni-moderation-latest"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	resp, err := client.CreateModeration(context.Background(), &provider.ModerationRequest{Input: []string{"hello"}})
+	if err != nil {
+		t.Fatalf("CreateModeration failed: %v", err)
+	}
+	if modProv.lastReq.Model != "omni-moderation-latest" {
+		t.Errorf("Model = %s, want omni-moderation-latest", modProv.lastReq.Model)
+	}
+	if resp.Results[0].Flagged {
+		t.Error("Flagged = true, want provider decision (false)")
+	}
+}
+
+func TestChatClient_CreateModeration_Thresholds(t *testing.T) {
+	modProv := newMockModerationProvider("moderator")
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+		ModerationConfig: &ModerationConfig{
+			Provider:   modProv,
+			Thresholds: map[string]float64{ModerationCategoryViolence: 0.5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	resp, err := client.CreateModeration(context.Background(), &provider.ModerationRequest{Input: []string{"hello"}})
+	if err != nil {
+		t.Fatalf("CreateModeration failed: %v", err)
+	}
+
+	result := resp.Results[0]
+	if !result.Flagged {
+		t.Error("Flagged = false, want true")
+	}
+	if !result.Categories[ModerationCategoryViolence] {
+		t.Error("violence category should be flagged")
+	}
+	if result.Categories[ModerationCategoryHate] {
+		t.Error("hate category should not be flagged")
+	}
+}
+
+func TestChatClient_CreateModeration_EmptyInput(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: newMockModerationProvider("moderator")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	_, err = client.CreateModeration(context.Background(), &provider.ModerationRequest{})
+	if !errors.Is(err, ErrEmptyMessages) {
+		t.Errorf("error = %v, want ErrEmptyMessages", err)
+	}
+}
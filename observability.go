@@ -43,7 +43,80 @@ type ObservabilityHook interface {
 	// The returned stream must implement the same interface as the input.
 	//
 	// Note: For streaming, AfterResponse is only called if stream creation fails.
-	// To track streaming completion timing and content, the wrapper returned here
-	// should handle Close() or detect EOF in Recv() to finalize metrics/traces.
+	// To track streaming completion timing and content, implement
+	// StreamObservabilityHook, or have the wrapper returned here handle Close()
+	// or detect EOF in Recv() to finalize metrics/traces.
 	WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream
 }
+
+// ComposeHooks combines several hooks into one so that tracing, metrics, and
+// audit logging can observe the same calls. Nil hooks are skipped.
+//
+// Hooks are nested like middleware, with the first hook outermost:
+//   - BeforeRequest runs in order, each hook receiving the context returned by the previous one.
+//   - AfterResponse runs in reverse order.
+//   - WrapStream is applied in reverse order, so the first hook's wrapper is
+//     outermost and observes each chunk after the hooks listed later.
+//
+// AfterResponse and WrapStream give each hook the context that hook returned
+// from BeforeRequest, so a hook always sees its own span even when later hooks
+// start spans of their own.
+func ComposeHooks(hooks ...ObservabilityHook) ObservabilityHook {
+	nonNil := make([]ObservabilityHook, 0, len(hooks))
+	for _, h := range hooks {
+		if h != nil {
+			nonNil = append(nonNil, h)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	}
+	return &hookChain{hooks: nonNil}
+}
+
+// hookChain is the ObservabilityHook returned by ComposeHooks
+type hookChain struct {
+	hooks []ObservabilityHook
+}
+
+// hookChainContextKey stores the per-hook contexts for a hookChain
+type hookChainContextKey struct {
+	chain *hookChain
+}
+
+// BeforeRequest calls each hook in order
+func (c *hookChain) BeforeRequest(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	contexts := make([]context.Context, len(c.hooks))
+	for i, h := range c.hooks {
+		ctx = h.BeforeRequest(ctx, info, req)
+		contexts[i] = ctx
+	}
+	return context.WithValue(ctx, hookChainContextKey{c}, contexts)
+}
+
+// hookContext returns the context hook i returned from BeforeRequest, or ctx
+// if BeforeRequest was not called through this chain
+func (c *hookChain) hookContext(ctx context.Context, i int) context.Context {
+	if contexts, ok := ctx.Value(hookChainContextKey{c}).([]context.Context); ok && i < len(contexts) {
+		return contexts[i]
+	}
+	return ctx
+}
+
+// AfterResponse calls each hook in reverse order
+func (c *hookChain) AfterResponse(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	for i := len(c.hooks) - 1; i >= 0; i-- {
+		c.hooks[i].AfterResponse(c.hookContext(ctx, i), info, req, resp, err)
+	}
+}
+
+// WrapStream applies each hook's wrapper in reverse order
+func (c *hookChain) WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	for i := len(c.hooks) - 1; i >= 0; i-- {
+		stream = c.hooks[i].WrapStream(c.hookContext(ctx, i), info, req, stream)
+	}
+	return stream
+}
@@ -0,0 +1,119 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+type hookCtxKey string
+
+// orderHook records the order of hook callbacks in a shared log
+type orderHook struct {
+	name string
+	log  *[]string
+}
+
+func (h *orderHook) BeforeRequest(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	*h.log = append(*h.log, "before:"+h.name)
+	return context.WithValue(ctx, hookCtxKey("span"), h.name)
+}
+
+func (h *orderHook) AfterResponse(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	*h.log = append(*h.log, fmt.Sprintf("after:%s(span=%v)", h.name, ctx.Value(hookCtxKey("span"))))
+}
+
+func (h *orderHook) WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	*h.log = append(*h.log, fmt.Sprintf("wrap:%s(span=%v)", h.name, ctx.Value(hookCtxKey("span"))))
+	return &orderStream{ChatCompletionStream: stream, name: h.name, log: h.log}
+}
+
+type orderStream struct {
+	provider.ChatCompletionStream
+	name string
+	log  *[]string
+}
+
+func (s *orderStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if errors.Is(err, io.EOF) {
+		*s.log = append(*s.log, "eof:"+s.name)
+	}
+	return chunk, err
+}
+
+func assertLog(t *testing.T, got, want []string) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("hook order:\n got  %v\n want %v", got, want)
+	}
+}
+
+func TestComposeHooks_Simplifies(t *testing.T) {
+	if ComposeHooks() != nil || ComposeHooks(nil, nil) != nil {
+		t.Error("expected nil for no hooks")
+	}
+	var log []string
+	h := &orderHook{name: "a", log: &log}
+	if ComposeHooks(nil, h) != h {
+		t.Error("expected a single hook to be returned unwrapped")
+	}
+}
+
+func TestClient_ObservabilityHooksOrder(t *testing.T) {
+	var log []string
+	mockProv := NewMockProvider("test")
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mockProv}},
+		ObservabilityHook: &orderHook{name: "trace", log: &log},
+		ObservabilityHooks: []ObservabilityHook{
+			&orderHook{name: "metrics", log: &log},
+			nil,
+			&orderHook{name: "audit", log: &log},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	assertLog(t, log, []string{
+		"before:trace", "before:metrics", "before:audit",
+		"after:audit(span=audit)", "after:metrics(span=metrics)", "after:trace(span=trace)",
+	})
+
+	log = nil
+	mockProv.streamChunks = textChunks("a")
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatal(err)
+	}
+	assertLog(t, log, []string{
+		"before:trace", "before:metrics", "before:audit",
+		"wrap:audit(span=audit)", "wrap:metrics(span=metrics)", "wrap:trace(span=trace)",
+		"eof:audit", "eof:metrics", "eof:trace",
+	})
+
+	log = nil
+	mockProv.streamError = errors.New("boom")
+	if _, err := client.CreateChatCompletionStream(context.Background(), req); err == nil {
+		t.Fatal("expected stream error")
+	}
+	assertLog(t, log, []string{
+		"before:trace", "before:metrics", "before:audit",
+		"after:audit(span=audit)", "after:metrics(span=metrics)", "after:trace(span=trace)",
+	})
+}
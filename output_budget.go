@@ -0,0 +1,124 @@
+package omnillm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrOutputBudgetExceeded is matched by an OutputBudgetError
+var ErrOutputBudgetExceeded = errors.New("output budget exceeded")
+
+// OutputBudget caps the output of a stream. Zero fields mean no limit.
+type OutputBudget struct {
+	// MaxTokens caps the output tokens. Tokens are estimated from the bytes
+	// of generated content, thinking, and tool call arguments, or taken from
+	// the provider's reported completion tokens when those are higher.
+	MaxTokens int
+
+	// MaxBytes caps the bytes of generated content, thinking, and tool call
+	// arguments
+	MaxBytes int
+
+	// CharactersPerToken converts bytes to estimated tokens.
+	// Default: 4.0, as for TokenEstimatorConfig
+	CharactersPerToken float64
+}
+
+// OutputBudgetError reports a stream stopped for going over its OutputBudget
+type OutputBudgetError struct {
+	// Tokens is the estimated output tokens when the stream was stopped
+	Tokens int
+
+	// Bytes is the output bytes when the stream was stopped
+	Bytes int
+
+	// Budget is the budget that was exceeded
+	Budget OutputBudget
+}
+
+func (e *OutputBudgetError) Error() string {
+	if e.Budget.MaxTokens > 0 && e.Tokens > e.Budget.MaxTokens {
+		return fmt.Sprintf("output of about %d tokens exceeds budget of %d tokens", e.Tokens, e.Budget.MaxTokens)
+	}
+	return fmt.Sprintf("output of %d bytes exceeds budget of %d bytes", e.Bytes, e.Budget.MaxBytes)
+}
+
+// Is matches ErrOutputBudgetExceeded
+func (e *OutputBudgetError) Is(target error) bool {
+	return target == ErrOutputBudgetExceeded
+}
+
+// WithOutputBudget wraps stream so a runaway generation is cut off once it
+// goes over budget. The chunk that crosses the budget is not returned:
+// Recv closes the upstream stream, which stops the provider generating and
+// billing further tokens, and returns an OutputBudgetError. Chunks already
+// returned are the caller's to keep.
+func WithOutputBudget(stream provider.ChatCompletionStream, budget OutputBudget) provider.ChatCompletionStream {
+	if budget.CharactersPerToken <= 0 {
+		budget.CharactersPerToken = 4.0
+	}
+	return &budgetStream{stream: stream, budget: budget}
+}
+
+// budgetStream enforces an OutputBudget on a stream
+type budgetStream struct {
+	stream provider.ChatCompletionStream
+	budget OutputBudget
+
+	bytes     int
+	reported  int // Completion tokens reported by the provider
+	err       error
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (s *budgetStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	chunk, err := s.stream.Recv()
+	if err != nil {
+		return chunk, err
+	}
+
+	s.bytes += outputBytes(chunk)
+	if chunk.Usage != nil {
+		s.reported = max(s.reported, chunk.Usage.CompletionTokens)
+	}
+	tokens := max(s.reported, int(float64(s.bytes)/s.budget.CharactersPerToken))
+	if (s.budget.MaxTokens > 0 && tokens > s.budget.MaxTokens) ||
+		(s.budget.MaxBytes > 0 && s.bytes > s.budget.MaxBytes) {
+		s.err = &OutputBudgetError{Tokens: tokens, Bytes: s.bytes, Budget: s.budget}
+		_ = s.Close()
+		return nil, s.err
+	}
+	return chunk, nil
+}
+
+func (s *budgetStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.stream.Close()
+	})
+	return s.closeErr
+}
+
+// outputBytes counts the generated bytes in a chunk
+func outputBytes(chunk *provider.ChatCompletionChunk) int {
+	n := 0
+	for _, choice := range chunk.Choices {
+		if choice.Delta == nil {
+			continue
+		}
+		n += len(choice.Delta.Content)
+		for _, block := range choice.Delta.Thinking {
+			n += len(block.Text)
+		}
+		for _, tc := range choice.Delta.ToolCalls {
+			n += len(tc.Function.Arguments)
+		}
+	}
+	return n
+}
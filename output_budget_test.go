@@ -0,0 +1,73 @@
+package omnillm
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestWithOutputBudget_Bytes(t *testing.T) {
+	upstream := &MockStream{chunks: textChunks("hello ", "world ", "again")}
+	stream := WithOutputBudget(upstream, OutputBudget{MaxBytes: 12})
+
+	var got string
+	var err error
+	for {
+		var chunk *provider.ChatCompletionChunk
+		chunk, err = stream.Recv()
+		if err != nil {
+			break
+		}
+		got += chunk.Choices[0].Delta.Content
+	}
+	if got != "hello world " {
+		t.Errorf("got %q, want the chunks within budget", got)
+	}
+	if !errors.Is(err, ErrOutputBudgetExceeded) {
+		t.Fatalf("got %v, want ErrOutputBudgetExceeded", err)
+	}
+	var budgetErr *OutputBudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Bytes != 17 {
+		t.Errorf("got %#v, want 17 bytes", budgetErr)
+	}
+	if !upstream.closed {
+		t.Error("upstream stream was not closed")
+	}
+	if _, err := stream.Recv(); !errors.Is(err, ErrOutputBudgetExceeded) {
+		t.Errorf("Recv after the budget got %v, want ErrOutputBudgetExceeded", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestWithOutputBudget_Tokens(t *testing.T) {
+	chunks := textChunks("abcdefgh", "abcdefgh")
+	chunks = append(chunks, &provider.ChatCompletionChunk{Usage: &provider.Usage{CompletionTokens: 50}})
+
+	// 16 bytes is about 4 tokens, within budget, but the provider reports 50
+	stream := WithOutputBudget(&MockStream{chunks: chunks}, OutputBudget{MaxTokens: 10})
+	var err error
+	for n := 0; err == nil; n++ {
+		_, err = stream.Recv()
+		if err != nil && n < 2 {
+			t.Fatalf("chunk %d: %v", n, err)
+		}
+	}
+	var budgetErr *OutputBudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Tokens != 50 {
+		t.Errorf("got %v, want an OutputBudgetError for 50 tokens", err)
+	}
+}
+
+func TestWithOutputBudget_Within(t *testing.T) {
+	stream := WithOutputBudget(&MockStream{chunks: textChunks("short")}, OutputBudget{MaxTokens: 100, MaxBytes: 100})
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,242 @@
+package omnillm
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultCompressionPrompt instructs the model used by ModelPromptCompressor
+const DefaultCompressionPrompt = "Compress the following text so it uses as few tokens as possible while keeping every fact, figure, name, and instruction a reader would need. Drop filler, repetition, and formatting. Reply with the compressed text only."
+
+// PromptCompressionConfig configures the compression of prompts before they
+// are sent. System and user messages are compressed; assistant messages, and
+// tool results unless CompressToolResults is set, are sent as they are. Set it as ClientConfig.PromptCompression, or
+// per request with WithPromptCompression.
+type PromptCompressionConfig struct {
+	// NormalizeWhitespace trims trailing whitespace, collapses runs of
+	// spaces and tabs within lines, and collapses runs of blank lines.
+	// Leading indentation and fenced code blocks are kept.
+	NormalizeWhitespace bool
+
+	// Dedup drops paragraphs that repeat one earlier in the request, such as
+	// the same document retrieved for several RAG queries
+	Dedup bool
+
+	// DedupMinChars is the shortest paragraph Dedup drops, so short lines
+	// such as headings are kept. Default: 50
+	DedupMinChars int
+
+	// Compressor compresses messages with a model, after the other steps
+	// (optional). The last message, usually the question being asked, is
+	// not compressed.
+	Compressor PromptCompressor
+
+	// CompressMinChars is the shortest message Compressor is used for.
+	// Default: 2000
+	CompressMinChars int
+
+	// CompressToolResults also compresses tool messages. They often hold
+	// JSON or other exact output, which whitespace normalization and model
+	// compression can change, so they are left alone by default.
+	CompressToolResults bool
+}
+
+func (c PromptCompressionConfig) enabled() bool {
+	return c.NormalizeWhitespace || c.Dedup || c.Compressor != nil
+}
+
+// PromptCompressionStats reports what CompressMessages saved
+type PromptCompressionStats struct {
+	// OriginalChars and CompressedChars are the bytes of message content
+	// before and after compression
+	OriginalChars   int
+	CompressedChars int
+
+	// DroppedParagraphs is the number of duplicate paragraphs removed
+	DroppedParagraphs int
+
+	// ModelCompressed is the number of messages compressed by the Compressor
+	ModelCompressed int
+}
+
+// PromptCompressor shortens the text of a message
+type PromptCompressor interface {
+	CompressPrompt(ctx context.Context, text string) (string, error)
+}
+
+// PromptCompressorFunc adapts a function to a PromptCompressor
+type PromptCompressorFunc func(ctx context.Context, text string) (string, error)
+
+// CompressPrompt calls f(ctx, text)
+func (f PromptCompressorFunc) CompressPrompt(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
+
+// ModelPromptCompressor returns a PromptCompressor that asks model, usually a
+// small and cheap one, to compress text in the style of LLMLingua. Its
+// requests are sent without prompt compression.
+func ModelPromptCompressor(client *ChatClient, model string) PromptCompressor {
+	return PromptCompressorFunc(func(ctx context.Context, text string) (string, error) {
+		ctx = WithPromptCompression(ctx, PromptCompressionConfig{})
+		resp, err := client.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+			Model: model,
+			Messages: []provider.Message{
+				{Role: provider.RoleSystem, Content: DefaultCompressionPrompt},
+				{Role: provider.RoleUser, Content: text},
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("compression model %s returned no choices", model)
+		}
+		return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+	})
+}
+
+type promptCompressionContextKey struct{}
+
+// WithPromptCompression returns a context that overrides the client's
+// PromptCompressionConfig for requests made with it. A zero config turns
+// compression off.
+func WithPromptCompression(ctx context.Context, config PromptCompressionConfig) context.Context {
+	return context.WithValue(ctx, promptCompressionContextKey{}, config)
+}
+
+// CompressMessages returns messages compressed according to config. The
+// given messages are not modified.
+func CompressMessages(ctx context.Context, messages []provider.Message, config PromptCompressionConfig) ([]provider.Message, PromptCompressionStats, error) {
+	if config.DedupMinChars <= 0 {
+		config.DedupMinChars = 50
+	}
+	if config.CompressMinChars <= 0 {
+		config.CompressMinChars = 2000
+	}
+
+	var stats PromptCompressionStats
+	result := make([]provider.Message, len(messages))
+	seen := make(map[string]bool)
+	for i, msg := range messages {
+		result[i] = msg
+		stats.OriginalChars += len(msg.Content)
+		if msg.Role == provider.RoleAssistant || (msg.Role == provider.RoleTool && !config.CompressToolResults) || msg.Content == "" {
+			stats.CompressedChars += len(msg.Content)
+			continue
+		}
+
+		content := msg.Content
+		if config.NormalizeWhitespace {
+			content = normalizeWhitespace(content)
+		}
+		if config.Dedup {
+			var dropped int
+			content, dropped = dedupParagraphs(content, seen, config.DedupMinChars)
+			stats.DroppedParagraphs += dropped
+		}
+		if config.Compressor != nil && i < len(messages)-1 && len(content) >= config.CompressMinChars {
+			compressed, err := config.Compressor.CompressPrompt(ctx, content)
+			if err != nil {
+				return nil, stats, fmt.Errorf("failed to compress message %d: %w", i, err)
+			}
+			if compressed != "" && len(compressed) < len(content) {
+				content = compressed
+				stats.ModelCompressed++
+			}
+		}
+		result[i].Content = content
+		stats.CompressedChars += len(content)
+	}
+	return result, stats, nil
+}
+
+// compressPrompt returns req with its prompt compressed, or req itself if
+// compression is off. A failed compression is logged and the request sent
+// uncompressed, since compression only saves cost.
+func (c *ChatClient) compressPrompt(ctx context.Context, req *provider.ChatCompletionRequest) *provider.ChatCompletionRequest {
+	config := c.promptCompression
+	if override, ok := ctx.Value(promptCompressionContextKey{}).(PromptCompressionConfig); ok {
+		config = override
+	}
+	if !config.enabled() || req == nil {
+		return req
+	}
+
+	messages, stats, err := CompressMessages(ctx, req.Messages, config)
+	if err != nil {
+		c.logger.Warn("prompt compression failed", slog.String("error", err.Error()))
+		return req
+	}
+	if stats.CompressedChars == stats.OriginalChars {
+		return req
+	}
+	c.logger.Debug("compressed prompt",
+		slog.Int("original_chars", stats.OriginalChars),
+		slog.Int("compressed_chars", stats.CompressedChars))
+
+	compressed := *req
+	compressed.Messages = messages
+	return &compressed
+}
+
+// normalizeWhitespace collapses redundant whitespace outside fenced code blocks
+func normalizeWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	inFence, blank := false, false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			out = append(out, strings.TrimRight(line, " \t\r"))
+			blank = false
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || strings.TrimSpace(trimmed) == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		indent := line[:len(line)-len(trimmed)]
+		out = append(out, indent+strings.Join(strings.Fields(trimmed), " "))
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+// dedupParagraphs drops paragraphs of at least minChars already in seen and
+// adds the rest to seen. It returns the text and the number dropped.
+func dedupParagraphs(text string, seen map[string]bool, minChars int) (string, int) {
+	paragraphs := strings.Split(text, "\n\n")
+	kept := paragraphs[:0]
+	dropped := 0
+	for _, p := range paragraphs {
+		key := strings.Join(strings.Fields(p), " ")
+		if len(key) >= minChars {
+			if seen[key] {
+				dropped++
+				continue
+			}
+			seen[key] = true
+		}
+		kept = append(kept, p)
+	}
+	if dropped == 0 {
+		return text, 0
+	}
+	return strings.Join(kept, "\n\n"), dropped
+}
@@ -0,0 +1,204 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestNormalizeWhitespace(t *testing.T) {
+	in := "Title   here  \n\n\n\n  indented\t\tline \n```\nkeep    this\n\n\n```\n\n"
+	want := "Title here\n\n  indented line\n```\nkeep    this\n\n\n```"
+	if got := normalizeWhitespace(in); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCompressMessages(t *testing.T) {
+	doc := "The quarterly report shows revenue grew twelve percent year over year."
+	messages := []provider.Message{
+		{Role: provider.RoleSystem, Content: "Context:\n\n" + doc},
+		{Role: provider.RoleAssistant, Content: "Noted.   " + doc},
+		{Role: provider.RoleUser, Content: doc + "\n\nShort\n\nWhat   changed?"},
+	}
+
+	got, stats, err := CompressMessages(context.Background(), messages, PromptCompressionConfig{
+		NormalizeWhitespace: true,
+		Dedup:               true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[1].Content != messages[1].Content {
+		t.Errorf("assistant message changed: %q", got[1].Content)
+	}
+	if want := "Short\n\nWhat changed?"; got[2].Content != want {
+		t.Errorf("user message = %q, want %q", got[2].Content, want)
+	}
+	if stats.DroppedParagraphs != 1 || stats.CompressedChars >= stats.OriginalChars {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if messages[2].Content == got[2].Content {
+		t.Error("input messages were modified")
+	}
+}
+
+func TestCompressMessages_ToolResults(t *testing.T) {
+	messages := []provider.Message{
+		{Role: provider.RoleTool, Content: `{"query":  "a  b"}`},
+		{Role: provider.RoleUser, Content: "Summarize"},
+	}
+
+	got, _, err := CompressMessages(context.Background(), messages, PromptCompressionConfig{NormalizeWhitespace: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0].Content != messages[0].Content {
+		t.Errorf("tool result changed: %q", got[0].Content)
+	}
+
+	got, _, err = CompressMessages(context.Background(), messages, PromptCompressionConfig{NormalizeWhitespace: true, CompressToolResults: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"query": "a b"}`; got[0].Content != want {
+		t.Errorf("tool result = %q, want %q", got[0].Content, want)
+	}
+}
+
+func TestCompressMessages_Compressor(t *testing.T) {
+	long := strings.Repeat("very long context ", 10)
+	var calls int
+	compressor := PromptCompressorFunc(func(_ context.Context, text string) (string, error) {
+		calls++
+		return "short context", nil
+	})
+	messages := []provider.Message{
+		{Role: provider.RoleUser, Content: long},
+		{Role: provider.RoleUser, Content: long + "?"},
+	}
+
+	got, stats, err := CompressMessages(context.Background(), messages, PromptCompressionConfig{
+		Compressor:       compressor,
+		CompressMinChars: 100,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 || stats.ModelCompressed != 1 {
+		t.Errorf("compressor called %d times, stats %+v; want only the first message", calls, stats)
+	}
+	if got[0].Content != "short context" || got[1].Content != long+"?" {
+		t.Errorf("unexpected messages: %+v", got)
+	}
+
+	failing := PromptCompressorFunc(func(context.Context, string) (string, error) {
+		return "", errors.New("boom")
+	})
+	if _, _, err := CompressMessages(context.Background(), messages, PromptCompressionConfig{Compressor: failing, CompressMinChars: 100}); err == nil {
+		t.Error("expected the compressor error")
+	}
+}
+
+func TestClient_PromptCompression(t *testing.T) {
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("ok"))
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mock}},
+		PromptCompression: &PromptCompressionConfig{NormalizeWhitespace: true},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hello    there  "}},
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx := WithPromptCompression(context.Background(), PromptCompressionConfig{})
+	if _, err := client.CreateChatCompletion(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent := mock.Requests()
+	if len(sent) != 2 {
+		t.Fatalf("got %d requests, want 2", len(sent))
+	}
+	if got := sent[0].Request.Messages[0].Content; got != "hello there" {
+		t.Errorf("compressed content = %q", got)
+	}
+	if got := sent[1].Request.Messages[0].Content; got != "hello    there  " {
+		t.Errorf("content with compression off = %q", got)
+	}
+}
+
+func TestClient_PromptCompressionAfterCache(t *testing.T) {
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("ok"))
+	var calls int
+	compressor := PromptCompressorFunc(func(context.Context, string) (string, error) {
+		calls++
+		return fmt.Sprintf("summary %d", calls), nil
+	})
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mock}},
+		Cache:             mocktest.NewMockKVS(),
+		PromptCompression: &PromptCompressionConfig{Compressor: compressor, CompressMinChars: 10},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{
+		Model: "m",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: strings.Repeat("long context ", 5)},
+			{Role: provider.RoleUser, Content: "question"},
+		},
+	}
+	for range 2 {
+		if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("compressor called %d times, want once for the cache miss", calls)
+	}
+	sent := mock.Requests()
+	if len(sent) != 1 || sent[0].Request.Messages[0].Content != "summary 1" {
+		t.Errorf("unexpected requests: %+v", sent)
+	}
+}
+
+func TestModelPromptCompressor(t *testing.T) {
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("  terse  "))
+	client, err := NewClient(ClientConfig{
+		Providers:         []ProviderConfig{{CustomProvider: mock}},
+		PromptCompression: &PromptCompressionConfig{NormalizeWhitespace: true},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	got, err := ModelPromptCompressor(client, "small").CompressPrompt(context.Background(), "some   verbose text")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "terse" {
+		t.Errorf("got %q, want terse", got)
+	}
+	sent := mock.Requests()
+	if len(sent) != 1 || sent[0].Request.Model != "small" || sent[0].Request.Messages[1].Content != "some   verbose text" {
+		t.Errorf("unexpected compression request: %+v", sent)
+	}
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBatchRequestCanceled is the result error of a batch request canceled before it was processed
+	ErrBatchRequestCanceled = errors.New("batch request canceled")
+
+	// ErrBatchRequestExpired is the result error of a batch request not processed before the batch expired
+	ErrBatchRequestExpired = errors.New("batch request expired")
+
+	// ErrBatchResultMissing is the result error of a request the provider returned no result for
+	ErrBatchResultMissing = errors.New("batch result missing")
+)
+
+// ValidateBatchRequests checks that a batch is non-empty and that every
+// request has a request body and a custom ID unique within the batch
+func ValidateBatchRequests(requests []BatchRequest) error {
+	if len(requests) == 0 {
+		return fmt.Errorf("%w: batch has no requests", ErrInvalidRequest)
+	}
+	seen := make(map[string]bool, len(requests))
+	for i, r := range requests {
+		switch {
+		case r.CustomID == "":
+			return fmt.Errorf("%w: batch request %d has no custom ID", ErrInvalidRequest, i)
+		case seen[r.CustomID]:
+			return fmt.Errorf("%w: duplicate batch custom ID %q", ErrInvalidRequest, r.CustomID)
+		case r.Request == nil:
+			return fmt.Errorf("%w: batch request %q has no request", ErrInvalidRequest, r.CustomID)
+		}
+		seen[r.CustomID] = true
+	}
+	return nil
+}
+
+// MatchBatchResults returns results in the order of requests, matched by
+// custom ID, with each result's Request set. Requests without a result get
+// ErrBatchResultMissing; results without a request are dropped.
+func MatchBatchResults(requests []BatchRequest, results []BatchResult) []BatchResult {
+	byID := make(map[string]BatchResult, len(results))
+	for _, r := range results {
+		byID[r.CustomID] = r
+	}
+
+	matched := make([]BatchResult, len(requests))
+	for i, req := range requests {
+		result, ok := byID[req.CustomID]
+		if !ok {
+			result = BatchResult{CustomID: req.CustomID, Err: ErrBatchResultMissing}
+		}
+		result.Request = req.Request
+		matched[i] = result
+	}
+	return matched
+}
@@ -0,0 +1,69 @@
+package provider
+
+import "encoding/json"
+
+// DeterminismKey is the ProviderMetadata key holding a response's Determinism
+const DeterminismKey = "determinism"
+
+// Determinism describes what a response says about reproducing it. Adapters
+// record it in ProviderMetadata under DeterminismKey when the request set a
+// seed or the provider reported a fingerprint.
+type Determinism struct {
+	// Seed is the seed sent to the provider, or nil if none was sent
+	Seed *int `json:"seed,omitempty"`
+
+	// SeedIgnored is true if the request set a seed that the provider has
+	// no parameter for, so it had no effect
+	SeedIgnored bool `json:"seed_ignored,omitempty"`
+
+	// Fingerprint identifies the backend configuration that served the
+	// response: OpenAI's system_fingerprint or Gemini's model version.
+	// Responses to the same request and seed are most likely to match when
+	// their fingerprints match.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// RecordDeterminism records the determinism of a response to a request with
+// seed in metadata, creating the map if needed, and returns it.
+// seedSupported says whether the provider accepts a seed. Nothing is
+// recorded if seed is nil and fingerprint is empty.
+func RecordDeterminism(metadata map[string]any, seed *int, seedSupported bool, fingerprint string) map[string]any {
+	var d Determinism
+	if seed != nil {
+		if seedSupported {
+			seed := *seed
+			d.Seed = &seed
+		} else {
+			d.SeedIgnored = true
+		}
+	}
+	d.Fingerprint = fingerprint
+	if d == (Determinism{}) {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata[DeterminismKey] = d
+	return metadata
+}
+
+// GetDeterminism returns the Determinism recorded in metadata. It also reads
+// one decoded from JSON, as in a response loaded from a cache.
+func GetDeterminism(metadata map[string]any) (Determinism, bool) {
+	switch v := metadata[DeterminismKey].(type) {
+	case Determinism:
+		return v, true
+	case map[string]any:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return Determinism{}, false
+		}
+		var d Determinism
+		if err := json.Unmarshal(data, &d); err != nil {
+			return Determinism{}, false
+		}
+		return d, true
+	}
+	return Determinism{}, false
+}
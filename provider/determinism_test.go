@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRecordDeterminism(t *testing.T) {
+	seed := 42
+
+	if got := RecordDeterminism(nil, nil, true, ""); got != nil {
+		t.Errorf("expected nothing recorded without a seed or fingerprint, got %v", got)
+	}
+
+	metadata := RecordDeterminism(nil, &seed, true, "fp_1")
+	d, ok := GetDeterminism(metadata)
+	if !ok || d.Seed == nil || *d.Seed != 42 || d.SeedIgnored || d.Fingerprint != "fp_1" {
+		t.Errorf("supported seed: got %+v, %v", d, ok)
+	}
+
+	d, ok = GetDeterminism(RecordDeterminism(nil, &seed, false, ""))
+	if !ok || d.Seed != nil || !d.SeedIgnored {
+		t.Errorf("ignored seed: got %+v, %v", d, ok)
+	}
+}
+
+func TestGetDeterminism_Decoded(t *testing.T) {
+	seed := 7
+	data, err := json.Marshal(RecordDeterminism(nil, &seed, true, "fp_2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata map[string]any
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	d, ok := GetDeterminism(metadata)
+	if !ok || d.Seed == nil || *d.Seed != 7 || d.Fingerprint != "fp_2" {
+		t.Errorf("got %+v, %v", d, ok)
+	}
+	if _, ok := GetDeterminism(nil); ok {
+		t.Error("expected no determinism in empty metadata")
+	}
+}
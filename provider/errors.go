@@ -0,0 +1,320 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors matched, via errors.Is, by APIErrors with the
+// corresponding status or error code. See APIError.Unwrap.
+var (
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+	ErrQuotaExceeded     = errors.New("quota exceeded")
+	ErrModelNotFound     = errors.New("model not found")
+	ErrServerError       = errors.New("server error")
+	ErrAuthentication    = errors.New("authentication failed")
+)
+
+// ProviderName identifies an LLM provider, such as "openai"
+type ProviderName string
+
+// APIError is an error response from a provider's API
+type APIError struct {
+	StatusCode int          `json:"status_code"`
+	Message    string       `json:"message"`
+	Type       string       `json:"type"`
+	Code       string       `json:"code"`
+	Provider   ProviderName `json:"provider"`
+
+	// RetryAfter is how long the provider asked clients to wait before
+	// retrying, from the Retry-After header or, for rate limit errors, the
+	// rate limit reset headers. Zero if the response did not say.
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+
+	// RateLimit holds the response's rate limit headers, or nil if it had none
+	RateLimit *RateLimitInfo `json:"rate_limit,omitempty"`
+
+	// Param is the request parameter the error refers to, if the provider
+	// names one (OpenAI, X.AI)
+	Param string `json:"param,omitempty"`
+
+	// RequestID is the provider's ID for the failed request, from the
+	// x-request-id or request-id header, for reporting issues to the provider
+	RequestID string `json:"request_id,omitempty"`
+
+	// Body is the raw error response body
+	Body string `json:"body,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("[%s] %s (status: %d, type: %s, code: %s)",
+		e.Provider, e.Message, e.StatusCode, e.Type, e.Code)
+}
+
+// Unwrap returns the sentinel error for the kind of failure, so callers can
+// branch with errors.Is instead of matching messages: ErrQuotaExceeded or
+// ErrRateLimitExceeded for 429s, ErrAuthentication for 401s and 403s,
+// ErrModelNotFound for unknown models, ErrInvalidRequest for 400, 413, and
+// 422, and ErrServerError for 5xx. It returns nil for other errors.
+func (e *APIError) Unwrap() error {
+	kind := strings.ToLower(e.Type + " " + e.Code)
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		if strings.Contains(kind, "quota") {
+			return ErrQuotaExceeded
+		}
+		return ErrRateLimitExceeded
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrAuthentication
+	case e.Code == "model_not_found" ||
+		e.StatusCode == http.StatusNotFound && strings.Contains(strings.ToLower(e.Message), "model"):
+		return ErrModelNotFound
+	case e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusRequestEntityTooLarge ||
+		e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrInvalidRequest
+	case e.StatusCode >= 500:
+		return ErrServerError
+	}
+	return nil
+}
+
+// WithBody sets the raw error response body and returns e
+func (e *APIError) WithBody(body []byte) *APIError {
+	e.Body = string(body)
+	return e
+}
+
+// StreamError is the error payload of an error event received mid-stream:
+// an object with a message, type, and code, as sent by OpenAI, Anthropic, and
+// X.AI, or a bare message string
+type StreamError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code"`
+}
+
+// UnmarshalJSON accepts an error object, whose code may be a string or a
+// number, or a message string
+func (e *StreamError) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		*e = StreamError{Message: message}
+		return nil
+	}
+	var raw struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    any    `json:"code"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = StreamError{Message: raw.Message, Type: raw.Type}
+	if raw.Code != nil {
+		e.Code = fmt.Sprint(raw.Code)
+	}
+	return nil
+}
+
+// streamErrorStatus maps the error types sent in stream error events to the
+// HTTP status the same error gets before streaming starts
+var streamErrorStatus = map[string]int{
+	"invalid_request_error": http.StatusBadRequest,
+	"authentication_error":  http.StatusUnauthorized,
+	"permission_error":      http.StatusForbidden,
+	"not_found_error":       http.StatusNotFound,
+	"request_too_large":     http.StatusRequestEntityTooLarge,
+	"rate_limit_error":      http.StatusTooManyRequests,
+	"rate_limit_exceeded":   http.StatusTooManyRequests,
+	"tokens":                http.StatusTooManyRequests,
+	"requests":              http.StatusTooManyRequests,
+	"api_error":             http.StatusInternalServerError,
+	"server_error":          http.StatusInternalServerError,
+	"internal_error":        http.StatusInternalServerError,
+	"timeout_error":         http.StatusGatewayTimeout,
+	"overloaded_error":      529, // Anthropic's overloaded status
+}
+
+// NewStreamAPIError creates an APIError for an error event received
+// mid-stream. The stream's HTTP status was 200, so StatusCode is inferred from
+// the error type, or 0 if the type is unknown.
+func NewStreamAPIError(providerName string, e *StreamError) *APIError {
+	return &APIError{
+		StatusCode: streamErrorStatus[e.Type],
+		Message:    e.Message,
+		Type:       e.Type,
+		Code:       e.Code,
+		Provider:   ProviderName(providerName),
+	}
+}
+
+// ErrContentBlocked is matched, via errors.Is, by every ContentBlockedError
+var ErrContentBlocked = errors.New("content blocked by safety filter")
+
+// ContentBlockedError reports a prompt or response blocked by a provider's
+// safety filters, with the categories that triggered it
+type ContentBlockedError struct {
+	Provider string `json:"provider"`
+	Reason   string `json:"reason"`            // Provider block reason, e.g. "SAFETY" or "PROHIBITED_CONTENT"
+	Prompt   bool   `json:"prompt"`            // The prompt was blocked, so nothing was generated
+	Message  string `json:"message,omitempty"` // Provider explanation, if given
+
+	// Ratings holds the safety ratings reported with the block
+	Ratings []SafetyRating `json:"ratings,omitempty"`
+}
+
+// SafetyRating is a provider's harm assessment for one category
+type SafetyRating struct {
+	Category    string `json:"category"`              // e.g. "HARM_CATEGORY_HARASSMENT"
+	Probability string `json:"probability,omitempty"` // e.g. "HIGH"
+	Blocked     bool   `json:"blocked,omitempty"`     // This category caused the block
+}
+
+func (e *ContentBlockedError) Error() string {
+	target := "response"
+	if e.Prompt {
+		target = "prompt"
+	}
+	msg := fmt.Sprintf("[%s] %s blocked: %s", e.Provider, target, e.Reason)
+	var categories []string
+	for _, r := range e.Ratings {
+		if r.Blocked {
+			categories = append(categories, r.Category)
+		}
+	}
+	if len(categories) > 0 {
+		msg += " (" + strings.Join(categories, ", ") + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Is reports whether target is ErrContentBlocked
+func (e *ContentBlockedError) Is(target error) bool {
+	return target == ErrContentBlocked
+}
+
+// RateLimitInfo is the rate limit state reported in response headers.
+// Counts are -1 when the header is absent.
+type RateLimitInfo struct {
+	LimitRequests     int           `json:"limit_requests"`
+	RemainingRequests int           `json:"remaining_requests"`
+	ResetRequests     time.Duration `json:"reset_requests,omitempty"` // Until the request limit resets
+	LimitTokens       int           `json:"limit_tokens"`
+	RemainingTokens   int           `json:"remaining_tokens"`
+	ResetTokens       time.Duration `json:"reset_tokens,omitempty"` // Until the token limit resets
+}
+
+// NewAPIErrorFromResponse creates an APIError for an HTTP error response,
+// reading Retry-After and rate limit headers from resp
+func NewAPIErrorFromResponse(providerName string, resp *http.Response, message, errorType, code string) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		Type:       errorType,
+		Code:       code,
+		Provider:   ProviderName(providerName),
+		RetryAfter: ParseRetryAfter(resp.Header),
+		RateLimit:  ParseRateLimitHeaders(resp.Header),
+		RequestID:  RequestIDFromHeader(resp.Header),
+	}
+
+	// Without Retry-After, wait for the exhausted limit to reset
+	if apiErr.RetryAfter == 0 && resp.StatusCode == http.StatusTooManyRequests && apiErr.RateLimit != nil {
+		rl := apiErr.RateLimit
+		if rl.RemainingRequests == 0 {
+			apiErr.RetryAfter = rl.ResetRequests
+		}
+		if rl.RemainingTokens == 0 && rl.ResetTokens > apiErr.RetryAfter {
+			apiErr.RetryAfter = rl.ResetTokens
+		}
+	}
+	return apiErr
+}
+
+// ParseRetryAfter returns the wait requested by the retry-after-ms or
+// Retry-After header, which may be in seconds or an HTTP date. It returns 0
+// if neither header is present or valid.
+func ParseRetryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	value := strings.TrimSpace(h.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// ParseRateLimitHeaders reads the OpenAI-style x-ratelimit-* headers, also
+// used by X.AI, or Anthropic's anthropic-ratelimit-* headers. It returns nil
+// if none are present.
+func ParseRateLimitHeaders(h http.Header) *RateLimitInfo {
+	rl := &RateLimitInfo{LimitRequests: -1, RemainingRequests: -1, LimitTokens: -1, RemainingTokens: -1}
+	found := false
+
+	count := func(dst *int, keys ...string) {
+		for _, key := range keys {
+			if n, err := strconv.Atoi(h.Get(key)); err == nil {
+				*dst = n
+				found = true
+				return
+			}
+		}
+	}
+	reset := func(dst *time.Duration, keys ...string) {
+		for _, key := range keys {
+			if d := parseReset(h.Get(key)); d > 0 {
+				*dst = d
+				found = true
+				return
+			}
+		}
+	}
+
+	count(&rl.LimitRequests, "x-ratelimit-limit-requests", "anthropic-ratelimit-requests-limit")
+	count(&rl.RemainingRequests, "x-ratelimit-remaining-requests", "anthropic-ratelimit-requests-remaining")
+	reset(&rl.ResetRequests, "x-ratelimit-reset-requests", "anthropic-ratelimit-requests-reset")
+	count(&rl.LimitTokens, "x-ratelimit-limit-tokens", "anthropic-ratelimit-tokens-limit")
+	count(&rl.RemainingTokens, "x-ratelimit-remaining-tokens", "anthropic-ratelimit-tokens-remaining")
+	reset(&rl.ResetTokens, "x-ratelimit-reset-tokens", "anthropic-ratelimit-tokens-reset")
+
+	if !found {
+		return nil
+	}
+	return rl
+}
+
+// parseReset parses a rate limit reset header, which is a duration such as
+// "6m0s" or "20ms" (OpenAI, X.AI) or an RFC 3339 time (Anthropic)
+func parseReset(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return d
+	}
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"seconds", http.Header{"Retry-After": {"20"}}, 20 * time.Second},
+		{"milliseconds header wins", http.Header{"Retry-After": {"20"}, "Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{"past date", http.Header{"Retry-After": {"Wed, 21 Oct 2015 07:28:00 GMT"}}, 0},
+		{"missing", http.Header{}, 0},
+		{"invalid", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := ParseRetryAfter(http.Header{"Retry-After": {future}}); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("expected about a minute for an HTTP date, got %s", got)
+	}
+}
+
+func TestNewAPIErrorFromResponse_RateLimitHeaders(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("x-ratelimit-limit-requests", "500")
+	resp.Header.Set("x-ratelimit-remaining-requests", "0")
+	resp.Header.Set("x-ratelimit-reset-requests", "6s")
+	resp.Header.Set("x-ratelimit-remaining-tokens", "1200")
+
+	apiErr := NewAPIErrorFromResponse("openai", resp, "Rate limit reached", "requests", "rate_limit_exceeded")
+	if apiErr.RetryAfter != 6*time.Second {
+		t.Errorf("expected retry after the request limit reset, got %s", apiErr.RetryAfter)
+	}
+	rl := apiErr.RateLimit
+	if rl == nil || rl.LimitRequests != 500 || rl.RemainingRequests != 0 || rl.RemainingTokens != 1200 || rl.LimitTokens != -1 {
+		t.Errorf("unexpected rate limit info: %+v", rl)
+	}
+
+	anthropic := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	anthropic.Header.Set("retry-after", "3")
+	anthropic.Header.Set("anthropic-ratelimit-tokens-remaining", "0")
+	anthropic.Header.Set("anthropic-ratelimit-tokens-reset", time.Now().Add(time.Minute).Format(time.RFC3339))
+	apiErr = NewAPIErrorFromResponse("anthropic", anthropic, "rate limited", "rate_limit_error", "")
+	if apiErr.RetryAfter != 3*time.Second {
+		t.Errorf("expected Retry-After to take precedence, got %s", apiErr.RetryAfter)
+	}
+	if apiErr.RateLimit == nil || apiErr.RateLimit.RemainingTokens != 0 || apiErr.RateLimit.ResetTokens <= 0 {
+		t.Errorf("unexpected rate limit info: %+v", apiErr.RateLimit)
+	}
+
+	if NewAPIErrorFromResponse("openai", &http.Response{StatusCode: 500, Header: http.Header{}}, "boom", "", "").RateLimit != nil {
+		t.Error("expected no rate limit info without headers")
+	}
+}
+
+func TestContentBlockedError(t *testing.T) {
+	err := fmt.Errorf("request failed: %w", &ContentBlockedError{
+		Provider: "gemini",
+		Reason:   "SAFETY",
+		Ratings: []SafetyRating{
+			{Category: "HARM_CATEGORY_HARASSMENT", Probability: "HIGH", Blocked: true},
+			{Category: "HARM_CATEGORY_HATE_SPEECH", Probability: "LOW"},
+		},
+	})
+
+	if !errors.Is(err, ErrContentBlocked) {
+		t.Error("expected errors.Is to match ErrContentBlocked")
+	}
+	var blocked *ContentBlockedError
+	if !errors.As(err, &blocked) || blocked.Prompt || len(blocked.Ratings) != 2 {
+		t.Fatalf("unexpected error: %#v", blocked)
+	}
+	if got, want := blocked.Error(), "[gemini] response blocked: SAFETY (HARM_CATEGORY_HARASSMENT)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestStreamError_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want StreamError
+	}{
+		{"object", `{"message":"Overloaded","type":"overloaded_error"}`, StreamError{Message: "Overloaded", Type: "overloaded_error"}},
+		{"string code", `{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}`, StreamError{Message: "slow down", Type: "requests", Code: "rate_limit_exceeded"}},
+		{"numeric code", `{"message":"boom","type":"server_error","code":500}`, StreamError{Message: "boom", Type: "server_error", Code: "500"}},
+		{"string", `"upstream disconnected"`, StreamError{Message: "upstream disconnected"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StreamError
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStreamAPIError(t *testing.T) {
+	apiErr := NewStreamAPIError("anthropic", &StreamError{Message: "Overloaded", Type: "overloaded_error"})
+	if apiErr.StatusCode != 529 || apiErr.Type != "overloaded_error" || apiErr.Provider != "anthropic" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+	if apiErr := NewStreamAPIError("openai", &StreamError{Message: "?", Type: "mystery"}); apiErr.StatusCode != 0 {
+		t.Errorf("StatusCode = %d, want 0 for an unknown type", apiErr.StatusCode)
+	}
+}
+
+func TestAPIError_Unwrap(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *APIError
+		want error
+	}{
+		{"rate limit", &APIError{StatusCode: 429, Type: "tokens"}, ErrRateLimitExceeded},
+		{"quota", &APIError{StatusCode: 429, Type: "insufficient_quota", Code: "insufficient_quota"}, ErrQuotaExceeded},
+		{"unauthorized", &APIError{StatusCode: 401}, ErrAuthentication},
+		{"forbidden", &APIError{StatusCode: 403}, ErrAuthentication},
+		{"model code", &APIError{StatusCode: 404, Code: "model_not_found"}, ErrModelNotFound},
+		{"model message", &APIError{StatusCode: 404, Message: "model: claude-9 not found"}, ErrModelNotFound},
+		{"other not found", &APIError{StatusCode: 404, Message: "file not found"}, nil},
+		{"bad request", &APIError{StatusCode: 400}, ErrInvalidRequest},
+		{"overloaded", &APIError{StatusCode: 529}, ErrServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("request failed: %w", tt.err)
+			if got := tt.err.Unwrap(); got != tt.want {
+				t.Errorf("Unwrap() = %v, want %v", got, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAPIErrorFromResponse_RequestID(t *testing.T) {
+	resp := &http.Response{StatusCode: 400, Header: http.Header{}}
+	resp.Header.Set("request-id", "req_018")
+
+	err := NewAPIErrorFromResponse("anthropic", resp, "bad", "invalid_request_error", "").WithBody([]byte(`{"error":{}}`))
+	if err.RequestID != "req_018" || err.Body != `{"error":{}}` {
+		t.Errorf("RequestID = %q, Body = %q", err.RequestID, err.Body)
+	}
+}
@@ -0,0 +1,79 @@
+package provider
+
+import "strings"
+
+// Normalized finish reasons. Adapters map provider-specific values such as
+// "end_turn" or "MAX_TOKENS" onto these.
+const (
+	FinishReasonStop          = "stop"           // Natural end of output or a stop sequence
+	FinishReasonLength        = "length"         // Token limit reached
+	FinishReasonToolCalls     = "tool_calls"     // The model called tools
+	FinishReasonContentFilter = "content_filter" // Output withheld or cut by a safety filter
+	FinishReasonError         = "error"          // Generation failed, e.g. a malformed tool call
+)
+
+// RawFinishReasonKey is the ProviderMetadata key holding the provider's
+// original finish reason for the first choice
+const RawFinishReasonKey = "raw_finish_reason"
+
+// finishReasons maps provider finish reasons to normalized values. Gemini
+// values are upper case; lookups are case-insensitive.
+var finishReasons = map[string]string{
+	// OpenAI, X.AI, Ollama
+	"stop":           FinishReasonStop,
+	"length":         FinishReasonLength,
+	"tool_calls":     FinishReasonToolCalls,
+	"function_call":  FinishReasonToolCalls,
+	"content_filter": FinishReasonContentFilter,
+
+	// Anthropic
+	"end_turn":                      FinishReasonStop,
+	"stop_sequence":                 FinishReasonStop,
+	"pause_turn":                    FinishReasonStop,
+	"max_tokens":                    FinishReasonLength,
+	"model_context_window_exceeded": FinishReasonLength,
+	"tool_use":                      FinishReasonToolCalls,
+	"refusal":                       FinishReasonContentFilter,
+
+	// Gemini
+	"safety":                  FinishReasonContentFilter,
+	"recitation":              FinishReasonContentFilter,
+	"blocklist":               FinishReasonContentFilter,
+	"prohibited_content":      FinishReasonContentFilter,
+	"spii":                    FinishReasonContentFilter,
+	"image_safety":            FinishReasonContentFilter,
+	"language":                FinishReasonError,
+	"other":                   FinishReasonError,
+	"malformed_function_call": FinishReasonError,
+	"unexpected_tool_call":    FinishReasonError,
+}
+
+// NormalizeFinishReason maps a provider finish reason to one of the
+// FinishReason constants. Unrecognized values are returned unchanged.
+func NormalizeFinishReason(raw string) string {
+	if reason, ok := finishReasons[strings.ToLower(raw)]; ok {
+		return reason
+	}
+	return raw
+}
+
+// NormalizeFinishReasons normalizes the finish reason of each choice in place.
+// The original value for choice 0 is recorded in metadata under
+// RawFinishReasonKey; the map is created if needed and returned.
+func NormalizeFinishReasons(choices []ChatCompletionChoice, metadata map[string]any) map[string]any {
+	for i := range choices {
+		if choices[i].FinishReason == nil {
+			continue
+		}
+		raw := *choices[i].FinishReason
+		if choices[i].Index == 0 {
+			if metadata == nil {
+				metadata = make(map[string]any)
+			}
+			metadata[RawFinishReasonKey] = raw
+		}
+		reason := NormalizeFinishReason(raw)
+		choices[i].FinishReason = &reason
+	}
+	return metadata
+}
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetHeaders sets each header on an outgoing request, replacing any value
+// already set under the same name. Adapters call it after setting their own
+// headers so per-request headers take precedence.
+func SetHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
+
+// validHeaderName reports whether name is a valid HTTP header field name
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+)
+
+// IdempotencyKeyHeader is the HTTP header that carries an idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey returns a context whose requests carry key. Providers
+// that support idempotency process repeated requests with the same key once,
+// so a retried request is not billed or submitted twice. A key must only be
+// reused for the same request.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the key set with WithIdempotencyKey, or ""
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// SetIdempotencyKey sets the Idempotency-Key header on req from the key in
+// its context, if there is one
+func SetIdempotencyKey(req *http.Request) {
+	if key := IdempotencyKeyFromContext(req.Context()); key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+}
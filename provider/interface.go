@@ -36,3 +36,103 @@ type ChatCompletionStream interface {
 	// Close closes the stream
 	Close() error
 }
+
+// ModerationProvider is an optional capability for providers that expose a
+// content moderation endpoint. Callers should use a type assertion to check
+// whether a Provider supports moderation.
+type ModerationProvider interface {
+	// CreateModeration classifies the given input against the provider's safety categories
+	CreateModeration(ctx context.Context, req *ModerationRequest) (*ModerationResponse, error)
+}
+
+// CapabilityProvider is an optional interface for providers that report the
+// request features they support. Requests to providers that do not implement
+// it are sent unchecked.
+type CapabilityProvider interface {
+	// Capabilities returns the request features the provider supports
+	Capabilities() Capabilities
+}
+
+// RerankProvider is an optional capability for providers that score documents
+// by their relevance to a query, as used in retrieval pipelines
+type RerankProvider interface {
+	// Rerank orders documents by relevance to the query
+	Rerank(ctx context.Context, req *RerankRequest) (*RerankResponse, error)
+}
+
+// FIMProvider is an optional capability for providers that complete code
+// between a prefix and a suffix (fill-in-the-middle)
+type FIMProvider interface {
+	// CreateFIMCompletion generates the text between the prompt and suffix
+	CreateFIMCompletion(ctx context.Context, req *FIMRequest) (*FIMResponse, error)
+}
+
+// TextCompletionProvider is an optional capability for providers that serve
+// legacy text completion, which base models use instead of chat
+type TextCompletionProvider interface {
+	// CreateTextCompletion continues the prompt
+	CreateTextCompletion(ctx context.Context, req *TextCompletionRequest) (*TextCompletionResponse, error)
+}
+
+// FileProvider is an optional capability for providers that support file storage.
+// Uploaded files can be referenced from messages by their unified handle.
+type FileProvider interface {
+	// UploadFile uploads a file and returns its handle
+	UploadFile(ctx context.Context, req *FileUploadRequest) (*File, error)
+
+	// GetFile retrieves the metadata for a previously uploaded file
+	GetFile(ctx context.Context, fileID string) (*File, error)
+
+	// DeleteFile deletes a previously uploaded file
+	DeleteFile(ctx context.Context, fileID string) error
+}
+
+// VectorStoreProvider is an optional capability for providers that host vector
+// stores for retrieval. Stores are searched by enabling a ToolTypeFileSearch tool
+// on a chat completion request.
+type VectorStoreProvider interface {
+	// CreateVectorStore creates a vector store, attaching any files given in the request
+	CreateVectorStore(ctx context.Context, req *VectorStoreRequest) (*VectorStore, error)
+
+	// GetVectorStore retrieves the metadata for a vector store
+	GetVectorStore(ctx context.Context, storeID string) (*VectorStore, error)
+
+	// AddVectorStoreFile attaches a previously uploaded file to a vector store
+	AddVectorStoreFile(ctx context.Context, storeID, fileID string) error
+
+	// DeleteVectorStore deletes a vector store
+	DeleteVectorStore(ctx context.Context, storeID string) error
+}
+
+// BatchProvider is an optional capability for providers that process batches
+// of chat completion requests asynchronously
+type BatchProvider interface {
+	// CreateBatch submits requests for asynchronous processing
+	CreateBatch(ctx context.Context, requests []BatchRequest) (*Batch, error)
+
+	// GetBatch retrieves the current status of a batch
+	GetBatch(ctx context.Context, batchID string) (*Batch, error)
+
+	// GetBatchResults fetches the results of a batch that has ended. If the
+	// requests the batch was created with are given, results are converted
+	// with and returned in the order of their matching requests.
+	GetBatchResults(ctx context.Context, batchID string, requests []BatchRequest) ([]BatchResult, error)
+
+	// CancelBatch stops processing of a batch. Requests already processed
+	// keep their results.
+	CancelBatch(ctx context.Context, batchID string) (*Batch, error)
+}
+
+// ModelLister is an optional capability for providers that can list the
+// models available to the caller
+type ModelLister interface {
+	// ListModels returns the models the provider's models endpoint reports
+	ListModels(ctx context.Context) ([]Model, error)
+}
+
+// RealtimeProvider is an optional capability for providers that support
+// low-latency bidirectional sessions, such as voice conversations
+type RealtimeProvider interface {
+	// ConnectRealtime opens a realtime session
+	ConnectRealtime(ctx context.Context, config *RealtimeConfig) (RealtimeSession, error)
+}
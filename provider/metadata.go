@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"cmp"
+	"net/http"
+	"time"
+)
+
+// Reserved ProviderMetadata keys. The client and adapters record these, and
+// the ChatCompletionResponse accessors read them; custom providers and hooks
+// should not store anything else under them.
+const (
+	// MetadataKeyCacheHit is true on a response served from the cache
+	MetadataKeyCacheHit = "cache_hit"
+
+	// MetadataKeyCachedAt is when a cached response was stored
+	MetadataKeyCachedAt = "cached_at"
+
+	// MetadataKeyFallbackProvider names the provider that served a response
+	// when fallback providers are configured
+	MetadataKeyFallbackProvider = "fallback_provider_used"
+
+	// MetadataKeyFallbackAttempts is how many providers were tried
+	MetadataKeyFallbackAttempts = "fallback_attempt_count"
+
+	// MetadataKeyRequestID is the provider's ID for the request, from the
+	// x-request-id or request-id response header
+	MetadataKeyRequestID = "request_id"
+
+	// MetadataKeyLatency is the request latency in milliseconds, as measured
+	// by the client
+	MetadataKeyLatency = "latency_ms"
+
+	// MetadataKeyRegion is the region that served the request, for providers
+	// that report one
+	MetadataKeyRegion = "region"
+)
+
+// RequestIDFromHeader returns the provider request ID from response headers,
+// or "" if there is none
+func RequestIDFromHeader(header http.Header) string {
+	return cmp.Or(header.Get("x-request-id"), header.Get("request-id"))
+}
+
+// RecordRequestID records a provider request ID in metadata, creating the map
+// if needed, and returns it. Nothing is recorded for an empty ID.
+func RecordRequestID(metadata map[string]any, requestID string) map[string]any {
+	if requestID == "" {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	metadata[MetadataKeyRequestID] = requestID
+	return metadata
+}
+
+// CacheHit reports whether the response was served from the cache
+func (r *ChatCompletionResponse) CacheHit() bool {
+	hit, _ := r.metadata(MetadataKeyCacheHit).(bool)
+	return hit
+}
+
+// CachedAt returns when a cached response was stored
+func (r *ChatCompletionResponse) CachedAt() (time.Time, bool) {
+	switch v := r.metadata(MetadataKeyCachedAt).(type) {
+	case time.Time:
+		return v, true
+	case string:
+		// Decoded from JSON
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// FallbackProvider returns the name of the provider that served the
+// response when fallback providers are configured, or "" otherwise
+func (r *ChatCompletionResponse) FallbackProvider() string {
+	name, _ := r.metadata(MetadataKeyFallbackProvider).(string)
+	return name
+}
+
+// FallbackAttempts returns how many providers were tried before the
+// response, or 0 if fallback providers are not configured
+func (r *ChatCompletionResponse) FallbackAttempts() int {
+	n, _ := metadataInt(r.metadata(MetadataKeyFallbackAttempts))
+	return int(n)
+}
+
+// ProviderRequestID returns the provider's ID for the request, for
+// reporting issues to the provider, or "" if it did not send one
+func (r *ChatCompletionResponse) ProviderRequestID() string {
+	id, _ := r.metadata(MetadataKeyRequestID).(string)
+	return id
+}
+
+// Latency returns the request latency measured by the client, or 0 if it was
+// not recorded
+func (r *ChatCompletionResponse) Latency() time.Duration {
+	ms, _ := metadataInt(r.metadata(MetadataKeyLatency))
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Region returns the region that served the request, or "" if the provider
+// does not report one
+func (r *ChatCompletionResponse) Region() string {
+	region, _ := r.metadata(MetadataKeyRegion).(string)
+	return region
+}
+
+// metadata returns the ProviderMetadata value under key, or nil
+func (r *ChatCompletionResponse) metadata(key string) any {
+	if r == nil {
+		return nil
+	}
+	return r.ProviderMetadata[key]
+}
+
+// metadataInt reads an integer stored directly or decoded from JSON
+func metadataInt(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseMetadataAccessors(t *testing.T) {
+	cachedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	resp := &ChatCompletionResponse{ProviderMetadata: map[string]any{
+		MetadataKeyCacheHit:         true,
+		MetadataKeyCachedAt:         cachedAt,
+		MetadataKeyFallbackProvider: "anthropic",
+		MetadataKeyFallbackAttempts: 2,
+		MetadataKeyRequestID:        "req_123",
+		MetadataKeyLatency:          int64(250),
+		MetadataKeyRegion:           "us-east-1",
+	}}
+
+	check := func(t *testing.T, resp *ChatCompletionResponse) {
+		t.Helper()
+		if !resp.CacheHit() {
+			t.Error("CacheHit() = false")
+		}
+		if got, ok := resp.CachedAt(); !ok || !got.Equal(cachedAt) {
+			t.Errorf("CachedAt() = %v, %v", got, ok)
+		}
+		if got := resp.FallbackProvider(); got != "anthropic" {
+			t.Errorf("FallbackProvider() = %q", got)
+		}
+		if got := resp.FallbackAttempts(); got != 2 {
+			t.Errorf("FallbackAttempts() = %d", got)
+		}
+		if got := resp.ProviderRequestID(); got != "req_123" {
+			t.Errorf("ProviderRequestID() = %q", got)
+		}
+		if got := resp.Latency(); got != 250*time.Millisecond {
+			t.Errorf("Latency() = %v", got)
+		}
+		if got := resp.Region(); got != "us-east-1" {
+			t.Errorf("Region() = %q", got)
+		}
+	}
+	check(t, resp)
+
+	// The accessors also read metadata decoded from JSON, as from a cache
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ChatCompletionResponse
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	check(t, &decoded)
+}
+
+func TestResponseMetadataAccessors_Empty(t *testing.T) {
+	for _, resp := range []*ChatCompletionResponse{nil, {}} {
+		if resp.CacheHit() || resp.FallbackProvider() != "" || resp.FallbackAttempts() != 0 ||
+			resp.ProviderRequestID() != "" || resp.Latency() != 0 || resp.Region() != "" {
+			t.Errorf("expected zero values for %+v", resp)
+		}
+		if _, ok := resp.CachedAt(); ok {
+			t.Errorf("CachedAt() ok for %+v", resp)
+		}
+	}
+}
+
+func TestRecordRequestID(t *testing.T) {
+	if got := RecordRequestID(nil, ""); got != nil {
+		t.Errorf("expected nothing recorded for an empty ID, got %v", got)
+	}
+
+	header := http.Header{}
+	header.Set("request-id", "req_abc")
+	metadata := RecordRequestID(nil, RequestIDFromHeader(header))
+	if metadata[MetadataKeyRequestID] != "req_abc" {
+		t.Errorf("metadata = %v", metadata)
+	}
+}
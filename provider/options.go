@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DecodeOptions reads the ProviderOptions entry for name as a T. The entry may
+// be a T, a *T, or any value with the same JSON shape, such as a map loaded
+// from a config file. It returns nil if the request has no entry for name.
+func DecodeOptions[T any](req *ChatCompletionRequest, name string) (*T, error) {
+	switch v := req.ProviderOptions[name].(type) {
+	case nil:
+		return nil, nil
+	case *T:
+		return v, nil
+	case T:
+		return &v, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s provider options: %w", name, err)
+		}
+		var opts T
+		if err := json.Unmarshal(data, &opts); err != nil {
+			return nil, fmt.Errorf("invalid %s provider options: %w", name, err)
+		}
+		return &opts, nil
+	}
+}
@@ -0,0 +1,20 @@
+package provider
+
+// RawExchange is a chat completion in the provider's own wire format, for
+// debugging adapters and for reading fields the unified types do not map
+type RawExchange struct {
+	// Request is the provider request body as sent
+	Request []byte
+
+	// Response is the provider response body as received
+	Response []byte
+}
+
+// RawCapable is an optional capability for providers that can expose the
+// wire format of their chat completions. Providers that implement it attach a
+// RawExchange to ChatCompletionResponse.Raw when a request sets IncludeRaw.
+type RawCapable interface {
+	// MarshalChatCompletionRequest returns the provider request body req
+	// converts to, without sending it
+	MarshalChatCompletionRequest(req *ChatCompletionRequest) ([]byte, error)
+}
@@ -0,0 +1,87 @@
+package provider
+
+// RealtimeConfig configures a realtime session
+type RealtimeConfig struct {
+	Model        string `json:"model"`
+	Instructions string `json:"instructions,omitempty"` // System prompt for the session
+
+	// Modalities lists the output types, "text" or "audio". Default: audio
+	Modalities []string `json:"modalities,omitempty"`
+
+	// Voice selects the voice of spoken output, e.g. "alloy" (OpenAI) or "Puck" (Gemini)
+	Voice string `json:"voice,omitempty"`
+
+	// Tools the model may call. Calls arrive as RealtimeEventToolCall events
+	// and are answered with RealtimeSession.SendToolResult.
+	Tools []Tool `json:"tools,omitempty"`
+
+	// InputTranscription reports transcripts of the user's audio as
+	// RealtimeEventInputTranscript events
+	InputTranscription bool `json:"input_transcription,omitempty"`
+}
+
+// RealtimeEventType identifies the kind of a RealtimeEvent
+type RealtimeEventType string
+
+const (
+	// RealtimeEventText carries a fragment of text output in Text
+	RealtimeEventText RealtimeEventType = "text"
+
+	// RealtimeEventAudio carries a fragment of spoken output in Audio, as
+	// 16-bit little-endian mono PCM at 24kHz
+	RealtimeEventAudio RealtimeEventType = "audio"
+
+	// RealtimeEventTranscript carries a fragment of the transcript of spoken output in Text
+	RealtimeEventTranscript RealtimeEventType = "transcript"
+
+	// RealtimeEventInputTranscript carries a transcript of the user's audio in Text
+	RealtimeEventInputTranscript RealtimeEventType = "input_transcript"
+
+	// RealtimeEventToolCall carries a complete tool call in ToolCall
+	RealtimeEventToolCall RealtimeEventType = "tool_call"
+
+	// RealtimeEventInterrupted reports that the user started speaking over
+	// the model; playback of queued audio should stop
+	RealtimeEventInterrupted RealtimeEventType = "interrupted"
+
+	// RealtimeEventTurnDone reports that the model finished responding, with
+	// the turn's usage in Usage if the provider reports it
+	RealtimeEventTurnDone RealtimeEventType = "turn_done"
+
+	// RealtimeEventError carries an error in Err. The session stays open
+	// unless the events channel is closed after it.
+	RealtimeEventError RealtimeEventType = "error"
+)
+
+// RealtimeEvent is an event received from a realtime session
+type RealtimeEvent struct {
+	Type     RealtimeEventType `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Audio    []byte            `json:"audio,omitempty"`
+	ToolCall *ToolCall         `json:"tool_call,omitempty"`
+	Usage    *Usage            `json:"usage,omitempty"`
+	Err      error             `json:"-"`
+}
+
+// RealtimeSession is a low-latency bidirectional session with a model. Input
+// may be sent while events are being received. Send methods are safe for
+// concurrent use.
+type RealtimeSession interface {
+	// SendAudio streams a chunk of the user's speech as 16-bit
+	// little-endian mono PCM (24kHz for OpenAI, 16kHz for Gemini). The
+	// provider detects the end of each utterance and responds.
+	SendAudio(data []byte) error
+
+	// SendText sends a user message, and the model responds
+	SendText(text string) error
+
+	// SendToolResult answers a tool call received as a RealtimeEventToolCall
+	SendToolResult(callID, output string) error
+
+	// Events returns the channel of events from the model. It is closed
+	// when the session ends.
+	Events() <-chan RealtimeEvent
+
+	// Close ends the session
+	Close() error
+}
@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"context"
+	"io"
+)
+
+// contextBody is a response body bound to the lifetime of a context
+type contextBody struct {
+	ctx  context.Context
+	body io.ReadCloser
+	stop func() bool
+}
+
+// NewContextBody wraps a streaming response body so that canceling ctx
+// closes it at once, instead of at the next read. A read that fails because
+// ctx is done returns ctx.Err(). Closing the returned body releases ctx.
+func NewContextBody(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	return &contextBody{
+		ctx:  ctx,
+		body: body,
+		stop: context.AfterFunc(ctx, func() { _ = body.Close() }),
+	}
+}
+
+func (b *contextBody) Read(p []byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := b.body.Read(p)
+	if err != nil {
+		if ctxErr := b.ctx.Err(); ctxErr != nil {
+			return n, ctxErr
+		}
+	}
+	return n, err
+}
+
+func (b *contextBody) Close() error {
+	b.stop()
+	return b.body.Close()
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestNewContextBody(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	body := NewContextBody(ctx, pr)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := body.Read(make([]byte, 16))
+		done <- err
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Read() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Read() did not return after cancel")
+	}
+
+	// The underlying body was closed by the cancel
+	if _, err := pw.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after cancel = %v, want io.ErrClosedPipe", err)
+	}
+	if err := body.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package provider
 
+import "math"
+
 // Role represents the role of a message sender
 type Role string
 
@@ -17,10 +19,78 @@ type Message struct {
 	Name       *string    `json:"name,omitempty"`
 	ToolCallID *string    `json:"tool_call_id,omitempty"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+
+	// Parts holds additional multi-part content (e.g., documents) sent after Content
+	Parts []ContentPart `json:"parts,omitempty"`
+
+	// Annotations carries derived signals persisted with the message (not sent to providers)
+	Annotations []Annotation `json:"annotations,omitempty"`
+
+	// Thinking holds the model's reasoning (Anthropic extended thinking),
+	// kept apart from Content so it can be shown or hidden. In a stream, each
+	// delta carries a fragment. Send it back unchanged with the assistant
+	// message when continuing a tool-use turn.
+	Thinking []ThinkingBlock `json:"thinking,omitempty"`
+
+	// Audio holds spoken output (OpenAI audio models) with its transcript.
+	// Content is empty when the model answers in audio. In a stream, each
+	// delta carries a fragment. Send it back with the assistant message to
+	// continue the conversation; only its ID is sent.
+	Audio *Audio `json:"audio,omitempty"`
+}
+
+// Audio is audio sent in a content part or returned by an audio-capable model
+type Audio struct {
+	Format     string `json:"format,omitempty"`     // e.g., "wav", "mp3", "pcm16"
+	Data       []byte `json:"data,omitempty"`       // Encoded audio bytes
+	ID         string `json:"id,omitempty"`         // OpenAI - references returned audio in later turns
+	Transcript string `json:"transcript,omitempty"` // Text of the spoken output
+	ExpiresAt  int64  `json:"expires_at,omitempty"` // Unix timestamp after which ID can no longer be referenced
+}
+
+// ThinkingBlock is one block of model reasoning
+type ThinkingBlock struct {
+	Text      string `json:"text,omitempty"`
+	Signature string `json:"signature,omitempty"` // Anthropic - verifies the block when it is sent back
+	Redacted  string `json:"redacted,omitempty"`  // Anthropic - encrypted reasoning flagged by safety systems
+}
+
+// ThinkingConfig enables extended thinking
+type ThinkingConfig struct {
+	// BudgetTokens caps the tokens spent on thinking. Anthropic requires at
+	// least 1024, and less than MaxTokens.
+	BudgetTokens int `json:"budget_tokens"`
+}
+
+// ContentPartType identifies the kind of content in a ContentPart
+type ContentPartType string
+
+const (
+	ContentPartTypeText     ContentPartType = "text"
+	ContentPartTypeDocument ContentPartType = "document"
+	ContentPartTypeAudio    ContentPartType = "audio"
+)
+
+// ContentPart is a typed segment of multi-part message content
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Document *Document       `json:"document,omitempty"`
+	Audio    *Audio          `json:"audio,omitempty"` // Format and Data are required
+}
+
+// Document is a document attachment provided either inline or as a reference to an uploaded file
+type Document struct {
+	MIMEType string `json:"mime_type"`          // e.g., "application/pdf", "text/plain"
+	Filename string `json:"filename,omitempty"` // Optional filename shown to the model
+	Data     []byte `json:"data,omitempty"`     // Inline document bytes
+	FileID   string `json:"file_id,omitempty"`  // OpenAI, Anthropic - ID returned by UploadFile
+	FileURI  string `json:"file_uri,omitempty"` // Gemini - URI returned by UploadFile
 }
 
 // ToolCall represents a tool function call
 type ToolCall struct {
+	Index    *int         `json:"index,omitempty"` // Position of the call in a streaming delta
 	ID       string       `json:"id"`
 	Type     string       `json:"type"`
 	Function ToolFunction `json:"function"`
@@ -48,22 +118,71 @@ type ChatCompletionRequest struct {
 	User             *string         `json:"user,omitempty"`
 	Tools            []Tool          `json:"tools,omitempty"`
 	ToolChoice       any             `json:"tool_choice,omitempty"`
-	Seed             *int            `json:"seed,omitempty"`            // OpenAI, X.AI - for reproducible outputs
-	N                *int            `json:"n,omitempty"`               // OpenAI - number of completions
-	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"` // OpenAI, Gemini - JSON mode
-	Logprobs         *bool           `json:"logprobs,omitempty"`        // OpenAI - return log probabilities
-	TopLogprobs      *int            `json:"top_logprobs,omitempty"`    // OpenAI - number of top logprobs
+	Seed             *int            `json:"seed,omitempty"`            // OpenAI, X.AI, Gemini, Ollama - for reproducible outputs
+	N                *int            `json:"n,omitempty"`               // OpenAI, X.AI, Gemini - number of completions
+	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"` // JSON mode or JSON Schema structured output
+	Logprobs         *bool           `json:"logprobs,omitempty"`        // OpenAI, X.AI - return log probabilities
+	TopLogprobs      *int            `json:"top_logprobs,omitempty"`    // OpenAI, X.AI - number of top logprobs
+	Thinking         *ThinkingConfig `json:"thinking,omitempty"`        // Anthropic - extended thinking, returned in Message.Thinking
+
+	// ProviderOptions carries provider-specific options keyed by provider name
+	// (e.g. "openai", "xai"), typically that provider package's Options struct.
+	// Each provider reads only its own entry, via DecodeOptions, and ignores the rest.
+	ProviderOptions map[string]any `json:"provider_options,omitempty"`
+
+	// Headers are extra HTTP headers sent with this request, such as OpenAI
+	// organization and project headers or gateway routing headers. They are
+	// applied after the provider's own headers and replace any with the same
+	// name, except anthropic-beta, which Anthropic merges with its own betas.
+	Headers map[string]string `json:"-"`
+
+	// IncludeRaw asks providers that implement RawCapable to attach the raw
+	// request and response bodies to ChatCompletionResponse.Raw. Streaming
+	// requests and cached responses have none.
+	IncludeRaw bool `json:"-"`
 }
 
+// Response format types
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
 // ResponseFormat specifies the format of the response
 type ResponseFormat struct {
-	Type string `json:"type"` // "text" or "json_object"
+	Type       string      `json:"type"`                  // "text", "json_object", or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"` // Required when Type is "json_schema"
+}
+
+// JSONSchema describes the structured output required by a "json_schema"
+// response format. OpenAI and X.AI enforce it natively, Gemini uses it as the
+// response schema, and Anthropic emulates it by forcing a tool call whose
+// input is the schema.
+type JSONSchema struct {
+	Name        string `json:"name"`                  // Identifier, e.g. "weather_report"
+	Description string `json:"description,omitempty"` // Helps the model understand the output
+	Schema      any    `json:"schema"`                // JSON Schema object
+	Strict      *bool  `json:"strict,omitempty"`      // OpenAI - enforce exact schema adherence
 }
 
 // Tool represents a tool that can be called
 type Tool struct {
-	Type     string   `json:"type"`
-	Function ToolSpec `json:"function"`
+	Type       string          `json:"type"`
+	Function   ToolSpec        `json:"function"`
+	FileSearch *FileSearchTool `json:"file_search,omitempty"` // Set when Type is ToolTypeFileSearch
+}
+
+// Tool types
+const (
+	ToolTypeFunction   = "function"
+	ToolTypeFileSearch = "file_search" // Provider-hosted retrieval over vector stores
+)
+
+// FileSearchTool configures provider-hosted retrieval over vector stores
+type FileSearchTool struct {
+	VectorStoreIDs []string `json:"vector_store_ids"`      // OpenAI vector store IDs or Gemini file search store names
+	MaxResults     *int     `json:"max_results,omitempty"` // Maximum number of retrieved chunks
 }
 
 // ToolSpec defines a tool specification
@@ -83,15 +202,77 @@ type ChatCompletionResponse struct {
 	Choices           []ChatCompletionChoice `json:"choices"`
 	Usage             Usage                  `json:"usage"`
 	ProviderMetadata  map[string]any         `json:"provider_metadata,omitempty"` // Provider-specific metadata
+
+	// Raw is the request and response in the provider's wire format, set when
+	// the request has IncludeRaw and the provider is RawCapable
+	Raw *RawExchange `json:"-"`
 }
 
 // ChatCompletionChoice represents a single choice in the response
 type ChatCompletionChoice struct {
-	Index        int      `json:"index"`
-	Message      Message  `json:"message"`
-	Delta        *Message `json:"delta,omitempty"`
-	FinishReason *string  `json:"finish_reason"`
-	Logprobs     any      `json:"logprobs,omitempty"`
+	Index        int       `json:"index"`
+	Message      Message   `json:"message"`
+	Delta        *Message  `json:"delta,omitempty"`
+	FinishReason *string   `json:"finish_reason"`
+	Logprobs     *Logprobs `json:"logprobs,omitempty"` // Set when the request enables Logprobs
+
+	// Annotations carries derived signals such as safety, confidence, or citations
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// Logprobs holds the log probabilities of the generated tokens
+type Logprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
+
+// TokenLogprob is the log probability of one generated token, with the most
+// likely alternatives at that position when TopLogprobs was requested
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is an alternative token considered at a position
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+// Probability returns the token's probability, exp(Logprob)
+func (t TokenLogprob) Probability() float64 {
+	return math.Exp(t.Logprob)
+}
+
+// Probability returns the alternative's probability, exp(Logprob)
+func (t TopLogprob) Probability() float64 {
+	return math.Exp(t.Logprob)
+}
+
+// Confidence returns the geometric mean probability of the generated tokens,
+// or 0 if there are none. For a single-token classification answer it is the
+// probability of that token.
+func (l *Logprobs) Confidence() float64 {
+	if l == nil || len(l.Content) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, t := range l.Content {
+		sum += t.Logprob
+	}
+	return math.Exp(sum / float64(len(l.Content)))
+}
+
+// Annotation is a derived signal attached to a choice or message by a post-processing
+// component such as a guardrail, judge, moderation check, or citation extractor.
+type Annotation struct {
+	Type   string         `json:"type"`             // e.g., "safety", "confidence", "citation"
+	Source string         `json:"source,omitempty"` // Component that produced the annotation
+	Label  string         `json:"label,omitempty"`  // Short human-readable verdict or title
+	Score  *float64       `json:"score,omitempty"`  // Optional numeric score (0-1 where applicable)
+	Data   map[string]any `json:"data,omitempty"`   // Type-specific details
 }
 
 // Usage represents token usage information
@@ -99,6 +280,35 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	// PromptTokensDetails breaks down PromptTokens, when the provider reports it
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+
+	// CompletionTokensDetails breaks down CompletionTokens, when the provider reports it
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt tokens. Each count is included in
+// Usage.PromptTokens.
+type PromptTokensDetails struct {
+	// CachedTokens were read from the provider's prompt cache, usually at a discount
+	CachedTokens int `json:"cached_tokens"`
+
+	// CacheCreationTokens were written to the prompt cache (Anthropic), usually at a premium
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"`
+
+	// AudioTokens are audio input tokens
+	AudioTokens int `json:"audio_tokens,omitempty"`
+}
+
+// CompletionTokensDetails breaks down completion tokens. Each count is
+// included in Usage.CompletionTokens.
+type CompletionTokensDetails struct {
+	// ReasoningTokens were spent on hidden reasoning and are not part of the message content
+	ReasoningTokens int `json:"reasoning_tokens"`
+
+	// AudioTokens are audio output tokens
+	AudioTokens int `json:"audio_tokens,omitempty"`
 }
 
 // ChatCompletionChunk represents a chunk in streaming response
@@ -112,3 +322,243 @@ type ChatCompletionChunk struct {
 	Usage             *Usage                 `json:"usage,omitempty"`
 	ProviderMetadata  map[string]any         `json:"provider_metadata,omitempty"` // Provider-specific metadata
 }
+
+// ModerationRequest represents a request to classify content for safety
+type ModerationRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model,omitempty"`
+}
+
+// ModerationResponse represents a provider-agnostic moderation result
+type ModerationResponse struct {
+	ID               string             `json:"id"`
+	Model            string             `json:"model"`
+	Results          []ModerationResult `json:"results"`
+	ProviderMetadata map[string]any     `json:"provider_metadata,omitempty"` // Provider-specific metadata
+}
+
+// ModerationResult contains the classification for a single input
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// RerankRequest represents a request to order documents by relevance to a query
+type RerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"` // Number of results to return; 0 returns all documents
+}
+
+// RerankResponse represents a provider-agnostic rerank result
+type RerankResponse struct {
+	Model    string         `json:"model,omitempty"`
+	Provider string         `json:"provider"`
+	Results  []RerankResult `json:"results"` // Most relevant first
+	Usage    RerankUsage    `json:"usage"`
+}
+
+// RerankResult scores a single document
+type RerankResult struct {
+	Index    int     `json:"index"`    // Position of the document in RerankRequest.Documents
+	Score    float64 `json:"score"`    // Relevance score; higher is more relevant
+	Document string  `json:"document"` // The document text
+}
+
+// RerankUsage reports what a rerank request was billed for
+type RerankUsage struct {
+	TotalTokens int `json:"total_tokens,omitempty"` // Voyage, Jina
+	SearchUnits int `json:"search_units,omitempty"` // Cohere
+}
+
+// FIMRequest represents a fill-in-the-middle code completion request: the
+// model generates the text between Prompt and Suffix
+type FIMRequest struct {
+	Model       string   `json:"model"`
+	Prompt      string   `json:"prompt"`           // Code before the cursor
+	Suffix      string   `json:"suffix,omitempty"` // Code after the cursor
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"` // Mistral, Ollama
+}
+
+// FIMResponse represents a provider-agnostic fill-in-the-middle completion
+type FIMResponse struct {
+	ID           string  `json:"id,omitempty"`
+	Model        string  `json:"model"`
+	Provider     string  `json:"provider"`
+	Text         string  `json:"text"` // The generated middle
+	FinishReason *string `json:"finish_reason,omitempty"`
+	Usage        Usage   `json:"usage"`
+}
+
+// TextCompletionRequest represents a legacy (non-chat) text completion
+// request, as served to base models
+type TextCompletionRequest struct {
+	Model            string   `json:"model"`
+	Prompt           string   `json:"prompt"`
+	Suffix           string   `json:"suffix,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	N                *int     `json:"n,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+
+	// Echo returns the prompt before the completion in Text, and in Logprobs
+	// when they are requested
+	Echo bool `json:"echo,omitempty"`
+
+	// Logprobs returns the log probability of each token, with up to this
+	// many alternatives per position. 0 returns no alternatives.
+	Logprobs *int `json:"logprobs,omitempty"`
+}
+
+// TextCompletionResponse represents a provider-agnostic text completion result
+type TextCompletionResponse struct {
+	ID       string                 `json:"id,omitempty"`
+	Created  int64                  `json:"created"`
+	Model    string                 `json:"model"`
+	Provider string                 `json:"provider"`
+	Choices  []TextCompletionChoice `json:"choices"`
+	Usage    Usage                  `json:"usage"`
+}
+
+// TextCompletionChoice is one generated completion
+type TextCompletionChoice struct {
+	Index        int       `json:"index"`
+	Text         string    `json:"text"`
+	FinishReason *string   `json:"finish_reason"`
+	Logprobs     *Logprobs `json:"logprobs,omitempty"` // Set when the request sets Logprobs
+}
+
+// FileUploadRequest represents a request to upload a file to a provider
+type FileUploadRequest struct {
+	Filename string `json:"filename"`
+	MIMEType string `json:"mime_type,omitempty"`
+	Purpose  string `json:"purpose,omitempty"` // OpenAI - e.g., "user_data", "assistants"
+	Data     []byte `json:"-"`
+}
+
+// File is a provider-agnostic handle to an uploaded file
+type File struct {
+	ID        string `json:"id"`                   // Provider file ID (e.g., "file-abc123", "files/abc123")
+	Provider  string `json:"provider"`             // Name of the provider that stores the file
+	Filename  string `json:"filename,omitempty"`   // Original filename or display name
+	MIMEType  string `json:"mime_type,omitempty"`  // Content type of the file
+	Bytes     int64  `json:"bytes,omitempty"`      // Size of the file in bytes
+	Purpose   string `json:"purpose,omitempty"`    // OpenAI - intended use of the file
+	URI       string `json:"uri,omitempty"`        // Gemini - URI used to reference the file in content
+	Status    string `json:"status,omitempty"`     // Processing status reported by the provider
+	CreatedAt int64  `json:"created_at,omitempty"` // Unix timestamp
+	ExpiresAt int64  `json:"expires_at,omitempty"` // Unix timestamp, 0 if the file does not expire
+}
+
+// VectorStoreRequest represents a request to create a provider-hosted vector store
+type VectorStoreRequest struct {
+	Name    string   `json:"name"`
+	FileIDs []string `json:"file_ids,omitempty"` // Previously uploaded files to attach on creation
+}
+
+// VectorStore is a provider-agnostic handle to a provider-hosted vector store
+type VectorStore struct {
+	ID        string `json:"id"`                   // Provider store ID (e.g., "vs_abc123", "fileSearchStores/abc123")
+	Provider  string `json:"provider"`             // Name of the provider that hosts the store
+	Name      string `json:"name,omitempty"`       // Display name
+	FileCount int    `json:"file_count,omitempty"` // Number of files attached to the store
+	Status    string `json:"status,omitempty"`     // Processing status reported by the provider
+	CreatedAt int64  `json:"created_at,omitempty"` // Unix timestamp
+}
+
+// BatchRequest is one request in a batch. Results carry the CustomID back so
+// they can be matched to the request that produced them.
+type BatchRequest struct {
+	CustomID string                 `json:"custom_id"` // Unique within the batch
+	Request  *ChatCompletionRequest `json:"request"`
+}
+
+// Batch processing statuses
+const (
+	BatchStatusInProgress = "in_progress"
+	BatchStatusCanceling  = "canceling"
+	BatchStatusEnded      = "ended"
+)
+
+// Batch is a provider-agnostic handle to an asynchronously processed batch of requests
+type Batch struct {
+	ID        string      `json:"id"`                   // Provider batch ID (e.g., "msgbatch_abc123")
+	Provider  string      `json:"provider"`             // Name of the provider processing the batch
+	Status    string      `json:"status"`               // One of the BatchStatus constants
+	Counts    BatchCounts `json:"counts"`               // Requests by outcome so far
+	CreatedAt int64       `json:"created_at,omitempty"` // Unix timestamp
+	EndedAt   int64       `json:"ended_at,omitempty"`   // Unix timestamp, 0 until processing ends
+	ExpiresAt int64       `json:"expires_at,omitempty"` // Unix timestamp after which unfinished requests expire
+}
+
+// Done returns true once the batch has finished processing and its results can be fetched
+func (b *Batch) Done() bool {
+	return b.Status == BatchStatusEnded
+}
+
+// BatchCounts tallies the requests in a batch by outcome
+type BatchCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// BatchResult is the outcome of one request in a batch. Exactly one of
+// Response and Err is set.
+type BatchResult struct {
+	CustomID string                  `json:"custom_id"`
+	Request  *ChatCompletionRequest  `json:"-"` // The original request, if it was supplied when fetching results
+	Response *ChatCompletionResponse `json:"response,omitempty"`
+	Err      error                   `json:"-"` // *APIError, ErrBatchRequestCanceled, or ErrBatchRequestExpired
+}
+
+// Model describes a model reported by a provider's models endpoint. Fields the
+// provider does not report are left zero.
+type Model struct {
+	ID              string            `json:"id"`
+	Provider        string            `json:"provider"`
+	DisplayName     string            `json:"display_name,omitempty"`
+	Created         int64             `json:"created,omitempty"`           // Unix seconds
+	OwnedBy         string            `json:"owned_by,omitempty"`          // OpenAI
+	ContextWindow   int               `json:"context_window,omitempty"`    // Max input tokens
+	MaxOutputTokens int               `json:"max_output_tokens,omitempty"` // Max generated tokens
+	Capabilities    ModelCapabilities `json:"capabilities"`
+}
+
+// ModelCapabilities flags what a model supports, as far as the provider
+// reports or its model family implies
+type ModelCapabilities struct {
+	Chat       bool `json:"chat"`
+	Streaming  bool `json:"streaming"`
+	Tools      bool `json:"tools"`
+	Vision     bool `json:"vision"`
+	Embeddings bool `json:"embeddings"`
+	JSONMode   bool `json:"json_mode"` // Native JSON output via ResponseFormat
+}
+
+// Capabilities flags the request features a provider adapter can send, so
+// requests using anything else can be rejected or rerouted before they
+// reach the provider. Individual models may support less; see
+// ModelCapabilities.
+type Capabilities struct {
+	Streaming       bool `json:"streaming"`
+	Tools           bool `json:"tools"`            // Function tools
+	FileSearch      bool `json:"file_search"`      // Provider-hosted file_search tools over vector stores
+	Vision          bool `json:"vision"`           // Image documents (image/* MIME types)
+	JSONSchema      bool `json:"json_schema"`      // "json_schema" response format, natively or emulated
+	Logprobs        bool `json:"logprobs"`         // Logprobs and TopLogprobs
+	MultipleChoices bool `json:"multiple_choices"` // N > 1
+	Audio           bool `json:"audio"`            // Audio content parts
+}
@@ -0,0 +1,390 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidRequest is matched, via errors.Is, by every ValidationError
+var ErrInvalidRequest = errors.New("invalid request")
+
+// DefaultMaxDocumentBytes is the largest inline document accepted by request
+// validation. Individual providers may enforce lower limits.
+const DefaultMaxDocumentBytes = 32 << 20
+
+// toolNamePattern matches tool names accepted by all supported providers
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ValidationError describes a single problem found while validating a request
+type ValidationError struct {
+	// Field is the request field that failed validation (e.g., "model", "tools[0].function.name")
+	Field string
+
+	// Message describes the problem
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidRequest) to match validation errors
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidRequest
+}
+
+// ValidationErrors is a multi-error containing every validation problem found in a request
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, ve := range e {
+		msgs = append(msgs, ve.Error())
+	}
+	return fmt.Sprintf("invalid request (%d problems): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the individual validation errors for use with errors.Is and errors.As
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, ve := range e {
+		errs = append(errs, ve)
+	}
+	return errs
+}
+
+// ValidateRequest checks a request for problems that providers would reject:
+// missing model or messages, out-of-range sampling parameters, mutually
+// exclusive parameters, and malformed tools and schemas. It returns nil or a
+// ValidationErrors listing every problem found, without making any calls.
+func ValidateRequest(req *ChatCompletionRequest) error {
+	if req == nil {
+		return ValidationErrors{{Field: "request", Message: "request cannot be nil"}}
+	}
+
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(req.Model) == "" {
+		add("model", "model cannot be empty")
+	}
+	if len(req.Messages) == 0 {
+		add("messages", "messages cannot be empty")
+	}
+
+	for i, msg := range req.Messages {
+		switch msg.Role {
+		case RoleSystem, RoleUser, RoleAssistant:
+		case RoleTool:
+			if msg.ToolCallID == nil || *msg.ToolCallID == "" {
+				add(fmt.Sprintf("messages[%d].tool_call_id", i), "tool messages require a tool_call_id")
+			}
+		default:
+			add(fmt.Sprintf("messages[%d].role", i), "unknown role %q", msg.Role)
+		}
+		for j, part := range msg.Parts {
+			field := fmt.Sprintf("messages[%d].parts[%d]", i, j)
+			switch part.Type {
+			case ContentPartTypeText:
+			case ContentPartTypeDocument:
+				doc := part.Document
+				switch {
+				case doc == nil:
+					add(field, "document part requires a document")
+				case len(doc.Data) == 0 && doc.FileID == "" && doc.FileURI == "":
+					add(field, "document requires data, a file ID, or a file URI")
+				case len(doc.Data) > DefaultMaxDocumentBytes:
+					add(field, "document is %d bytes, exceeds limit of %d bytes", len(doc.Data), DefaultMaxDocumentBytes)
+				}
+			case ContentPartTypeAudio:
+				switch {
+				case part.Audio == nil:
+					add(field, "audio part requires audio")
+				case len(part.Audio.Data) == 0:
+					add(field, "audio requires data")
+				case part.Audio.Format == "":
+					add(field, "audio requires a format")
+				}
+			default:
+				add(field, "unknown content part type %q", part.Type)
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(req.Headers)) {
+		if value := req.Headers[name]; !validHeaderName(name) {
+			add("headers", "invalid header name %q", name)
+		} else if strings.ContainsAny(value, "\r\n") {
+			add("headers."+name, "header value cannot contain line breaks")
+		}
+	}
+
+	if req.MaxTokens != nil && *req.MaxTokens <= 0 {
+		add("max_tokens", "must be positive, got %d", *req.MaxTokens)
+	}
+	if req.Temperature != nil && (*req.Temperature < 0 || *req.Temperature > 2) {
+		add("temperature", "must be between 0 and 2, got %g", *req.Temperature)
+	}
+	if req.TopP != nil && (*req.TopP < 0 || *req.TopP > 1) {
+		add("top_p", "must be between 0 and 1, got %g", *req.TopP)
+	}
+	if req.TopK != nil && *req.TopK <= 0 {
+		add("top_k", "must be positive, got %d", *req.TopK)
+	}
+	if req.PresencePenalty != nil && (*req.PresencePenalty < -2 || *req.PresencePenalty > 2) {
+		add("presence_penalty", "must be between -2 and 2, got %g", *req.PresencePenalty)
+	}
+	if req.FrequencyPenalty != nil && (*req.FrequencyPenalty < -2 || *req.FrequencyPenalty > 2) {
+		add("frequency_penalty", "must be between -2 and 2, got %g", *req.FrequencyPenalty)
+	}
+	if req.N != nil && *req.N < 1 {
+		add("n", "must be at least 1, got %d", *req.N)
+	}
+	if req.TopLogprobs != nil && (req.Logprobs == nil || !*req.Logprobs) {
+		add("top_logprobs", "requires logprobs to be enabled")
+	}
+	if t := req.Thinking; t != nil {
+		if t.BudgetTokens < 1024 {
+			add("thinking.budget_tokens", "must be at least 1024, got %d", t.BudgetTokens)
+		} else if req.MaxTokens != nil && t.BudgetTokens >= *req.MaxTokens {
+			add("thinking.budget_tokens", "must be less than max_tokens (%d), got %d", *req.MaxTokens, t.BudgetTokens)
+		}
+		// Extended thinking fixes the sampling parameters
+		if req.Temperature != nil && *req.Temperature != 1 {
+			add("temperature", "cannot be combined with thinking unless it is 1, got %g", *req.Temperature)
+		}
+		if req.TopK != nil {
+			add("top_k", "cannot be combined with thinking")
+		}
+	}
+	if req.ToolChoice != nil && len(req.Tools) == 0 {
+		add("tool_choice", "cannot be set without tools")
+	}
+	if rf := req.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "", ResponseFormatText, ResponseFormatJSONObject:
+		case ResponseFormatJSONSchema:
+			switch {
+			case rf.JSONSchema == nil || rf.JSONSchema.Schema == nil:
+				add("response_format.json_schema", "json_schema response format requires a schema")
+			case !toolNamePattern.MatchString(rf.JSONSchema.Name):
+				add("response_format.json_schema.name", "invalid schema name %q (must match %s)", rf.JSONSchema.Name, toolNamePattern.String())
+			default:
+				if msg := validateToolParameters(rf.JSONSchema.Schema); msg != "" {
+					add("response_format.json_schema.schema", "%s", msg)
+				}
+			}
+		default:
+			add("response_format.type", "unsupported response format %q", rf.Type)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, tool := range req.Tools {
+		field := fmt.Sprintf("tools[%d]", i)
+		switch tool.Type {
+		case ToolTypeFunction:
+		case ToolTypeFileSearch:
+			if tool.FileSearch == nil || len(tool.FileSearch.VectorStoreIDs) == 0 {
+				add(field+".file_search.vector_store_ids", "file_search tools require at least one vector store")
+			}
+			if tool.FileSearch != nil && tool.FileSearch.MaxResults != nil && *tool.FileSearch.MaxResults <= 0 {
+				add(field+".file_search.max_results", "must be positive, got %d", *tool.FileSearch.MaxResults)
+			}
+			continue
+		default:
+			add(field+".type", "unsupported tool type %q", tool.Type)
+		}
+		name := tool.Function.Name
+		if !toolNamePattern.MatchString(name) {
+			add(field+".function.name", "invalid tool name %q (must match %s)", name, toolNamePattern.String())
+		} else if seen[name] {
+			add(field+".function.name", "duplicate tool name %q", name)
+		}
+		seen[name] = true
+		if msg := validateToolParameters(tool.Function.Parameters); msg != "" {
+			add(field+".function.parameters", "%s", msg)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateToolParameters checks that tool parameters describe a JSON Schema object.
+// Returns an empty string if valid.
+func validateToolParameters(params any) string {
+	if params == nil {
+		return ""
+	}
+
+	schema, ok := params.(map[string]any)
+	if !ok {
+		// Typed schemas (structs, json.RawMessage) are passed through as-is
+		return ""
+	}
+
+	if t, ok := schema["type"]; ok && t != "object" {
+		return fmt.Sprintf("schema type must be \"object\", got %v", t)
+	}
+	if props, ok := schema["properties"]; ok {
+		if _, ok := props.(map[string]any); !ok {
+			return "schema properties must be an object"
+		}
+	}
+	if required, ok := schema["required"]; ok {
+		props, _ := schema["properties"].(map[string]any)
+		var names []string
+		switch r := required.(type) {
+		case []string:
+			names = r
+		case []any:
+			for _, v := range r {
+				s, ok := v.(string)
+				if !ok {
+					return "schema required must be a list of strings"
+				}
+				names = append(names, s)
+			}
+		default:
+			return "schema required must be a list of strings"
+		}
+		for _, n := range names {
+			if _, ok := props[n]; !ok {
+				return fmt.Sprintf("required property %q is not defined in properties", n)
+			}
+		}
+	}
+
+	return ""
+}
+
+// ValidateCapabilities checks a request against the capabilities of the model
+// it targets, such as tools sent to a model without tool support. It returns
+// nil or a ValidationErrors listing every mismatch.
+func ValidateCapabilities(req *ChatCompletionRequest, caps ModelCapabilities) error {
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, &ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !caps.Chat {
+		add("model", "model %q does not support chat completions", req.Model)
+	}
+	if len(req.Tools) > 0 && !caps.Tools {
+		add("tools", "model %q does not support tools", req.Model)
+	}
+	if req.Stream != nil && *req.Stream && !caps.Streaming {
+		add("stream", "model %q does not support streaming", req.Model)
+	}
+	// json_schema is not checked: providers without native JSON output
+	// emulate it with a tool call
+	if rf := req.ResponseFormat; rf != nil && rf.Type == ResponseFormatJSONObject && !caps.JSONMode {
+		add("response_format", "model %q does not support JSON output", req.Model)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ErrUnsupportedCapability is matched, via errors.Is, by every CapabilityError
+var ErrUnsupportedCapability = errors.New("capability not supported by provider")
+
+// CapabilityError reports request features a provider does not support. It
+// also matches ErrInvalidRequest, since sending the request unchanged will
+// fail again.
+type CapabilityError struct {
+	Provider string   `json:"provider"`
+	Missing  []string `json:"missing"` // e.g. "tools", "logprobs"
+}
+
+func (e *CapabilityError) Error() string {
+	return fmt.Sprintf("[%s] request uses unsupported features: %s", e.Provider, strings.Join(e.Missing, ", "))
+}
+
+// Is reports whether target is ErrUnsupportedCapability or ErrInvalidRequest
+func (e *CapabilityError) Is(target error) bool {
+	return target == ErrUnsupportedCapability || target == ErrInvalidRequest
+}
+
+// CheckCapabilities checks a request against the capabilities p reports. It
+// returns nil if p does not implement CapabilityProvider or supports every
+// feature the request uses, and a *CapabilityError otherwise. stream is true
+// when the request will be streamed, whatever req.Stream says.
+func CheckCapabilities(p Provider, req *ChatCompletionRequest, stream bool) error {
+	cp, ok := p.(CapabilityProvider)
+	if !ok || req == nil {
+		return nil
+	}
+	if missing := MissingCapabilities(req, cp.Capabilities(), stream); len(missing) > 0 {
+		return &CapabilityError{Provider: p.Name(), Missing: missing}
+	}
+	return nil
+}
+
+// MissingCapabilities returns the names of the features req uses that caps
+// does not include, in a fixed order
+func MissingCapabilities(req *ChatCompletionRequest, caps Capabilities, stream bool) []string {
+	var missing []string
+	need := func(name string, required, supported bool) {
+		if required && !supported {
+			missing = append(missing, name)
+		}
+	}
+
+	need("streaming", stream || (req.Stream != nil && *req.Stream), caps.Streaming)
+	need("tools", hasFunctionTools(req.Tools), caps.Tools)
+	need("file_search", hasFileSearchTools(req.Tools), caps.FileSearch)
+	need("vision", hasPart(req.Messages, func(part ContentPart) bool {
+		return part.Type == ContentPartTypeDocument && part.Document != nil && strings.HasPrefix(part.Document.MIMEType, "image/")
+	}), caps.Vision)
+	need("json_schema", req.ResponseFormat != nil && req.ResponseFormat.Type == ResponseFormatJSONSchema, caps.JSONSchema)
+	need("logprobs", (req.Logprobs != nil && *req.Logprobs) || req.TopLogprobs != nil, caps.Logprobs)
+	need("n > 1", req.N != nil && *req.N > 1, caps.MultipleChoices)
+	need("audio", hasPart(req.Messages, func(part ContentPart) bool {
+		return part.Type == ContentPartTypeAudio
+	}), caps.Audio)
+	return missing
+}
+
+// hasFunctionTools returns true if tools includes a function tool
+func hasFunctionTools(tools []Tool) bool {
+	for _, tool := range tools {
+		if tool.Type != ToolTypeFileSearch {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFileSearchTools returns true if tools includes a file_search tool
+func hasFileSearchTools(tools []Tool) bool {
+	for _, tool := range tools {
+		if tool.Type == ToolTypeFileSearch {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPart returns true if any message has a content part matching match
+func hasPart(messages []Message, match func(ContentPart) bool) bool {
+	for _, msg := range messages {
+		for _, part := range msg.Parts {
+			if match(part) {
+				return true
+			}
+		}
+	}
+	return false
+}
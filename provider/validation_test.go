@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidateRequest(t *testing.T) {
+	temp := 0.5
+	topK := 40
+	user := []Message{{Role: RoleUser, Content: "hi"}}
+
+	tests := []struct {
+		name   string
+		req    *ChatCompletionRequest
+		fields []string
+	}{
+		{"valid", &ChatCompletionRequest{Model: "m", Messages: user}, nil},
+		{"nil request", nil, []string{"request"}},
+		{"empty", &ChatCompletionRequest{}, []string{"model", "messages"}},
+		{"thinking with sampling", &ChatCompletionRequest{
+			Model: "m", Messages: user, Temperature: &temp, TopK: &topK,
+			Thinking: &ThinkingConfig{BudgetTokens: 2048},
+		}, []string{"temperature", "top_k"}},
+		{"audio without format", &ChatCompletionRequest{Model: "m", Messages: []Message{{
+			Role: RoleUser, Parts: []ContentPart{{Type: ContentPartTypeAudio, Audio: &Audio{Data: []byte("RIFF")}}},
+		}}}, []string{"messages[0].parts[0]"}},
+		{"headers", &ChatCompletionRequest{Model: "m", Messages: user, Headers: map[string]string{
+			"Bad Name": "v", "X-Trace": "a\r\nInjected: 1", "OpenAI-Project": "proj_1",
+		}}, []string{"headers", "headers.X-Trace"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequest(tt.req)
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateRequest() = %v, want nil", err)
+				}
+				return
+			}
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) || !errors.Is(err, ErrInvalidRequest) {
+				t.Fatalf("ValidateRequest() = %v, want ValidationErrors", err)
+			}
+			if len(verrs) != len(tt.fields) {
+				t.Fatalf("got %d problems, want %d: %v", len(verrs), len(tt.fields), err)
+			}
+			for i, field := range tt.fields {
+				if verrs[i].Field != field {
+					t.Errorf("problem %d field = %q, want %q", i, verrs[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCapabilities(t *testing.T) {
+	stream := true
+	req := &ChatCompletionRequest{
+		Model:          "m",
+		Tools:          []Tool{{Type: ToolTypeFunction, Function: ToolSpec{Name: "lookup"}}},
+		Stream:         &stream,
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject},
+	}
+
+	if err := ValidateCapabilities(req, ModelCapabilities{Chat: true, Streaming: true, Tools: true, JSONMode: true}); err != nil {
+		t.Errorf("expected a capable model to pass, got %v", err)
+	}
+
+	var verrs ValidationErrors
+	if err := ValidateCapabilities(req, ModelCapabilities{Chat: true}); !errors.As(err, &verrs) || len(verrs) != 3 {
+		t.Fatalf("expected tools, stream, and response_format problems, got %v", err)
+	}
+
+	// json_schema is emulated by providers without native JSON output
+	req = &ChatCompletionRequest{Model: "m", ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONSchema}}
+	if err := ValidateCapabilities(req, ModelCapabilities{Chat: true}); err != nil {
+		t.Errorf("expected json_schema to pass without JSON mode, got %v", err)
+	}
+}
+
+// capableProvider is a stub Provider that reports caps
+type capableProvider struct {
+	Provider
+	caps Capabilities
+}
+
+func (p *capableProvider) Name() string               { return "stub" }
+func (p *capableProvider) Capabilities() Capabilities { return p.caps }
+
+func TestCheckCapabilities(t *testing.T) {
+	n, logprobs := 2, true
+	req := &ChatCompletionRequest{
+		Model: "m",
+		Messages: []Message{{Role: RoleUser, Content: "Describe these.", Parts: []ContentPart{
+			{Type: ContentPartTypeDocument, Document: &Document{MIMEType: "image/png", Data: []byte{1}}},
+			{Type: ContentPartTypeAudio, Audio: &Audio{Format: "wav", Data: []byte{1}}},
+		}}},
+		Tools: []Tool{
+			{Type: ToolTypeFunction, Function: ToolSpec{Name: "lookup"}},
+			{Type: ToolTypeFileSearch, FileSearch: &FileSearchTool{VectorStoreIDs: []string{"vs_1"}}},
+		},
+		ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONSchema},
+		Logprobs:       &logprobs,
+		N:              &n,
+	}
+
+	all := Capabilities{Streaming: true, Tools: true, FileSearch: true, Vision: true, JSONSchema: true, Logprobs: true, MultipleChoices: true, Audio: true}
+	if err := CheckCapabilities(&capableProvider{caps: all}, req, true); err != nil {
+		t.Errorf("expected a capable provider to pass, got %v", err)
+	}
+
+	err := CheckCapabilities(&capableProvider{}, req, true)
+	var capErr *CapabilityError
+	if !errors.As(err, &capErr) || !errors.Is(err, ErrUnsupportedCapability) || !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("CheckCapabilities() = %v, want CapabilityError", err)
+	}
+	want := []string{"streaming", "tools", "file_search", "vision", "json_schema", "logprobs", "n > 1", "audio"}
+	if capErr.Provider != "stub" || !slices.Equal(capErr.Missing, want) {
+		t.Errorf("CapabilityError = %+v, want missing %v", capErr, want)
+	}
+
+	// Hosted tools are not function tools, and providers that do not report
+	// capabilities are not checked
+	req = &ChatCompletionRequest{Model: "m", Tools: []Tool{{Type: ToolTypeFileSearch}}}
+	if err := CheckCapabilities(&capableProvider{caps: Capabilities{FileSearch: true}}, req, false); err != nil {
+		t.Errorf("expected file search to pass without tools, got %v", err)
+	}
+	if err := CheckCapabilities(struct{ Provider }{}, &ChatCompletionRequest{N: &n}, true); err != nil {
+		t.Errorf("expected an unreporting provider to pass, got %v", err)
+	}
+}
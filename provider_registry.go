@@ -0,0 +1,59 @@
+package omnillm
+
+import (
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ProviderFactory builds a provider from its configuration
+type ProviderFactory func(config ProviderConfig) (provider.Provider, error)
+
+var (
+	providerFactoriesMu sync.RWMutex
+	providerFactories   = make(map[ProviderName]ProviderFactory)
+)
+
+// RegisterProviderFactory makes a provider available by name in
+// ProviderConfig.Provider, for the primary provider, fallbacks, and key pools
+// alike. It lets external modules such as omnillm-bedrock plug in without
+// every caller constructing a CustomProvider:
+//
+//	func init() {
+//		omnillm.RegisterProviderFactory(omnillm.ProviderNameBedrock, func(c omnillm.ProviderConfig) (provider.Provider, error) {
+//			return NewProvider(c.Region)
+//		})
+//	}
+//
+// A registered factory takes precedence over the built-in one of the same
+// name. Registering a name again replaces its factory, and a nil factory
+// removes the registration.
+func RegisterProviderFactory(name ProviderName, factory ProviderFactory) {
+	providerFactoriesMu.Lock()
+	defer providerFactoriesMu.Unlock()
+	if factory == nil {
+		delete(providerFactories, name)
+		return
+	}
+	providerFactories[name] = factory
+}
+
+// RegisteredProviders returns the names of providers registered with
+// RegisterProviderFactory
+func RegisteredProviders() []ProviderName {
+	providerFactoriesMu.RLock()
+	defer providerFactoriesMu.RUnlock()
+	names := make([]ProviderName, 0, len(providerFactories))
+	for name := range providerFactories {
+		names = append(names, name)
+	}
+	return names
+}
+
+// lookupProviderFactory returns the registered factory for a provider name
+func lookupProviderFactory(name ProviderName) (ProviderFactory, bool) {
+	providerFactoriesMu.RLock()
+	defer providerFactoriesMu.RUnlock()
+	factory, ok := providerFactories[name]
+	return factory, ok
+}
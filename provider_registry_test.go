@@ -0,0 +1,67 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestRegisterProviderFactory_Fallback(t *testing.T) {
+	var gotRegion string
+	bedrock := NewMockProvider("bedrock")
+	RegisterProviderFactory(ProviderNameBedrock, func(config ProviderConfig) (provider.Provider, error) {
+		gotRegion = config.Region
+		return bedrock, nil
+	})
+	t.Cleanup(func() { RegisterProviderFactory(ProviderNameBedrock, nil) })
+
+	if !slices.Contains(RegisteredProviders(), ProviderNameBedrock) {
+		t.Errorf("RegisteredProviders() = %v, want bedrock", RegisteredProviders())
+	}
+
+	primary := NewMockProvider("primary")
+	primary.completionError = &provider.APIError{StatusCode: 503, Message: "unavailable"}
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: primary},
+			{Provider: ProviderNameBedrock, Region: "us-east-1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if gotRegion != "us-east-1" {
+		t.Errorf("factory got region %q", gotRegion)
+	}
+
+	resp, err := client.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion failed: %v", err)
+	}
+	if resp == nil {
+		t.Fatal("expected response from bedrock fallback")
+	}
+}
+
+func TestRegisterProviderFactory_Unregistered(t *testing.T) {
+	_, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{Provider: ProviderNameBedrock, Region: "us-east-1"}},
+	})
+	if !errors.Is(err, ErrBedrockExternal) {
+		t.Errorf("error = %v, want ErrBedrockExternal", err)
+	}
+
+	RegisterProviderFactory("acme", func(config ProviderConfig) (provider.Provider, error) {
+		return nil, ErrEmptyAPIKey
+	})
+	RegisterProviderFactory("acme", nil)
+	if _, err := buildProviderFromConfig(ProviderConfig{Provider: "acme"}); !errors.Is(err, ErrUnsupportedProvider) {
+		t.Errorf("error after unregistering = %v, want ErrUnsupportedProvider", err)
+	}
+}
@@ -1,24 +1,31 @@
 package omnillm
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/plexusone/omnillm/provider"
 	"github.com/plexusone/omnillm/providers/anthropic"
 	"github.com/plexusone/omnillm/providers/gemini"
+	"github.com/plexusone/omnillm/providers/huggingface"
 	"github.com/plexusone/omnillm/providers/ollama"
 	"github.com/plexusone/omnillm/providers/openai"
 	"github.com/plexusone/omnillm/providers/xai"
 )
 
 // getHTTPClientFromProviderConfig returns the HTTPClient from config, or creates one with the
-// configured Timeout. Returns nil if neither is set (provider will use defaults).
+// configured Timeout and Transport. Returns nil if none is set (provider will use defaults).
 func getHTTPClientFromProviderConfig(config ProviderConfig) *http.Client {
 	if config.HTTPClient != nil {
 		return config.HTTPClient
 	}
-	if config.Timeout > 0 {
-		return &http.Client{Timeout: config.Timeout}
+	if config.Timeout > 0 || config.Transport != nil {
+		timeout := config.Timeout
+		if timeout == 0 {
+			timeout = defaultHTTPTimeout(config.Provider)
+		}
+		return &http.Client{Timeout: timeout, Transport: config.Transport}
 	}
 	return nil
 }
@@ -49,7 +56,7 @@ func newGeminiProvider(config ProviderConfig) (provider.Provider, error) {
 	if config.APIKey == "" {
 		return nil, ErrEmptyAPIKey
 	}
-	return gemini.NewProvider(config.APIKey), nil
+	return gemini.NewProvider(config.APIKey, config.BaseURL, getHTTPClientFromProviderConfig(config)), nil
 }
 
 // newXAIProvider creates a new X.AI provider adapter
@@ -59,3 +66,30 @@ func newXAIProvider(config ProviderConfig) (provider.Provider, error) {
 	}
 	return xai.NewProvider(config.APIKey, config.BaseURL, getHTTPClientFromProviderConfig(config)), nil
 }
+
+// newHuggingFaceProvider creates a new Hugging Face provider adapter. The
+// "loading_timeout" Extra key (a time.Duration or duration string) sets how
+// long requests wait for a cold model to load.
+func newHuggingFaceProvider(config ProviderConfig) (provider.Provider, error) {
+	if config.APIKey == "" {
+		return nil, ErrEmptyAPIKey
+	}
+	p := huggingface.NewProvider(config.APIKey, config.BaseURL, getHTTPClientFromProviderConfig(config))
+	if v, ok := config.Extra["loading_timeout"]; ok {
+		var timeout time.Duration
+		switch t := v.(type) {
+		case time.Duration:
+			timeout = t
+		case string:
+			parsed, err := time.ParseDuration(t)
+			if err != nil {
+				return nil, fmt.Errorf("%w: loading_timeout: %w", ErrInvalidConfiguration, err)
+			}
+			timeout = parsed
+		default:
+			return nil, fmt.Errorf("%w: loading_timeout must be a duration, got %T", ErrInvalidConfiguration, v)
+		}
+		p.(*huggingface.Provider).SetLoadingTimeout(timeout)
+	}
+	return p, nil
+}
@@ -2,13 +2,29 @@
 package anthropic
 
 import (
+	"cmp"
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/plexusone/omnillm/provider"
 )
 
+// MaxDocumentBytes is the largest inline document accepted by the Anthropic API
+const MaxDocumentBytes = 32 << 20
+
+// filesAPIBeta is the beta header value required to reference uploaded files
+const filesAPIBeta = "files-api-2025-04-14"
+
+// defaultStructuredOutputTool names the tool used to emulate a JSON Schema
+// response format when the schema has no name
+const defaultStructuredOutputTool = "structured_output"
+
 // Provider represents the Anthropic provider adapter
 type Provider struct {
 	client *Client
@@ -25,37 +41,17 @@ func (p *Provider) Name() string {
 	return p.client.Name()
 }
 
+// Capabilities returns the request features the Anthropic adapter supports.
+// JSON Schema output is emulated with a forced tool call.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Streaming: true, Tools: true, JSONSchema: true}
+}
+
 // CreateChatCompletion creates a chat completion
 func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
-	// Convert from unified format to Anthropic format
-	anthropicReq := &Request{
-		Model:       req.Model,
-		MaxTokens:   4096, // Default
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
-		TopK:        req.TopK,
-	}
-
-	if req.MaxTokens != nil {
-		anthropicReq.MaxTokens = *req.MaxTokens
-	}
-
-	// Convert messages (Anthropic separates system messages)
-	var systemMessage string
-	for _, msg := range req.Messages {
-		switch msg.Role {
-		case provider.RoleSystem:
-			systemMessage = msg.Content
-		case provider.RoleUser, provider.RoleAssistant:
-			anthropicReq.Messages = append(anthropicReq.Messages, Message{
-				Role:    string(msg.Role),
-				Content: msg.Content,
-			})
-		}
-	}
-
-	if systemMessage != "" {
-		anthropicReq.System = systemMessage
+	anthropicReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := p.client.CreateCompletion(ctx, anthropicReq)
@@ -63,10 +59,51 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 		return nil, err
 	}
 
-	// Convert back to unified format
-	var content string
-	if len(resp.Content) > 0 && resp.Content[0].Type == "text" {
-		content = resp.Content[0].Text
+	return convertResponse(req, resp), nil
+}
+
+// convertResponse converts an Anthropic response to unified format. req is
+// the request that produced it, used to unwrap emulated structured output;
+// it may be nil.
+func convertResponse(req *provider.ChatCompletionRequest, resp *Response) *provider.ChatCompletionResponse {
+	// Convert back to unified format. Thinking blocks precede the text, so
+	// the text blocks are collected rather than taking the first block.
+	var text strings.Builder
+	var thinking []provider.ThinkingBlock
+	var toolCalls []provider.ToolCall
+	structuredTool := structuredOutputTool(req)
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "thinking":
+			thinking = append(thinking, provider.ThinkingBlock{Text: block.Thinking, Signature: block.Signature})
+		case "redacted_thinking":
+			thinking = append(thinking, provider.ThinkingBlock{Redacted: block.Data})
+		case "tool_use":
+			if block.Name != structuredTool {
+				toolCalls = append(toolCalls, provider.ToolCall{
+					ID:       block.ID,
+					Type:     "function",
+					Function: provider.ToolFunction{Name: block.Name, Arguments: string(block.Input)},
+				})
+			}
+		}
+	}
+	content := text.String()
+	stopReason := resp.StopReason
+
+	// A forced structured output tool call is the answer itself
+	if name := structuredTool; name != "" {
+		for _, block := range resp.Content {
+			if block.Type == "tool_use" && block.Name == name {
+				content = string(block.Input)
+				break
+			}
+		}
+		if stopReason == "tool_use" {
+			stopReason = "end_turn"
+		}
 	}
 
 	// Preserve Anthropic-specific metadata
@@ -77,7 +114,7 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 		"anthropic_stop_reason": resp.StopReason,
 	}
 
-	return &provider.ChatCompletionResponse{
+	result := &provider.ChatCompletionResponse{
 		ID:      resp.ID,
 		Object:  "chat.completion",
 		Created: time.Now().Unix(),
@@ -86,24 +123,82 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 			{
 				Index: 0,
 				Message: provider.Message{
-					Role:    provider.RoleAssistant,
-					Content: content,
+					Role:      provider.RoleAssistant,
+					Content:   content,
+					ToolCalls: toolCalls,
+					Thinking:  thinking,
 				},
-				FinishReason: &resp.StopReason,
+				FinishReason: &stopReason,
 			},
 		},
-		Usage: provider.Usage{
-			PromptTokens:     resp.Usage.InputTokens,
-			CompletionTokens: resp.Usage.OutputTokens,
-			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
-		},
+		Usage:            convertUsage(resp.Usage),
 		ProviderMetadata: metadata,
-	}, nil
+	}
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	if req != nil {
+		// Anthropic has no seed parameter
+		result.ProviderMetadata = provider.RecordDeterminism(result.ProviderMetadata, req.Seed, false, "")
+	}
+	result.ProviderMetadata = provider.RecordRequestID(result.ProviderMetadata, resp.RequestID)
+	result.Raw = resp.Raw
+	return result
+}
+
+// MarshalChatCompletionRequest returns the request body sent for req
+func (p *Provider) MarshalChatCompletionRequest(req *provider.ChatCompletionRequest) ([]byte, error) {
+	anthropicReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(anthropicReq)
 }
 
 // CreateChatCompletionStream creates a streaming chat completion
 func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
-	// Convert from unified format to Anthropic format
+	anthropicReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := p.client.CreateCompletionStream(ctx, anthropicReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamAdapter{stream: stream, structuredTool: structuredOutputTool(req), seed: req.Seed}, nil
+}
+
+// ListModels lists the available Claude models. Current Claude models all
+// support tools, vision, and streaming.
+func (p *Provider) ListModels(ctx context.Context) ([]provider.Model, error) {
+	infos, err := p.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]provider.Model, 0, len(infos))
+	for _, info := range infos {
+		model := provider.Model{
+			ID:           info.ID,
+			Provider:     p.Name(),
+			DisplayName:  info.DisplayName,
+			Capabilities: provider.ModelCapabilities{Chat: true, Streaming: true, Tools: true, Vision: true},
+		}
+		if created, err := time.Parse(time.RFC3339, info.CreatedAt); err == nil {
+			model.Created = created.Unix()
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// Close closes the provider
+func (p *Provider) Close() error {
+	return p.client.Close()
+}
+
+// convertRequest converts a unified request to Anthropic format
+func convertRequest(req *provider.ChatCompletionRequest) (*Request, error) {
 	anthropicReq := &Request{
 		Model:       req.Model,
 		MaxTokens:   4096, // Default
@@ -115,36 +210,292 @@ func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider
 	if req.MaxTokens != nil {
 		anthropicReq.MaxTokens = *req.MaxTokens
 	}
+	if req.Thinking != nil && req.Thinking.BudgetTokens > 0 {
+		anthropicReq.Thinking = &Thinking{Type: "enabled", BudgetTokens: req.Thinking.BudgetTokens}
+	}
 
-	// Convert messages (Anthropic separates system messages)
-	var systemMessage string
+	// Convert messages (Anthropic takes one system prompt, so system
+	// messages are joined in order)
+	var systemParts []string
 	for _, msg := range req.Messages {
 		switch msg.Role {
 		case provider.RoleSystem:
-			systemMessage = msg.Content
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
 		case provider.RoleUser, provider.RoleAssistant:
-			anthropicReq.Messages = append(anthropicReq.Messages, Message{
+			anthropicMsg := Message{
 				Role:    string(msg.Role),
 				Content: msg.Content,
-			})
+			}
+			if msg.Role == provider.RoleAssistant {
+				anthropicMsg.Thinking = convertThinking(msg.Thinking)
+			}
+			for _, part := range msg.Parts {
+				block, err := convertContentPart(part)
+				if err != nil {
+					return nil, err
+				}
+				if block.Source != nil && block.Source.Type == "file" {
+					anthropicReq.Betas = appendUnique(anthropicReq.Betas, filesAPIBeta)
+				}
+				anthropicMsg.Blocks = append(anthropicMsg.Blocks, block)
+			}
+			if msg.Role == provider.RoleAssistant {
+				for _, call := range msg.ToolCalls {
+					block, err := convertToolCall(call)
+					if err != nil {
+						return nil, err
+					}
+					anthropicMsg.Blocks = append(anthropicMsg.Blocks, block)
+				}
+			}
+			anthropicReq.Messages = append(anthropicReq.Messages, anthropicMsg)
+		case provider.RoleTool:
+			if msg.ToolCallID == nil || *msg.ToolCallID == "" {
+				return nil, errors.New("tool message has no tool call ID")
+			}
+			block := ContentBlock{Type: "tool_result", ToolUseID: *msg.ToolCallID, Content: msg.Content}
+			// The results of one turn's tool calls go back in a single user message
+			if n := len(anthropicReq.Messages); n > 0 && isToolResults(anthropicReq.Messages[n-1]) {
+				last := &anthropicReq.Messages[n-1]
+				last.Blocks = append(last.Blocks, block)
+				continue
+			}
+			anthropicReq.Messages = append(anthropicReq.Messages, Message{Role: "user", Blocks: []ContentBlock{block}})
 		}
 	}
 
-	if systemMessage != "" {
-		anthropicReq.System = systemMessage
+	if len(systemParts) > 0 {
+		anthropicReq.System = strings.Join(systemParts, "\n\n")
 	}
 
-	stream, err := p.client.CreateCompletionStream(ctx, anthropicReq)
+	// Convert function tools; file_search has no Anthropic equivalent, so it
+	// is rejected rather than dropped
+	for _, tool := range req.Tools {
+		if tool.Type == provider.ToolTypeFileSearch {
+			return nil, &provider.CapabilityError{Provider: "anthropic", Missing: []string{"file_search"}}
+		}
+		schema := tool.Function.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object"}
+		}
+		anthropicReq.Tools = append(anthropicReq.Tools, Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	toolChoice, err := convertToolChoice(req.ToolChoice)
 	if err != nil {
 		return nil, err
 	}
+	anthropicReq.ToolChoice = toolChoice
+
+	// Anthropic has no native structured outputs, so a JSON Schema response
+	// format is emulated by forcing a call to a tool whose input is the schema
+	if name := structuredOutputTool(req); name != "" {
+		js := req.ResponseFormat.JSONSchema
+		anthropicReq.Tools = append(anthropicReq.Tools, Tool{
+			Name:        name,
+			Description: cmp.Or(js.Description, "Respond with output matching this schema"),
+			InputSchema: js.Schema,
+		})
+		anthropicReq.ToolChoice = &ToolChoice{Type: "tool", Name: name}
+	}
+
+	opts, err := provider.DecodeOptions[Options](req, "anthropic")
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		if opts.TopK != nil {
+			anthropicReq.TopK = opts.TopK
+		}
+		if opts.ThinkingBudget > 0 {
+			anthropicReq.Thinking = &Thinking{Type: "enabled", BudgetTokens: opts.ThinkingBudget}
+		}
+		for _, beta := range opts.Betas {
+			anthropicReq.Betas = appendUnique(anthropicReq.Betas, beta)
+		}
+	}
+
+	anthropicReq.IncludeRaw = req.IncludeRaw
+
+	// Merge a per-request anthropic-beta header with the betas the request
+	// already needs rather than letting it replace them
+	for name, value := range req.Headers {
+		if !strings.EqualFold(name, "anthropic-beta") {
+			if anthropicReq.Headers == nil {
+				anthropicReq.Headers = make(map[string]string, len(req.Headers))
+			}
+			anthropicReq.Headers[name] = value
+			continue
+		}
+		for beta := range strings.SplitSeq(value, ",") {
+			if beta = strings.TrimSpace(beta); beta != "" {
+				anthropicReq.Betas = appendUnique(anthropicReq.Betas, beta)
+			}
+		}
+	}
+
+	// The thinking budget counts toward max_tokens, so leave room for the
+	// answer when the default would not
+	if t := anthropicReq.Thinking; t != nil && req.MaxTokens == nil && anthropicReq.MaxTokens <= t.BudgetTokens {
+		anthropicReq.MaxTokens = t.BudgetTokens + 4096
+	}
 
-	return &StreamAdapter{stream: stream}, nil
+	return anthropicReq, nil
 }
 
-// Close closes the provider
-func (p *Provider) Close() error {
-	return p.client.Close()
+// convertToolCall converts a unified tool call to a tool_use content block
+func convertToolCall(call provider.ToolCall) (ContentBlock, error) {
+	input := json.RawMessage(call.Function.Arguments)
+	if len(input) == 0 {
+		input = json.RawMessage("{}")
+	} else if !json.Valid(input) {
+		return ContentBlock{}, fmt.Errorf("tool call %q has invalid JSON arguments", call.ID)
+	}
+	return ContentBlock{Type: "tool_use", ID: call.ID, Name: call.Function.Name, Input: input}, nil
+}
+
+// isToolResults returns true if msg is a user message holding only tool results
+func isToolResults(msg Message) bool {
+	if msg.Role != "user" || msg.Content != "" || len(msg.Blocks) == 0 {
+		return false
+	}
+	for _, block := range msg.Blocks {
+		if block.Type != "tool_result" {
+			return false
+		}
+	}
+	return true
+}
+
+// convertToolChoice converts an OpenAI-style tool choice ("auto", "none",
+// "required", or {"type": "function", "function": {"name": ...}}) to
+// Anthropic format
+func convertToolChoice(choice any) (*ToolChoice, error) {
+	if choice == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(choice)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tool choice: %w", err)
+	}
+
+	var mode string
+	if json.Unmarshal(data, &mode) == nil {
+		switch mode {
+		case "auto", "none":
+			return &ToolChoice{Type: mode}, nil
+		case "required", "any":
+			return &ToolChoice{Type: "any"}, nil
+		}
+		return nil, fmt.Errorf("unsupported tool choice %q", mode)
+	}
+
+	var named struct {
+		Name     string `json:"name"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("invalid tool choice: %w", err)
+	}
+	name := cmp.Or(named.Function.Name, named.Name)
+	if name == "" {
+		return nil, fmt.Errorf("tool choice %s names no tool", data)
+	}
+	return &ToolChoice{Type: "tool", Name: name}, nil
+}
+
+// convertUsage converts Anthropic usage to unified format. Anthropic reports
+// cache reads and writes separately from input tokens; the unified prompt
+// token count includes them, with the breakdown in PromptTokensDetails.
+func convertUsage(usage Usage) provider.Usage {
+	promptTokens := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	result := provider.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      promptTokens + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
+		result.PromptTokensDetails = &provider.PromptTokensDetails{
+			CachedTokens:        usage.CacheReadInputTokens,
+			CacheCreationTokens: usage.CacheCreationInputTokens,
+		}
+	}
+	return result
+}
+
+// structuredOutputTool returns the name of the tool used to emulate a JSON
+// Schema response format, or "" if the request does not use one
+func structuredOutputTool(req *provider.ChatCompletionRequest) string {
+	if req == nil || req.ResponseFormat == nil || req.ResponseFormat.Type != provider.ResponseFormatJSONSchema || req.ResponseFormat.JSONSchema == nil {
+		return ""
+	}
+	return cmp.Or(req.ResponseFormat.JSONSchema.Name, defaultStructuredOutputTool)
+}
+
+// convertContentPart converts a unified content part to an Anthropic content block
+func convertContentPart(part provider.ContentPart) (ContentBlock, error) {
+	switch part.Type {
+	case provider.ContentPartTypeText:
+		return ContentBlock{Type: "text", Text: part.Text}, nil
+
+	case provider.ContentPartTypeDocument:
+		doc := part.Document
+		if doc == nil {
+			return ContentBlock{}, fmt.Errorf("document part is missing document")
+		}
+		block := ContentBlock{Type: "document", Title: doc.Filename}
+		switch {
+		case doc.FileID != "":
+			block.Source = &DocumentSource{Type: "file", FileID: doc.FileID}
+		case len(doc.Data) > MaxDocumentBytes:
+			return ContentBlock{}, fmt.Errorf("document %q is %d bytes, exceeds Anthropic limit of %d bytes",
+				doc.Filename, len(doc.Data), MaxDocumentBytes)
+		case strings.HasPrefix(doc.MIMEType, "text/"):
+			block.Source = &DocumentSource{Type: "text", MediaType: "text/plain", Data: string(doc.Data)}
+		case len(doc.Data) > 0:
+			block.Source = &DocumentSource{
+				Type:      "base64",
+				MediaType: doc.MIMEType,
+				Data:      base64.StdEncoding.EncodeToString(doc.Data),
+			}
+		default:
+			return ContentBlock{}, fmt.Errorf("document %q has no data or file ID", doc.Filename)
+		}
+		return block, nil
+
+	default:
+		return ContentBlock{}, fmt.Errorf("unsupported content part type %q", part.Type)
+	}
+}
+
+// convertThinking converts unified thinking blocks to Anthropic content blocks
+func convertThinking(blocks []provider.ThinkingBlock) []ContentBlock {
+	var result []ContentBlock
+	for _, block := range blocks {
+		if block.Redacted != "" {
+			result = append(result, ContentBlock{Type: "redacted_thinking", Data: block.Redacted})
+			continue
+		}
+		result = append(result, ContentBlock{Type: "thinking", Thinking: block.Text, Signature: block.Signature})
+	}
+	return result
+}
+
+// appendUnique appends value to values if not already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
 }
 
 // StreamAdapter adapts Anthropic stream to unified interface
@@ -152,6 +503,21 @@ type StreamAdapter struct {
 	stream    *Stream
 	messageID string
 	model     string
+
+	// startUsage holds the prompt usage from message_start, which is combined
+	// with the output tokens reported by message_delta
+	startUsage Usage
+
+	// structuredTool is the forced tool emulating a JSON Schema response
+	// format; its streamed input is returned as content
+	structuredTool string
+
+	// toolCalls maps the content block index of each tool_use block to its
+	// position among the streamed tool calls
+	toolCalls map[int]int
+
+	// seed is the request's seed, reported as ignored at the end of the stream
+	seed *int
 }
 
 // Recv receives the next chunk from the stream
@@ -168,6 +534,7 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 		if event.Message != nil {
 			s.messageID = event.Message.ID
 			s.model = event.Message.Model
+			s.startUsage = event.Message.Usage
 		}
 		// Return empty chunk for message_start
 		metadata := map[string]any{
@@ -183,11 +550,90 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 			ProviderMetadata: metadata,
 		}, nil
 
+	case "content_block_start":
+		// A tool call starts with its ID and name, and streams its input as
+		// deltas
+		if block := event.ContentBlock; block != nil && block.Type == "tool_use" && block.Name != s.structuredTool {
+			if s.toolCalls == nil {
+				s.toolCalls = make(map[int]int)
+			}
+			index := len(s.toolCalls)
+			s.toolCalls[blockIndex(event)] = index
+			return &provider.ChatCompletionChunk{
+				ID:      s.messageID,
+				Object:  "chat.completion.chunk",
+				Created: time.Now().Unix(),
+				Model:   s.model,
+				Choices: []provider.ChatCompletionChoice{
+					{
+						Index: 0,
+						Delta: &provider.Message{
+							Role: provider.RoleAssistant,
+							ToolCalls: []provider.ToolCall{{
+								Index:    &index,
+								ID:       block.ID,
+								Type:     "function",
+								Function: provider.ToolFunction{Name: block.Name},
+							}},
+						},
+					},
+				},
+				ProviderMetadata: map[string]any{
+					"anthropic_event_type": event.Type,
+					"anthropic_index":      event.Index,
+				},
+			}, nil
+		}
+
+		// Redacted thinking arrives whole in the block start; other blocks
+		// stream their content as deltas
+		if event.ContentBlock == nil || event.ContentBlock.Type != "redacted_thinking" {
+			return s.Recv()
+		}
+		return &provider.ChatCompletionChunk{
+			ID:      s.messageID,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   s.model,
+			Choices: []provider.ChatCompletionChoice{
+				{
+					Index: 0,
+					Delta: &provider.Message{
+						Role:     provider.RoleAssistant,
+						Thinking: []provider.ThinkingBlock{{Redacted: event.ContentBlock.Data}},
+					},
+				},
+			},
+			ProviderMetadata: map[string]any{
+				"anthropic_event_type": event.Type,
+				"anthropic_index":      event.Index,
+			},
+		}, nil
+
 	case "content_block_delta":
-		// This contains the actual text content
+		// This contains the actual text content, or a fragment of thinking
+		// or of a tool call's input
 		var content string
-		if event.Delta != nil && event.Delta.Type == "text_delta" {
-			content = event.Delta.Text
+		var thinking []provider.ThinkingBlock
+		var toolCalls []provider.ToolCall
+		if event.Delta != nil {
+			switch event.Delta.Type {
+			case "text_delta":
+				content = event.Delta.Text
+			case "input_json_delta":
+				if index, ok := s.toolCalls[blockIndex(event)]; ok {
+					toolCalls = []provider.ToolCall{{
+						Index:    &index,
+						Function: provider.ToolFunction{Arguments: event.Delta.PartialJSON},
+					}}
+				} else if s.structuredTool != "" {
+					content = event.Delta.PartialJSON
+				}
+			case "thinking_delta":
+				thinking = []provider.ThinkingBlock{{Text: event.Delta.Thinking}}
+			case "signature_delta":
+				thinking = []provider.ThinkingBlock{{Signature: event.Delta.Signature}}
+			}
 		}
 
 		metadata := map[string]any{
@@ -205,8 +651,10 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 				{
 					Index: 0,
 					Delta: &provider.Message{
-						Role:    provider.RoleAssistant,
-						Content: content,
+						Role:      provider.RoleAssistant,
+						Content:   content,
+						ToolCalls: toolCalls,
+						Thinking:  thinking,
 					},
 				},
 			},
@@ -217,7 +665,11 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 		// Contains stop reason and usage info
 		var finishReason *string
 		if event.Delta != nil && event.Delta.StopReason != "" {
-			finishReason = &event.Delta.StopReason
+			reason := event.Delta.StopReason
+			if reason == "tool_use" && s.structuredTool != "" {
+				reason = "end_turn"
+			}
+			finishReason = &reason
 		}
 
 		metadata := map[string]any{
@@ -242,10 +694,23 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 
 		// Add usage if available
 		if event.Usage != nil {
-			chunk.Usage = &provider.Usage{
-				CompletionTokens: event.Usage.OutputTokens,
+			usage := s.startUsage
+			usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				usage.InputTokens = event.Usage.InputTokens
 			}
+			if event.Usage.CacheCreationInputTokens > 0 {
+				usage.CacheCreationInputTokens = event.Usage.CacheCreationInputTokens
+			}
+			if event.Usage.CacheReadInputTokens > 0 {
+				usage.CacheReadInputTokens = event.Usage.CacheReadInputTokens
+			}
+			converted := convertUsage(usage)
+			chunk.Usage = &converted
 		}
+		chunk.ProviderMetadata = provider.NormalizeFinishReasons(chunk.Choices, chunk.ProviderMetadata)
+		chunk.ProviderMetadata = provider.RecordDeterminism(chunk.ProviderMetadata, s.seed, false, "")
+		chunk.ProviderMetadata = provider.RecordRequestID(chunk.ProviderMetadata, s.stream.RequestID())
 
 		return chunk, nil
 
@@ -269,6 +734,14 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 	}
 }
 
+// blockIndex returns the content block index of a stream event
+func blockIndex(event *StreamEvent) int {
+	if event.Index == nil {
+		return 0
+	}
+	return *event.Index
+}
+
 // Close closes the stream
 func (s *StreamAdapter) Close() error {
 	return s.stream.Close()
@@ -1,6 +1,13 @@
 package anthropic
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 
@@ -40,13 +47,13 @@ func TestProvider_CreateChatCompletion_MessageConversion(t *testing.T) {
 			wantMsgCount: 2,
 		},
 		{
-			name: "multiple system messages (last one wins)",
+			name: "multiple system messages are joined",
 			messages: []provider.Message{
 				{Role: provider.RoleSystem, Content: "First system"},
 				{Role: provider.RoleUser, Content: "Hello"},
 				{Role: provider.RoleSystem, Content: "Second system"},
 			},
-			wantSystem:   "Second system",
+			wantSystem:   "First system\n\nSecond system",
 			wantMsgCount: 1,
 		},
 	}
@@ -59,21 +66,11 @@ func TestProvider_CreateChatCompletion_MessageConversion(t *testing.T) {
 				Messages: tt.messages,
 			}
 
-			// Extract the system message and convert messages
-			// (This simulates what CreateChatCompletion does)
-			var systemMessage string
-			var anthropicMessages []Message
-			for _, msg := range req.Messages {
-				switch msg.Role {
-				case provider.RoleSystem:
-					systemMessage = msg.Content
-				case provider.RoleUser, provider.RoleAssistant:
-					anthropicMessages = append(anthropicMessages, Message{
-						Role:    string(msg.Role),
-						Content: msg.Content,
-					})
-				}
+			anthropicReq, err := convertRequest(req)
+			if err != nil {
+				t.Fatalf("convertRequest() error = %v", err)
 			}
+			systemMessage, anthropicMessages := anthropicReq.System, anthropicReq.Messages
 
 			if systemMessage != tt.wantSystem {
 				t.Errorf("System message = %q, want %q", systemMessage, tt.wantSystem)
@@ -268,3 +265,330 @@ func TestBoolPtr(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertRequest_DocumentParts(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model: "claude-3-5-sonnet-20241022",
+		Messages: []provider.Message{
+			{
+				Role:    provider.RoleUser,
+				Content: "Summarize these",
+				Parts: []provider.ContentPart{
+					{Type: provider.ContentPartTypeDocument, Document: &provider.Document{MIMEType: "application/pdf", Data: []byte("%PDF-1.4")}},
+					{Type: provider.ContentPartTypeDocument, Document: &provider.Document{MIMEType: "application/pdf", FileID: "file_123"}},
+				},
+			},
+		},
+	}
+
+	anthropicReq, err := convertRequest(req)
+	if err != nil {
+		t.Fatalf("convertRequest() error = %v", err)
+	}
+	if len(anthropicReq.Betas) != 1 || anthropicReq.Betas[0] != filesAPIBeta {
+		t.Errorf("Betas = %v, want [%s]", anthropicReq.Betas, filesAPIBeta)
+	}
+
+	data, err := json.Marshal(anthropicReq.Messages[0])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"type":"text"`, `"type":"base64"`, `"media_type":"application/pdf"`, `"file_id":"file_123"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("marshaled message %s missing %s", data, want)
+		}
+	}
+}
+
+func TestConvertRequest_DocumentTooLarge(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model: "claude-3-5-sonnet-20241022",
+		Messages: []provider.Message{
+			{
+				Role: provider.RoleUser,
+				Parts: []provider.ContentPart{
+					{Type: provider.ContentPartTypeDocument, Document: &provider.Document{MIMEType: "application/pdf", Data: make([]byte, MaxDocumentBytes+1)}},
+				},
+			},
+		},
+	}
+
+	if _, err := convertRequest(req); err == nil {
+		t.Error("convertRequest() expected error for oversized document")
+	}
+}
+
+func TestConvertRequest_FileSearchUnsupported(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model:    "claude-3-5-sonnet-20241022",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Search my files"}},
+		Tools:    []provider.Tool{{Type: provider.ToolTypeFileSearch, FileSearch: &provider.FileSearchTool{VectorStoreIDs: []string{"vs_1"}}}},
+	}
+
+	_, err := convertRequest(req)
+	var capErr *provider.CapabilityError
+	if !errors.As(err, &capErr) || !slices.Equal(capErr.Missing, []string{"file_search"}) {
+		t.Errorf("convertRequest() = %v, want a file_search CapabilityError", err)
+	}
+}
+
+func TestConvertRequest_ProviderOptions(t *testing.T) {
+	topK := 3
+	req := &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		ProviderOptions: map[string]any{
+			"anthropic": map[string]any{"top_k": 7, "thinking_budget": 2048, "betas": []string{"interleaved-thinking-2025-05-14"}},
+		},
+		TopK: &topK,
+	}
+
+	got, err := convertRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TopK == nil || *got.TopK != 7 {
+		t.Errorf("TopK = %v, want provider option 7", got.TopK)
+	}
+	if got.Thinking == nil || got.Thinking.Type != "enabled" || got.Thinking.BudgetTokens != 2048 {
+		t.Errorf("Thinking = %+v", got.Thinking)
+	}
+	if len(got.Betas) != 1 {
+		t.Errorf("Betas = %v", got.Betas)
+	}
+
+	req.ProviderOptions["anthropic"] = map[string]any{"top_k": "many"}
+	if _, err := convertRequest(req); err == nil {
+		t.Error("expected invalid options error")
+	}
+}
+
+func TestConvertRequest_Headers(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		ProviderOptions: map[string]any{
+			"anthropic": map[string]any{"betas": []string{"interleaved-thinking-2025-05-14"}},
+		},
+		Headers: map[string]string{
+			"Anthropic-Beta":  "token-efficient-tools-2025-02-19, interleaved-thinking-2025-05-14",
+			"X-Gateway-Route": "us-east",
+		},
+	}
+
+	got, err := convertRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"interleaved-thinking-2025-05-14", "token-efficient-tools-2025-02-19"}
+	if !slices.Equal(got.Betas, want) {
+		t.Errorf("Betas = %v, want %v", got.Betas, want)
+	}
+	if len(got.Headers) != 1 || got.Headers["X-Gateway-Route"] != "us-east" {
+		t.Errorf("Headers = %v, want only the gateway header", got.Headers)
+	}
+}
+
+func TestConvertUsage_PromptCache(t *testing.T) {
+	usage := convertUsage(Usage{InputTokens: 10, OutputTokens: 20, CacheReadInputTokens: 1000, CacheCreationInputTokens: 50})
+	if usage.PromptTokens != 1060 || usage.CompletionTokens != 20 || usage.TotalTokens != 1080 {
+		t.Errorf("usage = %+v", usage)
+	}
+	details := usage.PromptTokensDetails
+	if details == nil || details.CachedTokens != 1000 || details.CacheCreationTokens != 50 {
+		t.Errorf("PromptTokensDetails = %+v", details)
+	}
+
+	if plain := convertUsage(Usage{InputTokens: 10, OutputTokens: 5}); plain.PromptTokensDetails != nil || plain.TotalTokens != 15 {
+		t.Errorf("usage without caching = %+v", plain)
+	}
+}
+
+func TestStreamAdapter_UsageCombinesMessageStart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message_start\ndata: "+
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":12,"cache_read_input_tokens":400}}}`+"\n\n")
+		_, _ = io.WriteString(w, "event: message_delta\ndata: "+
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`+"\n\n")
+		_, _ = io.WriteString(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if usage == nil || usage.PromptTokens != 412 || usage.CompletionTokens != 7 || usage.TotalTokens != 419 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if usage.PromptTokensDetails == nil || usage.PromptTokensDetails.CachedTokens != 400 {
+		t.Errorf("PromptTokensDetails = %+v", usage.PromptTokensDetails)
+	}
+}
+
+func TestStreamAdapter_UsageFromMessageDelta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message_start\ndata: "+
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":12,"output_tokens":1}}}`+"\n\n")
+		_, _ = io.WriteString(w, "event: message_delta\ndata: "+
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"input_tokens":850,"output_tokens":40}}`+"\n\n")
+		_, _ = io.WriteString(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	// Cumulative input tokens in message_delta replace the message_start count
+	if usage == nil || usage.PromptTokens != 850 || usage.CompletionTokens != 40 || usage.TotalTokens != 890 {
+		t.Fatalf("usage = %+v", usage)
+	}
+}
+
+func TestStreamAdapter_ErrorEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "event: message_start\ndata: "+
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4"}}`+"\n\n")
+		_, _ = io.WriteString(w, "event: error\ndata: "+
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("message_start: %v", err)
+	}
+	_, err = stream.Recv()
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 529 || apiErr.Type != "overloaded_error" || apiErr.Message != "Overloaded" {
+		t.Errorf("expected an overloaded APIError, got %v", err)
+	}
+}
+
+func TestProvider_FinishReasonNormalized(t *testing.T) {
+	tests := []struct {
+		stopReason string
+		want       string
+	}{
+		{"end_turn", provider.FinishReasonStop},
+		{"stop_sequence", provider.FinishReasonStop},
+		{"max_tokens", provider.FinishReasonLength},
+		{"tool_use", provider.FinishReasonToolCalls},
+		{"refusal", provider.FinishReasonContentFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stopReason, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewEncoder(w).Encode(Response{
+					ID:         "msg_1",
+					Content:    []Content{{Type: "text", Text: "Hi"}},
+					StopReason: tt.stopReason,
+				})
+			}))
+			defer server.Close()
+
+			p := NewProvider("test-key", server.URL, server.Client())
+			resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+				Model:    "claude-sonnet-4",
+				Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := *resp.Choices[0].FinishReason; got != tt.want {
+				t.Errorf("FinishReason = %q, want %q", got, tt.want)
+			}
+			if got := resp.ProviderMetadata[provider.RawFinishReasonKey]; got != tt.stopReason {
+				t.Errorf("raw finish reason = %v, want %q", got, tt.stopReason)
+			}
+		})
+	}
+}
+
+func TestProvider_ListModelsPaginates(t *testing.T) {
+	var afterIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("missing API key header")
+		}
+		afterID := r.URL.Query().Get("after_id")
+		afterIDs = append(afterIDs, afterID)
+		page := ModelList{
+			Data:    []ModelInfo{{Type: "model", ID: "claude-sonnet-4-20250514", DisplayName: "Claude Sonnet 4", CreatedAt: "2025-05-22T00:00:00Z"}},
+			HasMore: true,
+			LastID:  "claude-sonnet-4-20250514",
+		}
+		if afterID != "" {
+			page = ModelList{Data: []ModelInfo{{Type: "model", ID: "claude-3-haiku-20240307", DisplayName: "Claude Haiku 3"}}}
+		}
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	models, err := p.(provider.ModelLister).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(afterIDs) != 2 || afterIDs[1] != "claude-sonnet-4-20250514" {
+		t.Errorf("pages requested with after_id = %q", afterIDs)
+	}
+	if len(models) != 2 || models[0].DisplayName != "Claude Sonnet 4" || models[1].ID != "claude-3-haiku-20240307" {
+		t.Fatalf("models = %+v", models)
+	}
+	if models[0].Created != 1747872000 || !models[0].Capabilities.Tools {
+		t.Errorf("model = %+v", models[0])
+	}
+}
@@ -9,10 +9,16 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/plexusone/omnillm/provider"
 )
 
+// DefaultTimeout is the HTTP client timeout used when no client is given
+const DefaultTimeout = 30 * time.Second
+
 // Client implements Anthropic API client
 type Client struct {
 	apiKey  string
@@ -26,7 +32,7 @@ func New(apiKey, baseURL string, httpClient *http.Client) *Client {
 		baseURL = "https://api.anthropic.com"
 	}
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 30 * time.Second}
+		httpClient = &http.Client{Timeout: DefaultTimeout}
 	}
 
 	return &Client{
@@ -60,7 +66,8 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	c.setHeaders(httpReq)
+	c.setHeaders(httpReq, req.Betas)
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -72,10 +79,18 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 		return nil, c.handleErrorResponse(resp)
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 	var response Response
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	response.RequestID = provider.RequestIDFromHeader(resp.Header)
+	if req.IncludeRaw {
+		response.Raw = &provider.RawExchange{Request: reqBody, Response: body}
+	}
 
 	return &response, nil
 }
@@ -102,8 +117,9 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	c.setHeaders(httpReq)
+	c.setHeaders(httpReq, req.Betas)
 	httpReq.Header.Set("Accept", "text/event-stream")
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -115,7 +131,9 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 		return nil, c.handleErrorResponse(resp)
 	}
 
+	resp.Body = provider.NewContextBody(ctx, resp.Body)
 	return &Stream{
+		ctx:      ctx,
 		response: resp,
 		scanner:  bufio.NewScanner(resp.Body),
 	}, nil
@@ -126,18 +144,68 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// ListModels lists all available models, following pagination
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var models []ModelInfo
+	afterID := ""
+	for {
+		endpoint := c.baseURL + "/v1/models?limit=1000"
+		if afterID != "" {
+			endpoint += "&after_id=" + url.QueryEscape(afterID)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		c.setHeaders(httpReq, nil)
+
+		page, err := c.getModelPage(httpReq)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, page.Data...)
+		if !page.HasMore || page.LastID == "" {
+			return models, nil
+		}
+		afterID = page.LastID
+	}
+}
+
+// getModelPage sends a models request and decodes one page of results
+func (c *Client) getModelPage(httpReq *http.Request) (*ModelList, error) {
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var page ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &page, nil
+}
+
 // setHeaders sets the required headers for Anthropic API requests
-func (c *Client) setHeaders(req *http.Request) {
+func (c *Client) setHeaders(req *http.Request, betas []string) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	if len(betas) > 0 {
+		req.Header.Set("anthropic-beta", strings.Join(betas, ","))
+	}
 }
 
 // handleErrorResponse handles error responses from Anthropic API
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read error response")
+		return provider.NewAPIErrorFromResponse("anthropic", resp, "failed to read error response", "", "")
 	}
 
 	var errorResp struct {
@@ -147,25 +215,35 @@ func (c *Client) handleErrorResponse(resp *http.Response) error {
 		} `json:"error"`
 	}
 
-	if err := json.Unmarshal(body, &errorResp); err != nil {
-		return fmt.Errorf("api error: %s", string(body))
+	if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.Error.Message == "" {
+		return provider.NewAPIErrorFromResponse("anthropic", resp, "anthropic api error: "+string(body), "", "").WithBody(body)
 	}
 
-	return fmt.Errorf("anthropic api error: %s", errorResp.Error.Message)
+	return provider.NewAPIErrorFromResponse("anthropic", resp, "anthropic api error: "+errorResp.Error.Message,
+		errorResp.Error.Type, "").WithBody(body)
 }
 
 // Stream implements streaming for Anthropic
 type Stream struct {
+	ctx      context.Context
 	response *http.Response
 	scanner  *bufio.Scanner
 	closed   bool
 }
 
+// RequestID returns the provider's ID for the request, or "" if it sent none
+func (s *Stream) RequestID() string {
+	return provider.RequestIDFromHeader(s.response.Header)
+}
+
 // Recv receives the next chunk from the stream
 func (s *Stream) Recv() (*StreamEvent, error) {
 	if s.closed {
 		return nil, fmt.Errorf("stream is closed")
 	}
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	var currentEvent string
 	var currentData strings.Builder
@@ -185,9 +263,13 @@ func (s *Stream) Recv() (*StreamEvent, error) {
 					continue
 				}
 
+				if event.Type == "error" && event.Error != nil {
+					return nil, provider.NewStreamAPIError("anthropic", event.Error)
+				}
+
 				// Only return events we care about
-				if event.Type == "content_block_delta" || event.Type == "message_start" ||
-					event.Type == "message_delta" || event.Type == "message_stop" {
+				if event.Type == "content_block_delta" || event.Type == "content_block_start" ||
+					event.Type == "message_start" || event.Type == "message_delta" || event.Type == "message_stop" {
 					return &event, nil
 				}
 
@@ -213,6 +295,9 @@ func (s *Stream) Recv() (*StreamEvent, error) {
 	}
 
 	if err := s.scanner.Err(); err != nil {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("stream error: %w", err)
 	}
 
@@ -0,0 +1,242 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// batchCustomIDPattern matches the custom IDs the Message Batches API accepts
+var batchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// CreateMessageBatch submits a batch of Messages requests
+func (c *Client) CreateMessageBatch(ctx context.Context, req *BatchCreateRequest) (*MessageBatch, error) {
+	var betas []string
+	for _, item := range req.Requests {
+		for _, beta := range item.Params.Betas {
+			betas = appendUnique(betas, beta)
+		}
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var batch MessageBatch
+	if err := c.doBatchRequest(ctx, "POST", "/v1/messages/batches", bytes.NewReader(reqBody), betas, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetMessageBatch retrieves the status of a Message Batch
+func (c *Client) GetMessageBatch(ctx context.Context, batchID string) (*MessageBatch, error) {
+	var batch MessageBatch
+	if err := c.doBatchRequest(ctx, "GET", "/v1/messages/batches/"+url.PathEscape(batchID), nil, nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CancelMessageBatch cancels a Message Batch that is still processing
+func (c *Client) CancelMessageBatch(ctx context.Context, batchID string) (*MessageBatch, error) {
+	var batch MessageBatch
+	if err := c.doBatchRequest(ctx, "POST", "/v1/messages/batches/"+url.PathEscape(batchID)+"/cancel", nil, nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetMessageBatchResults fetches the results of an ended Message Batch. The
+// results are in no particular order.
+func (c *Client) GetMessageBatchResults(ctx context.Context, batchID string) ([]BatchResultLine, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/messages/batches/"+url.PathEscape(batchID)+"/results", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setHeaders(httpReq, nil)
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	// The results file is JSONL, one result per line
+	var lines []BatchResultLine
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var line BatchResultLine
+		if err := decoder.Decode(&line); err == io.EOF {
+			return lines, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode batch result: %w", err)
+		}
+		lines = append(lines, line)
+	}
+}
+
+// doBatchRequest sends a Message Batches request and decodes the JSON response into out
+func (c *Client) doBatchRequest(ctx context.Context, method, path string, body io.Reader, betas []string, out any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setHeaders(httpReq, betas)
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// CreateBatch submits requests to the Message Batches API. Each custom ID
+// must be 1-64 letters, digits, hyphens, or underscores.
+func (p *Provider) CreateBatch(ctx context.Context, requests []provider.BatchRequest) (*provider.Batch, error) {
+	if err := provider.ValidateBatchRequests(requests); err != nil {
+		return nil, err
+	}
+
+	batchReq := &BatchCreateRequest{Requests: make([]BatchRequestItem, 0, len(requests))}
+	for _, r := range requests {
+		if !batchCustomIDPattern.MatchString(r.CustomID) {
+			return nil, fmt.Errorf("%w: batch custom ID %q must be 1-64 letters, digits, hyphens, or underscores",
+				provider.ErrInvalidRequest, r.CustomID)
+		}
+		params, err := convertRequest(r.Request)
+		if err != nil {
+			return nil, fmt.Errorf("batch request %q: %w", r.CustomID, err)
+		}
+		batchReq.Requests = append(batchReq.Requests, BatchRequestItem{CustomID: r.CustomID, Params: params})
+	}
+
+	batch, err := p.client.CreateMessageBatch(ctx, batchReq)
+	if err != nil {
+		return nil, err
+	}
+	return p.convertBatch(batch), nil
+}
+
+// GetBatch retrieves the status of a Message Batch
+func (p *Provider) GetBatch(ctx context.Context, batchID string) (*provider.Batch, error) {
+	batch, err := p.client.GetMessageBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return p.convertBatch(batch), nil
+}
+
+// CancelBatch cancels a Message Batch that is still processing
+func (p *Provider) CancelBatch(ctx context.Context, batchID string) (*provider.Batch, error) {
+	batch, err := p.client.CancelMessageBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return p.convertBatch(batch), nil
+}
+
+// GetBatchResults fetches the results of an ended Message Batch. Given the
+// requests the batch was created with, results are returned in their order
+// and responses to JSON Schema requests are unwrapped from the emulating tool
+// call, as for CreateChatCompletion.
+func (p *Provider) GetBatchResults(ctx context.Context, batchID string, requests []provider.BatchRequest) ([]provider.BatchResult, error) {
+	lines, err := p.client.GetMessageBatchResults(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*provider.ChatCompletionRequest, len(requests))
+	for _, r := range requests {
+		byID[r.CustomID] = r.Request
+	}
+
+	results := make([]provider.BatchResult, 0, len(lines))
+	for _, line := range lines {
+		results = append(results, p.convertBatchResult(line, byID[line.CustomID]))
+	}
+	if len(requests) > 0 {
+		results = provider.MatchBatchResults(requests, results)
+	}
+	return results, nil
+}
+
+// convertBatch converts a Message Batch to unified format
+func (p *Provider) convertBatch(batch *MessageBatch) *provider.Batch {
+	return &provider.Batch{
+		ID:       batch.ID,
+		Provider: p.Name(),
+		Status:   batch.ProcessingStatus,
+		Counts: provider.BatchCounts{
+			Processing: batch.RequestCounts.Processing,
+			Succeeded:  batch.RequestCounts.Succeeded,
+			Errored:    batch.RequestCounts.Errored,
+			Canceled:   batch.RequestCounts.Canceled,
+			Expired:    batch.RequestCounts.Expired,
+		},
+		CreatedAt: parseBatchTime(batch.CreatedAt),
+		EndedAt:   parseBatchTime(batch.EndedAt),
+		ExpiresAt: parseBatchTime(batch.ExpiresAt),
+	}
+}
+
+// convertBatchResult converts one Message Batch result to unified format. req
+// is the request that produced it, if known.
+func (p *Provider) convertBatchResult(line BatchResultLine, req *provider.ChatCompletionRequest) provider.BatchResult {
+	result := provider.BatchResult{CustomID: line.CustomID}
+	switch line.Result.Type {
+	case "succeeded":
+		if line.Result.Message == nil {
+			result.Err = errors.New("anthropic batch result has no message")
+			break
+		}
+		result.Response = convertResponse(req, line.Result.Message)
+	case "errored":
+		streamErr := &provider.StreamError{Type: "api_error", Message: "batch request failed"}
+		if line.Result.Error != nil && line.Result.Error.Error != nil {
+			streamErr = line.Result.Error.Error
+		}
+		result.Err = provider.NewStreamAPIError(p.Name(), streamErr)
+	case "canceled":
+		result.Err = provider.ErrBatchRequestCanceled
+	case "expired":
+		result.Err = provider.ErrBatchRequestExpired
+	default:
+		result.Err = fmt.Errorf("unknown anthropic batch result type %q", line.Result.Type)
+	}
+	return result
+}
+
+// parseBatchTime converts an RFC 3339 timestamp to Unix seconds, or 0 if unset
+func parseBatchTime(value string) int64 {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
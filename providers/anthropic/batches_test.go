@@ -0,0 +1,116 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestProvider_Batches(t *testing.T) {
+	var created BatchCreateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := MessageBatch{
+			ID:               "msgbatch_123",
+			Type:             "message_batch",
+			ProcessingStatus: "in_progress",
+			RequestCounts:    RequestCounts{Processing: 3},
+			CreatedAt:        "2025-05-22T00:00:00Z",
+			ExpiresAt:        "2025-05-23T00:00:00Z",
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/messages/batches":
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Errorf("decode create request: %v", err)
+			}
+		case "GET /v1/messages/batches/msgbatch_123":
+			batch.ProcessingStatus = "ended"
+			batch.RequestCounts = RequestCounts{Succeeded: 2, Errored: 1}
+			batch.EndedAt = "2025-05-22T01:00:00Z"
+		case "GET /v1/messages/batches/msgbatch_123/results":
+			// Results arrive in no particular order
+			_, _ = w.Write([]byte(`{"custom_id":"b","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}}}
+{"custom_id":"c","result":{"type":"succeeded","message":{"id":"msg_c","type":"message","role":"assistant","model":"claude-sonnet-4","stop_reason":"tool_use","content":[{"type":"tool_use","id":"tu_1","name":"answer","input":{"ok":true}}],"usage":{"input_tokens":5,"output_tokens":2}}}}
+{"custom_id":"a","result":{"type":"succeeded","message":{"id":"msg_a","type":"message","role":"assistant","model":"claude-sonnet-4","stop_reason":"end_turn","content":[{"type":"text","text":"hello"}],"usage":{"input_tokens":3,"output_tokens":1}}}}
+`))
+			return
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(batch)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client()).(provider.BatchProvider)
+	ctx := context.Background()
+
+	requests := []provider.BatchRequest{
+		{CustomID: "a", Request: &provider.ChatCompletionRequest{Model: "claude-sonnet-4", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}},
+		{CustomID: "b", Request: &provider.ChatCompletionRequest{Model: "claude-sonnet-4", Messages: []provider.Message{{Role: provider.RoleUser, Content: "bad"}}}},
+		{CustomID: "c", Request: &provider.ChatCompletionRequest{
+			Model:    "claude-sonnet-4",
+			Messages: []provider.Message{{Role: provider.RoleUser, Content: "json"}},
+			ResponseFormat: &provider.ResponseFormat{
+				Type:       provider.ResponseFormatJSONSchema,
+				JSONSchema: &provider.JSONSchema{Name: "answer", Schema: map[string]any{"type": "object"}},
+			},
+		}},
+	}
+
+	batch, err := p.CreateBatch(ctx, requests)
+	if err != nil {
+		t.Fatalf("CreateBatch() error = %v", err)
+	}
+	if batch.ID != "msgbatch_123" || batch.Provider != "anthropic" || batch.Status != provider.BatchStatusInProgress ||
+		batch.Counts.Processing != 3 || batch.CreatedAt != 1747872000 {
+		t.Errorf("batch = %+v", batch)
+	}
+	if len(created.Requests) != 3 || created.Requests[0].CustomID != "a" || created.Requests[0].Params.Model != "claude-sonnet-4" {
+		t.Errorf("create request = %+v", created)
+	}
+
+	batch, err = p.GetBatch(ctx, "msgbatch_123")
+	if err != nil {
+		t.Fatalf("GetBatch() error = %v", err)
+	}
+	if !batch.Done() || batch.Counts.Succeeded != 2 || batch.EndedAt == 0 {
+		t.Errorf("batch = %+v", batch)
+	}
+
+	results, err := p.GetBatchResults(ctx, "msgbatch_123", requests)
+	if err != nil {
+		t.Fatalf("GetBatchResults() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	for i, r := range results {
+		if r.CustomID != requests[i].CustomID || r.Request != requests[i].Request {
+			t.Errorf("result %d not matched to its request: %+v", i, r)
+		}
+	}
+	if results[0].Response == nil || results[0].Response.Choices[0].Message.Content != "hello" {
+		t.Errorf("result a = %+v", results[0])
+	}
+	var apiErr *provider.APIError
+	if !errors.As(results[1].Err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "bad" {
+		t.Errorf("result b error = %v", results[1].Err)
+	}
+	if results[2].Response == nil || results[2].Response.Choices[0].Message.Content != `{"ok":true}` {
+		t.Errorf("structured result c = %+v", results[2].Response)
+	}
+}
+
+func TestProvider_CreateBatchRejectsInvalidCustomID(t *testing.T) {
+	p := NewProvider("test-key", "http://127.0.0.1:0", nil).(provider.BatchProvider)
+	_, err := p.CreateBatch(context.Background(), []provider.BatchRequest{
+		{CustomID: "has spaces", Request: &provider.ChatCompletionRequest{Model: "claude-sonnet-4"}},
+	})
+	if !errors.Is(err, provider.ErrInvalidRequest) {
+		t.Errorf("CreateBatch() error = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,111 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func structuredRequest() *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Weather in Paris?"}},
+		ResponseFormat: &provider.ResponseFormat{
+			Type: provider.ResponseFormatJSONSchema,
+			JSONSchema: &provider.JSONSchema{
+				Name:   "weather_report",
+				Schema: map[string]any{"type": "object", "properties": map[string]any{"temp": map[string]any{"type": "number"}}},
+			},
+		},
+	}
+}
+
+func TestProvider_StructuredOutputEmulation(t *testing.T) {
+	var sent Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4",
+			"content":[{"type":"tool_use","id":"tu_1","name":"weather_report","input":{"temp":21.5}}],
+			"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), structuredRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent.Tools) != 1 || sent.Tools[0].Name != "weather_report" || sent.Tools[0].InputSchema == nil {
+		t.Errorf("schema tool not sent: %+v", sent.Tools)
+	}
+	if sent.ToolChoice == nil || sent.ToolChoice.Type != "tool" || sent.ToolChoice.Name != "weather_report" {
+		t.Errorf("tool not forced: %+v", sent.ToolChoice)
+	}
+	if got := resp.Choices[0].Message.Content; got != `{"temp":21.5}` {
+		t.Errorf("Content = %q, want the tool input", got)
+	}
+	if got := *resp.Choices[0].FinishReason; got != provider.FinishReasonStop {
+		t.Errorf("FinishReason = %q, want stop", got)
+	}
+}
+
+func TestProvider_StructuredOutputStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4"}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"tu_1","name":"weather_report","input":{}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"temp\":"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"21.5}"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			var head struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(e), &head)
+			_, _ = io.WriteString(w, "event: "+head.Type+"\ndata: "+e+"\n\n")
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), structuredRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var finish string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil {
+				content.WriteString(choice.Delta.Content)
+			}
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+		}
+	}
+	if content.String() != `{"temp":21.5}` || finish != provider.FinishReasonStop {
+		t.Errorf("content = %q, finish = %q", content.String(), finish)
+	}
+}
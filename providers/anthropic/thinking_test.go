@@ -0,0 +1,156 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestConvertRequest_Thinking(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model: "claude-sonnet-4",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "What is 27 * 453?"},
+			{Role: provider.RoleAssistant, Content: "Let me check.", Thinking: []provider.ThinkingBlock{
+				{Text: "27 * 453 = 12231", Signature: "sig-1"},
+				{Redacted: "opaque"},
+			}},
+		},
+		Thinking: &provider.ThinkingConfig{BudgetTokens: 8000},
+	}
+
+	anthropicReq, err := convertRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anthropicReq.Thinking == nil || anthropicReq.Thinking.Type != "enabled" || anthropicReq.Thinking.BudgetTokens != 8000 {
+		t.Errorf("Thinking = %+v", anthropicReq.Thinking)
+	}
+	if anthropicReq.MaxTokens != 8000+4096 {
+		t.Errorf("MaxTokens = %d, want room beyond the thinking budget", anthropicReq.MaxTokens)
+	}
+
+	data, err := json.Marshal(anthropicReq.Messages[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"assistant","content":[` +
+		`{"type":"thinking","thinking":"27 * 453 = 12231","signature":"sig-1"},` +
+		`{"type":"redacted_thinking","data":"opaque"},` +
+		`{"type":"text","text":"Let me check."}]}`
+	if string(data) != want {
+		t.Errorf("assistant message =\n%s\nwant\n%s", data, want)
+	}
+
+	// An explicit max_tokens is left alone
+	maxTokens := 16000
+	req.MaxTokens = &maxTokens
+	if anthropicReq, _ := convertRequest(req); anthropicReq.MaxTokens != 16000 {
+		t.Errorf("MaxTokens = %d, want 16000", anthropicReq.MaxTokens)
+	}
+}
+
+func TestProvider_ThinkingResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4",
+			"content":[
+				{"type":"thinking","thinking":"Multiply step by step.","signature":"sig-1"},
+				{"type":"redacted_thinking","data":"opaque"},
+				{"type":"text","text":"12231"}],
+			"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":50}}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "What is 27 * 453?"}},
+		Thinking: &provider.ThinkingConfig{BudgetTokens: 2048},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := resp.Choices[0].Message
+	if msg.Content != "12231" {
+		t.Errorf("Content = %q, want only the text", msg.Content)
+	}
+	if len(msg.Thinking) != 2 || msg.Thinking[0].Text != "Multiply step by step." || msg.Thinking[0].Signature != "sig-1" ||
+		msg.Thinking[1].Redacted != "opaque" {
+		t.Errorf("Thinking = %+v", msg.Thinking)
+	}
+}
+
+func TestProvider_ThinkingStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4"}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Multiply "}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"step by step."}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig-1"}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"opaque"}}`,
+			`{"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"12231"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":50}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			var head struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(e), &head)
+			_, _ = io.WriteString(w, "event: "+head.Type+"\ndata: "+e+"\n\n")
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "What is 27 * 453?"}},
+		Thinking: &provider.ThinkingConfig{BudgetTokens: 2048},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	var thinking []provider.ThinkingBlock
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil {
+				content.WriteString(choice.Delta.Content)
+				thinking = append(thinking, choice.Delta.Thinking...)
+			}
+		}
+	}
+
+	if content.String() != "12231" {
+		t.Errorf("content = %q, want only the text", content.String())
+	}
+	want := []provider.ThinkingBlock{{Text: "Multiply "}, {Text: "step by step."}, {Signature: "sig-1"}, {Redacted: "opaque"}}
+	if len(thinking) != len(want) {
+		t.Fatalf("thinking deltas = %+v", thinking)
+	}
+	for i := range want {
+		if thinking[i] != want[i] {
+			t.Errorf("thinking[%d] = %+v, want %+v", i, thinking[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func weatherTool() provider.Tool {
+	return provider.Tool{
+		Type: provider.ToolTypeFunction,
+		Function: provider.ToolSpec{
+			Name:        "get_weather",
+			Description: "Get the weather for a city",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		},
+	}
+}
+
+func TestConvertRequest_Tools(t *testing.T) {
+	callID := "tu_1"
+	otherID := "tu_2"
+	req := &provider.ChatCompletionRequest{
+		Model: "claude-sonnet-4",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Weather in Paris and Rome?"},
+			{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{
+				{ID: callID, Type: "function", Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+				{ID: otherID, Type: "function", Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"city":"Rome"}`}},
+			}},
+			{Role: provider.RoleTool, ToolCallID: &callID, Content: "21C"},
+			{Role: provider.RoleTool, ToolCallID: &otherID, Content: "25C"},
+		},
+		Tools:      []provider.Tool{weatherTool()},
+		ToolChoice: "required",
+	}
+
+	got, err := convertRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Tools) != 1 || got.Tools[0].Name != "get_weather" || got.Tools[0].InputSchema == nil {
+		t.Errorf("Tools = %+v", got.Tools)
+	}
+	if got.ToolChoice == nil || got.ToolChoice.Type != "any" {
+		t.Errorf("ToolChoice = %+v, want any", got.ToolChoice)
+	}
+
+	data, err := json.Marshal(got.Messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"role":"user","content":"Weather in Paris and Rome?"},` +
+		`{"role":"assistant","content":[{"type":"tool_use","id":"tu_1","name":"get_weather","input":{"city":"Paris"}},` +
+		`{"type":"tool_use","id":"tu_2","name":"get_weather","input":{"city":"Rome"}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"tu_1","content":"21C"},` +
+		`{"type":"tool_result","tool_use_id":"tu_2","content":"25C"}]}]`
+	if string(data) != want {
+		t.Errorf("messages =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	tests := []struct {
+		choice any
+		want   *ToolChoice
+	}{
+		{nil, nil},
+		{"auto", &ToolChoice{Type: "auto"}},
+		{"none", &ToolChoice{Type: "none"}},
+		{"required", &ToolChoice{Type: "any"}},
+		{map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}, &ToolChoice{Type: "tool", Name: "get_weather"}},
+	}
+	for _, tt := range tests {
+		got, err := convertToolChoice(tt.choice)
+		if err != nil {
+			t.Errorf("%v: %v", tt.choice, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%v: got %+v, want %+v", tt.choice, got, tt.want)
+		}
+	}
+
+	if _, err := convertToolChoice("sometimes"); err == nil {
+		t.Error("expected an error for an unknown tool choice")
+	}
+}
+
+func TestProvider_ToolCallResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4",
+			"content":[
+				{"type":"text","text":"Checking."},
+				{"type":"tool_use","id":"tu_1","name":"get_weather","input":{"city":"Paris"}}],
+			"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Weather in Paris?"}},
+		Tools:    []provider.Tool{weatherTool()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	choice := resp.Choices[0]
+	if choice.Message.Content != "Checking." || len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("Message = %+v", choice.Message)
+	}
+	call := choice.Message.ToolCalls[0]
+	if call.ID != "tu_1" || call.Function.Name != "get_weather" || call.Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("ToolCall = %+v", call)
+	}
+	if *choice.FinishReason != provider.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q, want tool_calls", *choice.FinishReason)
+	}
+}
+
+func TestProvider_ToolCallStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4"}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking."}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"tu_1","name":"get_weather","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+			`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"tu_2","name":"get_weather","input":{}}}`,
+			`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":\"Rome\"}"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			var head struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(e), &head)
+			_, _ = io.WriteString(w, "event: "+head.Type+"\ndata: "+e+"\n\n")
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "claude-sonnet-4",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Weather in Paris and Rome?"}},
+		Tools:    []provider.Tool{weatherTool()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	// Merge the tool call fragments by index, as a stream consumer would
+	var calls []provider.ToolCall
+	var finish string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+			if choice.Delta == nil {
+				continue
+			}
+			for _, tc := range choice.Delta.ToolCalls {
+				if tc.Index == nil {
+					t.Fatalf("tool call fragment without an index: %+v", tc)
+				}
+				if *tc.Index == len(calls) {
+					calls = append(calls, provider.ToolCall{ID: tc.ID, Type: tc.Type, Function: provider.ToolFunction{Name: tc.Function.Name}})
+				}
+				calls[*tc.Index].Function.Arguments += tc.Function.Arguments
+			}
+		}
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", calls)
+	}
+	if calls[0].ID != "tu_1" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("first call = %+v", calls[0])
+	}
+	if calls[1].ID != "tu_2" || calls[1].Function.Arguments != `{"city":"Rome"}` {
+		t.Errorf("second call = %+v", calls[1])
+	}
+	if finish != provider.FinishReasonToolCalls {
+		t.Errorf("finish = %q, want tool_calls", finish)
+	}
+}
+
+func TestConvertResponse_SeedIgnored(t *testing.T) {
+	seed := 42
+	req := &provider.ChatCompletionRequest{Model: "claude-sonnet-4", Seed: &seed}
+	resp := convertResponse(req, &Response{
+		ID:         "msg_1",
+		Model:      "claude-sonnet-4",
+		Content:    []Content{{Type: "text", Text: "4"}},
+		StopReason: "end_turn",
+	})
+
+	d, ok := provider.GetDeterminism(resp.ProviderMetadata)
+	if !ok || !d.SeedIgnored || d.Seed != nil {
+		t.Errorf("Determinism = %+v, %v, want the seed reported as ignored", d, ok)
+	}
+
+	resp = convertResponse(&provider.ChatCompletionRequest{Model: "claude-sonnet-4"}, &Response{Model: "claude-sonnet-4"})
+	if _, ok := provider.GetDeterminism(resp.ProviderMetadata); ok {
+		t.Error("expected no determinism without a seed")
+	}
+}
+
+func TestProvider_IncludeRaw(t *testing.T) {
+	const body = `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`
+	var sent []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, _ = io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	req := &provider.ChatCompletionRequest{
+		Model:      "claude-sonnet-4",
+		Messages:   []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		IncludeRaw: true,
+	}
+	resp, err := p.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Raw == nil || string(resp.Raw.Request) != string(sent) || string(resp.Raw.Response) != body {
+		t.Fatalf("Raw = %+v", resp.Raw)
+	}
+
+	marshaled, err := p.(provider.RawCapable).MarshalChatCompletionRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(marshaled) != string(sent) {
+		t.Errorf("MarshalChatCompletionRequest() =\n%s\nwant\n%s", marshaled, sent)
+	}
+}
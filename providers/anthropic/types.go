@@ -1,21 +1,124 @@
 package anthropic
 
+import (
+	"encoding/json"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
 // Request represents an Anthropic API request
 type Request struct {
-	Model       string    `json:"model"`
-	MaxTokens   int       `json:"max_tokens"`
-	Messages    []Message `json:"messages"`
-	System      string    `json:"system,omitempty"`
-	Temperature *float64  `json:"temperature,omitempty"`
-	TopP        *float64  `json:"top_p,omitempty"`
-	TopK        *int      `json:"top_k,omitempty"`
-	Stream      *bool     `json:"stream,omitempty"`
+	Model       string      `json:"model"`
+	MaxTokens   int         `json:"max_tokens"`
+	Messages    []Message   `json:"messages"`
+	System      string      `json:"system,omitempty"`
+	Temperature *float64    `json:"temperature,omitempty"`
+	TopP        *float64    `json:"top_p,omitempty"`
+	TopK        *int        `json:"top_k,omitempty"`
+	Stream      *bool       `json:"stream,omitempty"`
+	Thinking    *Thinking   `json:"thinking,omitempty"`
+	Tools       []Tool      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice `json:"tool_choice,omitempty"`
+
+	// Betas lists beta features sent in the anthropic-beta header
+	Betas []string `json:"-"`
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
+
+	// IncludeRaw keeps the request and response bodies in Response.Raw
+	IncludeRaw bool `json:"-"`
+}
+
+// Tool defines a tool the model may call
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+// ToolChoice controls how the model uses tools
+type ToolChoice struct {
+	Type string `json:"type"`           // "auto", "any", or "tool"
+	Name string `json:"name,omitempty"` // Tool to call when Type is "tool"
 }
 
-// Message represents a message in Anthropic format
+// Thinking enables extended thinking with a token budget
+type Thinking struct {
+	Type         string `json:"type"` // "enabled"
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// Options are the Anthropic-specific request options, passed in
+// ChatCompletionRequest.ProviderOptions under the "anthropic" key
+type Options struct {
+	// TopK overrides ChatCompletionRequest.TopK
+	TopK *int `json:"top_k,omitempty"`
+
+	// ThinkingBudget enables extended thinking with the given token budget,
+	// which must be at least 1024 and less than max_tokens
+	ThinkingBudget int `json:"thinking_budget,omitempty"`
+
+	// Betas adds beta feature names to the anthropic-beta header
+	Betas []string `json:"betas,omitempty"`
+}
+
+// Message represents a message in Anthropic format.
+// When Blocks or Thinking is set, content is sent as an array of content
+// blocks: the thinking blocks, then Content (if any) as a text block, then Blocks.
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role     string         `json:"role"`
+	Content  string         `json:"content"`
+	Blocks   []ContentBlock `json:"-"`
+	Thinking []ContentBlock `json:"-"` // Assistant thinking blocks returned in an earlier response
+}
+
+// ContentBlock represents a content block in an Anthropic message
+type ContentBlock struct {
+	Type   string          `json:"type"`
+	Text   string          `json:"text,omitempty"`
+	Source *DocumentSource `json:"source,omitempty"`
+	Title  string          `json:"title,omitempty"`
+
+	Thinking  string `json:"thinking,omitempty"`  // thinking
+	Signature string `json:"signature,omitempty"` // thinking
+	Data      string `json:"data,omitempty"`      // redacted_thinking
+
+	ID        string          `json:"id,omitempty"`          // tool_use
+	Name      string          `json:"name,omitempty"`        // tool_use
+	Input     json.RawMessage `json:"input,omitempty"`       // tool_use
+	ToolUseID string          `json:"tool_use_id,omitempty"` // tool_result
+	Content   string          `json:"content,omitempty"`     // tool_result
+}
+
+// DocumentSource represents the source of a document content block
+type DocumentSource struct {
+	Type      string `json:"type"` // "base64", "text", or "file"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+}
+
+// MarshalJSON encodes the message content as a string or as content blocks
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) == 0 && len(m.Thinking) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+
+	blocks := make([]ContentBlock, 0, len(m.Thinking)+len(m.Blocks)+1)
+	blocks = append(blocks, m.Thinking...)
+	if m.Content != "" {
+		blocks = append(blocks, ContentBlock{Type: "text", Text: m.Content})
+	}
+	blocks = append(blocks, m.Blocks...)
+
+	return json.Marshal(struct {
+		Role    string         `json:"role"`
+		Content []ContentBlock `json:"content"`
+	}{m.Role, blocks})
 }
 
 // Response represents an Anthropic API response
@@ -27,18 +130,31 @@ type Response struct {
 	Model      string    `json:"model"`
 	StopReason string    `json:"stop_reason"`
 	Usage      Usage     `json:"usage"`
+
+	RequestID string `json:"-"` // From the x-request-id or request-id response header
+
+	Raw *provider.RawExchange `json:"-"` // Set when the request has IncludeRaw
 }
 
 // Content represents content in Anthropic response
 type Content struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	ID    string          `json:"id,omitempty"`    // tool_use
+	Name  string          `json:"name,omitempty"`  // tool_use
+	Input json.RawMessage `json:"input,omitempty"` // tool_use
+
+	Thinking  string `json:"thinking,omitempty"`  // thinking
+	Signature string `json:"signature,omitempty"` // thinking
+	Data      string `json:"data,omitempty"`      // redacted_thinking
 }
 
 // Usage represents token usage in Anthropic response
 type Usage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"` // Excludes cache reads and writes
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
 }
 
 // StreamEvent represents a streaming event from Anthropic API
@@ -49,13 +165,19 @@ type StreamEvent struct {
 	Message      *StreamMessage `json:"message,omitempty"`
 	ContentBlock *Content       `json:"content_block,omitempty"`
 	Usage        *StreamUsage   `json:"usage,omitempty"`
+
+	// Error is set on error events, e.g. when the API is overloaded mid-stream
+	Error *provider.StreamError `json:"error,omitempty"`
 }
 
 // StreamDelta represents the delta content in a streaming event
 type StreamDelta struct {
-	Type       string `json:"type"`
-	Text       string `json:"text,omitempty"`
-	StopReason string `json:"stop_reason,omitempty"`
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"` // input_json_delta
+	Thinking    string `json:"thinking,omitempty"`     // thinking_delta
+	Signature   string `json:"signature,omitempty"`    // signature_delta
+	StopReason  string `json:"stop_reason,omitempty"`
 }
 
 // StreamMessage represents message metadata in streaming events
@@ -67,7 +189,77 @@ type StreamMessage struct {
 	Usage Usage  `json:"usage"`
 }
 
-// StreamUsage represents usage information in streaming events
+// StreamUsage represents usage information in streaming events. The
+// message_delta event reports cumulative counts; input and cache counts are
+// only present when they differ from message_start.
 type StreamUsage struct {
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens,omitempty"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// ModelList is a page of results from the models endpoint
+type ModelList struct {
+	Data    []ModelInfo `json:"data"`
+	HasMore bool        `json:"has_more"`
+	FirstID string      `json:"first_id"`
+	LastID  string      `json:"last_id"`
+}
+
+// ModelInfo describes an Anthropic model
+type ModelInfo struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	CreatedAt   string `json:"created_at"` // RFC 3339
+}
+
+// BatchCreateRequest is the body of a Message Batches create request
+type BatchCreateRequest struct {
+	Requests []BatchRequestItem `json:"requests"`
+}
+
+// BatchRequestItem is one Messages request in a batch
+type BatchRequestItem struct {
+	CustomID string   `json:"custom_id"`
+	Params   *Request `json:"params"`
+}
+
+// MessageBatch describes a Message Batch and its processing status
+type MessageBatch struct {
+	ID                string        `json:"id"`
+	Type              string        `json:"type"`
+	ProcessingStatus  string        `json:"processing_status"` // in_progress, canceling, or ended
+	RequestCounts     RequestCounts `json:"request_counts"`
+	CreatedAt         string        `json:"created_at"`                    // RFC 3339
+	EndedAt           string        `json:"ended_at,omitempty"`            // RFC 3339
+	ExpiresAt         string        `json:"expires_at"`                    // RFC 3339
+	CancelInitiatedAt string        `json:"cancel_initiated_at,omitempty"` // RFC 3339
+	ResultsURL        string        `json:"results_url,omitempty"`         // Set once processing ends
+}
+
+// RequestCounts tallies the requests in a Message Batch by outcome
+type RequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// BatchResultLine is one line of a Message Batch results file
+type BatchResultLine struct {
+	CustomID string      `json:"custom_id"`
+	Result   BatchResult `json:"result"`
+}
+
+// BatchResult is the outcome of one request in a Message Batch
+type BatchResult struct {
+	Type    string    `json:"type"` // succeeded, errored, canceled, or expired
+	Message *Response `json:"message,omitempty"`
+	Error   *struct {
+		Type  string                `json:"type"`
+		Error *provider.StreamError `json:"error"`
+	} `json:"error,omitempty"`
 }
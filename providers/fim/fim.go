@@ -0,0 +1,196 @@
+// Package fim provides clients for the Mistral (Codestral) and DeepSeek
+// fill-in-the-middle APIs, which complete code between a prefix and a suffix.
+package fim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// Default models for each service
+const (
+	DefaultMistralModel  = "codestral-latest"
+	DefaultDeepSeekModel = "deepseek-chat"
+)
+
+// CodestralBaseURL is the base URL of the Codestral endpoint, which takes
+// Codestral-specific API keys. The default Mistral base URL takes
+// La Plateforme keys.
+const CodestralBaseURL = "https://codestral.mistral.ai/v1"
+
+// Client implements a fill-in-the-middle API client
+type Client struct {
+	name         string
+	apiKey       string
+	baseURL      string
+	path         string
+	defaultModel string
+	seed         bool // Send Seed as random_seed; DeepSeek has no seed parameter
+	client       *http.Client
+}
+
+// NewMistral creates a client for the Mistral FIM API, which serves the
+// Codestral models
+func NewMistral(apiKey, baseURL string, httpClient *http.Client) *Client {
+	return newClient("mistral", apiKey, baseURL, "https://api.mistral.ai/v1", "/fim/completions", DefaultMistralModel, true, httpClient)
+}
+
+// NewDeepSeek creates a client for the DeepSeek FIM API
+func NewDeepSeek(apiKey, baseURL string, httpClient *http.Client) *Client {
+	return newClient("deepseek", apiKey, baseURL, "https://api.deepseek.com/beta", "/completions", DefaultDeepSeekModel, false, httpClient)
+}
+
+func newClient(name, apiKey, baseURL, defaultBaseURL, path, defaultModel string, seed bool, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		name:         name,
+		apiKey:       apiKey,
+		baseURL:      baseURL,
+		path:         path,
+		defaultModel: defaultModel,
+		seed:         seed,
+		client:       httpClient,
+	}
+}
+
+// Name returns the provider name
+func (c *Client) Name() string {
+	return c.name
+}
+
+// CreateCompletion calls the FIM endpoint
+func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+c.path, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// CreateFIMCompletion generates the code between the prompt and suffix
+func (c *Client) CreateFIMCompletion(ctx context.Context, req *provider.FIMRequest) (*provider.FIMResponse, error) {
+	if req.Prompt == "" && req.Suffix == "" {
+		return nil, fmt.Errorf("%w: FIM prompt and suffix cannot both be empty", provider.ErrInvalidRequest)
+	}
+
+	wireReq := &Request{
+		Model:       req.Model,
+		Prompt:      req.Prompt,
+		Suffix:      req.Suffix,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+	}
+	if wireReq.Model == "" {
+		wireReq.Model = c.defaultModel
+	}
+	if c.seed {
+		wireReq.RandomSeed = req.Seed
+	}
+
+	resp, err := c.CreateCompletion(ctx, wireReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("%s FIM response has no choices", c.name)
+	}
+
+	choice := resp.Choices[0]
+	text := choice.Text
+	if choice.Message != nil {
+		text = choice.Message.Content
+	}
+	var finishReason *string
+	if choice.FinishReason != nil {
+		reason := provider.NormalizeFinishReason(*choice.FinishReason)
+		finishReason = &reason
+	}
+
+	return &provider.FIMResponse{
+		ID:           resp.ID,
+		Model:        resp.Model,
+		Provider:     c.name,
+		Text:         text,
+		FinishReason: finishReason,
+		Usage: provider.Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+			TotalTokens:      resp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// handleErrorResponse converts an error response. DeepSeek reports errors
+// as {"error": {...}}; Mistral as {"message": ...} or {"detail": ...}.
+func (c *Client) handleErrorResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return provider.NewAPIErrorFromResponse(c.name, resp, "failed to read error response", "", "")
+	}
+
+	var errorResp struct {
+		Error *struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+		Message any    `json:"message"`
+		Type    string `json:"type"`
+		Detail  any    `json:"detail"`
+	}
+	message, errorType := string(body), ""
+	if err := json.Unmarshal(body, &errorResp); err == nil {
+		switch {
+		case errorResp.Error != nil && errorResp.Error.Message != "":
+			message, errorType = errorResp.Error.Message, errorResp.Error.Type
+		case errorResp.Message != nil:
+			if msg, ok := errorResp.Message.(string); ok && msg != "" {
+				message, errorType = msg, errorResp.Type
+			}
+		case errorResp.Detail != nil:
+			if detail, ok := errorResp.Detail.(string); ok {
+				message = detail
+			}
+		}
+	}
+
+	return provider.NewAPIErrorFromResponse(c.name, resp, c.name+" FIM API error: "+message, errorType, "").WithBody(body)
+}
@@ -0,0 +1,87 @@
+package fim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestClient_CreateFIMCompletion(t *testing.T) {
+	seed := 7
+	tests := []struct {
+		name      string
+		newClient func(apiKey, baseURL string, httpClient *http.Client) *Client
+		path      string
+		response  string
+		wantSeed  bool
+	}{
+		{
+			name:      "mistral",
+			newClient: NewMistral,
+			path:      "/fim/completions",
+			response:  `{"id":"fim-1","model":"codestral-latest","choices":[{"index":0,"message":{"role":"assistant","content":"return a + b"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+			wantSeed:  true,
+		},
+		{
+			name:      "deepseek",
+			newClient: NewDeepSeek,
+			path:      "/completions",
+			response:  `{"id":"fim-1","model":"deepseek-chat","choices":[{"index":0,"text":"return a + b","finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path || r.Header.Get("Authorization") != "Bearer test-key" {
+					t.Errorf("request = %s %s", r.Method, r.URL)
+				}
+				var body map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				if body["prompt"] != "def add(a, b):\n    " || body["suffix"] != "\n\nprint(add(1, 2))" {
+					t.Errorf("body = %v", body)
+				}
+				if _, ok := body["random_seed"]; ok != tt.wantSeed {
+					t.Errorf("random_seed sent = %v, want %v", ok, tt.wantSeed)
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := tt.newClient("test-key", server.URL, server.Client())
+			resp, err := client.CreateFIMCompletion(context.Background(), &provider.FIMRequest{
+				Prompt: "def add(a, b):\n    ",
+				Suffix: "\n\nprint(add(1, 2))",
+				Seed:   &seed,
+			})
+			if err != nil {
+				t.Fatalf("CreateFIMCompletion() error = %v", err)
+			}
+			if resp.Text != "return a + b" || resp.Provider != tt.name || *resp.FinishReason != provider.FinishReasonStop || resp.Usage.TotalTokens != 15 {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestClient_CreateFIMCompletion_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"Authentication Fails","type":"authentication_error"}}`))
+	}))
+	defer server.Close()
+
+	client := NewDeepSeek("bad-key", server.URL, server.Client())
+	_, err := client.CreateFIMCompletion(context.Background(), &provider.FIMRequest{Prompt: "x = "})
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Type != "authentication_error" {
+		t.Errorf("error = %v, want 401 APIError", err)
+	}
+}
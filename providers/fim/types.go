@@ -0,0 +1,46 @@
+package fim
+
+// Request is the fill-in-the-middle request body shared by Mistral and
+// DeepSeek. Only Mistral accepts RandomSeed.
+type Request struct {
+	Model       string   `json:"model"`
+	Prompt      string   `json:"prompt"`
+	Suffix      string   `json:"suffix,omitempty"`
+	MaxTokens   *int     `json:"max_tokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	RandomSeed  *int     `json:"random_seed,omitempty"`
+}
+
+// Response is the completion response body. Mistral returns the text as a
+// chat message; DeepSeek returns it as text.
+type Response struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// Choice is one completion
+type Choice struct {
+	Index        int      `json:"index"`
+	Text         string   `json:"text,omitempty"`    // DeepSeek
+	Message      *Message `json:"message,omitempty"` // Mistral
+	FinishReason *string  `json:"finish_reason"`
+}
+
+// Message holds the completion text in Mistral responses
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Usage reports token usage
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
@@ -2,26 +2,40 @@
 package gemini
 
 import (
+	"cmp"
 	"context"
-	"io"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genai"
 
 	"github.com/plexusone/omnillm/provider"
 )
 
+// MaxInlineDataBytes is the largest inline document accepted by the Gemini API
+const MaxInlineDataBytes = 20 << 20
+
+// generatedCallIDPrefix marks tool call IDs made up for function calls the
+// API returned without one; they are not sent back to the API
+const generatedCallIDPrefix = "gemini_call_"
+
 // Provider represents the Gemini provider adapter
 type Provider struct {
 	client *Client
 }
 
 // NewProvider creates a new Gemini provider adapter
-func NewProvider(apiKey string) provider.Provider {
-	client := New(apiKey)
+func NewProvider(apiKey, baseURL string, httpClient *http.Client) provider.Provider {
+	client := New(apiKey, baseURL, httpClient)
 	return &Provider{client: client}
 }
 
 // NewProviderWithContext creates a new Gemini provider adapter with context
-func NewProviderWithContext(ctx context.Context, apiKey string) (provider.Provider, error) {
-	client, err := NewWithContext(ctx, apiKey)
+func NewProviderWithContext(ctx context.Context, apiKey, baseURL string, httpClient *http.Client) (provider.Provider, error) {
+	client, err := NewWithContext(ctx, apiKey, baseURL, httpClient)
 	if err != nil {
 		return nil, err
 	}
@@ -33,32 +47,16 @@ func (p *Provider) Name() string {
 	return p.client.Name()
 }
 
+// Capabilities returns the request features the Gemini adapter supports
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Streaming: true, Tools: true, FileSearch: true, Vision: true, JSONSchema: true, MultipleChoices: true}
+}
+
 // CreateChatCompletion creates a chat completion
 func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
-	// Convert from unified format to Gemini format
-	geminiReq := &Request{
-		Model:       req.Model,
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
-		TopK:        req.TopK,
-		Stop:        req.Stop,
-	}
-
-	// Convert response format if provided
-	if req.ResponseFormat != nil {
-		geminiReq.ResponseFormat = &ResponseFormat{
-			Type: req.ResponseFormat.Type,
-		}
-	}
-
-	// Convert messages
-	for _, msg := range req.Messages {
-		geminiReq.Messages = append(geminiReq.Messages, Message{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-			Name:    msg.Name,
-		})
+	geminiReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := p.client.CreateCompletion(ctx, geminiReq)
@@ -81,31 +79,132 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 
 	// Convert choices
 	for _, choice := range resp.Choices {
+		toolCalls := convertFunctionCalls(choice.Message.FunctionCalls, 0, false)
 		unifiedChoice := provider.ChatCompletionChoice{
 			Index: choice.Index,
 			Message: provider.Message{
-				Role:    provider.Role(choice.Message.Role),
-				Content: choice.Message.Content,
-				Name:    choice.Message.Name,
+				Role:      provider.Role(choice.Message.Role),
+				Content:   choice.Message.Content,
+				Name:      choice.Message.Name,
+				ToolCalls: toolCalls,
 			},
-			FinishReason: choice.FinishReason,
+			FinishReason: toolCallsFinishReason(choice.FinishReason, len(toolCalls) > 0),
+			Annotations:  groundingAnnotations(choice.Grounding),
 		}
 		unifiedResp.Choices = append(unifiedResp.Choices, unifiedChoice)
 	}
+	unifiedResp.ProviderMetadata = provider.NormalizeFinishReasons(unifiedResp.Choices, unifiedResp.ProviderMetadata)
+	unifiedResp.ProviderMetadata = provider.RecordDeterminism(unifiedResp.ProviderMetadata, req.Seed, true, resp.ModelVersion)
+	unifiedResp.ProviderMetadata = provider.RecordRequestID(unifiedResp.ProviderMetadata, resp.RequestID)
 
 	return unifiedResp, nil
 }
 
 // CreateChatCompletionStream creates a streaming chat completion
 func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
-	// Convert from unified format to Gemini format
+	geminiReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := p.client.CreateCompletionStream(ctx, geminiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamAdapter{stream: stream, seed: req.Seed}, nil
+}
+
+// UploadFile uploads a file to the Gemini Files API and returns a unified file handle
+func (p *Provider) UploadFile(ctx context.Context, req *provider.FileUploadRequest) (*provider.File, error) {
+	file, err := p.client.UploadFile(ctx, req.Data, req.MIMEType, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return convertFile(file), nil
+}
+
+// GetFile retrieves a Gemini file as a unified file handle
+func (p *Provider) GetFile(ctx context.Context, fileID string) (*provider.File, error) {
+	file, err := p.client.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return convertFile(file), nil
+}
+
+// DeleteFile deletes a Gemini file
+func (p *Provider) DeleteFile(ctx context.Context, fileID string) error {
+	return p.client.DeleteFile(ctx, fileID)
+}
+
+// convertFile converts a Gemini file to a unified file handle
+func convertFile(file *genai.File) *provider.File {
+	result := &provider.File{
+		ID:       file.Name,
+		Provider: "gemini",
+		Filename: file.DisplayName,
+		MIMEType: file.MIMEType,
+		URI:      file.URI,
+		Status:   string(file.State),
+	}
+	if file.SizeBytes != nil {
+		result.Bytes = *file.SizeBytes
+	}
+	if !file.CreateTime.IsZero() {
+		result.CreatedAt = file.CreateTime.Unix()
+	}
+	if !file.ExpirationTime.IsZero() {
+		result.ExpiresAt = file.ExpirationTime.Unix()
+	}
+	return result
+}
+
+// CreateVectorStore creates a Gemini file search store, importing any files given in the request
+func (p *Provider) CreateVectorStore(ctx context.Context, req *provider.VectorStoreRequest) (*provider.VectorStore, error) {
+	store, err := p.client.CreateFileSearchStore(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileID := range req.FileIDs {
+		if err := p.client.ImportFile(ctx, store.Name, fileID); err != nil {
+			return nil, err
+		}
+	}
+	return convertFileSearchStore(store), nil
+}
+
+// GetVectorStore retrieves a Gemini file search store as a unified vector store
+func (p *Provider) GetVectorStore(ctx context.Context, storeID string) (*provider.VectorStore, error) {
+	store, err := p.client.GetFileSearchStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	return convertFileSearchStore(store), nil
+}
+
+// AddVectorStoreFile imports an uploaded file into a Gemini file search store
+func (p *Provider) AddVectorStoreFile(ctx context.Context, storeID, fileID string) error {
+	return p.client.ImportFile(ctx, storeID, fileID)
+}
+
+// DeleteVectorStore deletes a Gemini file search store
+func (p *Provider) DeleteVectorStore(ctx context.Context, storeID string) error {
+	return p.client.DeleteFileSearchStore(ctx, storeID)
+}
+
+// convertRequest converts from unified format to Gemini format
+func convertRequest(req *provider.ChatCompletionRequest) (*Request, error) {
 	geminiReq := &Request{
-		Model:       req.Model,
-		MaxTokens:   req.MaxTokens,
-		Temperature: req.Temperature,
-		TopP:        req.TopP,
-		TopK:        req.TopK,
-		Stop:        req.Stop,
+		Model:          req.Model,
+		MaxTokens:      req.MaxTokens,
+		Temperature:    req.Temperature,
+		TopP:           req.TopP,
+		TopK:           req.TopK,
+		CandidateCount: req.N,
+		Seed:           req.Seed,
+		Stop:           req.Stop,
+		Headers:        req.Headers,
 	}
 
 	// Convert response format if provided
@@ -113,23 +212,327 @@ func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider
 		geminiReq.ResponseFormat = &ResponseFormat{
 			Type: req.ResponseFormat.Type,
 		}
+		if req.ResponseFormat.JSONSchema != nil {
+			geminiReq.ResponseFormat.Schema = req.ResponseFormat.JSONSchema.Schema
+		}
 	}
 
 	// Convert messages
-	for _, msg := range req.Messages {
-		geminiReq.Messages = append(geminiReq.Messages, Message{
+	messages, err := convertMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	geminiReq.Messages = messages
+	geminiReq.SystemInstruction = systemInstruction(req.Messages)
+	geminiReq.FileSearch = convertFileSearch(req.Tools)
+	geminiReq.Functions = convertFunctions(req.Tools)
+	geminiReq.FunctionCalling, err = convertToolChoice(req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := provider.DecodeOptions[Options](req, "gemini")
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		geminiReq.SafetySettings = opts.SafetySettings
+		geminiReq.GoogleSearch = opts.GoogleSearch
+		if opts.SystemInstruction != "" {
+			geminiReq.SystemInstruction = opts.SystemInstruction
+		}
+	}
+
+	return geminiReq, nil
+}
+
+// convertFileSearchStore converts a Gemini file search store to a unified vector store
+func convertFileSearchStore(store *genai.FileSearchStore) *provider.VectorStore {
+	result := &provider.VectorStore{
+		ID:        store.Name,
+		Provider:  "gemini",
+		Name:      store.DisplayName,
+		FileCount: int(store.ActiveDocumentsCount + store.PendingDocumentsCount + store.FailedDocumentsCount),
+		Status:    "completed",
+	}
+	if store.PendingDocumentsCount > 0 {
+		result.Status = "in_progress"
+	}
+	if !store.CreateTime.IsZero() {
+		result.CreatedAt = store.CreateTime.Unix()
+	}
+	return result
+}
+
+// convertFileSearch merges file_search tools into a Gemini file search configuration
+func convertFileSearch(tools []provider.Tool) *FileSearch {
+	var fileSearch *FileSearch
+	for _, tool := range tools {
+		if tool.Type != provider.ToolTypeFileSearch || tool.FileSearch == nil {
+			continue
+		}
+		if fileSearch == nil {
+			fileSearch = &FileSearch{}
+		}
+		fileSearch.StoreNames = append(fileSearch.StoreNames, tool.FileSearch.VectorStoreIDs...)
+		if tool.FileSearch.MaxResults != nil {
+			fileSearch.TopK = tool.FileSearch.MaxResults
+		}
+	}
+	return fileSearch
+}
+
+// convertFunctions converts function tools to Gemini function declarations
+func convertFunctions(tools []provider.Tool) []Function {
+	var functions []Function
+	for _, tool := range tools {
+		if tool.Type == provider.ToolTypeFileSearch {
+			continue
+		}
+		functions = append(functions, Function{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+	return functions
+}
+
+// convertToolChoice converts an OpenAI-style tool choice ("auto", "none",
+// "required", or {"type": "function", "function": {"name": ...}}) to a
+// Gemini function calling mode
+func convertToolChoice(choice any) (*FunctionCalling, error) {
+	if choice == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(choice)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tool choice: %w", err)
+	}
+
+	var mode string
+	if json.Unmarshal(data, &mode) == nil {
+		switch mode {
+		case "auto":
+			return &FunctionCalling{Mode: "AUTO"}, nil
+		case "none":
+			return &FunctionCalling{Mode: "NONE"}, nil
+		case "required":
+			return &FunctionCalling{Mode: "ANY"}, nil
+		}
+		return nil, fmt.Errorf("unsupported tool choice %q", mode)
+	}
+
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(data, &named); err != nil {
+		return nil, fmt.Errorf("invalid tool choice: %w", err)
+	}
+	if named.Function.Name == "" {
+		return nil, fmt.Errorf("tool choice %s names no function", data)
+	}
+	return &FunctionCalling{Mode: "ANY", AllowedFunctions: []string{named.Function.Name}}, nil
+}
+
+// convertFunctionCalls converts Gemini function calls to unified tool calls,
+// giving calls without an ID one. first is the index of the first call among
+// those streamed for the choice; indexed sets ToolCall.Index for stream deltas.
+func convertFunctionCalls(calls []FunctionCall, first int, indexed bool) []provider.ToolCall {
+	var result []provider.ToolCall
+	for i, call := range calls {
+		args := "{}"
+		if len(call.Args) > 0 {
+			if data, err := json.Marshal(call.Args); err == nil {
+				args = string(data)
+			}
+		}
+		tc := provider.ToolCall{
+			ID:       cmp.Or(call.ID, fmt.Sprintf("%s%d", generatedCallIDPrefix, first+i)),
+			Type:     "function",
+			Function: provider.ToolFunction{Name: call.Name, Arguments: args},
+		}
+		if indexed {
+			index := first + i
+			tc.Index = &index
+		}
+		result = append(result, tc)
+	}
+	return result
+}
+
+// toolCallsFinishReason reports a choice that called functions as finishing
+// with tool calls; Gemini reports it as a normal stop
+func toolCallsFinishReason(reason *string, calledTools bool) *string {
+	if reason == nil || !calledTools || *reason != "STOP" {
+		return reason
+	}
+	toolCalls := provider.FinishReasonToolCalls
+	return &toolCalls
+}
+
+// callID returns the ID to send back to the API for a unified tool call ID,
+// which is empty for IDs made up by convertFunctionCalls
+func callID(id string) string {
+	if strings.HasPrefix(id, generatedCallIDPrefix) {
+		return ""
+	}
+	return id
+}
+
+// functionResponse converts a tool result to a Gemini function response. A
+// JSON object result is sent as is; anything else is sent as its "output".
+func functionResponse(content string) map[string]any {
+	var object map[string]any
+	if json.Unmarshal([]byte(content), &object) == nil && object != nil {
+		return object
+	}
+	return map[string]any{"output": content}
+}
+
+// convertMessages converts unified messages to Gemini format
+func convertMessages(messages []provider.Message) ([]Message, error) {
+	result := make([]Message, 0, len(messages))
+	callNames := make(map[string]string) // Tool call ID to function name
+	for _, msg := range messages {
+		if msg.Role == provider.RoleSystem {
+			continue // Sent as the system instruction
+		}
+		geminiMsg := Message{
 			Role:    string(msg.Role),
 			Content: msg.Content,
 			Name:    msg.Name,
+		}
+		if msg.Role == provider.RoleAssistant {
+			for _, call := range msg.ToolCalls {
+				var args map[string]any
+				if call.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+						return nil, fmt.Errorf("tool call %q has invalid JSON arguments: %w", call.ID, err)
+					}
+				}
+				callNames[call.ID] = call.Function.Name
+				geminiMsg.FunctionCalls = append(geminiMsg.FunctionCalls, FunctionCall{ID: callID(call.ID), Name: call.Function.Name, Args: args})
+			}
+		}
+		if msg.Role == provider.RoleTool {
+			if msg.ToolCallID == nil || *msg.ToolCallID == "" {
+				return nil, errors.New("tool message has no tool call ID")
+			}
+			name := callNames[*msg.ToolCallID]
+			if msg.Name != nil && *msg.Name != "" {
+				name = *msg.Name
+			}
+			if name == "" {
+				return nil, fmt.Errorf("tool message for %q does not follow an assistant call with that ID", *msg.ToolCallID)
+			}
+			geminiMsg.Content = ""
+			geminiMsg.FunctionResponses = []FunctionResponse{{ID: callID(*msg.ToolCallID), Name: name, Response: functionResponse(msg.Content)}}
+		}
+		for _, part := range msg.Parts {
+			switch part.Type {
+			case provider.ContentPartTypeText:
+				if geminiMsg.Content != "" {
+					geminiMsg.Content += "\n"
+				}
+				geminiMsg.Content += part.Text
+			case provider.ContentPartTypeDocument:
+				doc, err := convertDocument(part.Document)
+				if err != nil {
+					return nil, err
+				}
+				geminiMsg.Documents = append(geminiMsg.Documents, doc)
+			default:
+				return nil, fmt.Errorf("unsupported content part type %q", part.Type)
+			}
+		}
+		result = append(result, geminiMsg)
+	}
+	return result, nil
+}
+
+// systemInstruction joins the content of the system messages
+func systemInstruction(messages []provider.Message) string {
+	var parts []string
+	for _, msg := range messages {
+		if msg.Role == provider.RoleSystem && msg.Content != "" {
+			parts = append(parts, msg.Content)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// groundingAnnotations converts grounding sources into citation annotations
+func groundingAnnotations(grounding *Grounding) []provider.Annotation {
+	if grounding == nil || len(grounding.Sources) == 0 {
+		return nil
+	}
+	annotations := make([]provider.Annotation, 0, len(grounding.Sources))
+	for i, source := range grounding.Sources {
+		annotations = append(annotations, provider.Annotation{
+			Type:   "citation",
+			Source: "gemini.google_search",
+			Label:  cmp.Or(source.Title, source.URI),
+			Data: map[string]any{
+				"url":   source.URI,
+				"index": i,
+			},
 		})
 	}
+	return annotations
+}
 
-	stream, err := p.client.CreateCompletionStream(ctx, geminiReq)
+// convertDocument converts a unified document to a Gemini document
+func convertDocument(doc *provider.Document) (Document, error) {
+	switch {
+	case doc == nil:
+		return Document{}, fmt.Errorf("document part is missing document")
+	case doc.FileURI != "":
+		return Document{MIMEType: doc.MIMEType, FileURI: doc.FileURI}, nil
+	case len(doc.Data) > MaxInlineDataBytes:
+		return Document{}, fmt.Errorf("document %q is %d bytes, exceeds Gemini inline limit of %d bytes; upload it with UploadFile instead",
+			doc.Filename, len(doc.Data), MaxInlineDataBytes)
+	case len(doc.Data) > 0:
+		return Document{MIMEType: doc.MIMEType, Data: doc.Data}, nil
+	default:
+		return Document{}, fmt.Errorf("document %q has no data or file URI", doc.Filename)
+	}
+}
+
+// ListModels lists the available Gemini models with their token limits.
+// Capabilities come from the generation methods each model supports.
+func (p *Provider) ListModels(ctx context.Context) ([]provider.Model, error) {
+	infos, err := p.client.ListModels(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return &StreamAdapter{stream: stream}, nil
+	models := make([]provider.Model, 0, len(infos))
+	for _, info := range infos {
+		model := provider.Model{
+			ID:              strings.TrimPrefix(info.Name, "models/"),
+			Provider:        p.Name(),
+			DisplayName:     info.DisplayName,
+			ContextWindow:   int(info.InputTokenLimit),
+			MaxOutputTokens: int(info.OutputTokenLimit),
+		}
+		for _, action := range info.SupportedActions {
+			switch action {
+			case "generateContent":
+				// Gemini chat models are multimodal, stream, and support function calling
+				model.Capabilities.Chat = true
+				model.Capabilities.Streaming = true
+				model.Capabilities.Tools = true
+				model.Capabilities.Vision = true
+			case "embedContent", "batchEmbedContents":
+				model.Capabilities.Embeddings = true
+			}
+		}
+		models = append(models, model)
+	}
+	return models, nil
 }
 
 // Close closes the provider
@@ -140,15 +543,16 @@ func (p *Provider) Close() error {
 // StreamAdapter adapts Gemini stream to unified interface
 type StreamAdapter struct {
 	stream *Stream
+	seed   *int // Seed sent with the request, if any
+
+	// toolCalls counts the tool calls streamed so far for each choice
+	toolCalls map[int]int
 }
 
 // Recv receives the next chunk from the stream
 func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 	chunk, err := s.stream.Recv()
 	if err != nil {
-		if err == io.EOF {
-			return nil, io.EOF
-		}
 		return nil, err
 	}
 
@@ -172,18 +576,30 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 		unifiedChoice := provider.ChatCompletionChoice{
 			Index:        choice.Index,
 			FinishReason: choice.FinishReason,
+			Annotations:  groundingAnnotations(choice.Grounding),
 		}
 
 		if choice.Delta != nil {
+			if s.toolCalls == nil {
+				s.toolCalls = make(map[int]int)
+			}
+			toolCalls := convertFunctionCalls(choice.Delta.FunctionCalls, s.toolCalls[choice.Index], true)
+			s.toolCalls[choice.Index] += len(toolCalls)
 			unifiedChoice.Delta = &provider.Message{
-				Role:    provider.Role(choice.Delta.Role),
-				Content: choice.Delta.Content,
-				Name:    choice.Delta.Name,
+				Role:      provider.Role(choice.Delta.Role),
+				Content:   choice.Delta.Content,
+				Name:      choice.Delta.Name,
+				ToolCalls: toolCalls,
 			}
 		}
+		unifiedChoice.FinishReason = toolCallsFinishReason(unifiedChoice.FinishReason, s.toolCalls[choice.Index] > 0)
 
 		result.Choices = append(result.Choices, unifiedChoice)
 	}
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	if _, finished := result.ProviderMetadata[provider.RawFinishReasonKey]; finished {
+		result.ProviderMetadata = provider.RecordDeterminism(result.ProviderMetadata, s.seed, true, chunk.ModelVersion)
+	}
 
 	return result, nil
 }
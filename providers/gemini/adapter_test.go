@@ -0,0 +1,180 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestConvertRequest_SystemInstructionAndGrounding(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Be brief."},
+			{Role: provider.RoleSystem, Content: "Cite sources."},
+			{Role: provider.RoleUser, Content: "Who won the match?"},
+		},
+		ProviderOptions: map[string]any{"gemini": Options{GoogleSearch: true}},
+	}
+
+	geminiReq, err := convertRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if geminiReq.SystemInstruction != "Be brief.\n\nCite sources." {
+		t.Errorf("SystemInstruction = %q", geminiReq.SystemInstruction)
+	}
+	if len(geminiReq.Messages) != 1 || geminiReq.Messages[0].Role != "user" {
+		t.Errorf("system messages should not be sent as content: %+v", geminiReq.Messages)
+	}
+
+	config := generateConfig(geminiReq)
+	if config == nil || config.SystemInstruction == nil || config.SystemInstruction.Parts[0].Text != geminiReq.SystemInstruction {
+		t.Fatalf("system instruction not set: %+v", config)
+	}
+	if len(config.Tools) != 1 || config.Tools[0].GoogleSearch == nil {
+		t.Errorf("Google Search tool not set: %+v", config.Tools)
+	}
+
+	req.ProviderOptions = map[string]any{"gemini": map[string]any{"system_instruction": "Override."}}
+	if geminiReq, _ := convertRequest(req); geminiReq.SystemInstruction != "Override." {
+		t.Errorf("SystemInstruction = %q, want the option to override", geminiReq.SystemInstruction)
+	}
+}
+
+func TestBlockedError(t *testing.T) {
+	harassment := []*genai.SafetyRating{{Category: genai.HarmCategoryHarassment, Probability: genai.HarmProbabilityHigh, Blocked: true}}
+
+	err := blockedError(&genai.GenerateContentResponse{
+		PromptFeedback: &genai.GenerateContentResponsePromptFeedback{BlockReason: genai.BlockedReasonSafety, SafetyRatings: harassment},
+	})
+	var blocked *provider.ContentBlockedError
+	if !errors.As(err, &blocked) || !blocked.Prompt || blocked.Reason != "SAFETY" ||
+		len(blocked.Ratings) != 1 || blocked.Ratings[0].Category != "HARM_CATEGORY_HARASSMENT" {
+		t.Errorf("unexpected prompt block error: %v", err)
+	}
+
+	err = blockedError(&genai.GenerateContentResponse{Candidates: []*genai.Candidate{
+		{FinishReason: genai.FinishReasonProhibitedContent, SafetyRatings: harassment},
+	}})
+	if !errors.Is(err, provider.ErrContentBlocked) || !errors.As(err, &blocked) || blocked.Prompt || blocked.Reason != "PROHIBITED_CONTENT" {
+		t.Errorf("unexpected response block error: %v", err)
+	}
+
+	// A candidate that finished normally is returned as is
+	err = blockedError(&genai.GenerateContentResponse{Candidates: []*genai.Candidate{
+		{FinishReason: genai.FinishReasonSafety},
+		{FinishReason: genai.FinishReasonStop},
+	}})
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestGroundingAnnotations(t *testing.T) {
+	grounding := convertGrounding(&genai.GroundingMetadata{
+		WebSearchQueries: []string{"match result"},
+		GroundingChunks: []*genai.GroundingChunk{
+			{Web: &genai.GroundingChunkWeb{URI: "https://example.com/a", Title: "example.com"}},
+			{Web: &genai.GroundingChunkWeb{URI: "https://example.com/b"}},
+		},
+	})
+	annotations := groundingAnnotations(grounding)
+	if len(annotations) != 2 || annotations[0].Type != "citation" || annotations[0].Label != "example.com" ||
+		annotations[1].Label != "https://example.com/b" || annotations[1].Data["url"] != "https://example.com/b" {
+		t.Errorf("unexpected annotations: %+v", annotations)
+	}
+	if convertGrounding(&genai.GroundingMetadata{}) != nil {
+		t.Error("expected nil grounding for empty metadata")
+	}
+}
+
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestProvider_CustomBaseURLAndHTTPClient(t *testing.T) {
+	var path, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiKey = r.URL.Path, r.Header.Get("x-goog-api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":1,"totalTokenCount":5}}`)
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	p := NewProvider("test-key", server.URL+"/proxy/", &http.Client{Transport: transport})
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(path, "/proxy/") || !strings.HasSuffix(path, "models/gemini-2.5-flash:generateContent") {
+		t.Errorf("request path = %q, want it under the custom base URL", path)
+	}
+	if apiKey != "test-key" {
+		t.Errorf("API key header = %q", apiKey)
+	}
+	if transport.requests.Load() != 1 {
+		t.Errorf("custom HTTP client made %d requests, want 1", transport.requests.Load())
+	}
+	if resp.Choices[0].Message.Content != "Hello" {
+		t.Errorf("Content = %q", resp.Choices[0].Message.Content)
+	}
+	if resp.Usage.PromptTokens != 4 || resp.Usage.CompletionTokens != 1 || resp.Usage.TotalTokens != 5 {
+		t.Errorf("Usage = %+v, want the reported usage", resp.Usage)
+	}
+}
+
+func TestProvider_StreamUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}],"usageMetadata":{"promptTokenCount":4,"totalTokenCount":4}}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"thoughtsTokenCount":3,"totalTokenCount":9}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var usages []*provider.Usage
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage != nil {
+			usages = append(usages, chunk.Usage)
+		}
+	}
+	if len(usages) != 1 || usages[0].PromptTokens != 4 || usages[0].CompletionTokens != 5 || usages[0].TotalTokens != 9 {
+		t.Errorf("usage chunks = %+v, want one final usage", usages)
+	}
+}
@@ -2,12 +2,19 @@
 package gemini
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"net/http"
 	"time"
 
 	"google.golang.org/genai"
+
+	"github.com/plexusone/omnillm/provider"
 )
 
 // Client implements Google Gemini API client
@@ -17,13 +24,12 @@ type Client struct {
 	initErr error
 }
 
-// New creates a new Gemini client
-func New(apiKey string) *Client {
+// New creates a new Gemini client. baseURL overrides the API endpoint, e.g.
+// for a proxy or a regional endpoint, and httpClient the HTTP client; either
+// may be empty to use the default.
+func New(apiKey, baseURL string, httpClient *http.Client) *Client {
 	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
+	client, err := genai.NewClient(ctx, clientConfig(apiKey, baseURL, httpClient))
 
 	// For simplicity, we'll store the error and handle it during first use
 	// In a production implementation, you might want to return the error here
@@ -35,11 +41,8 @@ func New(apiKey string) *Client {
 }
 
 // NewWithContext creates a new Gemini client with context
-func NewWithContext(ctx context.Context, apiKey string) (*Client, error) {
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
+func NewWithContext(ctx context.Context, apiKey, baseURL string, httpClient *http.Client) (*Client, error) {
+	client, err := genai.NewClient(ctx, clientConfig(apiKey, baseURL, httpClient))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	}
@@ -50,6 +53,16 @@ func NewWithContext(ctx context.Context, apiKey string) (*Client, error) {
 	}, nil
 }
 
+// clientConfig builds the genai client configuration
+func clientConfig(apiKey, baseURL string, httpClient *http.Client) *genai.ClientConfig {
+	return &genai.ClientConfig{
+		APIKey:      apiKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPClient:  httpClient,
+		HTTPOptions: genai.HTTPOptions{BaseURL: baseURL},
+	}
+}
+
 // Name returns the provider name
 func (c *Client) Name() string {
 	return "gemini"
@@ -67,53 +80,38 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 		return nil, fmt.Errorf("messages cannot be empty")
 	}
 
-	// Create a chat session
-	chat, err := c.client.Chats.Create(ctx, req.Model, nil, nil)
+	// Send the conversation and get the response
+	response, err := c.client.Models.GenerateContent(ctx, req.Model, convertContents(req.Messages), generateConfig(req))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create chat: %w", err)
+		return nil, fmt.Errorf("failed to send message: %w", apiError(err))
 	}
-
-	// Convert messages to Gemini format
-	parts := make([]*genai.Part, 0, len(req.Messages))
-	for _, msg := range req.Messages {
-		if msg.Content != "" {
-			parts = append(parts, genai.NewPartFromText(msg.Content))
-		}
-	}
-
-	// Send the message and get response
-	response, err := chat.Send(ctx, parts...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send message: %w", err)
+	if err := blockedError(response); err != nil {
+		return nil, err
 	}
 
 	// Convert response to our format
 	result := &Response{
-		ID:      generateID(),
-		Object:  "chat.completion",
-		Created: currentTimestamp(),
-		Model:   req.Model,
-	}
-
-	if response.Candidates != nil && len(response.Candidates) > 0 {
-		candidate := response.Candidates[0]
-		content := ""
-
-		// Extract text content from the candidate
-		if candidate.Content != nil && candidate.Content.Parts != nil {
-			for _, part := range candidate.Content.Parts {
-				if part.Text != "" {
-					content += part.Text
-				}
-			}
-		}
+		ID:           generateID(),
+		Object:       "chat.completion",
+		Created:      currentTimestamp(),
+		Model:        req.Model,
+		ModelVersion: response.ModelVersion,
+	}
+	if response.SDKHTTPResponse != nil {
+		result.RequestID = provider.RequestIDFromHeader(response.SDKHTTPResponse.Headers)
+	}
+
+	for i, candidate := range response.Candidates {
+		content, calls := candidateContent(candidate)
 
 		choice := Choice{
-			Index: 0,
+			Index: i,
 			Message: Message{
-				Role:    "assistant",
-				Content: content,
+				Role:          "assistant",
+				Content:       content,
+				FunctionCalls: calls,
 			},
+			Grounding: convertGrounding(candidate.GroundingMetadata),
 		}
 
 		if candidate.FinishReason != "" {
@@ -121,16 +119,19 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 			choice.FinishReason = &reason
 		}
 
-		result.Choices = []Choice{choice}
+		result.Choices = append(result.Choices, choice)
 	}
 
-	// Set usage information (Gemini doesn't provide detailed token counts)
-	result.Usage = Usage{
-		PromptTokens:     estimateTokens(req.Messages),
-		CompletionTokens: estimateTokens(result.Choices),
-		TotalTokens:      0, // Will be calculated
+	// Set usage information, estimating it if the response has none
+	if usage := convertUsageMetadata(response.UsageMetadata); usage != nil {
+		result.Usage = *usage
+	} else {
+		result.Usage = Usage{
+			PromptTokens:     estimateTokens(req.Messages),
+			CompletionTokens: estimateTokens(result.Choices),
+		}
+		result.Usage.TotalTokens = result.Usage.PromptTokens + result.Usage.CompletionTokens
 	}
-	result.Usage.TotalTokens = result.Usage.PromptTokens + result.Usage.CompletionTokens
 
 	return result, nil
 }
@@ -147,41 +148,243 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 		return nil, fmt.Errorf("messages cannot be empty")
 	}
 
-	// Create a chat session
-	chat, err := c.client.Chats.Create(ctx, req.Model, nil, nil)
+	// Pull responses as they arrive, rather than collecting the whole stream,
+	// so that canceling ctx ends it promptly. The first response is read
+	// here so that errors sending the message are returned to the caller.
+	next, stop := iter.Pull2(c.client.Models.GenerateContentStream(ctx, req.Model, convertContents(req.Messages), generateConfig(req)))
+	first, err, ok := next()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create chat: %w", err)
+		stop()
+		return nil, fmt.Errorf("failed to send message: %w", apiError(err))
 	}
 
-	// Convert messages to Gemini format
-	parts := make([]*genai.Part, 0, len(req.Messages))
-	for _, msg := range req.Messages {
-		if msg.Content != "" {
-			parts = append(parts, genai.NewPartFromText(msg.Content))
+	stream := &Stream{
+		ctx:   ctx,
+		next:  next,
+		stop:  stop,
+		model: req.Model,
+	}
+	if ok {
+		stream.pending = first
+	}
+	return stream, nil
+}
+
+// apiError converts a genai.APIError to a provider.APIError, reading the
+// retry delay from its RetryInfo detail. Other errors are returned unchanged.
+func apiError(err error) error {
+	var genaiErr genai.APIError
+	if !errors.As(err, &genaiErr) {
+		return err
+	}
+	apiErr := &provider.APIError{
+		StatusCode: genaiErr.Code,
+		Message:    genaiErr.Message,
+		Type:       genaiErr.Status,
+		Provider:   "gemini",
+	}
+	// genai has already decoded the body, so re-encode the error it held
+	if body, err := json.Marshal(map[string]any{"error": genaiErr}); err == nil {
+		apiErr.Body = string(body)
+	}
+	for _, detail := range genaiErr.Details {
+		if delay, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(delay); err == nil {
+				apiErr.RetryAfter = d
+			}
 		}
 	}
+	return apiErr
+}
 
-	// Send the message with streaming
-	stream := chat.SendStream(ctx, parts...)
+// blockingFinishReasons are the finish reasons of a candidate stopped by a
+// safety or policy filter
+var blockingFinishReasons = map[genai.FinishReason]bool{
+	genai.FinishReasonSafety:                 true,
+	genai.FinishReasonRecitation:             true,
+	genai.FinishReasonBlocklist:              true,
+	genai.FinishReasonProhibitedContent:      true,
+	genai.FinishReasonSPII:                   true,
+	genai.FinishReasonImageSafety:            true,
+	genai.FinishReasonImageProhibitedContent: true,
+	genai.FinishReasonImageRecitation:        true,
+}
 
-	// Collect all responses from the stream
-	var responses []*genai.GenerateContentResponse
-	var errors []error
+// blockedError returns a ContentBlockedError if the prompt was blocked or
+// every candidate was stopped by a safety filter, otherwise nil
+func blockedError(resp *genai.GenerateContentResponse) error {
+	if fb := resp.PromptFeedback; fb != nil && fb.BlockReason != "" {
+		return &provider.ContentBlockedError{
+			Provider: "gemini",
+			Reason:   string(fb.BlockReason),
+			Prompt:   true,
+			Message:  fb.BlockReasonMessage,
+			Ratings:  convertSafetyRatings(fb.SafetyRatings),
+		}
+	}
+	if len(resp.Candidates) == 0 {
+		return nil
+	}
+	for _, candidate := range resp.Candidates {
+		if !blockingFinishReasons[candidate.FinishReason] {
+			return nil
+		}
+	}
+	candidate := resp.Candidates[0]
+	return &provider.ContentBlockedError{
+		Provider: "gemini",
+		Reason:   string(candidate.FinishReason),
+		Message:  candidate.FinishMessage,
+		Ratings:  convertSafetyRatings(candidate.SafetyRatings),
+	}
+}
 
-	for response, err := range stream {
-		if err != nil {
-			errors = append(errors, err)
-		} else {
-			responses = append(responses, response)
+// convertSafetyRatings converts Gemini safety ratings to unified ratings
+func convertSafetyRatings(ratings []*genai.SafetyRating) []provider.SafetyRating {
+	var result []provider.SafetyRating
+	for _, r := range ratings {
+		if r == nil {
+			continue
 		}
+		result = append(result, provider.SafetyRating{
+			Category:    string(r.Category),
+			Probability: string(r.Probability),
+			Blocked:     r.Blocked,
+		})
 	}
+	return result
+}
 
-	return &Stream{
-		responses: responses,
-		errors:    errors,
-		model:     req.Model,
-		index:     0,
-	}, nil
+// convertGrounding extracts the search queries and web sources from
+// grounding metadata, or returns nil if there are none
+func convertGrounding(md *genai.GroundingMetadata) *Grounding {
+	if md == nil {
+		return nil
+	}
+	grounding := &Grounding{SearchQueries: md.WebSearchQueries}
+	for _, chunk := range md.GroundingChunks {
+		if chunk != nil && chunk.Web != nil && chunk.Web.URI != "" {
+			grounding.Sources = append(grounding.Sources, Source{URI: chunk.Web.URI, Title: chunk.Web.Title})
+		}
+	}
+	if len(grounding.SearchQueries) == 0 && len(grounding.Sources) == 0 {
+		return nil
+	}
+	return grounding
+}
+
+// UploadFile uploads a file using the Gemini Files API
+func (c *Client) UploadFile(ctx context.Context, data []byte, mimeType, displayName string) (*genai.File, error) {
+	if c.initErr != nil {
+		return nil, fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+	if mimeType == "" {
+		return nil, fmt.Errorf("mime type cannot be empty")
+	}
+
+	file, err := c.client.Files.Upload(ctx, bytes.NewReader(data), &genai.UploadFileConfig{
+		MIMEType:    mimeType,
+		DisplayName: displayName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	return file, nil
+}
+
+// GetFile retrieves file metadata from the Gemini Files API
+func (c *Client) GetFile(ctx context.Context, name string) (*genai.File, error) {
+	if c.initErr != nil {
+		return nil, fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	file, err := c.client.Files.Get(ctx, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+	return file, nil
+}
+
+// DeleteFile deletes a file using the Gemini Files API
+func (c *Client) DeleteFile(ctx context.Context, name string) error {
+	if c.initErr != nil {
+		return fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	if _, err := c.client.Files.Delete(ctx, name, nil); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// CreateFileSearchStore creates a Gemini file search store
+func (c *Client) CreateFileSearchStore(ctx context.Context, displayName string) (*genai.FileSearchStore, error) {
+	if c.initErr != nil {
+		return nil, fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	store, err := c.client.FileSearchStores.Create(ctx, &genai.CreateFileSearchStoreConfig{
+		DisplayName: displayName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file search store: %w", err)
+	}
+	return store, nil
+}
+
+// GetFileSearchStore retrieves a Gemini file search store
+func (c *Client) GetFileSearchStore(ctx context.Context, name string) (*genai.FileSearchStore, error) {
+	if c.initErr != nil {
+		return nil, fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	store, err := c.client.FileSearchStores.Get(ctx, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file search store: %w", err)
+	}
+	return store, nil
+}
+
+// ImportFile imports a file uploaded with the Files API into a file search store.
+// The import completes asynchronously on the server.
+func (c *Client) ImportFile(ctx context.Context, storeName, fileName string) error {
+	if c.initErr != nil {
+		return fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	if _, err := c.client.FileSearchStores.ImportFile(ctx, storeName, fileName, nil); err != nil {
+		return fmt.Errorf("failed to import file: %w", err)
+	}
+	return nil
+}
+
+// DeleteFileSearchStore deletes a Gemini file search store and its documents
+func (c *Client) DeleteFileSearchStore(ctx context.Context, name string) error {
+	if c.initErr != nil {
+		return fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	force := true
+	if err := c.client.FileSearchStores.Delete(ctx, name, &genai.DeleteFileSearchStoreConfig{Force: &force}); err != nil {
+		return fmt.Errorf("failed to delete file search store: %w", err)
+	}
+	return nil
+}
+
+// ListModels lists the models available to the API key
+func (c *Client) ListModels(ctx context.Context) ([]*genai.Model, error) {
+	if c.initErr != nil {
+		return nil, fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+
+	var models []*genai.Model
+	for model, err := range c.client.Models.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list models: %w", err)
+		}
+		models = append(models, model)
+	}
+	return models, nil
 }
 
 // Close closes the client
@@ -192,53 +395,60 @@ func (c *Client) Close() error {
 
 // Stream represents a streaming response
 type Stream struct {
-	responses []*genai.GenerateContentResponse
-	errors    []error
-	model     string
-	index     int
+	ctx     context.Context
+	next    func() (*genai.GenerateContentResponse, error, bool)
+	stop    func()
+	pending *genai.GenerateContentResponse // Read ahead when the stream was opened
+	model   string
 }
 
 // Recv receives the next chunk from the stream
 func (s *Stream) Recv() (*Chunk, error) {
-	if s.index >= len(s.responses) {
-		return nil, io.EOF
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	if s.index < len(s.errors) && s.errors[s.index] != nil {
-		err := s.errors[s.index]
-		s.index++
-		return nil, fmt.Errorf("failed to receive stream chunk: %w", err)
+	response := s.pending
+	s.pending = nil
+	if response == nil {
+		var err error
+		var ok bool
+		response, err, ok = s.next()
+		if !ok {
+			return nil, io.EOF
+		}
+		if err != nil {
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, fmt.Errorf("failed to receive stream chunk: %w", apiError(err))
+		}
+	}
+	if err := blockedError(response); err != nil {
+		return nil, err
 	}
-
-	response := s.responses[s.index]
-	s.index++
 
 	chunk := &Chunk{
-		ID:      generateID(),
-		Object:  "chat.completion.chunk",
-		Created: currentTimestamp(),
-		Model:   s.model,
-	}
-
-	if len(response.Candidates) > 0 {
-		candidate := response.Candidates[0]
-		content := ""
-
-		// Extract text content from the candidate
-		if candidate.Content != nil && candidate.Content.Parts != nil {
-			for _, part := range candidate.Content.Parts {
-				if part.Text != "" {
-					content += part.Text
-				}
-			}
-		}
+		ID:           generateID(),
+		Object:       "chat.completion.chunk",
+		Created:      currentTimestamp(),
+		Model:        s.model,
+		ModelVersion: response.ModelVersion,
+	}
+
+	// A chunk may carry any subset of candidates, so each keeps its own index
+	finished := false
+	for _, candidate := range response.Candidates {
+		content, calls := candidateContent(candidate)
 
 		choice := Choice{
-			Index: 0,
+			Index: int(candidate.Index),
 			Delta: &Message{
-				Role:    "assistant",
-				Content: content,
+				Role:          "assistant",
+				Content:       content,
+				FunctionCalls: calls,
 			},
+			Grounding: convertGrounding(candidate.GroundingMetadata),
 		}
 
 		if candidate.FinishReason != "" {
@@ -246,20 +456,170 @@ func (s *Stream) Recv() (*Chunk, error) {
 			choice.FinishReason = &reason
 		}
 
-		chunk.Choices = []Choice{choice}
+		chunk.Choices = append(chunk.Choices, choice)
+		finished = finished || choice.FinishReason != nil
+	}
+
+	// Every response carries the usage so far; report it once, with the finish
+	if finished {
+		chunk.Usage = convertUsageMetadata(response.UsageMetadata)
 	}
 
 	return chunk, nil
 }
 
+// candidateContent returns the text and function calls in a candidate.
+// Gemini sends function calls whole, even when streaming.
+func candidateContent(candidate *genai.Candidate) (string, []FunctionCall) {
+	if candidate.Content == nil {
+		return "", nil
+	}
+	var content string
+	var calls []FunctionCall
+	for _, part := range candidate.Content.Parts {
+		if part.Text != "" {
+			content += part.Text
+		}
+		if fc := part.FunctionCall; fc != nil {
+			calls = append(calls, FunctionCall{ID: fc.ID, Name: fc.Name, Args: fc.Args})
+		}
+	}
+	return content, calls
+}
+
+// convertUsageMetadata converts Gemini usage metadata, or returns nil if there is none
+func convertUsageMetadata(md *genai.GenerateContentResponseUsageMetadata) *Usage {
+	if md == nil || md.TotalTokenCount == 0 {
+		return nil
+	}
+	completion := int(md.CandidatesTokenCount + md.ThoughtsTokenCount)
+	return &Usage{
+		PromptTokens:     int(md.PromptTokenCount),
+		CompletionTokens: completion,
+		TotalTokens:      int(md.TotalTokenCount),
+	}
+}
+
 // Close closes the stream
 func (s *Stream) Close() error {
-	// Gemini stream iterator doesn't have explicit close
+	s.stop()
 	return nil
 }
 
 // Helper functions
 
+// generateConfig builds the generation config for a request, or nil if no options need one
+func generateConfig(req *Request) *genai.GenerateContentConfig {
+	jsonOutput := req.ResponseFormat != nil &&
+		(req.ResponseFormat.Type == "json_object" || req.ResponseFormat.Type == "json_schema")
+	if req.FileSearch == nil && len(req.SafetySettings) == 0 && !jsonOutput && req.CandidateCount == nil && req.Seed == nil &&
+		req.SystemInstruction == "" && !req.GoogleSearch && len(req.Functions) == 0 && req.FunctionCalling == nil &&
+		len(req.Headers) == 0 {
+		return nil
+	}
+
+	config := &genai.GenerateContentConfig{}
+	if req.SystemInstruction != "" {
+		config.SystemInstruction = genai.NewContentFromText(req.SystemInstruction, genai.RoleUser)
+	}
+	if req.CandidateCount != nil {
+		config.CandidateCount = int32(*req.CandidateCount) //nolint:gosec // G115: candidate counts are small
+	}
+	if req.Seed != nil {
+		seed := int32(*req.Seed) //nolint:gosec // G115: Gemini seeds are int32
+		config.Seed = &seed
+	}
+	if jsonOutput {
+		config.ResponseMIMEType = "application/json"
+		config.ResponseJsonSchema = req.ResponseFormat.Schema
+	}
+	if req.FileSearch != nil {
+		fileSearch := &genai.FileSearch{FileSearchStoreNames: req.FileSearch.StoreNames}
+		if req.FileSearch.TopK != nil {
+			topK := int32(*req.FileSearch.TopK) //nolint:gosec // G115: top-k values are small
+			fileSearch.TopK = &topK
+		}
+		config.Tools = append(config.Tools, &genai.Tool{FileSearch: fileSearch})
+	}
+	if req.GoogleSearch {
+		config.Tools = append(config.Tools, &genai.Tool{GoogleSearch: &genai.GoogleSearch{}})
+	}
+	if len(req.Functions) > 0 {
+		declarations := make([]*genai.FunctionDeclaration, 0, len(req.Functions))
+		for _, fn := range req.Functions {
+			declarations = append(declarations, &genai.FunctionDeclaration{
+				Name:                 fn.Name,
+				Description:          fn.Description,
+				ParametersJsonSchema: fn.Parameters,
+			})
+		}
+		config.Tools = append(config.Tools, &genai.Tool{FunctionDeclarations: declarations})
+	}
+	if fc := req.FunctionCalling; fc != nil {
+		config.ToolConfig = &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{
+			Mode:                 genai.FunctionCallingConfigMode(fc.Mode),
+			AllowedFunctionNames: fc.AllowedFunctions,
+		}}
+	}
+	for _, setting := range req.SafetySettings {
+		config.SafetySettings = append(config.SafetySettings, &genai.SafetySetting{
+			Category:  genai.HarmCategory(setting.Category),
+			Threshold: genai.HarmBlockThreshold(setting.Threshold),
+		})
+	}
+	if len(req.Headers) > 0 {
+		// genai merges these over the client's own headers
+		headers := make(http.Header, len(req.Headers))
+		for name, value := range req.Headers {
+			headers.Set(name, value)
+		}
+		config.HTTPOptions = &genai.HTTPOptions{Headers: headers}
+	}
+	return config
+}
+
+// convertContents converts messages to Gemini conversation turns. Assistant
+// messages become model turns; user and tool messages become user turns, and
+// consecutive ones are merged so that the results of parallel function calls
+// go back together.
+func convertContents(messages []Message) []*genai.Content {
+	contents := make([]*genai.Content, 0, len(messages))
+	for _, msg := range messages {
+		role := genai.Role(genai.RoleUser)
+		if msg.Role == "assistant" {
+			role = genai.RoleModel
+		}
+
+		var parts []*genai.Part
+		if msg.Content != "" {
+			parts = append(parts, genai.NewPartFromText(msg.Content))
+		}
+		for _, doc := range msg.Documents {
+			if doc.FileURI != "" {
+				parts = append(parts, genai.NewPartFromURI(doc.FileURI, doc.MIMEType))
+			} else if len(doc.Data) > 0 {
+				parts = append(parts, genai.NewPartFromBytes(doc.Data, doc.MIMEType))
+			}
+		}
+		for _, call := range msg.FunctionCalls {
+			parts = append(parts, &genai.Part{FunctionCall: &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: call.Args}})
+		}
+		for _, resp := range msg.FunctionResponses {
+			parts = append(parts, &genai.Part{FunctionResponse: &genai.FunctionResponse{ID: resp.ID, Name: resp.Name, Response: resp.Response}})
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		if n := len(contents); n > 0 && contents[n-1].Role == string(role) {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			continue
+		}
+		contents = append(contents, genai.NewContentFromParts(parts, role))
+	}
+	return contents
+}
+
 func generateID() string {
 	return fmt.Sprintf("chatcmpl-%d", currentTimestamp())
 }
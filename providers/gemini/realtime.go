@@ -0,0 +1,251 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/genai"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// liveInputAudioMIMEType describes the PCM audio sent to a Live session
+const liveInputAudioMIMEType = "audio/pcm;rate=16000"
+
+// ConnectLive opens a Live API session
+func (c *Client) ConnectLive(ctx context.Context, model string, config *genai.LiveConnectConfig) (*genai.Session, error) {
+	if c.initErr != nil {
+		return nil, fmt.Errorf("client initialization failed: %w", c.initErr)
+	}
+	if model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+	session, err := c.client.Live.Connect(ctx, model, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect live session: %w", apiError(err))
+	}
+	return session, nil
+}
+
+// ConnectRealtime opens a Live API session configured for the unified config
+func (p *Provider) ConnectRealtime(ctx context.Context, config *provider.RealtimeConfig) (provider.RealtimeSession, error) {
+	session, err := p.client.ConnectLive(ctx, config.Model, convertLiveConfig(config))
+	if err != nil {
+		return nil, err
+	}
+
+	live := &LiveSession{
+		session:   session,
+		events:    make(chan provider.RealtimeEvent, 64),
+		done:      make(chan struct{}),
+		toolNames: make(map[string]string),
+	}
+	go live.readLoop()
+	return live, nil
+}
+
+// convertLiveConfig converts a unified realtime config to a Live API config
+func convertLiveConfig(config *provider.RealtimeConfig) *genai.LiveConnectConfig {
+	live := &genai.LiveConnectConfig{ResponseModalities: []genai.Modality{genai.ModalityAudio}}
+	if len(config.Modalities) > 0 {
+		live.ResponseModalities = nil
+		for _, m := range config.Modalities {
+			live.ResponseModalities = append(live.ResponseModalities, genai.Modality(strings.ToUpper(m)))
+		}
+	}
+	if config.Instructions != "" {
+		live.SystemInstruction = &genai.Content{Parts: []*genai.Part{{Text: config.Instructions}}}
+	}
+	if config.Voice != "" {
+		live.SpeechConfig = &genai.SpeechConfig{VoiceConfig: &genai.VoiceConfig{
+			PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: config.Voice},
+		}}
+	}
+	if config.InputTranscription {
+		live.InputAudioTranscription = &genai.AudioTranscriptionConfig{}
+	}
+	// Transcripts of spoken output are always reported, as for OpenAI
+	live.OutputAudioTranscription = &genai.AudioTranscriptionConfig{}
+
+	if len(config.Tools) > 0 {
+		tool := &genai.Tool{}
+		for _, t := range config.Tools {
+			tool.FunctionDeclarations = append(tool.FunctionDeclarations, &genai.FunctionDeclaration{
+				Name:                 t.Function.Name,
+				Description:          t.Function.Description,
+				ParametersJsonSchema: t.Function.Parameters,
+			})
+		}
+		live.Tools = []*genai.Tool{tool}
+	}
+	return live
+}
+
+// LiveSession is a Gemini Live API session
+type LiveSession struct {
+	session   *genai.Session
+	writeMu   sync.Mutex
+	events    chan provider.RealtimeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// toolNames maps pending tool call IDs to function names, which Gemini
+	// requires in function responses
+	toolMu    sync.Mutex
+	toolNames map[string]string
+
+	usage *provider.Usage // Latest usage, reported with the next turn_done
+}
+
+// SendAudio streams 16kHz PCM audio. Automatic activity detection ends the
+// user's turn and starts a response.
+func (s *LiveSession) SendAudio(data []byte) error {
+	return s.send(func() error {
+		return s.session.SendRealtimeInput(genai.LiveRealtimeInput{Audio: &genai.Blob{Data: data, MIMEType: liveInputAudioMIMEType}})
+	})
+}
+
+// SendText sends a user message, and the model responds
+func (s *LiveSession) SendText(text string) error {
+	return s.send(func() error {
+		return s.session.SendRealtimeInput(genai.LiveRealtimeInput{Text: text})
+	})
+}
+
+// SendToolResult answers a function call. Output that is a JSON object is
+// sent as the response; anything else is sent as {"output": output}.
+func (s *LiveSession) SendToolResult(callID, output string) error {
+	s.toolMu.Lock()
+	name := s.toolNames[callID]
+	delete(s.toolNames, callID)
+	s.toolMu.Unlock()
+
+	var response map[string]any
+	if err := json.Unmarshal([]byte(output), &response); err != nil || response == nil {
+		response = map[string]any{"output": output}
+	}
+	return s.send(func() error {
+		return s.session.SendToolResponse(genai.LiveToolResponseInput{
+			FunctionResponses: []*genai.FunctionResponse{{ID: callID, Name: name, Response: response}},
+		})
+	})
+}
+
+// Events returns the channel of events from the model
+func (s *LiveSession) Events() <-chan provider.RealtimeEvent {
+	return s.events
+}
+
+// Close ends the session
+func (s *LiveSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.session.Close()
+	})
+	return err
+}
+
+// send serializes writes to the session
+func (s *LiveSession) send(write func() error) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := write(); err != nil {
+		return fmt.Errorf("live send failed: %w", err)
+	}
+	return nil
+}
+
+// readLoop delivers server messages until the session ends
+func (s *LiveSession) readLoop() {
+	defer close(s.events)
+	for {
+		msg, err := s.session.Receive()
+		if err != nil {
+			select {
+			case <-s.done:
+			default:
+				s.emit(provider.RealtimeEvent{Type: provider.RealtimeEventError, Err: fmt.Errorf("live receive failed: %w", apiError(err))})
+			}
+			return
+		}
+		for _, event := range s.convertLiveMessage(msg) {
+			if !s.emit(event) {
+				return
+			}
+		}
+	}
+}
+
+// emit delivers an event, returning false if the session was closed first
+func (s *LiveSession) emit(event provider.RealtimeEvent) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// convertLiveMessage converts a server message to unified events. One
+// message may carry several, e.g. audio and its transcript.
+func (s *LiveSession) convertLiveMessage(msg *genai.LiveServerMessage) []provider.RealtimeEvent {
+	var events []provider.RealtimeEvent
+
+	if md := msg.UsageMetadata; md != nil {
+		s.usage = &provider.Usage{
+			PromptTokens:     int(md.PromptTokenCount),
+			CompletionTokens: int(md.ResponseTokenCount),
+			TotalTokens:      int(md.TotalTokenCount),
+		}
+	}
+
+	if content := msg.ServerContent; content != nil {
+		if content.Interrupted {
+			events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventInterrupted})
+		}
+		if t := content.InputTranscription; t != nil && t.Text != "" {
+			events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventInputTranscript, Text: t.Text})
+		}
+		if turn := content.ModelTurn; turn != nil {
+			for _, part := range turn.Parts {
+				switch {
+				case part.Thought:
+				case part.Text != "":
+					events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventText, Text: part.Text})
+				case part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "audio/"):
+					events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventAudio, Audio: part.InlineData.Data})
+				}
+			}
+		}
+		if t := content.OutputTranscription; t != nil && t.Text != "" {
+			events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventTranscript, Text: t.Text})
+		}
+		if content.TurnComplete {
+			events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventTurnDone, Usage: s.usage})
+			s.usage = nil
+		}
+	}
+
+	if msg.ToolCall != nil {
+		for _, call := range msg.ToolCall.FunctionCalls {
+			args, err := json.Marshal(call.Args)
+			if err != nil {
+				args = []byte("{}")
+			}
+			s.toolMu.Lock()
+			s.toolNames[call.ID] = call.Name
+			s.toolMu.Unlock()
+			events = append(events, provider.RealtimeEvent{Type: provider.RealtimeEventToolCall, ToolCall: &provider.ToolCall{
+				ID:       call.ID,
+				Type:     "function",
+				Function: provider.ToolFunction{Name: call.Name, Arguments: string(args)},
+			}})
+		}
+	}
+
+	return events
+}
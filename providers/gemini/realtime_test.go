@@ -0,0 +1,70 @@
+package gemini
+
+import (
+	"testing"
+
+	"google.golang.org/genai"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestConvertLiveConfig(t *testing.T) {
+	config := convertLiveConfig(&provider.RealtimeConfig{
+		Model:        "gemini-live-2.5-flash",
+		Instructions: "Be brief.",
+		Modalities:   []string{"text"},
+		Voice:        "Puck",
+		Tools:        []provider.Tool{{Type: "function", Function: provider.ToolSpec{Name: "lookup", Parameters: map[string]any{"type": "object"}}}},
+	})
+	if len(config.ResponseModalities) != 1 || config.ResponseModalities[0] != genai.ModalityText {
+		t.Errorf("ResponseModalities = %v", config.ResponseModalities)
+	}
+	if config.SystemInstruction.Parts[0].Text != "Be brief." || config.SpeechConfig.VoiceConfig.PrebuiltVoiceConfig.VoiceName != "Puck" {
+		t.Errorf("config = %+v", config)
+	}
+	if config.InputAudioTranscription != nil || config.OutputAudioTranscription == nil {
+		t.Errorf("transcription config = %+v, %+v", config.InputAudioTranscription, config.OutputAudioTranscription)
+	}
+	if len(config.Tools) != 1 || config.Tools[0].FunctionDeclarations[0].Name != "lookup" {
+		t.Errorf("Tools = %+v", config.Tools)
+	}
+}
+
+func TestLiveSession_ConvertLiveMessage(t *testing.T) {
+	s := &LiveSession{toolNames: make(map[string]string)}
+
+	events := s.convertLiveMessage(&genai.LiveServerMessage{
+		ServerContent: &genai.LiveServerContent{
+			InputTranscription: &genai.Transcription{Text: "what time is it"},
+			ModelTurn: &genai.Content{Parts: []*genai.Part{
+				{Text: "planning", Thought: true},
+				{InlineData: &genai.Blob{MIMEType: "audio/pcm;rate=24000", Data: []byte{1, 2}}},
+			}},
+			OutputTranscription: &genai.Transcription{Text: "It is noon"},
+		},
+	})
+	want := []provider.RealtimeEventType{provider.RealtimeEventInputTranscript, provider.RealtimeEventAudio, provider.RealtimeEventTranscript}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Errorf("event %d type = %q, want %q", i, events[i].Type, typ)
+		}
+	}
+
+	events = s.convertLiveMessage(&genai.LiveServerMessage{
+		ToolCall: &genai.LiveServerToolCall{FunctionCalls: []*genai.FunctionCall{{ID: "call_1", Name: "lookup", Args: map[string]any{"q": "x"}}}},
+	})
+	if len(events) != 1 || events[0].ToolCall.Function.Arguments != `{"q":"x"}` || s.toolNames["call_1"] != "lookup" {
+		t.Errorf("tool call events = %+v", events)
+	}
+
+	events = s.convertLiveMessage(&genai.LiveServerMessage{
+		UsageMetadata: &genai.UsageMetadata{PromptTokenCount: 5, ResponseTokenCount: 4, TotalTokenCount: 9},
+		ServerContent: &genai.LiveServerContent{TurnComplete: true},
+	})
+	if len(events) != 1 || events[0].Type != provider.RealtimeEventTurnDone || events[0].Usage.TotalTokens != 9 {
+		t.Errorf("turn done events = %+v", events)
+	}
+}
@@ -0,0 +1,212 @@
+package gemini
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func weatherTool() provider.Tool {
+	return provider.Tool{
+		Type: provider.ToolTypeFunction,
+		Function: provider.ToolSpec{
+			Name:        "get_weather",
+			Description: "Get the weather for a city",
+			Parameters:  map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		},
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	tests := []struct {
+		choice any
+		mode   string
+		names  []string
+	}{
+		{"auto", "AUTO", nil},
+		{"none", "NONE", nil},
+		{"required", "ANY", nil},
+		{map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}, "ANY", []string{"get_weather"}},
+	}
+	for _, tt := range tests {
+		got, err := convertToolChoice(tt.choice)
+		if err != nil {
+			t.Errorf("%v: %v", tt.choice, err)
+			continue
+		}
+		if got.Mode != tt.mode || len(got.AllowedFunctions) != len(tt.names) || (len(tt.names) > 0 && got.AllowedFunctions[0] != tt.names[0]) {
+			t.Errorf("%v: got %+v", tt.choice, got)
+		}
+	}
+	if got, err := convertToolChoice(nil); got != nil || err != nil {
+		t.Errorf("nil: got %+v, %v", got, err)
+	}
+	if _, err := convertToolChoice("sometimes"); err == nil {
+		t.Error("expected an error for an unknown tool choice")
+	}
+}
+
+func TestProvider_FunctionCalling(t *testing.T) {
+	var sent struct {
+		Contents []struct {
+			Role  string           `json:"role"`
+			Parts []map[string]any `json:"parts"`
+		} `json:"contents"`
+		Tools []struct {
+			FunctionDeclarations []map[string]any `json:"functionDeclarations"`
+		} `json:"tools"`
+		ToolConfig struct {
+			FunctionCallingConfig struct {
+				Mode                 string   `json:"mode"`
+				AllowedFunctionNames []string `json:"allowedFunctionNames"`
+			} `json:"functionCallingConfig"`
+		} `json:"toolConfig"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Rome"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":1,"totalTokenCount":5}}`)
+	}))
+	defer server.Close()
+
+	callID := "gemini_call_0"
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Weather in Paris, then Rome?"},
+			{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{
+				{ID: callID, Type: "function", Function: provider.ToolFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+			}},
+			{Role: provider.RoleTool, ToolCallID: &callID, Content: "21C"},
+		},
+		Tools:      []provider.Tool{weatherTool()},
+		ToolChoice: map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The conversation is sent as turns, with the call and its result
+	if len(sent.Contents) != 3 || sent.Contents[0].Role != "user" || sent.Contents[1].Role != "model" || sent.Contents[2].Role != "user" {
+		t.Fatalf("contents = %+v", sent.Contents)
+	}
+	call, _ := sent.Contents[1].Parts[0]["functionCall"].(map[string]any)
+	if call["name"] != "get_weather" || call["id"] != nil {
+		t.Errorf("functionCall = %+v, want the call without its generated ID", call)
+	}
+	result, _ := sent.Contents[2].Parts[0]["functionResponse"].(map[string]any)
+	if result["name"] != "get_weather" || result["response"].(map[string]any)["output"] != "21C" {
+		t.Errorf("functionResponse = %+v", result)
+	}
+	if len(sent.Tools) != 1 || len(sent.Tools[0].FunctionDeclarations) != 1 || sent.Tools[0].FunctionDeclarations[0]["name"] != "get_weather" {
+		t.Errorf("tools = %+v", sent.Tools)
+	}
+	if fc := sent.ToolConfig.FunctionCallingConfig; fc.Mode != "ANY" || len(fc.AllowedFunctionNames) != 1 {
+		t.Errorf("functionCallingConfig = %+v", fc)
+	}
+
+	choice := resp.Choices[0]
+	if len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("ToolCalls = %+v", choice.Message.ToolCalls)
+	}
+	tc := choice.Message.ToolCalls[0]
+	if tc.ID == "" || tc.Function.Name != "get_weather" || tc.Function.Arguments != `{"city":"Rome"}` {
+		t.Errorf("ToolCall = %+v", tc)
+	}
+	if *choice.FinishReason != provider.FinishReasonToolCalls {
+		t.Errorf("FinishReason = %q, want tool_calls", *choice.FinishReason)
+	}
+}
+
+func TestProvider_FunctionCallingStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"fc_1","name":"get_weather","args":{"city":"Paris"}}}]}}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"id":"fc_2","name":"get_weather","args":{"city":"Rome"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"totalTokenCount":6}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Weather in Paris and Rome?"}},
+		Tools:    []provider.Tool{weatherTool()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var calls []provider.ToolCall
+	var finish string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+			if choice.Delta != nil {
+				calls = append(calls, choice.Delta.ToolCalls...)
+			}
+		}
+	}
+
+	if len(calls) != 2 || calls[0].ID != "fc_1" || calls[1].ID != "fc_2" || calls[1].Function.Arguments != `{"city":"Rome"}` {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if *calls[0].Index != 0 || *calls[1].Index != 1 {
+		t.Errorf("indexes = %d, %d, want 0, 1", *calls[0].Index, *calls[1].Index)
+	}
+	if finish != provider.FinishReasonToolCalls {
+		t.Errorf("finish = %q, want tool_calls", finish)
+	}
+}
+
+func TestProvider_Seed(t *testing.T) {
+	var sent struct {
+		GenerationConfig struct {
+			Seed *int `json:"seed"`
+		} `json:"generationConfig"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":"4"}]},"finishReason":"STOP"}],"modelVersion":"gemini-2.5-flash-001"}`)
+	}))
+	defer server.Close()
+
+	seed := 42
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "2+2?"}},
+		Seed:     &seed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent.GenerationConfig.Seed == nil || *sent.GenerationConfig.Seed != 42 {
+		t.Errorf("sent seed = %v, want 42", sent.GenerationConfig.Seed)
+	}
+	d, ok := provider.GetDeterminism(resp.ProviderMetadata)
+	if !ok || d.Seed == nil || *d.Seed != 42 || d.Fingerprint != "gemini-2.5-flash-001" {
+		t.Errorf("Determinism = %+v, %v", d, ok)
+	}
+}
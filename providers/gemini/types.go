@@ -2,41 +2,125 @@ package gemini
 
 // Request represents a Gemini chat completion request
 type Request struct {
-	Model            string          `json:"model"`
-	Messages         []Message       `json:"messages"`
-	MaxTokens        *int            `json:"max_tokens,omitempty"`
-	Temperature      *float64        `json:"temperature,omitempty"`
-	TopP             *float64        `json:"top_p,omitempty"`
-	TopK             *int            `json:"top_k,omitempty"`
-	Stream           *bool           `json:"stream,omitempty"`
-	Stop             []string        `json:"stop,omitempty"`
-	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
-	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
-	LogitBias        map[string]int  `json:"logit_bias,omitempty"`
-	User             *string         `json:"user,omitempty"`
-	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+	Model             string           `json:"model"`
+	Messages          []Message        `json:"messages"`
+	MaxTokens         *int             `json:"max_tokens,omitempty"`
+	Temperature       *float64         `json:"temperature,omitempty"`
+	TopP              *float64         `json:"top_p,omitempty"`
+	TopK              *int             `json:"top_k,omitempty"`
+	CandidateCount    *int             `json:"candidate_count,omitempty"`
+	Seed              *int             `json:"seed,omitempty"`
+	Stream            *bool            `json:"stream,omitempty"`
+	Stop              []string         `json:"stop,omitempty"`
+	PresencePenalty   *float64         `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  *float64         `json:"frequency_penalty,omitempty"`
+	LogitBias         map[string]int   `json:"logit_bias,omitempty"`
+	User              *string          `json:"user,omitempty"`
+	ResponseFormat    *ResponseFormat  `json:"response_format,omitempty"`
+	FileSearch        *FileSearch      `json:"file_search,omitempty"`
+	SafetySettings    []SafetySetting  `json:"safety_settings,omitempty"`
+	SystemInstruction string           `json:"system_instruction,omitempty"`
+	GoogleSearch      bool             `json:"google_search,omitempty"`
+	Functions         []Function       `json:"functions,omitempty"`
+	FunctionCalling   *FunctionCalling `json:"function_calling,omitempty"`
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
+}
+
+// Options are the Gemini-specific request options, passed in
+// ChatCompletionRequest.ProviderOptions under the "gemini" key
+type Options struct {
+	// SafetySettings override the default blocking thresholds per harm category
+	SafetySettings []SafetySetting `json:"safety_settings,omitempty"`
+
+	// SystemInstruction replaces the request's system messages, which are
+	// otherwise sent as the system instruction
+	SystemInstruction string `json:"system_instruction,omitempty"`
+
+	// GoogleSearch grounds responses in Google Search results. The sources
+	// are returned as citation annotations on each choice.
+	GoogleSearch bool `json:"google_search,omitempty"`
+}
+
+// SafetySetting sets the blocking threshold for one harm category, using the
+// Gemini API names, e.g. "HARM_CATEGORY_HARASSMENT" and "BLOCK_ONLY_HIGH"
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// FileSearch enables retrieval over Gemini file search stores
+type FileSearch struct {
+	StoreNames []string `json:"store_names"`
+	TopK       *int     `json:"top_k,omitempty"`
+}
+
+// Function declares a function the model may call
+type Function struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"` // JSON Schema for the arguments object
+}
+
+// FunctionCalling controls whether and which functions the model calls
+type FunctionCalling struct {
+	Mode             string   `json:"mode"`                        // "AUTO", "ANY", or "NONE"
+	AllowedFunctions []string `json:"allowed_functions,omitempty"` // Restricts "ANY" to these functions
+}
+
+// FunctionCall is a call the model made to a declared function. ID is empty
+// when the API does not assign one.
+type FunctionCall struct {
+	ID   string         `json:"id,omitempty"`
+	Name string         `json:"name"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// FunctionResponse returns the result of a function call to the model
+type FunctionResponse struct {
+	ID       string         `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Response map[string]any `json:"response"`
 }
 
 // ResponseFormat specifies the format of the response
 type ResponseFormat struct {
-	Type string `json:"type"` // "text" or "json_object"
+	Type   string `json:"type"`             // "text", "json_object", or "json_schema"
+	Schema any    `json:"schema,omitempty"` // JSON Schema for "json_schema"
 }
 
 // Message represents a chat message
 type Message struct {
-	Role    string  `json:"role"`
-	Content string  `json:"content"`
-	Name    *string `json:"name,omitempty"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	Name      *string    `json:"name,omitempty"`
+	Documents []Document `json:"documents,omitempty"`
+
+	// FunctionCalls are the calls made in an assistant message
+	FunctionCalls []FunctionCall `json:"function_calls,omitempty"`
+
+	// FunctionResponses are the results sent back in a tool message
+	FunctionResponses []FunctionResponse `json:"function_responses,omitempty"`
+}
+
+// Document represents a document attached to a message, either inline or as a file URI
+type Document struct {
+	MIMEType string `json:"mime_type"`
+	Data     []byte `json:"data,omitempty"`
+	FileURI  string `json:"file_uri,omitempty"`
 }
 
 // Response represents a Gemini chat completion response
 type Response struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
+	ID           string   `json:"id"`
+	Object       string   `json:"object"`
+	Created      int64    `json:"created"`
+	Model        string   `json:"model"`
+	ModelVersion string   `json:"model_version,omitempty"` // The model version that served the request
+	Choices      []Choice `json:"choices"`
+	Usage        Usage    `json:"usage"`
+	RequestID    string   `json:"-"` // From the x-request-id or request-id response header
 }
 
 // Choice represents a choice in the response
@@ -45,6 +129,21 @@ type Choice struct {
 	Message      Message  `json:"message"`
 	Delta        *Message `json:"delta,omitempty"`
 	FinishReason *string  `json:"finish_reason"`
+
+	// Grounding holds the search queries and sources used, when grounding is enabled
+	Grounding *Grounding `json:"grounding,omitempty"`
+}
+
+// Grounding describes the search results a response was grounded in
+type Grounding struct {
+	SearchQueries []string `json:"search_queries,omitempty"`
+	Sources       []Source `json:"sources,omitempty"`
+}
+
+// Source is a web page a grounded response drew on
+type Source struct {
+	URI   string `json:"uri"`
+	Title string `json:"title,omitempty"`
 }
 
 // Usage represents token usage information
@@ -56,10 +155,11 @@ type Usage struct {
 
 // Chunk represents a chunk in streaming response
 type Chunk struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   *Usage   `json:"usage,omitempty"`
+	ID           string   `json:"id"`
+	Object       string   `json:"object"`
+	Created      int64    `json:"created"`
+	Model        string   `json:"model"`
+	ModelVersion string   `json:"model_version,omitempty"`
+	Choices      []Choice `json:"choices"`
+	Usage        *Usage   `json:"usage,omitempty"`
 }
@@ -0,0 +1,184 @@
+// Package huggingface provides Hugging Face provider adapter for the OmniLLM unified interface
+package huggingface
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// Provider represents the Hugging Face provider adapter
+type Provider struct {
+	client *Client
+}
+
+// NewProvider creates a new Hugging Face provider adapter
+func NewProvider(apiKey, baseURL string, httpClient *http.Client) provider.Provider {
+	client := New(apiKey, baseURL, httpClient)
+	return &Provider{client: client}
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return p.client.Name()
+}
+
+// Capabilities returns the request features the Hugging Face adapter supports.
+// Content parts are not yet mapped.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Streaming: true, Tools: true, JSONSchema: true}
+}
+
+// SetLoadingTimeout sets how long requests wait for a model that is still loading
+func (p *Provider) SetLoadingTimeout(timeout time.Duration) {
+	p.client.SetLoadingTimeout(timeout)
+}
+
+// CreateChatCompletion creates a chat completion
+func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	resp, err := p.client.CreateCompletion(ctx, convertRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert back to unified format
+	choices := make([]provider.ChatCompletionChoice, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		choices = append(choices, provider.ChatCompletionChoice{
+			Index: choice.Index,
+			Message: provider.Message{
+				Role:      provider.Role(choice.Message.Role),
+				Content:   choice.Message.Content,
+				ToolCalls: choice.Message.ToolCalls,
+			},
+			FinishReason: choice.FinishReason,
+		})
+	}
+
+	result := &provider.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  resp.Object,
+		Created: resp.Created,
+		Model:   resp.Model,
+		Choices: choices,
+		Usage:   convertUsage(resp.Usage),
+	}
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	return result, nil
+}
+
+// CreateChatCompletionStream creates a streaming chat completion
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	stream, err := p.client.CreateCompletionStream(ctx, convertRequest(req))
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamAdapter{stream: stream}, nil
+}
+
+// convertRequest converts from unified format to Hugging Face format (OpenAI-compatible)
+func convertRequest(req *provider.ChatCompletionRequest) *Request {
+	hfReq := &Request{
+		Model:            req.Model,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		Stop:             req.Stop,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Seed:             req.Seed,
+		Tools:            req.Tools,
+		ToolChoice:       req.ToolChoice,
+		Headers:          req.Headers,
+	}
+
+	// Convert messages
+	for _, msg := range req.Messages {
+		hfReq.Messages = append(hfReq.Messages, Message{
+			Role:       string(msg.Role),
+			Content:    msg.Content,
+			Name:       msg.Name,
+			ToolCalls:  msg.ToolCalls,
+			ToolCallID: msg.ToolCallID,
+		})
+	}
+
+	if req.ResponseFormat != nil {
+		hfReq.ResponseFormat = &ResponseFormat{Type: req.ResponseFormat.Type}
+		if js := req.ResponseFormat.JSONSchema; js != nil {
+			hfReq.ResponseFormat.JSONSchema = &JSONSchema{
+				Name:        js.Name,
+				Description: js.Description,
+				Schema:      js.Schema,
+				Strict:      js.Strict,
+			}
+		}
+	}
+
+	return hfReq
+}
+
+// Close closes the provider
+func (p *Provider) Close() error {
+	return p.client.Close()
+}
+
+// StreamAdapter adapts Hugging Face stream to unified interface
+type StreamAdapter struct {
+	stream *Stream
+}
+
+// Recv receives the next chunk from the stream
+func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to unified format
+	result := &provider.ChatCompletionChunk{
+		ID:      chunk.ID,
+		Object:  chunk.Object,
+		Created: chunk.Created,
+		Model:   chunk.Model,
+	}
+
+	if chunk.Usage != nil {
+		usage := convertUsage(*chunk.Usage)
+		result.Usage = &usage
+	}
+
+	for _, choice := range chunk.Choices {
+		result.Choices = append(result.Choices, provider.ChatCompletionChoice{
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+		})
+		if choice.Delta != nil {
+			result.Choices[len(result.Choices)-1].Delta = &provider.Message{
+				Role:      provider.Role(choice.Delta.Role),
+				Content:   choice.Delta.Content,
+				ToolCalls: choice.Delta.ToolCalls,
+			}
+		}
+	}
+
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	return result, nil
+}
+
+// Close closes the stream
+func (s *StreamAdapter) Close() error {
+	return s.stream.Close()
+}
+
+// convertUsage converts token usage to unified format
+func convertUsage(usage Usage) provider.Usage {
+	return provider.Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
@@ -0,0 +1,152 @@
+package huggingface
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestProvider_CreateChatCompletion(t *testing.T) {
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer hf_test" {
+			t.Errorf("request = %s %s", r.Method, r.URL)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","model":"meta-llama/Llama-3.3-70B-Instruct","choices":[{"index":0,"message":{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":\"Paris\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	callID := "call_0"
+	p := NewProvider("hf_test", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model: "meta-llama/Llama-3.3-70B-Instruct",
+		Messages: []provider.Message{
+			{Role: provider.RoleUser, Content: "Weather in Paris?"},
+			{Role: provider.RoleTool, Content: "sunny", ToolCallID: &callID},
+		},
+		Tools: []provider.Tool{{Type: "function", Function: provider.ToolSpec{Name: "weather"}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	if *got.Stream || len(got.Tools) != 1 || got.Messages[1].ToolCallID == nil || *got.Messages[1].ToolCallID != "call_0" {
+		t.Errorf("request = %+v", got)
+	}
+	msg := resp.Choices[0].Message
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Name != "weather" || resp.Usage.TotalTokens != 20 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestProvider_WaitsForModelLoading(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"Model meta-llama/Llama-3.1-8B-Instruct is currently loading","estimated_time":0.5}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Ready."}}]}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("hf_test", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "meta-llama/Llama-3.1-8B-Instruct",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if attempts != 2 || resp.Choices[0].Message.Content != "Ready." {
+		t.Errorf("attempts = %d, response = %+v", attempts, resp)
+	}
+}
+
+func TestProvider_LoadingTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"Model is currently loading","estimated_time":30}`))
+	}))
+	defer server.Close()
+
+	p := NewProvider("hf_test", server.URL, server.Client())
+	p.(*Provider).SetLoadingTimeout(0)
+	_, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Provider != "huggingface" {
+		t.Fatalf("error = %v, want 503 APIError", err)
+	}
+	if apiErr.Message != "Hugging Face API error: Model is currently loading" {
+		t.Errorf("message = %q", apiErr.Message)
+	}
+}
+
+func TestProvider_CreateChatCompletionStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !*req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("stream request = %+v", req)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"id":"1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+			`{"id":"1","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+			`{"id":"1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data:%s\n\n", data)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("hf_test", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content string
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil {
+				content += choice.Delta.Content
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if content != "Hello" || usage == nil || usage.TotalTokens != 5 {
+		t.Errorf("content = %q, usage = %+v", content, usage)
+	}
+}
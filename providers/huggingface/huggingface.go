@@ -0,0 +1,279 @@
+// Package huggingface provides Hugging Face Inference API client implementation
+package huggingface
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// DefaultBaseURL is the OpenAI-compatible route of the Inference Providers
+// router. Inference Endpoints serve the same route at
+// https://<endpoint>.endpoints.huggingface.cloud/v1.
+const DefaultBaseURL = "https://router.huggingface.co/v1"
+
+// DefaultLoadingTimeout bounds how long a request waits for a cold model to
+// load before the 503 is returned to the caller
+const DefaultLoadingTimeout = 2 * time.Minute
+
+// minLoadingWait is the shortest wait between loading retries
+const minLoadingWait = time.Second
+
+// DefaultTimeout is the HTTP client timeout used when no client is given,
+// longer than most to allow for cold models
+const DefaultTimeout = 120 * time.Second
+
+// Client implements Hugging Face Inference API client
+type Client struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+
+	// loadingTimeout bounds the total time spent waiting for a model to load;
+	// 0 disables waiting
+	loadingTimeout time.Duration
+}
+
+// New creates a new Hugging Face client
+func New(apiKey, baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	return &Client{
+		apiKey:         apiKey,
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		client:         httpClient,
+		loadingTimeout: DefaultLoadingTimeout,
+	}
+}
+
+// Name returns the provider name
+func (c *Client) Name() string {
+	return "huggingface"
+}
+
+// SetLoadingTimeout sets how long requests wait for a model that is still
+// loading. 0 returns the 503 immediately.
+func (c *Client) SetLoadingTimeout(timeout time.Duration) {
+	c.loadingTimeout = timeout
+}
+
+// CreateCompletion creates a chat completion
+func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages cannot be empty")
+	}
+
+	req.Stream = boolPtr(false)
+	req.StreamOptions = nil
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// CreateCompletionStream creates a streaming chat completion
+func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Stream, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("messages cannot be empty")
+	}
+
+	req.Stream = boolPtr(true)
+	if req.StreamOptions == nil {
+		// Report usage in the final chunk, which streams otherwise omit
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+
+	resp, err := c.doRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = provider.NewContextBody(ctx, resp.Body)
+	return &Stream{
+		ctx:      ctx,
+		response: resp,
+		scanner:  bufio.NewScanner(resp.Body),
+	}, nil
+}
+
+// doRequest posts a chat completion request, waiting for the model to load
+// when the API reports it is still loading. On success the caller owns the
+// response body.
+func (c *Client) doRequest(ctx context.Context, req *Request) (*http.Response, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	deadline := time.Now().Add(c.loadingTimeout)
+	for {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+		provider.SetHeaders(httpReq, req.Headers)
+
+		resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, provider.NewAPIErrorFromResponse("huggingface", resp, "failed to read error response", "", "")
+		}
+
+		wait, loading := loadingWait(resp.StatusCode, body)
+		if !loading || time.Now().Add(wait).After(deadline) {
+			return nil, c.handleErrorResponse(resp, body)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// loadingWait reports whether an error response means the model is still
+// loading and, if so, how long to wait before retrying
+func loadingWait(status int, body []byte) (time.Duration, bool) {
+	if status != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	var errorResp ErrorResponse
+	if err := json.Unmarshal(body, &errorResp); err != nil {
+		return 0, false
+	}
+	if errorResp.EstimatedTime <= 0 && !strings.Contains(strings.ToLower(errorResp.message()), "loading") {
+		return 0, false
+	}
+	wait := time.Duration(errorResp.EstimatedTime * float64(time.Second))
+	if wait < minLoadingWait {
+		wait = minLoadingWait
+	}
+	return wait, true
+}
+
+// handleErrorResponse converts an error response body to an APIError
+func (c *Client) handleErrorResponse(resp *http.Response, body []byte) error {
+	var errorResp ErrorResponse
+	if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.message() == "" {
+		return provider.NewAPIErrorFromResponse("huggingface", resp, "Hugging Face API error: "+string(body), "", "").WithBody(body)
+	}
+
+	var errorType, code string
+	if detail, ok := errorResp.Error.(map[string]any); ok {
+		errorType, _ = detail["type"].(string)
+		code, _ = detail["code"].(string)
+	}
+	return provider.NewAPIErrorFromResponse("huggingface", resp, "Hugging Face API error: "+errorResp.message(), errorType, code).WithBody(body)
+}
+
+// Close closes the client
+func (c *Client) Close() error {
+	return nil
+}
+
+// Stream implements streaming for Hugging Face
+type Stream struct {
+	ctx      context.Context
+	response *http.Response
+	scanner  *bufio.Scanner
+	closed   bool
+}
+
+// Recv receives the next chunk from the stream
+func (s *Stream) Recv() (*StreamChunk, error) {
+	if s.closed {
+		return nil, fmt.Errorf("stream is closed")
+	}
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for s.scanner.Scan() {
+		line := s.scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return nil, io.EOF
+			}
+
+			var chunk StreamChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				continue
+			}
+			if chunk.Error != nil {
+				return nil, provider.NewStreamAPIError("huggingface", chunk.Error)
+			}
+
+			return &chunk, nil
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("stream error: %w", err)
+	}
+
+	return nil, io.EOF
+}
+
+// Close closes the stream
+func (s *Stream) Close() error {
+	if !s.closed {
+		s.closed = true
+		return s.response.Body.Close()
+	}
+	return nil
+}
+
+// Helper function to create a bool pointer
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,125 @@
+package huggingface
+
+import "github.com/plexusone/omnillm/provider"
+
+// Request represents a Hugging Face chat completion request (OpenAI-compatible format)
+type Request struct {
+	Model            string          `json:"model"`
+	Messages         []Message       `json:"messages"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"top_p,omitempty"`
+	Stream           *bool           `json:"stream,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	Tools            []provider.Tool `json:"tools,omitempty"`
+	ToolChoice       any             `json:"tool_choice,omitempty"`
+	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+	StreamOptions    *StreamOptions  `json:"stream_options,omitempty"` // Streaming only
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
+}
+
+// ResponseFormat specifies the format of the response (OpenAI-compatible)
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "text", "json_object", or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema configures structured outputs
+type JSONSchema struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema"`
+	Strict      *bool  `json:"strict,omitempty"`
+}
+
+// Message represents a message in Hugging Face format (OpenAI-compatible)
+type Message struct {
+	Role       string              `json:"role"`
+	Content    string              `json:"content"`
+	Name       *string             `json:"name,omitempty"`
+	ToolCalls  []provider.ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID *string             `json:"tool_call_id,omitempty"`
+}
+
+// StreamOptions configures a streaming response
+type StreamOptions struct {
+	// IncludeUsage adds a final chunk, with no choices, reporting the usage
+	// for the whole request
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Response represents a Hugging Face chat completion response (OpenAI-compatible)
+type Response struct {
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+}
+
+// Choice represents a completion choice in a Hugging Face response
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// Usage represents token usage in a Hugging Face response
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// StreamChunk represents a chunk in a Hugging Face streaming response (OpenAI-compatible)
+type StreamChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []StreamDelta `json:"choices"`
+	Usage   *Usage        `json:"usage,omitempty"`
+
+	// Error is set instead of the other fields when the stream fails mid-response
+	Error *provider.StreamError `json:"error,omitempty"`
+}
+
+// StreamDelta represents delta content in a streaming chunk
+type StreamDelta struct {
+	Index        int          `json:"index"`
+	Delta        *DeltaChange `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+// DeltaChange represents the actual content change in a stream
+type DeltaChange struct {
+	Role      string              `json:"role,omitempty"`
+	Content   string              `json:"content,omitempty"`
+	ToolCalls []provider.ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ErrorResponse is the body of an error response. The router returns an
+// OpenAI-style error object; serverless models that are still loading return
+// a message string with an estimated load time.
+type ErrorResponse struct {
+	Error         any     `json:"error"`
+	EstimatedTime float64 `json:"estimated_time,omitempty"` // Seconds until the model is loaded
+}
+
+// message returns the error message
+func (e *ErrorResponse) message() string {
+	switch v := e.Error.(type) {
+	case string:
+		return v
+	case map[string]any:
+		msg, _ := v["message"].(string)
+		return msg
+	}
+	return ""
+}
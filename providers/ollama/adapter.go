@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/plexusone/omnillm/provider"
@@ -26,33 +27,17 @@ func (p *Provider) Name() string {
 	return p.client.Name()
 }
 
+// Capabilities returns the request features the Ollama adapter supports.
+// Tools and content parts are not yet mapped.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Streaming: true, JSONSchema: true}
+}
+
 // CreateChatCompletion creates a chat completion
 func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
-	// Convert from unified format to Ollama format
-	ollamaReq := &Request{
-		Model: req.Model,
-	}
-
-	// Set options if provided
-	if req.MaxTokens != nil || req.Temperature != nil || req.TopP != nil || req.TopK != nil || req.Seed != nil || len(req.Stop) > 0 {
-		ollamaReq.Options = &Options{
-			Temperature: req.Temperature,
-			TopP:        req.TopP,
-			TopK:        req.TopK,
-			Stop:        req.Stop,
-			Seed:        req.Seed,
-		}
-		if req.MaxTokens != nil {
-			ollamaReq.Options.NumPredict = req.MaxTokens
-		}
-	}
-
-	// Convert messages
-	for _, msg := range req.Messages {
-		ollamaReq.Messages = append(ollamaReq.Messages, Message{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-		})
+	ollamaReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := p.client.CreateCompletion(ctx, ollamaReq)
@@ -61,7 +46,7 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 	}
 
 	// Convert back to unified format
-	return &provider.ChatCompletionResponse{
+	result := &provider.ChatCompletionResponse{
 		ID:      fmt.Sprintf("ollama-%d", time.Now().Unix()),
 		Object:  "chat.completion",
 		Created: time.Now().Unix(),
@@ -73,13 +58,7 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 					Role:    provider.Role(resp.Message.Role),
 					Content: resp.Message.Content,
 				},
-				FinishReason: func() *string {
-					if resp.Done {
-						reason := "stop"
-						return &reason
-					}
-					return nil
-				}(),
+				FinishReason: finishReason(resp.Done, resp.DoneReason),
 			},
 		},
 		Usage: provider.Usage{
@@ -87,19 +66,36 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 			CompletionTokens: resp.EvalCount,
 			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
 		},
-	}, nil
+	}
+	result.ProviderMetadata = provider.RecordDeterminism(nil, req.Seed, true, "")
+	return result, nil
 }
 
 // CreateChatCompletionStream creates a streaming chat completion
 func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
-	// Convert from unified format to Ollama format
+	ollamaReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := p.client.CreateCompletionStream(ctx, ollamaReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamAdapter{stream: stream, seed: req.Seed}, nil
+}
+
+// convertRequest converts a unified request to Ollama format
+func convertRequest(req *provider.ChatCompletionRequest) (*Request, error) {
 	ollamaReq := &Request{
-		Model: req.Model,
+		Model:   req.Model,
+		Headers: req.Headers,
 	}
 
 	// Set options if provided
 	if req.MaxTokens != nil || req.Temperature != nil || req.TopP != nil || req.TopK != nil || req.Seed != nil || len(req.Stop) > 0 {
-		ollamaReq.Options = &Options{
+		ollamaReq.Options = &ModelOptions{
 			Temperature: req.Temperature,
 			TopP:        req.TopP,
 			TopK:        req.TopK,
@@ -111,6 +107,16 @@ func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider
 		}
 	}
 
+	// Map JSON mode and JSON Schema structured output to Ollama's format
+	if rf := req.ResponseFormat; rf != nil {
+		switch {
+		case rf.Type == provider.ResponseFormatJSONObject:
+			ollamaReq.Format = "json"
+		case rf.Type == provider.ResponseFormatJSONSchema && rf.JSONSchema != nil:
+			ollamaReq.Format = rf.JSONSchema.Schema
+		}
+	}
+
 	// Convert messages
 	for _, msg := range req.Messages {
 		ollamaReq.Messages = append(ollamaReq.Messages, Message{
@@ -119,12 +125,41 @@ func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider
 		})
 	}
 
-	stream, err := p.client.CreateCompletionStream(ctx, ollamaReq)
+	opts, err := provider.DecodeOptions[Options](req, "ollama")
 	if err != nil {
 		return nil, err
 	}
+	if opts != nil {
+		applyOptions(ollamaReq, opts)
+	}
 
-	return &StreamAdapter{stream: stream}, nil
+	return ollamaReq, nil
+}
+
+// applyOptions sets the request-level fields and engine options from opts
+func applyOptions(req *Request, opts *Options) {
+	if opts.Format != nil {
+		req.Format = opts.Format
+	}
+	req.KeepAlive = opts.KeepAlive
+
+	if req.Options == nil {
+		req.Options = &ModelOptions{}
+	}
+	mo := req.Options
+	mo.NumCtx = opts.NumCtx
+	mo.NumGPU = opts.NumGPU
+	mo.MainGPU = opts.MainGPU
+	mo.NumThread = opts.NumThread
+	mo.NumBatch = opts.NumBatch
+	mo.NumKeep = opts.NumKeep
+	mo.UseMMap = opts.UseMMap
+	mo.Mirostat = opts.Mirostat
+	mo.MirostatEta = opts.MirostatEta
+	mo.MirostatTau = opts.MirostatTau
+	mo.MinP = opts.MinP
+	mo.RepeatLastN = opts.RepeatLastN
+	mo.RepeatPenalty = opts.RepeatPenalty
 }
 
 // Close closes the provider
@@ -135,6 +170,7 @@ func (p *Provider) Close() error {
 // StreamAdapter adapts Ollama stream to unified interface
 type StreamAdapter struct {
 	stream *Stream
+	seed   *int // Seed sent with the request, if any
 }
 
 // Recv receives the next chunk from the stream
@@ -157,13 +193,7 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 					Role:    provider.Role(chunk.Message.Role),
 					Content: chunk.Message.Content,
 				},
-				FinishReason: func() *string {
-					if chunk.Done {
-						reason := "stop"
-						return &reason
-					}
-					return nil
-				}(),
+				FinishReason: finishReason(chunk.Done, chunk.DoneReason),
 			},
 		},
 	}
@@ -175,6 +205,9 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 			TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
 		}
 	}
+	if chunk.Done {
+		result.ProviderMetadata = provider.RecordDeterminism(nil, s.seed, true, "")
+	}
 
 	return result, nil
 }
@@ -183,3 +216,125 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 func (s *StreamAdapter) Close() error {
 	return s.stream.Close()
 }
+
+// ListModels lists the models pulled into the local Ollama instance.
+// Embedding models are recognized by name or BERT family; all others are
+// treated as chat models.
+func (p *Provider) ListModels(ctx context.Context) ([]provider.Model, error) {
+	tags, err := p.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]provider.Model, 0, len(tags.Models))
+	for _, tag := range tags.Models {
+		model := provider.Model{
+			ID:           tag.Name,
+			Provider:     p.Name(),
+			Capabilities: provider.ModelCapabilities{Chat: true, Streaming: true},
+		}
+		if strings.Contains(tag.Name, "embed") || strings.Contains(tag.Details.Family, "bert") {
+			model.Capabilities = provider.ModelCapabilities{Embeddings: true}
+		}
+		if modified, err := time.Parse(time.RFC3339Nano, tag.ModifiedAt); err == nil {
+			model.Created = modified.Unix()
+		}
+		models = append(models, model)
+	}
+	return models, nil
+}
+
+// CreateFIMCompletion generates the code between the prompt and suffix. The
+// model's template must support a suffix, as code models such as
+// qwen2.5-coder and codellama:code do.
+func (p *Provider) CreateFIMCompletion(ctx context.Context, req *provider.FIMRequest) (*provider.FIMResponse, error) {
+	resp, err := p.client.Generate(ctx, &GenerateRequest{
+		Model:  req.Model,
+		Prompt: req.Prompt,
+		Suffix: req.Suffix,
+		Options: &ModelOptions{
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			NumPredict:  req.MaxTokens,
+			Stop:        req.Stop,
+			Seed:        req.Seed,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &provider.FIMResponse{
+		Model:        resp.Model,
+		Provider:     p.Name(),
+		Text:         resp.Response,
+		FinishReason: finishReason(resp.Done, resp.DoneReason),
+		Usage: provider.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+	}, nil
+}
+
+// CreateTextCompletion continues the prompt as raw text, without the model's
+// chat template. Echo is emulated; logprobs and multiple completions are not
+// supported.
+func (p *Provider) CreateTextCompletion(ctx context.Context, req *provider.TextCompletionRequest) (*provider.TextCompletionResponse, error) {
+	if req.Logprobs != nil {
+		return nil, fmt.Errorf("%w: ollama text completions do not return logprobs", provider.ErrInvalidRequest)
+	}
+	if req.N != nil && *req.N > 1 {
+		return nil, fmt.Errorf("%w: ollama text completions return one completion", provider.ErrInvalidRequest)
+	}
+
+	resp, err := p.client.Generate(ctx, &GenerateRequest{
+		Model:  req.Model,
+		Prompt: req.Prompt,
+		Suffix: req.Suffix,
+		Raw:    req.Suffix == "", // The template supplies the suffix markers for fill-in-the-middle
+		Options: &ModelOptions{
+			Temperature: req.Temperature,
+			TopP:        req.TopP,
+			NumPredict:  req.MaxTokens,
+			Stop:        req.Stop,
+			Seed:        req.Seed,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	text := resp.Response
+	if req.Echo {
+		text = req.Prompt + text
+	}
+	return &provider.TextCompletionResponse{
+		ID:       fmt.Sprintf("ollama-%d", time.Now().Unix()),
+		Created:  time.Now().Unix(),
+		Model:    resp.Model,
+		Provider: p.Name(),
+		Choices: []provider.TextCompletionChoice{{
+			Text:         text,
+			FinishReason: finishReason(resp.Done, resp.DoneReason),
+		}},
+		Usage: provider.Usage{
+			PromptTokens:     resp.PromptEvalCount,
+			CompletionTokens: resp.EvalCount,
+			TotalTokens:      resp.PromptEvalCount + resp.EvalCount,
+		},
+	}, nil
+}
+
+// finishReason returns the normalized finish reason of a finished response,
+// or nil while generation continues
+func finishReason(done bool, doneReason string) *string {
+	if !done {
+		return nil
+	}
+	reason := provider.FinishReasonStop
+	if doneReason != "" {
+		reason = provider.NormalizeFinishReason(doneReason)
+	}
+	return &reason
+}
@@ -0,0 +1,208 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestProvider_Options(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(Response{
+			Model:   "llama3",
+			Message: Message{Role: "assistant", Content: `{"ok": true}`},
+			Done:    true,
+		})
+	}))
+	defer server.Close()
+
+	numCtx, mirostat, temperature := 32768, 2, 0.2
+	p := NewProvider(server.URL, server.Client())
+	_, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:          "llama3",
+		Messages:       []provider.Message{{Role: provider.RoleUser, Content: "Reply in JSON"}},
+		Temperature:    &temperature,
+		ResponseFormat: &provider.ResponseFormat{Type: provider.ResponseFormatJSONObject},
+		ProviderOptions: map[string]any{"ollama": &Options{
+			KeepAlive: "10m",
+			NumCtx:    &numCtx,
+			Mirostat:  &mirostat,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion failed: %v", err)
+	}
+
+	if got["format"] != "json" || got["keep_alive"] != "10m" {
+		t.Errorf("unexpected request fields: format=%v keep_alive=%v", got["format"], got["keep_alive"])
+	}
+	options, _ := got["options"].(map[string]any)
+	if options["num_ctx"] != float64(32768) || options["mirostat"] != float64(2) || options["temperature"] != 0.2 {
+		t.Errorf("unexpected options: %v", options)
+	}
+}
+
+func TestConvertRequest_Format(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	req := &provider.ChatCompletionRequest{
+		Model:    "llama3",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+		ResponseFormat: &provider.ResponseFormat{
+			Type:       provider.ResponseFormatJSONSchema,
+			JSONSchema: &provider.JSONSchema{Name: "reply", Schema: schema},
+		},
+	}
+
+	ollamaReq, err := convertRequest(req)
+	if err != nil {
+		t.Fatalf("convertRequest failed: %v", err)
+	}
+	if format, ok := ollamaReq.Format.(map[string]any); !ok || format["type"] != "object" {
+		t.Errorf("expected JSON Schema format, got %v", ollamaReq.Format)
+	}
+	if ollamaReq.Options != nil {
+		t.Errorf("expected no options, got %+v", ollamaReq.Options)
+	}
+
+	// Format from provider options overrides the response format
+	req.ProviderOptions = map[string]any{"ollama": map[string]any{"format": "json"}}
+	ollamaReq, err = convertRequest(req)
+	if err != nil {
+		t.Fatalf("convertRequest failed: %v", err)
+	}
+	if ollamaReq.Format != "json" {
+		t.Errorf("expected format override, got %v", ollamaReq.Format)
+	}
+
+	req.ProviderOptions = map[string]any{"ollama": map[string]any{"num_ctx": "large"}}
+	if _, err := convertRequest(req); err == nil {
+		t.Error("expected error for malformed options")
+	}
+}
+
+func TestProvider_CreateFIMCompletion(t *testing.T) {
+	var got GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(GenerateResponse{
+			Model:           "qwen2.5-coder",
+			Response:        "return a + b",
+			Done:            true,
+			DoneReason:      "stop",
+			PromptEvalCount: 12,
+			EvalCount:       4,
+		})
+	}))
+	defer server.Close()
+
+	maxTokens := 64
+	p := NewProvider(server.URL, server.Client()).(provider.FIMProvider)
+	resp, err := p.CreateFIMCompletion(context.Background(), &provider.FIMRequest{
+		Model:     "qwen2.5-coder",
+		Prompt:    "def add(a, b):\n    ",
+		Suffix:    "\n",
+		MaxTokens: &maxTokens,
+	})
+	if err != nil {
+		t.Fatalf("CreateFIMCompletion() error = %v", err)
+	}
+
+	if got.Suffix != "\n" || got.Stream == nil || *got.Stream || *got.Options.NumPredict != 64 {
+		t.Errorf("request = %+v", got)
+	}
+	if resp.Text != "return a + b" || *resp.FinishReason != provider.FinishReasonStop || resp.Usage.TotalTokens != 16 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestProvider_CreateTextCompletion(t *testing.T) {
+	var got GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(GenerateResponse{Model: "llama3:text", Response: " blue", Done: true, DoneReason: "length"})
+	}))
+	defer server.Close()
+
+	p := NewProvider(server.URL, server.Client()).(provider.TextCompletionProvider)
+	resp, err := p.CreateTextCompletion(context.Background(), &provider.TextCompletionRequest{
+		Model:  "llama3:text",
+		Prompt: "The sky is",
+		Echo:   true,
+	})
+	if err != nil {
+		t.Fatalf("CreateTextCompletion() error = %v", err)
+	}
+	if !got.Raw || got.Prompt != "The sky is" {
+		t.Errorf("request = %+v", got)
+	}
+	if choice := resp.Choices[0]; choice.Text != "The sky is blue" || *choice.FinishReason != provider.FinishReasonLength {
+		t.Errorf("choice = %+v", choice)
+	}
+
+	logprobs := 1
+	if _, err := p.CreateTextCompletion(context.Background(), &provider.TextCompletionRequest{Model: "m", Logprobs: &logprobs}); !errors.Is(err, provider.ErrInvalidRequest) {
+		t.Errorf("logprobs error = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestProvider_StreamContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Both chunks arrive in one read
+		_, _ = io.WriteString(w, `{"model":"llama3","message":{"role":"assistant","content":"Hel"},"done":false}`+"\n"+
+			`{"model":"llama3","message":{"role":"assistant","content":"lo"},"done":false}`+"\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewProvider(server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(ctx, &provider.ChatCompletionRequest{
+		Model:    "llama3",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var content string
+	for range 2 {
+		chunk, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		content += chunk.Choices[0].Delta.Content
+	}
+	if content != "Hello" {
+		t.Errorf("content = %q, want Hello", content)
+	}
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := stream.Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Recv() error = %v, want context.Canceled", err)
+	}
+}
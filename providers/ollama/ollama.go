@@ -10,8 +10,14 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/plexusone/omnillm/provider"
 )
 
+// DefaultTimeout is the HTTP client timeout used when no client is given,
+// longer than most to allow for local models
+const DefaultTimeout = 60 * time.Second
+
 // Client implements Ollama API client
 type Client struct {
 	baseURL string
@@ -24,7 +30,7 @@ func New(baseURL string, httpClient *http.Client) *Client {
 		baseURL = "http://localhost:11434"
 	}
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 60 * time.Second} // Longer timeout for local models
+		httpClient = &http.Client{Timeout: DefaultTimeout}
 	}
 
 	return &Client{
@@ -60,6 +66,7 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -68,12 +75,7 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		var errorResp ErrorResponse
-		if json.Unmarshal(body, &errorResp) == nil {
-			return nil, fmt.Errorf("ollama API error: %s", errorResp.Error)
-		}
-		return nil, fmt.Errorf("ollama API error: status %d, body: %s", resp.StatusCode, string(body))
+		return nil, handleErrorResponse(resp)
 	}
 
 	var response Response
@@ -84,6 +86,68 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 	return &response, nil
 }
 
+// Generate creates a text completion with /api/generate
+func (c *Client) Generate(ctx context.Context, req *GenerateRequest) (*GenerateResponse, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+
+	req.Stream = boolPtr(false)
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleErrorResponse(resp)
+	}
+
+	var response GenerateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// ListModels lists the models available locally
+func (c *Client) ListModels(ctx context.Context) (*TagsResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleErrorResponse(resp)
+	}
+
+	var tags TagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &tags, nil
+}
+
 // CreateCompletionStream creates a streaming chat completion
 func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Stream, error) {
 	if req.Model == "" {
@@ -106,6 +170,7 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -113,18 +178,15 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		var errorResp ErrorResponse
-		if json.Unmarshal(body, &errorResp) == nil {
-			return nil, fmt.Errorf("ollama API error: %s", errorResp.Error)
-		}
-		return nil, fmt.Errorf("ollama API error: status %d, body: %s", resp.StatusCode, string(body))
+		defer resp.Body.Close()
+		return nil, handleErrorResponse(resp)
 	}
 
+	resp.Body = provider.NewContextBody(ctx, resp.Body)
 	return &Stream{
-		reader: bufio.NewReader(resp.Body),
-		closer: resp.Body,
+		ctx:     ctx,
+		scanner: bufio.NewScanner(resp.Body),
+		closer:  resp.Body,
 	}, nil
 }
 
@@ -133,23 +195,39 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// handleErrorResponse converts an error response to an APIError
+func handleErrorResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	var errorResp ErrorResponse
+	if json.Unmarshal(body, &errorResp) == nil && errorResp.Error != "" {
+		return provider.NewAPIErrorFromResponse("ollama", resp, "ollama API error: "+errorResp.Error, "", "").WithBody(body)
+	}
+	return provider.NewAPIErrorFromResponse("ollama", resp, "ollama API error: "+string(body), "", "").WithBody(body)
+}
+
 // Stream represents a streaming response from Ollama
 type Stream struct {
-	reader io.Reader
-	closer io.Closer
+	ctx     context.Context
+	scanner *bufio.Scanner
+	closer  io.Closer
 }
 
 // Recv receives the next chunk from the stream
 func (s *Stream) Recv() (*StreamResponse, error) {
-	scanner := bufio.NewScanner(s.reader)
-	if !scanner.Scan() {
-		if err := scanner.Err(); err != nil {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			if ctxErr := s.ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
 			return nil, err
 		}
 		return nil, io.EOF
 	}
 
-	line := scanner.Text()
+	line := s.scanner.Text()
 	if line == "" {
 		return nil, io.EOF
 	}
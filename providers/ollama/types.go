@@ -9,20 +9,93 @@ type Message struct {
 
 // Request represents an Ollama chat completion request
 type Request struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   *bool     `json:"stream,omitempty"`
-	Options  *Options  `json:"options,omitempty"`
+	Model     string        `json:"model"`
+	Messages  []Message     `json:"messages"`
+	Stream    *bool         `json:"stream,omitempty"`
+	Format    any           `json:"format,omitempty"`     // "json" or a JSON Schema object
+	KeepAlive string        `json:"keep_alive,omitempty"` // How long the model stays loaded after the request
+	Options   *ModelOptions `json:"options,omitempty"`
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
 }
 
-// Options represents generation options for Ollama
-type Options struct {
+// ModelOptions represents the generation and engine options sent in a request's
+// "options" object
+type ModelOptions struct {
 	Temperature *float64 `json:"temperature,omitempty"`
 	TopP        *float64 `json:"top_p,omitempty"`
 	TopK        *int     `json:"top_k,omitempty"`
 	NumPredict  *int     `json:"num_predict,omitempty"` // Ollama's equivalent to max_tokens
 	Stop        []string `json:"stop,omitempty"`
 	Seed        *int     `json:"seed,omitempty"`
+
+	// Engine options, set from Options
+	NumCtx        *int     `json:"num_ctx,omitempty"`
+	NumGPU        *int     `json:"num_gpu,omitempty"`
+	MainGPU       *int     `json:"main_gpu,omitempty"`
+	NumThread     *int     `json:"num_thread,omitempty"`
+	NumBatch      *int     `json:"num_batch,omitempty"`
+	NumKeep       *int     `json:"num_keep,omitempty"`
+	UseMMap       *bool    `json:"use_mmap,omitempty"`
+	Mirostat      *int     `json:"mirostat,omitempty"`
+	MirostatEta   *float64 `json:"mirostat_eta,omitempty"`
+	MirostatTau   *float64 `json:"mirostat_tau,omitempty"`
+	MinP          *float64 `json:"min_p,omitempty"`
+	RepeatLastN   *int     `json:"repeat_last_n,omitempty"`
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
+}
+
+// Options are the Ollama-specific request options, passed in
+// ChatCompletionRequest.ProviderOptions under the "ollama" key
+type Options struct {
+	// Format constrains the output: "json", or a JSON Schema object.
+	// Overrides ChatCompletionRequest.ResponseFormat.
+	Format any `json:"format,omitempty"`
+
+	// KeepAlive is how long the model stays loaded after the request, e.g.
+	// "10m". "0" unloads it immediately and "-1" keeps it loaded.
+	KeepAlive string `json:"keep_alive,omitempty"`
+
+	// NumCtx is the context window size in tokens. Ollama's default is
+	// often much smaller than the model supports.
+	NumCtx *int `json:"num_ctx,omitempty"`
+
+	// NumGPU is the number of layers to offload to the GPU
+	NumGPU *int `json:"num_gpu,omitempty"`
+
+	// MainGPU is the GPU used for small tensors when splitting across GPUs
+	MainGPU *int `json:"main_gpu,omitempty"`
+
+	// NumThread is the number of CPU threads used for generation
+	NumThread *int `json:"num_thread,omitempty"`
+
+	// NumBatch is the prompt processing batch size
+	NumBatch *int `json:"num_batch,omitempty"`
+
+	// NumKeep is the number of prompt tokens kept when the context is truncated
+	NumKeep *int `json:"num_keep,omitempty"`
+
+	// UseMMap set to false loads the whole model into memory
+	UseMMap *bool `json:"use_mmap,omitempty"`
+
+	// Mirostat enables Mirostat sampling: 0 disabled, 1 Mirostat, 2 Mirostat 2.0
+	Mirostat *int `json:"mirostat,omitempty"`
+
+	// MirostatEta is the Mirostat learning rate
+	MirostatEta *float64 `json:"mirostat_eta,omitempty"`
+
+	// MirostatTau is the Mirostat target entropy
+	MirostatTau *float64 `json:"mirostat_tau,omitempty"`
+
+	// MinP is the minimum token probability relative to the most likely token
+	MinP *float64 `json:"min_p,omitempty"`
+
+	// RepeatLastN is how far back the model looks to penalize repetition
+	RepeatLastN *int `json:"repeat_last_n,omitempty"`
+
+	// RepeatPenalty is the strength of the repetition penalty
+	RepeatPenalty *float64 `json:"repeat_penalty,omitempty"`
 }
 
 // Response represents an Ollama chat completion response
@@ -31,6 +104,7 @@ type Response struct {
 	CreatedAt          string  `json:"created_at"`
 	Message            Message `json:"message"`
 	Done               bool    `json:"done"`
+	DoneReason         string  `json:"done_reason,omitempty"` // "stop", "length", "load", or "unload"
 	TotalDuration      int64   `json:"total_duration,omitempty"`
 	LoadDuration       int64   `json:"load_duration,omitempty"`
 	PromptEvalCount    int     `json:"prompt_eval_count,omitempty"`
@@ -39,12 +113,40 @@ type Response struct {
 	EvalDuration       int64   `json:"eval_duration,omitempty"`
 }
 
+// GenerateRequest represents an Ollama /api/generate request
+type GenerateRequest struct {
+	Model     string        `json:"model"`
+	Prompt    string        `json:"prompt"`
+	Suffix    string        `json:"suffix,omitempty"` // Text after the generated text, for fill-in-the-middle
+	System    string        `json:"system,omitempty"`
+	Raw       bool          `json:"raw,omitempty"` // Send the prompt without applying the model's template
+	Stream    *bool         `json:"stream,omitempty"`
+	KeepAlive string        `json:"keep_alive,omitempty"`
+	Options   *ModelOptions `json:"options,omitempty"`
+}
+
+// GenerateResponse represents an Ollama /api/generate response
+type GenerateResponse struct {
+	Model              string `json:"model"`
+	CreatedAt          string `json:"created_at"`
+	Response           string `json:"response"`
+	Done               bool   `json:"done"`
+	DoneReason         string `json:"done_reason,omitempty"` // "stop", "length", "load", or "unload"
+	TotalDuration      int64  `json:"total_duration,omitempty"`
+	LoadDuration       int64  `json:"load_duration,omitempty"`
+	PromptEvalCount    int    `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64  `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int    `json:"eval_count,omitempty"`
+	EvalDuration       int64  `json:"eval_duration,omitempty"`
+}
+
 // StreamResponse represents a streaming response chunk from Ollama
 type StreamResponse struct {
 	Model              string  `json:"model"`
 	CreatedAt          string  `json:"created_at"`
 	Message            Message `json:"message"`
 	Done               bool    `json:"done"`
+	DoneReason         string  `json:"done_reason,omitempty"` // "stop", "length", "load", or "unload"
 	TotalDuration      int64   `json:"total_duration,omitempty"`
 	LoadDuration       int64   `json:"load_duration,omitempty"`
 	PromptEvalCount    int     `json:"prompt_eval_count,omitempty"`
@@ -53,6 +155,30 @@ type StreamResponse struct {
 	EvalDuration       int64   `json:"eval_duration,omitempty"`
 }
 
+// TagsResponse is the list of local models returned by /api/tags
+type TagsResponse struct {
+	Models []ModelTag `json:"models"`
+}
+
+// ModelTag describes a locally available model
+type ModelTag struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt string       `json:"modified_at"` // RFC 3339
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// ModelDetails describes a model's format and family
+type ModelDetails struct {
+	Format            string   `json:"format"`
+	Family            string   `json:"family"`
+	Families          []string `json:"families"`
+	ParameterSize     string   `json:"parameter_size"`
+	QuantizationLevel string   `json:"quantization_level"`
+}
+
 // ErrorResponse represents an Ollama error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -3,11 +3,18 @@ package openai
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/plexusone/omnillm/provider"
 )
 
+// MaxFileInputBytes is the largest inline file accepted in a chat completion request
+const MaxFileInputBytes = 32 << 20
+
 // Provider represents the OpenAI provider adapter
 type Provider struct {
 	client *Client
@@ -24,9 +31,249 @@ func (p *Provider) Name() string {
 	return p.client.Name()
 }
 
+// Capabilities returns the request features the OpenAI adapter supports.
+// Image inputs are not yet mapped; documents are sent as file inputs, which
+// accept PDFs only.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Streaming: true, Tools: true, FileSearch: true, JSONSchema: true, Logprobs: true, MultipleChoices: true, Audio: true}
+}
+
 // CreateChatCompletion creates a chat completion
 func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
-	// Convert from unified format to OpenAI format
+	// Provider-hosted tools are only available through the Responses API
+	if hasHostedTools(req.Tools) {
+		return p.createResponseCompletion(ctx, req)
+	}
+
+	openaiReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.CreateCompletion(ctx, openaiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert back to unified format
+	choices := make([]provider.ChatCompletionChoice, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		choices = append(choices, convertChoice(choice, audioFormat(openaiReq)))
+	}
+
+	result := &provider.ChatCompletionResponse{
+		ID:                resp.ID,
+		Object:            resp.Object,
+		Created:           resp.Created,
+		Model:             resp.Model,
+		SystemFingerprint: resp.SystemFingerprint,
+		Choices:           choices,
+		Usage:             convertUsage(resp.Usage),
+	}
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	result.ProviderMetadata = provider.RecordDeterminism(result.ProviderMetadata, req.Seed, true, fingerprint(resp.SystemFingerprint))
+	result.ProviderMetadata = provider.RecordRequestID(result.ProviderMetadata, resp.RequestID)
+	result.Raw = resp.Raw
+	return result, nil
+}
+
+// MarshalChatCompletionRequest returns the request body sent for req: a
+// Responses API request if it uses hosted tools, otherwise a chat completion
+func (p *Provider) MarshalChatCompletionRequest(req *provider.ChatCompletionRequest) ([]byte, error) {
+	if hasHostedTools(req.Tools) {
+		responsesReq, err := convertResponsesRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(responsesReq)
+	}
+
+	openaiReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	openaiReq.Stream = boolPtr(false)
+	return json.Marshal(openaiReq)
+}
+
+// CreateChatCompletionStream creates a streaming chat completion
+func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	// Hosted tools use the Responses API, which is not streamed; the error
+	// matches ErrInvalidRequest so fallback does not retry it elsewhere
+	if hasHostedTools(req.Tools) {
+		return nil, &provider.CapabilityError{Provider: p.Name(), Missing: []string{"streaming file_search"}}
+	}
+
+	openaiReq, err := convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := p.client.CreateCompletionStream(ctx, openaiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamAdapter{stream: stream, audioFormat: audioFormat(openaiReq), seed: req.Seed}, nil
+}
+
+// CreateModeration classifies content using the OpenAI moderations endpoint
+func (p *Provider) CreateModeration(ctx context.Context, req *provider.ModerationRequest) (*provider.ModerationResponse, error) {
+	resp, err := p.client.CreateModeration(ctx, &ModerationRequest{
+		Input: req.Input,
+		Model: req.Model,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &provider.ModerationResponse{
+		ID:    resp.ID,
+		Model: resp.Model,
+	}
+	for _, r := range resp.Results {
+		result.Results = append(result.Results, provider.ModerationResult{
+			Flagged:        r.Flagged,
+			Categories:     r.Categories,
+			CategoryScores: r.CategoryScores,
+		})
+	}
+
+	return result, nil
+}
+
+// ListModels lists the models available to the API key. OpenAI reports no
+// limits or capabilities, so capabilities are inferred from the model family.
+func (p *Provider) ListModels(ctx context.Context) ([]provider.Model, error) {
+	list, err := p.client.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]provider.Model, 0, len(list.Data))
+	for _, m := range list.Data {
+		models = append(models, provider.Model{
+			ID:           m.ID,
+			Provider:     p.Name(),
+			Created:      m.Created,
+			OwnedBy:      m.OwnedBy,
+			Capabilities: modelCapabilities(m.ID),
+		})
+	}
+	return models, nil
+}
+
+// modelCapabilities infers capabilities from an OpenAI model ID
+func modelCapabilities(id string) provider.ModelCapabilities {
+	if strings.Contains(id, "embedding") {
+		return provider.ModelCapabilities{Embeddings: true}
+	}
+
+	chatFamily := strings.HasPrefix(id, "gpt-") || strings.HasPrefix(id, "chatgpt-") ||
+		(len(id) > 1 && id[0] == 'o' && id[1] >= '1' && id[1] <= '9')
+	for _, special := range []string{"instruct", "audio", "realtime", "transcribe", "tts", "image", "search"} {
+		if strings.Contains(id, special) {
+			chatFamily = false
+		}
+	}
+	if !chatFamily {
+		return provider.ModelCapabilities{}
+	}
+
+	legacy := strings.HasPrefix(id, "gpt-3.5") || id == "gpt-4" || strings.HasPrefix(id, "gpt-4-0")
+	return provider.ModelCapabilities{
+		Chat:      true,
+		Streaming: true,
+		Tools:     true,
+		Vision:    !legacy && !strings.HasPrefix(id, "o1-mini") && !strings.HasPrefix(id, "o3-mini"),
+	}
+}
+
+// UploadFile uploads a file to OpenAI and returns a unified file handle
+func (p *Provider) UploadFile(ctx context.Context, req *provider.FileUploadRequest) (*provider.File, error) {
+	file, err := p.client.UploadFile(ctx, req.Filename, req.Purpose, req.Data)
+	if err != nil {
+		return nil, err
+	}
+	result := convertFile(file)
+	result.MIMEType = req.MIMEType
+	return result, nil
+}
+
+// GetFile retrieves an OpenAI file as a unified file handle
+func (p *Provider) GetFile(ctx context.Context, fileID string) (*provider.File, error) {
+	file, err := p.client.GetFile(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return convertFile(file), nil
+}
+
+// DeleteFile deletes an OpenAI file
+func (p *Provider) DeleteFile(ctx context.Context, fileID string) error {
+	_, err := p.client.DeleteFile(ctx, fileID)
+	return err
+}
+
+// CreateVectorStore creates a vector store using the OpenAI vector stores endpoint
+func (p *Provider) CreateVectorStore(ctx context.Context, req *provider.VectorStoreRequest) (*provider.VectorStore, error) {
+	store, err := p.client.CreateVectorStore(ctx, &VectorStoreCreateRequest{
+		Name:    req.Name,
+		FileIDs: req.FileIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertVectorStore(store), nil
+}
+
+// GetVectorStore retrieves vector store metadata
+func (p *Provider) GetVectorStore(ctx context.Context, storeID string) (*provider.VectorStore, error) {
+	store, err := p.client.GetVectorStore(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	return convertVectorStore(store), nil
+}
+
+// AddVectorStoreFile attaches an uploaded file to a vector store
+func (p *Provider) AddVectorStoreFile(ctx context.Context, storeID, fileID string) error {
+	return p.client.AddVectorStoreFile(ctx, storeID, fileID)
+}
+
+// DeleteVectorStore deletes a vector store
+func (p *Provider) DeleteVectorStore(ctx context.Context, storeID string) error {
+	return p.client.DeleteVectorStore(ctx, storeID)
+}
+
+// convertVectorStore converts an OpenAI vector store to a unified handle
+func convertVectorStore(store *VectorStoreObject) *provider.VectorStore {
+	return &provider.VectorStore{
+		ID:        store.ID,
+		Provider:  "openai",
+		Name:      store.Name,
+		FileCount: store.FileCounts.Total,
+		Status:    store.Status,
+		CreatedAt: store.CreatedAt,
+	}
+}
+
+// convertFile converts an OpenAI file object to a unified file handle
+func convertFile(file *FileObject) *provider.File {
+	return &provider.File{
+		ID:        file.ID,
+		Provider:  "openai",
+		Filename:  file.Filename,
+		Bytes:     file.Bytes,
+		Purpose:   file.Purpose,
+		Status:    file.Status,
+		CreatedAt: file.CreatedAt,
+		ExpiresAt: file.ExpiresAt,
+	}
+}
+
+// convertRequest converts a unified request to OpenAI format
+func convertRequest(req *provider.ChatCompletionRequest) (*Request, error) {
 	openaiReq := &Request{
 		Model:            req.Model,
 		MaxTokens:        req.MaxTokens,
@@ -41,6 +288,8 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 		N:                req.N,
 		Logprobs:         req.Logprobs,
 		TopLogprobs:      req.TopLogprobs,
+		Headers:          req.Headers,
+		IncludeRaw:       req.IncludeRaw,
 	}
 
 	// Convert response format if provided
@@ -48,6 +297,14 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 		openaiReq.ResponseFormat = &ResponseFormat{
 			Type: req.ResponseFormat.Type,
 		}
+		if js := req.ResponseFormat.JSONSchema; js != nil {
+			openaiReq.ResponseFormat.JSONSchema = &JSONSchema{
+				Name:        js.Name,
+				Description: js.Description,
+				Schema:      js.Schema,
+				Strict:      js.Strict,
+			}
+		}
 	}
 
 	// Convert tools
@@ -63,6 +320,20 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 	}
 	openaiReq.ToolChoice = req.ToolChoice
 
+	opts, err := provider.DecodeOptions[Options](req, "openai")
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil {
+		openaiReq.ParallelToolCalls = opts.ParallelToolCalls
+		openaiReq.ReasoningEffort = opts.ReasoningEffort
+		openaiReq.ServiceTier = opts.ServiceTier
+		openaiReq.Store = opts.Store
+		openaiReq.Metadata = opts.Metadata
+		openaiReq.Modalities = opts.Modalities
+		openaiReq.Audio = opts.Audio
+	}
+
 	// Convert messages
 	for _, msg := range req.Messages {
 		openaiMsg := Message{
@@ -71,6 +342,10 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 			Name:       msg.Name,
 			ToolCallID: msg.ToolCallID,
 		}
+		// Earlier audio output is referenced by ID
+		if msg.Role == provider.RoleAssistant && msg.Audio != nil && msg.Audio.ID != "" {
+			openaiMsg.Audio = &MessageAudio{ID: msg.Audio.ID}
+		}
 		// Convert tool calls if present
 		for _, tc := range msg.ToolCalls {
 			openaiMsg.ToolCalls = append(openaiMsg.ToolCalls, ToolCall{
@@ -82,93 +357,90 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 				},
 			})
 		}
+		// Convert multi-part content if present
+		for _, part := range msg.Parts {
+			contentPart, err := convertContentPart(part)
+			if err != nil {
+				return nil, err
+			}
+			openaiMsg.Parts = append(openaiMsg.Parts, contentPart)
+		}
 		openaiReq.Messages = append(openaiReq.Messages, openaiMsg)
 	}
 
-	resp, err := p.client.CreateCompletion(ctx, openaiReq)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert tool calls from response
-	var toolCalls []provider.ToolCall
-	for _, tc := range resp.Choices[0].Message.ToolCalls {
-		toolCalls = append(toolCalls, provider.ToolCall{
-			ID:   tc.ID,
-			Type: tc.Type,
-			Function: provider.ToolFunction{
-				Name:      tc.Function.Name,
-				Arguments: tc.Function.Arguments,
-			},
-		})
-	}
-
-	// Convert back to unified format
-	return &provider.ChatCompletionResponse{
-		ID:      resp.ID,
-		Object:  resp.Object,
-		Created: resp.Created,
-		Model:   resp.Model,
-		Choices: []provider.ChatCompletionChoice{
-			{
-				Index: 0,
-				Message: provider.Message{
-					Role:      provider.Role(resp.Choices[0].Message.Role),
-					Content:   resp.Choices[0].Message.Content,
-					ToolCalls: toolCalls,
-				},
-				FinishReason: resp.Choices[0].FinishReason,
-			},
-		},
-		Usage: provider.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
-	}, nil
+	return openaiReq, nil
 }
 
-// CreateChatCompletionStream creates a streaming chat completion
-func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
-	// Convert from unified format to OpenAI format
-	openaiReq := &Request{
-		Model:            req.Model,
-		MaxTokens:        req.MaxTokens,
-		Temperature:      req.Temperature,
-		TopP:             req.TopP,
-		Stop:             req.Stop,
-		PresencePenalty:  req.PresencePenalty,
-		FrequencyPenalty: req.FrequencyPenalty,
-		LogitBias:        req.LogitBias,
-		User:             req.User,
-		Seed:             req.Seed,
-		N:                req.N,
-		Logprobs:         req.Logprobs,
-		TopLogprobs:      req.TopLogprobs,
-	}
+// convertContentPart converts a unified content part to an OpenAI content part
+func convertContentPart(part provider.ContentPart) (ContentPart, error) {
+	switch part.Type {
+	case provider.ContentPartTypeText:
+		return ContentPart{Type: "text", Text: part.Text}, nil
 
-	// Convert response format if provided
-	if req.ResponseFormat != nil {
-		openaiReq.ResponseFormat = &ResponseFormat{
-			Type: req.ResponseFormat.Type,
+	case provider.ContentPartTypeDocument:
+		doc := part.Document
+		if doc == nil {
+			return ContentPart{}, fmt.Errorf("document part is missing document")
+		}
+		switch {
+		case doc.FileID != "":
+			return ContentPart{Type: "file", File: &FileInput{FileID: doc.FileID}}, nil
+		case len(doc.Data) > MaxFileInputBytes:
+			return ContentPart{}, fmt.Errorf("document %q is %d bytes, exceeds OpenAI limit of %d bytes",
+				doc.Filename, len(doc.Data), MaxFileInputBytes)
+		case len(doc.Data) > 0:
+			filename := doc.Filename
+			if filename == "" {
+				filename = "document"
+			}
+			return ContentPart{Type: "file", File: &FileInput{
+				Filename: filename,
+				FileData: "data:" + doc.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(doc.Data),
+			}}, nil
+		default:
+			return ContentPart{}, fmt.Errorf("document %q has no data or file ID", doc.Filename)
 		}
-	}
 
-	// Convert messages
-	for _, msg := range req.Messages {
-		openaiReq.Messages = append(openaiReq.Messages, Message{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-			Name:    msg.Name,
-		})
+	case provider.ContentPartTypeAudio:
+		audio := part.Audio
+		if audio == nil || len(audio.Data) == 0 {
+			return ContentPart{}, fmt.Errorf("audio part is missing audio data")
+		}
+		return ContentPart{Type: "input_audio", InputAudio: &InputAudio{
+			Data:   base64.StdEncoding.EncodeToString(audio.Data),
+			Format: audio.Format,
+		}}, nil
+
+	default:
+		return ContentPart{}, fmt.Errorf("unsupported content part type %q", part.Type)
 	}
+}
 
-	stream, err := p.client.CreateCompletionStream(ctx, openaiReq)
-	if err != nil {
-		return nil, err
+// audioFormat returns the format of the audio a request asks for, or "" if
+// it asks for none
+func audioFormat(req *Request) string {
+	if req.Audio == nil {
+		return ""
 	}
+	return req.Audio.Format
+}
 
-	return &StreamAdapter{stream: stream}, nil
+// convertAudio converts model audio output to unified format. Data that is
+// not valid base64 is dropped.
+func convertAudio(audio *MessageAudio, format string) *provider.Audio {
+	if audio == nil {
+		return nil
+	}
+	result := &provider.Audio{
+		Format:     format,
+		ID:         audio.ID,
+		Transcript: audio.Transcript,
+		ExpiresAt:  audio.ExpiresAt,
+	}
+	if data, err := base64.StdEncoding.DecodeString(audio.Data); err == nil {
+		result.Data = data
+	}
+	return result
 }
 
 // Close closes the provider
@@ -176,9 +448,19 @@ func (p *Provider) Close() error {
 	return p.client.Close()
 }
 
+// fingerprint returns a reported system fingerprint, or "" if there is none
+func fingerprint(systemFingerprint *string) string {
+	if systemFingerprint == nil {
+		return ""
+	}
+	return *systemFingerprint
+}
+
 // StreamAdapter adapts OpenAI stream to unified interface
 type StreamAdapter struct {
-	stream *Stream
+	stream      *Stream
+	audioFormat string // Format of requested audio output, if any
+	seed        *int   // Seed sent with the request, if any
 }
 
 // Recv receives the next chunk from the stream
@@ -190,33 +472,53 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 
 	// Convert to unified format
 	result := &provider.ChatCompletionChunk{
-		ID:      chunk.ID,
-		Object:  chunk.Object,
-		Created: chunk.Created,
-		Model:   chunk.Model,
+		ID:                chunk.ID,
+		Object:            chunk.Object,
+		Created:           chunk.Created,
+		Model:             chunk.Model,
+		SystemFingerprint: chunk.SystemFingerprint,
 	}
 
 	if chunk.Usage != nil {
-		result.Usage = &provider.Usage{
-			PromptTokens:     chunk.Usage.PromptTokens,
-			CompletionTokens: chunk.Usage.CompletionTokens,
-			TotalTokens:      chunk.Usage.TotalTokens,
-		}
+		usage := convertUsage(*chunk.Usage)
+		result.Usage = &usage
 	}
 
 	for _, choice := range chunk.Choices {
 		result.Choices = append(result.Choices, provider.ChatCompletionChoice{
 			Index:        choice.Index,
 			FinishReason: choice.FinishReason,
+			Logprobs:     convertLogprobs(choice.Logprobs),
 		})
 		if choice.Delta != nil {
-			result.Choices[len(result.Choices)-1].Delta = &provider.Message{
+			delta := &provider.Message{
 				Role:    provider.Role(choice.Delta.Role),
 				Content: choice.Delta.Content,
+				Audio:   convertAudio(choice.Delta.Audio, s.audioFormat),
+			}
+			for _, tc := range choice.Delta.ToolCalls {
+				delta.ToolCalls = append(delta.ToolCalls, provider.ToolCall{
+					Index: tc.Index,
+					ID:    tc.ID,
+					Type:  tc.Type,
+					Function: provider.ToolFunction{
+						Name:      tc.Function.Name,
+						Arguments: tc.Function.Arguments,
+					},
+				})
 			}
+			result.Choices[len(result.Choices)-1].Delta = delta
 		}
 	}
 
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	_, finished := result.ProviderMetadata[provider.RawFinishReasonKey]
+	if finished || chunk.SystemFingerprint != nil {
+		result.ProviderMetadata = provider.RecordDeterminism(result.ProviderMetadata, s.seed, true, fingerprint(chunk.SystemFingerprint))
+	}
+	if finished {
+		result.ProviderMetadata = provider.RecordRequestID(result.ProviderMetadata, s.stream.RequestID())
+	}
 	return result, nil
 }
 
@@ -224,3 +526,87 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 func (s *StreamAdapter) Close() error {
 	return s.stream.Close()
 }
+
+// convertChoice converts a response choice to unified format
+func convertChoice(choice Choice, audioFormat string) provider.ChatCompletionChoice {
+	var toolCalls []provider.ToolCall
+	for _, tc := range choice.Message.ToolCalls {
+		toolCalls = append(toolCalls, provider.ToolCall{
+			ID:   tc.ID,
+			Type: tc.Type,
+			Function: provider.ToolFunction{
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			},
+		})
+	}
+
+	return provider.ChatCompletionChoice{
+		Index: choice.Index,
+		Message: provider.Message{
+			Role:      provider.Role(choice.Message.Role),
+			Content:   choice.Message.Content,
+			ToolCalls: toolCalls,
+			Audio:     convertAudio(choice.Message.Audio, audioFormat),
+		},
+		FinishReason: choice.FinishReason,
+		Logprobs:     convertLogprobs(choice.Logprobs),
+	}
+}
+
+// convertLogprobs converts token log probabilities to unified format
+func convertLogprobs(lp *Logprobs) *provider.Logprobs {
+	if lp == nil {
+		return nil
+	}
+	result := &provider.Logprobs{Content: make([]provider.TokenLogprob, 0, len(lp.Content))}
+	for _, token := range lp.Content {
+		converted := provider.TokenLogprob{
+			Token:   token.Token,
+			Logprob: token.Logprob,
+			Bytes:   token.Bytes,
+		}
+		for _, alt := range token.TopLogprobs {
+			converted.TopLogprobs = append(converted.TopLogprobs, provider.TopLogprob{
+				Token:   alt.Token,
+				Logprob: alt.Logprob,
+				Bytes:   alt.Bytes,
+			})
+		}
+		result.Content = append(result.Content, converted)
+	}
+	return result
+}
+
+// convertUsage converts token usage, including cached and reasoning token details, to unified format
+func convertUsage(usage Usage) provider.Usage {
+	return provider.Usage{
+		PromptTokens:            usage.PromptTokens,
+		CompletionTokens:        usage.CompletionTokens,
+		TotalTokens:             usage.TotalTokens,
+		PromptTokensDetails:     convertPromptTokensDetails(usage.PromptTokensDetails),
+		CompletionTokensDetails: convertCompletionTokensDetails(usage.CompletionTokensDetails),
+	}
+}
+
+// convertPromptTokensDetails converts a prompt token breakdown to unified format
+func convertPromptTokensDetails(details *PromptTokensDetails) *provider.PromptTokensDetails {
+	if details == nil {
+		return nil
+	}
+	return &provider.PromptTokensDetails{
+		CachedTokens: details.CachedTokens,
+		AudioTokens:  details.AudioTokens,
+	}
+}
+
+// convertCompletionTokensDetails converts a completion token breakdown to unified format
+func convertCompletionTokensDetails(details *CompletionTokensDetails) *provider.CompletionTokensDetails {
+	if details == nil {
+		return nil
+	}
+	return &provider.CompletionTokensDetails{
+		ReasoningTokens: details.ReasoningTokens,
+		AudioTokens:     details.AudioTokens,
+	}
+}
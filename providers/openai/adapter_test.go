@@ -0,0 +1,679 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestConvertRequest_DocumentParts(t *testing.T) {
+	req := &provider.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []provider.Message{
+			{
+				Role:    provider.RoleUser,
+				Content: "Summarize these",
+				Parts: []provider.ContentPart{
+					{Type: provider.ContentPartTypeDocument, Document: &provider.Document{MIMEType: "application/pdf", Filename: "a.pdf", Data: []byte("%PDF-1.4")}},
+					{Type: provider.ContentPartTypeDocument, Document: &provider.Document{MIMEType: "application/pdf", FileID: "file-123"}},
+				},
+			},
+		},
+	}
+
+	openaiReq, err := convertRequest(req)
+	if err != nil {
+		t.Fatalf("convertRequest() error = %v", err)
+	}
+
+	data, err := json.Marshal(openaiReq.Messages[0])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, want := range []string{`"type":"text"`, `"type":"file"`, `"file_data":"data:application/pdf;base64,`, `"file_id":"file-123"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("marshaled message %s missing %s", data, want)
+		}
+	}
+}
+
+func TestMessage_MarshalJSON_PlainContent(t *testing.T) {
+	data, err := json.Marshal(Message{Role: "user", Content: "Hello"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"content":"Hello"`) {
+		t.Errorf("marshaled message %s should keep string content", data)
+	}
+}
+
+func TestConvertRequest_ProviderOptions(t *testing.T) {
+	parallel := false
+	req := &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		ProviderOptions: map[string]any{
+			"openai":    &Options{ParallelToolCalls: &parallel, ReasoningEffort: "low"},
+			"anthropic": map[string]any{"top_k": 5},
+		},
+	}
+
+	got, err := convertRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["parallel_tool_calls"] != false || fields["reasoning_effort"] != "low" {
+		t.Errorf("options not sent: %s", body)
+	}
+	if _, ok := fields["top_k"]; ok {
+		t.Errorf("another provider's options leaked: %s", body)
+	}
+}
+
+func TestConvertRequest_JSONSchema(t *testing.T) {
+	strict := true
+	got, err := convertRequest(&provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		ResponseFormat: &provider.ResponseFormat{
+			Type:       provider.ResponseFormatJSONSchema,
+			JSONSchema: &provider.JSONSchema{Name: "report", Schema: map[string]any{"type": "object"}, Strict: &strict},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(got.ResponseFormat)
+	want := `{"type":"json_schema","json_schema":{"name":"report","schema":{"type":"object"},"strict":true}}`
+	if string(body) != want {
+		t.Errorf("response_format = %s, want %s", body, want)
+	}
+}
+
+func TestProvider_Headers(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_, _ = io.WriteString(w, `{"id":"r1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	_, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		Headers:  map[string]string{"OpenAI-Organization": "org-123", "Content-Type": "application/json; charset=utf-8"},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if v := got.Get("OpenAI-Organization"); v != "org-123" {
+		t.Errorf("OpenAI-Organization = %q, want org-123", v)
+	}
+	if v := got.Get("Content-Type"); v != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want the per-request override", v)
+	}
+	if v := got.Get("Authorization"); v != "Bearer test-key" {
+		t.Errorf("Authorization = %q", v)
+	}
+	if v := got.Get(provider.IdempotencyKeyHeader); v != "" {
+		t.Errorf("Idempotency-Key = %q, want none without a key in the context", v)
+	}
+
+	ctx := provider.WithIdempotencyKey(context.Background(), "order-42")
+	if _, err := p.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}); err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if v := got.Get(provider.IdempotencyKeyHeader); v != "order-42" {
+		t.Errorf("Idempotency-Key = %q, want order-42", v)
+	}
+}
+
+func TestProvider_Logprobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"r1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"positive"},
+			"finish_reason":"stop","logprobs":{"content":[{"token":"positive","logprob":-0.05,"bytes":[112],
+			"top_logprobs":[{"token":"positive","logprob":-0.05},{"token":"negative","logprob":-3.2}]}]}}]}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Sentiment of: great product"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	lp := resp.Choices[0].Logprobs
+	if lp == nil || len(lp.Content) != 1 {
+		t.Fatalf("Logprobs = %+v", lp)
+	}
+	token := lp.Content[0]
+	if token.Token != "positive" || len(token.Bytes) != 1 || len(token.TopLogprobs) != 2 || token.TopLogprobs[1].Token != "negative" {
+		t.Errorf("token = %+v", token)
+	}
+	if got, want := lp.Confidence(), math.Exp(-0.05); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Confidence() = %v, want %v", got, want)
+	}
+}
+
+func TestStreamAdapter_Logprobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"content":"ye"},"logprobs":{"content":[{"token":"ye","logprob":-0.2}]}}]}`+"\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lp := chunk.Choices[0].Logprobs; lp == nil || len(lp.Content) != 1 || lp.Content[0].Token != "ye" {
+		t.Errorf("chunk Logprobs = %+v", lp)
+	}
+}
+
+func TestStreamAdapter_IncludesUsage(t *testing.T) {
+	var sent Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":"stop"}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}`+"\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if sent.StreamOptions == nil || !sent.StreamOptions.IncludeUsage {
+		t.Errorf("stream_options = %+v, want include_usage", sent.StreamOptions)
+	}
+	if usage == nil || usage.TotalTokens != 10 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestStreamAdapter_ErrorEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"}}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"error":{"message":"The server had an error","type":"server_error","code":null}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Type != "server_error" {
+		t.Errorf("expected a server_error APIError, got %v", err)
+	}
+}
+
+func TestProvider_ErrorDetails(t *testing.T) {
+	body := `{"error":{"message":"Unsupported value: 'temperature'","type":"invalid_request_error","param":"temperature","code":"unsupported_value"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_abc")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	_, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "o3",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %v", err)
+	}
+	if apiErr.Param != "temperature" || apiErr.RequestID != "req_abc" || apiErr.Body != body {
+		t.Errorf("APIError = %+v", apiErr)
+	}
+	if !errors.Is(err, provider.ErrInvalidRequest) {
+		t.Error("expected a 400 to match ErrInvalidRequest")
+	}
+}
+
+func TestProvider_MultipleChoices(t *testing.T) {
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"id":"r1","choices":[
+			{"index":0,"message":{"role":"assistant","content":"one"},"finish_reason":"stop"},
+			{"index":1,"message":{"role":"assistant","content":"two"},"finish_reason":"length"},
+			{"index":2,"message":{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},"finish_reason":"tool_calls"}]}`)
+	}))
+	defer server.Close()
+
+	n := 3
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Name a number"}},
+		N:        &n,
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if got.N == nil || *got.N != 3 {
+		t.Errorf("n not sent: %v", got.N)
+	}
+	if len(resp.Choices) != 3 {
+		t.Fatalf("Choices = %d, want 3", len(resp.Choices))
+	}
+	if resp.Choices[1].Index != 1 || resp.Choices[1].Message.Content != "two" || *resp.Choices[1].FinishReason != "length" {
+		t.Errorf("choice 1 = %+v", resp.Choices[1])
+	}
+	if calls := resp.Choices[2].Message.ToolCalls; len(calls) != 1 || calls[0].Function.Name != "f" {
+		t.Errorf("choice 2 tool calls = %+v", calls)
+	}
+}
+
+func TestProvider_UsageDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"r1","choices":[{"index":0,"message":{"role":"assistant","content":"4"}}],
+			"usage":{"prompt_tokens":2000,"completion_tokens":500,"total_tokens":2500,
+			"prompt_tokens_details":{"cached_tokens":1536,"audio_tokens":0},
+			"completion_tokens_details":{"reasoning_tokens":448,"audio_tokens":0}}}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "o3-mini",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "2+2?"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	usage := resp.Usage
+	if usage.PromptTokens != 2000 || usage.TotalTokens != 2500 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if usage.PromptTokensDetails == nil || usage.PromptTokensDetails.CachedTokens != 1536 {
+		t.Errorf("PromptTokensDetails = %+v", usage.PromptTokensDetails)
+	}
+	if usage.CompletionTokensDetails == nil || usage.CompletionTokensDetails.ReasoningTokens != 448 {
+		t.Errorf("CompletionTokensDetails = %+v", usage.CompletionTokensDetails)
+	}
+}
+
+func TestProvider_FinishReasonNormalized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"id":"r1","choices":[
+			{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"function_call"},
+			{"index":1,"message":{"role":"assistant","content":"hi"},"finish_reason":"content_filter"}]}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *resp.Choices[0].FinishReason; got != provider.FinishReasonToolCalls {
+		t.Errorf("choice 0 FinishReason = %q", got)
+	}
+	if got := *resp.Choices[1].FinishReason; got != provider.FinishReasonContentFilter {
+		t.Errorf("choice 1 FinishReason = %q", got)
+	}
+	if got := resp.ProviderMetadata[provider.RawFinishReasonKey]; got != "function_call" {
+		t.Errorf("raw finish reason = %v", got)
+	}
+}
+
+func TestProvider_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_, _ = io.WriteString(w, `{"object":"list","data":[
+			{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"},
+			{"id":"text-embedding-3-small","object":"model","created":1705948997,"owned_by":"system"},
+			{"id":"whisper-1","object":"model","created":1677532384,"owned_by":"openai-internal"}]}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	models, err := p.(provider.ModelLister).ListModels(context.Background())
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(models) != 3 {
+		t.Fatalf("models = %+v", models)
+	}
+
+	gpt := models[0]
+	if gpt.Provider != "openai" || gpt.Created != 1715367049 || gpt.OwnedBy != "system" {
+		t.Errorf("gpt-4o = %+v", gpt)
+	}
+	if c := gpt.Capabilities; !c.Chat || !c.Streaming || !c.Tools || !c.Vision || c.Embeddings {
+		t.Errorf("gpt-4o capabilities = %+v", c)
+	}
+	if c := models[1].Capabilities; !c.Embeddings || c.Chat {
+		t.Errorf("embedding capabilities = %+v", c)
+	}
+	if c := models[2].Capabilities; c != (provider.ModelCapabilities{}) {
+		t.Errorf("whisper capabilities = %+v", c)
+	}
+}
+
+func TestProvider_AudioInputAndOutput(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.WriteString(w, `{"id":"r1","model":"gpt-4o-audio-preview","choices":[{"index":0,"message":{"role":"assistant","content":null,
+			"audio":{"id":"audio_1","data":"UklGRg==","transcript":"Hello there","expires_at":1735689600}},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model: "gpt-4o-audio-preview",
+		Messages: []provider.Message{
+			{Role: provider.RoleAssistant, Audio: &provider.Audio{ID: "audio_0", Transcript: "Hi"}},
+			{Role: provider.RoleUser, Parts: []provider.ContentPart{
+				{Type: provider.ContentPartTypeAudio, Audio: &provider.Audio{Format: "wav", Data: []byte("RIFF")}},
+			}},
+		},
+		ProviderOptions: map[string]any{"openai": Options{
+			Modalities: []string{"text", "audio"},
+			Audio:      &AudioOptions{Voice: "alloy", Format: "wav"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	if sent["audio"].(map[string]any)["voice"] != "alloy" || len(sent["modalities"].([]any)) != 2 {
+		t.Errorf("request audio options = %v, %v", sent["audio"], sent["modalities"])
+	}
+	messages := sent["messages"].([]any)
+	if ref := messages[0].(map[string]any)["audio"]; ref == nil || ref.(map[string]any)["id"] != "audio_0" || ref.(map[string]any)["transcript"] != nil {
+		t.Errorf("assistant audio reference = %v", ref)
+	}
+	part := messages[1].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if part["type"] != "input_audio" || part["input_audio"].(map[string]any)["data"] != "UklGRg==" {
+		t.Errorf("audio part = %v", part)
+	}
+
+	audio := resp.Choices[0].Message.Audio
+	if audio == nil || audio.ID != "audio_1" || string(audio.Data) != "RIFF" || audio.Transcript != "Hello there" ||
+		audio.Format != "wav" || audio.ExpiresAt != 1735689600 {
+		t.Errorf("Audio = %+v", audio)
+	}
+}
+
+func TestStreamAdapter_Audio(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"role":"assistant","audio":{"id":"audio_1","transcript":"Hel"}}}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"audio":{"data":"AAE="}}}]}`+"\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o-audio-preview",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		ProviderOptions: map[string]any{"openai": Options{
+			Modalities: []string{"text", "audio"},
+			Audio:      &AudioOptions{Voice: "alloy", Format: "pcm16"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audio := first.Choices[0].Delta.Audio; audio == nil || audio.ID != "audio_1" || audio.Transcript != "Hel" || audio.Format != "pcm16" {
+		t.Errorf("first delta audio = %+v", audio)
+	}
+	second, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audio := second.Choices[0].Delta.Audio; audio == nil || len(audio.Data) != 2 || audio.Data[1] != 1 {
+		t.Errorf("second delta audio = %+v", audio)
+	}
+}
+
+func TestStreamAdapter_ContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"}}]}`+"\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(ctx, &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancel while Recv is blocked waiting for the next chunk
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := stream.Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Recv() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Recv() returned after %v, want prompt return on cancel", elapsed)
+	}
+}
+
+func TestProvider_Determinism(t *testing.T) {
+	var sent Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		if sent.Stream != nil && *sent.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, `data: {"id":"c1","system_fingerprint":"fp_abc","choices":[{"index":0,"delta":{"content":"4"}}]}`+"\n\n")
+			_, _ = io.WriteString(w, `data: {"id":"c1","system_fingerprint":"fp_abc","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`+"\n\n")
+			_, _ = io.WriteString(w, "data: [DONE]\n\n")
+			return
+		}
+		_, _ = io.WriteString(w, `{"id":"r1","model":"gpt-4o","system_fingerprint":"fp_abc","choices":[{"index":0,"message":{"role":"assistant","content":"4"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	seed := 42
+	req := &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "2+2?"}},
+		Seed:     &seed,
+	}
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Seed == nil || *sent.Seed != 42 {
+		t.Errorf("sent seed = %v, want 42", sent.Seed)
+	}
+	d, ok := provider.GetDeterminism(resp.ProviderMetadata)
+	if !ok || d.Seed == nil || *d.Seed != 42 || d.Fingerprint != "fp_abc" {
+		t.Errorf("Determinism = %+v, %v", d, ok)
+	}
+
+	stream, err := p.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	var last provider.Determinism
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d, ok := provider.GetDeterminism(chunk.ProviderMetadata); ok {
+			last = d
+		}
+	}
+	if last.Seed == nil || *last.Seed != 42 || last.Fingerprint != "fp_abc" {
+		t.Errorf("stream Determinism = %+v", last)
+	}
+}
+
+func TestProvider_RequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_123")
+		_, _ = io.WriteString(w, `{"id":"r1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.ProviderRequestID(); got != "req_123" {
+		t.Errorf("ProviderRequestID() = %q, want req_123", got)
+	}
+}
+
+func TestProvider_IncludeRaw(t *testing.T) {
+	const body = `{"id":"r1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"unmapped_field":1}`
+	var sent []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent, _ = io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	req := &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+	resp, err := p.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Raw != nil {
+		t.Errorf("Raw = %+v, want none without IncludeRaw", resp.Raw)
+	}
+
+	req.IncludeRaw = true
+	resp, err = p.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Raw == nil || string(resp.Raw.Request) != string(sent) || string(resp.Raw.Response) != body {
+		t.Fatalf("Raw = %+v", resp.Raw)
+	}
+
+	marshaled, err := p.(provider.RawCapable).MarshalChatCompletionRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(marshaled) != string(sent) {
+		t.Errorf("MarshalChatCompletionRequest() =\n%s\nwant\n%s", marshaled, sent)
+	}
+}
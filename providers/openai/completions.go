@@ -0,0 +1,80 @@
+package openai
+
+import (
+	"context"
+	"sort"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// CreateTextCompletion creates a legacy text completion. This serves
+// gpt-3.5-turbo-instruct and davinci-002 on OpenAI, and base models on
+// OpenAI-compatible servers such as vLLM.
+func (p *Provider) CreateTextCompletion(ctx context.Context, req *provider.TextCompletionRequest) (*provider.TextCompletionResponse, error) {
+	resp, err := p.client.CreateTextCompletion(ctx, &TextCompletionRequest{
+		Model:            req.Model,
+		Prompt:           req.Prompt,
+		Suffix:           req.Suffix,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		Stop:             req.Stop,
+		N:                req.N,
+		Seed:             req.Seed,
+		PresencePenalty:  req.PresencePenalty,
+		FrequencyPenalty: req.FrequencyPenalty,
+		Echo:             req.Echo,
+		Logprobs:         req.Logprobs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &provider.TextCompletionResponse{
+		ID:       resp.ID,
+		Created:  resp.Created,
+		Model:    resp.Model,
+		Provider: p.Name(),
+		Choices:  make([]provider.TextCompletionChoice, 0, len(resp.Choices)),
+		Usage:    convertUsage(resp.Usage),
+	}
+	for _, choice := range resp.Choices {
+		converted := provider.TextCompletionChoice{
+			Index:    choice.Index,
+			Text:     choice.Text,
+			Logprobs: convertTextLogprobs(choice.Logprobs),
+		}
+		if choice.FinishReason != nil {
+			reason := provider.NormalizeFinishReason(*choice.FinishReason)
+			converted.FinishReason = &reason
+		}
+		result.Choices = append(result.Choices, converted)
+	}
+	return result, nil
+}
+
+// convertTextLogprobs converts legacy parallel-array log probabilities to
+// unified format. A null logprob, as for the first echoed prompt token, is
+// reported as 0. Alternatives are ordered most likely first.
+func convertTextLogprobs(lp *TextLogprobs) *provider.Logprobs {
+	if lp == nil {
+		return nil
+	}
+	result := &provider.Logprobs{Content: make([]provider.TokenLogprob, 0, len(lp.Tokens))}
+	for i, token := range lp.Tokens {
+		converted := provider.TokenLogprob{Token: token}
+		if i < len(lp.TokenLogprobs) && lp.TokenLogprobs[i] != nil {
+			converted.Logprob = *lp.TokenLogprobs[i]
+		}
+		if i < len(lp.TopLogprobs) {
+			for alt, logprob := range lp.TopLogprobs[i] {
+				converted.TopLogprobs = append(converted.TopLogprobs, provider.TopLogprob{Token: alt, Logprob: logprob})
+			}
+			sort.Slice(converted.TopLogprobs, func(a, b int) bool {
+				return converted.TopLogprobs[a].Logprob > converted.TopLogprobs[b].Logprob
+			})
+		}
+		result.Content = append(result.Content, converted)
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestProvider_CreateTextCompletion(t *testing.T) {
+	var got TextCompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(`{
+			"id": "cmpl-1", "object": "text_completion", "created": 1700000000, "model": "gpt-3.5-turbo-instruct",
+			"choices": [{"index": 0, "text": "The sky is blue", "finish_reason": "length", "logprobs": {
+				"tokens": ["The", " sky", " is", " blue"],
+				"token_logprobs": [null, -2.5, -0.3, -0.1],
+				"top_logprobs": [null, {" sky": -2.5, " sun": -1.2}, {" is": -0.3}, {" blue": -0.1}],
+				"text_offset": [0, 3, 7, 10]
+			}}],
+			"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}
+		}`))
+	}))
+	defer server.Close()
+
+	logprobs := 2
+	p := NewProvider("test-key", server.URL, server.Client()).(provider.TextCompletionProvider)
+	resp, err := p.CreateTextCompletion(context.Background(), &provider.TextCompletionRequest{
+		Model:    "gpt-3.5-turbo-instruct",
+		Prompt:   "The sky is",
+		Echo:     true,
+		Logprobs: &logprobs,
+	})
+	if err != nil {
+		t.Fatalf("CreateTextCompletion() error = %v", err)
+	}
+
+	if !got.Echo || got.Logprobs == nil || *got.Logprobs != 2 || got.Prompt != "The sky is" {
+		t.Errorf("request = %+v", got)
+	}
+
+	choice := resp.Choices[0]
+	if choice.Text != "The sky is blue" || *choice.FinishReason != provider.FinishReasonLength || resp.Usage.TotalTokens != 4 {
+		t.Errorf("response = %+v", resp)
+	}
+	tokens := choice.Logprobs.Content
+	if len(tokens) != 4 || tokens[0].Logprob != 0 || tokens[1].Logprob != -2.5 {
+		t.Fatalf("logprobs = %+v", tokens)
+	}
+	if alts := tokens[1].TopLogprobs; len(alts) != 2 || alts[0].Token != " sun" {
+		t.Errorf("alternatives = %+v, want most likely first", alts)
+	}
+}
@@ -0,0 +1,83 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestProvider_Files(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Errorf("ParseMultipartForm failed: %v", err)
+			}
+			f, header, err := r.FormFile("file")
+			if err != nil {
+				t.Errorf("FormFile failed: %v", err)
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"message":"missing file"}}`))
+				return
+			}
+			data, _ := io.ReadAll(f)
+			_ = json.NewEncoder(w).Encode(FileObject{
+				ID:       "file-abc",
+				Object:   "file",
+				Bytes:    int64(len(data)),
+				Filename: header.Filename,
+				Purpose:  r.FormValue("purpose"),
+			})
+		case r.Method == http.MethodGet && r.URL.Path == "/files/file-abc":
+			_ = json.NewEncoder(w).Encode(FileObject{ID: "file-abc", Filename: "notes.txt", Purpose: "user_data"})
+		case r.Method == http.MethodDelete && r.URL.Path == "/files/file-abc":
+			_ = json.NewEncoder(w).Encode(FileDeleteResponse{ID: "file-abc", Deleted: true})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":{"message":"not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, nil)
+	fp, ok := p.(provider.FileProvider)
+	if !ok {
+		t.Fatal("OpenAI provider does not implement provider.FileProvider")
+	}
+
+	ctx := context.Background()
+	file, err := fp.UploadFile(ctx, &provider.FileUploadRequest{
+		Filename: "notes.txt",
+		MIMEType: "text/plain",
+		Data:     []byte("hello"),
+	})
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if file.ID != "file-abc" || file.Bytes != 5 || file.Purpose != "user_data" || file.Provider != "openai" {
+		t.Errorf("file = %+v", file)
+	}
+
+	got, err := fp.GetFile(ctx, "file-abc")
+	if err != nil || got.Filename != "notes.txt" {
+		t.Errorf("GetFile = %+v, %v", got, err)
+	}
+
+	if err := fp.DeleteFile(ctx, "file-abc"); err != nil {
+		t.Errorf("DeleteFile failed: %v", err)
+	}
+
+	if _, err := fp.GetFile(ctx, "missing"); err == nil {
+		t.Error("GetFile should fail for unknown file")
+	}
+}
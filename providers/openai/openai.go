@@ -8,11 +8,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/plexusone/omnillm/provider"
 )
 
+// DefaultTimeout is the HTTP client timeout used when no client is given
+const DefaultTimeout = 30 * time.Second
+
 // Client implements OpenAI API client
 type Client struct {
 	apiKey  string
@@ -26,7 +33,7 @@ func New(apiKey, baseURL string, httpClient *http.Client) *Client {
 		baseURL = "https://api.openai.com/v1"
 	}
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 30 * time.Second}
+		httpClient = &http.Client{Timeout: DefaultTimeout}
 	}
 
 	return &Client{
@@ -51,6 +58,7 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 	}
 
 	req.Stream = boolPtr(false)
+	req.StreamOptions = nil
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
@@ -64,6 +72,8 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	provider.SetIdempotencyKey(httpReq)
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -75,10 +85,18 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 		return nil, c.handleErrorResponse(resp)
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 	var response Response
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	response.RequestID = provider.RequestIDFromHeader(resp.Header)
+	if req.IncludeRaw {
+		response.Raw = &provider.RawExchange{Request: reqBody, Response: body}
+	}
 
 	return &response, nil
 }
@@ -93,6 +111,10 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 	}
 
 	req.Stream = boolPtr(true)
+	if req.StreamOptions == nil {
+		// Report usage in the final chunk, which streams otherwise omit
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
@@ -106,7 +128,9 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	provider.SetIdempotencyKey(httpReq)
 	httpReq.Header.Set("Accept", "text/event-stream")
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -118,12 +142,265 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 		return nil, c.handleErrorResponse(resp)
 	}
 
+	resp.Body = provider.NewContextBody(ctx, resp.Body)
 	return &Stream{
+		ctx:      ctx,
 		response: resp,
 		scanner:  bufio.NewScanner(resp.Body),
 	}, nil
 }
 
+// CreateModeration classifies input text using the OpenAI moderations endpoint
+func (c *Client) CreateModeration(ctx context.Context, req *ModerationRequest) (*ModerationResponse, error) {
+	if len(req.Input) == 0 {
+		return nil, fmt.Errorf("input cannot be empty")
+	}
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/moderations", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	provider.SetIdempotencyKey(httpReq)
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var response ModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// UploadFile uploads a file using the OpenAI files endpoint
+func (c *Client) UploadFile(ctx context.Context, filename, purpose string, data []byte) (*FileObject, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("filename cannot be empty")
+	}
+	if purpose == "" {
+		purpose = "user_data"
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("purpose", purpose); err != nil {
+		return nil, fmt.Errorf("failed to write purpose: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write file data: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/files", &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	provider.SetIdempotencyKey(httpReq)
+
+	var file FileObject
+	if err := c.doJSON(httpReq, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// GetFile retrieves file metadata from the OpenAI files endpoint
+func (c *Client) GetFile(ctx context.Context, fileID string) (*FileObject, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/files/"+url.PathEscape(fileID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var file FileObject
+	if err := c.doJSON(httpReq, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// DeleteFile deletes a file using the OpenAI files endpoint
+func (c *Client) DeleteFile(ctx context.Context, fileID string) (*FileDeleteResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/files/"+url.PathEscape(fileID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var result FileDeleteResponse
+	if err := c.doJSON(httpReq, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListModels lists the models available to the API key
+func (c *Client) ListModels(ctx context.Context) (*ModelList, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var list ModelList
+	if err := c.doJSON(httpReq, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// CreateResponse creates a model response using the OpenAI Responses API
+func (c *Client) CreateResponse(ctx context.Context, req *ResponsesRequest) (*ResponsesResponse, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+	if len(req.Input) == 0 {
+		return nil, fmt.Errorf("input cannot be empty")
+	}
+
+	httpReq, err := c.newJSONRequest(ctx, "/responses", req)
+	if err != nil {
+		return nil, err
+	}
+	provider.SetHeaders(httpReq, req.Headers)
+
+	var response ResponsesResponse
+	if err := c.doJSON(httpReq, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateTextCompletion creates a legacy text completion
+func (c *Client) CreateTextCompletion(ctx context.Context, req *TextCompletionRequest) (*TextCompletionResponse, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+
+	var response TextCompletionResponse
+	if err := c.postJSON(ctx, "/completions", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// CreateVectorStore creates a vector store
+func (c *Client) CreateVectorStore(ctx context.Context, req *VectorStoreCreateRequest) (*VectorStoreObject, error) {
+	var store VectorStoreObject
+	if err := c.postJSON(ctx, "/vector_stores", req, &store); err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// GetVectorStore retrieves a vector store
+func (c *Client) GetVectorStore(ctx context.Context, storeID string) (*VectorStoreObject, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/vector_stores/"+url.PathEscape(storeID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var store VectorStoreObject
+	if err := c.doJSON(httpReq, &store); err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// AddVectorStoreFile attaches an uploaded file to a vector store
+func (c *Client) AddVectorStoreFile(ctx context.Context, storeID, fileID string) error {
+	var result map[string]any
+	return c.postJSON(ctx, "/vector_stores/"+url.PathEscape(storeID)+"/files", &VectorStoreFileRequest{FileID: fileID}, &result)
+}
+
+// DeleteVectorStore deletes a vector store
+func (c *Client) DeleteVectorStore(ctx context.Context, storeID string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/vector_stores/"+url.PathEscape(storeID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	var result map[string]any
+	return c.doJSON(httpReq, &result)
+}
+
+// postJSON sends a JSON body to the given path and decodes the JSON response into out
+func (c *Client) postJSON(ctx context.Context, path string, body, out any) error {
+	httpReq, err := c.newJSONRequest(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(httpReq, out)
+}
+
+// newJSONRequest builds an authenticated POST request with a JSON body
+func (c *Client) newJSONRequest(ctx context.Context, path string, body any) (*http.Request, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	provider.SetIdempotencyKey(httpReq)
+	return httpReq, nil
+}
+
+// doJSON sends a request and decodes a JSON response body into out
+func (c *Client) doJSON(httpReq *http.Request, out any) error {
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
 // Close closes the client
 func (c *Client) Close() error {
 	return nil
@@ -133,7 +410,7 @@ func (c *Client) Close() error {
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read error response")
+		return provider.NewAPIErrorFromResponse("openai", resp, "failed to read error response", "", "")
 	}
 
 	var errorResp struct {
@@ -141,28 +418,41 @@ func (c *Client) handleErrorResponse(resp *http.Response) error {
 			Message string `json:"message"`
 			Type    string `json:"type"`
 			Code    string `json:"code"`
+			Param   string `json:"param"`
 		} `json:"error"`
 	}
 
-	if err := json.Unmarshal(body, &errorResp); err != nil {
-		return fmt.Errorf("API error: %s", string(body))
+	if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.Error.Message == "" {
+		return provider.NewAPIErrorFromResponse("openai", resp, "OpenAI API error: "+string(body), "", "").WithBody(body)
 	}
 
-	return fmt.Errorf("OpenAI API error: %s", errorResp.Error.Message)
+	apiErr := provider.NewAPIErrorFromResponse("openai", resp, "OpenAI API error: "+errorResp.Error.Message,
+		errorResp.Error.Type, errorResp.Error.Code).WithBody(body)
+	apiErr.Param = errorResp.Error.Param
+	return apiErr
 }
 
 // Stream implements streaming for OpenAI
 type Stream struct {
+	ctx      context.Context
 	response *http.Response
 	scanner  *bufio.Scanner
 	closed   bool
 }
 
+// RequestID returns the provider's ID for the request, or "" if it sent none
+func (s *Stream) RequestID() string {
+	return provider.RequestIDFromHeader(s.response.Header)
+}
+
 // Recv receives the next chunk from the stream
 func (s *Stream) Recv() (*StreamChunk, error) {
 	if s.closed {
 		return nil, fmt.Errorf("stream is closed")
 	}
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	for s.scanner.Scan() {
 		line := s.scanner.Text()
@@ -180,12 +470,18 @@ func (s *Stream) Recv() (*StreamChunk, error) {
 			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 				continue
 			}
+			if chunk.Error != nil {
+				return nil, provider.NewStreamAPIError("openai", chunk.Error)
+			}
 
 			return &chunk, nil
 		}
 	}
 
 	if err := s.scanner.Err(); err != nil {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("stream error: %w", err)
 	}
 
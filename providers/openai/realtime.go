@@ -0,0 +1,261 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// realtimeSampleRate is the sample rate of Realtime API PCM audio, in and out
+const realtimeSampleRate = 24000
+
+// realtimeTranscriptionModel transcribes the user's audio when requested
+const realtimeTranscriptionModel = "gpt-4o-transcribe"
+
+// realtimeURL returns the WebSocket URL of the Realtime API for a model
+func (c *Client) realtimeURL(model string) (string, error) {
+	u, err := url.Parse(c.baseURL + "/realtime")
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	u.RawQuery = url.Values{"model": {model}}.Encode()
+	return u.String(), nil
+}
+
+// ConnectRealtime opens a WebSocket connection to the Realtime API
+func (c *Client) ConnectRealtime(ctx context.Context, model string) (*websocket.Conn, error) {
+	if model == "" {
+		return nil, fmt.Errorf("model cannot be empty")
+	}
+	endpoint, err := c.realtimeURL(model)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.apiKey)
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, endpoint, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			defer resp.Body.Close()
+			return nil, c.handleErrorResponse(resp)
+		}
+		return nil, fmt.Errorf("realtime connection failed: %w", err)
+	}
+	return conn, nil
+}
+
+// ConnectRealtime opens a Realtime API session configured for the unified config
+func (p *Provider) ConnectRealtime(ctx context.Context, config *provider.RealtimeConfig) (provider.RealtimeSession, error) {
+	conn, err := p.client.ConnectRealtime(ctx, config.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	session := newRealtimeSession(conn)
+	if err := session.send(RealtimeClientEvent{Type: "session.update", Session: convertRealtimeConfig(config)}); err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	go session.readLoop()
+	return session, nil
+}
+
+// convertRealtimeConfig converts a unified realtime config to a session.update payload
+func convertRealtimeConfig(config *provider.RealtimeConfig) *RealtimeSessionConfig {
+	pcm := &RealtimeAudioFormat{Type: "audio/pcm", Rate: realtimeSampleRate}
+	session := &RealtimeSessionConfig{
+		Type:             "realtime",
+		Instructions:     config.Instructions,
+		OutputModalities: config.Modalities,
+		Audio: &RealtimeAudio{
+			Input:  &RealtimeAudioInput{Format: pcm},
+			Output: &RealtimeAudioOutput{Format: pcm, Voice: config.Voice},
+		},
+	}
+	if config.InputTranscription {
+		session.Audio.Input.Transcription = &RealtimeTranscription{Model: realtimeTranscriptionModel}
+	}
+	for _, tool := range config.Tools {
+		session.Tools = append(session.Tools, RealtimeTool{
+			Type:        "function",
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+	return session
+}
+
+// RealtimeSession is a Realtime API session
+type RealtimeSession struct {
+	conn      *websocket.Conn
+	writeMu   sync.Mutex
+	events    chan provider.RealtimeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newRealtimeSession(conn *websocket.Conn) *RealtimeSession {
+	return &RealtimeSession{
+		conn:   conn,
+		events: make(chan provider.RealtimeEvent, 64),
+		done:   make(chan struct{}),
+	}
+}
+
+// SendAudio appends 24kHz PCM audio to the input buffer. Server voice
+// activity detection commits the buffer and starts a response.
+func (s *RealtimeSession) SendAudio(data []byte) error {
+	return s.send(RealtimeClientEvent{Type: "input_audio_buffer.append", Audio: base64.StdEncoding.EncodeToString(data)})
+}
+
+// SendText adds a user message to the conversation and requests a response
+func (s *RealtimeSession) SendText(text string) error {
+	item := &RealtimeItem{Type: "message", Role: "user", Content: []RealtimeContent{{Type: "input_text", Text: text}}}
+	if err := s.send(RealtimeClientEvent{Type: "conversation.item.create", Item: item}); err != nil {
+		return err
+	}
+	return s.send(RealtimeClientEvent{Type: "response.create"})
+}
+
+// SendToolResult adds a function call's output to the conversation and
+// requests a response
+func (s *RealtimeSession) SendToolResult(callID, output string) error {
+	item := &RealtimeItem{Type: "function_call_output", CallID: callID, Output: output}
+	if err := s.send(RealtimeClientEvent{Type: "conversation.item.create", Item: item}); err != nil {
+		return err
+	}
+	return s.send(RealtimeClientEvent{Type: "response.create"})
+}
+
+// Events returns the channel of events from the model
+func (s *RealtimeSession) Events() <-chan provider.RealtimeEvent {
+	return s.events
+}
+
+// Close ends the session
+func (s *RealtimeSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.writeMu.Lock()
+		_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		s.writeMu.Unlock()
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// send writes a client event
+func (s *RealtimeSession) send(event RealtimeClientEvent) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.conn.WriteJSON(event); err != nil {
+		return fmt.Errorf("realtime send failed: %w", err)
+	}
+	return nil
+}
+
+// readLoop delivers server events until the connection ends
+func (s *RealtimeSession) readLoop() {
+	defer close(s.events)
+	for {
+		var event RealtimeServerEvent
+		if err := s.conn.ReadJSON(&event); err != nil {
+			select {
+			case <-s.done:
+			default:
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					s.emit(provider.RealtimeEvent{Type: provider.RealtimeEventError, Err: fmt.Errorf("realtime receive failed: %w", err)})
+				}
+			}
+			return
+		}
+		if converted, ok := convertRealtimeEvent(&event); ok && !s.emit(converted) {
+			return
+		}
+	}
+}
+
+// emit delivers an event, returning false if the session was closed first
+func (s *RealtimeSession) emit(event provider.RealtimeEvent) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// convertRealtimeEvent converts a server event to a unified event. Events
+// with no unified counterpart, such as session and buffer bookkeeping, are
+// skipped.
+func convertRealtimeEvent(event *RealtimeServerEvent) (provider.RealtimeEvent, bool) {
+	switch event.Type {
+	case "response.output_text.delta":
+		return provider.RealtimeEvent{Type: provider.RealtimeEventText, Text: event.Delta}, true
+
+	case "response.output_audio.delta":
+		data, err := base64.StdEncoding.DecodeString(event.Delta)
+		if err != nil {
+			return provider.RealtimeEvent{Type: provider.RealtimeEventError, Err: fmt.Errorf("invalid realtime audio: %w", err)}, true
+		}
+		return provider.RealtimeEvent{Type: provider.RealtimeEventAudio, Audio: data}, true
+
+	case "response.output_audio_transcript.delta":
+		return provider.RealtimeEvent{Type: provider.RealtimeEventTranscript, Text: event.Delta}, true
+
+	case "conversation.item.input_audio_transcription.completed":
+		return provider.RealtimeEvent{Type: provider.RealtimeEventInputTranscript, Text: event.Transcript}, true
+
+	case "response.output_item.done":
+		if event.Item == nil || event.Item.Type != "function_call" {
+			return provider.RealtimeEvent{}, false
+		}
+		return provider.RealtimeEvent{Type: provider.RealtimeEventToolCall, ToolCall: &provider.ToolCall{
+			ID:       event.Item.CallID,
+			Type:     "function",
+			Function: provider.ToolFunction{Name: event.Item.Name, Arguments: event.Item.Arguments},
+		}}, true
+
+	case "input_audio_buffer.speech_started":
+		return provider.RealtimeEvent{Type: provider.RealtimeEventInterrupted}, true
+
+	case "response.done":
+		result := provider.RealtimeEvent{Type: provider.RealtimeEventTurnDone}
+		if event.Response != nil && event.Response.Usage != nil {
+			usage := event.Response.Usage
+			result.Usage = &provider.Usage{
+				PromptTokens:     usage.InputTokens,
+				CompletionTokens: usage.OutputTokens,
+				TotalTokens:      usage.TotalTokens,
+			}
+		}
+		return result, true
+
+	case "error":
+		streamErr := &provider.StreamError{Type: "api_error", Message: "realtime error"}
+		if event.Error != nil {
+			streamErr = event.Error
+		}
+		return provider.RealtimeEvent{Type: provider.RealtimeEventError, Err: provider.NewStreamAPIError("openai", streamErr)}, true
+
+	default:
+		return provider.RealtimeEvent{}, false
+	}
+}
@@ -0,0 +1,103 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestProvider_ConnectRealtime(t *testing.T) {
+	received := make(chan RealtimeClientEvent, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/realtime" || r.URL.Query().Get("model") != "gpt-realtime" {
+			t.Errorf("connected to %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("missing API key header")
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		for range 3 { // session.update, conversation.item.create, response.create
+			var event RealtimeClientEvent
+			if err := conn.ReadJSON(&event); err != nil {
+				t.Errorf("read: %v", err)
+				return
+			}
+			received <- event
+		}
+		for _, event := range []string{
+			`{"type":"session.updated"}`,
+			`{"type":"response.output_audio.delta","delta":"AAE="}`,
+			`{"type":"response.output_audio_transcript.delta","delta":"Hi"}`,
+			`{"type":"response.output_item.done","item":{"type":"function_call","call_id":"call_1","name":"lookup","arguments":"{}"}}`,
+			`{"type":"response.done","response":{"status":"completed","usage":{"total_tokens":9,"input_tokens":5,"output_tokens":4}}}`,
+			`{"type":"error","error":{"type":"invalid_request_error","message":"bad event"}}`,
+		} {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(event))
+		}
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client()).(provider.RealtimeProvider)
+	session, err := p.ConnectRealtime(context.Background(), &provider.RealtimeConfig{
+		Model:              "gpt-realtime",
+		Instructions:       "Be brief.",
+		Voice:              "alloy",
+		InputTranscription: true,
+		Tools:              []provider.Tool{{Type: "function", Function: provider.ToolSpec{Name: "lookup"}}},
+	})
+	if err != nil {
+		t.Fatalf("ConnectRealtime() error = %v", err)
+	}
+	defer session.Close()
+
+	if err := session.SendText("hello"); err != nil {
+		t.Fatalf("SendText() error = %v", err)
+	}
+
+	update := <-received
+	if update.Type != "session.update" || update.Session.Instructions != "Be brief." || update.Session.Audio.Output.Voice != "alloy" ||
+		update.Session.Audio.Input.Transcription == nil || len(update.Session.Tools) != 1 {
+		t.Errorf("session.update = %+v", update.Session)
+	}
+	if item := <-received; item.Type != "conversation.item.create" || item.Item.Content[0].Text != "hello" {
+		t.Errorf("item event = %+v", item)
+	}
+	if create := <-received; create.Type != "response.create" {
+		t.Errorf("create event = %+v", create)
+	}
+
+	var events []provider.RealtimeEvent
+	for event := range session.Events() {
+		events = append(events, event)
+	}
+	want := []provider.RealtimeEventType{
+		provider.RealtimeEventAudio,
+		provider.RealtimeEventTranscript,
+		provider.RealtimeEventToolCall,
+		provider.RealtimeEventTurnDone,
+		provider.RealtimeEventError,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, typ := range want {
+		if events[i].Type != typ {
+			t.Errorf("event %d type = %q, want %q", i, events[i].Type, typ)
+		}
+	}
+	if len(events[0].Audio) != 2 || events[2].ToolCall.ID != "call_1" || events[3].Usage.TotalTokens != 9 {
+		t.Errorf("events = %+v", events)
+	}
+}
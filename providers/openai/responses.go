@@ -0,0 +1,218 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// hasHostedTools reports whether a request enables provider-hosted tools that
+// require the Responses API
+func hasHostedTools(tools []provider.Tool) bool {
+	for _, tool := range tools {
+		if tool.Type == provider.ToolTypeFileSearch {
+			return true
+		}
+	}
+	return false
+}
+
+// createResponseCompletion serves a chat completion through the Responses API
+func (p *Provider) createResponseCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	responsesReq, err := convertResponsesRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.client.CreateResponse(ctx, responsesReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResponsesResponse(resp), nil
+}
+
+// convertResponsesRequest converts a unified request to Responses API format
+func convertResponsesRequest(req *provider.ChatCompletionRequest) (*ResponsesRequest, error) {
+	responsesReq := &ResponsesRequest{
+		Model:           req.Model,
+		MaxOutputTokens: req.MaxTokens,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		User:            req.User,
+		ToolChoice:      req.ToolChoice,
+		Headers:         req.Headers,
+	}
+
+	for _, tool := range req.Tools {
+		switch tool.Type {
+		case provider.ToolTypeFileSearch:
+			if tool.FileSearch == nil {
+				return nil, fmt.Errorf("file_search tool is missing configuration")
+			}
+			responsesReq.Tools = append(responsesReq.Tools, ResponseTool{
+				Type:           "file_search",
+				VectorStoreIDs: tool.FileSearch.VectorStoreIDs,
+				MaxNumResults:  tool.FileSearch.MaxResults,
+			})
+		default:
+			responsesReq.Tools = append(responsesReq.Tools, ResponseTool{
+				Type:        "function",
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			})
+		}
+	}
+
+	for _, msg := range req.Messages {
+		switch {
+		case msg.Role == provider.RoleSystem:
+			if responsesReq.Instructions != "" {
+				responsesReq.Instructions += "\n\n"
+			}
+			responsesReq.Instructions += msg.Content
+
+		case msg.Role == provider.RoleTool:
+			var callID string
+			if msg.ToolCallID != nil {
+				callID = *msg.ToolCallID
+			}
+			responsesReq.Input = append(responsesReq.Input, ResponseInputItem{
+				Type:   "function_call_output",
+				CallID: callID,
+				Output: msg.Content,
+			})
+
+		default:
+			if msg.Content != "" || len(msg.Parts) > 0 {
+				item := ResponseInputItem{Type: "message", Role: string(msg.Role), Content: msg.Content}
+				if len(msg.Parts) > 0 {
+					content, err := convertResponseContent(msg)
+					if err != nil {
+						return nil, err
+					}
+					item.Content = content
+				}
+				responsesReq.Input = append(responsesReq.Input, item)
+			}
+			for _, tc := range msg.ToolCalls {
+				responsesReq.Input = append(responsesReq.Input, ResponseInputItem{
+					Type:      "function_call",
+					CallID:    tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				})
+			}
+		}
+	}
+
+	return responsesReq, nil
+}
+
+// convertResponseContent converts multi-part message content to Responses API input content
+func convertResponseContent(msg provider.Message) ([]ResponseInputContent, error) {
+	var content []ResponseInputContent
+	if msg.Content != "" {
+		content = append(content, ResponseInputContent{Type: "input_text", Text: msg.Content})
+	}
+	for _, part := range msg.Parts {
+		converted, err := convertContentPart(part)
+		if err != nil {
+			return nil, err
+		}
+		if converted.InputAudio != nil {
+			return nil, fmt.Errorf("audio content parts are not supported with file_search tools")
+		}
+		if converted.File == nil {
+			content = append(content, ResponseInputContent{Type: "input_text", Text: converted.Text})
+			continue
+		}
+		content = append(content, ResponseInputContent{
+			Type:     "input_file",
+			FileID:   converted.File.FileID,
+			Filename: converted.File.Filename,
+			FileData: converted.File.FileData,
+		})
+	}
+	return content, nil
+}
+
+// convertResponsesResponse converts a Responses API response to unified format.
+// File citations are surfaced as citation annotations on the choice.
+func convertResponsesResponse(resp *ResponsesResponse) *provider.ChatCompletionResponse {
+	var text strings.Builder
+	var toolCalls []provider.ToolCall
+	var annotations []provider.Annotation
+
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "message":
+			for _, content := range item.Content {
+				if content.Type != "output_text" {
+					continue
+				}
+				text.WriteString(content.Text)
+				for _, a := range content.Annotations {
+					if a.Type != "file_citation" {
+						continue
+					}
+					annotations = append(annotations, provider.Annotation{
+						Type:   "citation",
+						Source: "openai.file_search",
+						Label:  a.Filename,
+						Data: map[string]any{
+							"file_id": a.FileID,
+							"index":   a.Index,
+						},
+					})
+				}
+			}
+		case "function_call":
+			toolCalls = append(toolCalls, provider.ToolCall{
+				ID:   item.CallID,
+				Type: "function",
+				Function: provider.ToolFunction{
+					Name:      item.Name,
+					Arguments: item.Arguments,
+				},
+			})
+		}
+	}
+
+	finishReason := "stop"
+	switch {
+	case len(toolCalls) > 0:
+		finishReason = "tool_calls"
+	case resp.Status == "incomplete":
+		finishReason = "length"
+	}
+
+	return &provider.ChatCompletionResponse{
+		ID:      resp.ID,
+		Object:  "chat.completion",
+		Created: resp.CreatedAt,
+		Model:   resp.Model,
+		Choices: []provider.ChatCompletionChoice{
+			{
+				Index: 0,
+				Message: provider.Message{
+					Role:      provider.RoleAssistant,
+					Content:   text.String(),
+					ToolCalls: toolCalls,
+				},
+				FinishReason: &finishReason,
+				Annotations:  annotations,
+			},
+		},
+		Usage: provider.Usage{
+			PromptTokens:            resp.Usage.InputTokens,
+			CompletionTokens:        resp.Usage.OutputTokens,
+			TotalTokens:             resp.Usage.TotalTokens,
+			PromptTokensDetails:     convertPromptTokensDetails(resp.Usage.InputTokensDetails),
+			CompletionTokensDetails: convertCompletionTokensDetails(resp.Usage.OutputTokensDetails),
+		},
+	}
+}
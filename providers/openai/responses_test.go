@@ -0,0 +1,145 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestConvertResponsesRequest(t *testing.T) {
+	callID := "call_1"
+	req := &provider.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []provider.Message{
+			{Role: provider.RoleSystem, Content: "Answer from the handbook"},
+			{Role: provider.RoleUser, Content: "What is the PTO policy?"},
+			{Role: provider.RoleAssistant, ToolCalls: []provider.ToolCall{{ID: callID, Type: "function", Function: provider.ToolFunction{Name: "lookup", Arguments: "{}"}}}},
+			{Role: provider.RoleTool, Content: "ok", ToolCallID: &callID},
+		},
+		Tools: []provider.Tool{
+			{Type: provider.ToolTypeFileSearch, FileSearch: &provider.FileSearchTool{VectorStoreIDs: []string{"vs_1"}}},
+			{Type: provider.ToolTypeFunction, Function: provider.ToolSpec{Name: "lookup"}},
+		},
+	}
+
+	got, err := convertResponsesRequest(req)
+	if err != nil {
+		t.Fatalf("convertResponsesRequest() error = %v", err)
+	}
+	if got.Instructions != "Answer from the handbook" {
+		t.Errorf("Instructions = %q", got.Instructions)
+	}
+	if len(got.Input) != 3 {
+		t.Fatalf("Input = %d items, want 3", len(got.Input))
+	}
+	if got.Input[1].Type != "function_call" || got.Input[2].Type != "function_call_output" {
+		t.Errorf("unexpected input items: %+v", got.Input)
+	}
+	if got.Tools[0].Type != "file_search" || got.Tools[0].VectorStoreIDs[0] != "vs_1" {
+		t.Errorf("unexpected file_search tool: %+v", got.Tools[0])
+	}
+	if got.Tools[1].Type != "function" || got.Tools[1].Name != "lookup" {
+		t.Errorf("unexpected function tool: %+v", got.Tools[1])
+	}
+}
+
+func TestProvider_FileSearchUsesResponsesAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/responses" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(ResponsesResponse{
+			ID:     "resp_1",
+			Model:  "gpt-4o",
+			Status: "completed",
+			Output: []ResponseOutputItem{
+				{Type: "file_search_call", ID: "fs_1"},
+				{Type: "message", Role: "assistant", Content: []ResponseOutputContent{{
+					Type:        "output_text",
+					Text:        "20 days.",
+					Annotations: []ResponseAnnotation{{Type: "file_citation", FileID: "file-1", Filename: "handbook.pdf", Index: 7}},
+				}}},
+			},
+			Usage: ResponseUsage{InputTokens: 10, OutputTokens: 3, TotalTokens: 13},
+		})
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "PTO?"}},
+		Tools:    []provider.Tool{{Type: provider.ToolTypeFileSearch, FileSearch: &provider.FileSearchTool{VectorStoreIDs: []string{"vs_1"}}}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	choice := resp.Choices[0]
+	if choice.Message.Content != "20 days." {
+		t.Errorf("Content = %q", choice.Message.Content)
+	}
+	if len(choice.Annotations) != 1 || choice.Annotations[0].Type != "citation" || choice.Annotations[0].Label != "handbook.pdf" {
+		t.Errorf("unexpected annotations: %+v", choice.Annotations)
+	}
+	if resp.Usage.TotalTokens != 13 {
+		t.Errorf("TotalTokens = %d, want 13", resp.Usage.TotalTokens)
+	}
+}
+
+func TestProvider_FileSearchStreamUnsupported(t *testing.T) {
+	p := NewProvider("test-key", "http://127.0.0.1:0", nil)
+	_, err := p.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "PTO?"}},
+		Tools:    []provider.Tool{{Type: provider.ToolTypeFileSearch, FileSearch: &provider.FileSearchTool{VectorStoreIDs: []string{"vs_1"}}}},
+	})
+	var capErr *provider.CapabilityError
+	if !errors.As(err, &capErr) || !errors.Is(err, provider.ErrInvalidRequest) {
+		t.Fatalf("error = %v, want a CapabilityError matching ErrInvalidRequest", err)
+	}
+}
+
+func TestProvider_VectorStores(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/vector_stores":
+			_ = json.NewEncoder(w).Encode(VectorStoreObject{ID: "vs_1", Name: "docs", Status: "completed", FileCounts: VectorStoreFileCount{Total: 1}})
+		case r.Method == http.MethodPost && r.URL.Path == "/vector_stores/vs_1/files":
+			var body VectorStoreFileRequest
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body.FileID != "file-2" {
+				t.Errorf("file_id = %q, want file-2", body.FileID)
+			}
+			_, _ = w.Write([]byte(`{"id":"file-2","object":"vector_store.file"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/vector_stores/vs_1":
+			_, _ = w.Write([]byte(`{"id":"vs_1","deleted":true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client()).(provider.VectorStoreProvider)
+	ctx := context.Background()
+
+	store, err := p.CreateVectorStore(ctx, &provider.VectorStoreRequest{Name: "docs", FileIDs: []string{"file-1"}})
+	if err != nil {
+		t.Fatalf("CreateVectorStore() error = %v", err)
+	}
+	if store.ID != "vs_1" || store.Provider != "openai" || store.FileCount != 1 {
+		t.Errorf("unexpected store: %+v", store)
+	}
+	if err := p.AddVectorStoreFile(ctx, "vs_1", "file-2"); err != nil {
+		t.Fatalf("AddVectorStoreFile() error = %v", err)
+	}
+	if err := p.DeleteVectorStore(ctx, "vs_1"); err != nil {
+		t.Fatalf("DeleteVectorStore() error = %v", err)
+	}
+}
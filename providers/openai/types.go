@@ -1,5 +1,11 @@
 package openai
 
+import (
+	"encoding/json"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
 // Request represents an OpenAI chat completion request
 type Request struct {
 	Model            string          `json:"model"`
@@ -20,6 +26,54 @@ type Request struct {
 	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
 	Logprobs         *bool           `json:"logprobs,omitempty"`
 	TopLogprobs      *int            `json:"top_logprobs,omitempty"`
+	StreamOptions    *StreamOptions  `json:"stream_options,omitempty"` // Streaming only
+
+	// Options from ChatCompletionRequest.ProviderOptions
+	ParallelToolCalls *bool             `json:"parallel_tool_calls,omitempty"`
+	ReasoningEffort   string            `json:"reasoning_effort,omitempty"`
+	ServiceTier       string            `json:"service_tier,omitempty"`
+	Store             *bool             `json:"store,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	Modalities        []string          `json:"modalities,omitempty"`
+	Audio             *AudioOptions     `json:"audio,omitempty"`
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
+
+	// IncludeRaw keeps the request and response bodies in Response.Raw
+	IncludeRaw bool `json:"-"`
+}
+
+// Options are the OpenAI-specific request options, passed in
+// ChatCompletionRequest.ProviderOptions under the "openai" key
+type Options struct {
+	// ParallelToolCalls set to false makes the model call at most one tool per turn
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
+
+	// ReasoningEffort is "low", "medium", or "high" for reasoning models
+	ReasoningEffort string `json:"reasoning_effort,omitempty"`
+
+	// ServiceTier selects the processing tier, e.g. "auto", "default", or "flex"
+	ServiceTier string `json:"service_tier,omitempty"`
+
+	// Store keeps the completion for use in OpenAI evals and distillation
+	Store *bool `json:"store,omitempty"`
+
+	// Metadata tags stored completions
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Modalities lists the output types to generate, e.g. ["text", "audio"]
+	// for audio models such as gpt-4o-audio-preview
+	Modalities []string `json:"modalities,omitempty"`
+
+	// Audio configures spoken output; required when Modalities includes "audio"
+	Audio *AudioOptions `json:"audio,omitempty"`
+}
+
+// AudioOptions configures the audio generated by audio models
+type AudioOptions struct {
+	Voice  string `json:"voice"`  // e.g., "alloy", "ash", "coral"
+	Format string `json:"format"` // "wav", "mp3", "flac", "opus", or "pcm16"; streaming requires "pcm16"
 }
 
 // Tool represents a tool that can be called
@@ -37,6 +91,7 @@ type ToolSpec struct {
 
 // ToolCall represents a tool function call
 type ToolCall struct {
+	Index    *int         `json:"index,omitempty"`
 	ID       string       `json:"id"`
 	Type     string       `json:"type"`
 	Function ToolFunction `json:"function"`
@@ -50,55 +105,489 @@ type ToolFunction struct {
 
 // ResponseFormat specifies the format of the response
 type ResponseFormat struct {
-	Type string `json:"type"` // "text" or "json_object"
+	Type       string      `json:"type"` // "text", "json_object", or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
 }
 
-// Message represents a chat message
+// JSONSchema configures structured outputs
+type JSONSchema struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema"`
+	Strict      *bool  `json:"strict,omitempty"`
+}
+
+// Message represents a chat message.
+// When Parts is set, content is sent as an array of content parts
+// with Content (if any) as the leading text part.
 type Message struct {
-	Role       string     `json:"role"`
-	Content    string     `json:"content"`
-	Name       *string    `json:"name,omitempty"`
-	ToolCallID *string    `json:"tool_call_id,omitempty"`
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	Role       string        `json:"role"`
+	Content    string        `json:"content"`
+	Name       *string       `json:"name,omitempty"`
+	ToolCallID *string       `json:"tool_call_id,omitempty"`
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`
+	Audio      *MessageAudio `json:"audio,omitempty"`
+	Parts      []ContentPart `json:"-"`
+}
+
+// MessageAudio is audio generated by the model. Requests reference earlier
+// audio by ID only.
+type MessageAudio struct {
+	ID         string `json:"id"`
+	Data       string `json:"data,omitempty"` // Base64-encoded
+	Transcript string `json:"transcript,omitempty"`
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+}
+
+// ContentPart represents a part of multi-part message content
+type ContentPart struct {
+	Type       string      `json:"type"` // "text", "file", or "input_audio"
+	Text       string      `json:"text,omitempty"`
+	File       *FileInput  `json:"file,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+}
+
+// FileInput references a file by ID or provides it inline as a data URL
+type FileInput struct {
+	FileID   string `json:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+}
+
+// InputAudio provides audio inline in a message
+type InputAudio struct {
+	Data   string `json:"data"`   // Base64-encoded
+	Format string `json:"format"` // "wav" or "mp3"
+}
+
+// MarshalJSON encodes the message content as a string or as content parts
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(message(m))
+	}
+
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: m.Content})
+	}
+	parts = append(parts, m.Parts...)
+
+	return json.Marshal(struct {
+		message
+		Content []ContentPart `json:"content"`
+	}{message(m), parts})
+}
+
+// StreamOptions configures a streaming response
+type StreamOptions struct {
+	// IncludeUsage adds a final chunk, with no choices, reporting the usage
+	// for the whole request
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // Response represents an OpenAI chat completion response
 type Response struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
+	ID                string   `json:"id"`
+	Object            string   `json:"object"`
+	Created           int64    `json:"created"`
+	Model             string   `json:"model"`
+	SystemFingerprint *string  `json:"system_fingerprint,omitempty"`
+	Choices           []Choice `json:"choices"`
+	Usage             Usage    `json:"usage"`
+
+	RequestID string `json:"-"` // From the x-request-id or request-id response header
+
+	Raw *provider.RawExchange `json:"-"` // Set when the request has IncludeRaw
 }
 
 // Choice represents a choice in the response
 type Choice struct {
-	Index        int     `json:"index"`
-	Message      Message `json:"message"`
-	FinishReason *string `json:"finish_reason"`
+	Index        int       `json:"index"`
+	Message      Message   `json:"message"`
+	FinishReason *string   `json:"finish_reason"`
+	Logprobs     *Logprobs `json:"logprobs,omitempty"`
 }
 
 // Usage represents token usage information
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt tokens
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+	AudioTokens  int `json:"audio_tokens"`
+}
+
+// CompletionTokensDetails breaks down completion tokens
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+	AudioTokens     int `json:"audio_tokens"`
 }
 
 // StreamChunk represents a chunk in streaming response
 type StreamChunk struct {
-	ID      string         `json:"id"`
-	Object  string         `json:"object"`
-	Created int64          `json:"created"`
-	Model   string         `json:"model"`
-	Choices []StreamChoice `json:"choices"`
-	Usage   *Usage         `json:"usage,omitempty"`
+	ID                string         `json:"id"`
+	Object            string         `json:"object"`
+	Created           int64          `json:"created"`
+	Model             string         `json:"model"`
+	SystemFingerprint *string        `json:"system_fingerprint,omitempty"`
+	Choices           []StreamChoice `json:"choices"`
+	Usage             *Usage         `json:"usage,omitempty"`
+
+	// Error is set instead of the other fields when the stream fails mid-response
+	Error *provider.StreamError `json:"error,omitempty"`
 }
 
 // StreamChoice represents a choice in streaming response
 type StreamChoice struct {
-	Index        int      `json:"index"`
-	Delta        *Message `json:"delta,omitempty"`
-	FinishReason *string  `json:"finish_reason"`
+	Index        int       `json:"index"`
+	Delta        *Message  `json:"delta,omitempty"`
+	FinishReason *string   `json:"finish_reason"`
+	Logprobs     *Logprobs `json:"logprobs,omitempty"`
+}
+
+// ModerationRequest represents an OpenAI moderation request
+type ModerationRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model,omitempty"`
+}
+
+// ModerationResponse represents an OpenAI moderation response
+type ModerationResponse struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+}
+
+// ModerationResult represents the moderation result for a single input
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// ModelList is the response from the OpenAI models endpoint
+type ModelList struct {
+	Object string        `json:"object"`
+	Data   []ModelObject `json:"data"`
+}
+
+// ModelObject describes an OpenAI model
+type ModelObject struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// FileObject represents an OpenAI file object
+type FileObject struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at,omitempty"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status,omitempty"`
+}
+
+// FileDeleteResponse represents the response to an OpenAI file deletion
+type FileDeleteResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
+// VectorStoreCreateRequest represents an OpenAI vector store creation request
+type VectorStoreCreateRequest struct {
+	Name    string   `json:"name,omitempty"`
+	FileIDs []string `json:"file_ids,omitempty"`
+}
+
+// VectorStoreObject represents an OpenAI vector store
+type VectorStoreObject struct {
+	ID         string               `json:"id"`
+	Object     string               `json:"object"`
+	CreatedAt  int64                `json:"created_at"`
+	Name       string               `json:"name"`
+	Status     string               `json:"status"`
+	FileCounts VectorStoreFileCount `json:"file_counts"`
+}
+
+// VectorStoreFileCount represents file processing counts for a vector store
+type VectorStoreFileCount struct {
+	InProgress int `json:"in_progress"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Cancelled  int `json:"cancelled"`
+	Total      int `json:"total"`
+}
+
+// VectorStoreFileRequest represents a request to attach a file to a vector store
+type VectorStoreFileRequest struct {
+	FileID string `json:"file_id"`
+}
+
+// ResponsesRequest represents an OpenAI Responses API request. The Responses
+// API is used for requests that enable provider-hosted tools such as file_search,
+// which chat completions does not support.
+type ResponsesRequest struct {
+	Model           string              `json:"model"`
+	Instructions    string              `json:"instructions,omitempty"`
+	Input           []ResponseInputItem `json:"input"`
+	Tools           []ResponseTool      `json:"tools,omitempty"`
+	ToolChoice      any                 `json:"tool_choice,omitempty"`
+	MaxOutputTokens *int                `json:"max_output_tokens,omitempty"`
+	Temperature     *float64            `json:"temperature,omitempty"`
+	TopP            *float64            `json:"top_p,omitempty"`
+	User            *string             `json:"user,omitempty"`
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
+}
+
+// ResponseInputItem represents a message, function call, or function call output
+// in a Responses API request
+type ResponseInputItem struct {
+	Type      string `json:"type"`
+	Role      string `json:"role,omitempty"`
+	Content   any    `json:"content,omitempty"` // string or []ResponseInputContent
+	CallID    string `json:"call_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+	Output    string `json:"output,omitempty"`
+}
+
+// ResponseInputContent represents a typed content part in a Responses API message
+type ResponseInputContent struct {
+	Type     string `json:"type"` // "input_text" or "input_file"
+	Text     string `json:"text,omitempty"`
+	FileID   string `json:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+}
+
+// ResponseTool represents a tool in a Responses API request
+type ResponseTool struct {
+	Type           string   `json:"type"`
+	Name           string   `json:"name,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Parameters     any      `json:"parameters,omitempty"`
+	VectorStoreIDs []string `json:"vector_store_ids,omitempty"`
+	MaxNumResults  *int     `json:"max_num_results,omitempty"`
+}
+
+// ResponsesResponse represents an OpenAI Responses API response
+type ResponsesResponse struct {
+	ID        string               `json:"id"`
+	Object    string               `json:"object"`
+	CreatedAt int64                `json:"created_at"`
+	Model     string               `json:"model"`
+	Status    string               `json:"status"`
+	Output    []ResponseOutputItem `json:"output"`
+	Usage     ResponseUsage        `json:"usage"`
+}
+
+// ResponseOutputItem represents an item in a Responses API output
+type ResponseOutputItem struct {
+	Type      string                  `json:"type"` // "message", "function_call", "file_search_call"
+	ID        string                  `json:"id"`
+	Role      string                  `json:"role,omitempty"`
+	Status    string                  `json:"status,omitempty"`
+	Content   []ResponseOutputContent `json:"content,omitempty"`
+	CallID    string                  `json:"call_id,omitempty"`
+	Name      string                  `json:"name,omitempty"`
+	Arguments string                  `json:"arguments,omitempty"`
+}
+
+// ResponseOutputContent represents a content part in a Responses API output message
+type ResponseOutputContent struct {
+	Type        string               `json:"type"` // "output_text" or "refusal"
+	Text        string               `json:"text,omitempty"`
+	Annotations []ResponseAnnotation `json:"annotations,omitempty"`
+}
+
+// ResponseAnnotation represents an annotation on Responses API output text
+type ResponseAnnotation struct {
+	Type     string `json:"type"` // e.g., "file_citation"
+	FileID   string `json:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Index    int    `json:"index"`
+}
+
+// ResponseUsage represents token usage in a Responses API response
+type ResponseUsage struct {
+	InputTokens         int                      `json:"input_tokens"`
+	OutputTokens        int                      `json:"output_tokens"`
+	TotalTokens         int                      `json:"total_tokens"`
+	InputTokensDetails  *PromptTokensDetails     `json:"input_tokens_details,omitempty"`
+	OutputTokensDetails *CompletionTokensDetails `json:"output_tokens_details,omitempty"`
+}
+
+// Logprobs holds token log probabilities for a choice
+type Logprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
+
+// TokenLogprob is the log probability of a generated token
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is an alternative token at a position
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
+
+// TextCompletionRequest is a legacy /completions request
+type TextCompletionRequest struct {
+	Model            string   `json:"model"`
+	Prompt           string   `json:"prompt"`
+	Suffix           string   `json:"suffix,omitempty"`
+	MaxTokens        *int     `json:"max_tokens,omitempty"`
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	N                *int     `json:"n,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	Echo             bool     `json:"echo,omitempty"`
+	Logprobs         *int     `json:"logprobs,omitempty"`
+}
+
+// TextCompletionResponse is a legacy /completions response
+type TextCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []TextCompletionChoice `json:"choices"`
+	Usage   Usage                  `json:"usage"`
+}
+
+// TextCompletionChoice is one completion in a legacy /completions response
+type TextCompletionChoice struct {
+	Index        int           `json:"index"`
+	Text         string        `json:"text"`
+	FinishReason *string       `json:"finish_reason"`
+	Logprobs     *TextLogprobs `json:"logprobs"`
+}
+
+// TextLogprobs holds the log probabilities of a legacy completion as
+// parallel arrays. With echo, the first prompt token has a null logprob.
+type TextLogprobs struct {
+	Tokens        []string             `json:"tokens"`
+	TokenLogprobs []*float64           `json:"token_logprobs"`
+	TopLogprobs   []map[string]float64 `json:"top_logprobs"`
+	TextOffset    []int                `json:"text_offset"`
+}
+
+// RealtimeSessionConfig is the session configuration sent in a session.update event
+type RealtimeSessionConfig struct {
+	Type             string         `json:"type"` // "realtime"
+	Instructions     string         `json:"instructions,omitempty"`
+	OutputModalities []string       `json:"output_modalities,omitempty"`
+	Audio            *RealtimeAudio `json:"audio,omitempty"`
+	Tools            []RealtimeTool `json:"tools,omitempty"`
+}
+
+// RealtimeAudio configures the audio of a realtime session
+type RealtimeAudio struct {
+	Input  *RealtimeAudioInput  `json:"input,omitempty"`
+	Output *RealtimeAudioOutput `json:"output,omitempty"`
+}
+
+// RealtimeAudioInput configures the user's audio
+type RealtimeAudioInput struct {
+	Format        *RealtimeAudioFormat   `json:"format,omitempty"`
+	Transcription *RealtimeTranscription `json:"transcription,omitempty"`
+}
+
+// RealtimeAudioOutput configures the model's audio
+type RealtimeAudioOutput struct {
+	Format *RealtimeAudioFormat `json:"format,omitempty"`
+	Voice  string               `json:"voice,omitempty"`
+}
+
+// RealtimeAudioFormat describes an audio encoding, e.g. "audio/pcm" at 24000Hz
+type RealtimeAudioFormat struct {
+	Type string `json:"type"`
+	Rate int    `json:"rate,omitempty"`
+}
+
+// RealtimeTranscription enables transcription of the user's audio
+type RealtimeTranscription struct {
+	Model string `json:"model"`
+}
+
+// RealtimeTool defines a function the model may call in a realtime session
+type RealtimeTool struct {
+	Type        string `json:"type"` // "function"
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+// RealtimeClientEvent is an event sent to the Realtime API
+type RealtimeClientEvent struct {
+	Type    string                 `json:"type"`
+	Session *RealtimeSessionConfig `json:"session,omitempty"` // session.update
+	Audio   string                 `json:"audio,omitempty"`   // input_audio_buffer.append, base64-encoded
+	Item    *RealtimeItem          `json:"item,omitempty"`    // conversation.item.create
+}
+
+// RealtimeItem is a conversation item: a message, a function call, or a
+// function call's output
+type RealtimeItem struct {
+	Type      string            `json:"type"`
+	Role      string            `json:"role,omitempty"`
+	Content   []RealtimeContent `json:"content,omitempty"`
+	CallID    string            `json:"call_id,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Arguments string            `json:"arguments,omitempty"`
+	Output    string            `json:"output,omitempty"`
+}
+
+// RealtimeContent is a content part of a realtime message item
+type RealtimeContent struct {
+	Type string `json:"type"` // e.g., "input_text"
+	Text string `json:"text,omitempty"`
+}
+
+// RealtimeServerEvent is an event received from the Realtime API. Only the
+// fields of the events the session handles are decoded.
+type RealtimeServerEvent struct {
+	Type       string                `json:"type"`
+	Delta      string                `json:"delta,omitempty"`
+	Transcript string                `json:"transcript,omitempty"`
+	Item       *RealtimeItem         `json:"item,omitempty"`
+	Response   *RealtimeResponse     `json:"response,omitempty"`
+	Error      *provider.StreamError `json:"error,omitempty"`
+}
+
+// RealtimeResponse summarizes a completed realtime response
+type RealtimeResponse struct {
+	ID     string         `json:"id"`
+	Status string         `json:"status"`
+	Usage  *RealtimeUsage `json:"usage,omitempty"`
+}
+
+// RealtimeUsage reports the tokens used by a realtime response
+type RealtimeUsage struct {
+	TotalTokens  int `json:"total_tokens"`
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
@@ -0,0 +1,207 @@
+// Package rerank provides clients for the Cohere, Voyage AI, and Jina AI
+// rerank APIs, which score documents by their relevance to a query.
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// Default models for each service
+const (
+	DefaultCohereModel = "rerank-v3.5"
+	DefaultVoyageModel = "rerank-2.5"
+	DefaultJinaModel   = "jina-reranker-v2-base-multilingual"
+)
+
+// Client implements a rerank API client. The three services share one
+// request shape, differing in base URL and a few field names.
+type Client struct {
+	name         string
+	apiKey       string
+	baseURL      string
+	defaultModel string
+	topK         bool // Send the result limit as top_k rather than top_n
+	noDocuments  bool // Send return_documents=false; documents are attached locally
+	client       *http.Client
+}
+
+// NewCohere creates a client for the Cohere v2 rerank API
+func NewCohere(apiKey, baseURL string, httpClient *http.Client) *Client {
+	return newClient("cohere", apiKey, baseURL, "https://api.cohere.com/v2", DefaultCohereModel, false, false, httpClient)
+}
+
+// NewVoyage creates a client for the Voyage AI rerank API
+func NewVoyage(apiKey, baseURL string, httpClient *http.Client) *Client {
+	return newClient("voyage", apiKey, baseURL, "https://api.voyageai.com/v1", DefaultVoyageModel, true, true, httpClient)
+}
+
+// NewJina creates a client for the Jina AI rerank API
+func NewJina(apiKey, baseURL string, httpClient *http.Client) *Client {
+	return newClient("jina", apiKey, baseURL, "https://api.jina.ai/v1", DefaultJinaModel, false, true, httpClient)
+}
+
+func newClient(name, apiKey, baseURL, defaultBaseURL, defaultModel string, topK, noDocuments bool, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Client{
+		name:         name,
+		apiKey:       apiKey,
+		baseURL:      baseURL,
+		defaultModel: defaultModel,
+		topK:         topK,
+		noDocuments:  noDocuments,
+		client:       httpClient,
+	}
+}
+
+// Name returns the provider name
+func (c *Client) Name() string {
+	return c.name
+}
+
+// CreateRerank calls the rerank endpoint
+func (c *Client) CreateRerank(ctx context.Context, req *Request) (*Response, error) {
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/rerank", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
+}
+
+// Rerank orders documents by relevance to the query
+func (c *Client) Rerank(ctx context.Context, req *provider.RerankRequest) (*provider.RerankResponse, error) {
+	if req.Query == "" {
+		return nil, fmt.Errorf("%w: rerank query cannot be empty", provider.ErrInvalidRequest)
+	}
+	if len(req.Documents) == 0 {
+		return nil, fmt.Errorf("%w: rerank documents cannot be empty", provider.ErrInvalidRequest)
+	}
+
+	wireReq := &Request{
+		Model:     req.Model,
+		Query:     req.Query,
+		Documents: req.Documents,
+	}
+	if wireReq.Model == "" {
+		wireReq.Model = c.defaultModel
+	}
+	if c.topK {
+		wireReq.TopK = req.TopN
+	} else {
+		wireReq.TopN = req.TopN
+	}
+	if c.noDocuments {
+		returnDocuments := false
+		wireReq.ReturnDocuments = &returnDocuments
+	}
+
+	resp, err := c.CreateRerank(ctx, wireReq)
+	if err != nil {
+		return nil, err
+	}
+	return c.convertResponse(wireReq, resp)
+}
+
+// convertResponse converts a rerank response, attaching each scored
+// document's text and ordering results from most to least relevant
+func (c *Client) convertResponse(req *Request, resp *Response) (*provider.RerankResponse, error) {
+	results := resp.Results
+	if len(results) == 0 {
+		results = resp.Data
+	}
+
+	out := &provider.RerankResponse{
+		Model:    resp.Model,
+		Provider: c.name,
+		Results:  make([]provider.RerankResult, 0, len(results)),
+	}
+	if out.Model == "" {
+		out.Model = req.Model
+	}
+	for _, r := range results {
+		if r.Index < 0 || r.Index >= len(req.Documents) {
+			return nil, fmt.Errorf("rerank result index %d out of range for %d documents", r.Index, len(req.Documents))
+		}
+		out.Results = append(out.Results, provider.RerankResult{
+			Index:    r.Index,
+			Score:    r.RelevanceScore,
+			Document: req.Documents[r.Index],
+		})
+	}
+	sort.SliceStable(out.Results, func(i, j int) bool {
+		return out.Results[i].Score > out.Results[j].Score
+	})
+
+	if resp.Usage != nil {
+		out.Usage.TotalTokens = resp.Usage.TotalTokens
+	}
+	if resp.Meta != nil && resp.Meta.BilledUnits != nil {
+		out.Usage.SearchUnits = resp.Meta.BilledUnits.SearchUnits
+	}
+	return out, nil
+}
+
+// handleErrorResponse converts an error response. Cohere reports errors as
+// {"message": ...}; Voyage and Jina as {"detail": ...}.
+func (c *Client) handleErrorResponse(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return provider.NewAPIErrorFromResponse(c.name, resp, "failed to read error response", "", "")
+	}
+
+	var errorResp struct {
+		Message string `json:"message"`
+		Detail  any    `json:"detail"`
+	}
+	message := string(body)
+	if err := json.Unmarshal(body, &errorResp); err == nil {
+		switch {
+		case errorResp.Message != "":
+			message = errorResp.Message
+		case errorResp.Detail != nil:
+			if detail, ok := errorResp.Detail.(string); ok {
+				message = detail
+			}
+		}
+	}
+
+	return provider.NewAPIErrorFromResponse(c.name, resp, c.name+" rerank API error: "+message, "", "").WithBody(body)
+}
@@ -0,0 +1,117 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestClient_Rerank(t *testing.T) {
+	docs := []string{"Paris is in France.", "Berlin is in Germany.", "The Eiffel Tower is in Paris."}
+
+	tests := []struct {
+		name      string
+		newClient func(apiKey, baseURL string, httpClient *http.Client) *Client
+		response  string
+		checkBody func(t *testing.T, body map[string]any)
+		wantUsage provider.RerankUsage
+	}{
+		{
+			name:      "cohere",
+			newClient: NewCohere,
+			response:  `{"id":"r1","results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.7}],"meta":{"billed_units":{"search_units":1}}}`,
+			checkBody: func(t *testing.T, body map[string]any) {
+				if body["top_n"] != float64(2) || body["model"] != DefaultCohereModel {
+					t.Errorf("body = %v", body)
+				}
+				if _, ok := body["return_documents"]; ok {
+					t.Errorf("cohere body should not set return_documents: %v", body)
+				}
+			},
+			wantUsage: provider.RerankUsage{SearchUnits: 1},
+		},
+		{
+			name:      "voyage",
+			newClient: NewVoyage,
+			response:  `{"object":"list","model":"rerank-2.5","data":[{"index":0,"relevance_score":0.7},{"index":2,"relevance_score":0.9}],"usage":{"total_tokens":30}}`,
+			checkBody: func(t *testing.T, body map[string]any) {
+				if body["top_k"] != float64(2) || body["return_documents"] != false {
+					t.Errorf("body = %v", body)
+				}
+			},
+			wantUsage: provider.RerankUsage{TotalTokens: 30},
+		},
+		{
+			name:      "jina",
+			newClient: NewJina,
+			response:  `{"model":"jina-reranker-v2-base-multilingual","results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.7}],"usage":{"total_tokens":25}}`,
+			checkBody: func(t *testing.T, body map[string]any) {
+				if body["top_n"] != float64(2) || body["return_documents"] != false {
+					t.Errorf("body = %v", body)
+				}
+			},
+			wantUsage: provider.RerankUsage{TotalTokens: 25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/rerank" || r.Header.Get("Authorization") != "Bearer test-key" {
+					t.Errorf("request = %s %s", r.Method, r.URL)
+				}
+				var body map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("decode body: %v", err)
+				}
+				tt.checkBody(t, body)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := tt.newClient("test-key", server.URL, server.Client())
+			resp, err := client.Rerank(context.Background(), &provider.RerankRequest{
+				Query:     "Where is the Eiffel Tower?",
+				Documents: docs,
+				TopN:      2,
+			})
+			if err != nil {
+				t.Fatalf("Rerank() error = %v", err)
+			}
+
+			if resp.Provider != tt.name || len(resp.Results) != 2 {
+				t.Fatalf("response = %+v", resp)
+			}
+			if top := resp.Results[0]; top.Index != 2 || top.Score != 0.9 || top.Document != docs[2] {
+				t.Errorf("top result = %+v", top)
+			}
+			if resp.Usage != tt.wantUsage {
+				t.Errorf("usage = %+v, want %+v", resp.Usage, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestClient_Rerank_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"detail":"Invalid API key"}`))
+	}))
+	defer server.Close()
+
+	client := NewVoyage("bad-key", server.URL, server.Client())
+	_, err := client.Rerank(context.Background(), &provider.RerankRequest{Query: "q", Documents: []string{"d"}})
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Provider != "voyage" {
+		t.Errorf("error = %v, want voyage 401 APIError", err)
+	}
+
+	if _, err := client.Rerank(context.Background(), &provider.RerankRequest{Query: "q"}); !errors.Is(err, provider.ErrInvalidRequest) {
+		t.Errorf("empty documents error = %v, want ErrInvalidRequest", err)
+	}
+}
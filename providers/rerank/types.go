@@ -0,0 +1,44 @@
+package rerank
+
+// Request is the rerank request body shared by Cohere, Voyage, and Jina.
+// Voyage names the result limit top_k; the others name it top_n.
+type Request struct {
+	Model           string   `json:"model"`
+	Query           string   `json:"query"`
+	Documents       []string `json:"documents"`
+	TopN            int      `json:"top_n,omitempty"`
+	TopK            int      `json:"top_k,omitempty"`
+	ReturnDocuments *bool    `json:"return_documents,omitempty"`
+}
+
+// Response is the rerank response body. Cohere and Jina return Results;
+// Voyage returns Data.
+type Response struct {
+	ID      string   `json:"id,omitempty"`
+	Model   string   `json:"model,omitempty"`
+	Results []Result `json:"results,omitempty"`
+	Data    []Result `json:"data,omitempty"`
+	Usage   *Usage   `json:"usage,omitempty"`
+	Meta    *Meta    `json:"meta,omitempty"`
+}
+
+// Result scores one document
+type Result struct {
+	Index          int     `json:"index"`
+	RelevanceScore float64 `json:"relevance_score"`
+}
+
+// Usage reports token usage (Voyage, Jina)
+type Usage struct {
+	TotalTokens int `json:"total_tokens"`
+}
+
+// Meta carries Cohere billing information
+type Meta struct {
+	BilledUnits *BilledUnits `json:"billed_units,omitempty"`
+}
+
+// BilledUnits reports Cohere search units
+type BilledUnits struct {
+	SearchUnits int `json:"search_units"`
+}
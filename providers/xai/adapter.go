@@ -3,6 +3,7 @@ package xai
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"github.com/plexusone/omnillm/provider"
@@ -24,27 +25,17 @@ func (p *Provider) Name() string {
 	return p.client.Name()
 }
 
+// Capabilities returns the request features the X.AI adapter supports.
+// Function tools are not yet mapped.
+func (p *Provider) Capabilities() provider.Capabilities {
+	return provider.Capabilities{Streaming: true, JSONSchema: true, Logprobs: true, MultipleChoices: true}
+}
+
 // CreateChatCompletion creates a chat completion
 func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
-	// Convert from unified format to X.AI format (OpenAI-compatible)
-	xaiReq := &Request{
-		Model:            req.Model,
-		MaxTokens:        req.MaxTokens,
-		Temperature:      req.Temperature,
-		TopP:             req.TopP,
-		Stop:             req.Stop,
-		PresencePenalty:  req.PresencePenalty,
-		FrequencyPenalty: req.FrequencyPenalty,
-		Seed:             req.Seed,
-	}
-
-	// Convert messages
-	for _, msg := range req.Messages {
-		xaiReq.Messages = append(xaiReq.Messages, Message{
-			Role:    string(msg.Role),
-			Content: msg.Content,
-			Name:    msg.Name,
-		})
+	xaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
 	}
 
 	resp, err := p.client.CreateCompletion(ctx, xaiReq)
@@ -52,33 +43,65 @@ func (p *Provider) CreateChatCompletion(ctx context.Context, req *provider.ChatC
 		return nil, err
 	}
 
-	// Convert back to unified format
-	return &provider.ChatCompletionResponse{
+	// Convert back to unified format. Citations come from the shared search,
+	// so every choice carries them.
+	annotations := citationAnnotations(resp.Citations)
+	choices := make([]provider.ChatCompletionChoice, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		choices = append(choices, provider.ChatCompletionChoice{
+			Index: choice.Index,
+			Message: provider.Message{
+				Role:    provider.Role(choice.Message.Role),
+				Content: choice.Message.Content,
+			},
+			FinishReason: choice.FinishReason,
+			Logprobs:     convertLogprobs(choice.Logprobs),
+			Annotations:  annotations,
+		})
+	}
+
+	result := &provider.ChatCompletionResponse{
 		ID:      resp.ID,
 		Object:  resp.Object,
 		Created: resp.Created,
 		Model:   resp.Model,
-		Choices: []provider.ChatCompletionChoice{
-			{
-				Index: 0,
-				Message: provider.Message{
-					Role:    provider.Role(resp.Choices[0].Message.Role),
-					Content: resp.Choices[0].Message.Content,
-				},
-				FinishReason: resp.Choices[0].FinishReason,
-			},
-		},
-		Usage: provider.Usage{
-			PromptTokens:     resp.Usage.PromptTokens,
-			CompletionTokens: resp.Usage.CompletionTokens,
-			TotalTokens:      resp.Usage.TotalTokens,
-		},
-	}, nil
+		Choices: choices,
+		Usage:   convertUsage(resp.Usage),
+	}
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	result.ProviderMetadata = provider.RecordDeterminism(result.ProviderMetadata, req.Seed, true, resp.SystemFingerprint)
+	result.ProviderMetadata = provider.RecordRequestID(result.ProviderMetadata, resp.RequestID)
+	result.Raw = resp.Raw
+	return result, nil
+}
+
+// MarshalChatCompletionRequest returns the request body sent for req
+func (p *Provider) MarshalChatCompletionRequest(req *provider.ChatCompletionRequest) ([]byte, error) {
+	xaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	xaiReq.Stream = boolPtr(false)
+	return json.Marshal(xaiReq)
 }
 
 // CreateChatCompletionStream creates a streaming chat completion
 func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
-	// Convert from unified format to X.AI format
+	xaiReq, err := p.convertRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := p.client.CreateCompletionStream(ctx, xaiReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamAdapter{stream: stream, seed: req.Seed}, nil
+}
+
+// convertRequest converts from unified format to X.AI format (OpenAI-compatible)
+func (p *Provider) convertRequest(req *provider.ChatCompletionRequest) (*Request, error) {
 	xaiReq := &Request{
 		Model:            req.Model,
 		MaxTokens:        req.MaxTokens,
@@ -88,6 +111,11 @@ func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider
 		PresencePenalty:  req.PresencePenalty,
 		FrequencyPenalty: req.FrequencyPenalty,
 		Seed:             req.Seed,
+		N:                req.N,
+		Logprobs:         req.Logprobs,
+		TopLogprobs:      req.TopLogprobs,
+		Headers:          req.Headers,
+		IncludeRaw:       req.IncludeRaw,
 	}
 
 	// Convert messages
@@ -99,12 +127,47 @@ func (p *Provider) CreateChatCompletionStream(ctx context.Context, req *provider
 		})
 	}
 
-	stream, err := p.client.CreateCompletionStream(ctx, xaiReq)
+	if req.ResponseFormat != nil {
+		xaiReq.ResponseFormat = &ResponseFormat{Type: req.ResponseFormat.Type}
+		if js := req.ResponseFormat.JSONSchema; js != nil {
+			xaiReq.ResponseFormat.JSONSchema = &JSONSchema{
+				Name:        js.Name,
+				Description: js.Description,
+				Schema:      js.Schema,
+				Strict:      js.Strict,
+			}
+		}
+	}
+
+	opts, err := provider.DecodeOptions[Options](req, "xai")
 	if err != nil {
 		return nil, err
 	}
+	if opts != nil {
+		xaiReq.SearchParameters = opts.Search
+	}
+
+	return xaiReq, nil
+}
 
-	return &StreamAdapter{stream: stream}, nil
+// citationAnnotations converts Live Search source URLs into citation annotations
+func citationAnnotations(citations []string) []provider.Annotation {
+	if len(citations) == 0 {
+		return nil
+	}
+	annotations := make([]provider.Annotation, 0, len(citations))
+	for i, url := range citations {
+		annotations = append(annotations, provider.Annotation{
+			Type:   "citation",
+			Source: "xai.live_search",
+			Label:  url,
+			Data: map[string]any{
+				"url":   url,
+				"index": i,
+			},
+		})
+	}
+	return annotations
 }
 
 // Close closes the provider
@@ -115,6 +178,7 @@ func (p *Provider) Close() error {
 // StreamAdapter adapts X.AI stream to unified interface
 type StreamAdapter struct {
 	stream *Stream
+	seed   *int // Seed sent with the request, if any
 }
 
 // Recv receives the next chunk from the stream
@@ -133,17 +197,15 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 	}
 
 	if chunk.Usage != nil {
-		result.Usage = &provider.Usage{
-			PromptTokens:     chunk.Usage.PromptTokens,
-			CompletionTokens: chunk.Usage.CompletionTokens,
-			TotalTokens:      chunk.Usage.TotalTokens,
-		}
+		usage := convertUsage(*chunk.Usage)
+		result.Usage = &usage
 	}
 
 	for _, choice := range chunk.Choices {
 		result.Choices = append(result.Choices, provider.ChatCompletionChoice{
 			Index:        choice.Index,
 			FinishReason: choice.FinishReason,
+			Logprobs:     convertLogprobs(choice.Logprobs),
 		})
 		if choice.Delta != nil {
 			result.Choices[len(result.Choices)-1].Delta = &provider.Message{
@@ -153,6 +215,21 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 		}
 	}
 
+	if annotations := citationAnnotations(chunk.Citations); annotations != nil {
+		if len(result.Choices) == 0 {
+			result.Choices = append(result.Choices, provider.ChatCompletionChoice{})
+		}
+		result.Choices[0].Annotations = annotations
+	}
+
+	result.ProviderMetadata = provider.NormalizeFinishReasons(result.Choices, result.ProviderMetadata)
+	_, finished := result.ProviderMetadata[provider.RawFinishReasonKey]
+	if finished || chunk.SystemFingerprint != "" {
+		result.ProviderMetadata = provider.RecordDeterminism(result.ProviderMetadata, s.seed, true, chunk.SystemFingerprint)
+	}
+	if finished {
+		result.ProviderMetadata = provider.RecordRequestID(result.ProviderMetadata, s.stream.RequestID())
+	}
 	return result, nil
 }
 
@@ -160,3 +237,60 @@ func (s *StreamAdapter) Recv() (*provider.ChatCompletionChunk, error) {
 func (s *StreamAdapter) Close() error {
 	return s.stream.Close()
 }
+
+// convertLogprobs converts token log probabilities to unified format
+func convertLogprobs(lp *Logprobs) *provider.Logprobs {
+	if lp == nil {
+		return nil
+	}
+	result := &provider.Logprobs{Content: make([]provider.TokenLogprob, 0, len(lp.Content))}
+	for _, token := range lp.Content {
+		converted := provider.TokenLogprob{
+			Token:   token.Token,
+			Logprob: token.Logprob,
+			Bytes:   token.Bytes,
+		}
+		for _, alt := range token.TopLogprobs {
+			converted.TopLogprobs = append(converted.TopLogprobs, provider.TopLogprob{
+				Token:   alt.Token,
+				Logprob: alt.Logprob,
+				Bytes:   alt.Bytes,
+			})
+		}
+		result.Content = append(result.Content, converted)
+	}
+	return result
+}
+
+// convertUsage converts token usage, including cached and reasoning token details, to unified format
+func convertUsage(usage Usage) provider.Usage {
+	return provider.Usage{
+		PromptTokens:            usage.PromptTokens,
+		CompletionTokens:        usage.CompletionTokens,
+		TotalTokens:             usage.TotalTokens,
+		PromptTokensDetails:     convertPromptTokensDetails(usage.PromptTokensDetails),
+		CompletionTokensDetails: convertCompletionTokensDetails(usage.CompletionTokensDetails),
+	}
+}
+
+// convertPromptTokensDetails converts a prompt token breakdown to unified format
+func convertPromptTokensDetails(details *PromptTokensDetails) *provider.PromptTokensDetails {
+	if details == nil {
+		return nil
+	}
+	return &provider.PromptTokensDetails{
+		CachedTokens: details.CachedTokens,
+		AudioTokens:  details.AudioTokens,
+	}
+}
+
+// convertCompletionTokensDetails converts a completion token breakdown to unified format
+func convertCompletionTokensDetails(details *CompletionTokensDetails) *provider.CompletionTokensDetails {
+	if details == nil {
+		return nil
+	}
+	return &provider.CompletionTokensDetails{
+		ReasoningTokens: details.ReasoningTokens,
+		AudioTokens:     details.AudioTokens,
+	}
+}
@@ -0,0 +1,224 @@
+package xai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func searchRequest(opts any) *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{
+		Model:           "grok-3",
+		Messages:        []provider.Message{{Role: provider.RoleUser, Content: "What happened today?"}},
+		ProviderOptions: map[string]any{"xai": opts, "openai": "ignored"},
+	}
+}
+
+func TestProvider_LiveSearch(t *testing.T) {
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(Response{
+			ID:        "resp_1",
+			Model:     "grok-3",
+			Choices:   []Choice{{Message: Message{Role: "assistant", Content: "News."}}},
+			Citations: []string{"https://example.com/a", "https://x.com/b"},
+		})
+	}))
+	defer server.Close()
+
+	maxResults := 5
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), searchRequest(&Options{
+		Search: &SearchParameters{
+			Mode:             SearchModeOn,
+			Sources:          []SearchSource{{Type: SearchSourceWeb, Country: "US"}, {Type: SearchSourceX, IncludedXHandles: []string{"xai"}}},
+			FromDate:         "2025-01-01",
+			ToDate:           "2025-01-31",
+			MaxSearchResults: &maxResults,
+		},
+	}))
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+
+	sp := got.SearchParameters
+	if sp == nil || sp.Mode != SearchModeOn || sp.FromDate != "2025-01-01" || len(sp.Sources) != 2 || *sp.MaxSearchResults != 5 {
+		t.Fatalf("search parameters not sent: %+v", sp)
+	}
+	if sp.Sources[1].IncludedXHandles[0] != "xai" {
+		t.Errorf("unexpected X source: %+v", sp.Sources[1])
+	}
+
+	annotations := resp.Choices[0].Annotations
+	if len(annotations) != 2 || annotations[0].Type != "citation" || annotations[1].Data["url"] != "https://x.com/b" {
+		t.Errorf("citations not surfaced: %+v", annotations)
+	}
+}
+
+func TestProvider_LiveSearchMapOptions(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_ = json.NewEncoder(w).Encode(Response{Choices: []Choice{{Message: Message{Role: "assistant"}}}})
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	_, err := p.CreateChatCompletion(context.Background(), searchRequest(map[string]any{
+		"search": map[string]any{"mode": "auto", "sources": []any{map[string]any{"type": "news"}}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, ok := body["search_parameters"].(map[string]any)
+	if !ok || sp["mode"] != "auto" {
+		t.Errorf("search_parameters = %v", body["search_parameters"])
+	}
+}
+
+func TestProvider_StreamCitations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"role":"assistant","content":"News."}}]}`+"\n\n")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"id":"c1","choices":[],"citations":["https://example.com/a"]}`)
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), searchRequest(Options{Search: &SearchParameters{Mode: SearchModeAuto}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var annotations []provider.Annotation
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, choice := range chunk.Choices {
+			annotations = append(annotations, choice.Annotations...)
+		}
+	}
+	if len(annotations) != 1 || annotations[0].Label != "https://example.com/a" {
+		t.Errorf("stream citations = %+v", annotations)
+	}
+}
+
+func TestProvider_StreamIncludesUsage(t *testing.T) {
+	var sent Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"}}]}`+"\n\n")
+		_, _ = io.WriteString(w, `data: {"id":"c1","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}`+"\n\n")
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), searchRequest(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var usage *provider.Usage
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+	if sent.StreamOptions == nil || !sent.StreamOptions.IncludeUsage {
+		t.Errorf("stream_options = %+v, want include_usage", sent.StreamOptions)
+	}
+	if usage == nil || usage.TotalTokens != 10 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestProvider_StreamErrorString(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, `data: {"error":"upstream disconnected"}`+"\n\n")
+	}))
+	defer server.Close()
+
+	p := NewProvider("test-key", server.URL, server.Client())
+	stream, err := p.CreateChatCompletionStream(context.Background(), searchRequest(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	var apiErr *provider.APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "upstream disconnected" || apiErr.Provider != "xai" {
+		t.Errorf("expected an APIError, got %v", err)
+	}
+}
+
+func TestProvider_InvalidOptions(t *testing.T) {
+	p := NewProvider("test-key", "http://unused", nil)
+	_, err := p.CreateChatCompletion(context.Background(), searchRequest(map[string]any{"search": "yes"}))
+	if err == nil {
+		t.Error("expected invalid options error")
+	}
+}
+
+func TestProvider_Logprobs(t *testing.T) {
+	var got Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = io.WriteString(w, `{"id":"r1","choices":[{"index":0,"message":{"role":"assistant","content":"yes"},
+			"logprobs":{"content":[{"token":"yes","logprob":-0.1,"top_logprobs":[{"token":"yes","logprob":-0.1},{"token":"no","logprob":-2.4}]}]}}]}`)
+	}))
+	defer server.Close()
+
+	logprobs, top := true, 2
+	p := NewProvider("test-key", server.URL, server.Client())
+	resp, err := p.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:       "grok-3",
+		Messages:    []provider.Message{{Role: provider.RoleUser, Content: "Is the sky blue?"}},
+		Logprobs:    &logprobs,
+		TopLogprobs: &top,
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if got.Logprobs == nil || !*got.Logprobs || got.TopLogprobs == nil || *got.TopLogprobs != 2 {
+		t.Errorf("logprobs not sent: %v %v", got.Logprobs, got.TopLogprobs)
+	}
+
+	lp := resp.Choices[0].Logprobs
+	if lp == nil || len(lp.Content) != 1 || lp.Content[0].Token != "yes" || len(lp.Content[0].TopLogprobs) != 2 {
+		t.Fatalf("Logprobs = %+v", lp)
+	}
+	if alt := lp.Content[0].TopLogprobs[1]; alt.Token != "no" || alt.Logprob != -2.4 {
+		t.Errorf("alternative = %+v", alt)
+	}
+}
@@ -1,17 +1,92 @@
 package xai
 
+import "github.com/plexusone/omnillm/provider"
+
 // Request represents an X.AI API request (OpenAI-compatible format)
 type Request struct {
-	Model            string    `json:"model"`
-	Messages         []Message `json:"messages"`
-	MaxTokens        *int      `json:"max_tokens,omitempty"`
-	Temperature      *float64  `json:"temperature,omitempty"`
-	TopP             *float64  `json:"top_p,omitempty"`
-	Stream           *bool     `json:"stream,omitempty"`
-	Stop             []string  `json:"stop,omitempty"`
-	PresencePenalty  *float64  `json:"presence_penalty,omitempty"`
-	FrequencyPenalty *float64  `json:"frequency_penalty,omitempty"`
-	Seed             *int      `json:"seed,omitempty"`
+	Model            string         `json:"model"`
+	Messages         []Message      `json:"messages"`
+	MaxTokens        *int           `json:"max_tokens,omitempty"`
+	Temperature      *float64       `json:"temperature,omitempty"`
+	TopP             *float64       `json:"top_p,omitempty"`
+	Stream           *bool          `json:"stream,omitempty"`
+	Stop             []string       `json:"stop,omitempty"`
+	PresencePenalty  *float64       `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64       `json:"frequency_penalty,omitempty"`
+	Seed             *int           `json:"seed,omitempty"`
+	N                *int           `json:"n,omitempty"`
+	Logprobs         *bool          `json:"logprobs,omitempty"`
+	TopLogprobs      *int           `json:"top_logprobs,omitempty"`
+	StreamOptions    *StreamOptions `json:"stream_options,omitempty"` // Streaming only
+
+	ResponseFormat   *ResponseFormat   `json:"response_format,omitempty"`
+	SearchParameters *SearchParameters `json:"search_parameters,omitempty"`
+
+	// Headers are extra HTTP headers sent with the request
+	Headers map[string]string `json:"-"`
+
+	// IncludeRaw keeps the request and response bodies in Response.Raw
+	IncludeRaw bool `json:"-"`
+}
+
+// ResponseFormat specifies the format of the response (OpenAI-compatible)
+type ResponseFormat struct {
+	Type       string      `json:"type"` // "text", "json_object", or "json_schema"
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema configures structured outputs
+type JSONSchema struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema"`
+	Strict      *bool  `json:"strict,omitempty"`
+}
+
+// Options are the X.AI-specific request options, passed in
+// ChatCompletionRequest.ProviderOptions under the "xai" key
+type Options struct {
+	// Search enables Live Search, letting Grok query the web, X, news, and
+	// RSS feeds while answering
+	Search *SearchParameters `json:"search,omitempty"`
+}
+
+// Live Search modes
+const (
+	SearchModeAuto = "auto" // the model decides whether to search
+	SearchModeOn   = "on"   // always search
+	SearchModeOff  = "off"  // never search
+)
+
+// Live Search source types
+const (
+	SearchSourceWeb  = "web"
+	SearchSourceX    = "x"
+	SearchSourceNews = "news"
+	SearchSourceRSS  = "rss"
+)
+
+// SearchParameters configures X.AI Live Search
+type SearchParameters struct {
+	Mode             string         `json:"mode,omitempty"`      // SearchModeAuto (default), SearchModeOn, or SearchModeOff
+	Sources          []SearchSource `json:"sources,omitempty"`   // Default: web and X
+	FromDate         string         `json:"from_date,omitempty"` // ISO-8601 date, e.g. "2025-01-31"
+	ToDate           string         `json:"to_date,omitempty"`   // ISO-8601 date
+	ReturnCitations  *bool          `json:"return_citations,omitempty"`
+	MaxSearchResults *int           `json:"max_search_results,omitempty"`
+}
+
+// SearchSource is a data source for Live Search. Which fields apply depends
+// on Type.
+type SearchSource struct {
+	Type             string   `json:"type"`                         // SearchSourceWeb, SearchSourceX, SearchSourceNews, or SearchSourceRSS
+	Country          string   `json:"country,omitempty"`            // web, news - ISO alpha-2 country code
+	AllowedWebsites  []string `json:"allowed_websites,omitempty"`   // web
+	ExcludedWebsites []string `json:"excluded_websites,omitempty"`  // web, news
+	SafeSearch       *bool    `json:"safe_search,omitempty"`        // web, news
+	IncludedXHandles []string `json:"included_x_handles,omitempty"` // x
+	ExcludedXHandles []string `json:"excluded_x_handles,omitempty"` // x
+	Links            []string `json:"links,omitempty"`              // rss - feed URLs
 }
 
 // Message represents a message in X.AI format (OpenAI-compatible)
@@ -21,38 +96,72 @@ type Message struct {
 	Name    *string `json:"name,omitempty"`
 }
 
+// StreamOptions configures a streaming response
+type StreamOptions struct {
+	// IncludeUsage adds a final chunk, with no choices, reporting the usage
+	// for the whole request
+	IncludeUsage bool `json:"include_usage"`
+}
+
 // Response represents an X.AI API response (OpenAI-compatible)
 type Response struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
+	ID        string   `json:"id"`
+	Object    string   `json:"object"`
+	Created   int64    `json:"created"`
+	Model     string   `json:"model"`
+	Choices   []Choice `json:"choices"`
+	Usage     Usage    `json:"usage"`
+	Citations []string `json:"citations,omitempty"` // Live Search source URLs
+
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	RequestID         string `json:"-"` // From the x-request-id or request-id response header
+
+	Raw *provider.RawExchange `json:"-"` // Set when the request has IncludeRaw
 }
 
 // Choice represents a completion choice in X.AI response
 type Choice struct {
-	Index        int     `json:"index"`
-	Message      Message `json:"message"`
-	FinishReason *string `json:"finish_reason"`
+	Index        int       `json:"index"`
+	Message      Message   `json:"message"`
+	FinishReason *string   `json:"finish_reason"`
+	Logprobs     *Logprobs `json:"logprobs,omitempty"`
 }
 
 // Usage represents token usage in X.AI response
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int                      `json:"prompt_tokens"`
+	CompletionTokens        int                      `json:"completion_tokens"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptTokensDetails     *PromptTokensDetails     `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails breaks down prompt tokens
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+	AudioTokens  int `json:"audio_tokens"`
+}
+
+// CompletionTokensDetails breaks down completion tokens
+type CompletionTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens"`
+	AudioTokens     int `json:"audio_tokens"`
 }
 
 // StreamChunk represents a chunk in X.AI streaming response (OpenAI-compatible)
 type StreamChunk struct {
-	ID      string        `json:"id"`
-	Object  string        `json:"object"`
-	Created int64         `json:"created"`
-	Model   string        `json:"model"`
-	Choices []StreamDelta `json:"choices"`
-	Usage   *Usage        `json:"usage,omitempty"`
+	ID        string        `json:"id"`
+	Object    string        `json:"object"`
+	Created   int64         `json:"created"`
+	Model     string        `json:"model"`
+	Choices   []StreamDelta `json:"choices"`
+	Usage     *Usage        `json:"usage,omitempty"`
+	Citations []string      `json:"citations,omitempty"` // Sent with the final chunk when Live Search is used
+
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Error is set instead of the other fields when the stream fails mid-response
+	Error *provider.StreamError `json:"error,omitempty"`
 }
 
 // StreamDelta represents delta content in a streaming chunk
@@ -60,6 +169,7 @@ type StreamDelta struct {
 	Index        int          `json:"index"`
 	Delta        *DeltaChange `json:"delta,omitempty"`
 	FinishReason *string      `json:"finish_reason"`
+	Logprobs     *Logprobs    `json:"logprobs,omitempty"`
 }
 
 // DeltaChange represents the actual content change in a stream
@@ -67,3 +177,23 @@ type DeltaChange struct {
 	Role    string `json:"role,omitempty"`
 	Content string `json:"content,omitempty"`
 }
+
+// Logprobs holds token log probabilities for a choice
+type Logprobs struct {
+	Content []TokenLogprob `json:"content"`
+}
+
+// TokenLogprob is the log probability of a generated token
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is an alternative token at a position
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
+}
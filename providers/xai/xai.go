@@ -11,8 +11,13 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/plexusone/omnillm/provider"
 )
 
+// DefaultTimeout is the HTTP client timeout used when no client is given
+const DefaultTimeout = 60 * time.Second
+
 // Client implements X.AI API client
 type Client struct {
 	apiKey  string
@@ -26,7 +31,7 @@ func New(apiKey, baseURL string, httpClient *http.Client) *Client {
 		baseURL = "https://api.x.ai/v1"
 	}
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: 60 * time.Second}
+		httpClient = &http.Client{Timeout: DefaultTimeout}
 	}
 
 	return &Client{
@@ -51,6 +56,7 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 	}
 
 	req.Stream = boolPtr(false)
+	req.StreamOptions = nil
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
@@ -64,6 +70,7 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -75,10 +82,18 @@ func (c *Client) CreateCompletion(ctx context.Context, req *Request) (*Response,
 		return nil, c.handleErrorResponse(resp)
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 	var response Response
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	response.RequestID = provider.RequestIDFromHeader(resp.Header)
+	if req.IncludeRaw {
+		response.Raw = &provider.RawExchange{Request: reqBody, Response: body}
+	}
 
 	return &response, nil
 }
@@ -93,6 +108,10 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 	}
 
 	req.Stream = boolPtr(true)
+	if req.StreamOptions == nil {
+		// Report usage in the final chunk, which streams otherwise omit
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
 
 	reqBody, err := json.Marshal(req)
 	if err != nil {
@@ -107,6 +126,7 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
 	httpReq.Header.Set("Accept", "text/event-stream")
+	provider.SetHeaders(httpReq, req.Headers)
 
 	resp, err := c.client.Do(httpReq) //nolint:gosec // G704: baseURL is configured at client init, not user-controlled per-request
 	if err != nil {
@@ -118,7 +138,9 @@ func (c *Client) CreateCompletionStream(ctx context.Context, req *Request) (*Str
 		return nil, c.handleErrorResponse(resp)
 	}
 
+	resp.Body = provider.NewContextBody(ctx, resp.Body)
 	return &Stream{
+		ctx:      ctx,
 		response: resp,
 		scanner:  bufio.NewScanner(resp.Body),
 	}, nil
@@ -133,7 +155,7 @@ func (c *Client) Close() error {
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read error response")
+		return provider.NewAPIErrorFromResponse("xai", resp, "failed to read error response", "", "")
 	}
 
 	var errorResp struct {
@@ -141,28 +163,41 @@ func (c *Client) handleErrorResponse(resp *http.Response) error {
 			Message string `json:"message"`
 			Type    string `json:"type"`
 			Code    string `json:"code"`
+			Param   string `json:"param"`
 		} `json:"error"`
 	}
 
-	if err := json.Unmarshal(body, &errorResp); err != nil {
-		return fmt.Errorf("API error: %s", string(body))
+	if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.Error.Message == "" {
+		return provider.NewAPIErrorFromResponse("xai", resp, "X.AI API error: "+string(body), "", "").WithBody(body)
 	}
 
-	return fmt.Errorf("X.AI API error: %s", errorResp.Error.Message)
+	apiErr := provider.NewAPIErrorFromResponse("xai", resp, "X.AI API error: "+errorResp.Error.Message,
+		errorResp.Error.Type, errorResp.Error.Code).WithBody(body)
+	apiErr.Param = errorResp.Error.Param
+	return apiErr
 }
 
 // Stream implements streaming for X.AI
 type Stream struct {
+	ctx      context.Context
 	response *http.Response
 	scanner  *bufio.Scanner
 	closed   bool
 }
 
+// RequestID returns the provider's ID for the request, or "" if it sent none
+func (s *Stream) RequestID() string {
+	return provider.RequestIDFromHeader(s.response.Header)
+}
+
 // Recv receives the next chunk from the stream
 func (s *Stream) Recv() (*StreamChunk, error) {
 	if s.closed {
 		return nil, fmt.Errorf("stream is closed")
 	}
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	for s.scanner.Scan() {
 		line := s.scanner.Text()
@@ -180,12 +215,18 @@ func (s *Stream) Recv() (*StreamChunk, error) {
 			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 				continue
 			}
+			if chunk.Error != nil {
+				return nil, provider.NewStreamAPIError("xai", chunk.Error)
+			}
 
 			return &chunk, nil
 		}
 	}
 
 	if err := s.scanner.Err(); err != nil {
+		if ctxErr := s.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("stream error: %w", err)
 	}
 
@@ -0,0 +1,39 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrRawNotSupported is returned when the primary provider does not
+// implement provider.RawCapable
+var ErrRawNotSupported = errors.New("raw requests not supported by the primary provider")
+
+// MarshalChatCompletionRequest returns the request body the primary provider
+// would send for req, with the client's system prompt applied, without
+// sending it. Model aliases from ProviderConfig.ModelMap are not applied.
+// To see the bodies of a request that is sent, set IncludeRaw on it and read
+// ChatCompletionResponse.Raw.
+func (c *ChatClient) MarshalChatCompletionRequest(ctx context.Context, req *provider.ChatCompletionRequest) ([]byte, error) {
+	if len(c.providers) == 0 {
+		return nil, ErrRawNotSupported
+	}
+	req, err := c.applySystemPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+
+	p := c.providers[0]
+	if lazy, ok := p.(*lazyProvider); ok {
+		if p, err = lazy.get(ctx); err != nil {
+			return nil, err
+		}
+	}
+	raw, ok := p.(provider.RawCapable)
+	if !ok {
+		return nil, ErrRawNotSupported
+	}
+	return raw.MarshalChatCompletionRequest(req)
+}
@@ -0,0 +1,54 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// rawProvider is a scripted provider that marshals requests as they are
+type rawProvider struct {
+	*mocktest.ScriptedProvider
+}
+
+func (p rawProvider) MarshalChatCompletionRequest(req *provider.ChatCompletionRequest) ([]byte, error) {
+	return json.Marshal(req)
+}
+
+func TestChatClient_MarshalChatCompletionRequest(t *testing.T) {
+	scripted := mocktest.NewScriptedProvider("scripted")
+	client, err := NewClient(ClientConfig{
+		Providers:           []ProviderConfig{{CustomProvider: rawProvider{scripted}, Lazy: true}},
+		DefaultSystemPrompt: "Be brief.",
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	body, err := client.MarshalChatCompletionRequest(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "Be brief.") {
+		t.Errorf("body = %s, want the system prompt applied", body)
+	}
+	if scripted.Calls() != 0 {
+		t.Errorf("expected nothing to be sent, got %d calls", scripted.Calls())
+	}
+
+	plain, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: scripted}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer plain.Close()
+	if _, err := plain.MarshalChatCompletionRequest(context.Background(), req); !errors.Is(err, ErrRawNotSupported) {
+		t.Errorf("expected ErrRawNotSupported, got %v", err)
+	}
+}
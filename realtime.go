@@ -0,0 +1,200 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrRealtimeNotSupported is returned when no configured provider supports realtime sessions
+var ErrRealtimeNotSupported = errors.New("realtime sessions not supported by configured providers")
+
+// ConnectRealtime opens a realtime session using the first configured provider
+// that supports it. If config.Instructions is empty, the client's default
+// system prompt is used.
+//
+// Each model turn is reported to the observability hooks as one call:
+// BeforeRequest when the model starts responding, and AfterResponse with the
+// turn's text or transcript, tool calls, and usage when it finishes.
+func (c *ChatClient) ConnectRealtime(ctx context.Context, config *provider.RealtimeConfig) (provider.RealtimeSession, error) {
+	if c.realtime == nil {
+		return nil, ErrRealtimeNotSupported
+	}
+	if config == nil || config.Model == "" {
+		return nil, fmt.Errorf("%w: realtime sessions require a model", ErrInvalidRequest)
+	}
+
+	cfg := *config
+	if cfg.Instructions == "" {
+		cfg.Instructions = c.systemPrompt
+	}
+
+	session, err := c.realtime.ConnectRealtime(ctx, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if c.hook == nil {
+		return session, nil
+	}
+
+	providerName := ""
+	if named, ok := c.realtime.(interface{ Name() string }); ok {
+		providerName = named.Name()
+	}
+	observed := &observedRealtimeSession{
+		RealtimeSession: session,
+		ctx:             ctx,
+		hook:            c.hook,
+		providerName:    providerName,
+		config:          &cfg,
+		events:          make(chan provider.RealtimeEvent, cap(session.Events())),
+		done:            make(chan struct{}),
+	}
+	go observed.run()
+	return observed, nil
+}
+
+// HasRealtime returns true if a provider supporting realtime sessions is configured
+func (c *ChatClient) HasRealtime() bool {
+	return c.realtime != nil
+}
+
+// observedRealtimeSession reports each model turn of a realtime session to an
+// observability hook
+type observedRealtimeSession struct {
+	provider.RealtimeSession
+	ctx          context.Context
+	hook         ObservabilityHook
+	providerName string
+	config       *provider.RealtimeConfig
+
+	events    chan provider.RealtimeEvent
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu        sync.Mutex
+	lastInput string // Latest user text or transcript, reported as the turn's request
+}
+
+// realtimeTurn accumulates a model turn for AfterResponse
+type realtimeTurn struct {
+	ctx       context.Context
+	info      LLMCallInfo
+	req       *provider.ChatCompletionRequest
+	content   strings.Builder
+	toolCalls []provider.ToolCall
+}
+
+// SendText sends a user message, recording it as the next turn's request
+func (s *observedRealtimeSession) SendText(text string) error {
+	s.setInput(text)
+	return s.RealtimeSession.SendText(text)
+}
+
+// Events returns the channel of events from the model
+func (s *observedRealtimeSession) Events() <-chan provider.RealtimeEvent {
+	return s.events
+}
+
+// Close ends the session
+func (s *observedRealtimeSession) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return s.RealtimeSession.Close()
+}
+
+func (s *observedRealtimeSession) setInput(text string) {
+	s.mu.Lock()
+	s.lastInput = text
+	s.mu.Unlock()
+}
+
+// run forwards events from the underlying session, reporting turns as they end
+func (s *observedRealtimeSession) run() {
+	defer close(s.events)
+
+	var turn *realtimeTurn
+	for event := range s.RealtimeSession.Events() {
+		switch event.Type {
+		case provider.RealtimeEventInputTranscript:
+			s.setInput(event.Text)
+		case provider.RealtimeEventInterrupted:
+		default:
+			if turn == nil {
+				turn = s.startTurn()
+			}
+			switch event.Type {
+			case provider.RealtimeEventText, provider.RealtimeEventTranscript:
+				turn.content.WriteString(event.Text)
+			case provider.RealtimeEventToolCall:
+				turn.toolCalls = append(turn.toolCalls, *event.ToolCall)
+			case provider.RealtimeEventTurnDone:
+				s.endTurn(turn, event.Usage, nil)
+				turn = nil
+			case provider.RealtimeEventError:
+				s.endTurn(turn, nil, event.Err)
+				turn = nil
+			}
+		}
+
+		select {
+		case s.events <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// startTurn runs BeforeRequest for a model turn
+func (s *observedRealtimeSession) startTurn() *realtimeTurn {
+	req := &provider.ChatCompletionRequest{Model: s.config.Model}
+	if s.config.Instructions != "" {
+		req.Messages = append(req.Messages, provider.Message{Role: provider.RoleSystem, Content: s.config.Instructions})
+	}
+	s.mu.Lock()
+	if s.lastInput != "" {
+		req.Messages = append(req.Messages, provider.Message{Role: provider.RoleUser, Content: s.lastInput})
+		s.lastInput = ""
+	}
+	s.mu.Unlock()
+
+	turn := &realtimeTurn{
+		info: LLMCallInfo{
+			CallID:       newCallID(),
+			ProviderName: s.providerName,
+			StartTime:    time.Now(),
+		},
+		req: req,
+	}
+	turn.ctx = s.hook.BeforeRequest(s.ctx, turn.info, req)
+	return turn
+}
+
+// endTurn runs AfterResponse for a model turn
+func (s *observedRealtimeSession) endTurn(turn *realtimeTurn, usage *provider.Usage, err error) {
+	if err != nil {
+		s.hook.AfterResponse(turn.ctx, turn.info, turn.req, nil, err)
+		return
+	}
+
+	resp := &provider.ChatCompletionResponse{
+		Object:  "realtime.response",
+		Created: turn.info.StartTime.Unix(),
+		Model:   s.config.Model,
+		Choices: []provider.ChatCompletionChoice{{
+			Message: provider.Message{
+				Role:      provider.RoleAssistant,
+				Content:   turn.content.String(),
+				ToolCalls: turn.toolCalls,
+			},
+		}},
+	}
+	if usage != nil {
+		resp.Usage = *usage
+	}
+	s.hook.AfterResponse(turn.ctx, turn.info, turn.req, resp, nil)
+}
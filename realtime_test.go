@@ -0,0 +1,134 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockRealtimeProvider is a provider whose realtime sessions replay scripted events
+type mockRealtimeProvider struct {
+	*MockProvider
+	config *provider.RealtimeConfig
+	events chan provider.RealtimeEvent
+}
+
+func (m *mockRealtimeProvider) ConnectRealtime(ctx context.Context, config *provider.RealtimeConfig) (provider.RealtimeSession, error) {
+	m.config = config
+	return &mockRealtimeSession{events: m.events}, nil
+}
+
+type mockRealtimeSession struct {
+	events chan provider.RealtimeEvent
+	sent   []string
+}
+
+func (s *mockRealtimeSession) SendAudio(data []byte) error { return nil }
+
+func (s *mockRealtimeSession) SendText(text string) error {
+	s.sent = append(s.sent, text)
+	return nil
+}
+
+func (s *mockRealtimeSession) SendToolResult(callID, output string) error { return nil }
+
+func (s *mockRealtimeSession) Events() <-chan provider.RealtimeEvent { return s.events }
+
+func (s *mockRealtimeSession) Close() error { return nil }
+
+// turnHook records the calls reported for realtime turns
+type turnHook struct {
+	requests  []*provider.ChatCompletionRequest
+	responses []*provider.ChatCompletionResponse
+	errs      []error
+}
+
+func (h *turnHook) BeforeRequest(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	h.requests = append(h.requests, req)
+	return ctx
+}
+
+func (h *turnHook) AfterResponse(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	h.responses = append(h.responses, resp)
+	h.errs = append(h.errs, err)
+}
+
+func (h *turnHook) WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return stream
+}
+
+func TestChatClient_ConnectRealtime_ReportsTurns(t *testing.T) {
+	events := make(chan provider.RealtimeEvent, 16)
+	prov := &mockRealtimeProvider{MockProvider: NewMockProvider("live"), events: events}
+	hook := &turnHook{}
+	client, err := NewClient(ClientConfig{
+		Providers:           []ProviderConfig{{CustomProvider: prov}},
+		ObservabilityHook:   hook,
+		DefaultSystemPrompt: "Be brief.",
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	session, err := client.ConnectRealtime(context.Background(), &RealtimeConfig{Model: "voice-1"})
+	if err != nil {
+		t.Fatalf("ConnectRealtime failed: %v", err)
+	}
+	defer session.Close()
+	if prov.config.Instructions != "Be brief." {
+		t.Errorf("Instructions = %q, want default system prompt", prov.config.Instructions)
+	}
+
+	if err := session.SendText("What time is it?"); err != nil {
+		t.Fatalf("SendText failed: %v", err)
+	}
+	events <- provider.RealtimeEvent{Type: RealtimeEventAudio, Audio: []byte{1}}
+	events <- provider.RealtimeEvent{Type: RealtimeEventTranscript, Text: "It is "}
+	events <- provider.RealtimeEvent{Type: RealtimeEventTranscript, Text: "noon."}
+	events <- provider.RealtimeEvent{Type: RealtimeEventTurnDone, Usage: &provider.Usage{TotalTokens: 12}}
+	events <- provider.RealtimeEvent{Type: RealtimeEventInputTranscript, Text: "Thanks"}
+	events <- provider.RealtimeEvent{Type: RealtimeEventError, Err: errors.New("overloaded")}
+	close(events)
+
+	var forwarded int
+	for range session.Events() {
+		forwarded++
+	}
+	if forwarded != 6 {
+		t.Errorf("forwarded %d events, want 6", forwarded)
+	}
+
+	if len(hook.requests) != 2 || len(hook.responses) != 2 {
+		t.Fatalf("hook saw %d requests and %d responses, want 2 each", len(hook.requests), len(hook.responses))
+	}
+	first := hook.requests[0]
+	if first.Model != "voice-1" || len(first.Messages) != 2 || first.Messages[1].Content != "What time is it?" {
+		t.Errorf("first turn request = %+v", first)
+	}
+	if resp := hook.responses[0]; resp.Choices[0].Message.Content != "It is noon." || resp.Usage.TotalTokens != 12 {
+		t.Errorf("first turn response = %+v", resp)
+	}
+	if second := hook.requests[1]; second.Messages[len(second.Messages)-1].Content != "Thanks" {
+		t.Errorf("second turn request = %+v", second)
+	}
+	if hook.responses[1] != nil || hook.errs[1] == nil {
+		t.Errorf("second turn = %+v, %v; want error", hook.responses[1], hook.errs[1])
+	}
+}
+
+func TestChatClient_ConnectRealtime_NotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.HasRealtime() {
+		t.Error("HasRealtime() = true")
+	}
+	if _, err := client.ConnectRealtime(context.Background(), &RealtimeConfig{Model: "m"}); !errors.Is(err, ErrRealtimeNotSupported) {
+		t.Errorf("ConnectRealtime error = %v, want ErrRealtimeNotSupported", err)
+	}
+}
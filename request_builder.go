@@ -0,0 +1,266 @@
+package omnillm
+
+import (
+	"errors"
+	"maps"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// RequestBuilder incrementally constructs a ChatCompletionRequest.
+// Problems are accumulated rather than failing fast, and Build returns
+// all of them at once as ValidationErrors. New request fields can be
+// added as builder methods without breaking existing callers.
+type RequestBuilder struct {
+	req provider.ChatCompletionRequest
+}
+
+// NewRequestBuilder creates a new request builder for the given model
+func NewRequestBuilder(model string) *RequestBuilder {
+	return &RequestBuilder{
+		req: provider.ChatCompletionRequest{Model: model},
+	}
+}
+
+// NewRequest creates a new request builder for the given model. It is
+// shorthand for NewRequestBuilder, intended for fluent one-liners:
+//
+//	req := omnillm.NewRequest("gpt-4o").System("Be brief").User("Hi").Temperature(0.2).Request()
+func NewRequest(model string) *RequestBuilder {
+	return NewRequestBuilder(model)
+}
+
+// Model sets the model
+func (b *RequestBuilder) Model(model string) *RequestBuilder {
+	b.req.Model = model
+	return b
+}
+
+// Message appends a message
+func (b *RequestBuilder) Message(msg provider.Message) *RequestBuilder {
+	b.req.Messages = append(b.req.Messages, msg)
+	return b
+}
+
+// Messages appends multiple messages
+func (b *RequestBuilder) Messages(msgs ...provider.Message) *RequestBuilder {
+	b.req.Messages = append(b.req.Messages, msgs...)
+	return b
+}
+
+// System appends a system message
+func (b *RequestBuilder) System(content string) *RequestBuilder {
+	return b.Message(provider.Message{Role: provider.RoleSystem, Content: content})
+}
+
+// User appends a user message
+func (b *RequestBuilder) User(content string) *RequestBuilder {
+	return b.Message(provider.Message{Role: provider.RoleUser, Content: content})
+}
+
+// Assistant appends an assistant message
+func (b *RequestBuilder) Assistant(content string) *RequestBuilder {
+	return b.Message(provider.Message{Role: provider.RoleAssistant, Content: content})
+}
+
+// UserParts appends a user message with text and additional content parts,
+// such as documents created with NewDocumentPart
+func (b *RequestBuilder) UserParts(content string, parts ...provider.ContentPart) *RequestBuilder {
+	return b.Message(provider.Message{Role: provider.RoleUser, Content: content, Parts: parts})
+}
+
+// ToolResult appends a tool message answering the tool call with the given ID
+func (b *RequestBuilder) ToolResult(toolCallID, content string) *RequestBuilder {
+	return b.Message(provider.Message{Role: provider.RoleTool, Content: content, ToolCallID: &toolCallID})
+}
+
+// MaxTokens sets the maximum number of completion tokens
+func (b *RequestBuilder) MaxTokens(n int) *RequestBuilder {
+	b.req.MaxTokens = &n
+	return b
+}
+
+// Temperature sets the sampling temperature
+func (b *RequestBuilder) Temperature(t float64) *RequestBuilder {
+	b.req.Temperature = &t
+	return b
+}
+
+// TopP sets nucleus sampling
+func (b *RequestBuilder) TopP(p float64) *RequestBuilder {
+	b.req.TopP = &p
+	return b
+}
+
+// TopK sets top-k sampling (Anthropic, Gemini, Ollama)
+func (b *RequestBuilder) TopK(k int) *RequestBuilder {
+	b.req.TopK = &k
+	return b
+}
+
+// Stop sets the stop sequences
+func (b *RequestBuilder) Stop(sequences ...string) *RequestBuilder {
+	b.req.Stop = append(b.req.Stop, sequences...)
+	return b
+}
+
+// PresencePenalty sets the presence penalty
+func (b *RequestBuilder) PresencePenalty(p float64) *RequestBuilder {
+	b.req.PresencePenalty = &p
+	return b
+}
+
+// FrequencyPenalty sets the frequency penalty
+func (b *RequestBuilder) FrequencyPenalty(p float64) *RequestBuilder {
+	b.req.FrequencyPenalty = &p
+	return b
+}
+
+// UserID sets the end-user identifier sent to the provider
+func (b *RequestBuilder) UserID(id string) *RequestBuilder {
+	b.req.User = &id
+	return b
+}
+
+// Seed sets the seed for reproducible outputs
+func (b *RequestBuilder) Seed(seed int) *RequestBuilder {
+	b.req.Seed = &seed
+	return b
+}
+
+// N sets the number of completions to generate
+func (b *RequestBuilder) N(n int) *RequestBuilder {
+	b.req.N = &n
+	return b
+}
+
+// Tool appends a function tool
+func (b *RequestBuilder) Tool(name, description string, parameters any) *RequestBuilder {
+	b.req.Tools = append(b.req.Tools, provider.Tool{
+		Type: provider.ToolTypeFunction,
+		Function: provider.ToolSpec{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	})
+	return b
+}
+
+// Tools appends tools
+func (b *RequestBuilder) Tools(tools ...provider.Tool) *RequestBuilder {
+	b.req.Tools = append(b.req.Tools, tools...)
+	return b
+}
+
+// FileSearch enables provider-hosted retrieval over the given vector stores
+func (b *RequestBuilder) FileSearch(vectorStoreIDs ...string) *RequestBuilder {
+	b.req.Tools = append(b.req.Tools, provider.Tool{
+		Type:       provider.ToolTypeFileSearch,
+		FileSearch: &provider.FileSearchTool{VectorStoreIDs: vectorStoreIDs},
+	})
+	return b
+}
+
+// ToolChoice sets the tool choice
+func (b *RequestBuilder) ToolChoice(choice any) *RequestBuilder {
+	b.req.ToolChoice = choice
+	return b
+}
+
+// JSONMode requests a JSON object response
+func (b *RequestBuilder) JSONMode() *RequestBuilder {
+	b.req.ResponseFormat = &provider.ResponseFormat{Type: provider.ResponseFormatJSONObject}
+	return b
+}
+
+// JSONSchema requests structured output matching schema. With strict set,
+// providers that support it (OpenAI, X.AI) guarantee exact schema adherence.
+func (b *RequestBuilder) JSONSchema(name string, schema any, strict bool) *RequestBuilder {
+	b.req.ResponseFormat = &provider.ResponseFormat{
+		Type: provider.ResponseFormatJSONSchema,
+		JSONSchema: &provider.JSONSchema{
+			Name:   name,
+			Schema: schema,
+			Strict: &strict,
+		},
+	}
+	return b
+}
+
+// Logprobs enables log probabilities with the given number of top logprobs (0 to omit)
+func (b *RequestBuilder) Logprobs(topLogprobs int) *RequestBuilder {
+	enabled := true
+	b.req.Logprobs = &enabled
+	if topLogprobs > 0 {
+		b.req.TopLogprobs = &topLogprobs
+	}
+	return b
+}
+
+// Thinking enables extended thinking with the given token budget (Anthropic).
+// The reasoning is returned in Message.Thinking, separate from the content.
+func (b *RequestBuilder) Thinking(budgetTokens int) *RequestBuilder {
+	b.req.Thinking = &provider.ThinkingConfig{BudgetTokens: budgetTokens}
+	return b
+}
+
+// IncludeRaw asks the provider to attach the raw request and response bodies
+// to ChatCompletionResponse.Raw, for providers that support it
+func (b *RequestBuilder) IncludeRaw() *RequestBuilder {
+	b.req.IncludeRaw = true
+	return b
+}
+
+// ProviderOptions sets provider-specific options for one provider, such as an
+// openai.Options or anthropic.Options value. Other providers ignore them.
+func (b *RequestBuilder) ProviderOptions(name ProviderName, opts any) *RequestBuilder {
+	options := make(map[string]any, len(b.req.ProviderOptions)+1)
+	maps.Copy(options, b.req.ProviderOptions)
+	options[string(name)] = opts
+	b.req.ProviderOptions = options
+	return b
+}
+
+// Build validates the accumulated request and returns it.
+// If any problems were found, all of them are returned as ValidationErrors.
+func (b *RequestBuilder) Build() (*provider.ChatCompletionRequest, error) {
+	if errs := validateRequest(&b.req); len(errs) > 0 {
+		return nil, errs
+	}
+
+	return b.Request(), nil
+}
+
+// MustBuild is like Build but panics if the request is invalid.
+// It is intended for requests built from constant inputs.
+func (b *RequestBuilder) MustBuild() *provider.ChatCompletionRequest {
+	req, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
+
+// Request returns the accumulated request without validating it.
+// Providers and the client still reject invalid requests when they are sent.
+func (b *RequestBuilder) Request() *provider.ChatCompletionRequest {
+	return &b.Clone().req
+}
+
+// Clone returns an independent copy of the builder, so a common base request
+// can be extended in several directions without the copies affecting each other.
+func (b *RequestBuilder) Clone() *RequestBuilder {
+	clone := &RequestBuilder{req: b.req}
+	clone.req.Messages = append([]provider.Message(nil), b.req.Messages...)
+	clone.req.Stop = append([]string(nil), b.req.Stop...)
+	clone.req.Tools = append([]provider.Tool(nil), b.req.Tools...)
+	return clone
+}
+
+// validateRequest checks a request for problems that providers would reject
+func validateRequest(req *provider.ChatCompletionRequest) ValidationErrors {
+	var errs ValidationErrors
+	errors.As(provider.ValidateRequest(req), &errs)
+	return errs
+}
@@ -0,0 +1,241 @@
+package omnillm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestRequestBuilder_Build(t *testing.T) {
+	req, err := NewRequestBuilder(ModelGPT4o).
+		System("You are helpful").
+		User("Hello").
+		MaxTokens(100).
+		Temperature(0.7).
+		Tool("get_weather", "Get weather", map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"location": map[string]any{"type": "string"},
+			},
+			"required": []string{"location"},
+		}).
+		ToolChoice("auto").
+		Build()
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if req.Model != ModelGPT4o {
+		t.Errorf("Model = %s, want %s", req.Model, ModelGPT4o)
+	}
+	if len(req.Messages) != 2 {
+		t.Errorf("Messages count = %d, want 2", len(req.Messages))
+	}
+	if req.MaxTokens == nil || *req.MaxTokens != 100 {
+		t.Error("MaxTokens not set")
+	}
+	if len(req.Tools) != 1 {
+		t.Errorf("Tools count = %d, want 1", len(req.Tools))
+	}
+}
+
+func TestRequestBuilder_AccumulatesErrors(t *testing.T) {
+	_, err := NewRequestBuilder("").
+		Temperature(3).
+		TopP(1.5).
+		ToolChoice("auto").
+		Build()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("error type = %T, want ValidationErrors", err)
+	}
+
+	fields := make(map[string]bool)
+	for _, ve := range verrs {
+		fields[ve.Field] = true
+	}
+	for _, want := range []string{"model", "messages", "temperature", "top_p", "tool_choice"} {
+		if !fields[want] {
+			t.Errorf("missing validation error for %s (got %v)", want, err)
+		}
+	}
+
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Error("errors.Is(err, ErrInvalidRequest) = false, want true")
+	}
+	if !IsNonRetryableError(err) {
+		t.Error("validation errors should be non-retryable")
+	}
+}
+
+func TestRequestBuilder_InvalidToolSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   string
+		params any
+		field  string
+	}{
+		{"invalid name", "get weather", nil, "tools[0].function.name"},
+		{"non-object schema", "get_weather", map[string]any{"type": "string"}, "tools[0].function.parameters"},
+		{"undefined required", "get_weather", map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+			"required":   []any{"location"},
+		}, "tools[0].function.parameters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRequestBuilder(ModelGPT4o).User("hi").Tool(tt.tool, "", tt.params).Build()
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) {
+				t.Fatalf("error = %v, want ValidationErrors", err)
+			}
+			if len(verrs) != 1 || verrs[0].Field != tt.field {
+				t.Errorf("errors = %v, want single error on %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestRequestBuilder_DuplicateToolNames(t *testing.T) {
+	_, err := NewRequestBuilder(ModelGPT4o).
+		User("hi").
+		Tool("lookup", "", nil).
+		Tool("lookup", "", nil).
+		Build()
+	if err == nil {
+		t.Fatal("expected duplicate tool name error")
+	}
+}
+
+func TestRequestBuilder_ToolMessageRequiresID(t *testing.T) {
+	_, err := NewRequestBuilder(ModelGPT4o).
+		Message(Message{Role: RoleTool, Content: "result"}).
+		Build()
+	if err == nil {
+		t.Fatal("expected error for tool message without tool_call_id")
+	}
+}
+
+func TestNewRequest_Fluent(t *testing.T) {
+	req := NewRequest("gpt-4o").
+		System("Be brief").
+		User("What's the weather?").
+		Temperature(0.2).
+		MaxTokens(500).
+		Tool("get_weather", "Get the weather", map[string]any{"type": "object"}).
+		Request()
+
+	if req.Model != "gpt-4o" || len(req.Messages) != 2 || len(req.Tools) != 1 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if *req.Temperature != 0.2 || *req.MaxTokens != 500 {
+		t.Errorf("Temperature/MaxTokens = %v/%v", *req.Temperature, *req.MaxTokens)
+	}
+}
+
+func TestRequestBuilder_RequestSkipsValidation(t *testing.T) {
+	req := NewRequest("").Request()
+	if req == nil || req.Model != "" {
+		t.Errorf("Request() = %+v, want unvalidated empty request", req)
+	}
+}
+
+func TestRequestBuilder_Clone(t *testing.T) {
+	base := NewRequest("gpt-4o").System("Be brief")
+	a := base.Clone().User("first").Request()
+	b := base.Clone().User("second").Request()
+
+	if len(a.Messages) != 2 || a.Messages[1].Content != "first" {
+		t.Errorf("a.Messages = %+v", a.Messages)
+	}
+	if len(b.Messages) != 2 || b.Messages[1].Content != "second" {
+		t.Errorf("b.Messages = %+v", b.Messages)
+	}
+	if len(base.Request().Messages) != 1 {
+		t.Errorf("base was modified by clones")
+	}
+}
+
+func TestRequestBuilder_ToolResultAndUserParts(t *testing.T) {
+	req := NewRequest("gpt-4o").
+		UserParts("Summarize", NewDocumentPart([]byte("%PDF"), "application/pdf", "a.pdf")).
+		Assistant("calling tool").
+		ToolResult("call_1", "done").
+		MustBuild()
+
+	if len(req.Messages[0].Parts) != 1 {
+		t.Errorf("UserParts parts = %+v", req.Messages[0].Parts)
+	}
+	if tool := req.Messages[2]; tool.Role != RoleTool || *tool.ToolCallID != "call_1" {
+		t.Errorf("ToolResult message = %+v", tool)
+	}
+}
+
+func TestRequestBuilder_MustBuildPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustBuild() did not panic on invalid request")
+		}
+	}()
+	NewRequest("").MustBuild()
+}
+
+func TestRequestBuilder_ProviderOptions(t *testing.T) {
+	base := NewRequest("gpt-4o").User("hi").ProviderOptions(ProviderNameOpenAI, map[string]any{"parallel_tool_calls": false})
+	withXAI := base.Clone().ProviderOptions(ProviderNameXAI, "x").Request()
+
+	if len(withXAI.ProviderOptions) != 2 || withXAI.ProviderOptions["xai"] != "x" {
+		t.Errorf("ProviderOptions = %v", withXAI.ProviderOptions)
+	}
+	if len(base.Request().ProviderOptions) != 1 {
+		t.Errorf("base was modified by clone: %v", base.Request().ProviderOptions)
+	}
+}
+
+func TestRequestBuilder_JSONSchema(t *testing.T) {
+	schema := map[string]any{"type": "object", "properties": map[string]any{"temp": map[string]any{"type": "number"}}}
+	req, err := NewRequest("gpt-4o").User("weather?").JSONSchema("weather_report", schema, true).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf := req.ResponseFormat
+	if rf.Type != provider.ResponseFormatJSONSchema || rf.JSONSchema.Name != "weather_report" || !*rf.JSONSchema.Strict {
+		t.Errorf("unexpected response format: %+v", rf)
+	}
+
+	_, err = NewRequest("gpt-4o").User("weather?").JSONSchema("bad name", []string{"x"}, false).Build()
+	if err == nil || !strings.Contains(err.Error(), "response_format.json_schema.name") {
+		t.Errorf("expected schema name error, got %v", err)
+	}
+	_, err = NewRequest("gpt-4o").User("weather?").JSONSchema("report", map[string]any{"type": "array"}, false).Build()
+	if err == nil || !strings.Contains(err.Error(), "response_format.json_schema.schema") {
+		t.Errorf("expected schema type error, got %v", err)
+	}
+}
+
+func TestRequestBuilder_Thinking(t *testing.T) {
+	req, err := NewRequest("claude-sonnet-4").User("27 * 453?").Thinking(2048).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Thinking == nil || req.Thinking.BudgetTokens != 2048 {
+		t.Errorf("Thinking = %+v", req.Thinking)
+	}
+
+	_, err = NewRequest("claude-sonnet-4").User("27 * 453?").Thinking(512).Build()
+	if err == nil || !strings.Contains(err.Error(), "thinking.budget_tokens") {
+		t.Errorf("expected budget error, got %v", err)
+	}
+	_, err = NewRequest("claude-sonnet-4").User("27 * 453?").MaxTokens(2000).Thinking(2048).Build()
+	if err == nil || !strings.Contains(err.Error(), "less than max_tokens") {
+		t.Errorf("expected max_tokens error, got %v", err)
+	}
+}
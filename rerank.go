@@ -0,0 +1,47 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrRerankNotSupported is returned when no reranking provider is configured
+var ErrRerankNotSupported = errors.New("reranking not supported by configured providers")
+
+// RerankConfig configures document reranking
+type RerankConfig struct {
+	// Provider is the rerank provider to use, such as a client from the
+	// providers/rerank package. If nil, the first configured provider that
+	// implements provider.RerankProvider is used.
+	Provider provider.RerankProvider
+
+	// Model is the default rerank model used when the request does not set one.
+	Model string
+}
+
+// Rerank orders documents by their relevance to the query, most relevant
+// first. Each result carries the index of its document in documents.
+func (c *ChatClient) Rerank(ctx context.Context, query string, documents []string) (*provider.RerankResponse, error) {
+	return c.CreateRerank(ctx, &provider.RerankRequest{Query: query, Documents: documents})
+}
+
+// CreateRerank reranks documents using the configured rerank provider. Like
+// moderation, this works regardless of which chat provider is active.
+func (c *ChatClient) CreateRerank(ctx context.Context, req *provider.RerankRequest) (*provider.RerankResponse, error) {
+	if c.reranker == nil {
+		return nil, ErrRerankNotSupported
+	}
+
+	rerankReq := *req
+	if rerankReq.Model == "" {
+		rerankReq.Model = c.rerankConfig.Model
+	}
+	return c.reranker.Rerank(ctx, &rerankReq)
+}
+
+// HasRerank returns true if a rerank provider is available
+func (c *ChatClient) HasRerank() bool {
+	return c.reranker != nil
+}
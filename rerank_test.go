@@ -0,0 +1,63 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockReranker is a standalone rerank provider that scores documents by length
+type mockReranker struct {
+	lastReq *provider.RerankRequest
+}
+
+func (m *mockReranker) Rerank(ctx context.Context, req *provider.RerankRequest) (*provider.RerankResponse, error) {
+	m.lastReq = req
+	resp := &provider.RerankResponse{Model: req.Model, Provider: "mock"}
+	for i, doc := range req.Documents {
+		resp.Results = append(resp.Results, provider.RerankResult{Index: i, Score: float64(len(doc)), Document: doc})
+	}
+	return resp, nil
+}
+
+func TestChatClient_Rerank(t *testing.T) {
+	reranker := &mockReranker{}
+	client, err := NewClient(ClientConfig{
+		Providers:    []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+		RerankConfig: &RerankConfig{Provider: reranker, Model: "rerank-default"},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if !client.HasRerank() {
+		t.Fatal("HasRerank() = false")
+	}
+
+	resp, err := client.Rerank(context.Background(), "query", []string{"a", "bb"})
+	if err != nil {
+		t.Fatalf("Rerank failed: %v", err)
+	}
+	if reranker.lastReq.Model != "rerank-default" || reranker.lastReq.Query != "query" {
+		t.Errorf("request = %+v", reranker.lastReq)
+	}
+	if len(resp.Results) != 2 || resp.Results[1].Document != "bb" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestChatClient_Rerank_NotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if client.HasRerank() {
+		t.Error("HasRerank() = true")
+	}
+	if _, err := client.Rerank(context.Background(), "q", []string{"d"}); !errors.Is(err, ErrRerankNotSupported) {
+		t.Errorf("Rerank error = %v, want ErrRerankNotSupported", err)
+	}
+}
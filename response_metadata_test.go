@@ -0,0 +1,47 @@
+package omnillm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestChatClient_ResponseMetadata(t *testing.T) {
+	mockProv := mocktest.NewScriptedProvider("scripted").Default(mocktest.TextStep("hi"))
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mockProv}},
+		Cache:     mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.CacheHit() {
+		t.Error("expected the first response not to be a cache hit")
+	}
+	if _, ok := resp.ProviderMetadata[MetadataKeyLatency]; !ok {
+		t.Errorf("expected the latency to be recorded, got %v", resp.ProviderMetadata)
+	}
+
+	cached, err := client.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cached.CacheHit() {
+		t.Error("expected the second response to be a cache hit")
+	}
+	if _, ok := cached.CachedAt(); !ok {
+		t.Error("expected CachedAt on a cache hit")
+	}
+	if mockProv.Calls() != 1 {
+		t.Errorf("expected one provider call, got %d", mockProv.Calls())
+	}
+}
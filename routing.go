@@ -0,0 +1,395 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grokify/mogo/log/slogutil"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrNoRoute is returned when no route target satisfies a request's constraints
+var ErrNoRoute = errors.New("no route target satisfies the request constraints")
+
+// RoutingPolicy selects the order in which a RouterProvider tries its targets
+type RoutingPolicy int
+
+const (
+	// RouteInOrder tries capable targets in configuration order
+	RouteInOrder RoutingPolicy = iota
+	// CheapestCapable tries capable targets from the lowest to the highest
+	// estimated cost, using catalog pricing. Targets without pricing are
+	// tried last.
+	CheapestCapable
+)
+
+// String returns the string representation of the policy
+func (p RoutingPolicy) String() string {
+	switch p {
+	case RouteInOrder:
+		return "in-order"
+	case CheapestCapable:
+		return "cheapest-capable"
+	default:
+		return "unknown"
+	}
+}
+
+// defaultRouteOutputTokens is the completion length assumed when estimating
+// cost for a request without MaxTokens
+const defaultRouteOutputTokens = 1024
+
+// RouteTarget is a provider and model that a RouterProvider can send requests to
+type RouteTarget struct {
+	// Provider serves the target's requests
+	Provider provider.Provider
+
+	// Model replaces the request model. If empty, the request model is used.
+	Model string
+
+	// Spec overrides the catalog entry for Model, e.g. for fine-tuned or
+	// local models. Set Spec.Pricing to a zero ModelPricing for free models.
+	Spec *ModelSpec
+}
+
+// RoutingConstraints limit which targets may serve a request
+type RoutingConstraints struct {
+	// Capabilities lists required capabilities. Only the true fields are
+	// checked. Tools and JSONMode are also required automatically when the
+	// request uses tools or a JSON response format.
+	Capabilities provider.ModelCapabilities
+
+	// MaxLatency excludes targets whose moving-average latency exceeds it.
+	// Targets that have not served a request yet are not excluded.
+	MaxLatency time.Duration
+
+	// MinContextWindow excludes targets with a smaller context window. The
+	// request's estimated prompt tokens plus MaxTokens are always required
+	// to fit. Targets with an unknown context window are not excluded.
+	MinContextWindow int
+}
+
+// RouterConfig configures a RouterProvider
+type RouterConfig struct {
+	// Targets are the candidate provider/model pairs
+	Targets []RouteTarget
+
+	// Policy is the default routing policy. Override it per request with
+	// WithRoutingPolicy. Default: RouteInOrder
+	Policy RoutingPolicy
+
+	// Constraints apply to every request. Override them per request with
+	// WithRoutingConstraints.
+	Constraints RoutingConstraints
+
+	// Catalog supplies model specs and pricing. Default: DefaultModelCatalog()
+	Catalog *ModelCatalog
+
+	// TokenEstimator estimates prompt tokens for cost and context checks.
+	// Default: NewTokenEstimator(DefaultTokenEstimatorConfig())
+	TokenEstimator TokenEstimator
+
+	// Name is returned by Name. Default: "router"
+	Name string
+
+	// Logger for logging routing decisions
+	Logger *slog.Logger
+}
+
+// RouterProvider routes each request to a provider/model target chosen by a
+// RoutingPolicy among the targets that satisfy its constraints. The chosen
+// target is tried first; on a retryable error the remaining capable targets
+// are tried in policy order. It implements provider.Provider, so it can be
+// used as a CustomProvider.
+type RouterProvider struct {
+	targets     []*routeTarget
+	policy      RoutingPolicy
+	constraints RoutingConstraints
+	catalog     *ModelCatalog
+	estimator   TokenEstimator
+	name        string
+	logger      *slog.Logger
+
+	mu sync.Mutex // Guards routeTarget.latency
+}
+
+// routeTarget tracks one target and its observed latency
+type routeTarget struct {
+	RouteTarget
+	latency time.Duration // Exponentially weighted moving average
+}
+
+// routeCandidate is a capable target with its estimated cost for one request
+type routeCandidate struct {
+	target *routeTarget
+	model  string
+	cost   float64 // NaN if the target has no pricing
+}
+
+// NewRouterProvider creates a provider that routes requests across targets
+func NewRouterProvider(config RouterConfig) *RouterProvider {
+	r := &RouterProvider{
+		policy:      config.Policy,
+		constraints: config.Constraints,
+		catalog:     config.Catalog,
+		estimator:   config.TokenEstimator,
+		name:        config.Name,
+		logger:      config.Logger,
+	}
+	if r.catalog == nil {
+		r.catalog = DefaultModelCatalog()
+	}
+	if r.estimator == nil {
+		r.estimator = NewTokenEstimator(DefaultTokenEstimatorConfig())
+	}
+	if r.name == "" {
+		r.name = "router"
+	}
+	if r.logger == nil {
+		r.logger = slogutil.Null()
+	}
+	for _, t := range config.Targets {
+		r.targets = append(r.targets, &routeTarget{RouteTarget: t})
+	}
+	return r
+}
+
+type routingPolicyContextKey struct{}
+
+type routingConstraintsContextKey struct{}
+
+// WithRoutingPolicy returns a context that makes a RouterProvider use policy
+// for requests made with it:
+//
+//	ctx = omnillm.WithRoutingPolicy(ctx, omnillm.CheapestCapable)
+func WithRoutingPolicy(ctx context.Context, policy RoutingPolicy) context.Context {
+	return context.WithValue(ctx, routingPolicyContextKey{}, policy)
+}
+
+// WithRoutingConstraints returns a context that makes a RouterProvider use
+// constraints, instead of its configured constraints, for requests made with it
+func WithRoutingConstraints(ctx context.Context, constraints RoutingConstraints) context.Context {
+	return context.WithValue(ctx, routingConstraintsContextKey{}, constraints)
+}
+
+// CreateChatCompletion sends the request to the best target, trying the
+// others on retryable errors
+func (r *RouterProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	candidates, err := r.route(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts, _ := timeoutsFromContext(ctx)
+	var attempts []FallbackAttempt
+	for _, c := range candidates {
+		routed := *req
+		routed.Model = c.model
+
+		start := time.Now()
+		resp, err := completeWithTimeouts(ctx, c.target.Provider, &routed, timeouts)
+		duration := time.Since(start)
+		attempts = append(attempts, FallbackAttempt{Provider: c.target.Provider.Name(), Error: err, Duration: duration})
+
+		if err == nil {
+			r.recordLatency(c.target, duration)
+			if resp.ProviderMetadata == nil {
+				resp.ProviderMetadata = make(map[string]any)
+			}
+			resp.ProviderMetadata["route_provider"] = c.target.Provider.Name()
+			resp.ProviderMetadata["route_model"] = c.model
+			if !math.IsNaN(c.cost) {
+				resp.ProviderMetadata["route_estimated_cost"] = c.cost
+			}
+			return resp, nil
+		}
+
+		r.logger.Debug("routed request failed",
+			slog.String("provider", c.target.Provider.Name()),
+			slog.String("model", c.model),
+			slog.String("error", err.Error()))
+		if IsNonRetryableError(err) {
+			return nil, err
+		}
+	}
+	return nil, &FallbackError{Attempts: attempts, LastError: attempts[len(attempts)-1].Error}
+}
+
+// CreateChatCompletionStream opens a stream on the best target, trying the
+// others on retryable errors
+func (r *RouterProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	candidates, err := r.route(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts, _ := timeoutsFromContext(ctx)
+	var attempts []FallbackAttempt
+	for _, c := range candidates {
+		routed := *req
+		routed.Model = c.model
+
+		start := time.Now()
+		stream, err := openStreamWithTimeouts(ctx, c.target.Provider, &routed, timeouts)
+		duration := time.Since(start)
+		attempts = append(attempts, FallbackAttempt{Provider: c.target.Provider.Name(), Error: err, Duration: duration})
+
+		if err == nil {
+			r.recordLatency(c.target, duration)
+			return stream, nil
+		}
+		if IsNonRetryableError(err) {
+			return nil, err
+		}
+	}
+	return nil, &FallbackError{Attempts: attempts, LastError: attempts[len(attempts)-1].Error}
+}
+
+// route returns the targets that satisfy the request's constraints and
+// whose providers support the features it uses, in policy order
+func (r *RouterProvider) route(ctx context.Context, req *provider.ChatCompletionRequest, stream bool) ([]routeCandidate, error) {
+	policy := r.policy
+	if p, ok := ctx.Value(routingPolicyContextKey{}).(RoutingPolicy); ok {
+		policy = p
+	}
+	constraints := r.constraints
+	if c, ok := ctx.Value(routingConstraintsContextKey{}).(RoutingConstraints); ok {
+		constraints = c
+	}
+
+	required := constraints.Capabilities
+	if len(req.Tools) > 0 {
+		required.Tools = true
+	}
+	if req.ResponseFormat != nil && req.ResponseFormat.Type != provider.ResponseFormatText {
+		required.JSONMode = true
+	}
+
+	outputTokens := defaultRouteOutputTokens
+	if req.MaxTokens != nil {
+		outputTokens = *req.MaxTokens
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var candidates []routeCandidate
+	for _, t := range r.targets {
+		model := t.Model
+		if model == "" {
+			model = req.Model
+		}
+		spec, known := r.spec(t, model)
+
+		if !known && hasCapabilities(required) {
+			continue
+		}
+		if !satisfiesCapabilities(spec.Capabilities, required) {
+			continue
+		}
+		if checkCapabilities(t.Provider, req, stream) != nil {
+			continue
+		}
+		if constraints.MaxLatency > 0 && t.latency > constraints.MaxLatency {
+			continue
+		}
+
+		promptTokens, err := r.estimator.EstimateTokens(model, req.Messages)
+		if err != nil {
+			return nil, err
+		}
+		if spec.ContextWindow > 0 {
+			if spec.ContextWindow < constraints.MinContextWindow || spec.ContextWindow < promptTokens+outputTokens {
+				continue
+			}
+		}
+
+		cost := math.NaN()
+		if spec.Pricing != nil {
+			cost = spec.Pricing.Cost(provider.Usage{PromptTokens: promptTokens, CompletionTokens: outputTokens})
+		}
+		candidates = append(candidates, routeCandidate{target: t, model: model, cost: cost})
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoRoute
+	}
+
+	if policy == CheapestCapable {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			ci, cj := candidates[i].cost, candidates[j].cost
+			if math.IsNaN(cj) {
+				return !math.IsNaN(ci)
+			}
+			return ci < cj
+		})
+	}
+
+	r.logger.Debug("routed request",
+		slog.String("policy", policy.String()),
+		slog.String("provider", candidates[0].target.Provider.Name()),
+		slog.String("model", candidates[0].model),
+		slog.Int("candidates", len(candidates)))
+	return candidates, nil
+}
+
+// spec returns a target's spec override or its catalog entry, and whether either exists
+func (r *RouterProvider) spec(t *routeTarget, model string) (ModelSpec, bool) {
+	if t.Spec != nil {
+		return *t.Spec, true
+	}
+	return r.catalog.Lookup(model)
+}
+
+// hasCapabilities returns true if any capability is set
+func hasCapabilities(c provider.ModelCapabilities) bool {
+	return c != provider.ModelCapabilities{}
+}
+
+// satisfiesCapabilities returns true if have includes every capability set in want
+func satisfiesCapabilities(have, want provider.ModelCapabilities) bool {
+	return (!want.Chat || have.Chat) &&
+		(!want.Streaming || have.Streaming) &&
+		(!want.Tools || have.Tools) &&
+		(!want.Vision || have.Vision) &&
+		(!want.Embeddings || have.Embeddings) &&
+		(!want.JSONMode || have.JSONMode)
+}
+
+// recordLatency updates a target's latency average
+func (r *RouterProvider) recordLatency(t *routeTarget, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t.latency == 0 {
+		t.latency = latency
+	} else {
+		t.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(t.latency))
+	}
+}
+
+// Close closes every target's provider once
+func (r *RouterProvider) Close() error {
+	var errs []error
+	closed := make(map[provider.Provider]bool)
+	for _, t := range r.targets {
+		if closed[t.Provider] {
+			continue
+		}
+		closed[t.Provider] = true
+		if err := t.Provider.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name returns the configured name
+func (r *RouterProvider) Name() string {
+	return r.name
+}
@@ -0,0 +1,122 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func newTestRouter(policy RoutingPolicy) (*RouterProvider, []*countingProvider) {
+	providers := newCountingProviders(3)
+	catalog := NewModelCatalog(
+		ModelSpec{ID: "big", ContextWindow: 200000, Capabilities: chatCapabilities(true, true, true), Pricing: pricing(3, 15, 0)},
+		ModelSpec{ID: "small", ContextWindow: 16000, Capabilities: chatCapabilities(false, false, false), Pricing: pricing(0.1, 0.4, 0)},
+		ModelSpec{ID: "mid", ContextWindow: 128000, Capabilities: chatCapabilities(true, false, true), Pricing: pricing(1, 4, 0)},
+	)
+	router := NewRouterProvider(RouterConfig{
+		Targets: []RouteTarget{
+			{Provider: providers[0], Model: "big"},
+			{Provider: providers[1], Model: "small"},
+			{Provider: providers[2], Model: "mid"},
+		},
+		Policy:  policy,
+		Catalog: catalog,
+	})
+	return router, providers
+}
+
+func TestRouterProvider_Policies(t *testing.T) {
+	router, _ := newTestRouter(RouteInOrder)
+	req := &provider.ChatCompletionRequest{Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+
+	resp, err := router.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := resp.ProviderMetadata["route_model"]; got != "big" {
+		t.Errorf("expected in-order route to big, got %v", got)
+	}
+
+	resp, err = router.CreateChatCompletion(WithRoutingPolicy(context.Background(), CheapestCapable), req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := resp.ProviderMetadata["route_model"]; got != "small" {
+		t.Errorf("expected cheapest route to small, got %v", got)
+	}
+	if cost, ok := resp.ProviderMetadata["route_estimated_cost"].(float64); !ok || cost <= 0 {
+		t.Errorf("expected estimated cost, got %v", resp.ProviderMetadata["route_estimated_cost"])
+	}
+	if req.Model != "" {
+		t.Error("expected the caller's request to be left unchanged")
+	}
+}
+
+func TestRouterProvider_Constraints(t *testing.T) {
+	router, _ := newTestRouter(CheapestCapable)
+
+	// Tools rule out the small model
+	req := &provider.ChatCompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+		Tools:    []provider.Tool{{Type: provider.ToolTypeFunction, Function: provider.ToolSpec{Name: "lookup"}}},
+	}
+	resp, err := router.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := resp.ProviderMetadata["route_model"]; got != "mid" {
+		t.Errorf("expected cheapest tool-capable route to mid, got %v", got)
+	}
+
+	// Vision and a large context leave only the big model
+	ctx := WithRoutingConstraints(context.Background(), RoutingConstraints{
+		Capabilities:     provider.ModelCapabilities{Vision: true},
+		MinContextWindow: 150000,
+	})
+	resp, err = router.CreateChatCompletion(ctx, req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := resp.ProviderMetadata["route_model"]; got != "big" {
+		t.Errorf("expected route to big, got %v", got)
+	}
+
+	ctx = WithRoutingConstraints(context.Background(), RoutingConstraints{MinContextWindow: 500000})
+	if _, err := router.CreateChatCompletion(ctx, req); !errors.Is(err, ErrNoRoute) {
+		t.Errorf("expected ErrNoRoute, got %v", err)
+	}
+}
+
+func TestRouterProvider_MaxLatencyAndFailover(t *testing.T) {
+	router, providers := newTestRouter(CheapestCapable)
+	providers[1].delay = 20 * time.Millisecond
+	req := &provider.ChatCompletionRequest{Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+
+	if _, err := router.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	// The measured slow target is excluded
+	ctx := WithRoutingConstraints(context.Background(), RoutingConstraints{MaxLatency: 10 * time.Millisecond})
+	resp, err := router.CreateChatCompletion(ctx, req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if got := resp.ProviderMetadata["route_model"]; got != "mid" {
+		t.Errorf("expected slow target to be skipped, got %v", got)
+	}
+
+	// A retryable error moves on to the next cheapest target
+	providers[1].delay = 0
+	providers[1].err = ErrServerError
+	resp, err = router.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if got := resp.ProviderMetadata["route_model"]; got != "mid" {
+		t.Errorf("expected failover to mid, got %v", got)
+	}
+}
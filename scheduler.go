@@ -0,0 +1,274 @@
+package omnillm
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrSchedulerQueueFull is returned when a provider's request queue is at
+// SchedulerConfig.MaxQueued. It is retryable, so fallback moves on to the next
+// provider.
+var ErrSchedulerQueueFull = errors.New("scheduler queue is full")
+
+// RequestPriority orders queued requests. Higher priorities are served
+// first; requests with equal priority are served in arrival order.
+type RequestPriority int
+
+const (
+	// PriorityBatch is for background work that can wait
+	PriorityBatch RequestPriority = -10
+	// PriorityNormal is the default priority
+	PriorityNormal RequestPriority = 0
+	// PriorityInteractive is for requests a user is waiting on
+	PriorityInteractive RequestPriority = 10
+)
+
+type priorityContextKey struct{}
+
+// WithPriority returns a context that gives requests made with it priority
+// in the client's scheduler
+func WithPriority(ctx context.Context, priority RequestPriority) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, priority)
+}
+
+// PriorityFromContext returns the priority set with WithPriority, or PriorityNormal
+func PriorityFromContext(ctx context.Context) RequestPriority {
+	if p, ok := ctx.Value(priorityContextKey{}).(RequestPriority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// SchedulerConfig configures per-provider concurrency limits
+type SchedulerConfig struct {
+	// MaxConcurrent caps in-flight requests per provider. Requests over the
+	// cap wait in a priority queue. A stream holds its slot until it ends or
+	// is closed. If 0, providers without a ProviderLimits entry are not limited.
+	MaxConcurrent int
+
+	// ProviderLimits overrides MaxConcurrent for providers by name
+	ProviderLimits map[string]int
+
+	// MaxQueued caps waiting requests per provider. Requests beyond it fail
+	// with ErrSchedulerQueueFull. If 0, the queue is unbounded.
+	MaxQueued int
+}
+
+// SchedulerStats is a snapshot of one provider's scheduler state
+type SchedulerStats struct {
+	Limit    int
+	InFlight int
+	Queued   int
+}
+
+// Scheduler caps in-flight requests per provider and queues the excess by
+// priority. NewClient creates one when ClientConfig.SchedulerConfig is set.
+type Scheduler struct {
+	config SchedulerConfig
+
+	mu     sync.Mutex
+	queues map[string]*schedulerQueue
+	seq    uint64
+}
+
+// schedulerQueue is the state of one provider
+type schedulerQueue struct {
+	limit    int
+	inFlight int
+	waiting  waiterHeap
+}
+
+// waiter is a queued request. granted is set, under Scheduler.mu, when the
+// request is handed a slot.
+type waiter struct {
+	priority RequestPriority
+	seq      uint64
+	ready    chan struct{}
+	granted  bool
+	index    int
+}
+
+// NewScheduler creates a scheduler
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	return &Scheduler{config: config, queues: make(map[string]*schedulerQueue)}
+}
+
+// Acquire waits for an in-flight slot for the provider and returns a
+// function that releases it. Waiting ends early with ctx.Err() if ctx is
+// done. Release must be called exactly once; later calls do nothing.
+func (s *Scheduler) Acquire(ctx context.Context, providerName string, priority RequestPriority) (func(), error) {
+	s.mu.Lock()
+	q := s.queue(providerName)
+	if q.limit <= 0 {
+		s.mu.Unlock()
+		return func() {}, nil
+	}
+	if q.inFlight < q.limit && len(q.waiting) == 0 {
+		q.inFlight++
+		s.mu.Unlock()
+		return s.releaser(q), nil
+	}
+	if s.config.MaxQueued > 0 && len(q.waiting) >= s.config.MaxQueued {
+		s.mu.Unlock()
+		return nil, ErrSchedulerQueueFull
+	}
+
+	s.seq++
+	w := &waiter{priority: priority, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&q.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return s.releaser(q), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.granted {
+			// The slot arrived as we gave up; pass it on
+			s.mu.Unlock()
+			s.releaser(q)()
+		} else {
+			heap.Remove(&q.waiting, w.index)
+			s.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// queue returns the provider's queue, creating it (must be called with lock held)
+func (s *Scheduler) queue(providerName string) *schedulerQueue {
+	q, ok := s.queues[providerName]
+	if !ok {
+		limit := s.config.MaxConcurrent
+		if l, ok := s.config.ProviderLimits[providerName]; ok {
+			limit = l
+		}
+		q = &schedulerQueue{limit: limit}
+		s.queues[providerName] = q
+	}
+	return q
+}
+
+// releaser returns a function that hands the slot to the next waiter, or
+// frees it if none is waiting
+func (s *Scheduler) releaser(q *schedulerQueue) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if len(q.waiting) == 0 {
+				q.inFlight--
+				return
+			}
+			next := heap.Pop(&q.waiting).(*waiter)
+			next.granted = true
+			close(next.ready)
+		})
+	}
+}
+
+// Stats returns the state of every provider the scheduler has seen
+func (s *Scheduler) Stats() map[string]SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make(map[string]SchedulerStats, len(s.queues))
+	for name, q := range s.queues {
+		stats[name] = SchedulerStats{Limit: q.limit, InFlight: q.inFlight, Queued: len(q.waiting)}
+	}
+	return stats
+}
+
+// waiterHeap orders waiters by priority, then arrival
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() any {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	w.index = -1
+	return w
+}
+
+// scheduledProvider runs a provider's requests through a scheduler
+type scheduledProvider struct {
+	provider.Provider
+	scheduler *Scheduler
+}
+
+func (p *scheduledProvider) unwrapProvider() provider.Provider {
+	return p.Provider
+}
+
+func (p *scheduledProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	release, err := p.scheduler.Acquire(ctx, p.Name(), PriorityFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.Provider.CreateChatCompletion(ctx, req)
+}
+
+func (p *scheduledProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	release, err := p.scheduler.Acquire(ctx, p.Name(), PriorityFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	stream, err := p.Provider.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &scheduledStream{ChatCompletionStream: stream, release: release}, nil
+}
+
+// scheduledStream releases its scheduler slot when the stream ends or is closed
+type scheduledStream struct {
+	provider.ChatCompletionStream
+	release func()
+}
+
+func (s *scheduledStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if err != nil {
+		s.release()
+	}
+	return chunk, err
+}
+
+func (s *scheduledStream) Close() error {
+	s.release()
+	return s.ChatCompletionStream.Close()
+}
+
+// Scheduler returns the client's scheduler, or nil if scheduling is not configured
+func (c *ChatClient) Scheduler() *Scheduler {
+	return c.scheduler
+}
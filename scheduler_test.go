@@ -0,0 +1,154 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestScheduler_PriorityOrder(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxConcurrent: 1})
+	ctx := context.Background()
+
+	release, err := s.Acquire(ctx, "openai", PriorityNormal)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	queue := func(name string, priority RequestPriority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := s.Acquire(ctx, "openai", priority)
+			if err != nil {
+				t.Errorf("Acquire failed: %v", err)
+				return
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			r()
+		}()
+		waitFor(t, func() bool { return s.Stats()["openai"].Queued == queued })
+	}
+	queue("batch", PriorityBatch, 1)
+	queue("normal", PriorityNormal, 2)
+	queue("interactive-1", PriorityInteractive, 3)
+	queue("interactive-2", PriorityInteractive, 4)
+
+	release()
+	release() // Releasing twice is a no-op
+	wg.Wait()
+
+	want := []string{"interactive-1", "interactive-2", "normal", "batch"}
+	for i := range want {
+		if i >= len(order) || order[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, order)
+		}
+	}
+	if stats := s.Stats()["openai"]; stats.InFlight != 0 || stats.Queued != 0 {
+		t.Errorf("expected an idle scheduler, got %+v", stats)
+	}
+}
+
+func TestScheduler_CancelAndQueueLimit(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{MaxConcurrent: 1, MaxQueued: 1, ProviderLimits: map[string]int{"ollama": 0}})
+
+	release, err := s.Acquire(context.Background(), "openai", PriorityNormal)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, "openai", PriorityNormal); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if stats := s.Stats()["openai"]; stats.Queued != 0 {
+		t.Errorf("expected the cancelled request to leave the queue, got %+v", stats)
+	}
+
+	waitCtx, cancelWait := context.WithCancel(context.Background())
+	defer cancelWait()
+	go func() { _, _ = s.Acquire(waitCtx, "openai", PriorityNormal) }()
+	waitFor(t, func() bool { return s.Stats()["openai"].Queued == 1 })
+	if _, err := s.Acquire(context.Background(), "openai", PriorityNormal); !errors.Is(err, ErrSchedulerQueueFull) {
+		t.Errorf("expected ErrSchedulerQueueFull, got %v", err)
+	}
+	if !IsRetryableError(ErrSchedulerQueueFull) {
+		t.Error("expected a full queue to be retryable")
+	}
+	release()
+
+	// A limit of 0 disables scheduling for that provider
+	for i := 0; i < 3; i++ {
+		if _, err := s.Acquire(context.Background(), "ollama", PriorityNormal); err != nil {
+			t.Fatalf("expected unlimited provider, got %v", err)
+		}
+	}
+}
+
+func TestClient_SchedulerStreamHoldsSlot(t *testing.T) {
+	mock := mocktest.NewScriptedProvider("openai",
+		mocktest.TextStep("hello"),
+		mocktest.TextStep("world"),
+	)
+	client, err := NewClient(ClientConfig{
+		Providers:       []ProviderConfig{{CustomProvider: mock}},
+		SchedulerConfig: &SchedulerConfig{MaxConcurrent: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if stats := client.Scheduler().Stats()["openai"]; stats.InFlight != 1 {
+		t.Errorf("expected the open stream to hold a slot, got %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.CreateChatCompletion(ctx, req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the second request to wait, got %v", err)
+	}
+
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+	}
+	if stats := client.Scheduler().Stats()["openai"]; stats.InFlight != 0 {
+		t.Errorf("expected the finished stream to release its slot, got %+v", stats)
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Errorf("request failed after the stream finished: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
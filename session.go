@@ -0,0 +1,177 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// SessionClient is a ChatClient bound to one conversation. Requests made
+// through it load and save the session's messages in conversation memory,
+// carry its system prompt, and add to its usage. Create one with
+// ChatClient.Session. It is safe for concurrent use, though concurrent
+// requests in one conversation interleave their messages.
+//
+// Without ClientConfig.Memory, messages are not remembered between requests.
+type SessionClient struct {
+	client    *ChatClient
+	sessionID string
+
+	mu           sync.Mutex
+	model        string
+	systemPrompt string
+	usage        provider.Usage
+}
+
+// Session returns a client bound to the conversation with sessionID
+func (c *ChatClient) Session(sessionID string) *SessionClient {
+	return &SessionClient{client: c, sessionID: sessionID}
+}
+
+// WithModel sets the model for requests that do not set one, and returns s
+func (s *SessionClient) WithModel(model string) *SessionClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model = model
+	return s
+}
+
+// WithSystemPrompt sets a system prompt sent before the conversation on
+// every request, and returns s. It is not saved to memory, so changing it
+// changes the instructions for the whole conversation. The client's
+// DefaultSystemPrompt still applies according to its SystemPromptPolicy.
+func (s *SessionClient) WithSystemPrompt(prompt string) *SessionClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.systemPrompt = prompt
+	return s
+}
+
+// ID returns the session ID
+func (s *SessionClient) ID() string {
+	return s.sessionID
+}
+
+// Client returns the underlying client
+func (s *SessionClient) Client() *ChatClient {
+	return s.client
+}
+
+// Usage returns the usage accumulated by the session's requests
+func (s *SessionClient) Usage() provider.Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// Send sends text as a user message and returns the content of the reply
+func (s *SessionClient) Send(ctx context.Context, text string) (string, error) {
+	resp, err := s.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: text}},
+	})
+	if err != nil {
+		return "", err
+	}
+	choice, ok := primaryChoice(resp.Choices)
+	if !ok {
+		return "", ErrInvalidResponse
+	}
+	return choice.Message.Content, nil
+}
+
+// CreateChatCompletion sends req's messages after the conversation so far
+// and saves them, with the reply, to the conversation
+func (s *SessionClient) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	req, system := s.prepare(req)
+	resp, err := s.client.createChatCompletionWithMemory(ctx, s.sessionID, req, system)
+	if err != nil {
+		return nil, err
+	}
+	s.addUsage(resp.Usage)
+	return resp, nil
+}
+
+// CreateChatCompletionStream streams a reply to req's messages after the
+// conversation so far. The messages and reply are saved to the conversation
+// when the stream ends or is closed.
+func (s *SessionClient) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	req, system := s.prepare(req)
+	stream, err := s.client.createChatCompletionStreamWithMemory(ctx, s.sessionID, req, system)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionStream{ChatCompletionStream: stream, session: s}, nil
+}
+
+// Messages returns the conversation's stored messages
+func (s *SessionClient) Messages(ctx context.Context) ([]provider.Message, error) {
+	return s.client.GetConversationMessages(ctx, s.sessionID)
+}
+
+// Reset deletes the conversation and clears the accumulated usage
+func (s *SessionClient) Reset(ctx context.Context) error {
+	if err := s.client.DeleteConversation(ctx, s.sessionID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage = provider.Usage{}
+	return nil
+}
+
+// prepare returns req with the session's model filled in, and the session's
+// system prompt
+func (s *SessionClient) prepare(req *provider.ChatCompletionRequest) (*provider.ChatCompletionRequest, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Model == "" && s.model != "" {
+		withModel := *req
+		withModel.Model = s.model
+		req = &withModel
+	}
+	return req, s.systemPrompt
+}
+
+func (s *SessionClient) addUsage(usage provider.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.PromptTokens += usage.PromptTokens
+	s.usage.CompletionTokens += usage.CompletionTokens
+	s.usage.TotalTokens += usage.TotalTokens
+}
+
+// sessionStream adds the usage reported in a stream to its session when the
+// stream ends or is closed
+type sessionStream struct {
+	provider.ChatCompletionStream
+	session *SessionClient
+	usage   *provider.Usage
+	once    sync.Once
+}
+
+func (s *sessionStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if chunk != nil && chunk.Usage != nil {
+		s.usage = chunk.Usage
+	}
+	if errors.Is(err, io.EOF) {
+		s.record()
+	}
+	return chunk, err
+}
+
+func (s *sessionStream) Close() error {
+	s.record()
+	return s.ChatCompletionStream.Close()
+}
+
+func (s *sessionStream) record() {
+	s.once.Do(func() {
+		if s.usage != nil {
+			s.session.addUsage(*s.usage)
+		}
+	})
+}
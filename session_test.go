@@ -0,0 +1,104 @@
+package omnillm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestSessionClient_Send(t *testing.T) {
+	first := mocktest.TextResponse("Hi Ada.")
+	first.Usage = provider.Usage{PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13}
+	second := mocktest.TextResponse("Your name is Ada.")
+	second.Usage = provider.Usage{PromptTokens: 20, CompletionTokens: 5, TotalTokens: 25}
+	mockProv := mocktest.NewScriptedProvider("scripted", mocktest.Step{Response: first}, mocktest.Step{Response: second})
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mockProv}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	session := client.Session("user-1").WithModel("m").WithSystemPrompt("Be brief.")
+
+	if _, err := session.Send(ctx, "I'm Ada."); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	reply, err := session.Send(ctx, "What's my name?")
+	if err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if reply != "Your name is Ada." {
+		t.Errorf("unexpected reply %q", reply)
+	}
+
+	req := mockProv.LastRequest()
+	if req.Model != "m" {
+		t.Errorf("expected the session model, got %q", req.Model)
+	}
+	if len(req.Messages) != 4 || req.Messages[0].Content != "Be brief." || req.Messages[1].Content != "I'm Ada." || req.Messages[2].Content != "Hi Ada." {
+		t.Errorf("expected the system prompt and history before the message, got %+v", req.Messages)
+	}
+
+	// The system prompt is sent, not stored
+	stored, err := session.Messages(ctx)
+	if err != nil {
+		t.Fatalf("Messages failed: %v", err)
+	}
+	if len(stored) != 4 || stored[0].Role != provider.RoleUser {
+		t.Errorf("expected 4 stored messages without the system prompt, got %+v", stored)
+	}
+
+	if usage := session.Usage(); usage.TotalTokens != 38 || usage.PromptTokens != 30 {
+		t.Errorf("expected accumulated usage, got %+v", usage)
+	}
+
+	if err := session.Reset(ctx); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if stored, _ := session.Messages(ctx); len(stored) != 0 || session.Usage().TotalTokens != 0 {
+		t.Errorf("expected an empty session after Reset, got %d messages", len(stored))
+	}
+}
+
+func TestSessionClient_Stream(t *testing.T) {
+	chunks := mocktest.TextChunks("Hello ", "there")
+	chunks[1].Usage = &provider.Usage{PromptTokens: 4, CompletionTokens: 2, TotalTokens: 6}
+	mockProv := mocktest.NewScriptedProvider("scripted", mocktest.Step{Chunks: chunks})
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mockProv}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	session := client.Session("user-2").WithModel("m")
+	stream, err := session.CreateChatCompletionStream(ctx, &provider.ChatCompletionRequest{
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	_ = stream.Close()
+
+	if usage := session.Usage(); usage.TotalTokens != 6 {
+		t.Errorf("expected the stream's usage once, got %+v", usage)
+	}
+	stored, _ := session.Messages(ctx)
+	if len(stored) != 2 || stored[1].Content != "Hello there" {
+		t.Errorf("expected the streamed exchange stored, got %+v", stored)
+	}
+}
@@ -0,0 +1,298 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grokify/mogo/log/slogutil"
+	"github.com/plexusone/omnillm/provider"
+)
+
+const (
+	// DefaultShadowTimeout bounds each shadow request
+	DefaultShadowTimeout = 60 * time.Second
+
+	// DefaultShadowMaxInFlight caps concurrent shadow requests
+	DefaultShadowMaxInFlight = 16
+)
+
+// ShadowConfig configures a ShadowProvider
+type ShadowConfig struct {
+	// Candidate receives the mirrored requests (required)
+	Candidate provider.Provider
+
+	// Model replaces the request model for the candidate. If empty, the
+	// request model is sent unchanged.
+	Model string
+
+	// SampleRate is the fraction of requests mirrored, from 0 to 1. If 0,
+	// every request is mirrored.
+	SampleRate float64
+
+	// Hook observes both calls of each mirrored request. The context it
+	// receives carries a ShadowInfo. For a streamed primary call,
+	// AfterResponse is called when the stream ends, with the accumulated
+	// response.
+	Hook ObservabilityHook
+
+	// OnResult, if set, receives both responses of each mirrored request
+	// once both calls have finished
+	OnResult func(ShadowResult)
+
+	// Timeout bounds each shadow request. Default: DefaultShadowTimeout.
+	Timeout time.Duration
+
+	// MaxInFlight caps concurrent shadow requests. Requests sampled while
+	// the cap is reached are not mirrored. Default: DefaultShadowMaxInFlight.
+	MaxInFlight int
+
+	// Logger for shadow request failures
+	Logger *slog.Logger
+}
+
+// ShadowInfo identifies the calls of a mirrored request in hook contexts
+type ShadowInfo struct {
+	// ID is shared by the primary and shadow calls of a request
+	ID string
+
+	// Shadow is true for the candidate's call and false for the primary's
+	Shadow bool
+}
+
+type shadowContextKey struct{}
+
+// ShadowInfoFromContext returns the ShadowInfo of a call made by ShadowProvider
+func ShadowInfoFromContext(ctx context.Context) (ShadowInfo, bool) {
+	info, ok := ctx.Value(shadowContextKey{}).(ShadowInfo)
+	return info, ok
+}
+
+// ShadowResult pairs the primary and shadow responses of a mirrored request
+type ShadowResult struct {
+	ID      string
+	Request *provider.ChatCompletionRequest
+
+	PrimaryProvider string
+	Primary         *provider.ChatCompletionResponse
+	PrimaryErr      error
+	PrimaryLatency  time.Duration
+
+	ShadowProvider string
+	Shadow         *provider.ChatCompletionResponse
+	ShadowErr      error
+	ShadowLatency  time.Duration
+}
+
+// ShadowProvider serves requests from a primary provider and mirrors a
+// sample of them to a candidate provider in the background. The caller only
+// ever sees the primary's response; the candidate's is reported to the
+// hook and OnResult. Use it to compare a provider or model on real traffic
+// before migrating to it.
+type ShadowProvider struct {
+	primary provider.Provider
+	config  ShadowConfig
+	slots   chan struct{}
+	logger  *slog.Logger
+}
+
+// NewShadowProvider creates a provider that mirrors primary's traffic to config.Candidate
+func NewShadowProvider(primary provider.Provider, config ShadowConfig) (*ShadowProvider, error) {
+	if config.Candidate == nil {
+		return nil, fmt.Errorf("%w: shadow candidate provider is required", ErrInvalidConfiguration)
+	}
+	if config.SampleRate < 0 || config.SampleRate > 1 {
+		return nil, fmt.Errorf("%w: shadow sample rate must be between 0 and 1", ErrInvalidConfiguration)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultShadowTimeout
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DefaultShadowMaxInFlight
+	}
+
+	sp := &ShadowProvider{
+		primary: primary,
+		config:  config,
+		slots:   make(chan struct{}, config.MaxInFlight),
+		logger:  config.Logger,
+	}
+	if sp.logger == nil {
+		sp.logger = slogutil.Null()
+	}
+	return sp, nil
+}
+
+// CreateChatCompletion returns the primary's response, mirroring the request if sampled
+func (sp *ShadowProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	call := sp.mirror(ctx, req)
+	if call == nil {
+		return sp.primary.CreateChatCompletion(ctx, req)
+	}
+
+	hookCtx, info := call.beforePrimary(ctx)
+	resp, err := sp.primary.CreateChatCompletion(ctx, req)
+	call.finishPrimary(hookCtx, info, resp, err)
+	return resp, err
+}
+
+// CreateChatCompletionStream returns the primary's stream, mirroring the
+// request if sampled. The candidate is called without streaming.
+func (sp *ShadowProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	call := sp.mirror(ctx, req)
+	if call == nil {
+		return sp.primary.CreateChatCompletionStream(ctx, req)
+	}
+
+	hookCtx, info := call.beforePrimary(ctx)
+	stream, err := sp.primary.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		call.finishPrimary(hookCtx, info, nil, err)
+		return nil, err
+	}
+	return &shadowStream{stream: stream, acc: NewStreamAccumulator(), finish: func(resp *provider.ChatCompletionResponse, err error) {
+		call.finishPrimary(hookCtx, info, resp, err)
+	}}, nil
+}
+
+// Close closes the primary and candidate providers
+func (sp *ShadowProvider) Close() error {
+	return errors.Join(sp.primary.Close(), sp.config.Candidate.Close())
+}
+
+// Name returns the primary provider's name
+func (sp *ShadowProvider) Name() string {
+	return sp.primary.Name()
+}
+
+// mirror starts the shadow request if req is sampled and a slot is free,
+// returning nil otherwise
+func (sp *ShadowProvider) mirror(ctx context.Context, req *provider.ChatCompletionRequest) *shadowCall {
+	if sp.config.SampleRate > 0 && rand.Float64() >= sp.config.SampleRate {
+		return nil
+	}
+	select {
+	case sp.slots <- struct{}{}:
+	default:
+		sp.logger.Debug("shadow request dropped, too many in flight",
+			slog.String("candidate", sp.config.Candidate.Name()))
+		return nil
+	}
+
+	// Copy the request so later changes by the caller do not reach the shadow
+	mirrored := *req
+	mirrored.Messages = slices.Clone(req.Messages)
+	call := &shadowCall{
+		sp:         sp,
+		shadowDone: make(chan struct{}),
+		result: ShadowResult{
+			ID:              newCallID(),
+			Request:         &mirrored,
+			PrimaryProvider: sp.primary.Name(),
+			ShadowProvider:  sp.config.Candidate.Name(),
+		},
+	}
+
+	shadowReq := mirrored
+	if sp.config.Model != "" {
+		shadowReq.Model = sp.config.Model
+	}
+	// The shadow request outlives the caller's request, so it keeps the
+	// context's values but not its cancellation
+	shadowCtx, cancel := context.WithTimeout(deriveIdempotencyKey(context.WithoutCancel(ctx), "shadow"), sp.config.Timeout)
+	go func() {
+		defer func() { <-sp.slots }()
+		defer cancel()
+		defer close(call.shadowDone)
+
+		info := LLMCallInfo{CallID: newCallID(), ProviderName: sp.config.Candidate.Name(), StartTime: time.Now()}
+		hookCtx := context.WithValue(shadowCtx, shadowContextKey{}, ShadowInfo{ID: call.result.ID, Shadow: true})
+		if sp.config.Hook != nil {
+			hookCtx = sp.config.Hook.BeforeRequest(hookCtx, info, &shadowReq)
+		}
+		resp, err := sp.config.Candidate.CreateChatCompletion(hookCtx, &shadowReq)
+		call.result.Shadow, call.result.ShadowErr, call.result.ShadowLatency = resp, err, time.Since(info.StartTime)
+		if sp.config.Hook != nil {
+			sp.config.Hook.AfterResponse(hookCtx, info, &shadowReq, resp, err)
+		}
+		if err != nil {
+			sp.logger.Debug("shadow request failed",
+				slog.String("candidate", sp.config.Candidate.Name()),
+				slog.String("error", err.Error()))
+		}
+	}()
+	return call
+}
+
+// shadowCall tracks the two halves of a mirrored request
+type shadowCall struct {
+	sp         *ShadowProvider
+	result     ShadowResult
+	shadowDone chan struct{}
+}
+
+// beforePrimary runs the hook's BeforeRequest for the primary call
+func (c *shadowCall) beforePrimary(ctx context.Context) (context.Context, LLMCallInfo) {
+	info := LLMCallInfo{CallID: newCallID(), ProviderName: c.sp.primary.Name(), StartTime: time.Now()}
+	ctx = context.WithValue(ctx, shadowContextKey{}, ShadowInfo{ID: c.result.ID})
+	if c.sp.config.Hook != nil {
+		ctx = c.sp.config.Hook.BeforeRequest(ctx, info, c.result.Request)
+	}
+	return ctx, info
+}
+
+// finishPrimary records the primary's result and reports the pair once the
+// shadow request has finished
+func (c *shadowCall) finishPrimary(ctx context.Context, info LLMCallInfo, resp *provider.ChatCompletionResponse, err error) {
+	if c.sp.config.Hook != nil {
+		c.sp.config.Hook.AfterResponse(ctx, info, c.result.Request, resp, err)
+	}
+	if c.sp.config.OnResult == nil {
+		return
+	}
+
+	// Copy the response so the caller can modify theirs
+	if resp != nil {
+		copied := *resp
+		copied.Choices = slices.Clone(resp.Choices)
+		resp = &copied
+	}
+	c.result.Primary, c.result.PrimaryErr, c.result.PrimaryLatency = resp, err, time.Since(info.StartTime)
+	go func() {
+		<-c.shadowDone
+		c.sp.config.OnResult(c.result)
+	}()
+}
+
+// shadowStream accumulates the primary's stream so that its response can be
+// reported when the stream ends or is closed
+type shadowStream struct {
+	stream provider.ChatCompletionStream
+	acc    *StreamAccumulator
+	finish func(*provider.ChatCompletionResponse, error)
+	once   sync.Once
+}
+
+func (s *shadowStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	switch {
+	case err == nil:
+		s.acc.Add(chunk)
+	case errors.Is(err, io.EOF):
+		s.once.Do(func() { s.finish(s.acc.Response(), nil) })
+	default:
+		s.once.Do(func() { s.finish(nil, err) })
+	}
+	return chunk, err
+}
+
+func (s *shadowStream) Close() error {
+	s.once.Do(func() { s.finish(s.acc.Response(), ErrStreamClosed) })
+	return s.stream.Close()
+}
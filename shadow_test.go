@@ -0,0 +1,137 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// shadowRecorder is a hook that records ShadowInfo for each finished call
+type shadowRecorder struct {
+	mu    sync.Mutex
+	calls []ShadowInfo
+}
+
+func (r *shadowRecorder) BeforeRequest(ctx context.Context, _ LLMCallInfo, _ *provider.ChatCompletionRequest) context.Context {
+	return ctx
+}
+
+func (r *shadowRecorder) AfterResponse(ctx context.Context, _ LLMCallInfo, _ *provider.ChatCompletionRequest, _ *provider.ChatCompletionResponse, _ error) {
+	info, _ := ShadowInfoFromContext(ctx)
+	r.mu.Lock()
+	r.calls = append(r.calls, info)
+	r.mu.Unlock()
+}
+
+func (r *shadowRecorder) WrapStream(_ context.Context, _ LLMCallInfo, _ *provider.ChatCompletionRequest, s provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return s
+}
+
+func TestShadowProvider_MirrorsRequest(t *testing.T) {
+	primary := mocktest.NewScriptedProvider("openai", mocktest.TextStep("primary"))
+	candidate := mocktest.NewScriptedProvider("anthropic", mocktest.Step{Err: ErrServerError})
+	hook := &shadowRecorder{}
+	results := make(chan ShadowResult, 1)
+
+	sp, err := NewShadowProvider(primary, ShadowConfig{
+		Candidate: candidate,
+		Model:     "claude-sonnet-4-5",
+		Hook:      hook,
+		OnResult:  func(r ShadowResult) { results <- r },
+	})
+	if err != nil {
+		t.Fatalf("NewShadowProvider failed: %v", err)
+	}
+
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	resp, err := sp.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected the primary response despite the shadow error, got %v", err)
+	}
+	if resp.Choices[0].Message.Content != "primary" {
+		t.Errorf("unexpected response: %q", resp.Choices[0].Message.Content)
+	}
+
+	select {
+	case r := <-results:
+		if r.Primary == nil || r.PrimaryErr != nil || !errors.Is(r.ShadowErr, ErrServerError) {
+			t.Errorf("unexpected result: %+v", r)
+		}
+		if r.PrimaryProvider != "openai" || r.ShadowProvider != "anthropic" {
+			t.Errorf("unexpected providers: %s, %s", r.PrimaryProvider, r.ShadowProvider)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the shadow result")
+	}
+	if got := candidate.LastRequest().Model; got != "claude-sonnet-4-5" {
+		t.Errorf("expected the shadow model, got %q", got)
+	}
+
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
+	if len(hook.calls) != 2 || hook.calls[0].ID != hook.calls[1].ID || hook.calls[0].Shadow == hook.calls[1].Shadow {
+		t.Errorf("expected a primary and a shadow call with one ID, got %+v", hook.calls)
+	}
+}
+
+func TestShadowProvider_Stream(t *testing.T) {
+	primary := mocktest.NewScriptedProvider("openai", mocktest.Step{Chunks: mocktest.TextChunks("hel", "lo")})
+	candidate := mocktest.NewScriptedProvider("anthropic", mocktest.TextStep("hi there"))
+	results := make(chan ShadowResult, 1)
+
+	sp, err := NewShadowProvider(primary, ShadowConfig{Candidate: candidate, OnResult: func(r ShadowResult) { results <- r }})
+	if err != nil {
+		t.Fatalf("NewShadowProvider failed: %v", err)
+	}
+
+	stream, err := sp.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{Model: "m"})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+	}
+
+	select {
+	case r := <-results:
+		if r.Primary.Choices[0].Message.Content != "hello" || r.Shadow.Choices[0].Message.Content != "hi there" {
+			t.Errorf("unexpected result: primary %+v, shadow %+v", r.Primary, r.Shadow)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the shadow result")
+	}
+}
+
+func TestShadowProvider_Sampling(t *testing.T) {
+	if _, err := NewShadowProvider(mocktest.NewScriptedProvider("p"), ShadowConfig{}); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("expected configuration error without a candidate, got %v", err)
+	}
+
+	primary := mocktest.NewScriptedProvider("openai").Default(mocktest.TextStep("ok"))
+	candidate := mocktest.NewScriptedProvider("anthropic").Default(mocktest.TextStep("ok"))
+	sp, err := NewShadowProvider(primary, ShadowConfig{Candidate: candidate, SampleRate: 0.25, MaxInFlight: 400})
+	if err != nil {
+		t.Fatalf("NewShadowProvider failed: %v", err)
+	}
+
+	const n = 400
+	for i := 0; i < n; i++ {
+		if _, err := sp.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{Model: "m"}); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	waitFor(t, func() bool { return len(sp.slots) == 0 })
+	if calls := candidate.Calls(); calls < n/8 || calls > n/2 {
+		t.Errorf("expected about a quarter of %d requests mirrored, got %d", n, calls)
+	}
+}
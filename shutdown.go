@@ -0,0 +1,147 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrClientShutdown is returned for requests made after Shutdown has begun
+var ErrClientShutdown = errors.New("client is shutting down")
+
+// FlushingHook is an ObservabilityHook that buffers what it records.
+// Shutdown calls Flush once in-flight requests have finished, so nothing is
+// lost when the process exits.
+type FlushingHook interface {
+	ObservabilityHook
+
+	// Flush writes out anything buffered
+	Flush(ctx context.Context) error
+}
+
+// Flush forwards to each hook in the chain that implements FlushingHook
+func (c *hookChain) Flush(ctx context.Context) error {
+	var errs []error
+	for _, h := range c.hooks {
+		if f, ok := h.(FlushingHook); ok {
+			errs = append(errs, f.Flush(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush saves the tracker's usage to its store, if it has one
+func (t *UsageTracker) Flush(ctx context.Context) error {
+	if t.config.Store == nil {
+		return nil
+	}
+	return t.Save(ctx)
+}
+
+// Flush flushes the audit sink, if it buffers records with a
+// Flush(ctx) error method
+func (h *AuditHook) Flush(ctx context.Context) error {
+	if f, ok := h.config.Sink.(interface{ Flush(context.Context) error }); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// inflightTracker counts in-flight requests and refuses new ones once closed
+type inflightTracker struct {
+	mu     sync.Mutex
+	closed bool
+	count  int
+	idle   chan struct{} // Closed when count drops to 0 after close
+}
+
+// acquire registers a request, or returns ErrClientShutdown once closed
+func (t *inflightTracker) acquire() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClientShutdown
+	}
+	t.count++
+	return nil
+}
+
+// release ends a request registered with acquire
+func (t *inflightTracker) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.count--
+	if t.closed && t.count == 0 {
+		close(t.idle)
+	}
+}
+
+// drain refuses new requests and waits until in-flight ones finish or ctx
+// is done. Only the first call closes the tracker.
+func (t *inflightTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		t.idle = make(chan struct{})
+		if t.count == 0 {
+			close(t.idle)
+		}
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// inflightStream releases its in-flight registration when the stream ends
+// or is closed
+type inflightStream struct {
+	provider.ChatCompletionStream
+	release func()
+}
+
+func (s *inflightStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if err != nil {
+		s.release()
+	}
+	return chunk, err
+}
+
+func (s *inflightStream) Close() error {
+	err := s.ChatCompletionStream.Close()
+	s.release()
+	return err
+}
+
+// trackStream registers a stream as in flight until it ends or is closed
+func (c *ChatClient) trackStream(stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	var once sync.Once
+	return &inflightStream{ChatCompletionStream: stream, release: func() { once.Do(c.inflight.release) }}
+}
+
+// Shutdown stops the client gracefully. New chat completions and streams
+// fail with ErrClientShutdown at once, while those in flight are given
+// until ctx is done to finish; a stream is in flight until it ends or is
+// closed. Then observability hooks that implement FlushingHook, including
+// the UsageTracker, are flushed, and the client is closed. If ctx is done
+// first, the client is closed anyway, cutting off the remaining requests,
+// and ctx.Err() is returned. Do not call Close after Shutdown.
+func (c *ChatClient) Shutdown(ctx context.Context) error {
+	drainErr := c.inflight.drain(ctx)
+
+	var flushErr error
+	if f, ok := c.hook.(FlushingHook); ok {
+		// Usage is worth saving even if draining ran out of time
+		flushErr = f.Flush(context.WithoutCancel(ctx))
+	}
+
+	return errors.Join(drainErr, flushErr, c.Close())
+}
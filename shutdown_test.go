@@ -0,0 +1,116 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestChatClient_ShutdownDrainsInFlight(t *testing.T) {
+	mockProv := mocktest.NewScriptedProvider("scripted").Default(mocktest.Step{Response: mocktest.TextResponse("done"), Delay: 50 * time.Millisecond})
+	store := mocktest.NewMockKVS()
+	client, err := NewClient(ClientConfig{
+		Providers:    []ProviderConfig{{CustomProvider: mockProv}},
+		UsageTracker: NewUsageTracker(UsageTrackerConfig{Store: store}),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.CreateChatCompletion(context.Background(), req)
+		done <- err
+	}()
+	waitFor(t, func() bool { return mockProv.Calls() == 1 })
+
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the in-flight request to finish, got %v", err)
+		}
+	default:
+		t.Error("expected Shutdown to wait for the in-flight request")
+	}
+
+	if _, err := client.CreateChatCompletion(context.Background(), req); !errors.Is(err, ErrClientShutdown) {
+		t.Errorf("expected ErrClientShutdown after Shutdown, got %v", err)
+	}
+	if _, err := client.CreateChatCompletionStream(context.Background(), req); !errors.Is(err, ErrClientShutdown) {
+		t.Errorf("expected ErrClientShutdown for streams after Shutdown, got %v", err)
+	}
+	if !mockProv.Closed() {
+		t.Error("expected the provider to be closed")
+	}
+	if store.Size() == 0 {
+		t.Error("expected usage to be flushed to the store")
+	}
+}
+
+func TestChatClient_ShutdownWaitsForStreams(t *testing.T) {
+	mockProv := mocktest.NewScriptedProvider("scripted", mocktest.Step{Chunks: mocktest.TextChunks("a", "b")})
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+
+	// An open stream holds up Shutdown until its deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- client.Shutdown(ctx) }()
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("expected the open stream to keep working during Shutdown, got %v", err)
+	}
+
+	if err := <-shutdown; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to end Shutdown, got %v", err)
+	}
+	if !mockProv.Closed() {
+		t.Error("expected the provider to be closed after the deadline")
+	}
+}
+
+func TestChatClient_ShutdownAfterStreamEnds(t *testing.T) {
+	mockProv := mocktest.NewScriptedProvider("scripted", mocktest.Step{Chunks: mocktest.TextChunks("a", "b")})
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "m",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream failed: %v", err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	_ = stream.Close() // Closing after the end releases nothing twice
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Shutdown(ctx); err != nil {
+		t.Errorf("expected Shutdown to finish at once, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package omnillm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+var (
+	// ErrRequestTooLarge is matched by a SizeLimitError for a request over
+	// SizeLimitConfig.MaxRequestBytes
+	ErrRequestTooLarge = errors.New("request too large")
+
+	// ErrResponseTooLarge is matched by a SizeLimitError for a response over
+	// SizeLimitConfig.MaxResponseBytes
+	ErrResponseTooLarge = errors.New("response too large")
+)
+
+// SizeLimitConfig caps the size of requests sent to and responses read from
+// providers. Zero fields mean no limit.
+type SizeLimitConfig struct {
+	// MaxRequestBytes caps the JSON-encoded size of a chat completion
+	// request, checked before it is sent
+	MaxRequestBytes int64
+
+	// MaxResponseBytes caps each HTTP response body read from a provider,
+	// including the whole of a streamed response. It applies to built-in
+	// providers, which read their responses through a limited HTTP client;
+	// CustomProvider is not limited.
+	MaxResponseBytes int64
+}
+
+// SizeLimitError reports a request or response over its configured size limit
+type SizeLimitError struct {
+	// Response is true for a provider response, false for a request
+	Response bool
+
+	// Size is the size in bytes, when known. An oversized response is
+	// abandoned once it passes Limit, so its Size is 0 unless the provider
+	// declared a Content-Length.
+	Size int64
+
+	// Limit is the configured limit in bytes
+	Limit int64
+}
+
+func (e *SizeLimitError) Error() string {
+	what := "request"
+	if e.Response {
+		what = "response"
+	}
+	if e.Size > 0 {
+		return fmt.Sprintf("%s of %d bytes exceeds limit of %d bytes", what, e.Size, e.Limit)
+	}
+	return fmt.Sprintf("%s exceeds limit of %d bytes", what, e.Limit)
+}
+
+// Is matches ErrResponseTooLarge for responses and ErrRequestTooLarge for requests
+func (e *SizeLimitError) Is(target error) bool {
+	if e.Response {
+		return target == ErrResponseTooLarge
+	}
+	return target == ErrRequestTooLarge
+}
+
+// checkRequestSize returns a SizeLimitError if req encodes to more than
+// limit bytes. A limit of 0 accepts every request.
+func checkRequestSize(req *provider.ChatCompletionRequest, limit int64) error {
+	if limit <= 0 || req == nil {
+		return nil
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	if size := int64(len(data)); size > limit {
+		return &SizeLimitError{Size: size, Limit: limit}
+	}
+	return nil
+}
+
+// withResponseLimit returns providers whose HTTP clients stop reading a
+// response body after limit bytes
+func withResponseLimit(providers []ProviderConfig, limit int64) []ProviderConfig {
+	result := make([]ProviderConfig, len(providers))
+	for i, pc := range providers {
+		switch {
+		case pc.CustomProvider != nil:
+		case pc.HTTPClient != nil:
+			client := *pc.HTTPClient
+			client.Transport = &limitTransport{base: client.Transport, limit: limit}
+			pc.HTTPClient = &client
+		default:
+			pc.Transport = &limitTransport{base: pc.Transport, limit: limit}
+		}
+		result[i] = pc
+	}
+	return result
+}
+
+// limitTransport rejects responses that declare a body over limit and cuts
+// off those that send more than limit bytes
+type limitTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > t.limit {
+		resp.Body.Close()
+		return nil, &SizeLimitError{Response: true, Size: resp.ContentLength, Limit: t.limit}
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.limit, limit: t.limit}
+	return resp, nil
+}
+
+// limitedBody returns a SizeLimitError once more than limit bytes are read
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, &SizeLimitError{Response: true, Limit: b.limit}
+	}
+	// Read one byte past the limit to tell a body that ends exactly at the
+	// limit from one that goes over it
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n - 1, &SizeLimitError{Response: true, Limit: b.limit}
+	}
+	return n, err
+}
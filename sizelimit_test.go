@@ -0,0 +1,102 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestSizeLimits_Request(t *testing.T) {
+	mock := newMockProvider("primary")
+	c, err := NewClient(ClientConfig{
+		Providers:  []ProviderConfig{{CustomProvider: mock}},
+		SizeLimits: &SizeLimitConfig{MaxRequestBytes: 200},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	req := &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+	if _, err := c.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("small request error = %v", err)
+	}
+
+	req.Messages[0].Content = strings.Repeat("x", 500)
+	_, err = c.CreateChatCompletion(context.Background(), req)
+	var sizeErr *SizeLimitError
+	if !errors.As(err, &sizeErr) || !errors.Is(err, ErrRequestTooLarge) || sizeErr.Limit != 200 || sizeErr.Size <= 500 {
+		t.Fatalf("error = %v, want request SizeLimitError", err)
+	}
+	if _, err := c.CreateChatCompletionStream(context.Background(), req); !errors.Is(err, ErrRequestTooLarge) {
+		t.Errorf("stream error = %v, want ErrRequestTooLarge", err)
+	}
+	if mock.callCount != 1 {
+		t.Errorf("provider called %d times, want only the small request", mock.callCount)
+	}
+	if !IsNonRetryableError(err) {
+		t.Error("expected an oversized request to be non-retryable")
+	}
+}
+
+func TestSizeLimits_Response(t *testing.T) {
+	body := `{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"` + strings.Repeat("x", 1000) + `"},"finish_reason":"stop"}]}`
+	var chunked bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if chunked {
+			w.(http.Flusher).Flush() // Omits Content-Length
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	req := &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+	for _, chunked = range []bool{false, true} {
+		c, err := NewClient(ClientConfig{
+			Providers:  []ProviderConfig{{Provider: ProviderNameOpenAI, APIKey: "key", BaseURL: server.URL}},
+			SizeLimits: &SizeLimitConfig{MaxResponseBytes: 512},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.CreateChatCompletion(context.Background(), req)
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("chunked=%v: error = %v, want ErrResponseTooLarge", chunked, err)
+		}
+		c.Close()
+	}
+
+	own := &http.Client{}
+	providers := withResponseLimit([]ProviderConfig{{Provider: ProviderNameOpenAI, HTTPClient: own}, {CustomProvider: newMockProvider("custom")}}, 512)
+	if providers[0].HTTPClient == own || providers[0].HTTPClient.Transport == nil || own.Transport != nil {
+		t.Error("expected a copy of the provider's own HTTP client with a limited transport")
+	}
+	if providers[1].Transport != nil {
+		t.Error("expected custom providers to be left alone")
+	}
+}
+
+func TestLimitedBody(t *testing.T) {
+	for _, tt := range []struct {
+		size    int
+		wantErr bool
+	}{{511, false}, {512, false}, {513, true}} {
+		b := &limitedBody{ReadCloser: io.NopCloser(strings.NewReader(strings.Repeat("x", tt.size))), remaining: 512, limit: 512}
+		data, err := io.ReadAll(b)
+		if (err != nil) != tt.wantErr || len(data) > 512 {
+			t.Errorf("size %d: read %d bytes, error = %v", tt.size, len(data), err)
+		}
+	}
+}
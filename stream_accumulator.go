@@ -0,0 +1,254 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// StreamAccumulator assembles streaming chunks into a complete response.
+// Content deltas are concatenated, tool call fragments are merged by index,
+// and the last reported finish reason and usage are kept.
+type StreamAccumulator struct {
+	response *provider.ChatCompletionResponse
+	choices  map[int]*accumulatedChoice
+}
+
+// accumulatedChoice tracks the in-progress state of a single choice
+type accumulatedChoice struct {
+	role         provider.Role
+	content      strings.Builder
+	toolCalls    []*provider.ToolCall
+	toolIndex    map[int]*provider.ToolCall
+	thinking     []provider.ThinkingBlock
+	audio        *provider.Audio
+	finishReason *string
+	annotations  []provider.Annotation
+	logprobs     *provider.Logprobs
+}
+
+// NewStreamAccumulator creates an empty stream accumulator
+func NewStreamAccumulator() *StreamAccumulator {
+	return &StreamAccumulator{
+		response: &provider.ChatCompletionResponse{Object: "chat.completion"},
+		choices:  make(map[int]*accumulatedChoice),
+	}
+}
+
+// Add merges a chunk into the accumulated response
+func (a *StreamAccumulator) Add(chunk *provider.ChatCompletionChunk) {
+	if chunk == nil {
+		return
+	}
+
+	resp := a.response
+	if resp.ID == "" {
+		resp.ID = chunk.ID
+	}
+	if resp.Model == "" {
+		resp.Model = chunk.Model
+	}
+	if resp.Created == 0 {
+		resp.Created = chunk.Created
+	}
+	if resp.SystemFingerprint == nil {
+		resp.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		resp.Usage = *chunk.Usage
+	}
+	for k, v := range chunk.ProviderMetadata {
+		if resp.ProviderMetadata == nil {
+			resp.ProviderMetadata = make(map[string]any)
+		}
+		resp.ProviderMetadata[k] = v
+	}
+
+	for _, choice := range chunk.Choices {
+		acc := a.choice(choice.Index)
+		if choice.FinishReason != nil {
+			acc.finishReason = choice.FinishReason
+		}
+		acc.annotations = append(acc.annotations, choice.Annotations...)
+		if choice.Logprobs != nil {
+			if acc.logprobs == nil {
+				acc.logprobs = &provider.Logprobs{}
+			}
+			acc.logprobs.Content = append(acc.logprobs.Content, choice.Logprobs.Content...)
+		}
+
+		if choice.Delta == nil {
+			continue
+		}
+		if acc.role == "" {
+			acc.role = choice.Delta.Role
+		}
+		acc.content.WriteString(choice.Delta.Content)
+		for _, tc := range choice.Delta.ToolCalls {
+			acc.addToolCall(tc)
+		}
+		for _, block := range choice.Delta.Thinking {
+			acc.addThinking(block)
+		}
+		if choice.Delta.Audio != nil {
+			acc.addAudio(choice.Delta.Audio)
+		}
+	}
+}
+
+// choice returns the accumulated state for a choice index, creating it if needed
+func (a *StreamAccumulator) choice(index int) *accumulatedChoice {
+	acc, ok := a.choices[index]
+	if !ok {
+		acc = &accumulatedChoice{toolIndex: make(map[int]*provider.ToolCall)}
+		a.choices[index] = acc
+	}
+	return acc
+}
+
+// addToolCall merges a tool call fragment. Fragments are matched by index when
+// the provider reports one; otherwise a fragment with a new ID starts a new call
+// and a fragment without an ID continues the previous call.
+func (c *accumulatedChoice) addToolCall(delta provider.ToolCall) {
+	var tc *provider.ToolCall
+	switch {
+	case delta.Index != nil:
+		tc = c.toolIndex[*delta.Index]
+	case len(c.toolCalls) > 0 && (delta.ID == "" || delta.ID == c.toolCalls[len(c.toolCalls)-1].ID):
+		tc = c.toolCalls[len(c.toolCalls)-1]
+	}
+
+	if tc == nil {
+		tc = &provider.ToolCall{Type: "function"}
+		c.toolCalls = append(c.toolCalls, tc)
+		if delta.Index != nil {
+			c.toolIndex[*delta.Index] = tc
+		}
+	}
+
+	if delta.ID != "" {
+		tc.ID = delta.ID
+	}
+	if delta.Type != "" {
+		tc.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		tc.Function.Name = delta.Function.Name
+	}
+	tc.Function.Arguments += delta.Function.Arguments
+}
+
+// addThinking merges a thinking fragment. Text and a signature continue the
+// last block until it is signed; redacted blocks arrive whole.
+func (c *accumulatedChoice) addThinking(delta provider.ThinkingBlock) {
+	if delta.Redacted != "" {
+		c.thinking = append(c.thinking, delta)
+		return
+	}
+	if n := len(c.thinking); n == 0 || c.thinking[n-1].Signature != "" || c.thinking[n-1].Redacted != "" {
+		c.thinking = append(c.thinking, provider.ThinkingBlock{})
+	}
+	last := &c.thinking[len(c.thinking)-1]
+	last.Text += delta.Text
+	last.Signature += delta.Signature
+}
+
+// addAudio merges an audio fragment. Data and transcript fragments are
+// concatenated; the ID, format, and expiry are kept once reported.
+func (c *accumulatedChoice) addAudio(delta *provider.Audio) {
+	if c.audio == nil {
+		c.audio = &provider.Audio{}
+	}
+	c.audio.Data = append(c.audio.Data, delta.Data...)
+	c.audio.Transcript += delta.Transcript
+	if delta.ID != "" {
+		c.audio.ID = delta.ID
+	}
+	if delta.Format != "" {
+		c.audio.Format = delta.Format
+	}
+	if delta.ExpiresAt != 0 {
+		c.audio.ExpiresAt = delta.ExpiresAt
+	}
+}
+
+// Response returns the response assembled from the chunks added so far
+func (a *StreamAccumulator) Response() *provider.ChatCompletionResponse {
+	resp := *a.response
+
+	indexes := make([]int, 0, len(a.choices))
+	for index := range a.choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	resp.Choices = make([]provider.ChatCompletionChoice, 0, len(indexes))
+	for _, index := range indexes {
+		acc := a.choices[index]
+		role := acc.role
+		if role == "" {
+			role = provider.RoleAssistant
+		}
+
+		msg := provider.Message{
+			Role:     role,
+			Content:  acc.content.String(),
+			Thinking: slices.Clone(acc.thinking),
+		}
+		if acc.audio != nil {
+			audio := *acc.audio
+			audio.Data = slices.Clone(acc.audio.Data)
+			msg.Audio = &audio
+		}
+		for _, tc := range acc.toolCalls {
+			call := *tc
+			call.Index = nil
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+
+		resp.Choices = append(resp.Choices, provider.ChatCompletionChoice{
+			Index:        index,
+			Message:      msg,
+			FinishReason: acc.finishReason,
+			Annotations:  acc.annotations,
+			Logprobs:     acc.logprobs,
+		})
+	}
+
+	return &resp
+}
+
+// AccumulateStream consumes a stream until it ends and returns the assembled
+// response. The stream is closed before returning. If the stream fails midway,
+// the partial response is returned along with the error.
+func AccumulateStream(stream provider.ChatCompletionStream) (*provider.ChatCompletionResponse, error) {
+	defer stream.Close()
+
+	acc := NewStreamAccumulator()
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return acc.Response(), nil
+		}
+		if err != nil {
+			return acc.Response(), err
+		}
+		acc.Add(chunk)
+	}
+}
+
+// CollectChatCompletion sends a streaming request and returns the fully
+// assembled response, for callers that want streaming transport semantics
+// (e.g., long generations behind idle-timeout proxies) with a single result.
+func (c *ChatClient) CollectChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	stream, err := c.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return AccumulateStream(stream)
+}
@@ -0,0 +1,256 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func intPtr(i int) *int { return &i }
+
+func strPtr(s string) *string { return &s }
+
+func toolDeltaChunk(index *int, id, name, args string) *provider.ChatCompletionChunk {
+	return &provider.ChatCompletionChunk{
+		Choices: []provider.ChatCompletionChoice{{
+			Delta: &provider.Message{ToolCalls: []provider.ToolCall{{
+				Index:    index,
+				ID:       id,
+				Function: provider.ToolFunction{Name: name, Arguments: args},
+			}}},
+		}},
+	}
+}
+
+func TestAccumulateStream_Content(t *testing.T) {
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		{ID: "chunk-1", Model: "m", Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Role: RoleAssistant, Content: "Hello"}}}},
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: ", world"}}}},
+		{Choices: []provider.ChatCompletionChoice{{FinishReason: strPtr("stop")}}},
+		{Usage: &Usage{PromptTokens: 5, CompletionTokens: 3, TotalTokens: 8}},
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+	if !stream.closed {
+		t.Error("stream was not closed")
+	}
+	if resp.ID != "chunk-1" || resp.Model != "m" {
+		t.Errorf("ID/Model = %q/%q", resp.ID, resp.Model)
+	}
+	if len(resp.Choices) != 1 {
+		t.Fatalf("Choices = %d, want 1", len(resp.Choices))
+	}
+	choice := resp.Choices[0]
+	if choice.Message.Content != "Hello, world" || choice.Message.Role != RoleAssistant {
+		t.Errorf("Message = %+v", choice.Message)
+	}
+	if choice.FinishReason == nil || *choice.FinishReason != "stop" {
+		t.Errorf("FinishReason = %v, want stop", choice.FinishReason)
+	}
+	if resp.Usage.TotalTokens != 8 {
+		t.Errorf("TotalTokens = %d, want 8", resp.Usage.TotalTokens)
+	}
+}
+
+func TestAccumulateStream_IndexedToolCalls(t *testing.T) {
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		toolDeltaChunk(intPtr(0), "call_a", "get_weather", ""),
+		toolDeltaChunk(intPtr(1), "call_b", "get_time", `{"tz":`),
+		toolDeltaChunk(intPtr(0), "", "", `{"city":`),
+		toolDeltaChunk(intPtr(0), "", "", `"Paris"}`),
+		toolDeltaChunk(intPtr(1), "", "", `"UTC"}`),
+		{Choices: []provider.ChatCompletionChoice{{FinishReason: strPtr("tool_calls")}}},
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 2 {
+		t.Fatalf("ToolCalls = %d, want 2", len(calls))
+	}
+	if calls[0].ID != "call_a" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("ToolCalls[0] = %+v", calls[0])
+	}
+	if calls[1].ID != "call_b" || calls[1].Function.Arguments != `{"tz":"UTC"}` {
+		t.Errorf("ToolCalls[1] = %+v", calls[1])
+	}
+	if calls[0].Index != nil || calls[0].Type != "function" {
+		t.Errorf("assembled call should have no index and function type: %+v", calls[0])
+	}
+}
+
+func TestAccumulateStream_UnindexedToolCalls(t *testing.T) {
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		toolDeltaChunk(nil, "call_a", "first", `{"a":`),
+		toolDeltaChunk(nil, "", "", `1}`),
+		toolDeltaChunk(nil, "call_b", "second", `{}`),
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 2 || calls[0].Function.Arguments != `{"a":1}` || calls[1].Function.Name != "second" {
+		t.Errorf("ToolCalls = %+v", calls)
+	}
+}
+
+func TestAccumulateStream_MultipleChoices(t *testing.T) {
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		{Choices: []provider.ChatCompletionChoice{
+			{Index: 1, Delta: &provider.Message{Content: "B"}},
+			{Index: 0, Delta: &provider.Message{Content: "A"}},
+		}},
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+	if len(resp.Choices) != 2 || resp.Choices[0].Message.Content != "A" || resp.Choices[1].Message.Content != "B" {
+		t.Errorf("Choices = %+v", resp.Choices)
+	}
+}
+
+func TestAccumulateStream_Logprobs(t *testing.T) {
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		{Choices: []provider.ChatCompletionChoice{{
+			Delta:    &provider.Message{Content: "Hel"},
+			Logprobs: &Logprobs{Content: []TokenLogprob{{Token: "Hel", Logprob: -0.1}}},
+		}}},
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: ""}}}},
+		{Choices: []provider.ChatCompletionChoice{{
+			Delta:    &provider.Message{Content: "lo"},
+			Logprobs: &Logprobs{Content: []TokenLogprob{{Token: "lo", Logprob: -0.3}}},
+		}}},
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+	lp := resp.Choices[0].Logprobs
+	if lp == nil || len(lp.Content) != 2 || lp.Content[0].Token != "Hel" || lp.Content[1].Token != "lo" {
+		t.Errorf("Logprobs = %+v", lp)
+	}
+}
+
+func TestAccumulateStream_Thinking(t *testing.T) {
+	thinkingChunk := func(block provider.ThinkingBlock) *provider.ChatCompletionChunk {
+		return &provider.ChatCompletionChunk{Choices: []provider.ChatCompletionChoice{{
+			Delta: &provider.Message{Thinking: []provider.ThinkingBlock{block}},
+		}}}
+	}
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		thinkingChunk(provider.ThinkingBlock{Text: "First, "}),
+		thinkingChunk(provider.ThinkingBlock{Text: "multiply."}),
+		thinkingChunk(provider.ThinkingBlock{Signature: "sig-1"}),
+		thinkingChunk(provider.ThinkingBlock{Redacted: "opaque"}),
+		thinkingChunk(provider.ThinkingBlock{Text: "Then check."}),
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "12231"}}}},
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+	msg := resp.Choices[0].Message
+	want := []provider.ThinkingBlock{{Text: "First, multiply.", Signature: "sig-1"}, {Redacted: "opaque"}, {Text: "Then check."}}
+	if msg.Content != "12231" || len(msg.Thinking) != len(want) {
+		t.Fatalf("Message = %+v", msg)
+	}
+	for i := range want {
+		if msg.Thinking[i] != want[i] {
+			t.Errorf("Thinking[%d] = %+v, want %+v", i, msg.Thinking[i], want[i])
+		}
+	}
+}
+
+func TestAccumulateStream_Audio(t *testing.T) {
+	audioChunk := func(audio provider.Audio) *provider.ChatCompletionChunk {
+		return &provider.ChatCompletionChunk{Choices: []provider.ChatCompletionChoice{{
+			Delta: &provider.Message{Audio: &audio},
+		}}}
+	}
+	stream := &MockStream{chunks: []*provider.ChatCompletionChunk{
+		audioChunk(provider.Audio{ID: "audio_1", Format: "pcm16", Transcript: "Hel"}),
+		audioChunk(provider.Audio{Data: []byte{1, 2}, Transcript: "lo"}),
+		audioChunk(provider.Audio{Data: []byte{3}, ExpiresAt: 1735689600}),
+	}}
+
+	resp, err := AccumulateStream(stream)
+	if err != nil {
+		t.Fatalf("AccumulateStream() error = %v", err)
+	}
+	audio := resp.Choices[0].Message.Audio
+	if audio == nil || audio.ID != "audio_1" || audio.Format != "pcm16" || audio.Transcript != "Hello" ||
+		string(audio.Data) != "\x01\x02\x03" || audio.ExpiresAt != 1735689600 {
+		t.Errorf("Audio = %+v", audio)
+	}
+}
+
+type failingStream struct {
+	MockStream
+	err error
+}
+
+func (f *failingStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := f.MockStream.Recv()
+	if err != nil {
+		return nil, f.err
+	}
+	return chunk, nil
+}
+
+func TestAccumulateStream_ErrorReturnsPartial(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	stream := &failingStream{
+		MockStream: MockStream{chunks: []*provider.ChatCompletionChunk{
+			{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "partial"}}}},
+		}},
+		err: streamErr,
+	}
+
+	resp, err := AccumulateStream(stream)
+	if !errors.Is(err, streamErr) {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+	if resp == nil || resp.Choices[0].Message.Content != "partial" {
+		t.Errorf("expected partial response, got %+v", resp)
+	}
+}
+
+func TestChatClient_CollectChatCompletion(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.streamChunks = []*provider.ChatCompletionChunk{
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "Hi"}}}},
+		{Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: "!"}, FinishReason: strPtr("stop")}}},
+	}
+
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	resp, err := client.CollectChatCompletion(context.Background(), &ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: RoleUser, Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("CollectChatCompletion failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "Hi!" {
+		t.Errorf("Content = %q, want Hi!", resp.Choices[0].Message.Content)
+	}
+}
@@ -0,0 +1,98 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// StreamEvent is a chunk or terminal error delivered by StreamChannel
+type StreamEvent struct {
+	Chunk *provider.ChatCompletionChunk
+	Err   error
+}
+
+// StreamChunks adapts a stream to a range-over-func iterator. The stream is
+// closed when iteration ends or when the loop breaks early, always on the
+// iterating goroutine. When ctx is canceled, iteration ends with a final
+// ctx.Err() error once the pending Recv returns; streams created by ChatClient
+// with the same ctx are bound to it and return at once.
+func StreamChunks(ctx context.Context, stream provider.ChatCompletionStream) iter.Seq2[*provider.ChatCompletionChunk, error] {
+	return func(yield func(*provider.ChatCompletionChunk, error) bool) {
+		defer func() { _ = stream.Close() }()
+
+		for {
+			chunk, err := stream.Recv()
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				yield(nil, ctxErr)
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
+}
+
+// StreamText sends a streaming request and returns an iterator over the
+// content deltas of the first choice:
+//
+//	for text, err := range client.StreamText(ctx, req) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Print(text)
+//	}
+func (c *ChatClient) StreamText(ctx context.Context, req *provider.ChatCompletionRequest) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		stream, err := c.CreateChatCompletionStream(ctx, req)
+		if err != nil {
+			yield("", err)
+			return
+		}
+
+		for chunk, err := range StreamChunks(ctx, stream) {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			for _, choice := range chunk.Choices {
+				if choice.Index != 0 || choice.Delta == nil || choice.Delta.Content == "" {
+					continue
+				}
+				if !yield(choice.Delta.Content, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// StreamChannel consumes a stream in a background goroutine and delivers its
+// chunks on the returned channel. A non-EOF error is delivered as a final
+// event. The channel is closed when the stream ends, and the stream is closed
+// when ctx is canceled, so callers should cancel ctx if they stop reading early.
+func StreamChannel(ctx context.Context, stream provider.ChatCompletionStream) <-chan StreamEvent {
+	events := make(chan StreamEvent)
+	go func() {
+		defer close(events)
+		for chunk, err := range StreamChunks(ctx, stream) {
+			select {
+			case events <- StreamEvent{Chunk: chunk, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
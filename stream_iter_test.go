@@ -0,0 +1,233 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func textChunks(texts ...string) []*provider.ChatCompletionChunk {
+	chunks := make([]*provider.ChatCompletionChunk, 0, len(texts))
+	for _, text := range texts {
+		chunks = append(chunks, &provider.ChatCompletionChunk{
+			Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: text}}},
+		})
+	}
+	return chunks
+}
+
+// ctxStream returns its chunks and then blocks in Recv until ctx is done, as
+// a stream bound to its request context does
+type ctxStream struct {
+	ctx    context.Context
+	chunks []*provider.ChatCompletionChunk
+}
+
+func (s *ctxStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if len(s.chunks) > 0 {
+		chunk := s.chunks[0]
+		s.chunks = s.chunks[1:]
+		return chunk, nil
+	}
+	<-s.ctx.Done()
+	return nil, s.ctx.Err()
+}
+
+func (s *ctxStream) Close() error {
+	return nil
+}
+
+// ctxStreamProvider is a MockProvider whose streams are bound to the request
+// context
+type ctxStreamProvider struct {
+	*MockProvider
+}
+
+func (p ctxStreamProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	return &ctxStream{ctx: ctx, chunks: p.streamChunks}, nil
+}
+
+func TestStreamChunks(t *testing.T) {
+	stream := &MockStream{chunks: textChunks("a", "b", "c")}
+
+	var got string
+	for chunk, err := range StreamChunks(context.Background(), stream) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got += chunk.Choices[0].Delta.Content
+	}
+	if got != "abc" {
+		t.Errorf("got %q, want abc", got)
+	}
+	if !stream.closed {
+		t.Error("stream was not closed")
+	}
+}
+
+func TestStreamChunks_BreakClosesStream(t *testing.T) {
+	stream := &MockStream{chunks: textChunks("a", "b", "c")}
+
+	for range StreamChunks(context.Background(), stream) {
+		break
+	}
+	if !stream.closed {
+		t.Error("stream was not closed after break")
+	}
+}
+
+func TestStreamChunks_ContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &ctxStream{ctx: ctx}
+
+	done := make(chan error, 1)
+	go func() {
+		for _, err := range StreamChunks(ctx, stream) {
+			done <- err
+			return
+		}
+		done <- nil
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("iteration did not stop after cancel")
+	}
+}
+
+func TestStreamChunks_ContextTimeoutWithMemory(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.streamChunks = textChunks("partial", " reply")
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: ctxStreamProvider{mockProv}}},
+		Memory:    mocktest.NewMockKVS(),
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stream, err := client.CreateChatCompletionStreamWithMemory(ctx, "session", &ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: RoleUser, Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStreamWithMemory failed: %v", err)
+	}
+
+	var got string
+	var lastErr error
+	for chunk, err := range StreamChunks(ctx, stream) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		got += chunk.Choices[0].Delta.Content
+	}
+	if got != "partial reply" {
+		t.Errorf("got %q, want %q", got, "partial reply")
+	}
+	if !errors.Is(lastErr, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", lastErr)
+	}
+
+	// The reply was cut off, so nothing is saved
+	messages, err := client.GetConversationMessages(context.Background(), "session")
+	if err != nil {
+		t.Fatalf("GetConversationMessages failed: %v", err)
+	}
+	if len(messages) != 0 {
+		t.Errorf("expected no saved messages, got %d", len(messages))
+	}
+}
+
+func TestChatClient_StreamText(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.streamChunks = textChunks("Hello", ", ", "world")
+
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	var got string
+	for text, err := range client.StreamText(context.Background(), &ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: RoleUser, Content: "Hi"}},
+	}) {
+		if err != nil {
+			t.Fatalf("StreamText error: %v", err)
+		}
+		got += text
+	}
+	if got != "Hello, world" {
+		t.Errorf("got %q, want %q", got, "Hello, world")
+	}
+}
+
+func TestChatClient_StreamText_Error(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.streamError = errors.New("boom")
+
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mockProv}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	var gotErr error
+	for _, err := range client.StreamText(context.Background(), &ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []Message{{Role: RoleUser, Content: "Hi"}},
+	}) {
+		gotErr = err
+	}
+	if gotErr == nil {
+		t.Error("expected error from StreamText")
+	}
+}
+
+func TestStreamChannel(t *testing.T) {
+	stream := &MockStream{chunks: textChunks("x", "y")}
+
+	var got string
+	for event := range StreamChannel(context.Background(), stream) {
+		if event.Err != nil {
+			t.Fatalf("unexpected error: %v", event.Err)
+		}
+		got += event.Chunk.Choices[0].Delta.Content
+	}
+	if got != "xy" {
+		t.Errorf("got %q, want xy", got)
+	}
+}
+
+func TestStreamChannel_ContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := StreamChannel(ctx, &ctxStream{ctx: ctx})
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			// A cancellation event may be delivered before the channel closes
+			for range events {
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel did not close after cancel")
+	}
+}
@@ -0,0 +1,439 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrInvalidStreamJSON is returned when streamed JSON content is malformed or
+// an array element fails validation
+var ErrInvalidStreamJSON = errors.New("invalid streamed JSON")
+
+// StreamJSON consumes a stream whose content is a JSON document, such as a
+// response in JSON mode or with a JSON Schema response format, and yields the
+// value parsed so far each time it grows, so a UI can render it
+// progressively. Partial values are decoded as by ParsePartialJSON. The last
+// value yielded is the complete document; if the stream ends with content
+// that is not valid JSON, the final error matches ErrInvalidStreamJSON.
+func StreamJSON(ctx context.Context, stream provider.ChatCompletionStream) iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		var content strings.Builder
+		var last any
+		yielded := false
+		for chunk, err := range StreamChunks(ctx, stream) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			delta := deltaContent(chunk)
+			if delta == "" {
+				continue
+			}
+			content.WriteString(delta)
+
+			value, ok := ParsePartialJSON(content.String())
+			if !ok || (yielded && reflect.DeepEqual(value, last)) {
+				continue
+			}
+			last, yielded = value, true
+			if !yield(value, nil) {
+				return
+			}
+		}
+
+		var value any
+		if err := json.Unmarshal([]byte(content.String()), &value); err != nil {
+			yield(nil, fmt.Errorf("%w: %w", ErrInvalidStreamJSON, err))
+			return
+		}
+		if !yielded || !reflect.DeepEqual(value, last) {
+			yield(value, nil)
+		}
+	}
+}
+
+// StreamJSONArray consumes a stream whose content is a JSON array and yields
+// each top-level element as soon as it is complete. If itemSchema is not nil,
+// each element is validated against it, as for OutputGuardrailConfig's
+// JSONSchema. A malformed array or an invalid element ends iteration with an
+// error matching ErrInvalidStreamJSON.
+func StreamJSONArray(ctx context.Context, stream provider.ChatCompletionStream, itemSchema map[string]any) iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		var scanner jsonArrayScanner
+		emit := func(elements []json.RawMessage, err error) bool {
+			first := scanner.count - len(elements)
+			for i, element := range elements {
+				if verr := validateArrayElement(element, itemSchema, first+i); verr != nil {
+					yield(nil, verr)
+					return false
+				}
+				if !yield(element, nil) {
+					return false
+				}
+			}
+			if err != nil {
+				yield(nil, err)
+				return false
+			}
+			return true
+		}
+
+		for chunk, err := range StreamChunks(ctx, stream) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if delta := deltaContent(chunk); delta != "" {
+				if !emit(scanner.feed(delta)) {
+					return
+				}
+			}
+		}
+		if !scanner.ended {
+			yield(nil, fmt.Errorf("%w: array is not closed", ErrInvalidStreamJSON))
+		}
+	}
+}
+
+// validateArrayElement checks the element at index against itemSchema
+func validateArrayElement(element json.RawMessage, itemSchema map[string]any, index int) error {
+	var value any
+	if err := json.Unmarshal(element, &value); err != nil {
+		return fmt.Errorf("%w: element %d: %w", ErrInvalidStreamJSON, index, err)
+	}
+	if itemSchema == nil {
+		return nil
+	}
+	if problems := validateJSONSchema(value, itemSchema, fmt.Sprintf("$[%d]", index)); len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidStreamJSON, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// deltaContent returns the content delta of the first choice in chunk
+func deltaContent(chunk *provider.ChatCompletionChunk) string {
+	for _, choice := range chunk.Choices {
+		if choice.Index == 0 && choice.Delta != nil {
+			return choice.Delta.Content
+		}
+	}
+	return ""
+}
+
+// jsonArrayScanner splits streamed JSON array content into its top-level
+// elements as they complete
+type jsonArrayScanner struct {
+	buf       []byte
+	pos       int  // Next byte to scan
+	start     int  // Start of the current element
+	depth     int  // Nesting depth; 1 inside the top-level array
+	inString  bool // Inside a string
+	escaped   bool // After a backslash in a string
+	started   bool // The opening bracket was seen
+	ended     bool // The closing bracket was seen
+	count     int  // Elements found so far
+	separated bool // A comma was seen since the last element
+}
+
+// feed scans more content and returns the elements it completed
+func (s *jsonArrayScanner) feed(content string) ([]json.RawMessage, error) {
+	s.buf = append(s.buf, content...)
+	var elements []json.RawMessage
+	for ; s.pos < len(s.buf); s.pos++ {
+		c := s.buf[s.pos]
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+			}
+			continue
+		}
+		if !s.started || s.ended {
+			if isJSONSpace(c) {
+				continue
+			}
+			if s.ended {
+				return elements, fmt.Errorf("%w: content after the array", ErrInvalidStreamJSON)
+			}
+			if c != '[' {
+				return elements, fmt.Errorf("%w: expected a JSON array", ErrInvalidStreamJSON)
+			}
+			s.started, s.depth, s.start = true, 1, s.pos+1
+			continue
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+		case '{', '[':
+			s.depth++
+		case '}', ']':
+			s.depth--
+			if s.depth > 0 {
+				continue
+			}
+			s.ended = true
+			element, err := s.element()
+			if err == nil && element == nil && s.separated {
+				err = fmt.Errorf("%w: trailing comma", ErrInvalidStreamJSON)
+			}
+			if err != nil {
+				return elements, err
+			}
+			if element != nil {
+				elements = append(elements, element)
+			}
+		case ',':
+			if s.depth != 1 {
+				continue
+			}
+			element, err := s.element()
+			if err == nil && element == nil {
+				err = fmt.Errorf("%w: empty array element", ErrInvalidStreamJSON)
+			}
+			if err != nil {
+				return elements, err
+			}
+			elements = append(elements, element)
+			s.start, s.separated = s.pos+1, true
+		}
+	}
+	return elements, nil
+}
+
+// element returns the element between start and pos, or nil if it is empty
+func (s *jsonArrayScanner) element() (json.RawMessage, error) {
+	raw := strings.TrimFunc(string(s.buf[s.start:s.pos]), func(r rune) bool {
+		return r < utf8.RuneSelf && isJSONSpace(byte(r))
+	})
+	if raw == "" {
+		return nil, nil
+	}
+	if !json.Valid([]byte(raw)) {
+		return nil, fmt.Errorf("%w: element %d is not valid JSON", ErrInvalidStreamJSON, s.count)
+	}
+	s.count++
+	s.separated = false
+	return json.RawMessage(raw), nil
+}
+
+// ParsePartialJSON decodes the beginning of a JSON document, as received so
+// far from a stream, into the same types as encoding/json. Open objects and
+// arrays hold the members and elements received so far, including a partial
+// last one, and an open string holds the text received so far. Members whose
+// key or value has not started are left out, as are numbers and literals such
+// as true that may still be cut off. It returns false if prefix is malformed
+// or holds no value yet.
+func ParsePartialJSON(prefix string) (any, bool) {
+	p := partialJSONParser{s: prefix}
+	value, state := p.value()
+	if p.invalid || state == partialNone {
+		return nil, false
+	}
+	return value, true
+}
+
+// Parse states of a partial JSON value
+const (
+	partialNone       = iota // No value before the end of input
+	partialIncomplete        // A value cut off by the end of input
+	partialComplete          // A complete value
+)
+
+// partialJSONParser is a JSON parser that accepts input cut off at any point
+type partialJSONParser struct {
+	s       string
+	pos     int
+	invalid bool
+}
+
+func (p *partialJSONParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *partialJSONParser) skipSpace() {
+	for !p.eof() && isJSONSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *partialJSONParser) fail() (any, int) {
+	p.invalid = true
+	return nil, partialNone
+}
+
+// value parses the value at pos
+func (p *partialJSONParser) value() (any, int) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, partialNone
+	}
+	switch p.s[p.pos] {
+	case '{':
+		return p.object()
+	case '[':
+		return p.array()
+	case '"':
+		return p.string()
+	default:
+		return p.literal()
+	}
+}
+
+// object parses the object at pos
+func (p *partialJSONParser) object() (any, int) {
+	p.pos++
+	obj := make(map[string]any)
+	for first := true; ; first = false {
+		p.skipSpace()
+		if p.eof() {
+			return obj, partialIncomplete
+		}
+		if p.s[p.pos] == '}' {
+			p.pos++
+			return obj, partialComplete
+		}
+		if !first {
+			if p.s[p.pos] != ',' {
+				return p.fail()
+			}
+			p.pos++
+			p.skipSpace()
+			if p.eof() {
+				return obj, partialIncomplete
+			}
+		}
+		if p.s[p.pos] != '"' {
+			return p.fail()
+		}
+		key, state := p.string()
+		if p.invalid {
+			return nil, partialNone
+		}
+		if state != partialComplete {
+			return obj, partialIncomplete
+		}
+		p.skipSpace()
+		if p.eof() {
+			return obj, partialIncomplete
+		}
+		if p.s[p.pos] != ':' {
+			return p.fail()
+		}
+		p.pos++
+
+		value, state := p.value()
+		if p.invalid {
+			return nil, partialNone
+		}
+		if state == partialNone {
+			return obj, partialIncomplete
+		}
+		obj[key.(string)] = value
+		if state == partialIncomplete {
+			return obj, partialIncomplete
+		}
+	}
+}
+
+// array parses the array at pos
+func (p *partialJSONParser) array() (any, int) {
+	p.pos++
+	arr := []any{}
+	for first := true; ; first = false {
+		p.skipSpace()
+		if p.eof() {
+			return arr, partialIncomplete
+		}
+		if p.s[p.pos] == ']' {
+			p.pos++
+			return arr, partialComplete
+		}
+		if !first {
+			if p.s[p.pos] != ',' {
+				return p.fail()
+			}
+			p.pos++
+		}
+
+		value, state := p.value()
+		if p.invalid {
+			return nil, partialNone
+		}
+		if state == partialNone {
+			return arr, partialIncomplete
+		}
+		arr = append(arr, value)
+		if state == partialIncomplete {
+			return arr, partialIncomplete
+		}
+	}
+}
+
+// string parses the string at pos. A cut-off string is decoded up to the
+// last complete character.
+func (p *partialJSONParser) string() (any, int) {
+	start := p.pos
+	for p.pos++; !p.eof(); p.pos++ {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal([]byte(p.s[start:p.pos]), &s); err != nil {
+				return p.fail()
+			}
+			return s, partialComplete
+		}
+	}
+
+	// Drop a cut-off escape sequence or UTF-8 character, which are at most
+	// 12 bytes (a surrogate pair), until the rest decodes
+	raw := p.s[start+1:]
+	for trim := 0; trim <= 12 && trim <= len(raw); trim++ {
+		body := raw[:len(raw)-trim]
+		if !utf8.ValidString(body) {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal([]byte(`"`+body+`"`), &s); err == nil {
+			return s, partialIncomplete
+		}
+	}
+	return p.fail()
+}
+
+// literal parses the number, true, false, or null at pos. One that reaches
+// the end of input may be cut off, so it is not returned.
+func (p *partialJSONParser) literal() (any, int) {
+	start := p.pos
+	for !p.eof() && strings.IndexByte("+-.0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.eof() {
+		return nil, partialNone
+	}
+	var value any
+	if err := json.Unmarshal([]byte(p.s[start:p.pos]), &value); err != nil {
+		return p.fail()
+	}
+	return value, partialComplete
+}
+
+// isJSONSpace reports whether c is JSON whitespace
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
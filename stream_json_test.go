@@ -0,0 +1,137 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParsePartialJSON(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   any
+		ok     bool
+	}{
+		{``, nil, false},
+		{`{`, map[string]any{}, true},
+		{`{"na`, map[string]any{}, true},
+		{`{"name": "Ad`, map[string]any{"name": "Ad"}, true},
+		{`{"name": "Ada", "age": 3`, map[string]any{"name": "Ada"}, true},
+		{`{"name": "Ada", "age": 36,`, map[string]any{"name": "Ada", "age": float64(36)}, true},
+		{`{"tags": ["a", "b`, map[string]any{"tags": []any{"a", "b"}}, true},
+		{`{"ok": tr`, map[string]any{}, true},
+		{`{"s": "a\`, map[string]any{"s": "a"}, true},
+		{`{"s": "a\u00`, map[string]any{"s": "a"}, true},
+		{`{"s": "caf` + "\xc3", map[string]any{"s": "caf"}, true},
+		{`[1, 2, {"a": null}]`, []any{float64(1), float64(2), map[string]any{"a": nil}}, true},
+		{`{"a" 1`, nil, false},
+		{`{1`, nil, false},
+		{`[1 2`, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParsePartialJSON(tt.prefix)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePartialJSON(%q) = %#v, %v; want %#v, %v", tt.prefix, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestStreamJSON(t *testing.T) {
+	stream := &MockStream{chunks: textChunks(`{"title": "Dun`, `e", "year"`, `: 1965, `, `"tags": ["sf"]}`)}
+
+	var got []any
+	for value, err := range StreamJSON(context.Background(), stream) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, value)
+	}
+	want := []any{
+		map[string]any{"title": "Dun"},
+		map[string]any{"title": "Dune"},
+		map[string]any{"title": "Dune", "year": float64(1965)},
+		map[string]any{"title": "Dune", "year": float64(1965), "tags": []any{"sf"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestStreamJSON_Invalid(t *testing.T) {
+	stream := &MockStream{chunks: textChunks(`{"title": "Dune"`)}
+
+	var err error
+	for _, err = range StreamJSON(context.Background(), stream) {
+	}
+	if !errors.Is(err, ErrInvalidStreamJSON) {
+		t.Errorf("got %v, want ErrInvalidStreamJSON", err)
+	}
+}
+
+func TestStreamJSONArray(t *testing.T) {
+	stream := &MockStream{chunks: textChunks(` [{"n": 1, "s": "a,]"}`, `, {"n": [2`, `]}, 3`, `]`)}
+
+	var got []string
+	for element, err := range StreamJSONArray(context.Background(), stream, nil) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, string(element))
+	}
+	want := []string{`{"n": 1, "s": "a,]"}`, `{"n": [2]}`, `3`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStreamJSONArray_Schema(t *testing.T) {
+	schema := map[string]any{
+		"type":       "object",
+		"required":   []any{"n"},
+		"properties": map[string]any{"n": map[string]any{"type": "integer"}},
+	}
+	stream := &MockStream{chunks: textChunks(`[{"n": 1}, {"m": 2}, {"n": 3}]`)}
+
+	var got []json.RawMessage
+	var err error
+	for element, e := range StreamJSONArray(context.Background(), stream, schema) {
+		if e != nil {
+			err = e
+			break
+		}
+		got = append(got, element)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d elements before the error, want 1", len(got))
+	}
+	if !errors.Is(err, ErrInvalidStreamJSON) {
+		t.Errorf("got %v, want ErrInvalidStreamJSON", err)
+	}
+}
+
+func TestStreamJSONArray_Malformed(t *testing.T) {
+	tests := map[string][]string{
+		"not an array":   {`{"n": 1}`},
+		"unclosed":       {`[1, 2`},
+		"trailing comma": {`[1, 2,]`},
+		"empty element":  {`[1,, 2]`},
+		"trailing text":  {`[1]`, ` x`},
+		"invalid":        {`[tru]`},
+	}
+	for name, parts := range tests {
+		t.Run(name, func(t *testing.T) {
+			stream := &MockStream{chunks: textChunks(parts...)}
+			var err error
+			for _, e := range StreamJSONArray(context.Background(), stream, nil) {
+				if e != nil {
+					err = e
+				}
+			}
+			if !errors.Is(err, ErrInvalidStreamJSON) {
+				t.Errorf("got %v, want ErrInvalidStreamJSON", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// StreamStats summarizes a finished streaming call
+type StreamStats struct {
+	// TimeToFirstToken is the time from the start of the call to the first
+	// chunk carrying content or a tool call. Zero if no such chunk arrived.
+	TimeToFirstToken time.Duration
+
+	// Duration is the time from the start of the call to the end of the stream
+	Duration time.Duration
+
+	// Chunks is the number of chunks received
+	Chunks int
+
+	// MeanInterChunkLatency and MaxInterChunkLatency measure the gaps between
+	// consecutive chunks. Zero if fewer than two chunks arrived.
+	MeanInterChunkLatency time.Duration
+	MaxInterChunkLatency  time.Duration
+
+	// Response is the response assembled from the chunks received
+	Response *provider.ChatCompletionResponse
+
+	// Usage is the final usage reported by the provider, or nil if none was reported
+	Usage *provider.Usage
+
+	// Completed is true if the stream ended with a clean io.EOF
+	Completed bool
+
+	// Err is the error that ended the stream, or nil on clean EOF or when the
+	// caller closed the stream early
+	Err error
+}
+
+// StreamObservabilityHook is an optional extension of ObservabilityHook for
+// hooks that want a structured signal when a stream ends. The client calls
+// AfterStream exactly once per stream: on io.EOF, on a stream error, or when
+// the caller closes the stream early.
+type StreamObservabilityHook interface {
+	ObservabilityHook
+
+	// AfterStream is called when a stream created by CreateChatCompletionStream ends
+	AfterStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stats StreamStats)
+}
+
+// AfterStream forwards to each hook in the chain that implements
+// StreamObservabilityHook, in reverse order
+func (c *hookChain) AfterStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stats StreamStats) {
+	for i := len(c.hooks) - 1; i >= 0; i-- {
+		if h, ok := c.hooks[i].(StreamObservabilityHook); ok {
+			h.AfterStream(c.hookContext(ctx, i), info, req, stats)
+		}
+	}
+}
+
+// observesStreams reports whether AfterStream does anything; a hook chain only
+// observes streams if one of its hooks does
+func observesStreams(h StreamObservabilityHook) bool {
+	chain, ok := h.(*hookChain)
+	if !ok {
+		return true
+	}
+	for _, hook := range chain.hooks {
+		if _, ok := hook.(StreamObservabilityHook); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// metricsStream measures a stream and reports StreamStats when it ends
+type metricsStream struct {
+	stream provider.ChatCompletionStream
+	ctx    context.Context
+	info   LLMCallInfo
+	req    *provider.ChatCompletionRequest
+	hook   StreamObservabilityHook
+
+	acc        *StreamAccumulator
+	chunks     int
+	firstToken time.Duration
+	lastChunk  time.Time
+	totalGap   time.Duration
+	maxGap     time.Duration
+	finishOnce sync.Once
+}
+
+// newMetricsStream wraps stream so hook receives StreamStats when it ends
+func newMetricsStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream, hook StreamObservabilityHook) *metricsStream {
+	return &metricsStream{
+		stream: stream,
+		ctx:    ctx,
+		info:   info,
+		req:    req,
+		hook:   hook,
+		acc:    NewStreamAccumulator(),
+	}
+}
+
+func (s *metricsStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			s.finish(true, nil)
+		} else {
+			s.finish(false, err)
+		}
+		return chunk, err
+	}
+
+	now := time.Now()
+	if s.chunks > 0 {
+		gap := now.Sub(s.lastChunk)
+		s.totalGap += gap
+		if gap > s.maxGap {
+			s.maxGap = gap
+		}
+	}
+	s.lastChunk = now
+	s.chunks++
+	if s.firstToken == 0 && hasToken(chunk) {
+		s.firstToken = now.Sub(s.info.StartTime)
+	}
+	s.acc.Add(chunk)
+	return chunk, nil
+}
+
+func (s *metricsStream) Close() error {
+	err := s.stream.Close()
+	s.finish(false, nil)
+	return err
+}
+
+// finish reports the stats once
+func (s *metricsStream) finish(completed bool, err error) {
+	s.finishOnce.Do(func() {
+		resp := s.acc.Response()
+		stats := StreamStats{
+			TimeToFirstToken:     s.firstToken,
+			Duration:             time.Since(s.info.StartTime),
+			Chunks:               s.chunks,
+			MaxInterChunkLatency: s.maxGap,
+			Response:             resp,
+			Completed:            completed,
+			Err:                  err,
+		}
+		if s.chunks > 1 {
+			stats.MeanInterChunkLatency = s.totalGap / time.Duration(s.chunks-1)
+		}
+		if resp.Usage != (provider.Usage{}) {
+			usage := resp.Usage
+			stats.Usage = &usage
+		}
+		s.hook.AfterStream(s.ctx, s.info, s.req, stats)
+	})
+}
+
+// hasToken reports whether a chunk carries generated content or a tool call
+func hasToken(chunk *provider.ChatCompletionChunk) bool {
+	for _, choice := range chunk.Choices {
+		if choice.Delta != nil && (choice.Delta.Content != "" || len(choice.Delta.ToolCalls) > 0) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// statsHook records the StreamStats it receives
+type statsHook struct {
+	stats []StreamStats
+}
+
+func (h *statsHook) BeforeRequest(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	return ctx
+}
+
+func (h *statsHook) AfterResponse(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+}
+
+func (h *statsHook) WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return stream
+}
+
+func (h *statsHook) AfterStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stats StreamStats) {
+	h.stats = append(h.stats, stats)
+}
+
+func newStatsClient(t *testing.T, prov provider.Provider, hooks ...ObservabilityHook) *ChatClient {
+	t.Helper()
+	client, err := NewClient(ClientConfig{
+		Providers:          []ProviderConfig{{CustomProvider: prov}},
+		ObservabilityHooks: hooks,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func streamRequest() *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}
+}
+
+func TestStreamStats_CleanEOF(t *testing.T) {
+	chunks := append([]*provider.ChatCompletionChunk{{}}, textChunks("Hel", "lo")...)
+	chunks = append(chunks, &provider.ChatCompletionChunk{
+		Usage: &provider.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5},
+	})
+	mockProv := NewMockProvider("test")
+	mockProv.streamChunks = chunks
+
+	hook := &statsHook{}
+	client := newStatsClient(t, mockProv, hook)
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), streamRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(hook.stats) != 1 {
+		t.Fatalf("AfterStream called %d times, want 1", len(hook.stats))
+	}
+	stats := hook.stats[0]
+	if !stats.Completed || stats.Err != nil {
+		t.Errorf("expected clean completion, got completed=%v err=%v", stats.Completed, stats.Err)
+	}
+	if stats.Chunks != 4 {
+		t.Errorf("Chunks = %d, want 4", stats.Chunks)
+	}
+	if stats.TimeToFirstToken <= 0 || stats.TimeToFirstToken > stats.Duration {
+		t.Errorf("TimeToFirstToken = %v, Duration = %v", stats.TimeToFirstToken, stats.Duration)
+	}
+	if stats.MaxInterChunkLatency < stats.MeanInterChunkLatency {
+		t.Errorf("max latency %v < mean %v", stats.MaxInterChunkLatency, stats.MeanInterChunkLatency)
+	}
+	if stats.Usage == nil || stats.Usage.TotalTokens != 5 {
+		t.Errorf("Usage = %+v", stats.Usage)
+	}
+	if got := stats.Response.Choices[0].Message.Content; got != "Hello" {
+		t.Errorf("assembled content = %q", got)
+	}
+}
+
+func TestStreamStats_Error(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	prov := mocktest.NewScriptedProvider("scripted", mocktest.Step{
+		Chunks:    mocktest.TextChunks("partial"),
+		StreamErr: streamErr,
+	})
+	hook := &statsHook{}
+	client := newStatsClient(t, prov, hook)
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), streamRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AccumulateStream(stream); !errors.Is(err, streamErr) {
+		t.Fatalf("expected stream error, got %v", err)
+	}
+
+	if len(hook.stats) != 1 {
+		t.Fatalf("AfterStream called %d times, want 1", len(hook.stats))
+	}
+	stats := hook.stats[0]
+	if stats.Completed || !errors.Is(stats.Err, streamErr) {
+		t.Errorf("expected stream error, got completed=%v err=%v", stats.Completed, stats.Err)
+	}
+	if stats.Usage != nil {
+		t.Errorf("expected no usage, got %+v", stats.Usage)
+	}
+	if got := stats.Response.Choices[0].Message.Content; got != "partial" {
+		t.Errorf("partial content = %q", got)
+	}
+}
+
+func TestStreamStats_ClosedEarly(t *testing.T) {
+	mockProv := NewMockProvider("test")
+	mockProv.streamChunks = textChunks("a", "b", "c")
+	hook := &statsHook{}
+	var log []string
+	client := newStatsClient(t, mockProv, &orderHook{name: "plain", log: &log}, hook)
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), streamRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Close()
+	_ = stream.Close()
+
+	if len(hook.stats) != 1 {
+		t.Fatalf("AfterStream called %d times, want 1", len(hook.stats))
+	}
+	if stats := hook.stats[0]; stats.Completed || stats.Err != nil || stats.Chunks != 1 {
+		t.Errorf("unexpected stats for early close: %+v", stats)
+	}
+}
+
+func TestObservesStreams(t *testing.T) {
+	var log []string
+	plain := &orderHook{name: "plain", log: &log}
+	if h, ok := ComposeHooks(plain, plain).(StreamObservabilityHook); !ok || observesStreams(h) {
+		t.Error("chain without stream hooks should not observe streams")
+	}
+	if h, ok := ComposeHooks(plain, &statsHook{}).(StreamObservabilityHook); !ok || !observesStreams(h) {
+		t.Error("chain with a stream hook should observe streams")
+	}
+}
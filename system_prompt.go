@@ -0,0 +1,76 @@
+package omnillm
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrSystemPromptConflict is returned under SystemPromptError when a request
+// carries its own system messages
+var ErrSystemPromptConflict = errors.New("request system messages conflict with the default system prompt")
+
+// SystemPromptPolicy decides how ClientConfig.DefaultSystemPrompt is merged
+// with the system messages already in a request, including those loaded from
+// conversation memory
+type SystemPromptPolicy int
+
+const (
+	// SystemPromptPrepend places the default system prompt before the
+	// request's messages, keeping any system messages the request has
+	SystemPromptPrepend SystemPromptPolicy = iota
+
+	// SystemPromptReplace uses the default system prompt only for requests
+	// without system messages; a request's own system messages replace it
+	SystemPromptReplace
+
+	// SystemPromptError rejects requests that have their own system messages
+	// with ErrSystemPromptConflict
+	SystemPromptError
+)
+
+// String returns the string representation of the policy
+func (p SystemPromptPolicy) String() string {
+	switch p {
+	case SystemPromptPrepend:
+		return "prepend"
+	case SystemPromptReplace:
+		return "replace"
+	case SystemPromptError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// applySystemPrompt returns req with the client's default system prompt merged
+// in according to its policy, or req itself if nothing changes. The caller's
+// request is never modified.
+func (c *ChatClient) applySystemPrompt(req *provider.ChatCompletionRequest) (*provider.ChatCompletionRequest, error) {
+	if c.systemPrompt == "" || req == nil {
+		return req, nil
+	}
+
+	hasSystem := slices.ContainsFunc(req.Messages, func(m provider.Message) bool {
+		return m.Role == provider.RoleSystem
+	})
+	if hasSystem {
+		switch c.systemPromptPolicy {
+		case SystemPromptReplace:
+			return req, nil
+		case SystemPromptError:
+			return nil, ErrSystemPromptConflict
+		}
+		// A conversation that already starts with the default keeps one copy
+		if first := req.Messages[0]; first.Role == provider.RoleSystem && first.Content == c.systemPrompt {
+			return req, nil
+		}
+	}
+
+	merged := *req
+	merged.Messages = make([]provider.Message, 0, len(req.Messages)+1)
+	merged.Messages = append(merged.Messages, provider.Message{Role: provider.RoleSystem, Content: c.systemPrompt})
+	merged.Messages = append(merged.Messages, req.Messages...)
+	return &merged, nil
+}
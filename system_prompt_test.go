@@ -0,0 +1,157 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestClient_DefaultSystemPrompt(t *testing.T) {
+	user := provider.Message{Role: provider.RoleUser, Content: "hi"}
+	own := provider.Message{Role: provider.RoleSystem, Content: "Answer in French."}
+
+	tests := []struct {
+		name    string
+		policy  SystemPromptPolicy
+		msgs    []provider.Message
+		want    []string
+		wantErr error
+	}{
+		{"prepend without system", SystemPromptPrepend, []provider.Message{user}, []string{"Be brief.", "hi"}, nil},
+		{"prepend with system", SystemPromptPrepend, []provider.Message{own, user}, []string{"Be brief.", "Answer in French.", "hi"}, nil},
+		{"prepend keeps one copy", SystemPromptPrepend, []provider.Message{{Role: provider.RoleSystem, Content: "Be brief."}, user}, []string{"Be brief.", "hi"}, nil},
+		{"replace without system", SystemPromptReplace, []provider.Message{user}, []string{"Be brief.", "hi"}, nil},
+		{"replace with system", SystemPromptReplace, []provider.Message{own, user}, []string{"Answer in French.", "hi"}, nil},
+		{"error without system", SystemPromptError, []provider.Message{user}, []string{"Be brief.", "hi"}, nil},
+		{"error with system", SystemPromptError, []provider.Message{own, user}, nil, ErrSystemPromptConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("ok"))
+			client, err := NewClient(ClientConfig{
+				Providers:           []ProviderConfig{{CustomProvider: mock}},
+				DefaultSystemPrompt: "Be brief.",
+				SystemPromptPolicy:  tt.policy,
+			})
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+			defer client.Close()
+
+			req := &provider.ChatCompletionRequest{Model: "m", Messages: tt.msgs}
+			_, err = client.CreateChatCompletion(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if !IsNonRetryableError(err) || mock.Calls() != 0 {
+					t.Errorf("expected a non-retryable error before any provider call")
+				}
+				return
+			}
+
+			got := mock.LastRequest().Messages
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d messages, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, content := range tt.want {
+				if got[i].Content != content {
+					t.Errorf("message %d = %q, want %q", i, got[i].Content, content)
+				}
+			}
+			if len(req.Messages) != len(tt.msgs) {
+				t.Error("expected the caller's request to be unchanged")
+			}
+		})
+	}
+}
+
+func TestClient_DefaultSystemPromptWithMemory(t *testing.T) {
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.Step{Chunks: mocktest.TextChunks("ok")})
+	client, err := NewClient(ClientConfig{
+		Providers:           []ProviderConfig{{CustomProvider: mock}},
+		Memory:              mocktest.NewMockKVS(),
+		DefaultSystemPrompt: "Be brief.",
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	stream, err := client.CreateChatCompletionStreamWithMemory(ctx, "session", req)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	if _, err := AccumulateStream(stream); err != nil {
+		t.Fatalf("accumulate failed: %v", err)
+	}
+	if got := mock.LastRequest().Messages; len(got) != 2 || got[0].Content != "Be brief." {
+		t.Errorf("expected the default system prompt first, got %+v", got)
+	}
+
+	// The default is injected per request, not saved to the conversation
+	conversation, err := client.Memory().LoadConversation(ctx, "session")
+	if err != nil {
+		t.Fatalf("LoadConversation failed: %v", err)
+	}
+	for _, msg := range conversation.Messages {
+		if msg.Role == provider.RoleSystem {
+			t.Errorf("expected no stored system messages, got %+v", conversation.Messages)
+		}
+	}
+}
+
+func TestClient_DefaultSystemPromptReachesProviders(t *testing.T) {
+	tests := []struct {
+		provider ProviderName
+		response string
+	}{
+		{ProviderNameAnthropic, `{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn"}`},
+		{ProviderNameGemini, `{"candidates":[{"content":{"role":"model","parts":[{"text":"hi"}]},"finishReason":"STOP"}]}`},
+		{ProviderNameOpenAI, `{"id":"c1","object":"chat.completion","model":"m","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.provider), func(t *testing.T) {
+			var body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				body = string(data)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, tt.response)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{
+				Providers:           []ProviderConfig{{Provider: tt.provider, APIKey: "key", BaseURL: server.URL}},
+				DefaultSystemPrompt: "Follow the house style.",
+			})
+			if err != nil {
+				t.Fatalf("NewClient failed: %v", err)
+			}
+			defer client.Close()
+
+			_, err = client.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+				Model: "m",
+				Messages: []provider.Message{
+					{Role: provider.RoleSystem, Content: "Answer in French."},
+					{Role: provider.RoleUser, Content: "hi"},
+				},
+			})
+			if err != nil {
+				t.Fatalf("CreateChatCompletion failed: %v", err)
+			}
+			if !strings.Contains(body, "Follow the house style.") || !strings.Contains(body, "Answer in French.") {
+				t.Errorf("sent request lost a system prompt: %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrScriptExhausted is returned by ScriptedProvider when every step has been
+// used and no default step is set
+var ErrScriptExhausted = errors.New("scripted provider: no steps remaining")
+
+// Step is one scripted reply. Each call to CreateChatCompletion or
+// CreateChatCompletionStream consumes the next step.
+type Step struct {
+	// Response is returned by CreateChatCompletion. For streaming calls without
+	// Chunks, its content is streamed as a single chunk.
+	Response *provider.ChatCompletionResponse
+
+	// Chunks are returned one by one by a streaming call
+	Chunks []*provider.ChatCompletionChunk
+
+	// Err is returned instead of a response or stream
+	Err error
+
+	// StreamErr is returned by Recv after all chunks have been sent, instead of io.EOF
+	StreamErr error
+
+	// Delay is waited before the call returns. A canceled context ends the wait
+	// early and the call returns ctx.Err().
+	Delay time.Duration
+
+	// ChunkDelay is waited before each chunk is returned by Recv
+	ChunkDelay time.Duration
+}
+
+// CapturedRequest records a call made to a ScriptedProvider
+type CapturedRequest struct {
+	Request *provider.ChatCompletionRequest
+	Stream  bool
+	Time    time.Time
+}
+
+// ScriptedProvider is a provider.Provider that replies from a script of steps
+// and captures every request for assertions. It is safe for concurrent use.
+//
+//	p := omnillmtest.NewScriptedProvider("mock",
+//		omnillmtest.TextStep("first"),
+//		omnillmtest.Step{Err: errors.New("boom")},
+//	)
+//	client, _ := omnillm.NewClient(omnillm.ClientConfig{
+//		Providers: []omnillm.ProviderConfig{{CustomProvider: p}},
+//	})
+type ScriptedProvider struct {
+	name string
+
+	mu       sync.Mutex
+	steps    []Step
+	next     int
+	fallback *Step
+	requests []CapturedRequest
+	closed   bool
+}
+
+// NewScriptedProvider creates a provider that replies with the given steps in order
+func NewScriptedProvider(name string, steps ...Step) *ScriptedProvider {
+	return &ScriptedProvider{name: name, steps: steps}
+}
+
+// Then appends steps to the script
+func (p *ScriptedProvider) Then(steps ...Step) *ScriptedProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.steps = append(p.steps, steps...)
+	return p
+}
+
+// Default sets the step used once the script is exhausted
+func (p *ScriptedProvider) Default(step Step) *ScriptedProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fallback = &step
+	return p
+}
+
+// take records the request and returns the next step
+func (p *ScriptedProvider) take(req *provider.ChatCompletionRequest, stream bool) (Step, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, CapturedRequest{Request: cloneRequest(req), Stream: stream, Time: time.Now()})
+	if p.next < len(p.steps) {
+		step := p.steps[p.next]
+		p.next++
+		return step, nil
+	}
+	if p.fallback != nil {
+		return *p.fallback, nil
+	}
+	return Step{}, ErrScriptExhausted
+}
+
+// CreateChatCompletion returns the next scripted response
+func (p *ScriptedProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	step, err := p.take(req, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, step.Delay); err != nil {
+		return nil, err
+	}
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	if step.Response == nil && len(step.Chunks) > 0 {
+		return responseFromChunks(step.Chunks), nil
+	}
+	return step.Response, nil
+}
+
+// CreateChatCompletionStream returns a stream of the next scripted chunks
+func (p *ScriptedProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	step, err := p.take(req, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, step.Delay); err != nil {
+		return nil, err
+	}
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	chunks := step.Chunks
+	if chunks == nil && step.Response != nil {
+		chunks = chunksFromResponse(step.Response)
+	}
+	return &scriptedStream{ctx: ctx, chunks: chunks, delay: step.ChunkDelay, err: step.StreamErr}, nil
+}
+
+// Close marks the provider closed
+func (p *ScriptedProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Name returns the provider name
+func (p *ScriptedProvider) Name() string {
+	return p.name
+}
+
+// Requests returns every captured request in call order
+func (p *ScriptedProvider) Requests() []CapturedRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]CapturedRequest(nil), p.requests...)
+}
+
+// LastRequest returns the most recent request, or nil if there were no calls
+func (p *ScriptedProvider) LastRequest() *provider.ChatCompletionRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.requests) == 0 {
+		return nil
+	}
+	return p.requests[len(p.requests)-1].Request
+}
+
+// Calls returns the number of calls made
+func (p *ScriptedProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// Remaining returns the number of scripted steps not yet used
+func (p *ScriptedProvider) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.steps) - p.next
+}
+
+// Closed reports whether Close has been called
+func (p *ScriptedProvider) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// TextResponse returns a single-choice assistant response with the given content
+func TextResponse(content string) *provider.ChatCompletionResponse {
+	finish := "stop"
+	return &provider.ChatCompletionResponse{
+		ID:      "scripted",
+		Object:  "chat.completion",
+		Model:   "scripted-model",
+		Created: time.Now().Unix(),
+		Choices: []provider.ChatCompletionChoice{
+			{
+				Message:      provider.Message{Role: provider.RoleAssistant, Content: content},
+				FinishReason: &finish,
+			},
+		},
+	}
+}
+
+// TextStep returns a step that replies with the given content
+func TextStep(content string) Step {
+	return Step{Response: TextResponse(content)}
+}
+
+// TextChunks returns one content-delta chunk per part, with a stop finish reason on the last
+func TextChunks(parts ...string) []*provider.ChatCompletionChunk {
+	chunks := make([]*provider.ChatCompletionChunk, 0, len(parts))
+	for i, part := range parts {
+		choice := provider.ChatCompletionChoice{
+			Delta: &provider.Message{Role: provider.RoleAssistant, Content: part},
+		}
+		if i == len(parts)-1 {
+			finish := "stop"
+			choice.FinishReason = &finish
+		}
+		chunks = append(chunks, &provider.ChatCompletionChunk{
+			ID:      "scripted",
+			Object:  "chat.completion.chunk",
+			Model:   "scripted-model",
+			Choices: []provider.ChatCompletionChoice{choice},
+		})
+	}
+	return chunks
+}
+
+// chunksFromResponse converts a response to a single chunk carrying every choice
+func chunksFromResponse(resp *provider.ChatCompletionResponse) []*provider.ChatCompletionChunk {
+	chunk := &provider.ChatCompletionChunk{
+		ID:      resp.ID,
+		Object:  "chat.completion.chunk",
+		Created: resp.Created,
+		Model:   resp.Model,
+		Usage:   &resp.Usage,
+	}
+	for _, choice := range resp.Choices {
+		msg := choice.Message
+		chunk.Choices = append(chunk.Choices, provider.ChatCompletionChoice{
+			Index:        choice.Index,
+			Delta:        &msg,
+			FinishReason: choice.FinishReason,
+		})
+	}
+	return []*provider.ChatCompletionChunk{chunk}
+}
+
+// responseFromChunks concatenates chunk content into a response for the first choice
+func responseFromChunks(chunks []*provider.ChatCompletionChunk) *provider.ChatCompletionResponse {
+	var content string
+	var finish *string
+	for _, chunk := range chunks {
+		for _, choice := range chunk.Choices {
+			if choice.Delta != nil {
+				content += choice.Delta.Content
+			}
+			if choice.FinishReason != nil {
+				finish = choice.FinishReason
+			}
+		}
+	}
+	resp := TextResponse(content)
+	resp.Choices[0].FinishReason = finish
+	return resp
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// scriptedStream serves chunks with an optional delay, then err or io.EOF
+type scriptedStream struct {
+	ctx    context.Context
+	chunks []*provider.ChatCompletionChunk
+	index  int
+	delay  time.Duration
+	err    error
+	closed bool
+}
+
+func (s *scriptedStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if s.closed {
+		return nil, io.EOF
+	}
+	if s.index >= len(s.chunks) {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	if err := sleep(s.ctx, s.delay); err != nil {
+		return nil, err
+	}
+	chunk := s.chunks[s.index]
+	s.index++
+	return chunk, nil
+}
+
+func (s *scriptedStream) Close() error {
+	s.closed = true
+	return nil
+}
@@ -0,0 +1,147 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestScriptedProvider_Sequence(t *testing.T) {
+	boom := errors.New("boom")
+	p := NewScriptedProvider("scripted", TextStep("first"), Step{Err: boom}).
+		Then(TextStep("third"))
+	ctx := context.Background()
+
+	resp, err := p.CreateChatCompletion(ctx, userRequest("one"))
+	if err != nil || resp.Choices[0].Message.Content != "first" {
+		t.Fatalf("step 1: resp=%v err=%v", resp, err)
+	}
+	if _, err := p.CreateChatCompletion(ctx, userRequest("two")); !errors.Is(err, boom) {
+		t.Fatalf("step 2: expected boom, got %v", err)
+	}
+	resp, err = p.CreateChatCompletion(ctx, userRequest("three"))
+	if err != nil || resp.Choices[0].Message.Content != "third" {
+		t.Fatalf("step 3: resp=%v err=%v", resp, err)
+	}
+	if _, err := p.CreateChatCompletion(ctx, userRequest("four")); !errors.Is(err, ErrScriptExhausted) {
+		t.Errorf("expected ErrScriptExhausted, got %v", err)
+	}
+
+	p.Default(TextStep("again"))
+	resp, err = p.CreateChatCompletion(ctx, userRequest("five"))
+	if err != nil || resp.Choices[0].Message.Content != "again" {
+		t.Errorf("default step: resp=%v err=%v", resp, err)
+	}
+
+	if p.Calls() != 5 || p.Remaining() != 0 {
+		t.Errorf("Calls() = %d, Remaining() = %d", p.Calls(), p.Remaining())
+	}
+	if got := p.LastRequest().Messages[0].Content; got != "five" {
+		t.Errorf("LastRequest content = %q", got)
+	}
+	if got := p.Requests()[1].Request.Messages[0].Content; got != "two" {
+		t.Errorf("Requests()[1] content = %q", got)
+	}
+}
+
+func TestScriptedProvider_CapturesCopy(t *testing.T) {
+	p := NewScriptedProvider("scripted", TextStep("ok"))
+	req := userRequest("original")
+	_, _ = p.CreateChatCompletion(context.Background(), req)
+	req.Messages[0].Content = "mutated"
+
+	if got := p.LastRequest().Messages[0].Content; got != "original" {
+		t.Errorf("captured request changed to %q", got)
+	}
+}
+
+func TestScriptedProvider_Stream(t *testing.T) {
+	streamErr := errors.New("connection reset")
+	p := NewScriptedProvider("scripted",
+		Step{Chunks: TextChunks("Hel", "lo"), StreamErr: streamErr},
+		TextStep("whole"),
+	)
+	ctx := context.Background()
+
+	stream, err := p.CreateChatCompletionStream(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			if !errors.Is(err, streamErr) {
+				t.Errorf("expected stream error, got %v", err)
+			}
+			break
+		}
+		content += chunk.Choices[0].Delta.Content
+	}
+	if content != "Hello" {
+		t.Errorf("content = %q", content)
+	}
+
+	stream, err = p.CreateChatCompletionStream(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunk, err := stream.Recv()
+	if err != nil || chunk.Choices[0].Delta.Content != "whole" {
+		t.Errorf("response as stream: chunk=%v err=%v", chunk, err)
+	}
+	if _, err := stream.Recv(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+	if !p.Requests()[0].Stream {
+		t.Error("expected captured request to be marked as streaming")
+	}
+}
+
+func TestScriptedProvider_ChunksAsResponse(t *testing.T) {
+	p := NewScriptedProvider("scripted", Step{Chunks: TextChunks("a", "b")})
+	resp, err := p.CreateChatCompletion(context.Background(), userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Content != "ab" || *resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("unexpected response: %+v", resp.Choices[0])
+	}
+}
+
+func TestScriptedProvider_Delays(t *testing.T) {
+	p := NewScriptedProvider("scripted",
+		Step{Response: TextResponse("slow"), Delay: time.Second},
+		Step{Chunks: TextChunks("a", "b"), ChunkDelay: time.Second},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.CreateChatCompletion(ctx, userRequest("hi")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	stream, err := p.CreateChatCompletionStream(ctx2, userRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel2()
+	if _, err := stream.Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
+
+func TestScriptedProvider_ImplementsProvider(t *testing.T) {
+	var p provider.Provider = NewScriptedProvider("scripted")
+	if p.Name() != "scripted" {
+		t.Errorf("Name() = %q", p.Name())
+	}
+	if err := p.Close(); err != nil || !p.(*ScriptedProvider).Closed() {
+		t.Error("expected Close to mark provider closed")
+	}
+}
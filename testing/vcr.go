@@ -0,0 +1,317 @@
+package testing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrInteractionNotFound is returned by replay providers and transports when a
+// request has no matching recorded interaction
+var ErrInteractionNotFound = errors.New("no recorded interaction matches request")
+
+// Cassette is the on-disk record of provider interactions
+type Cassette struct {
+	Provider     string        `json:"provider,omitempty"`
+	Interactions []Interaction `json:"interactions,omitempty"`
+
+	// HTTPInteractions are recorded by RecordingTransport
+	HTTPInteractions []HTTPInteraction `json:"http_interactions,omitempty"`
+}
+
+// Interaction is a single recorded request and its outcome. Streaming calls
+// record every chunk in order; an error is recorded as its message.
+type Interaction struct {
+	Request  *provider.ChatCompletionRequest  `json:"request"`
+	Stream   bool                             `json:"stream,omitempty"`
+	Response *provider.ChatCompletionResponse `json:"response,omitempty"`
+	Chunks   []*provider.ChatCompletionChunk  `json:"chunks,omitempty"`
+	Error    string                           `json:"error,omitempty"`
+}
+
+// LoadCassette reads a cassette from disk
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: test fixture path supplied by the caller
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to disk as indented JSON, creating parent directories
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// requestKey returns the canonical form of a request used for matching
+func requestKey(req *provider.ChatCompletionRequest) string {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// cloneRequest deep-copies a request so later mutations by the caller do not
+// change the recording
+func cloneRequest(req *provider.ChatCompletionRequest) *provider.ChatCompletionRequest {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return req
+	}
+	var clone provider.ChatCompletionRequest
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return req
+	}
+	return &clone
+}
+
+// RecordingProvider wraps a real provider and records every call. Call Save
+// (or Close) to write the cassette to disk.
+type RecordingProvider struct {
+	inner provider.Provider
+	path  string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingProvider creates a provider that records calls to inner into the cassette at path
+func NewRecordingProvider(inner provider.Provider, path string) *RecordingProvider {
+	return &RecordingProvider{
+		inner:    inner,
+		path:     path,
+		cassette: Cassette{Provider: inner.Name()},
+	}
+}
+
+// record appends an interaction and returns its index
+func (r *RecordingProvider) record(interaction Interaction) int {
+	interaction.Request = cloneRequest(interaction.Request)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return len(r.cassette.Interactions) - 1
+}
+
+// CreateChatCompletion calls the wrapped provider and records the result
+func (r *RecordingProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	resp, err := r.inner.CreateChatCompletion(ctx, req)
+	interaction := Interaction{Request: req, Response: resp}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	r.record(interaction)
+	return resp, err
+}
+
+// CreateChatCompletionStream calls the wrapped provider and records each chunk as it is received
+func (r *RecordingProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	stream, err := r.inner.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		r.record(Interaction{Request: req, Stream: true, Error: err.Error()})
+		return nil, err
+	}
+	index := r.record(Interaction{Request: req, Stream: true})
+	return &recordingStream{stream: stream, recorder: r, index: index}, nil
+}
+
+// Close saves the cassette and closes the wrapped provider
+func (r *RecordingProvider) Close() error {
+	saveErr := r.Save()
+	return errors.Join(saveErr, r.inner.Close())
+}
+
+// Name returns the wrapped provider's name
+func (r *RecordingProvider) Name() string {
+	return r.inner.Name()
+}
+
+// Save writes the interactions recorded so far to disk
+func (r *RecordingProvider) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Cassette returns a copy of the interactions recorded so far
+func (r *RecordingProvider) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.cassette
+	c.Interactions = append([]Interaction(nil), r.cassette.Interactions...)
+	c.HTTPInteractions = append([]HTTPInteraction(nil), r.cassette.HTTPInteractions...)
+	return c
+}
+
+// recordingStream records chunks into the interaction at index
+type recordingStream struct {
+	stream   provider.ChatCompletionStream
+	recorder *RecordingProvider
+	index    int
+}
+
+func (s *recordingStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.stream.Recv()
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	interaction := &s.recorder.cassette.Interactions[s.index]
+	switch {
+	case err == nil:
+		interaction.Chunks = append(interaction.Chunks, chunk)
+	case !errors.Is(err, io.EOF):
+		interaction.Error = err.Error()
+	}
+	return chunk, err
+}
+
+func (s *recordingStream) Close() error {
+	return s.stream.Close()
+}
+
+// ReplayProvider serves recorded interactions deterministically. Requests are
+// matched by their full JSON form; identical requests are served in recorded
+// order. Recorded errors are returned as plain errors with the same message.
+type ReplayProvider struct {
+	name string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayProvider loads the cassette at path and returns a provider that replays it
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayProviderFromCassette(cassette), nil
+}
+
+// NewReplayProviderFromCassette returns a provider that replays an in-memory cassette
+func NewReplayProviderFromCassette(cassette *Cassette) *ReplayProvider {
+	name := cassette.Provider
+	if name == "" {
+		name = "replay"
+	}
+	return &ReplayProvider{
+		name:         name,
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// next returns the first unused interaction matching the request
+func (p *ReplayProvider) next(req *provider.ChatCompletionRequest, stream bool) (*Interaction, error) {
+	key := requestKey(req)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.interactions {
+		interaction := &p.interactions[i]
+		if p.used[i] || interaction.Stream != stream || requestKey(interaction.Request) != key {
+			continue
+		}
+		p.used[i] = true
+		return interaction, nil
+	}
+	return nil, fmt.Errorf("%w: model=%s stream=%t", ErrInteractionNotFound, req.Model, stream)
+}
+
+// CreateChatCompletion returns the recorded response for the request
+func (p *ReplayProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	interaction, err := p.next(req, false)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	return interaction.Response, nil
+}
+
+// CreateChatCompletionStream returns a stream of the recorded chunks for the request
+func (p *ReplayProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	interaction, err := p.next(req, true)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" && len(interaction.Chunks) == 0 {
+		return nil, errors.New(interaction.Error)
+	}
+	return &replayStream{chunks: interaction.Chunks, err: interaction.Error}, nil
+}
+
+// Close is a no-op
+func (p *ReplayProvider) Close() error {
+	return nil
+}
+
+// Name returns the recorded provider name
+func (p *ReplayProvider) Name() string {
+	return p.name
+}
+
+// Unused returns the number of recorded interactions that have not been replayed
+func (p *ReplayProvider) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, used := range p.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// replayStream serves recorded chunks, then the recorded error or io.EOF
+type replayStream struct {
+	chunks []*provider.ChatCompletionChunk
+	index  int
+	err    string
+}
+
+func (s *replayStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if s.index < len(s.chunks) {
+		chunk := s.chunks[s.index]
+		s.index++
+		return chunk, nil
+	}
+	if s.err != "" {
+		return nil, errors.New(s.err)
+	}
+	return nil, io.EOF
+}
+
+func (s *replayStream) Close() error {
+	return nil
+}
+
+// compactJSON normalizes a JSON body for matching; non-JSON bodies are returned unchanged
+func compactJSON(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return string(body)
+	}
+	return buf.String()
+}
@@ -0,0 +1,182 @@
+package testing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// HTTPInteraction is a single recorded HTTP exchange. Request headers and the
+// URL query are not recorded, so API keys never reach the cassette. Streaming
+// responses are recorded as their raw body (e.g., the full SSE event stream).
+type HTTPInteraction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	StatusCode     int         `json:"status_code"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body"`
+}
+
+// redactedURL returns the request URL without query parameters or user info
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.User = nil
+	return u.String()
+}
+
+// readRequestBody reads and restores the request body
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// RecordingTransport is an http.RoundTripper that records real HTTP exchanges.
+// Pass Client() as a provider's HTTPClient so the provider's own request and
+// response serialization is captured, then call Save to write the cassette.
+type RecordingTransport struct {
+	base http.RoundTripper
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingTransport creates a transport that records exchanges made through
+// base (http.DefaultTransport if nil) into the cassette at path
+func NewRecordingTransport(base http.RoundTripper, path string) *RecordingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RecordingTransport{base: base, path: path}
+}
+
+// Client returns an HTTP client that uses the transport
+func (t *RecordingTransport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// RoundTrip performs the request and records the exchange
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	t.cassette.HTTPInteractions = append(t.cassette.HTTPInteractions, HTTPInteraction{
+		Method:         req.Method,
+		URL:            redactedURL(req),
+		RequestBody:    compactJSON(reqBody),
+		StatusCode:     resp.StatusCode,
+		ResponseHeader: resp.Header.Clone(),
+		ResponseBody:   string(respBody),
+	})
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// Save writes the exchanges recorded so far to disk
+func (t *RecordingTransport) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cassette.Save(t.path)
+}
+
+// ReplayTransport is an http.RoundTripper that serves recorded exchanges without
+// network access. Requests are matched by method, URL (without query), and
+// JSON-normalized body; identical requests are served in recorded order.
+type ReplayTransport struct {
+	mu           sync.Mutex
+	interactions []HTTPInteraction
+	used         []bool
+}
+
+// NewReplayTransport loads the cassette at path and returns a transport that replays it
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{
+		interactions: cassette.HTTPInteractions,
+		used:         make([]bool, len(cassette.HTTPInteractions)),
+	}, nil
+}
+
+// Client returns an HTTP client that uses the transport
+func (t *ReplayTransport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// RoundTrip returns the recorded response for the request
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	url := redactedURL(req)
+	body := compactJSON(reqBody)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range t.interactions {
+		interaction := &t.interactions[i]
+		if t.used[i] || interaction.Method != req.Method || interaction.URL != url || interaction.RequestBody != body {
+			continue
+		}
+		t.used[i] = true
+		header := interaction.ResponseHeader.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+			StatusCode:    interaction.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(interaction.ResponseBody))),
+			ContentLength: int64(len(interaction.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, url)
+}
+
+// Unused returns the number of recorded exchanges that have not been replayed
+func (t *ReplayTransport) Unused() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, used := range t.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
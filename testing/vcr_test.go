@@ -0,0 +1,207 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+	"github.com/plexusone/omnillm/providers/openai"
+)
+
+// fakeProvider returns canned responses for VCR tests
+type fakeProvider struct {
+	chunks []string
+	err    error
+}
+
+func (p *fakeProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &provider.ChatCompletionResponse{
+		ID:    "resp-1",
+		Model: req.Model,
+		Choices: []provider.ChatCompletionChoice{
+			{Message: provider.Message{Role: provider.RoleAssistant, Content: "echo: " + req.Messages[0].Content}},
+		},
+	}, nil
+}
+
+func (p *fakeProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	chunks := make([]*provider.ChatCompletionChunk, 0, len(p.chunks))
+	for _, text := range p.chunks {
+		chunks = append(chunks, &provider.ChatCompletionChunk{
+			Choices: []provider.ChatCompletionChoice{{Delta: &provider.Message{Content: text}}},
+		})
+	}
+	return &replayStream{chunks: chunks}, nil
+}
+
+func (p *fakeProvider) Close() error { return nil }
+func (p *fakeProvider) Name() string { return "fake" }
+
+func userRequest(content string) *provider.ChatCompletionRequest {
+	return &provider.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: content}},
+	}
+}
+
+func readAll(t *testing.T, stream provider.ChatCompletionStream) string {
+	t.Helper()
+	var sb strings.Builder
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return sb.String()
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		sb.WriteString(chunk.Choices[0].Delta.Content)
+	}
+}
+
+func TestRecordAndReplayProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	ctx := context.Background()
+
+	recorder := NewRecordingProvider(&fakeProvider{chunks: []string{"Hel", "lo"}}, path)
+	req := userRequest("hi")
+	if _, err := recorder.CreateChatCompletion(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	req.Messages[0].Content = "mutated after the call"
+
+	stream, err := recorder.CreateChatCompletionStream(ctx, userRequest("stream please"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readAll(t, stream); got != "Hello" {
+		t.Errorf("recorded stream = %q", got)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatalf("NewReplayProvider failed: %v", err)
+	}
+	if replay.Name() != "fake" {
+		t.Errorf("Name() = %q, want fake", replay.Name())
+	}
+
+	resp, err := replay.CreateChatCompletion(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "echo: hi" {
+		t.Errorf("replayed content = %q", resp.Choices[0].Message.Content)
+	}
+
+	stream, err = replay.CreateChatCompletionStream(ctx, userRequest("stream please"))
+	if err != nil {
+		t.Fatalf("replay stream failed: %v", err)
+	}
+	if got := readAll(t, stream); got != "Hello" {
+		t.Errorf("replayed stream = %q", got)
+	}
+
+	if replay.Unused() != 0 {
+		t.Errorf("Unused() = %d, want 0", replay.Unused())
+	}
+	if _, err := replay.CreateChatCompletion(ctx, userRequest("hi")); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("expected ErrInteractionNotFound once consumed, got %v", err)
+	}
+}
+
+func TestReplayProvider_RecordedError(t *testing.T) {
+	recorder := NewRecordingProvider(&fakeProvider{err: errors.New("rate limited")}, filepath.Join(t.TempDir(), "c.json"))
+	_, _ = recorder.CreateChatCompletion(context.Background(), userRequest("hi"))
+
+	cassette := recorder.Cassette()
+	replay := NewReplayProviderFromCassette(&cassette)
+	_, err := replay.CreateChatCompletion(context.Background(), userRequest("hi"))
+	if err == nil || err.Error() != "rate limited" {
+		t.Errorf("expected recorded error, got %v", err)
+	}
+}
+
+func TestRecordAndReplayTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk-secret" {
+			t.Errorf("missing auth header")
+		}
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+			_, _ = io.WriteString(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\" there\"}}]}\n\n")
+			_, _ = io.WriteString(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":"r1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+	}))
+	path := filepath.Join(t.TempDir(), "openai.json")
+	ctx := context.Background()
+
+	recorder := NewRecordingTransport(nil, path)
+	live := openai.NewProvider("sk-secret", server.URL, recorder.Client())
+	if _, err := live.CreateChatCompletion(ctx, userRequest("hi")); err != nil {
+		t.Fatalf("live request failed: %v", err)
+	}
+	stream, err := live.CreateChatCompletionStream(ctx, userRequest("stream"))
+	if err != nil {
+		t.Fatalf("live stream failed: %v", err)
+	}
+	if got := readAll(t, stream); got != "Hi there" {
+		t.Errorf("live stream = %q", got)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	server.Close()
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, interaction := range cassette.HTTPInteractions {
+		if strings.Contains(interaction.RequestBody+interaction.URL, "sk-secret") {
+			t.Error("cassette must not contain the API key")
+		}
+	}
+
+	replay, err := NewReplayTransport(path)
+	if err != nil {
+		t.Fatalf("NewReplayTransport failed: %v", err)
+	}
+	offline := openai.NewProvider("sk-other", server.URL, replay.Client())
+
+	resp, err := offline.CreateChatCompletion(ctx, userRequest("hi"))
+	if err != nil {
+		t.Fatalf("replayed request failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "Hello!" || resp.Usage.TotalTokens != 5 {
+		t.Errorf("unexpected replayed response: %+v", resp)
+	}
+	stream, err = offline.CreateChatCompletionStream(ctx, userRequest("stream"))
+	if err != nil {
+		t.Fatalf("replayed stream failed: %v", err)
+	}
+	if got := readAll(t, stream); got != "Hi there" {
+		t.Errorf("replayed stream = %q", got)
+	}
+
+	if _, err := offline.CreateChatCompletion(ctx, userRequest("unrecorded")); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("expected ErrInteractionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Package textops implements common multi-call text processing on top of
+// an omnillm client.
+//
+// Summarize condenses a document of any length with map-reduce: it splits
+// the document into chunks that fit the model's context window, summarizes
+// the chunks concurrently, and combines the summaries into one:
+//
+//	summary, err := textops.Summarize(ctx, client, report, textops.SummarizeOptions{
+//		Model: "gpt-4o-mini",
+//	})
+//	fmt.Println(summary.Text)
+package textops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+)
+
+const (
+	// DefaultChunkTokens is the chunk size used for models whose context
+	// window is unknown
+	DefaultChunkTokens = 4000
+
+	// DefaultMapPrompt asks for a summary of one chunk
+	DefaultMapPrompt = "Summarize the following part of a longer document. Keep every key fact, figure, and name. Reply with the summary only."
+
+	// DefaultReducePrompt asks for the summaries of consecutive chunks to be
+	// combined
+	DefaultReducePrompt = "The following are summaries of consecutive parts of one document. Combine them into a single coherent summary. Reply with the summary only."
+
+	// maxReduceRounds bounds the reduce rounds in case summaries do not shrink
+	maxReduceRounds = 10
+)
+
+var (
+	// ErrEmptyText is returned by Summarize for empty or whitespace-only text
+	ErrEmptyText = errors.New("textops: text is empty")
+
+	// ErrSummaryTooLong is returned when summaries stop shrinking before they
+	// fit in one reduce request
+	ErrSummaryTooLong = errors.New("textops: summaries do not fit in the context window")
+)
+
+// SummarizeOptions configures Summarize
+type SummarizeOptions struct {
+	// Model is the model used for every request
+	Model string
+
+	// ChunkTokens is the maximum size of each chunk, and of the summaries
+	// combined in each reduce request. Default: a quarter of the model's
+	// context window, or DefaultChunkTokens if it is unknown. It is capped
+	// so that a chunk, the prompt, and MaxTokens fit in the window.
+	ChunkTokens int
+
+	// MapPrompt is the instruction sent with each chunk. Default: DefaultMapPrompt
+	MapPrompt string
+
+	// ReducePrompt is the instruction sent with the summaries to combine.
+	// Default: DefaultReducePrompt
+	ReducePrompt string
+
+	// MaxTokens caps each summary, if set
+	MaxTokens int
+
+	// Concurrency limits how many requests are in flight at once.
+	// Default: omnillm.DefaultChatCompletionsConcurrency
+	Concurrency int
+
+	// MaxRetries is how many times a failed request is retried
+	MaxRetries int
+
+	// Estimator counts tokens and supplies the context window.
+	// Default: the client's TokenEstimator, or omnillm.NewTokenEstimator(omnillm.DefaultTokenEstimatorConfig())
+	Estimator omnillm.TokenEstimator
+}
+
+// Summary is the result of Summarize
+type Summary struct {
+	// Text is the final summary
+	Text string
+
+	// Chunks is the number of chunks the document was split into
+	Chunks int
+
+	// Rounds is the number of reduce rounds, 0 if the document fit in one chunk
+	Rounds int
+
+	// Usage is the total usage of every request
+	Usage provider.Usage
+}
+
+// Summarize summarizes text with map-reduce. Chunks are summarized
+// concurrently; the summaries are then combined, in groups that fit
+// ChunkTokens if they do not all fit at once, until one summary remains.
+// Summarize fails if any request fails.
+func Summarize(ctx context.Context, client *omnillm.ChatClient, text string, opts SummarizeOptions) (*Summary, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyText
+	}
+	opts = opts.withDefaults(client)
+
+	chunks, err := omnillm.SplitByTokens(opts.Estimator, text, opts.Model, opts.ChunkTokens, 0)
+	if err != nil {
+		return nil, err
+	}
+	summary := &Summary{Chunks: len(chunks)}
+
+	summaries, err := summarizeAll(ctx, client, opts, opts.MapPrompt, chunks, &summary.Usage)
+	if err != nil {
+		return nil, err
+	}
+
+	for len(summaries) > 1 {
+		if summary.Rounds == maxReduceRounds {
+			return nil, ErrSummaryTooLong
+		}
+		summary.Rounds++
+
+		groups, err := groupByTokens(opts.Estimator, opts.Model, summaries, opts.ChunkTokens)
+		if err != nil {
+			return nil, err
+		}
+		if len(groups) == len(summaries) {
+			return nil, ErrSummaryTooLong
+		}
+		summaries, err = summarizeAll(ctx, client, opts, opts.ReducePrompt, groups, &summary.Usage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	summary.Text = summaries[0]
+	return summary, nil
+}
+
+// withDefaults fills in unset options
+func (o SummarizeOptions) withDefaults(client *omnillm.ChatClient) SummarizeOptions {
+	if o.Estimator == nil {
+		o.Estimator = client.TokenEstimator()
+	}
+	if o.Estimator == nil {
+		o.Estimator = omnillm.NewTokenEstimator(omnillm.DefaultTokenEstimatorConfig())
+	}
+	if o.MapPrompt == "" {
+		o.MapPrompt = DefaultMapPrompt
+	}
+	if o.ReducePrompt == "" {
+		o.ReducePrompt = DefaultReducePrompt
+	}
+
+	window := o.Estimator.GetContextWindow(o.Model)
+	if o.ChunkTokens <= 0 {
+		o.ChunkTokens = DefaultChunkTokens
+		if window > 0 {
+			o.ChunkTokens = window / 4
+		}
+	}
+	if window > 0 {
+		// Leave room for the prompt and the summary
+		prompt := 0
+		for _, p := range []string{o.MapPrompt, o.ReducePrompt} {
+			tokens, err := o.Estimator.EstimateTokens(o.Model, []provider.Message{{Role: provider.RoleUser, Content: p}})
+			if err == nil {
+				prompt = max(prompt, tokens)
+			}
+		}
+		o.ChunkTokens = max(1, min(o.ChunkTokens, window-o.MaxTokens-prompt))
+	}
+	return o
+}
+
+// summarizeAll sends one request per input and returns the summaries in order
+func summarizeAll(ctx context.Context, client *omnillm.ChatClient, opts SummarizeOptions, prompt string, inputs []string, usage *provider.Usage) ([]string, error) {
+	reqs := make([]*provider.ChatCompletionRequest, len(inputs))
+	for i, input := range inputs {
+		req := &provider.ChatCompletionRequest{
+			Model:    opts.Model,
+			Messages: []provider.Message{{Role: provider.RoleUser, Content: prompt + "\n\n" + input}},
+		}
+		if opts.MaxTokens > 0 {
+			maxTokens := opts.MaxTokens
+			req.MaxTokens = &maxTokens
+		}
+		reqs[i] = req
+	}
+
+	results := client.CreateChatCompletions(ctx, reqs, &omnillm.ChatCompletionsOptions{
+		MaxConcurrency: opts.Concurrency,
+		MaxRetries:     opts.MaxRetries,
+	})
+
+	summaries := make([]string, len(results))
+	for i, r := range results {
+		if r.Err != nil {
+			return nil, fmt.Errorf("textops: summarizing part %d of %d: %w", i+1, len(results), r.Err)
+		}
+		if len(r.Response.Choices) == 0 {
+			return nil, fmt.Errorf("textops: summarizing part %d of %d: %w", i+1, len(results), omnillm.ErrInvalidResponse)
+		}
+		summaries[i] = strings.TrimSpace(r.Response.Choices[0].Message.Content)
+		usage.PromptTokens += r.Response.Usage.PromptTokens
+		usage.CompletionTokens += r.Response.Usage.CompletionTokens
+		usage.TotalTokens += r.Response.Usage.TotalTokens
+	}
+	return summaries, nil
+}
+
+// groupByTokens joins consecutive texts into groups of at most maxTokens.
+// A text over maxTokens is a group of its own.
+func groupByTokens(estimator omnillm.TokenEstimator, model string, texts []string, maxTokens int) ([]string, error) {
+	var groups []string
+	var current []string
+	currentTokens := 0
+	for _, text := range texts {
+		tokens, err := omnillm.CountTokens(estimator, model, text)
+		if err != nil {
+			return nil, err
+		}
+		if len(current) > 0 && currentTokens+tokens > maxTokens {
+			groups = append(groups, strings.Join(current, "\n\n"))
+			current, currentTokens = nil, 0
+		}
+		current = append(current, text)
+		currentTokens += tokens
+	}
+	if len(current) > 0 {
+		groups = append(groups, strings.Join(current, "\n\n"))
+	}
+	return groups, nil
+}
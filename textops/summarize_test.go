@@ -0,0 +1,121 @@
+package textops
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm"
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func newClient(t *testing.T, p provider.Provider) *omnillm.ChatClient {
+	t.Helper()
+	client, err := omnillm.NewClient(omnillm.ClientConfig{Providers: []omnillm.ProviderConfig{{CustomProvider: p}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// document returns n paragraphs of about 100 characters each
+func document(n int) string {
+	paragraphs := make([]string, n)
+	for i := range paragraphs {
+		paragraphs[i] = strings.Repeat("word ", 20)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func TestSummarize_SingleChunk(t *testing.T) {
+	p := mocktest.NewScriptedProvider("scripted", mocktest.TextStep(" The summary. "))
+	client := newClient(t, p)
+
+	summary, err := Summarize(context.Background(), client, document(3), SummarizeOptions{Model: "m"})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if summary.Text != "The summary." || summary.Chunks != 1 || summary.Rounds != 0 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if got := p.LastRequest().Messages[0].Content; !strings.HasPrefix(got, DefaultMapPrompt) {
+		t.Errorf("expected the map prompt, got %q", got)
+	}
+}
+
+func TestSummarize_MapReduce(t *testing.T) {
+	resp := mocktest.TextResponse(strings.Repeat("summary ", 10))
+	resp.Usage = provider.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	p := mocktest.NewScriptedProvider("scripted").Default(mocktest.Step{Response: resp})
+	client := newClient(t, p)
+
+	summary, err := Summarize(context.Background(), client, document(20), SummarizeOptions{
+		Model:        "m",
+		ChunkTokens:  60,
+		ReducePrompt: "Combine:",
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+
+	// Each 25-token paragraph pairs up into a chunk, and the 20-token
+	// summaries are combined three at a time: 10, 4, 2, then 1
+	if summary.Chunks != 10 {
+		t.Errorf("expected 10 chunks, got %d", summary.Chunks)
+	}
+	if summary.Rounds != 3 {
+		t.Errorf("expected 3 reduce rounds, got %d", summary.Rounds)
+	}
+	calls := p.Calls()
+	if calls != 10+4+2+1 {
+		t.Errorf("expected 17 requests, got %d", calls)
+	}
+	if summary.Usage.TotalTokens != 15*calls {
+		t.Errorf("expected usage summed over %d requests, got %+v", calls, summary.Usage)
+	}
+	if got := p.LastRequest().Messages[0].Content; !strings.HasPrefix(got, "Combine:") {
+		t.Errorf("expected the last request to reduce, got %q", got)
+	}
+}
+
+func TestSummarize_ChunksFitContextWindow(t *testing.T) {
+	p := mocktest.NewScriptedProvider("scripted").Default(mocktest.TextStep("short"))
+	client := newClient(t, p)
+
+	estimator := omnillm.NewTokenEstimator(omnillm.TokenEstimatorConfig{
+		CustomContextWindows: map[string]int{"tiny": 320},
+	})
+	summary, err := Summarize(context.Background(), client, document(20), SummarizeOptions{
+		Model:     "tiny",
+		MaxTokens: 100,
+		Estimator: estimator,
+	})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+
+	// A quarter of the window is 80 tokens, so three 25-token paragraphs per chunk
+	if summary.Chunks != 7 {
+		t.Errorf("expected 7 chunks, got %d", summary.Chunks)
+	}
+	for _, r := range p.Requests() {
+		tokens, _ := estimator.EstimateTokens("tiny", r.Request.Messages)
+		if tokens+100 > 320 {
+			t.Errorf("request of %d tokens does not leave room for MaxTokens", tokens)
+		}
+	}
+}
+
+func TestSummarize_Errors(t *testing.T) {
+	client := newClient(t, mocktest.NewScriptedProvider("scripted").Default(mocktest.Step{Err: omnillm.ErrInvalidRequest}))
+
+	if _, err := Summarize(context.Background(), client, " \n\n ", SummarizeOptions{Model: "m"}); !errors.Is(err, ErrEmptyText) {
+		t.Errorf("expected ErrEmptyText, got %v", err)
+	}
+	if _, err := Summarize(context.Background(), client, document(3), SummarizeOptions{Model: "m"}); !errors.Is(err, omnillm.ErrInvalidRequest) {
+		t.Errorf("expected the request error, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+var (
+	// ErrAttemptTimeout is returned when a provider attempt exceeds
+	// TimeoutConfig.PerAttempt. It is retryable, so fallback moves on to the
+	// next provider.
+	ErrAttemptTimeout = errors.New("provider attempt timed out")
+
+	// ErrFirstTokenTimeout is returned when a stream's first chunk does not
+	// arrive within TimeoutConfig.FirstToken. It is retryable, so fallback
+	// moves on to the next provider.
+	ErrFirstTokenTimeout = errors.New("timed out waiting for first token")
+)
+
+// TimeoutConfig sets deadlines for the stages of a request. They are
+// separate from the HTTP client timeout, which bounds each HTTP call. Zero
+// fields are not enforced.
+type TimeoutConfig struct {
+	// Total bounds the whole request, including every fallback attempt.
+	// For streams it runs until the stream ends or is closed.
+	Total time.Duration
+
+	// FirstToken bounds how long each streaming attempt waits for its first
+	// chunk. When it passes, the attempt fails with ErrFirstTokenTimeout and
+	// fallback tries the next provider.
+	FirstToken time.Duration
+
+	// PerAttempt bounds each provider attempt, so that a slow provider does
+	// not use up the Total budget before fallback gets a turn. For streams
+	// it covers opening the stream and receiving the first chunk.
+	PerAttempt time.Duration
+}
+
+type timeoutsContextKey struct{}
+
+// WithTimeouts returns a context that overrides the client's TimeoutConfig
+// for requests made with it
+func WithTimeouts(ctx context.Context, timeouts TimeoutConfig) context.Context {
+	return context.WithValue(ctx, timeoutsContextKey{}, timeouts)
+}
+
+// timeoutsFromContext returns the timeouts set with WithTimeouts
+func timeoutsFromContext(ctx context.Context) (TimeoutConfig, bool) {
+	t, ok := ctx.Value(timeoutsContextKey{}).(TimeoutConfig)
+	return t, ok
+}
+
+// withRequestTimeouts resolves the request's timeouts from ctx or the
+// client default, records them in ctx for the fallback layer, and applies
+// the Total deadline. The returned cancel func must be called.
+func (c *ChatClient) withRequestTimeouts(ctx context.Context) (context.Context, TimeoutConfig, context.CancelFunc) {
+	timeouts, ok := timeoutsFromContext(ctx)
+	if !ok {
+		if c.timeouts == nil {
+			return ctx, TimeoutConfig{}, func() {}
+		}
+		timeouts = *c.timeouts
+		ctx = WithTimeouts(ctx, timeouts)
+	}
+	if timeouts.Total <= 0 {
+		return ctx, timeouts, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Total)
+	return ctx, timeouts, cancel
+}
+
+// managesAttempts reports whether p makes several provider attempts per
+// request and applies the per-attempt timeouts itself
+func managesAttempts(p provider.Provider) bool {
+	switch p.(type) {
+	case *FallbackProvider, *RouterProvider:
+		return true
+	}
+	return false
+}
+
+// completeWithTimeouts makes a non-streaming request within the PerAttempt budget
+func completeWithTimeouts(ctx context.Context, p provider.Provider, req *provider.ChatCompletionRequest, timeouts TimeoutConfig) (*provider.ChatCompletionResponse, error) {
+	if timeouts.PerAttempt <= 0 {
+		return p.CreateChatCompletion(ctx, req)
+	}
+
+	attemptCtx, cancel := context.WithTimeoutCause(ctx, timeouts.PerAttempt, ErrAttemptTimeout)
+	defer cancel()
+	resp, err := p.CreateChatCompletion(attemptCtx, req)
+	if err != nil && ctx.Err() == nil && errors.Is(context.Cause(attemptCtx), ErrAttemptTimeout) {
+		return nil, ErrAttemptTimeout
+	}
+	return resp, err
+}
+
+// openStreamWithTimeouts opens a stream and, if FirstToken or PerAttempt is
+// set, waits for its first chunk within the budget. The first chunk is
+// replayed by the returned stream.
+func openStreamWithTimeouts(ctx context.Context, p provider.Provider, req *provider.ChatCompletionRequest, timeouts TimeoutConfig) (provider.ChatCompletionStream, error) {
+	budget, cause := timeouts.PerAttempt, ErrAttemptTimeout
+	if timeouts.FirstToken > 0 && (budget <= 0 || timeouts.FirstToken < budget) {
+		budget, cause = timeouts.FirstToken, ErrFirstTokenTimeout
+	}
+	if budget <= 0 {
+		return p.CreateChatCompletionStream(ctx, req)
+	}
+
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(budget, func() { cancel(cause) })
+	timedOut := func(err error) error {
+		timer.Stop()
+		cancel(nil)
+		if ctx.Err() == nil && errors.Is(context.Cause(attemptCtx), cause) {
+			return cause
+		}
+		return err
+	}
+
+	stream, err := p.CreateChatCompletionStream(attemptCtx, req)
+	if err != nil {
+		return nil, timedOut(err)
+	}
+
+	// Stream providers do not all watch ctx while blocked in Recv, so the
+	// first chunk is awaited in a goroutine
+	type result struct {
+		chunk *provider.ChatCompletionChunk
+		err   error
+	}
+	first := make(chan result, 1)
+	go func() {
+		chunk, err := stream.Recv()
+		first <- result{chunk, err}
+	}()
+
+	select {
+	case r := <-first:
+		// If the timer fired as the chunk arrived, the stream's context is
+		// already cancelled
+		if !timer.Stop() || (r.err != nil && !errors.Is(r.err, io.EOF)) {
+			_ = stream.Close()
+			return nil, timedOut(r.err)
+		}
+		return &firstChunkStream{stream: stream, first: r.chunk, firstErr: r.err, cancel: cancel}, nil
+	case <-attemptCtx.Done():
+		// Close once the pending Recv, ended by the cancelled context, returns
+		go func() {
+			<-first
+			_ = stream.Close()
+		}()
+		return nil, timedOut(context.Cause(attemptCtx))
+	}
+}
+
+// firstChunkStream replays a stream's first chunk, which was received while
+// enforcing the first-token deadline or waiting to fall back on an empty
+// stream, and releases the attempt context, if any, when closed
+type firstChunkStream struct {
+	stream   provider.ChatCompletionStream
+	first    *provider.ChatCompletionChunk
+	firstErr error
+	replayed bool
+	cancel   context.CancelCauseFunc
+}
+
+func (s *firstChunkStream) Recv() (*provider.ChatCompletionChunk, error) {
+	if !s.replayed {
+		s.replayed = true
+		return s.first, s.firstErr
+	}
+	return s.stream.Recv()
+}
+
+func (s *firstChunkStream) Close() error {
+	if s.cancel != nil {
+		s.cancel(nil)
+	}
+	return s.stream.Close()
+}
+
+// cancelOnCloseStream cancels a request context when the stream ends or is closed
+type cancelOnCloseStream struct {
+	provider.ChatCompletionStream
+	cancel context.CancelFunc
+}
+
+func (s *cancelOnCloseStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if err != nil {
+		s.cancel()
+	}
+	return chunk, err
+}
+
+func (s *cancelOnCloseStream) Close() error {
+	err := s.ChatCompletionStream.Close()
+	s.cancel()
+	return err
+}
@@ -0,0 +1,121 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestTimeouts_PerAttemptFallsBack(t *testing.T) {
+	slow := mocktest.NewScriptedProvider("slow", mocktest.Step{Response: mocktest.TextResponse("late"), Delay: time.Second})
+	fast := mocktest.NewScriptedProvider("fast", mocktest.TextStep("fast"))
+
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: slow}, {CustomProvider: fast}},
+		Timeouts:  &TimeoutConfig{Total: time.Second, PerAttempt: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	start := time.Now()
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if resp.Choices[0].Message.Content != "fast" {
+		t.Errorf("expected the fallback response, got %q", resp.Choices[0].Message.Content)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the slow attempt to be cut short, took %s", elapsed)
+	}
+}
+
+func TestTimeouts_FirstToken(t *testing.T) {
+	stalled := mocktest.Step{Chunks: mocktest.TextChunks("late"), ChunkDelay: time.Second}
+
+	// Without a fallback, the caller gets ErrFirstTokenTimeout
+	single, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mocktest.NewScriptedProvider("stalled", stalled)}},
+		Timeouts:  &TimeoutConfig{FirstToken: 20 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer single.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	if _, err := single.CreateChatCompletionStream(context.Background(), req); !errors.Is(err, ErrFirstTokenTimeout) {
+		t.Errorf("expected ErrFirstTokenTimeout, got %v", err)
+	}
+
+	// With a fallback, the next provider streams instead
+	fast := mocktest.NewScriptedProvider("fast", mocktest.Step{Chunks: mocktest.TextChunks("hel", "lo")})
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: mocktest.NewScriptedProvider("stalled", stalled)}, {CustomProvider: fast}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := WithTimeouts(context.Background(), TimeoutConfig{FirstToken: 20 * time.Millisecond})
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		t.Fatalf("expected fallback stream, got %v", err)
+	}
+	defer stream.Close()
+
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv failed: %v", err)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta != nil {
+			content += chunk.Choices[0].Delta.Content
+		}
+	}
+	if content != "hello" {
+		t.Errorf("expected the first chunk to be replayed, got %q", content)
+	}
+}
+
+func TestTimeouts_TotalCoversStream(t *testing.T) {
+	p := mocktest.NewScriptedProvider("slow", mocktest.Step{Chunks: mocktest.TextChunks("a", "b", "c"), ChunkDelay: 30 * time.Millisecond})
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: p}},
+		Timeouts:  &TimeoutConfig{Total: 50 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "m", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	defer stream.Close()
+
+	for {
+		_, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the total deadline to end the stream, got %v", err)
+	}
+}
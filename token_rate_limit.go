@@ -0,0 +1,270 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrExceedsTokenRateLimit is returned when a request's estimated tokens
+// exceed its model's whole per-minute budget, so waiting would never let it
+// through
+var ErrExceedsTokenRateLimit = errors.New("request needs more tokens than the per-minute limit")
+
+// TokenRateLimitConfig configures tokens-per-minute budgets. Each provider
+// and model pair has its own bucket, which refills continuously at its
+// per-minute rate.
+type TokenRateLimitConfig struct {
+	// TokensPerMinute is the budget for each provider and model. If 0,
+	// models without a ModelLimits entry are not limited.
+	TokensPerMinute int
+
+	// ModelLimits overrides TokensPerMinute for models by name, as sent to
+	// the provider after ModelMap
+	ModelLimits map[string]int
+
+	// Estimator estimates the prompt tokens debited before a request is sent.
+	// Default: ClientConfig.TokenEstimator, or NewTokenEstimator(DefaultTokenEstimatorConfig())
+	Estimator TokenEstimator
+
+	// DefaultCompletionTokens is debited for the completion of requests that
+	// do not set MaxTokens. Default: 0, so only the prompt is debited up
+	// front and the completion when usage is reconciled.
+	DefaultCompletionTokens int
+}
+
+// TokenRateLimiter debits estimated tokens from per-model buckets before
+// requests are sent, waiting while a bucket is overdrawn, and reconciles
+// the estimate with the usage the provider reports. NewClient creates one
+// when ClientConfig.TokenRateLimit is set.
+type TokenRateLimiter struct {
+	config TokenRateLimitConfig
+
+	mu      sync.Mutex
+	buckets map[tokenBucketKey]*tokenBucket
+}
+
+type tokenBucketKey struct {
+	provider string
+	model    string
+}
+
+// tokenBucket holds the tokens available to one provider and model. tokens
+// goes negative while reserved requests wait for it to refill.
+type tokenBucket struct {
+	limit   float64
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the last update (must be called with lock held)
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.limit, b.tokens+now.Sub(b.updated).Minutes()*b.limit)
+	b.updated = now
+}
+
+// NewTokenRateLimiter creates a tokens-per-minute limiter
+func NewTokenRateLimiter(config TokenRateLimitConfig) *TokenRateLimiter {
+	if config.Estimator == nil {
+		config.Estimator = NewTokenEstimator(DefaultTokenEstimatorConfig())
+	}
+	return &TokenRateLimiter{config: config, buckets: make(map[tokenBucketKey]*tokenBucket)}
+}
+
+// TokenReservation is a debit taken by TokenRateLimiter.Reserve
+type TokenReservation struct {
+	limiter *TokenRateLimiter
+	bucket  *tokenBucket
+	tokens  int
+	once    sync.Once
+}
+
+// Reserve debits tokens from the provider and model's bucket, then waits
+// until the bucket is no longer overdrawn. Waiting ends early with
+// ctx.Err() if ctx is done, and the debit is returned. Call Settle on the
+// reservation with the tokens actually used.
+func (l *TokenRateLimiter) Reserve(ctx context.Context, providerName, model string, tokens int) (*TokenReservation, error) {
+	l.mu.Lock()
+	b := l.bucket(providerName, model)
+	if b == nil {
+		l.mu.Unlock()
+		return &TokenReservation{}, nil
+	}
+	if float64(tokens) > b.limit {
+		l.mu.Unlock()
+		return nil, ErrExceedsTokenRateLimit
+	}
+	b.refill(time.Now())
+	b.tokens -= float64(tokens)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.limit * float64(time.Minute))
+	}
+	l.mu.Unlock()
+
+	r := &TokenReservation{limiter: l, bucket: b, tokens: tokens}
+	if wait <= 0 {
+		return r, nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return r, nil
+	case <-ctx.Done():
+		r.Settle(0)
+		return nil, ctx.Err()
+	}
+}
+
+// Settle reconciles the reservation with the tokens actually used,
+// returning unused tokens to the bucket or debiting the excess. Only the
+// first call has an effect.
+func (r *TokenReservation) Settle(used int) {
+	if r.bucket == nil {
+		return
+	}
+	r.once.Do(func() {
+		r.limiter.mu.Lock()
+		defer r.limiter.mu.Unlock()
+		r.bucket.refill(time.Now())
+		r.bucket.tokens = math.Min(r.bucket.limit, r.bucket.tokens+float64(r.tokens-used))
+	})
+}
+
+// Available returns the tokens currently available to the provider and
+// model, and false if they are not limited
+func (l *TokenRateLimiter) Available(providerName, model string) (int, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := l.bucket(providerName, model)
+	if b == nil {
+		return 0, false
+	}
+	b.refill(time.Now())
+	return int(b.tokens), true
+}
+
+// bucket returns the provider and model's bucket, creating it full, or nil
+// if they are not limited (must be called with lock held)
+func (l *TokenRateLimiter) bucket(providerName, model string) *tokenBucket {
+	key := tokenBucketKey{provider: providerName, model: model}
+	if b, ok := l.buckets[key]; ok {
+		return b
+	}
+	limit := l.config.TokensPerMinute
+	if ml, ok := l.config.ModelLimits[model]; ok {
+		limit = ml
+	}
+	if limit <= 0 {
+		return nil
+	}
+	b := &tokenBucket{limit: float64(limit), tokens: float64(limit), updated: time.Now()}
+	l.buckets[key] = b
+	return b
+}
+
+// estimate returns the tokens to debit for req: its estimated prompt plus
+// its completion budget
+func (l *TokenRateLimiter) estimate(req *provider.ChatCompletionRequest) (int, error) {
+	prompt, err := l.config.Estimator.EstimateTokens(req.Model, req.Messages)
+	if err != nil {
+		return 0, err
+	}
+	completion := l.config.DefaultCompletionTokens
+	if req.MaxTokens != nil {
+		completion = *req.MaxTokens
+	}
+	return prompt + completion, nil
+}
+
+// tokenLimitedProvider debits each request from a token rate limiter
+type tokenLimitedProvider struct {
+	provider.Provider
+	limiter *TokenRateLimiter
+}
+
+func (p *tokenLimitedProvider) unwrapProvider() provider.Provider {
+	return p.Provider
+}
+
+// reserve estimates req's tokens and reserves them
+func (p *tokenLimitedProvider) reserve(ctx context.Context, req *provider.ChatCompletionRequest) (*TokenReservation, error) {
+	tokens, err := p.limiter.estimate(req)
+	if err != nil {
+		return nil, err
+	}
+	return p.limiter.Reserve(ctx, p.Name(), req.Model, tokens)
+}
+
+func (p *tokenLimitedProvider) CreateChatCompletion(ctx context.Context, req *provider.ChatCompletionRequest) (*provider.ChatCompletionResponse, error) {
+	r, err := p.reserve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.CreateChatCompletion(ctx, req)
+	switch {
+	case err != nil:
+		r.Settle(0)
+	case resp.Usage.TotalTokens > 0:
+		r.Settle(resp.Usage.TotalTokens)
+	default:
+		r.Settle(r.tokens)
+	}
+	return resp, err
+}
+
+func (p *tokenLimitedProvider) CreateChatCompletionStream(ctx context.Context, req *provider.ChatCompletionRequest) (provider.ChatCompletionStream, error) {
+	r, err := p.reserve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := p.Provider.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		r.Settle(0)
+		return nil, err
+	}
+	return &tokenLimitedStream{ChatCompletionStream: stream, reservation: r}, nil
+}
+
+// tokenLimitedStream settles its reservation with the usage reported in the
+// stream, or the estimate if none was, when the stream ends or is closed
+type tokenLimitedStream struct {
+	provider.ChatCompletionStream
+	reservation *TokenReservation
+	used        int
+}
+
+func (s *tokenLimitedStream) Recv() (*provider.ChatCompletionChunk, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	if chunk != nil && chunk.Usage != nil && chunk.Usage.TotalTokens > 0 {
+		s.used = chunk.Usage.TotalTokens
+	}
+	if err != nil {
+		s.settle()
+	}
+	return chunk, err
+}
+
+func (s *tokenLimitedStream) Close() error {
+	s.settle()
+	return s.ChatCompletionStream.Close()
+}
+
+func (s *tokenLimitedStream) settle() {
+	if s.used > 0 {
+		s.reservation.Settle(s.used)
+	} else {
+		s.reservation.Settle(s.reservation.tokens)
+	}
+}
+
+// TokenRateLimiter returns the client's token rate limiter, or nil if token
+// rate limiting is not configured
+func (c *ChatClient) TokenRateLimiter() *TokenRateLimiter {
+	return c.tokenLimiter
+}
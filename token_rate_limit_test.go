@@ -0,0 +1,114 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+// withinTokens reports whether got is want, allowing for the bucket
+// refilling while the test runs
+func withinTokens(got, want int) bool {
+	return got >= want && got <= want+100
+}
+
+func TestTokenRateLimiter_WaitsForRefill(t *testing.T) {
+	// 60,000 tokens per minute refill 1 token per millisecond
+	l := NewTokenRateLimiter(TokenRateLimitConfig{TokensPerMinute: 60000})
+	ctx := context.Background()
+
+	if _, err := l.Reserve(ctx, "openai", "gpt-4o", 60000); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := l.Reserve(ctx, "openai", "gpt-4o", 50); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected to wait for the bucket to refill, waited %v", elapsed)
+	}
+
+	// Other models and providers have their own buckets
+	if got, _ := l.Available("openai", "gpt-4o-mini"); got != 60000 {
+		t.Errorf("expected a full bucket for another model, got %d", got)
+	}
+	if got, _ := l.Available("anthropic", "gpt-4o"); got != 60000 {
+		t.Errorf("expected a full bucket for another provider, got %d", got)
+	}
+}
+
+func TestTokenRateLimiter_Settle(t *testing.T) {
+	l := NewTokenRateLimiter(TokenRateLimitConfig{
+		TokensPerMinute: 60000,
+		ModelLimits:     map[string]int{"unlimited": 0},
+	})
+	ctx := context.Background()
+
+	r, err := l.Reserve(ctx, "openai", "gpt-4o", 1000)
+	if err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	r.Settle(200)
+	r.Settle(5000) // Settling twice is a no-op
+	if got, _ := l.Available("openai", "gpt-4o"); !withinTokens(got, 59800) {
+		t.Errorf("expected 59800 tokens after settling, got %d", got)
+	}
+
+	if _, err := l.Reserve(ctx, "openai", "gpt-4o", 60001); !errors.Is(err, ErrExceedsTokenRateLimit) {
+		t.Errorf("expected ErrExceedsTokenRateLimit, got %v", err)
+	}
+	if _, limited := l.Available("openai", "unlimited"); limited {
+		t.Error("expected a model with a zero limit not to be limited")
+	}
+}
+
+func TestTokenRateLimiter_ContextCanceled(t *testing.T) {
+	l := NewTokenRateLimiter(TokenRateLimitConfig{TokensPerMinute: 600})
+	if _, err := l.Reserve(context.Background(), "openai", "gpt-4o", 600); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Reserve(ctx, "openai", "gpt-4o", 300); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if got, _ := l.Available("openai", "gpt-4o"); got < 0 {
+		t.Errorf("expected the abandoned reservation to be returned, got %d tokens", got)
+	}
+}
+
+func TestNewClient_TokenRateLimit(t *testing.T) {
+	resp := mocktest.TextResponse("hi")
+	resp.Usage = provider.Usage{PromptTokens: 300, CompletionTokens: 200, TotalTokens: 500}
+	mockProv := mocktest.NewScriptedProvider("scripted").Default(mocktest.Step{Response: resp})
+
+	client, err := NewClient(ClientConfig{
+		Providers:      []ProviderConfig{{CustomProvider: mockProv}},
+		TokenRateLimit: &TokenRateLimitConfig{TokensPerMinute: 60000},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	maxTokens := 4000
+	req := &provider.ChatCompletionRequest{
+		Model:     "m",
+		Messages:  []provider.Message{{Role: provider.RoleUser, Content: "hello"}},
+		MaxTokens: &maxTokens,
+	}
+	if _, err := client.CreateChatCompletion(context.Background(), req); err != nil {
+		t.Fatalf("CreateChatCompletion failed: %v", err)
+	}
+
+	// The MaxTokens debit is reconciled with the reported usage
+	if got, _ := client.TokenRateLimiter().Available("scripted", "m"); !withinTokens(got, 59500) {
+		t.Errorf("expected 59500 tokens after reconciling, got %d", got)
+	}
+}
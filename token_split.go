@@ -0,0 +1,222 @@
+package omnillm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// TokenCounter counts the tokens in raw text. Estimators that wrap a
+// model's exact tokenizer implement it so that SplitByTokens and CountTokens
+// match the model's own accounting. The default estimator implements it
+// with its character ratio.
+type TokenCounter interface {
+	CountTokens(model, text string) (int, error)
+}
+
+// CountTokens estimates the tokens in text using the default estimator's
+// character ratio, without per-message overhead
+func (e *defaultTokenEstimator) CountTokens(model, text string) (int, error) {
+	return int(math.Ceil(float64(len(text)) / e.config.CharactersPerToken)), nil
+}
+
+// CountTokens returns the tokens in text: from estimator's CountTokens if
+// it is a TokenCounter, or else its estimate for a message holding text,
+// less the estimate for an empty message
+func CountTokens(estimator TokenEstimator, model, text string) (int, error) {
+	if counter, ok := estimator.(TokenCounter); ok {
+		return counter.CountTokens(model, text)
+	}
+	tokens, err := estimator.EstimateTokens(model, []provider.Message{{Role: provider.RoleUser, Content: text}})
+	if err != nil {
+		return 0, err
+	}
+	overhead, err := estimator.EstimateTokens(model, []provider.Message{{Role: provider.RoleUser}})
+	if err != nil {
+		return 0, err
+	}
+	return max(tokens-overhead, 0), nil
+}
+
+// SplitByTokens splits text into chunks of at most maxTokens, counted with
+// CountTokens, for RAG ingestion and summarization. Each chunk after the
+// first repeats up to overlap tokens from the end of the one before it.
+// Chunks end at a paragraph break, or failing that a sentence end or a
+// word break, if one falls in the second half of the chunk. A word longer
+// than maxTokens is split mid-word. Chunks are trimmed of surrounding
+// whitespace, and text that is only whitespace has no chunks.
+func SplitByTokens(estimator TokenEstimator, text, model string, maxTokens, overlap int) ([]string, error) {
+	if maxTokens <= 0 {
+		return nil, fmt.Errorf("%w: maxTokens must be positive", ErrInvalidConfiguration)
+	}
+	if overlap < 0 || overlap >= maxTokens {
+		return nil, fmt.Errorf("%w: overlap must be at least 0 and less than maxTokens", ErrInvalidConfiguration)
+	}
+
+	s := &tokenSplitter{estimator: estimator, model: model, text: text}
+	bounds := wordBounds(text)
+
+	var chunks []string
+	start, prevEnd := 0, 0 // Indexes into bounds
+	for start < len(bounds)-1 {
+		end, err := s.lastFitting(bounds, start, maxTokens)
+		if err != nil {
+			return nil, err
+		}
+		if end <= prevEnd && start < prevEnd {
+			// The overlap left no room for new text, so drop it
+			start = prevEnd
+			continue
+		}
+
+		if end == start {
+			// Not even one word fits, so split the word by runes
+			runes := runeBounds(text, bounds[start], bounds[start+1])
+			n, err := s.lastFitting(runes, 0, maxTokens)
+			if err != nil {
+				return nil, err
+			}
+			n = max(n, 1)
+			if chunk := strings.TrimSpace(text[runes[0]:runes[n]]); chunk != "" {
+				chunks = append(chunks, chunk)
+			}
+			if n < len(runes)-1 {
+				bounds = append(append(bounds[:start+1:start+1], runes[n]), bounds[start+1:]...)
+			}
+			start++
+			prevEnd = start
+			continue
+		}
+
+		if end < len(bounds)-1 {
+			end = naturalBreak(text, bounds, max(start, prevEnd), end)
+		}
+		if chunk := strings.TrimSpace(text[bounds[start]:bounds[end]]); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(bounds)-1 {
+			break
+		}
+
+		prevEnd = end
+		next := end
+		if overlap > 0 {
+			next, err = s.firstFitting(bounds, start+1, end, overlap)
+			if err != nil {
+				return nil, err
+			}
+		}
+		start = next
+	}
+	return chunks, nil
+}
+
+// SplitByTokens splits text with the client's TokenEstimator, or the
+// default estimator if none is configured. See SplitByTokens.
+func (c *ChatClient) SplitByTokens(text, model string, maxTokens, overlap int) ([]string, error) {
+	estimator := c.tokenEstimator
+	if estimator == nil {
+		estimator = NewTokenEstimator(DefaultTokenEstimatorConfig())
+	}
+	return SplitByTokens(estimator, text, model, maxTokens, overlap)
+}
+
+// tokenSplitter counts the tokens between byte offsets of a text
+type tokenSplitter struct {
+	estimator TokenEstimator
+	model     string
+	text      string
+}
+
+func (s *tokenSplitter) count(from, to int) (int, error) {
+	return CountTokens(s.estimator, s.model, s.text[from:to])
+}
+
+// lastFitting returns the largest end > start such that the text from
+// bounds[start] to bounds[end] has at most limit tokens, or start if none does
+func (s *tokenSplitter) lastFitting(bounds []int, start, limit int) (int, error) {
+	var err error
+	n := sort.Search(len(bounds)-start-1, func(i int) bool {
+		if err != nil {
+			return true
+		}
+		tokens, cerr := s.count(bounds[start], bounds[start+i+1])
+		if cerr != nil {
+			err = cerr
+			return true
+		}
+		return tokens > limit
+	})
+	return start + n, err
+}
+
+// firstFitting returns the smallest start in [lo, end] such that the text
+// from bounds[start] to bounds[end] has at most limit tokens
+func (s *tokenSplitter) firstFitting(bounds []int, lo, end, limit int) (int, error) {
+	var err error
+	n := sort.Search(end-lo, func(i int) bool {
+		if err != nil {
+			return true
+		}
+		tokens, cerr := s.count(bounds[lo+i], bounds[end])
+		if cerr != nil {
+			err = cerr
+			return true
+		}
+		return tokens <= limit
+	})
+	return lo + n, err
+}
+
+// wordBounds returns 0, the offset of each word that follows whitespace,
+// and len(text)
+func wordBounds(text string) []int {
+	bounds := []int{0}
+	prevSpace := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if !space && prevSpace && i > 0 {
+			bounds = append(bounds, i)
+		}
+		prevSpace = space
+	}
+	if len(text) > 0 {
+		bounds = append(bounds, len(text))
+	}
+	return bounds
+}
+
+// runeBounds returns the offset of each rune in text[from:to], and to
+func runeBounds(text string, from, to int) []int {
+	bounds := make([]int, 0, utf8.RuneCountInString(text[from:to])+1)
+	for i := range text[from:to] {
+		bounds = append(bounds, from+i)
+	}
+	return append(bounds, to)
+}
+
+// naturalBreak moves end back to the last paragraph break, or else the last
+// sentence end, in the second half of the words from from to end
+func naturalBreak(text string, bounds []int, from, end int) int {
+	floor := from + (end-from)/2
+	for _, isBreak := range []func(string) bool{
+		func(before string) bool { return strings.Contains(before, "\n\n") },
+		func(before string) bool {
+			before = strings.TrimRightFunc(before, unicode.IsSpace)
+			return strings.HasSuffix(before, ".") || strings.HasSuffix(before, "!") || strings.HasSuffix(before, "?")
+		},
+	} {
+		for i := end; i > floor; i-- {
+			// The whitespace before the word at bounds[i]
+			if isBreak(text[bounds[i-1]:bounds[i]]) {
+				return i
+			}
+		}
+	}
+	return end
+}
@@ -0,0 +1,149 @@
+package omnillm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// wordCounter counts one token per word, like an exact tokenizer would
+type wordCounter struct {
+	TokenEstimator
+}
+
+func (wordCounter) CountTokens(model, text string) (int, error) {
+	return len(strings.Fields(text)), nil
+}
+
+func TestSplitByTokens(t *testing.T) {
+	estimator := wordCounter{NewTokenEstimator(DefaultTokenEstimatorConfig())}
+
+	tests := []struct {
+		name      string
+		text      string
+		maxTokens int
+		overlap   int
+		want      []string
+	}{
+		{
+			name:      "fits",
+			text:      "  one two three  ",
+			maxTokens: 5,
+			want:      []string{"one two three"},
+		},
+		{
+			name:      "words",
+			text:      "a b c d e f g",
+			maxTokens: 3,
+			want:      []string{"a b c", "d e f", "g"},
+		},
+		{
+			name:      "overlap",
+			text:      "a b c d e f g",
+			maxTokens: 4,
+			overlap:   1,
+			want:      []string{"a b c d", "d e f g"},
+		},
+		{
+			name:      "paragraph break",
+			text:      "a b c\n\nd e f g h",
+			maxTokens: 5,
+			want:      []string{"a b c", "d e f g h"},
+		},
+		{
+			name:      "sentence end",
+			text:      "a b c. d e f g h",
+			maxTokens: 5,
+			want:      []string{"a b c.", "d e f g h"},
+		},
+		{
+			name:      "break too early",
+			text:      "a. b c d e f",
+			maxTokens: 5,
+			want:      []string{"a. b c d e", "f"},
+		},
+		{
+			name:      "empty",
+			text:      " \n ",
+			maxTokens: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitByTokens(estimator, tt.text, "m", tt.maxTokens, tt.overlap)
+			if err != nil {
+				t.Fatalf("SplitByTokens failed: %v", err)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSplitByTokens_DefaultEstimator(t *testing.T) {
+	// The default estimator counts 4 characters per token
+	estimator := NewTokenEstimator(DefaultTokenEstimatorConfig())
+	text := strings.Repeat("abcdefg ", 50)
+
+	chunks, err := SplitByTokens(estimator, text, "gpt-4o", 20, 5)
+	if err != nil {
+		t.Fatalf("SplitByTokens failed: %v", err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if tokens, _ := CountTokens(estimator, "gpt-4o", chunk); tokens > 20 {
+			t.Errorf("chunk %d has %d tokens", i, tokens)
+		}
+		if i > 0 && !strings.HasPrefix(chunk, "abcdefg") {
+			t.Errorf("chunk %d does not start at a word: %q", i, chunk)
+		}
+	}
+	if !strings.HasPrefix(chunks[1], chunks[0][len(chunks[0])-7:]) {
+		t.Errorf("expected chunk 1 to overlap chunk 0, got %q and %q", chunks[0], chunks[1])
+	}
+
+	// A word longer than maxTokens is split mid-word
+	chunks, err = SplitByTokens(estimator, "héllo"+strings.Repeat("x", 30), "gpt-4o", 4, 0)
+	if err != nil {
+		t.Fatalf("SplitByTokens failed: %v", err)
+	}
+	if strings.Join(chunks, "") != "héllo"+strings.Repeat("x", 30) || len(chunks) < 3 {
+		t.Errorf("expected the word split into several chunks, got %q", chunks)
+	}
+}
+
+func TestSplitByTokens_InvalidArguments(t *testing.T) {
+	estimator := NewTokenEstimator(DefaultTokenEstimatorConfig())
+	for _, args := range [][2]int{{0, 0}, {10, 10}, {10, -1}} {
+		if _, err := SplitByTokens(estimator, "text", "m", args[0], args[1]); !errors.Is(err, ErrInvalidConfiguration) {
+			t.Errorf("maxTokens=%d overlap=%d: expected ErrInvalidConfiguration, got %v", args[0], args[1], err)
+		}
+	}
+}
+
+func TestCountTokens_EstimatorFallback(t *testing.T) {
+	// An estimator without CountTokens is measured without per-message overhead
+	estimator := messageOnlyEstimator{}
+	if got, _ := CountTokens(estimator, "m", "12345678"); got != 8 {
+		t.Errorf("expected 8 tokens, got %d", got)
+	}
+}
+
+// messageOnlyEstimator counts one token per character plus 10 per message
+type messageOnlyEstimator struct{}
+
+func (messageOnlyEstimator) EstimateTokens(model string, messages []provider.Message) (int, error) {
+	tokens := 0
+	for _, m := range messages {
+		tokens += 10 + len(m.Content)
+	}
+	return tokens, nil
+}
+
+func (messageOnlyEstimator) GetContextWindow(model string) int { return 0 }
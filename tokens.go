@@ -29,6 +29,10 @@ type TokenEstimatorConfig struct {
 	// Keys should be model IDs (e.g., "gpt-4o", "claude-3-opus").
 	CustomContextWindows map[string]int
 
+	// Catalog supplies context windows for models without a custom override.
+	// Default: DefaultModelCatalog()
+	Catalog *ModelCatalog
+
 	// TokenOverheadPerMessage is extra tokens added per message for formatting.
 	// Default: 4 (accounts for role, separators, etc.)
 	TokenOverheadPerMessage int
@@ -56,6 +60,9 @@ func NewTokenEstimator(config TokenEstimatorConfig) TokenEstimator {
 	if config.TokenOverheadPerMessage == 0 {
 		config.TokenOverheadPerMessage = 4
 	}
+	if config.Catalog == nil {
+		config.Catalog = DefaultModelCatalog()
+	}
 
 	return &defaultTokenEstimator{config: config}
 }
@@ -108,7 +115,8 @@ func (e *defaultTokenEstimator) EstimateTokens(model string, messages []provider
 }
 
 // GetContextWindow returns the context window size for a model.
-// Checks custom overrides first, then falls back to built-in knowledge.
+// Checks custom overrides first, then the model catalog, then falls back to
+// built-in knowledge of model aliases.
 func (e *defaultTokenEstimator) GetContextWindow(model string) int {
 	// Check custom overrides first
 	if e.config.CustomContextWindows != nil {
@@ -117,96 +125,77 @@ func (e *defaultTokenEstimator) GetContextWindow(model string) int {
 		}
 	}
 
-	// Check built-in ModelInfo
-	if info := GetModelInfo(model); info != nil && info.MaxTokens > 0 {
-		return info.MaxTokens
+	// Check the model catalog
+	if window := e.config.Catalog.ContextWindow(model); window > 0 {
+		return window
 	}
 
 	// Fall back to extended lookup
 	return getExtendedContextWindow(model)
 }
 
+// knownContextWindows holds context window sizes for common model aliases
+// and versions that are not in the model catalog
+var knownContextWindows = map[string]int{
+	// OpenAI Models
+	"gpt-4o-2024-05-13":   128000,
+	"gpt-4o-2024-08-06":   128000,
+	"gpt-4-turbo-preview": 128000,
+	"gpt-4-1106-preview":  128000,
+	"gpt-4":               8192,
+	"gpt-4-32k":           32768,
+	"gpt-3.5-turbo-16k":   16385,
+	"gpt-3.5-turbo-1106":  16385,
+	"o1":                  200000,
+	"o1-preview":          128000,
+	"o1-mini":             128000,
+
+	// Anthropic Models
+	"claude-opus-4":      200000,
+	"claude-sonnet-4":    200000,
+	"claude-3-opus":      200000,
+	"claude-3-sonnet":    200000,
+	"claude-3-haiku":     200000,
+	"claude-3.5-sonnet":  200000,
+	"claude-3.5-haiku":   200000,
+	"claude-2.1":         200000,
+	"claude-2":           100000,
+	"claude-instant-1.2": 100000,
+
+	// Google Gemini Models
+	"gemini-1.5-pro-latest":   2000000,
+	"gemini-1.5-flash-latest": 1000000,
+	"gemini-1.0-pro":          32768,
+
+	// X.AI Grok Models
+	"grok-4":      128000,
+	"grok-4-fast": 128000,
+	"grok-3-fast": 128000,
+	"grok-2":      128000,
+
+	// Ollama Local Models (common defaults)
+	"llama3":         8192,
+	"llama2":         4096,
+	"llama2:7b":      4096,
+	"llama2:13b":     4096,
+	"llama2:70b":     4096,
+	"mistral":        32768,
+	"mixtral":        32768,
+	"codellama":      16384,
+	"codellama:7b":   16384,
+	"codellama:34b":  16384,
+	"gemma":          8192,
+	"qwen":           32768,
+	"qwen:7b":        32768,
+	"qwen:14b":       32768,
+	"deepseek-coder": 16384,
+	"phi":            2048,
+	"phi:2.7b":       2048,
+}
+
 // getExtendedContextWindow provides context window sizes for common models
 func getExtendedContextWindow(model string) int {
-	windows := map[string]int{
-		// OpenAI Models
-		"gpt-4o":              128000,
-		"gpt-4o-mini":         128000,
-		"gpt-4o-2024-05-13":   128000,
-		"gpt-4o-2024-08-06":   128000,
-		"gpt-4-turbo":         128000,
-		"gpt-4-turbo-preview": 128000,
-		"gpt-4-1106-preview":  128000,
-		"gpt-4":               8192,
-		"gpt-4-32k":           32768,
-		"gpt-3.5-turbo":       16385,
-		"gpt-3.5-turbo-16k":   16385,
-		"gpt-3.5-turbo-1106":  16385,
-		"o1":                  200000,
-		"o1-preview":          128000,
-		"o1-mini":             128000,
-
-		// Anthropic Models
-		"claude-opus-4":            200000,
-		"claude-sonnet-4":          200000,
-		"claude-3-opus":            200000,
-		"claude-3-opus-20240229":   200000,
-		"claude-3-sonnet":          200000,
-		"claude-3-sonnet-20240229": 200000,
-		"claude-3-haiku":           200000,
-		"claude-3-haiku-20240307":  200000,
-		"claude-3.5-sonnet":        200000,
-		"claude-3.5-haiku":         200000,
-		"claude-2.1":               200000,
-		"claude-2":                 100000,
-		"claude-instant-1.2":       100000,
-
-		// Google Gemini Models
-		"gemini-2.5-pro":          1000000,
-		"gemini-2.5-flash":        1000000,
-		"gemini-1.5-pro":          2000000,
-		"gemini-1.5-pro-latest":   2000000,
-		"gemini-1.5-flash":        1000000,
-		"gemini-1.5-flash-latest": 1000000,
-		"gemini-1.0-pro":          32768,
-		"gemini-pro":              32768,
-
-		// X.AI Grok Models
-		"grok-4":      128000,
-		"grok-4-fast": 128000,
-		"grok-3":      128000,
-		"grok-3-fast": 128000,
-		"grok-2":      128000,
-		"grok-beta":   128000,
-
-		// Ollama Local Models (common defaults)
-		"llama3":         8192,
-		"llama3:8b":      8192,
-		"llama3:70b":     8192,
-		"llama2":         4096,
-		"llama2:7b":      4096,
-		"llama2:13b":     4096,
-		"llama2:70b":     4096,
-		"mistral":        32768,
-		"mistral:7b":     32768,
-		"mixtral":        32768,
-		"mixtral:8x7b":   32768,
-		"codellama":      16384,
-		"codellama:7b":   16384,
-		"codellama:13b":  16384,
-		"codellama:34b":  16384,
-		"gemma":          8192,
-		"gemma:2b":       8192,
-		"gemma:7b":       8192,
-		"qwen":           32768,
-		"qwen:7b":        32768,
-		"qwen:14b":       32768,
-		"deepseek-coder": 16384,
-		"phi":            2048,
-		"phi:2.7b":       2048,
-	}
-
-	if window, ok := windows[model]; ok {
+	if window, ok := knownContextWindows[model]; ok {
 		return window
 	}
 
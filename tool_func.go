@@ -0,0 +1,331 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrInvalidToolFunc is returned by ToolFromFunc when fn does not have a
+// supported signature
+var ErrInvalidToolFunc = errors.New("invalid tool function")
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+	timeType    = reflect.TypeFor[time.Time]()
+)
+
+// ToolFromFunc builds a tool definition and its executor from a Go function.
+//
+// fn takes an optional context.Context followed by one struct (or struct
+// pointer) holding the arguments, and returns a result, an error, or both:
+//
+//	func(ctx context.Context, args WeatherArgs) (string, error)
+//	func(args WeatherArgs) (*Forecast, error)
+//
+// The parameter schema is generated from the struct's fields using their json
+// tags for names; fields marked omitempty or with pointer types are optional.
+// A jsonschema tag adds schema details as comma-separated options, with "\,"
+// for a literal comma:
+//
+//	City  string `json:"city" jsonschema:"description=City name\, e.g. Paris"`
+//	Units string `json:"units,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+//
+// Supported options are description, enum (repeatable), format, minimum,
+// maximum, minLength, maxLength, and required. String results are returned
+// to the model as-is; other results are encoded as JSON.
+func ToolFromFunc(fn any, name, description string) (provider.Tool, ToolFunc, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if fv.Kind() != reflect.Func {
+		return provider.Tool{}, nil, fmt.Errorf("%w: %s is %T, not a function", ErrInvalidToolFunc, name, fn)
+	}
+
+	sig, err := parseToolSignature(ft)
+	if err != nil {
+		return provider.Tool{}, nil, fmt.Errorf("%w: %s: %v", ErrInvalidToolFunc, name, err)
+	}
+
+	tool := provider.Tool{
+		Type: ToolTypeFunction,
+		Function: provider.ToolSpec{
+			Name:        name,
+			Description: description,
+			Parameters:  schemaForType(sig.argType, nil),
+		},
+	}
+
+	invoke := func(ctx context.Context, arguments string) (string, error) {
+		argPtr := reflect.New(sig.argType)
+		if strings.TrimSpace(arguments) != "" {
+			if err := json.Unmarshal([]byte(arguments), argPtr.Interface()); err != nil {
+				return "", fmt.Errorf("invalid arguments for %s: %w", name, err)
+			}
+		}
+
+		in := make([]reflect.Value, 0, 2)
+		if sig.hasContext {
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		if sig.argIsPointer {
+			in = append(in, argPtr)
+		} else {
+			in = append(in, argPtr.Elem())
+		}
+
+		out := fv.Call(in)
+		if sig.hasError {
+			if errVal := out[len(out)-1]; !errVal.IsNil() {
+				return "", errVal.Interface().(error)
+			}
+		}
+		if !sig.hasResult {
+			return "", nil
+		}
+		return toolResultString(out[0].Interface())
+	}
+
+	return tool, invoke, nil
+}
+
+// AddFunc registers a Go function as a tool; see ToolFromFunc
+func (s *ToolSet) AddFunc(fn any, name, description string) error {
+	tool, invoke, err := ToolFromFunc(fn, name, description)
+	if err != nil {
+		return err
+	}
+	s.Add(tool, invoke)
+	return nil
+}
+
+// toolSignature describes the shape of a function accepted by ToolFromFunc
+type toolSignature struct {
+	hasContext   bool
+	argType      reflect.Type
+	argIsPointer bool
+	hasResult    bool
+	hasError     bool
+}
+
+func parseToolSignature(ft reflect.Type) (toolSignature, error) {
+	var sig toolSignature
+	if ft.IsVariadic() {
+		return sig, errors.New("variadic functions are not supported")
+	}
+
+	params := make([]reflect.Type, 0, ft.NumIn())
+	for i := range ft.NumIn() {
+		params = append(params, ft.In(i))
+	}
+	if len(params) > 0 && params[0] == contextType {
+		sig.hasContext = true
+		params = params[1:]
+	}
+	if len(params) != 1 {
+		return sig, errors.New("expected one argument struct after the optional context")
+	}
+	sig.argType = params[0]
+	if sig.argType.Kind() == reflect.Pointer {
+		sig.argIsPointer = true
+		sig.argType = sig.argType.Elem()
+	}
+	if sig.argType.Kind() != reflect.Struct {
+		return sig, fmt.Errorf("argument must be a struct, got %s", params[0])
+	}
+
+	switch ft.NumOut() {
+	case 0:
+	case 1:
+		if ft.Out(0) == errorType {
+			sig.hasError = true
+		} else {
+			sig.hasResult = true
+		}
+	case 2:
+		if ft.Out(1) != errorType {
+			return sig, errors.New("second result must be error")
+		}
+		sig.hasResult, sig.hasError = true, true
+	default:
+		return sig, errors.New("expected at most a result and an error")
+	}
+	return sig, nil
+}
+
+// toolResultString converts a tool function's result into message content
+func toolResultString(v any) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("encoding tool result: %w", err)
+	}
+	return string(data), nil
+}
+
+// schemaForType generates a JSON Schema for t. seen guards against recursive
+// types, which are emitted as an unconstrained object.
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]any{"type": "object"}
+		}
+		if seen == nil {
+			seen = make(map[reflect.Type]bool)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		properties := map[string]any{}
+		required := []string{}
+		addStructFields(t, properties, &required, seen)
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	return map[string]any{}
+}
+
+// addStructFields adds the schema of each exported field of t, flattening
+// embedded structs the way encoding/json does
+func addStructFields(t reflect.Type, properties map[string]any, required *[]string, seen map[reflect.Type]bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(jsonTag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructFields(ft, properties, required, seen)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemaForType(field.Type, seen)
+		isRequired := field.Type.Kind() != reflect.Pointer && !strings.Contains(","+opts+",", ",omitempty,")
+		if applySchemaTag(schema, field.Tag.Get("jsonschema")) {
+			isRequired = true
+		}
+
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applySchemaTag applies jsonschema tag options to schema and reports whether
+// the tag marks the field as required
+func applySchemaTag(schema map[string]any, tag string) bool {
+	required := false
+	var enum []any
+	for _, opt := range splitSchemaTag(tag) {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "required":
+			required = true
+		case "description", "format":
+			schema[key] = value
+		case "enum":
+			enum = append(enum, schemaTagValue(schema, value))
+		case "minimum", "maximum", "minLength", "maxLength":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				schema[key] = n
+			}
+		}
+	}
+	if len(enum) > 0 {
+		schema["enum"] = enum
+	}
+	return required
+}
+
+// schemaTagValue converts an enum value to the field's JSON type
+func schemaTagValue(schema map[string]any, value string) any {
+	switch schema["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// splitSchemaTag splits a jsonschema tag on commas, honoring "\," escapes
+func splitSchemaTag(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	var parts []string
+	var sb strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(tag[i])
+		}
+	}
+	return append(parts, sb.String())
+}
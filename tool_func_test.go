@@ -0,0 +1,123 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+type forecastArgs struct {
+	City  string     `json:"city" jsonschema:"description=City name\\, e.g. Paris"`
+	Units string     `json:"units,omitempty" jsonschema:"enum=celsius,enum=fahrenheit"`
+	Days  int        `json:"days" jsonschema:"minimum=1,maximum=7"`
+	Date  *time.Time `json:"date"`
+	Tags  []string   `json:"tags,omitempty"`
+	Debug bool       `json:"-"`
+	forecastPaging
+}
+
+type forecastPaging struct {
+	Page int `json:"page,omitempty" jsonschema:"required"`
+}
+
+type forecast struct {
+	City string  `json:"city"`
+	High float64 `json:"high"`
+}
+
+func TestToolFromFunc_Schema(t *testing.T) {
+	tool, _, err := ToolFromFunc(func(ctx context.Context, args forecastArgs) (*forecast, error) {
+		return nil, nil
+	}, "forecast", "Get a forecast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.Type != ToolTypeFunction || tool.Function.Name != "forecast" || tool.Function.Description != "Get a forecast" {
+		t.Errorf("unexpected tool: %+v", tool)
+	}
+
+	schema := tool.Function.Parameters.(map[string]any)
+	props := schema["properties"].(map[string]any)
+
+	want := map[string]map[string]any{
+		"city":  {"type": "string", "description": "City name, e.g. Paris"},
+		"units": {"type": "string", "enum": []any{"celsius", "fahrenheit"}},
+		"days":  {"type": "integer", "minimum": 1.0, "maximum": 7.0},
+		"date":  {"type": "string", "format": "date-time"},
+		"tags":  {"type": "array", "items": map[string]any{"type": "string"}},
+		"page":  {"type": "integer"},
+	}
+	if len(props) != len(want) {
+		t.Errorf("properties = %v", props)
+	}
+	for name, w := range want {
+		if !reflect.DeepEqual(props[name], w) {
+			t.Errorf("%s = %v, want %v", name, props[name], w)
+		}
+	}
+	if got := schema["required"]; !reflect.DeepEqual(got, []string{"city", "days", "page"}) {
+		t.Errorf("required = %v", got)
+	}
+}
+
+func TestToolFromFunc_Invoke(t *testing.T) {
+	set := NewToolSet()
+	err := set.AddFunc(func(args *forecastArgs) (forecast, error) {
+		if args.City == "" {
+			return forecast{}, errors.New("city is required")
+		}
+		return forecast{City: args.City, High: 21.5}, nil
+	}, "forecast", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = set.AddFunc(func(ctx context.Context, args struct {
+		Name string `json:"name"`
+	}) string {
+		return "hello " + args.Name
+	}, "greet", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(name, args string) (string, error) {
+		return set.Call(context.Background(), provider.ToolCall{Function: provider.ToolFunction{Name: name, Arguments: args}})
+	}
+
+	if got, err := call("forecast", `{"city":"Oslo","days":1}`); err != nil || got != `{"city":"Oslo","high":21.5}` {
+		t.Errorf("forecast = %q, %v", got, err)
+	}
+	if _, err := call("forecast", `{}`); err == nil || err.Error() != "city is required" {
+		t.Errorf("expected function error, got %v", err)
+	}
+	if _, err := call("forecast", `{"days":"many"}`); err == nil {
+		t.Error("expected invalid arguments error")
+	}
+	if got, err := call("greet", `{"name":"Ada"}`); err != nil || got != "hello Ada" {
+		t.Errorf("greet = %q, %v", got, err)
+	}
+}
+
+func TestToolFromFunc_InvalidSignatures(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   any
+	}{
+		{"not a function", "nope"},
+		{"no arguments", func() error { return nil }},
+		{"non-struct argument", func(s string) error { return nil }},
+		{"two arguments", func(a, b forecastArgs) error { return nil }},
+		{"second result not error", func(forecastArgs) (string, string) { return "", "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ToolFromFunc(tt.fn, "bad", ""); !errors.Is(err, ErrInvalidToolFunc) {
+				t.Errorf("expected ErrInvalidToolFunc, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+var (
+	// ErrUnknownTool is reported to the model when it calls a tool that is not in the ToolSet
+	ErrUnknownTool = errors.New("unknown tool")
+
+	// ErrToolLoopLimit is returned when the model keeps calling tools past MaxIterations
+	ErrToolLoopLimit = errors.New("tool loop exceeded maximum iterations")
+
+	// ErrToolTimeout is reported to the model when a tool runs past ToolTimeout
+	ErrToolTimeout = errors.New("tool call timed out")
+)
+
+// DefaultToolLoopMaxIterations is the default limit on model calls in RunTools
+const DefaultToolLoopMaxIterations = 10
+
+// ToolFunc executes a tool call. It receives the call's JSON arguments and
+// returns the result content sent back to the model.
+type ToolFunc func(ctx context.Context, arguments string) (string, error)
+
+// ToolSet holds tool definitions and the functions that execute them. It is
+// safe for concurrent use.
+type ToolSet struct {
+	mu    sync.RWMutex
+	tools []provider.Tool
+	funcs map[string]ToolFunc
+}
+
+// NewToolSet creates an empty tool set
+func NewToolSet() *ToolSet {
+	return &ToolSet{funcs: make(map[string]ToolFunc)}
+}
+
+// Add registers a tool and its function, replacing any tool with the same name
+func (s *ToolSet) Add(tool provider.Tool, fn ToolFunc) *ToolSet {
+	if tool.Type == "" {
+		tool.Type = ToolTypeFunction
+	}
+	name := tool.Function.Name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.funcs[name]; exists {
+		for i := range s.tools {
+			if s.tools[i].Function.Name == name {
+				s.tools = append(s.tools[:i], s.tools[i+1:]...)
+				break
+			}
+		}
+	}
+	s.tools = append(s.tools, tool)
+	s.funcs[name] = fn
+	return s
+}
+
+// Tools returns the tool definitions in registration order
+func (s *ToolSet) Tools() []provider.Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]provider.Tool(nil), s.tools...)
+}
+
+// Len returns the number of tools in the set
+func (s *ToolSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tools)
+}
+
+// Call executes a tool call and returns its result
+func (s *ToolSet) Call(ctx context.Context, call provider.ToolCall) (string, error) {
+	s.mu.RLock()
+	fn, ok := s.funcs[call.Function.Name]
+	s.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTool, call.Function.Name)
+	}
+	return fn(ctx, call.Function.Arguments)
+}
+
+// Execute runs a tool call and returns the tool result message for the
+// conversation. Tool errors are reported to the model as the message content
+// so it can recover, rather than failing the loop.
+func (s *ToolSet) Execute(ctx context.Context, call provider.ToolCall) provider.Message {
+	result, err := s.Call(ctx, call)
+	if err != nil {
+		result = "error: " + err.Error()
+	}
+	id := call.ID
+	return provider.Message{
+		Role:       provider.RoleTool,
+		Content:    result,
+		ToolCallID: &id,
+	}
+}
+
+// ToolLoopOptions configures RunTools
+type ToolLoopOptions struct {
+	// MaxIterations limits the number of model calls. Default: DefaultToolLoopMaxIterations
+	MaxIterations int
+
+	// MaxConcurrency limits how many tool calls from one assistant turn run at
+	// once. Set to 1 to run them sequentially. Default: 0 (no limit)
+	MaxConcurrency int
+
+	// ToolTimeout bounds each tool call. The call's context is canceled at the
+	// deadline and the model receives a timeout error as the result. Default: 0 (none)
+	ToolTimeout time.Duration
+
+	// Approve, if set, is called for every tool call before it runs. It may
+	// block while waiting for a human, replace the arguments, or reject the
+	// call. An error from Approve stops the loop.
+	Approve ToolApprovalFunc
+
+	// ResultTokens limits the tool results of one assistant turn to this many
+	// tokens, packed with a ContextPacker in call order. A result that does
+	// not fit is replaced with an error telling the model it was omitted, so
+	// every call still gets a result. Default: 0 (no limit)
+	ResultTokens int
+}
+
+// ToolDecisionAction is the outcome of a tool approval
+type ToolDecisionAction string
+
+const (
+	// ToolApprove runs the tool call
+	ToolApprove ToolDecisionAction = "approve"
+
+	// ToolReject skips the tool call and tells the model it was rejected
+	ToolReject ToolDecisionAction = "reject"
+)
+
+// ToolDecision is returned by a ToolApprovalFunc
+type ToolDecision struct {
+	// Action is ToolApprove or ToolReject; empty approves
+	Action ToolDecisionAction
+
+	// Arguments, if non-empty, replaces the call's JSON arguments when approved
+	Arguments string
+
+	// Reason is sent to the model when the call is rejected
+	Reason string
+}
+
+// ToolApprovalFunc decides whether a tool call may run
+type ToolApprovalFunc func(ctx context.Context, call provider.ToolCall) (ToolDecision, error)
+
+// ToolRejection is the JSON content of the tool result sent to the model for
+// a rejected call
+type ToolRejection struct {
+	Status string `json:"status"`
+	Tool   string `json:"tool"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ToolLoopResult is the outcome of RunTools
+type ToolLoopResult struct {
+	// Response is the final model response, which has no tool calls
+	Response *provider.ChatCompletionResponse
+
+	// Messages is the full conversation: the request messages followed by every
+	// assistant message and tool result, ending with the final answer
+	Messages []provider.Message
+
+	// Iterations is the number of model calls made
+	Iterations int
+}
+
+// RunTools sends req with the tools in the set and executes the tool calls the
+// model makes, feeding results back until the model answers without calling a
+// tool. Tools already on req are kept and sent alongside the set's tools.
+func (c *ChatClient) RunTools(ctx context.Context, req *provider.ChatCompletionRequest, tools *ToolSet, opts *ToolLoopOptions) (*ToolLoopResult, error) {
+	if opts == nil {
+		opts = &ToolLoopOptions{}
+	}
+	maxIterations := DefaultToolLoopMaxIterations
+	if opts.MaxIterations > 0 {
+		maxIterations = opts.MaxIterations
+	}
+
+	loopReq := *req
+	loopReq.Tools = append(append([]provider.Tool(nil), req.Tools...), tools.Tools()...)
+	loopReq.Messages = append([]provider.Message(nil), req.Messages...)
+
+	result := &ToolLoopResult{}
+	for result.Iterations < maxIterations {
+		resp, err := c.CreateChatCompletion(deriveIdempotencyKey(ctx, fmt.Sprintf("turn-%d", result.Iterations+1)), &loopReq)
+		result.Iterations++
+		if err != nil {
+			result.Messages = loopReq.Messages
+			return result, err
+		}
+		if len(resp.Choices) == 0 {
+			result.Messages = loopReq.Messages
+			return result, ErrInvalidResponse
+		}
+
+		msg := resp.Choices[0].Message
+		loopReq.Messages = append(loopReq.Messages, msg)
+		if len(msg.ToolCalls) == 0 {
+			result.Response = resp
+			result.Messages = loopReq.Messages
+			return result, nil
+		}
+
+		results, err := tools.executeCalls(ctx, msg.ToolCalls, opts)
+		if err == nil {
+			results, err = packToolResults(results, opts.ResultTokens, loopReq.Model, c.tokenEstimator)
+		}
+		if err != nil {
+			result.Messages = loopReq.Messages
+			return result, err
+		}
+		loopReq.Messages = append(loopReq.Messages, results...)
+	}
+
+	result.Messages = loopReq.Messages
+	return result, fmt.Errorf("%w (%d)", ErrToolLoopLimit, maxIterations)
+}
+
+// packToolResults fits the results of one turn in budget tokens, replacing
+// each result that is dropped with a short error
+func packToolResults(results []provider.Message, budget int, model string, estimator TokenEstimator) ([]provider.Message, error) {
+	if budget <= 0 {
+		return results, nil
+	}
+	items := make([]ContextItem, len(results))
+	for i, msg := range results {
+		items[i] = ContextItem{ID: strconv.Itoa(i), Message: msg}
+	}
+	packed, err := NewContextPacker(ContextPackerConfig{
+		Budget:    budget,
+		Order:     PackOrderInput,
+		Estimator: estimator,
+		Model:     model,
+	}).Pack(items)
+	if err != nil {
+		return nil, err
+	}
+
+	packedResults := slices.Clone(results)
+	for _, dropped := range packed.Dropped {
+		i, _ := strconv.Atoi(dropped.Item.ID)
+		packedResults[i].Content = fmt.Sprintf("error: result omitted: %s (%d tokens, budget %d)", dropped.Reason, dropped.Item.Tokens, budget)
+	}
+	return packedResults, nil
+}
+
+// executeCalls runs the tool calls from one assistant turn, concurrently up
+// to opts.MaxConcurrency, and returns their results in call order. Approvals
+// are requested one at a time, in order, before any call runs.
+func (s *ToolSet) executeCalls(ctx context.Context, calls []provider.ToolCall, opts *ToolLoopOptions) ([]provider.Message, error) {
+	results := make([]provider.Message, len(calls))
+	pending := make([]int, 0, len(calls))
+	approved := make([]provider.ToolCall, len(calls))
+	for i, call := range calls {
+		if opts.Approve == nil {
+			approved[i] = call
+			pending = append(pending, i)
+			continue
+		}
+		decision, err := opts.Approve(ctx, call)
+		if err != nil {
+			return nil, fmt.Errorf("tool approval for %s: %w", call.Function.Name, err)
+		}
+		if decision.Action == ToolReject {
+			results[i] = rejectionMessage(call, decision.Reason)
+			continue
+		}
+		if decision.Arguments != "" {
+			call.Function.Arguments = decision.Arguments
+		}
+		approved[i] = call
+		pending = append(pending, i)
+	}
+
+	if len(pending) <= 1 || opts.MaxConcurrency == 1 {
+		for _, i := range pending {
+			results[i] = s.executeWithTimeout(ctx, approved[i], opts.ToolTimeout)
+		}
+		return results, nil
+	}
+
+	limit := opts.MaxConcurrency
+	if limit <= 0 || limit > len(pending) {
+		limit = len(pending)
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = s.executeWithTimeout(ctx, approved[i], opts.ToolTimeout)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// rejectionMessage builds the tool result for a rejected call
+func rejectionMessage(call provider.ToolCall, reason string) provider.Message {
+	content, _ := json.Marshal(ToolRejection{Status: "rejected", Tool: call.Function.Name, Reason: reason})
+	id := call.ID
+	return provider.Message{
+		Role:       provider.RoleTool,
+		Content:    string(content),
+		ToolCallID: &id,
+	}
+}
+
+// executeWithTimeout runs Execute, giving up after timeout even if the tool
+// ignores context cancellation
+func (s *ToolSet) executeWithTimeout(ctx context.Context, call provider.ToolCall, timeout time.Duration) provider.Message {
+	if timeout <= 0 {
+		return s.Execute(ctx, call)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan provider.Message, 1)
+	go func() {
+		done <- s.Execute(ctx, call)
+	}()
+
+	select {
+	case msg := <-done:
+		return msg
+	case <-ctx.Done():
+		reason := ctx.Err().Error()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			reason = fmt.Sprintf("%s: %s after %s", ErrToolTimeout, call.Function.Name, timeout)
+		}
+		id := call.ID
+		return provider.Message{
+			Role:       provider.RoleTool,
+			Content:    "error: " + reason,
+			ToolCallID: &id,
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package omnillm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func toolCallStep(calls ...provider.ToolCall) mocktest.Step {
+	resp := mocktest.TextResponse("")
+	resp.Choices[0].Message.ToolCalls = calls
+	finish := "tool_calls"
+	resp.Choices[0].FinishReason = &finish
+	return mocktest.Step{Response: resp}
+}
+
+func toolCall(id, name, args string) provider.ToolCall {
+	return provider.ToolCall{ID: id, Type: ToolTypeFunction, Function: provider.ToolFunction{Name: name, Arguments: args}}
+}
+
+func weatherTools() *ToolSet {
+	return NewToolSet().Add(provider.Tool{
+		Function: provider.ToolSpec{Name: "get_weather", Parameters: map[string]any{"type": "object"}},
+	}, func(ctx context.Context, arguments string) (string, error) {
+		if strings.Contains(arguments, "Mars") {
+			return "", errors.New("no station on Mars")
+		}
+		return "sunny", nil
+	})
+}
+
+func TestRunTools(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted",
+		toolCallStep(toolCall("c1", "get_weather", `{"city":"Paris"}`), toolCall("c2", "get_weather", `{"city":"Mars"}`)),
+		toolCallStep(toolCall("c3", "missing_tool", `{}`)),
+		mocktest.TextStep("Paris is sunny"),
+	)
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: prov}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.RunTools(context.Background(), guardrailRequest(), weatherTools(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Iterations != 3 || result.Response.Choices[0].Message.Content != "Paris is sunny" {
+		t.Errorf("unexpected result: iterations=%d response=%+v", result.Iterations, result.Response)
+	}
+
+	// user, assistant, 2 tool results, assistant, 1 tool result, final answer
+	if len(result.Messages) != 7 {
+		t.Fatalf("expected 7 messages, got %d", len(result.Messages))
+	}
+	want := []string{"sunny", "error: no station on Mars"}
+	for i, w := range want {
+		msg := result.Messages[2+i]
+		if msg.Role != provider.RoleTool || msg.Content != w {
+			t.Errorf("tool result %d = %+v, want %q", i, msg, w)
+		}
+	}
+	if id := result.Messages[3].ToolCallID; id == nil || *id != "c2" {
+		t.Errorf("tool result not linked to its call: %v", id)
+	}
+	if !strings.Contains(result.Messages[5].Content, ErrUnknownTool.Error()) {
+		t.Errorf("unknown tool not reported: %q", result.Messages[5].Content)
+	}
+
+	first := prov.Requests()[0].Request
+	if len(first.Tools) != 1 || first.Tools[0].Type != ToolTypeFunction {
+		t.Errorf("tools not attached to request: %+v", first.Tools)
+	}
+}
+
+func TestRunTools_ResultTokens(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted",
+		toolCallStep(toolCall("c1", "get_weather", `{"city":"Paris"}`), toolCall("c2", "get_weather", `{"city":"Rome"}`)),
+		mocktest.TextStep("Both sunny"),
+	)
+	client, err := NewClient(ClientConfig{
+		Providers:      []ProviderConfig{{CustomProvider: prov}},
+		TokenEstimator: messageOnlyEstimator{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each result costs 15 tokens, so only the first fits
+	result, err := client.RunTools(context.Background(), guardrailRequest(), weatherTools(), &ToolLoopOptions{ResultTokens: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := result.Messages[2], result.Messages[3]
+	if first.Content != "sunny" {
+		t.Errorf("first result = %q, want sunny", first.Content)
+	}
+	if second.Role != provider.RoleTool || *second.ToolCallID != "c2" || !strings.HasPrefix(second.Content, "error: result omitted") {
+		t.Errorf("second result = %+v, want an omitted result for c2", second)
+	}
+}
+
+func TestRunTools_IterationLimit(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted").Default(toolCallStep(toolCall("c", "get_weather", `{}`)))
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: prov}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.RunTools(context.Background(), guardrailRequest(), weatherTools(), &ToolLoopOptions{MaxIterations: 2})
+	if !errors.Is(err, ErrToolLoopLimit) {
+		t.Fatalf("expected ErrToolLoopLimit, got %v", err)
+	}
+	if result.Iterations != 2 || prov.Calls() != 2 {
+		t.Errorf("iterations=%d calls=%d", result.Iterations, prov.Calls())
+	}
+}
+
+func TestToolSet_AddReplaces(t *testing.T) {
+	set := weatherTools().Add(provider.Tool{Function: provider.ToolSpec{Name: "get_weather"}},
+		func(ctx context.Context, arguments string) (string, error) { return "rainy", nil })
+	if set.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", set.Len())
+	}
+	if got, _ := set.Call(context.Background(), toolCall("c", "get_weather", "{}")); got != "rainy" {
+		t.Errorf("Call() = %q, want replacement result", got)
+	}
+}
+
+func TestRunTools_Parallel(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+	)
+	tools := NewToolSet().Add(provider.Tool{Function: provider.ToolSpec{Name: "slow"}},
+		func(ctx context.Context, arguments string) (string, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return "done " + arguments, nil
+		})
+
+	prov := mocktest.NewScriptedProvider("scripted",
+		toolCallStep(toolCall("c1", "slow", "1"), toolCall("c2", "slow", "2"), toolCall("c3", "slow", "3"), toolCall("c4", "slow", "4")),
+		mocktest.TextStep("finished"),
+	)
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: prov}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.RunTools(context.Background(), guardrailRequest(), tools, &ToolLoopOptions{MaxConcurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+	for i, msg := range result.Messages[2:6] {
+		want := fmt.Sprintf("c%d", i+1)
+		if msg.ToolCallID == nil || *msg.ToolCallID != want || msg.Content != fmt.Sprintf("done %d", i+1) {
+			t.Errorf("result %d out of order: %+v", i, msg)
+		}
+	}
+}
+
+func TestRunTools_ToolTimeout(t *testing.T) {
+	tools := NewToolSet().Add(provider.Tool{Function: provider.ToolSpec{Name: "hang"}},
+		func(ctx context.Context, arguments string) (string, error) {
+			time.Sleep(200 * time.Millisecond) // ignores cancellation
+			return "too late", nil
+		})
+
+	prov := mocktest.NewScriptedProvider("scripted",
+		toolCallStep(toolCall("c1", "hang", "{}")),
+		mocktest.TextStep("finished"),
+	)
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: prov}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result, err := client.RunTools(context.Background(), guardrailRequest(), tools, &ToolLoopOptions{ToolTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("loop waited %v for a timed-out tool", elapsed)
+	}
+	if got := result.Messages[2].Content; !strings.Contains(got, ErrToolTimeout.Error()) {
+		t.Errorf("timeout not reported: %q", got)
+	}
+}
+
+func TestRunTools_Approval(t *testing.T) {
+	var executed []string
+	tools := NewToolSet().
+		Add(provider.Tool{Function: provider.ToolSpec{Name: "shell"}}, func(ctx context.Context, arguments string) (string, error) {
+			executed = append(executed, "shell "+arguments)
+			return "ran", nil
+		}).
+		Add(provider.Tool{Function: provider.ToolSpec{Name: "search"}}, func(ctx context.Context, arguments string) (string, error) {
+			executed = append(executed, "search "+arguments)
+			return "found", nil
+		})
+
+	prov := mocktest.NewScriptedProvider("scripted",
+		toolCallStep(toolCall("c1", "shell", `{"cmd":"rm -rf /"}`), toolCall("c2", "search", `{"q":"cats"}`)),
+		mocktest.TextStep("ok"),
+	)
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: prov}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var asked []string
+	result, err := client.RunTools(context.Background(), guardrailRequest(), tools, &ToolLoopOptions{
+		MaxConcurrency: 1,
+		Approve: func(ctx context.Context, call provider.ToolCall) (ToolDecision, error) {
+			asked = append(asked, call.ID)
+			if call.Function.Name == "shell" {
+				return ToolDecision{Action: ToolReject, Reason: "destructive command"}, nil
+			}
+			return ToolDecision{Action: ToolApprove, Arguments: `{"q":"dogs"}`}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(asked, []string{"c1", "c2"}) {
+		t.Errorf("approvals requested for %v", asked)
+	}
+	if !slices.Equal(executed, []string{`search {"q":"dogs"}`}) {
+		t.Errorf("executed %v", executed)
+	}
+
+	var rejection ToolRejection
+	if err := json.Unmarshal([]byte(result.Messages[2].Content), &rejection); err != nil {
+		t.Fatalf("rejection is not JSON: %q", result.Messages[2].Content)
+	}
+	if rejection != (ToolRejection{Status: "rejected", Tool: "shell", Reason: "destructive command"}) {
+		t.Errorf("unexpected rejection: %+v", rejection)
+	}
+	if id := result.Messages[2].ToolCallID; id == nil || *id != "c1" {
+		t.Errorf("rejection not linked to its call: %v", id)
+	}
+	if result.Messages[3].Content != "found" {
+		t.Errorf("approved call result = %q", result.Messages[3].Content)
+	}
+}
+
+func TestRunTools_ApprovalError(t *testing.T) {
+	prov := mocktest.NewScriptedProvider("scripted", toolCallStep(toolCall("c1", "get_weather", `{}`)))
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: prov}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errDenied := errors.New("operator unavailable")
+	_, err = client.RunTools(context.Background(), guardrailRequest(), weatherTools(), &ToolLoopOptions{
+		Approve: func(ctx context.Context, call provider.ToolCall) (ToolDecision, error) {
+			return ToolDecision{}, errDenied
+		},
+	})
+	if !errors.Is(err, errDenied) {
+		t.Errorf("expected approval error, got %v", err)
+	}
+}
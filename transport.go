@@ -0,0 +1,146 @@
+package omnillm
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/plexusone/omnillm/providers/anthropic"
+	"github.com/plexusone/omnillm/providers/huggingface"
+	"github.com/plexusone/omnillm/providers/ollama"
+	"github.com/plexusone/omnillm/providers/openai"
+	"github.com/plexusone/omnillm/providers/xai"
+)
+
+// TransportConfig tunes the HTTP transport shared by providers. Zero fields
+// keep the values from DefaultTransportConfig.
+type TransportConfig struct {
+	// MaxIdleConns caps idle connections across all hosts
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost caps the idle connections kept open to each host,
+	// which bounds connection reuse under concurrency. net/http keeps only 2,
+	// so concurrent requests beyond that open new connections.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost caps connections to each host, idle or active. 0 means no limit.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout closes connections left idle for longer
+	IdleConnTimeout time.Duration
+
+	// TLSHandshakeTimeout bounds the TLS handshake of a new connection
+	TLSHandshakeTimeout time.Duration
+
+	// TLSSessionCacheSize is the number of TLS sessions kept for resumption,
+	// which lets new connections skip the full handshake
+	TLSSessionCacheSize int
+
+	// DisableHTTP2 restricts connections to HTTP/1.1. By default HTTP/2 is
+	// used with servers that support it.
+	DisableHTTP2 bool
+
+	// ProxyURL sends requests through a proxy, e.g. "http://proxy:8080".
+	// Default: the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables.
+	ProxyURL string
+}
+
+// DefaultTransportConfig returns a transport configuration tuned for many
+// concurrent requests to a few provider hosts
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSSessionCacheSize: 64,
+	}
+}
+
+// NewTransport creates an HTTP transport from config. Share one transport
+// across providers, by setting ClientConfig.TransportConfig or
+// ProviderConfig.Transport, so connections and TLS sessions are reused.
+func NewTransport(config TransportConfig) (*http.Transport, error) {
+	defaults := DefaultTransportConfig()
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = defaults.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost == 0 {
+		config.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout == 0 {
+		config.IdleConnTimeout = defaults.IdleConnTimeout
+	}
+	if config.TLSHandshakeTimeout == 0 {
+		config.TLSHandshakeTimeout = defaults.TLSHandshakeTimeout
+	}
+	if config.TLSSessionCacheSize == 0 {
+		config.TLSSessionCacheSize = defaults.TLSSessionCacheSize
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("%w: invalid proxy URL %q", ErrInvalidConfiguration, config.ProxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     !config.DisableHTTP2,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ClientSessionCache: tls.NewLRUClientSessionCache(config.TLSSessionCacheSize),
+		},
+	}
+	if config.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto disables HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport, nil
+}
+
+// withTransport returns providers with transport set on each one that
+// builds its own HTTP client and has none of its own
+func withTransport(providers []ProviderConfig, transport http.RoundTripper) []ProviderConfig {
+	result := make([]ProviderConfig, len(providers))
+	for i, pc := range providers {
+		if pc.CustomProvider == nil && pc.HTTPClient == nil && pc.Transport == nil {
+			pc.Transport = transport
+		}
+		result[i] = pc
+	}
+	return result
+}
+
+// defaultHTTPTimeout returns the HTTP client timeout a built-in provider uses
+// when it creates its own client, or 0 for none
+func defaultHTTPTimeout(name ProviderName) time.Duration {
+	switch name {
+	case ProviderNameOpenAI:
+		return openai.DefaultTimeout
+	case ProviderNameAnthropic:
+		return anthropic.DefaultTimeout
+	case ProviderNameOllama:
+		return ollama.DefaultTimeout
+	case ProviderNameXAI:
+		return xai.DefaultTimeout
+	case ProviderNameHuggingFace:
+		return huggingface.DefaultTimeout
+	default:
+		return 0
+	}
+}
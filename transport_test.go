@@ -0,0 +1,95 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport(TransportConfig{MaxIdleConnsPerHost: 8, ProxyURL: "http://proxy.internal:3128"})
+	if err != nil {
+		t.Fatalf("NewTransport() error = %v", err)
+	}
+
+	defaults := DefaultTransportConfig()
+	if transport.MaxIdleConnsPerHost != 8 || transport.MaxIdleConns != defaults.MaxIdleConns || transport.IdleConnTimeout != defaults.IdleConnTimeout {
+		t.Errorf("unexpected pool settings: %+v", transport)
+	}
+	if !transport.ForceAttemptHTTP2 || transport.TLSClientConfig.ClientSessionCache == nil {
+		t.Error("expected HTTP/2 and TLS session resumption")
+	}
+	proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "api.openai.com"}})
+	if err != nil || proxy.String() != "http://proxy.internal:3128" {
+		t.Errorf("proxy = %v, %v", proxy, err)
+	}
+
+	transport, err = NewTransport(TransportConfig{DisableHTTP2: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Error("expected HTTP/2 to be disabled")
+	}
+
+	if _, err := NewTransport(TransportConfig{ProxyURL: "proxy.internal"}); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("invalid proxy error = %v, want ErrInvalidConfiguration", err)
+	}
+}
+
+// recordingTransport counts the requests it carries
+type recordingTransport struct {
+	requests int
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientConfig_TransportConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	own := &http.Client{}
+	providers := []ProviderConfig{
+		{Provider: ProviderNameOpenAI, APIKey: "key", BaseURL: server.URL},
+		{Provider: ProviderNameAnthropic, APIKey: "key", HTTPClient: own},
+	}
+	if got := withTransport(providers, http.DefaultTransport); got[0].Transport != http.DefaultTransport || got[1].Transport != nil || providers[0].Transport != nil {
+		t.Errorf("withTransport() = %+v", got)
+	}
+
+	// A provider's own transport keeps the built-in default timeout
+	recorder := &recordingTransport{}
+	client := getHTTPClientFromProviderConfig(ProviderConfig{Provider: ProviderNameOpenAI, Transport: recorder})
+	if client.Transport != recorder || client.Timeout != 30*time.Second {
+		t.Errorf("HTTP client = %+v, want transport with the OpenAI default timeout", client)
+	}
+
+	c, err := NewClient(ClientConfig{
+		Providers:       []ProviderConfig{{Provider: ProviderNameOpenAI, APIKey: "key", BaseURL: server.URL, Transport: recorder}},
+		TransportConfig: &TransportConfig{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.CreateChatCompletion(context.Background(), &provider.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.requests != 1 {
+		t.Errorf("expected the provider's own transport to be kept, got %d requests through it", recorder.requests)
+	}
+}
@@ -13,7 +13,49 @@ type ToolSpec = provider.ToolSpec
 type ChatCompletionResponse = provider.ChatCompletionResponse
 type ChatCompletionChoice = provider.ChatCompletionChoice
 type Usage = provider.Usage
+type PromptTokensDetails = provider.PromptTokensDetails
+type CompletionTokensDetails = provider.CompletionTokensDetails
 type ChatCompletionChunk = provider.ChatCompletionChunk
+type Annotation = provider.Annotation
+type ContentPart = provider.ContentPart
+type Document = provider.Document
+type Audio = provider.Audio
+type ModerationRequest = provider.ModerationRequest
+type ModerationResponse = provider.ModerationResponse
+type ModerationResult = provider.ModerationResult
+type RerankRequest = provider.RerankRequest
+type RerankResponse = provider.RerankResponse
+type RerankResult = provider.RerankResult
+type RerankUsage = provider.RerankUsage
+type FIMRequest = provider.FIMRequest
+type FIMResponse = provider.FIMResponse
+type TextCompletionRequest = provider.TextCompletionRequest
+type TextCompletionResponse = provider.TextCompletionResponse
+type TextCompletionChoice = provider.TextCompletionChoice
+type FileUploadRequest = provider.FileUploadRequest
+type File = provider.File
+type FileSearchTool = provider.FileSearchTool
+type VectorStoreRequest = provider.VectorStoreRequest
+type VectorStore = provider.VectorStore
+type BatchRequest = provider.BatchRequest
+type Batch = provider.Batch
+type BatchCounts = provider.BatchCounts
+type BatchResult = provider.BatchResult
+type RealtimeConfig = provider.RealtimeConfig
+type RealtimeEvent = provider.RealtimeEvent
+type RealtimeSession = provider.RealtimeSession
+type ResponseFormat = provider.ResponseFormat
+type JSONSchema = provider.JSONSchema
+type Model = provider.Model
+type ModelCapabilities = provider.ModelCapabilities
+type Capabilities = provider.Capabilities
+type Logprobs = provider.Logprobs
+type TokenLogprob = provider.TokenLogprob
+type TopLogprob = provider.TopLogprob
+type ThinkingConfig = provider.ThinkingConfig
+type ThinkingBlock = provider.ThinkingBlock
+type Determinism = provider.Determinism
+type RawExchange = provider.RawExchange
 
 // Role constants for convenience
 const (
@@ -23,6 +65,73 @@ const (
 	RoleTool      = provider.RoleTool
 )
 
+// Tool type constants for convenience
+const (
+	ToolTypeFunction   = provider.ToolTypeFunction
+	ToolTypeFileSearch = provider.ToolTypeFileSearch
+)
+
+// Response format constants for convenience
+const (
+	ResponseFormatText       = provider.ResponseFormatText
+	ResponseFormatJSONObject = provider.ResponseFormatJSONObject
+	ResponseFormatJSONSchema = provider.ResponseFormatJSONSchema
+)
+
+// Finish reason constants for convenience
+const (
+	FinishReasonStop          = provider.FinishReasonStop
+	FinishReasonLength        = provider.FinishReasonLength
+	FinishReasonToolCalls     = provider.FinishReasonToolCalls
+	FinishReasonContentFilter = provider.FinishReasonContentFilter
+	FinishReasonError         = provider.FinishReasonError
+	RawFinishReasonKey        = provider.RawFinishReasonKey
+)
+
+// Reserved ProviderMetadata keys, read by the ChatCompletionResponse accessors
+// such as CacheHit and ProviderRequestID
+const (
+	MetadataKeyCacheHit         = provider.MetadataKeyCacheHit
+	MetadataKeyCachedAt         = provider.MetadataKeyCachedAt
+	MetadataKeyFallbackProvider = provider.MetadataKeyFallbackProvider
+	MetadataKeyFallbackAttempts = provider.MetadataKeyFallbackAttempts
+	MetadataKeyRequestID        = provider.MetadataKeyRequestID
+	MetadataKeyLatency          = provider.MetadataKeyLatency
+	MetadataKeyRegion           = provider.MetadataKeyRegion
+)
+
+// DeterminismKey is the ProviderMetadata key holding a response's Determinism
+const DeterminismKey = provider.DeterminismKey
+
+// ResponseDeterminism returns the seed and fingerprint recorded for resp, if
+// its provider reported either. Compare fingerprints before expecting two
+// responses to the same seeded request to match.
+func ResponseDeterminism(resp *ChatCompletionResponse) (Determinism, bool) {
+	if resp == nil {
+		return Determinism{}, false
+	}
+	return provider.GetDeterminism(resp.ProviderMetadata)
+}
+
+// Realtime event type constants for convenience
+const (
+	RealtimeEventText            = provider.RealtimeEventText
+	RealtimeEventAudio           = provider.RealtimeEventAudio
+	RealtimeEventTranscript      = provider.RealtimeEventTranscript
+	RealtimeEventInputTranscript = provider.RealtimeEventInputTranscript
+	RealtimeEventToolCall        = provider.RealtimeEventToolCall
+	RealtimeEventInterrupted     = provider.RealtimeEventInterrupted
+	RealtimeEventTurnDone        = provider.RealtimeEventTurnDone
+	RealtimeEventError           = provider.RealtimeEventError
+)
+
+// Batch status constants for convenience
+const (
+	BatchStatusInProgress = provider.BatchStatusInProgress
+	BatchStatusCanceling  = provider.BatchStatusCanceling
+	BatchStatusEnded      = provider.BatchStatusEnded
+)
+
 // ModelInfo represents information about a model
 type ModelInfo struct {
 	ID        string       `json:"id"`
@@ -31,49 +140,17 @@ type ModelInfo struct {
 	MaxTokens int          `json:"max_tokens"`
 }
 
-// GetModelInfo returns model information
+// GetModelInfo returns model information from DefaultModelCatalog, or nil if
+// the model is not in the catalog
 func GetModelInfo(modelID string) *ModelInfo {
-	modelMap := map[string]ModelInfo{
-		ModelGPT4o: {
-			ID:        ModelGPT4o,
-			Provider:  ProviderNameOpenAI,
-			Name:      "GPT-4o",
-			MaxTokens: 128000,
-		},
-		ModelClaude3Opus: {
-			ID:        ModelClaude3Opus,
-			Provider:  ProviderNameAnthropic,
-			Name:      "Claude 3 Opus",
-			MaxTokens: 200000,
-		},
-		ModelBedrockClaude3Sonnet: {
-			ID:        ModelBedrockClaude3Sonnet,
-			Provider:  ProviderNameBedrock,
-			Name:      "Claude 3 Sonnet (Bedrock)",
-			MaxTokens: 200000,
-		},
-		ModelOllamaLlama3_8B: {
-			ID:        ModelOllamaLlama3_8B,
-			Provider:  ProviderNameOllama,
-			Name:      "Llama 3 8B",
-			MaxTokens: 8192,
-		},
-		ModelOllamaMistral7B: {
-			ID:        ModelOllamaMistral7B,
-			Provider:  ProviderNameOllama,
-			Name:      "Mistral 7B",
-			MaxTokens: 32768,
-		},
-		ModelOllamaCodeLlama: {
-			ID:        ModelOllamaCodeLlama,
-			Provider:  ProviderNameOllama,
-			Name:      "CodeLlama 13B",
-			MaxTokens: 16384,
-		},
+	spec, ok := DefaultModelCatalog().Lookup(modelID)
+	if !ok {
+		return nil
 	}
-
-	if info, exists := modelMap[modelID]; exists {
-		return &info
+	return &ModelInfo{
+		ID:        spec.ID,
+		Provider:  spec.Provider,
+		Name:      spec.Name,
+		MaxTokens: spec.ContextWindow,
 	}
-	return nil
 }
@@ -0,0 +1,383 @@
+package omnillm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grokify/sogo/database/kvs"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// UsageKey identifies the provider, model, and tenant that usage is attributed to
+type UsageKey struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Tenant   string `json:"tenant,omitempty"`
+}
+
+// UsageStats is accumulated usage
+type UsageStats struct {
+	Requests         int64   `json:"requests"`
+	Errors           int64   `json:"errors"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CachedTokens     int64   `json:"cached_tokens,omitempty"` // Prompt cache reads, included in PromptTokens
+	Cost             float64 `json:"cost"`                    // Estimated USD at catalog list prices
+}
+
+// TotalTokens returns prompt plus completion tokens
+func (s UsageStats) TotalTokens() int64 {
+	return s.PromptTokens + s.CompletionTokens
+}
+
+// add adds other to s
+func (s *UsageStats) add(other UsageStats) {
+	s.Requests += other.Requests
+	s.Errors += other.Errors
+	s.PromptTokens += other.PromptTokens
+	s.CompletionTokens += other.CompletionTokens
+	s.CachedTokens += other.CachedTokens
+	s.Cost += other.Cost
+}
+
+// UsageEntry is the usage for one key in a report
+type UsageEntry struct {
+	UsageKey
+	UsageStats
+}
+
+// UsagePeriod is a time range for a usage report. A zero Start means no lower
+// bound and a zero End means now.
+type UsagePeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// UsageSince returns the period from d ago until now
+func UsageSince(d time.Duration) UsagePeriod {
+	return UsagePeriod{Start: time.Now().Add(-d)}
+}
+
+// UsageReport is the usage recorded in a period. Usage is recorded in
+// buckets, so the report covers every bucket that overlaps the period.
+type UsageReport struct {
+	Period  UsagePeriod
+	Entries []UsageEntry // Sorted by provider, model, and tenant
+	Total   UsageStats
+}
+
+// ByProvider sums the report's entries per provider
+func (r *UsageReport) ByProvider() map[string]UsageStats {
+	return r.groupBy(func(k UsageKey) string { return k.Provider })
+}
+
+// ByModel sums the report's entries per model
+func (r *UsageReport) ByModel() map[string]UsageStats {
+	return r.groupBy(func(k UsageKey) string { return k.Model })
+}
+
+// ByTenant sums the report's entries per tenant
+func (r *UsageReport) ByTenant() map[string]UsageStats {
+	return r.groupBy(func(k UsageKey) string { return k.Tenant })
+}
+
+func (r *UsageReport) groupBy(key func(UsageKey) string) map[string]UsageStats {
+	groups := make(map[string]UsageStats)
+	for _, e := range r.Entries {
+		stats := groups[key(e.UsageKey)]
+		stats.add(e.UsageStats)
+		groups[key(e.UsageKey)] = stats
+	}
+	return groups
+}
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context that attributes usage of calls made with it to tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with WithTenant, or ""
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// UsageTrackerConfig configures a UsageTracker
+type UsageTrackerConfig struct {
+	// BucketSize is the granularity of recorded usage. Default: 1 hour
+	BucketSize time.Duration
+
+	// Retention is how long buckets are kept in memory. Default: 30 days
+	Retention time.Duration
+
+	// Catalog supplies pricing for cost estimates. Default: DefaultModelCatalog()
+	Catalog *ModelCatalog
+
+	// Store persists buckets with Save and Load (optional)
+	Store kvs.Client
+
+	// KeyPrefix prefixes store keys. Default: "omnillm:usage"
+	KeyPrefix string
+}
+
+// UsageTracker accumulates requests, errors, tokens, and estimated cost per
+// provider, model, and tenant. It is an ObservabilityHook; set it as
+// ClientConfig.UsageTracker, or add it to ObservabilityHooks, and query it
+// with ChatClient.UsageReport or Report. Tenants are taken from WithTenant.
+// Responses served from the cache do not reach the provider and are not tracked.
+type UsageTracker struct {
+	config UsageTrackerConfig
+
+	mu      sync.Mutex
+	buckets map[int64]map[UsageKey]*UsageStats // Keyed by bucket start in Unix seconds
+}
+
+// NewUsageTracker creates a usage tracker
+func NewUsageTracker(config UsageTrackerConfig) *UsageTracker {
+	if config.BucketSize <= 0 {
+		config.BucketSize = time.Hour
+	}
+	if config.Retention <= 0 {
+		config.Retention = 30 * 24 * time.Hour
+	}
+	if config.Catalog == nil {
+		config.Catalog = DefaultModelCatalog()
+	}
+	if config.KeyPrefix == "" {
+		config.KeyPrefix = "omnillm:usage"
+	}
+	return &UsageTracker{
+		config:  config,
+		buckets: make(map[int64]map[UsageKey]*UsageStats),
+	}
+}
+
+// BeforeRequest returns ctx unchanged
+func (t *UsageTracker) BeforeRequest(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest) context.Context {
+	return ctx
+}
+
+// AfterResponse records a completed call or a failed stream creation
+func (t *UsageTracker) AfterResponse(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, err error) {
+	var usage *provider.Usage
+	if resp != nil {
+		usage = &resp.Usage
+	}
+	t.record(ctx, info, req, resp, usage, err)
+}
+
+// WrapStream returns stream unchanged; streams are recorded in AfterStream
+func (t *UsageTracker) WrapStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stream provider.ChatCompletionStream) provider.ChatCompletionStream {
+	return stream
+}
+
+// AfterStream records a streaming call with the usage reported by the provider
+func (t *UsageTracker) AfterStream(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, stats StreamStats) {
+	t.record(ctx, info, req, stats.Response, stats.Usage, stats.Err)
+}
+
+// Record adds usage for a call made outside a ChatClient
+func (t *UsageTracker) Record(key UsageKey, usage provider.Usage, err error) {
+	t.add(time.Now(), key, t.stats(key.Model, &usage, err))
+}
+
+func (t *UsageTracker) record(ctx context.Context, info LLMCallInfo, req *provider.ChatCompletionRequest, resp *provider.ChatCompletionResponse, usage *provider.Usage, err error) {
+	key := UsageKey{Provider: info.ProviderName, Tenant: TenantFromContext(ctx)}
+	if req != nil {
+		key.Model = req.Model
+	}
+	if resp != nil {
+		if used := resp.FallbackProvider(); used != "" {
+			key.Provider = used
+		}
+		if key.Model == "" {
+			key.Model = resp.Model
+		}
+	}
+	t.add(info.StartTime, key, t.stats(key.Model, usage, err))
+}
+
+// stats converts one call's usage to UsageStats
+func (t *UsageTracker) stats(model string, usage *provider.Usage, err error) UsageStats {
+	stats := UsageStats{Requests: 1}
+	if err != nil {
+		stats.Errors = 1
+	}
+	if usage != nil {
+		stats.PromptTokens = int64(usage.PromptTokens)
+		stats.CompletionTokens = int64(usage.CompletionTokens)
+		if d := usage.PromptTokensDetails; d != nil {
+			stats.CachedTokens = int64(d.CachedTokens)
+		}
+		if spec, ok := t.config.Catalog.Lookup(model); ok && spec.Pricing != nil {
+			stats.Cost = spec.Pricing.Cost(*usage)
+		}
+	}
+	return stats
+}
+
+// add adds stats to the bucket containing at and prunes expired buckets
+func (t *UsageTracker) add(at time.Time, key UsageKey, stats UsageStats) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	start := at.Truncate(t.config.BucketSize).Unix()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket, ok := t.buckets[start]
+	if !ok {
+		bucket = make(map[UsageKey]*UsageStats)
+		t.buckets[start] = bucket
+		t.prune()
+	}
+	s, ok := bucket[key]
+	if !ok {
+		s = &UsageStats{}
+		bucket[key] = s
+	}
+	s.add(stats)
+}
+
+// prune drops buckets older than the retention period
+func (t *UsageTracker) prune() {
+	cutoff := time.Now().Add(-t.config.Retention).Unix()
+	for start := range t.buckets {
+		if start < cutoff {
+			delete(t.buckets, start)
+		}
+	}
+}
+
+// Report returns the usage recorded in period
+func (t *UsageTracker) Report(period UsagePeriod) *UsageReport {
+	end := period.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	from := period.Start.Truncate(t.config.BucketSize).Unix()
+
+	totals := make(map[UsageKey]*UsageStats)
+	t.mu.Lock()
+	for start, bucket := range t.buckets {
+		if (!period.Start.IsZero() && start < from) || start >= end.Unix() {
+			continue
+		}
+		for key, stats := range bucket {
+			total, ok := totals[key]
+			if !ok {
+				total = &UsageStats{}
+				totals[key] = total
+			}
+			total.add(*stats)
+		}
+	}
+	t.mu.Unlock()
+
+	report := &UsageReport{Period: UsagePeriod{Start: period.Start, End: end}}
+	for key, stats := range totals {
+		report.Entries = append(report.Entries, UsageEntry{UsageKey: key, UsageStats: *stats})
+		report.Total.add(*stats)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i].UsageKey, report.Entries[j].UsageKey
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Tenant < b.Tenant
+	})
+	return report
+}
+
+// usageBucket is the stored form of one bucket
+type usageBucket struct {
+	Start   time.Time    `json:"start"`
+	Entries []UsageEntry `json:"entries"`
+}
+
+// Save writes every in-memory bucket to the store, one key per bucket.
+// Buckets written by other processes with the same key prefix are
+// overwritten, so give each process its own prefix to aggregate usage
+// across processes.
+func (t *UsageTracker) Save(ctx context.Context) error {
+	if t.config.Store == nil {
+		return fmt.Errorf("%w: usage tracker has no store", ErrInvalidConfiguration)
+	}
+
+	t.mu.Lock()
+	buckets := make([]usageBucket, 0, len(t.buckets))
+	for start, bucket := range t.buckets {
+		stored := usageBucket{Start: time.Unix(start, 0).UTC()}
+		for key, stats := range bucket {
+			stored.Entries = append(stored.Entries, UsageEntry{UsageKey: key, UsageStats: *stats})
+		}
+		buckets = append(buckets, stored)
+	}
+	t.mu.Unlock()
+
+	for _, bucket := range buckets {
+		if err := t.config.Store.SetAny(ctx, t.bucketKey(bucket.Start.Unix()), bucket); err != nil {
+			return fmt.Errorf("failed to save usage bucket %s: %w", bucket.Start.Format(time.RFC3339), err)
+		}
+	}
+	return nil
+}
+
+// Load reads the stored buckets that overlap period into memory, for
+// example after a restart. Buckets already in memory are kept, so usage is
+// never counted twice. Period must have a Start.
+func (t *UsageTracker) Load(ctx context.Context, period UsagePeriod) error {
+	if t.config.Store == nil {
+		return fmt.Errorf("%w: usage tracker has no store", ErrInvalidConfiguration)
+	}
+	if period.Start.IsZero() {
+		return fmt.Errorf("%w: usage period must have a start", ErrInvalidConfiguration)
+	}
+	end := period.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	for at := period.Start.Truncate(t.config.BucketSize); at.Before(end); at = at.Add(t.config.BucketSize) {
+		var stored usageBucket
+		if err := t.config.Store.GetAny(ctx, t.bucketKey(at.Unix()), &stored); err != nil {
+			// Missing bucket
+			continue
+		}
+
+		t.mu.Lock()
+		if _, ok := t.buckets[at.Unix()]; !ok {
+			bucket := make(map[UsageKey]*UsageStats, len(stored.Entries))
+			for _, e := range stored.Entries {
+				stats := e.UsageStats
+				bucket[e.UsageKey] = &stats
+			}
+			t.buckets[at.Unix()] = bucket
+		}
+		t.mu.Unlock()
+	}
+	return nil
+}
+
+func (t *UsageTracker) bucketKey(start int64) string {
+	return fmt.Sprintf("%s:%d", t.config.KeyPrefix, start)
+}
+
+// UsageReport returns the usage recorded by the client's UsageTracker in
+// period, or nil if no tracker is configured
+func (c *ChatClient) UsageReport(period UsagePeriod) *UsageReport {
+	if c.usage == nil {
+		return nil
+	}
+	return c.usage.Report(period)
+}
@@ -0,0 +1,91 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestUsageTracker_ClientReport(t *testing.T) {
+	mock := NewMockProvider("openai")
+	mock.completionResp.Usage = provider.Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+
+	tracker := NewUsageTracker(UsageTrackerConfig{})
+	client, err := NewClient(ClientConfig{
+		Providers:    []ProviderConfig{{CustomProvider: mock}},
+		UsageTracker: tracker,
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	req := &provider.ChatCompletionRequest{Model: "gpt-4o", Messages: []provider.Message{{Role: provider.RoleUser, Content: "hi"}}}
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		if _, err := client.CreateChatCompletion(WithTenant(context.Background(), tenant), req); err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+	}
+	mock.completionError = ErrServerError
+	if _, err := client.CreateChatCompletion(context.Background(), req); err == nil {
+		t.Fatal("expected error")
+	}
+
+	report := client.UsageReport(UsageSince(time.Hour))
+	if report.Total.Requests != 4 || report.Total.Errors != 1 {
+		t.Errorf("expected 4 requests and 1 error, got %+v", report.Total)
+	}
+	if report.Total.PromptTokens != 3000 || report.Total.TotalTokens() != 4500 {
+		t.Errorf("unexpected token totals: %+v", report.Total)
+	}
+
+	// gpt-4o is $2.50 in / $10 out per million tokens
+	if want := 3 * (1000*2.5 + 500*10) / 1e6; report.Total.Cost < want-1e-9 || report.Total.Cost > want+1e-9 {
+		t.Errorf("expected cost %f, got %f", want, report.Total.Cost)
+	}
+
+	byTenant := report.ByTenant()
+	if byTenant["acme"].Requests != 2 || byTenant["globex"].Requests != 1 || byTenant[""].Errors != 1 {
+		t.Errorf("unexpected per-tenant usage: %+v", byTenant)
+	}
+	if byModel := report.ByModel(); byModel["gpt-4o"].Requests != 4 {
+		t.Errorf("unexpected per-model usage: %+v", byModel)
+	}
+
+	if empty := client.UsageReport(UsagePeriod{End: time.Now().Add(-2 * time.Hour)}); len(empty.Entries) != 0 {
+		t.Errorf("expected no usage before the period, got %+v", empty.Entries)
+	}
+}
+
+func TestUsageTracker_SaveLoad(t *testing.T) {
+	store := mocktest.NewMockKVS()
+	tracker := NewUsageTracker(UsageTrackerConfig{Store: store})
+	tracker.Record(UsageKey{Provider: "anthropic", Model: "m"}, provider.Usage{PromptTokens: 10, CompletionTokens: 5}, nil)
+	tracker.Record(UsageKey{Provider: "anthropic", Model: "m"}, provider.Usage{}, errors.New("boom"))
+
+	if err := tracker.Save(context.Background()); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	restored := NewUsageTracker(UsageTrackerConfig{Store: store})
+	if err := restored.Load(context.Background(), UsageSince(time.Hour)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	// Loading again does not double count
+	if err := restored.Load(context.Background(), UsageSince(time.Hour)); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	total := restored.Report(UsagePeriod{}).Total
+	if total.Requests != 2 || total.Errors != 1 || total.PromptTokens != 10 || total.CompletionTokens != 5 {
+		t.Errorf("unexpected restored usage: %+v", total)
+	}
+
+	if err := NewUsageTracker(UsageTrackerConfig{}).Save(context.Background()); !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("expected configuration error without a store, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package omnillm
+
+import "github.com/plexusone/omnillm/provider"
+
+// RequestValidationConfig configures the checks ChatClient runs on each request
+// before it is sent to a provider
+type RequestValidationConfig struct {
+	// Disabled sends requests to providers without validating them
+	Disabled bool
+
+	// Catalog supplies the model capabilities that requests are checked
+	// against. Models not in the catalog skip the capability checks.
+	// Default: DefaultModelCatalog()
+	Catalog *ModelCatalog
+
+	// SkipCapabilities disables the checks against model and provider
+	// capabilities, leaving only provider.ValidateRequest
+	SkipCapabilities bool
+}
+
+// requestValidator checks requests before they are sent. A nil
+// requestValidator accepts every request.
+type requestValidator struct {
+	// models maps request models the way the primary provider will
+	models  *modelMapProvider
+	catalog *ModelCatalog
+
+	// provider is checked for the features each request uses. Providers
+	// that do not report capabilities, including FallbackProvider, which
+	// checks each provider it tries, are not checked.
+	provider provider.Provider
+}
+
+// newRequestValidator returns a validator for requests sent to p, the
+// provider configured by pc, or nil if config disables validation
+func newRequestValidator(config RequestValidationConfig, pc ProviderConfig, p provider.Provider) *requestValidator {
+	if config.Disabled {
+		return nil
+	}
+	v := &requestValidator{
+		models: &modelMapProvider{modelMap: pc.ModelMap, defaultModel: pc.DefaultModel},
+	}
+	if !config.SkipCapabilities {
+		v.catalog = config.Catalog
+		if v.catalog == nil {
+			v.catalog = DefaultModelCatalog()
+		}
+		v.provider = p
+	}
+	return v
+}
+
+// validate returns the problems found in req, as ValidationErrors, or nil if
+// there are none
+func (v *requestValidator) validate(req *provider.ChatCompletionRequest, stream bool) error {
+	if v == nil {
+		return nil
+	}
+	if req == nil {
+		return provider.ValidateRequest(req)
+	}
+
+	req = v.models.mapModel(req)
+	if err := provider.ValidateRequest(req); err != nil {
+		return err
+	}
+	if v.provider != nil {
+		if err := checkCapabilities(v.provider, req, stream); err != nil {
+			return err
+		}
+	}
+	if v.catalog == nil {
+		return nil
+	}
+	spec, ok := v.catalog.Lookup(req.Model)
+	if !ok {
+		return nil
+	}
+	if stream && (req.Stream == nil || !*req.Stream) {
+		streamed := *req
+		streamed.Stream = &stream
+		req = &streamed
+	}
+	return provider.ValidateCapabilities(req, spec.Capabilities)
+}
@@ -0,0 +1,97 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/models"
+	"github.com/plexusone/omnillm/provider"
+	mocktest "github.com/plexusone/omnillm/testing"
+)
+
+func TestClient_ValidatesRequests(t *testing.T) {
+	mock := mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("ok"))
+	client, err := NewClient(ClientConfig{Providers: []ProviderConfig{{CustomProvider: mock}}})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	user := []provider.Message{{Role: provider.RoleUser, Content: "hi"}}
+	tools := []provider.Tool{{Type: provider.ToolTypeFunction, Function: provider.ToolSpec{Name: "lookup"}}}
+
+	// Invalid requests are rejected before reaching the provider
+	var verrs ValidationErrors
+	if _, err := client.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{Messages: user}); !errors.As(err, &verrs) || verrs[0].Field != "model" {
+		t.Errorf("expected a model validation error, got %v", err)
+	}
+	if _, err := client.CreateChatCompletionStream(ctx, &provider.ChatCompletionRequest{Model: "m"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+
+	// Tools sent to a catalog model without tool support are rejected
+	req := &provider.ChatCompletionRequest{Model: models.OllamaLlama3_8B, Messages: user, Tools: tools}
+	_, err = client.CreateChatCompletion(ctx, req)
+	if !errors.As(err, &verrs) || verrs[0].Field != "tools" {
+		t.Errorf("expected a tools capability error, got %v", err)
+	}
+	if IsRetryableError(err) {
+		t.Error("expected validation errors to be non-retryable")
+	}
+	if mock.Calls() != 0 {
+		t.Fatalf("expected no provider calls, got %d", mock.Calls())
+	}
+
+	// Models not in the catalog skip the capability checks
+	req = &provider.ChatCompletionRequest{Model: "custom-model", Messages: user, Tools: tools}
+	if _, err := client.CreateChatCompletion(ctx, req); err != nil {
+		t.Errorf("expected an uncatalogued model to pass, got %v", err)
+	}
+}
+
+func TestClient_ValidationConfig(t *testing.T) {
+	user := []provider.Message{{Role: provider.RoleUser, Content: "hi"}}
+	tools := []provider.Tool{{Type: provider.ToolTypeFunction, Function: provider.ToolSpec{Name: "lookup"}}}
+	newClient := func(pc ProviderConfig, config *RequestValidationConfig) *ChatClient {
+		client, err := NewClient(ClientConfig{Providers: []ProviderConfig{pc}, RequestValidation: config})
+		if err != nil {
+			t.Fatalf("NewClient failed: %v", err)
+		}
+		t.Cleanup(func() { _ = client.Close() })
+		return client
+	}
+	mock := func() *mocktest.ScriptedProvider {
+		return mocktest.NewScriptedProvider("mock").Default(mocktest.TextStep("ok"))
+	}
+	ctx := context.Background()
+
+	// The primary provider's default model fills in an empty model
+	client := newClient(ProviderConfig{CustomProvider: mock(), DefaultModel: "m"}, nil)
+	if _, err := client.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{Messages: user}); err != nil {
+		t.Errorf("expected the default model to satisfy validation, got %v", err)
+	}
+
+	// Mapped models are checked against their own capabilities
+	client = newClient(ProviderConfig{CustomProvider: mock(), ModelMap: map[string]string{"small": models.OllamaLlama3_8B}}, nil)
+	req := &provider.ChatCompletionRequest{Model: "small", Messages: user, Tools: tools}
+	if _, err := client.CreateChatCompletion(ctx, req); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected the mapped model's capabilities to be checked, got %v", err)
+	}
+
+	client = newClient(ProviderConfig{CustomProvider: mock()}, &RequestValidationConfig{SkipCapabilities: true})
+	req = &provider.ChatCompletionRequest{Model: models.OllamaLlama3_8B, Messages: user, Tools: tools}
+	if _, err := client.CreateChatCompletion(ctx, req); err != nil {
+		t.Errorf("expected capability checks to be skipped, got %v", err)
+	}
+
+	disabled := mock()
+	client = newClient(ProviderConfig{CustomProvider: disabled}, &RequestValidationConfig{Disabled: true})
+	if _, err := client.CreateChatCompletion(ctx, &provider.ChatCompletionRequest{}); err != nil {
+		t.Errorf("expected validation to be disabled, got %v", err)
+	}
+	if disabled.Calls() != 1 {
+		t.Errorf("expected the provider to be called, got %d calls", disabled.Calls())
+	}
+}
@@ -0,0 +1,61 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// ErrVectorStoresNotSupported is returned when no configured provider hosts vector stores
+var ErrVectorStoresNotSupported = errors.New("vector stores not supported by configured providers")
+
+// CreateVectorStore creates a provider-hosted vector store using the first configured
+// provider that supports them. Search it by adding a FileSearchTool to a request.
+func (c *ChatClient) CreateVectorStore(ctx context.Context, req *provider.VectorStoreRequest) (*provider.VectorStore, error) {
+	if c.vectorStores == nil {
+		return nil, ErrVectorStoresNotSupported
+	}
+	return c.vectorStores.CreateVectorStore(ctx, req)
+}
+
+// GetVectorStore retrieves the metadata for a vector store
+func (c *ChatClient) GetVectorStore(ctx context.Context, storeID string) (*provider.VectorStore, error) {
+	if c.vectorStores == nil {
+		return nil, ErrVectorStoresNotSupported
+	}
+	return c.vectorStores.GetVectorStore(ctx, storeID)
+}
+
+// AddVectorStoreFile attaches a file uploaded with UploadFile to a vector store
+func (c *ChatClient) AddVectorStoreFile(ctx context.Context, storeID, fileID string) error {
+	if c.vectorStores == nil {
+		return ErrVectorStoresNotSupported
+	}
+	if storeID == "" || fileID == "" {
+		return ErrInvalidRequest
+	}
+	return c.vectorStores.AddVectorStoreFile(ctx, storeID, fileID)
+}
+
+// DeleteVectorStore deletes a vector store
+func (c *ChatClient) DeleteVectorStore(ctx context.Context, storeID string) error {
+	if c.vectorStores == nil {
+		return ErrVectorStoresNotSupported
+	}
+	return c.vectorStores.DeleteVectorStore(ctx, storeID)
+}
+
+// HasVectorStores returns true if a provider hosting vector stores is configured
+func (c *ChatClient) HasVectorStores() bool {
+	return c.vectorStores != nil
+}
+
+// NewFileSearchTool creates a tool that enables provider-hosted retrieval over
+// the given vector stores
+func NewFileSearchTool(vectorStoreIDs ...string) Tool {
+	return Tool{
+		Type:       ToolTypeFileSearch,
+		FileSearch: &FileSearchTool{VectorStoreIDs: vectorStoreIDs},
+	}
+}
@@ -0,0 +1,124 @@
+package omnillm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plexusone/omnillm/provider"
+)
+
+// mockVectorStoreProvider is a provider that also hosts vector stores
+type mockVectorStoreProvider struct {
+	*MockProvider
+	stores map[string]*provider.VectorStore
+}
+
+func newMockVectorStoreProvider(name string) *mockVectorStoreProvider {
+	return &mockVectorStoreProvider{
+		MockProvider: NewMockProvider(name),
+		stores:       make(map[string]*provider.VectorStore),
+	}
+}
+
+func (m *mockVectorStoreProvider) CreateVectorStore(ctx context.Context, req *provider.VectorStoreRequest) (*provider.VectorStore, error) {
+	store := &provider.VectorStore{
+		ID:        "vs-" + req.Name,
+		Provider:  m.name,
+		Name:      req.Name,
+		FileCount: len(req.FileIDs),
+	}
+	m.stores[store.ID] = store
+	return store, nil
+}
+
+func (m *mockVectorStoreProvider) GetVectorStore(ctx context.Context, storeID string) (*provider.VectorStore, error) {
+	store, ok := m.stores[storeID]
+	if !ok {
+		return nil, ErrModelNotFound
+	}
+	return store, nil
+}
+
+func (m *mockVectorStoreProvider) AddVectorStoreFile(ctx context.Context, storeID, fileID string) error {
+	store, ok := m.stores[storeID]
+	if !ok {
+		return ErrModelNotFound
+	}
+	store.FileCount++
+	return nil
+}
+
+func (m *mockVectorStoreProvider) DeleteVectorStore(ctx context.Context, storeID string) error {
+	delete(m.stores, storeID)
+	return nil
+}
+
+func TestChatClient_VectorStores(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{
+			{CustomProvider: newMockVectorStoreProvider("vs")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.HasVectorStores() {
+		t.Fatal("expected vector store support")
+	}
+
+	ctx := context.Background()
+	store, err := client.CreateVectorStore(ctx, &VectorStoreRequest{Name: "docs", FileIDs: []string{"file-1"}})
+	if err != nil {
+		t.Fatalf("CreateVectorStore failed: %v", err)
+	}
+	if err := client.AddVectorStoreFile(ctx, store.ID, "file-2"); err != nil {
+		t.Fatalf("AddVectorStoreFile failed: %v", err)
+	}
+
+	got, err := client.GetVectorStore(ctx, store.ID)
+	if err != nil {
+		t.Fatalf("GetVectorStore failed: %v", err)
+	}
+	if got.FileCount != 2 {
+		t.Errorf("FileCount = %d, want 2", got.FileCount)
+	}
+
+	if err := client.AddVectorStoreFile(ctx, "", "file-3"); !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+	if err := client.DeleteVectorStore(ctx, store.ID); err != nil {
+		t.Fatalf("DeleteVectorStore failed: %v", err)
+	}
+}
+
+func TestChatClient_VectorStoresNotSupported(t *testing.T) {
+	client, err := NewClient(ClientConfig{
+		Providers: []ProviderConfig{{CustomProvider: NewMockProvider("chat")}},
+	})
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.CreateVectorStore(context.Background(), &VectorStoreRequest{Name: "docs"}); !errors.Is(err, ErrVectorStoresNotSupported) {
+		t.Errorf("expected ErrVectorStoresNotSupported, got %v", err)
+	}
+}
+
+func TestValidateRequest_FileSearchTool(t *testing.T) {
+	valid, err := NewRequestBuilder("gpt-4o").User("What does the handbook say?").FileSearch("vs_123").Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if valid.Tools[0].Type != ToolTypeFileSearch {
+		t.Errorf("Tool type = %q, want %q", valid.Tools[0].Type, ToolTypeFileSearch)
+	}
+
+	_, err = NewRequestBuilder("gpt-4o").User("hi").Tools(NewFileSearchTool()).Build()
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for file_search without stores, got %v", err)
+	}
+}